@@ -16,31 +16,60 @@ func main() {
 
 	// Register all commands
 	rootCmd.AddCommand(cli.BuildCmd())
+	rootCmd.AddCommand(cli.WhyRebuildCmd())
+	rootCmd.AddCommand(cli.LogCmd())
+	rootCmd.AddCommand(cli.StatsCmd())
 	rootCmd.AddCommand(cli.RunCmd())
 	rootCmd.AddCommand(cli.TestCmd())
 	rootCmd.AddCommand(cli.BenchCmd())
+	rootCmd.AddCommand(cli.ProfileCmd())
 	rootCmd.AddCommand(cli.CleanCmd())
+	rootCmd.AddCommand(cli.CacheCmd())
 	rootCmd.AddCommand(cli.NewCmd())
+	rootCmd.AddCommand(cli.TemplateCmd())
 	rootCmd.AddCommand(cli.AddCmd())
 	rootCmd.AddCommand(cli.RemoveCmd())
 	rootCmd.AddCommand(cli.ListCmd())
+	rootCmd.AddCommand(cli.TargetsCmd())
 	rootCmd.AddCommand(cli.SearchCmd())
+	rootCmd.AddCommand(cli.DepsCmd())
 	rootCmd.AddCommand(cli.InfoCmd())
 	rootCmd.AddCommand(cli.FmtCmd())
 	rootCmd.AddCommand(cli.LintCmd())
 	rootCmd.AddCommand(cli.FlawfinderCmd())
 	rootCmd.AddCommand(cli.CppcheckCmd())
 	rootCmd.AddCommand(cli.AnalyzeCmd())
+	rootCmd.AddCommand(cli.AuditCmd())
+	rootCmd.AddCommand(cli.IncludesCmd())
+	rootCmd.AddCommand(cli.CompdbCmd())
+	rootCmd.AddCommand(cli.IdeCmd())
+	rootCmd.AddCommand(cli.SizeCmd())
+	rootCmd.AddCommand(cli.AbiCheckCmd())
+	rootCmd.AddCommand(cli.VerifyReproCmd())
+	rootCmd.AddCommand(cli.MutateCmd())
+	rootCmd.AddCommand(cli.MigrateCmd())
+	rootCmd.AddCommand(cli.TaskCmd())
 
 	rootCmd.AddCommand(cli.DocCmd())
+	rootCmd.AddCommand(cli.PackageCmd())
+	rootCmd.AddCommand(cli.PublishCmd())
 	rootCmd.AddCommand(cli.ReleaseCmd())
+	rootCmd.AddCommand(cli.DockerCmd())
 	rootCmd.AddCommand(cli.UpgradeCmd())
 	rootCmd.AddCommand(cli.ConfigCmd())
+	rootCmd.AddCommand(cli.UICmd())
+	rootCmd.AddCommand(cli.DoctorCmd())
+	rootCmd.AddCommand(cli.SetupCmd())
+	rootCmd.AddCommand(cli.EnvCmd())
+	rootCmd.AddCommand(cli.ExecCmd())
+	rootCmd.AddCommand(cli.BcrCmd())
 	rootCmd.AddCommand(cli.WorkflowCmd())
 	rootCmd.AddCommand(cli.HooksCmd())
 	rootCmd.AddCommand(cli.UpdateCmd())
 
 	// Toolchain, Runner management (simplified design)
+	rootCmd.AddCommand(cli.ToolchainCmd())
+	rootCmd.AddCommand(cli.CICmd())
 	rootCmd.AddCommand(cli.AddToolchainCmd())
 	rootCmd.AddCommand(cli.AddRunnerCmd())
 	rootCmd.AddCommand(cli.RmToolchainCmd())
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/abi"
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// AbiCheckCmd creates the abi-check command
+func AbiCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "abi-check",
+		Short: "Check a library for ABI breaks against an earlier version",
+		Long: `Build the library at the current checkout and at an earlier git ref, then
+compare each shared library with abidiff (libabigail) or, if that is not
+installed, abi-compliance-checker. Breaking ABI changes fail the command, so
+it can run before tagging a release.
+
+Both versions are built in debug mode, since the checkers read the types from
+the debug info, and as shared libraries. The earlier version is checked out in
+a git worktree under .cache/abi. Headers in include/ limit the report to the
+public API.`,
+		Example: `  cpx abi-check --against v1.2.0
+  cpx abi-check --against main --tool abi-compliance-checker`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withBuildLog(cmd, "abi-check", func() error { return runAbiCheck(cmd, args) })
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().String("against", "", "Git ref (tag, branch or commit) of the version to compare with")
+	cmd.Flags().String("tool", "auto", "ABI checker: auto, abidiff or abi-compliance-checker")
+	cmd.Flags().Bool("keep", false, "Keep the worktree of the earlier version")
+	cmd.Flags().Bool("verbose", false, "Show full build output")
+	_ = cmd.MarkFlagRequired("against")
+
+	return cmd
+}
+
+func runAbiCheck(cmd *cobra.Command, _ []string) error {
+	against, _ := cmd.Flags().GetString("against")
+	toolName, _ := cmd.Flags().GetString("tool")
+	keep, _ := cmd.Flags().GetBool("keep")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	if _, err := RequireProject("cpx abi-check"); err != nil {
+		return err
+	}
+	tool, err := abi.FindTool(toolName)
+	if err != nil {
+		return err
+	}
+	builder, err := projectBuilder()
+	if err != nil {
+		return err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if _, err := runGit(root, "rev-parse", "--verify", "--quiet", against+"^{commit}"); err != nil {
+		return fmt.Errorf("unknown git ref '%s'\n  hint: fetch tags with git fetch --tags", against)
+	}
+
+	opts := build.BuildOptions{Verbose: verbose, LibraryType: build.LibraryShared}
+
+	output.Stepf("Building the current version...")
+	newLibs, err := buildSharedLibraries(builder, opts)
+	if err != nil {
+		return err
+	}
+
+	workDir := filepath.Join(config.DirsOf(root).Cache, "abi")
+	worktree := filepath.Join(workDir, strings.NewReplacer("/", "-", "\\", "-", ":", "-").Replace(against))
+	_, _ = runGit(root, "worktree", "remove", "--force", worktree)
+	if _, err := runGit(root, "worktree", "add", "--force", "--detach", worktree, against); err != nil {
+		return err
+	}
+	if !keep {
+		defer func() { _, _ = runGit(root, "worktree", "remove", "--force", worktree) }()
+	}
+
+	output.Stepf("Building %s...", against)
+	if err := os.Chdir(worktree); err != nil {
+		return err
+	}
+	oldLibs, err := buildSharedLibraries(builder, opts)
+	if chdirErr := os.Chdir(root); chdirErr != nil && err == nil {
+		err = chdirErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to build %s: %w", against, err)
+	}
+
+	headers := func(dir string) string {
+		if info, err := os.Stat(filepath.Join(dir, "include")); err == nil && info.IsDir() {
+			return filepath.Join(dir, "include")
+		}
+		return ""
+	}
+
+	var breaking []string
+	keys := make([]string, 0, len(oldLibs))
+	for key := range oldLibs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(output.Stdout())
+	for _, key := range keys {
+		newLib, ok := newLibs[key]
+		if !ok {
+			output.Errorf("%s: removed", key)
+			breaking = append(breaking, key+" (removed)")
+			continue
+		}
+		result, err := abi.Compare(tool,
+			abi.Library{Path: filepath.Join(worktree, oldLibs[key]), Headers: headers(worktree), Version: against},
+			abi.Library{Path: filepath.Join(root, newLib), Headers: headers(root), Version: "current"},
+			workDir)
+		if err != nil {
+			return err
+		}
+		switch {
+		case result.Breaking:
+			output.Errorf("%s: breaking ABI changes", key)
+			breaking = append(breaking, key)
+		case result.Changed:
+			output.Warnf("%s: compatible ABI changes", key)
+		default:
+			output.Successf("✓ %s: no ABI changes", key)
+		}
+		// the report details the warning or error above, on stderr with it
+		if result.Changed && result.Report != "" {
+			if tool == abi.ToolAbidiff {
+				for _, line := range strings.Split(result.Report, "\n") {
+					fmt.Fprintf(os.Stderr, "    %s\n", line)
+				}
+			} else {
+				fmt.Fprintf(os.Stderr, "    %sReport: %s%s\n", colors.Gray, result.Report, colors.Reset)
+			}
+		}
+	}
+	var added []string
+	for key := range newLibs {
+		if _, ok := oldLibs[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		output.Infof("%s+ %s: new library%s", colors.Gray, key, colors.Reset)
+	}
+
+	if len(breaking) > 0 {
+		return fmt.Errorf("ABI breaking changes against %s: %s\n  hint: bump the major version (cpx release major) or restore compatibility", against, strings.Join(breaking, ", "))
+	}
+	output.Successf("✓ ABI compatible with %s", against)
+	return nil
+}
+
+// buildSharedLibraries builds the project in the current directory and
+// returns its shared libraries, relative to it, by abi.LibraryKey.
+func buildSharedLibraries(builder build.BuildSystem, opts build.BuildOptions) (map[string]string, error) {
+	if err := builder.Build(context.Background(), opts); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(config.BinDir(), "native", build.GetOutputDir(opts.Release, opts.OptLevel, ""), build.LibraryShared)
+	// Versioned libraries are found through their unversioned symlink
+	files, err := artifacts.Find(artifacts.Rule{Dir: dir, Extensions: []string{".so", ".dylib"}})
+	if err != nil {
+		return nil, err
+	}
+	libs := map[string]string{}
+	for _, f := range files {
+		libs[abi.LibraryKey(f)] = f
+	}
+	if len(libs) == 0 {
+		return nil, fmt.Errorf("no shared libraries in %s\n  hint: ABI checks compare shared libraries; leave the library type to BUILD_SHARED_LIBS / default_library instead of forcing STATIC", dir)
+	}
+	return libs, nil
+}
+
+// runGit runs git with args in dir and returns its trimmed output.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := execCommand("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w\n%s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -8,14 +8,13 @@ import (
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
 	"github.com/ozacod/cpx/internal/pkg/build/meson"
 	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
-	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
 func AddCmd() *cobra.Command {
 	// Set the BCR path provider for bazel builder
 	bazel.SetBCRPathProvider(func() string {
-		cfg, err := config.LoadGlobal()
+		cfg, err := projectConfig()
 		if err != nil {
 			return ""
 		}
@@ -29,22 +28,59 @@ func AddCmd() *cobra.Command {
 
 For vcpkg projects: passes through to 'vcpkg add port' and prints usage info.
 For Bazel projects: fetches the latest version from BCR and updates MODULE.bazel.
-For Meson projects: uses 'meson wrap install' to add from WrapDB.`,
+For Meson projects: uses 'meson wrap install' to add from WrapDB.
+
+With --path, the dependency is another cpx project on disk (e.g. a sibling
+library in the same repository), built from source with this one:
+  vcpkg: add_subdirectory and target_link_libraries in CMakeLists.txt, plus
+         the library's vcpkg ports in vcpkg.json
+  Bazel: bazel_dep and local_path_override in MODULE.bazel
+  Meson: a link to it in subprojects/
+
+With --submodule, the dependency is a git repository checked out as a
+submodule in third_party/<name> and built from source the same way. It
+need not be a cpx project: plain CMake projects are added with
+add_subdirectory, Bazel repositories without a MODULE.bazel as a
+local_repository and CMake projects in Meson through its cmake module.
+The submodule is recorded in cpx-ci.yaml and shown by cpx list.`,
+		Example: `  cpx add fmt
+  cpx add --path ../mylib
+  cpx add --submodule https://github.com/fmtlib/fmt.git`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runAdd(cmd, args)
 		},
-		Args: cobra.MinimumNArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			path, _ := cmd.Flags().GetString("path")
+			submodule, _ := cmd.Flags().GetString("submodule")
+			if path != "" || submodule != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 	}
 
+	cmd.Flags().String("path", "", "Add the cpx project in this directory as a dependency")
+	cmd.Flags().String("submodule", "", "Add the git repository at this URL as a submodule in third_party/")
+	cmd.Flags().String("name", "", "Directory name in third_party/ for --submodule (default: the repository name)")
+	cmd.MarkFlagsMutuallyExclusive("path", "submodule")
+
 	return cmd
 }
 
-func runAdd(_ *cobra.Command, args []string) error {
+func runAdd(cmd *cobra.Command, args []string) error {
 	projectType, err := RequireProject("cpx add")
 	if err != nil {
 		return err
 	}
 
+	if path, _ := cmd.Flags().GetString("path"); path != "" {
+		return addPathDependency(projectType, path)
+	}
+	if url, _ := cmd.Flags().GetString("submodule"); url != "" {
+		name, _ := cmd.Flags().GetString("name")
+		return addSubmoduleDependency(projectType, url, name)
+	}
+
 	name := args[0]
 	version := ""
 	if len(args) > 1 {
@@ -65,3 +101,21 @@ func runAdd(_ *cobra.Command, args []string) error {
 
 	return builder.AddDependency(context.Background(), name, version)
 }
+
+// addPathDependency adds the project at path, which must use the same build
+// system, as a dependency.
+func addPathDependency(projectType ProjectType, path string) error {
+	var adder build.PathDependencyAdder
+	switch projectType {
+	case ProjectTypeVcpkg:
+		adder = vcpkg.New()
+	case ProjectTypeBazel:
+		adder = bazel.New()
+	case ProjectTypeMeson:
+		adder = meson.New()
+	default:
+		return fmt.Errorf("unsupported project type")
+	}
+
+	return adder.AddPathDependency(context.Background(), path)
+}
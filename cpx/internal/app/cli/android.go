@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// androidOutputDir is where android toolchains put the per-ABI artifacts of
+// the project in root.
+func androidOutputDir(root string) string {
+	return filepath.Join(config.DirsOf(root).Bin, "android")
+}
+
+// Defaults for android toolchains.
+var defaultAndroidABIs = []string{"arm64-v8a", "x86_64"}
+
+const defaultAndroidAPILevel = 24
+
+// androidABI describes how an Android ABI maps onto the NDK, Meson and vcpkg.
+type androidABI struct {
+	Triple       string // clang target prefix in the NDK
+	CPUFamily    string
+	CPU          string
+	VcpkgTriplet string
+}
+
+var androidABIs = map[string]androidABI{
+	"arm64-v8a":   {Triple: "aarch64-linux-android", CPUFamily: "aarch64", CPU: "aarch64", VcpkgTriplet: "arm64-android"},
+	"armeabi-v7a": {Triple: "armv7a-linux-androideabi", CPUFamily: "arm", CPU: "armv7a", VcpkgTriplet: "arm-neon-android"},
+	"x86_64":      {Triple: "x86_64-linux-android", CPUFamily: "x86_64", CPU: "x86_64", VcpkgTriplet: "x64-android"},
+	"x86":         {Triple: "i686-linux-android", CPUFamily: "x86", CPU: "i686", VcpkgTriplet: "x86-android"},
+}
+
+// androidBuild describes an android build. Paths are as seen by the build,
+// i.e. inside the container for docker builds.
+type androidBuild struct {
+	ProjectType ProjectType
+	Toolchain   config.Toolchain
+	MesonArgs   []string
+	NDK         string // empty uses $ANDROID_NDK_HOME of the build environment
+	ABIs        []string
+	APILevel    int
+	SourceDir   string
+	CacheDir    string
+	OutputDir   string
+}
+
+// script returns the shell script that builds every ABI and collects the
+// shared libraries into <output>/<abi>.
+func (a androidBuild) script() string {
+	tc := a.Toolchain
+	buildType := tc.BuildType
+	if buildType == "" {
+		buildType = "Release"
+	}
+
+	var s strings.Builder
+	s.WriteString("set -e\n")
+	if a.NDK != "" {
+		fmt.Fprintf(&s, "export ANDROID_NDK_HOME=%q\n", a.NDK)
+	}
+	s.WriteString(`NDK="$ANDROID_NDK_HOME"
+if [ ! -f "$NDK/build/cmake/android.toolchain.cmake" ]; then
+    echo "Android NDK not found (ANDROID_NDK_HOME='$NDK')"
+    exit 1
+fi
+`)
+
+	for _, abi := range a.ABIs {
+		info := androidABIs[abi]
+		buildDir := a.CacheDir + "/" + tc.Name + "/" + abi
+		outDir := a.OutputDir + "/" + abi
+		fmt.Fprintf(&s, "echo \" Building %s (android-%d)...\"\n", abi, a.APILevel)
+		fmt.Fprintf(&s, "mkdir -p %q %q\n", buildDir, outDir)
+
+		switch a.ProjectType {
+		case ProjectTypeMeson:
+			crossFile := a.CacheDir + "/" + tc.Name + "-" + abi + ".ini"
+			compiler := fmt.Sprintf("$TC/bin/%s%d", info.Triple, a.APILevel)
+			fmt.Fprintf(&s, `TC=$(echo "$NDK"/toolchains/llvm/prebuilt/*)
+cat > %q <<EOF
+[binaries]
+c = '%s-clang'
+cpp = '%s-clang++'
+ar = '$TC/bin/llvm-ar'
+strip = '$TC/bin/llvm-strip'
+
+[host_machine]
+system = 'android'
+cpu_family = '%s'
+cpu = '%s'
+endian = 'little'
+EOF
+`, crossFile, compiler, compiler, info.CPUFamily, info.CPU)
+			mesonBuildType := "release"
+			if strings.EqualFold(buildType, "Debug") {
+				mesonBuildType = "debug"
+			}
+			setupArgs := append([]string{"--cross-file", crossFile, "--buildtype=" + mesonBuildType}, a.MesonArgs...)
+			fmt.Fprintf(&s, "if [ -f %q ]; then\n", buildDir+"/build.ninja")
+			fmt.Fprintf(&s, "    meson setup --reconfigure %s %s %s\n", buildDir, a.SourceDir, strings.Join(setupArgs, " "))
+			s.WriteString("else\n")
+			fmt.Fprintf(&s, "    meson setup %s %s %s\n", buildDir, a.SourceDir, strings.Join(setupArgs, " "))
+			s.WriteString("fi\n")
+			compileArgs := []string{"-C", buildDir}
+			if tc.Jobs > 0 {
+				compileArgs = append(compileArgs, "-j", fmt.Sprintf("%d", tc.Jobs))
+			}
+			compileArgs = append(compileArgs, tc.BuildOptions...)
+			fmt.Fprintf(&s, "meson compile %s\n", strings.Join(compileArgs, " "))
+			fmt.Fprintf(&s, "find %s -type f -name \"*.so\" ! -path \"*.p/*\" -exec cp {} %s/ \\;\n", buildDir, outDir)
+
+		case ProjectTypeBazel:
+			// The project's .bazelrc defines the NDK platform for each ABI,
+			// e.g. build:android_arm64_v8a --platforms=//platforms:android_arm64
+			bazelConfig := "android_" + strings.ReplaceAll(abi, "-", "_")
+			bazel := fmt.Sprintf("bazel --output_base=%s/bazel", a.CacheDir)
+			bazelArgs := []string{"--config=" + bazelConfig, "--repo_env=ANDROID_NDK_HOME=\"$NDK\"", "--symlink_prefix=/"}
+			if strings.EqualFold(buildType, "Debug") {
+				bazelArgs = append(bazelArgs, "-c", "dbg")
+			} else {
+				bazelArgs = append(bazelArgs, "-c", "opt")
+			}
+			bazelArgs = append(bazelArgs, tc.BuildOptions...)
+			fmt.Fprintf(&s, "%s build %s //...\n", bazel, strings.Join(bazelArgs, " "))
+			fmt.Fprintf(&s, "BIN=$(%s info %s bazel-bin)\n", bazel, strings.Join(bazelArgs[:len(bazelArgs)-len(tc.BuildOptions)], " "))
+			fmt.Fprintf(&s, "find \"$BIN\" -type f -name \"*.so\" ! -path \"*.runfiles*\" -exec cp {} %s/ \\;\n", outDir)
+
+		default:
+			cmakeArgs := []string{
+				"-GNinja",
+				"-B", buildDir,
+				"-S", a.SourceDir,
+				"-DCMAKE_BUILD_TYPE=" + buildType,
+				"-DANDROID_ABI=" + abi,
+				fmt.Sprintf("-DANDROID_PLATFORM=android-%d", a.APILevel),
+			}
+			if tc.Optimization != "" {
+				cmakeArgs = append(cmakeArgs, "-DCMAKE_CXX_FLAGS=-O"+tc.Optimization)
+			}
+			cmakeArgs = append(cmakeArgs, tc.CMakeOptions...)
+
+			// vcpkg dependencies are built for the ABI's android triplet,
+			// with the NDK toolchain chain-loaded from the vcpkg toolchain
+			fmt.Fprintf(&s, "TOOLCHAIN_ARGS='-DCMAKE_TOOLCHAIN_FILE=\"$NDK/build/cmake/android.toolchain.cmake\"'\n")
+			fmt.Fprintf(&s, "if [ -n \"$VCPKG_ROOT\" ] && [ -f %q ]; then\n", a.SourceDir+"/vcpkg.json")
+			fmt.Fprintf(&s, "    TOOLCHAIN_ARGS='-DCMAKE_TOOLCHAIN_FILE=\"$VCPKG_ROOT/scripts/buildsystems/vcpkg.cmake\" "+
+				"-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE=\"$NDK/build/cmake/android.toolchain.cmake\" -DVCPKG_TARGET_TRIPLET=%s'\n", info.VcpkgTriplet)
+			s.WriteString("fi\n")
+			fmt.Fprintf(&s, "eval cmake %s $TOOLCHAIN_ARGS\n", strings.Join(cmakeArgs, " "))
+
+			buildArgs := []string{"--build", buildDir}
+			if tc.Jobs > 0 {
+				buildArgs = append(buildArgs, "--parallel", fmt.Sprintf("%d", tc.Jobs))
+			}
+			buildArgs = append(buildArgs, tc.BuildOptions...)
+			fmt.Fprintf(&s, "cmake %s\n", strings.Join(buildArgs, " "))
+			fmt.Fprintf(&s, "find %s -type f -name \"*.so\" ! -path \"*/CMakeFiles/*\" -exec cp {} %s/ \\;\n", buildDir, outDir)
+		}
+	}
+	return s.String()
+}
+
+// runAndroidBuild builds a toolchain of type android for each of its ABIs,
+// with the local NDK or in the runner's docker image.
+func runAndroidBuild(tc config.Toolchain, runner *config.Runner, projectRoot string, env map[string]string, mesonArgs []string, options ToolchainBuildOptions) error {
+	a := androidBuild{
+		ProjectType: DetectProjectType(),
+		Toolchain:   tc,
+		MesonArgs:   mesonArgs,
+		ABIs:        defaultAndroidABIs,
+		APILevel:    defaultAndroidAPILevel,
+	}
+	if a.ProjectType == ProjectTypeUnknown {
+		if _, err := os.Stat(filepath.Join(projectRoot, "CMakeLists.txt")); err != nil {
+			return fmt.Errorf("android toolchains support CMake, Meson and Bazel projects")
+		}
+	}
+	if tc.Android != nil {
+		if len(tc.Android.ABIs) > 0 {
+			a.ABIs = tc.Android.ABIs
+		}
+		if tc.Android.APILevel > 0 {
+			a.APILevel = tc.Android.APILevel
+		}
+		a.NDK = tc.Android.NDK
+	}
+	for _, abi := range a.ABIs {
+		if _, ok := androidABIs[abi]; !ok {
+			return fmt.Errorf("unknown Android ABI '%s' (supported: arm64-v8a, armeabi-v7a, x86_64, x86)", abi)
+		}
+	}
+
+	if options.ExecuteAfterBuild || options.RunTests || options.RunBenchmarks {
+		fmt.Fprintf(output.Stdout(), "  %sNote: Android binaries need a device or emulator; skipping run/test%s\n", colors.Yellow, colors.Reset)
+	}
+
+	image := ""
+	switch {
+	case runner != nil && runner.IsDocker():
+		resolved, err := resolveDockerImageNew(runner, projectRoot, options.Rebuild, options.Verbose)
+		if err != nil {
+			return err
+		}
+		image = resolved
+	case runner != nil && runner.IsSSH():
+		return fmt.Errorf("SSH runners are not supported for android toolchains")
+	default:
+		if a.NDK == "" {
+			ndk, err := findAndroidNDK()
+			if err != nil {
+				return err
+			}
+			a.NDK = ndk
+		}
+	}
+
+	cacheDir := filepath.Join(config.DirsOf(projectRoot).Cache, "android")
+	outputDir := androidOutputDir(projectRoot)
+	for _, dir := range []string{cacheDir, outputDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	if image == "" {
+		fmt.Fprintf(output.Stdout(), "  %s Building with Android NDK %s...%s\n", colors.Cyan, a.NDK, colors.Reset)
+	} else {
+		fmt.Fprintf(output.Stdout(), "  %s Building with Android NDK in %s...%s\n", colors.Cyan, image, colors.Reset)
+	}
+	err := runBuildScript(image, projectRoot, cacheDir, outputDir, env, func(p scriptPaths) string {
+		a.SourceDir, a.CacheDir, a.OutputDir = p.Source, p.Cache, p.Output
+		return a.script()
+	})
+	if err != nil {
+		return fmt.Errorf("android build failed: %w", err)
+	}
+
+	for _, abi := range a.ABIs {
+		fmt.Fprintf(output.Stdout(), "  %s Artifacts for %s are in: %s%s\n", colors.Green, abi, filepath.Join(androidOutputDir("."), abi), colors.Reset)
+	}
+	return nil
+}
+
+// findAndroidNDK locates a local NDK from the environment, preferring the
+// newest side-by-side NDK of the Android SDK.
+func findAndroidNDK() (string, error) {
+	for _, key := range []string{"ANDROID_NDK_HOME", "ANDROID_NDK_ROOT", "ANDROID_NDK"} {
+		if ndk := os.Getenv(key); ndk != "" {
+			return ndk, nil
+		}
+	}
+	for _, key := range []string{"ANDROID_HOME", "ANDROID_SDK_ROOT"} {
+		sdk := os.Getenv(key)
+		if sdk == "" {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(sdk, "ndk"))
+		if err != nil {
+			continue
+		}
+		var versions []string
+		for _, e := range entries {
+			if e.IsDir() {
+				versions = append(versions, e.Name())
+			}
+		}
+		if len(versions) > 0 {
+			sort.Slice(versions, func(i, j int) bool { return versionLess(versions[j], versions[i]) })
+			return filepath.Join(sdk, "ndk", versions[0]), nil
+		}
+	}
+	return "", fmt.Errorf("Android NDK not found\n  hint: set ANDROID_NDK_HOME, 'android.ndk' in cpx-ci.yaml, or install the NDK with 'sdkmanager \"ndk;<version>\"'")
+}
+
+// versionLess compares dotted numeric versions ("25.2.9519653" < "26.1.10909125").
+func versionLess(a, b string) bool {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA != nil || errB != nil {
+			if pa[i] != pb[i] {
+				return pa[i] < pb[i]
+			}
+			continue
+		}
+		if na != nb {
+			return na < nb
+		}
+	}
+	return len(pa) < len(pb)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAndroidBuildScriptCMake(t *testing.T) {
+	a := androidBuild{
+		ProjectType: ProjectTypeVcpkg,
+		Toolchain:   config.Toolchain{Name: "android", BuildType: "Release"},
+		NDK:         "/opt/ndk",
+		ABIs:        []string{"arm64-v8a", "x86_64"},
+		APILevel:    26,
+		SourceDir:   "/workspace",
+		CacheDir:    "/tmp/cache",
+		OutputDir:   "/output",
+	}
+	script := a.script()
+
+	assert.Contains(t, script, `export ANDROID_NDK_HOME="/opt/ndk"`)
+	assert.Contains(t, script, "eval cmake -GNinja -B /tmp/cache/android/arm64-v8a -S /workspace -DCMAKE_BUILD_TYPE=Release -DANDROID_ABI=arm64-v8a -DANDROID_PLATFORM=android-26 $TOOLCHAIN_ARGS")
+	assert.Contains(t, script, "-DVCPKG_TARGET_TRIPLET=arm64-android")
+	assert.Contains(t, script, "-DVCPKG_TARGET_TRIPLET=x64-android")
+	assert.Contains(t, script, `-exec cp {} /output/arm64-v8a/ \;`)
+	assert.Contains(t, script, `-exec cp {} /output/x86_64/ \;`)
+}
+
+func TestAndroidBuildScriptMeson(t *testing.T) {
+	a := androidBuild{
+		ProjectType: ProjectTypeMeson,
+		Toolchain:   config.Toolchain{Name: "droid", BuildType: "Debug"},
+		ABIs:        []string{"armeabi-v7a"},
+		APILevel:    24,
+		SourceDir:   "/src",
+		CacheDir:    "/cache",
+		OutputDir:   "/out",
+	}
+	script := a.script()
+
+	assert.NotContains(t, script, "export ANDROID_NDK_HOME")
+	assert.Contains(t, script, "cpp = '$TC/bin/armv7a-linux-androideabi24-clang++'")
+	assert.Contains(t, script, "cpu_family = 'arm'")
+	assert.Contains(t, script, "meson setup /cache/droid/armeabi-v7a /src --cross-file /cache/droid-armeabi-v7a.ini --buildtype=debug")
+}
+
+func TestAndroidBuildScriptBazel(t *testing.T) {
+	a := androidBuild{
+		ProjectType: ProjectTypeBazel,
+		Toolchain:   config.Toolchain{Name: "android"},
+		ABIs:        []string{"arm64-v8a"},
+		APILevel:    24,
+		CacheDir:    "/cache",
+		OutputDir:   "/out",
+	}
+	script := a.script()
+	assert.Contains(t, script, "bazel --output_base=/cache/bazel build --config=android_arm64_v8a")
+	assert.Contains(t, script, "-c opt //...")
+}
+
+func TestRunAndroidBuildRejectsUnknownABI(t *testing.T) {
+	projectRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectRoot, "CMakeLists.txt"), nil, 0644))
+
+	tc := config.Toolchain{Name: "android", Android: &config.AndroidConfig{ABIs: []string{"mips"}}}
+	err := runAndroidBuild(tc, nil, projectRoot, nil, nil, ToolchainBuildOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown Android ABI 'mips'")
+}
+
+func TestFindAndroidNDK(t *testing.T) {
+	for _, key := range []string{"ANDROID_NDK_HOME", "ANDROID_NDK_ROOT", "ANDROID_NDK", "ANDROID_SDK_ROOT"} {
+		t.Setenv(key, "")
+	}
+
+	sdk := t.TempDir()
+	for _, v := range []string{"25.2.9519653", "26.1.10909125", "9.0.0"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(sdk, "ndk", v), 0755))
+	}
+	t.Setenv("ANDROID_HOME", sdk)
+
+	ndk, err := findAndroidNDK()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(sdk, "ndk", "26.1.10909125"), ndk)
+
+	t.Setenv("ANDROID_NDK_HOME", "/opt/ndk")
+	ndk, err = findAndroidNDK()
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/ndk", ndk)
+
+	t.Setenv("ANDROID_NDK_HOME", "")
+	t.Setenv("ANDROID_HOME", "")
+	_, err = findAndroidNDK()
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/quality"
+	"github.com/spf13/cobra"
+)
+
+func AuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Run all quality checks and render an HTML dashboard",
+		Long: `Run clang-tidy, cppcheck, flawfinder, coverage (gcovr) and complexity metrics
+(lizard) and render a single static HTML dashboard with a page per file.
+
+The report directory is self-contained and can be attached to CI runs as an
+artifact. It also contains audit.json with the collected data. Tools that are
+not installed are shown as skipped. Coverage is read from the .gcda files of
+the last test run of a build compiled with --coverage.`,
+		Example: `  cpx audit                        # Write the report to audit/
+  cpx audit --html report/
+  cpx audit --skip-coverage --max-complexity 20`,
+		RunE: runAudit,
+		Args: cobra.ArbitraryArgs,
+	}
+
+	cmd.Flags().String("html", "audit", "Output directory of the HTML report")
+	cmd.Flags().Bool("skip-lint", false, "Skip clang-tidy analysis")
+	cmd.Flags().Bool("skip-cppcheck", false, "Skip Cppcheck analysis")
+	cmd.Flags().Bool("skip-flawfinder", false, "Skip Flawfinder analysis")
+	cmd.Flags().Bool("skip-coverage", false, "Skip coverage collection")
+	cmd.Flags().Bool("skip-complexity", false, "Skip complexity metrics")
+	cmd.Flags().Int("max-complexity", quality.DefaultMaxComplexity, "Cyclomatic complexity above which functions are reported")
+
+	return cmd
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	outputDir, _ := cmd.Flags().GetString("html")
+	skipLint, _ := cmd.Flags().GetBool("skip-lint")
+	skipCppcheck, _ := cmd.Flags().GetBool("skip-cppcheck")
+	skipFlawfinder, _ := cmd.Flags().GetBool("skip-flawfinder")
+	skipCoverage, _ := cmd.Flags().GetBool("skip-coverage")
+	skipComplexity, _ := cmd.Flags().GetBool("skip-complexity")
+	maxComplexity, _ := cmd.Flags().GetInt("max-complexity")
+
+	return quality.RunAudit(quality.AuditOptions{
+		OutputDir:      outputDir,
+		Targets:        args,
+		SkipLint:       skipLint,
+		SkipCppcheck:   skipCppcheck,
+		SkipFlawfinder: skipFlawfinder,
+		SkipCoverage:   skipCoverage,
+		SkipComplexity: skipComplexity,
+		MaxComplexity:  maxComplexity,
+	}, vcpkg.New())
+}
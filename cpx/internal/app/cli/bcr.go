@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// bcrRepoURL is the upstream Bazel Central Registry repository.
+const bcrRepoURL = "https://github.com/bazelbuild/bazel-central-registry.git"
+
+// bcrSparsePatterns are the paths checked out in sparse mode. cpx only needs
+// module metadata for search, info and version resolution.
+var bcrSparsePatterns = []string{"/bazel_registry.json", "/modules/*/metadata.json"}
+
+// BcrSyncOptions contains options for syncing the local BCR mirror.
+type BcrSyncOptions struct {
+	Dir     string
+	URL     string
+	Shallow bool
+	Sparse  bool
+}
+
+// BcrCmd creates the bcr command
+func BcrCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bcr",
+		Short: "Manage the local Bazel Central Registry mirror",
+		Long:  "Manage the local Bazel Central Registry (BCR) mirror used for Bazel dependency search and resolution.",
+	}
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Clone or update the local BCR mirror",
+		Long: `Clone the Bazel Central Registry into the cpx cache directory, or update an
+existing checkout, and configure it as bcr_root.`,
+		Example: `  cpx bcr sync                 # Shallow clone/update into the cache dir
+  cpx bcr sync --sparse        # Only check out module metadata (smallest)
+  cpx bcr sync --full          # Full history
+  cpx bcr sync --dir ~/bcr     # Use a custom location`,
+		RunE: runBcrSync,
+	}
+	syncCmd.Flags().String("dir", "", "Mirror location (default: configured bcr_root or cache dir)")
+	syncCmd.Flags().String("url", bcrRepoURL, "Registry git URL")
+	syncCmd.Flags().Bool("full", false, "Fetch full history instead of a shallow clone")
+	syncCmd.Flags().Bool("sparse", false, "Only check out module metadata files")
+	cmd.AddCommand(syncCmd)
+
+	return cmd
+}
+
+func runBcrSync(cmd *cobra.Command, _ []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	url, _ := cmd.Flags().GetString("url")
+	full, _ := cmd.Flags().GetBool("full")
+	sparse, _ := cmd.Flags().GetBool("sparse")
+
+	if dir == "" {
+		var err error
+		dir, err = defaultBcrDir()
+		if err != nil {
+			return err
+		}
+	}
+
+	return syncBcr(BcrSyncOptions{
+		Dir:     dir,
+		URL:     url,
+		Shallow: !full,
+		Sparse:  sparse,
+	})
+}
+
+// defaultBcrDir returns the configured bcr_root, falling back to the cache dir.
+func defaultBcrDir() (string, error) {
+	if cfg, err := projectConfig(); err == nil && cfg.BcrRoot != "" {
+		return cfg.BcrRoot, nil
+	}
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "bazel-central-registry"), nil
+}
+
+func syncBcr(opts BcrSyncOptions) error {
+	if !CheckCommandExists("git") {
+		return fmt.Errorf("git is required to sync the Bazel Central Registry")
+	}
+
+	absDir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	var steps [][]string
+	if _, err := os.Stat(filepath.Join(absDir, ".git")); err == nil {
+		output.Stepf("Updating Bazel Central Registry in %s...", absDir)
+		steps = bcrUpdateCommands(absDir, opts)
+	} else {
+		if entries, err := os.ReadDir(absDir); err == nil && len(entries) > 0 {
+			return fmt.Errorf("%s exists and is not a git checkout\n  hint: remove it or use --dir to choose another location", absDir)
+		}
+		if err := os.MkdirAll(filepath.Dir(absDir), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		output.Stepf("Cloning Bazel Central Registry to %s...", absDir)
+		if !opts.Sparse && !opts.Shallow {
+			fmt.Printf("%s(This may take a while - the registry is large)%s\n", colors.Yellow, colors.Reset)
+		}
+		steps = bcrCloneCommands(absDir, opts)
+	}
+
+	for _, args := range steps {
+		gitCmd := execCommand("git", args...)
+		gitCmd.Stdout = os.Stdout
+		gitCmd.Stderr = os.Stderr
+		if err := gitCmd.Run(); err != nil {
+			return fmt.Errorf("git %s failed: %w", args[0], err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(absDir, "modules")); err != nil {
+		return fmt.Errorf("%s does not look like a Bazel Central Registry (modules/ not found)", absDir)
+	}
+
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	if cfg.BcrRoot != absDir {
+		cfg.BcrRoot = absDir
+		if err := config.SaveGlobal(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		output.Successf("✓ Set bcr_root to: %s", absDir)
+	}
+
+	output.Successf("✓ Bazel Central Registry is up to date")
+	return nil
+}
+
+// bcrCloneCommands returns the git invocations that create a new mirror.
+func bcrCloneCommands(dir string, opts BcrSyncOptions) [][]string {
+	clone := []string{"clone"}
+	if opts.Shallow {
+		clone = append(clone, "--depth", "1")
+	}
+	if opts.Sparse {
+		clone = append(clone, "--filter=blob:none", "--no-checkout")
+	}
+	clone = append(clone, opts.URL, dir)
+
+	steps := [][]string{clone}
+	if opts.Sparse {
+		steps = append(steps,
+			append([]string{"-C", dir, "sparse-checkout", "set", "--no-cone"}, bcrSparsePatterns...),
+			[]string{"-C", dir, "checkout"},
+		)
+	}
+	return steps
+}
+
+// bcrUpdateCommands returns the git invocations that refresh an existing mirror.
+func bcrUpdateCommands(dir string, opts BcrSyncOptions) [][]string {
+	var steps [][]string
+	if opts.Sparse {
+		steps = append(steps, append([]string{"-C", dir, "sparse-checkout", "set", "--no-cone"}, bcrSparsePatterns...))
+	}
+	if opts.Shallow {
+		steps = append(steps,
+			[]string{"-C", dir, "fetch", "--depth", "1", "origin"},
+			[]string{"-C", dir, "reset", "--hard", "FETCH_HEAD"},
+		)
+	} else {
+		steps = append(steps, []string{"-C", dir, "pull", "--ff-only"})
+	}
+	return steps
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBcrCloneCommands(t *testing.T) {
+	steps := bcrCloneCommands("/tmp/bcr", BcrSyncOptions{URL: bcrRepoURL, Shallow: true})
+	assert.Equal(t, [][]string{{"clone", "--depth", "1", bcrRepoURL, "/tmp/bcr"}}, steps)
+
+	steps = bcrCloneCommands("/tmp/bcr", BcrSyncOptions{URL: bcrRepoURL, Shallow: true, Sparse: true})
+	require.Len(t, steps, 3)
+	assert.Equal(t, []string{"clone", "--depth", "1", "--filter=blob:none", "--no-checkout", bcrRepoURL, "/tmp/bcr"}, steps[0])
+	assert.Equal(t, []string{"-C", "/tmp/bcr", "sparse-checkout", "set", "--no-cone", "/bazel_registry.json", "/modules/*/metadata.json"}, steps[1])
+	assert.Equal(t, []string{"-C", "/tmp/bcr", "checkout"}, steps[2])
+}
+
+func TestBcrUpdateCommands(t *testing.T) {
+	steps := bcrUpdateCommands("/tmp/bcr", BcrSyncOptions{Shallow: false})
+	assert.Equal(t, [][]string{{"-C", "/tmp/bcr", "pull", "--ff-only"}}, steps)
+
+	steps = bcrUpdateCommands("/tmp/bcr", BcrSyncOptions{Shallow: true})
+	assert.Equal(t, [][]string{
+		{"-C", "/tmp/bcr", "fetch", "--depth", "1", "origin"},
+		{"-C", "/tmp/bcr", "reset", "--hard", "FETCH_HEAD"},
+	}, steps)
+}
+
+func TestSyncBcrConfiguresRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	bcrDir := filepath.Join(tmpDir, "bcr")
+
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+	execLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	var calls [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		calls = append(calls, append([]string{name}, arg...))
+		// Simulate the clone creating the registry layout
+		require.NoError(t, os.MkdirAll(filepath.Join(bcrDir, "modules"), 0755))
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		return cmd
+	}
+
+	require.NoError(t, syncBcr(BcrSyncOptions{Dir: bcrDir, URL: bcrRepoURL, Shallow: true}))
+	require.Len(t, calls, 1)
+	assert.Equal(t, "clone", calls[0][1])
+
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, bcrDir, cfg.BcrRoot)
+}
+
+func TestSyncBcrRejectsNonGitDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("x"), 0644))
+
+	oldExecLookPath := execLookPath
+	defer func() { execLookPath = oldExecLookPath }()
+	execLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	err := syncBcr(BcrSyncOptions{Dir: tmpDir, URL: bcrRepoURL})
+	assert.Error(t, err)
+}
@@ -15,18 +15,26 @@ func BenchCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "bench",
 		Short: "Build and run benchmarks",
-		Long:  "Build the project benchmarks and run them. Detects vcpkg/CMake or Bazel projects automatically.",
+		Long: `Build the project benchmarks and run them. Detects vcpkg/CMake or Bazel projects automatically.
+
+Without --target and with stdin a terminal, a fuzzy-searchable picker asks
+which benchmark to run when there are several. The choice is remembered in
+.cache/last-targets.json.`,
 		Example: `  cpx bench            # Build + run all benchmarks
   cpx bench --verbose  # Show verbose output
   cpx bench --target //bench:myapp_bench  # Run specific benchmark (Bazel)`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runBenchCmd(cmd, args)
+			return withBuildLog(cmd, "bench", func() error { return runBenchCmd(cmd, args) })
 		},
 	}
 
 	cmd.Flags().BoolP("verbose", "v", false, "Show verbose build output")
 	cmd.Flags().String("target", "", "Specific benchmark target to run (Bazel projects)")
 	cmd.Flags().String("toolchain", "", "Toolchain to run benchmarks in (from cpx-ci.yaml)")
+	addEnvFileFlag(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("target", completeTargets)
+	_ = cmd.RegisterFlagCompletionFunc("toolchain", completeToolchains)
 
 	return cmd
 }
@@ -46,12 +54,18 @@ func runBenchCmd(cmd *cobra.Command, args []string) error {
 			Verbose:           verbose,
 		})
 	}
+	if err := loadEnvFiles(cmd); err != nil {
+		return err
+	}
 	projectType := DetectProjectType()
 
 	opts := build.BenchOptions{
 		Verbose: verbose,
 		Target:  target,
 	}
+	if target == "" {
+		opts.Pick = targetPicker("bench", "")
+	}
 
 	var builder build.BuildSystem
 
@@ -3,12 +3,18 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/ozacod/cpx/internal/pkg/build/bazel"
+	"github.com/ozacod/cpx/internal/pkg/build/events"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
 	"github.com/ozacod/cpx/internal/pkg/build/meson"
 	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
-	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/script"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +25,34 @@ func BuildCmd() *cobra.Command {
 		Short: "Compile the project",
 		Long: `Compile the project. Automatically detects project type:
   - vcpkg/CMake projects: Uses CMake with vcpkg toolchain
-  - Bazel projects: Uses bazel build`,
+  - Bazel projects: Uses bazel build
+
+--profile selects a named set of options from the profiles: section of
+cpx.yaml or cpx-ci.yaml; flags given on the command line take precedence:
+
+  profiles:
+    release-lto:
+      release: true
+      args: [-DCMAKE_INTERPROCEDURAL_OPTIMIZATION=ON]
+    asan-ci:
+      sanitizer: asan,ubsan
+      defines: [CI_BUILD, LOG_LEVEL=2]
+
+--variants builds several variants one after the other, each in its own
+.cache/native/<variant> and .bin/native/<variant> directories, and ends with a
+summary; a failing variant does not stop the others. A variant is a build type
+(debug, release, O0-O3, Os, Ofast) and sanitizers joined with '-', such as
+asan or release-asan-ubsan, or the name of a profile.
+
+--preset builds a CMake project with one of its configure or build presets,
+from CMakePresets.json, CMakeUserPresets.json and the files they include, in
+.cache/native/preset-<name>; --list-presets lists them. Without --preset, the
+preset named "default" is used if there is one.
+
+--reproducible builds bit-identical artifacts from the same commit: it sets
+SOURCE_DATE_EPOCH to the commit time (unless already set), maps the project
+path out of objects and debug info and writes static libraries without
+timestamps, in .cache/native/<variant>-repro. cpx verify-repro checks it.`,
 		Example: `  cpx build              # Debug build (default)
   cpx build --release    # Release build (-O2)
   cpx build -O3          # Maximum optimization
@@ -27,24 +60,47 @@ func BuildCmd() *cobra.Command {
   cpx build --clean      # Clean rebuild
   cpx build --asan       # Build with AddressSanitizer
   cpx build --tsan       # Build with ThreadSanitizer
-  cpx build all          # Build all toolchains (Docker)`,
+  cpx build --sanitizer asan,ubsan  # Combine sanitizers
+  cpx build --shared     # Build libraries as shared libraries
+  cpx build --pch-report # Time clean builds with and without the precompiled header
+  cpx build --profile asan-ci  # Use a profile from cpx.yaml
+  cpx build --variants debug,release,asan  # Build three variants and summarize
+  cpx build --preset asan  # Build with the asan CMake preset
+  cpx build --release --reproducible  # Bit-identical release build
+  cpx build all          # Build all toolchains (Docker)
+  cpx build all -p 4     # Build up to 4 toolchains concurrently
+  cpx build --message-format json  # Stream build events as NDJSON`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runBuild(cmd, args)
+			return withNotify("build", func() error {
+				return withBuildLog(cmd, "build", func() error {
+					return withMessageFormat(cmd, func() error {
+						return withCommandHooks("build", func() error { return runBuild(cmd, args) })
+					})
+				})
+			})
 		},
 	}
 
 	cmd.Flags().BoolP("release", "r", false, "Release build (-O2). Default is debug")
 	cmd.Flags().Bool("debug", false, "Debug build (-O0). Default; kept for compatibility")
-	cmd.Flags().IntP("jobs", "j", 0, "Parallel jobs for build (0 = auto)")
+	cmd.Flags().IntP("jobs", "j", 0, "Parallel jobs for build (0 = the jobs config setting, else auto)")
 	cmd.Flags().String("toolchain", "", "Toolchain to build (from cpx-ci.yaml)")
 	cmd.Flags().BoolP("clean", "c", false, "Clean build directory before building")
 	cmd.Flags().StringP("opt", "O", "", "Override optimization level: 0,1,2,3,s,fast")
 	cmd.Flags().Bool("verbose", false, "Show full build output")
-	cmd.Flags().Bool("asan", false, "Build with AddressSanitizer")
-	cmd.Flags().Bool("tsan", false, "Build with ThreadSanitizer")
-	cmd.Flags().Bool("msan", false, "Build with MemorySanitizer")
-	cmd.Flags().Bool("ubsan", false, "Build with UndefinedBehaviorSanitizer")
+	addSanitizerFlags(cmd, "Build")
+	addLibraryTypeFlags(cmd, "Build")
 	cmd.Flags().Bool("list", false, "List available build targets")
+	cmd.Flags().Bool("pch-report", false, "Do clean builds without and with the precompiled header and report the time saved")
+	cmd.Flags().String("message-format", "human", "Output format: human or json (NDJSON build events on stdout)")
+	cmd.Flags().String("profile", "", "Build profile from cpx.yaml or cpx-ci.yaml (opt level, sanitizer, defines, extra args)")
+	cmd.Flags().StringSlice("variants", nil, "Build several variants in one go, e.g. debug,release,asan (build types, sanitizers or profiles)")
+	cmd.Flags().String("preset", "", "Build with a configure or build preset of CMakePresets.json or CMakeUserPresets.json")
+	cmd.Flags().Bool("list-presets", false, "List the CMake presets of the project")
+	cmd.Flags().Bool("reproducible", false, "Build bit-identical artifacts from the same commit (SOURCE_DATE_EPOCH, path maps, deterministic archives)")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	_ = cmd.RegisterFlagCompletionFunc("variants", completeVariants)
+	_ = cmd.RegisterFlagCompletionFunc("preset", completePresets)
 
 	//todo: all should be tested
 	allCmd := &cobra.Command{
@@ -54,6 +110,7 @@ func BuildCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			rebuild, _ := cmd.Flags().GetBool("rebuild")
 			toolchainName, _ := cmd.Flags().GetString("toolchain")
+			parallel, _ := cmd.Flags().GetInt("parallel")
 			return runToolchainBuild(ToolchainBuildOptions{
 				ToolchainName:     toolchainName,
 				Rebuild:           rebuild,
@@ -61,16 +118,46 @@ func BuildCmd() *cobra.Command {
 				RunTests:          false,
 				RunBenchmarks:     false,
 				Verbose:           true, // Build all is often verbose or we can get it from flag
+				Parallel:          parallel,
 			})
 		},
 	}
 	allCmd.Flags().String("toolchain", "", "Build only specific toolchain (default: all)")
 	allCmd.Flags().Bool("rebuild", false, "Rebuild Docker images even if they exist")
+	allCmd.Flags().IntP("parallel", "p", 1, "Number of toolchains to build concurrently")
+	_ = allCmd.RegisterFlagCompletionFunc("toolchain", completeToolchains)
+	_ = cmd.RegisterFlagCompletionFunc("toolchain", completeToolchains)
 	cmd.AddCommand(allCmd)
 
 	return cmd
 }
 
+// withMessageFormat runs fn with build events written to stdout as NDJSON
+// when --message-format is json. Human-readable output moves to stderr so
+// stdout carries only events, ending with a build-finished event.
+func withMessageFormat(cmd *cobra.Command, fn func() error) error {
+	format, _ := cmd.Flags().GetString("message-format")
+	switch format {
+	case "", "human":
+		return fn()
+	case "json":
+	default:
+		return fmt.Errorf("unknown message format '%s'\n  hint: use human or json", format)
+	}
+
+	stdout := os.Stdout
+	events.Enable(stdout)
+	os.Stdout = os.Stderr
+	defer func() {
+		os.Stdout = stdout
+		events.Disable()
+	}()
+
+	err := fn()
+	events.Finished(err)
+	return err
+}
+
 func runBuild(cmd *cobra.Command, _ []string) error {
 	release, _ := cmd.Flags().GetBool("release")
 	jobs, _ := cmd.Flags().GetInt("jobs")
@@ -78,8 +165,17 @@ func runBuild(cmd *cobra.Command, _ []string) error {
 	clean, _ := cmd.Flags().GetBool("clean")
 	optLevel, _ := cmd.Flags().GetString("opt")
 	verbose, _ := cmd.Flags().GetBool("verbose")
+	reproducible, _ := cmd.Flags().GetBool("reproducible")
+
+	variants, err := variantsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
 
 	if toolchain != "" {
+		if reproducible {
+			return fmt.Errorf("--reproducible is not supported with --toolchain")
+		}
 		return runToolchainBuild(ToolchainBuildOptions{
 			ToolchainName:     toolchain,
 			Rebuild:           false,
@@ -90,36 +186,44 @@ func runBuild(cmd *cobra.Command, _ []string) error {
 		})
 	}
 
-	asan, _ := cmd.Flags().GetBool("asan")
-	tsan, _ := cmd.Flags().GetBool("tsan")
-	msan, _ := cmd.Flags().GetBool("msan")
-	ubsan, _ := cmd.Flags().GetBool("ubsan")
-
-	sanitizer := ""
-	sanitizerCount := 0
-	if asan {
-		sanitizer = "asan"
-		sanitizerCount++
+	sanitizer, err := sanitizerFromFlags(cmd)
+	if err != nil {
+		return err
 	}
-	if tsan {
-		sanitizer = "tsan"
-		sanitizerCount++
+	profile, err := buildProfileFromFlags(cmd)
+	if err != nil {
+		return err
 	}
-	if msan {
-		sanitizer = "msan"
-		sanitizerCount++
+	release = release || profile.Release
+	if optLevel == "" {
+		optLevel = profile.Opt
 	}
-	if ubsan {
-		sanitizer = "ubsan"
-		sanitizerCount++
+	if sanitizer == "" {
+		sanitizer = profile.Sanitizer
 	}
-	if sanitizerCount > 1 {
-		return fmt.Errorf("only one sanitizer can be used at a time (got %d)", sanitizerCount)
+
+	if jobs == 0 {
+		if cfg, err := projectConfig(); err == nil {
+			jobs = cfg.Jobs
+		}
 	}
 
 	projectType := DetectProjectType()
 
+	if listPresetsFlag, _ := cmd.Flags().GetBool("list-presets"); listPresetsFlag {
+		return listPresets()
+	}
+	preset, err := presetFromFlags(cmd, projectType)
+	if err != nil {
+		return err
+	}
+
 	WarnMissingBuildTools(projectType)
+	if reproducible {
+		if err := setupReproducible("."); err != nil {
+			return err
+		}
+	}
 
 	list, _ := cmd.Flags().GetBool("list")
 
@@ -132,7 +236,7 @@ func runBuild(cmd *cobra.Command, _ []string) error {
 			fmt.Printf("No targets found for %s.\n", b.Name())
 			return nil
 		}
-		fmt.Printf("%sListing %s targets...%s\n", colors.Cyan, b.Name(), colors.Reset)
+		output.Stepf("Listing %s targets...", b.Name())
 		for _, t := range targets {
 			fmt.Printf("  %s\n", t)
 		}
@@ -140,13 +244,33 @@ func runBuild(cmd *cobra.Command, _ []string) error {
 	}
 
 	buildOpts := build.BuildOptions{
-		Release:   release,
-		OptLevel:  optLevel,
-		Sanitizer: sanitizer,
-		Target:    "",
-		Jobs:      jobs,
-		Clean:     clean,
-		Verbose:   verbose,
+		Release:      release,
+		OptLevel:     optLevel,
+		Sanitizer:    sanitizer,
+		Target:       "",
+		Jobs:         jobs,
+		Clean:        clean,
+		Verbose:      verbose,
+		LibraryType:  libraryTypeFromFlags(cmd),
+		Defines:      profile.Defines,
+		Preset:       preset,
+		Reproducible: reproducible,
+	}
+	if buildOpts.PCH, err = pchFromConfig("cpx-ci.yaml"); err != nil {
+		return err
+	}
+	if buildOpts.CUDAArchitectures, err = cudaArchitecturesFromConfig("cpx-ci.yaml"); err != nil {
+		return err
+	}
+	// the hardened runtime of Developer ID signatures keeps debuggers and
+	// sanitizers out, so only plain release builds get one
+	if release && sanitizer == "" {
+		if buildOpts.DeveloperID, err = developerIDFromConfig("cpx-ci.yaml"); err != nil {
+			return err
+		}
+	}
+	if err := setupCUDA(); err != nil {
+		return err
 	}
 
 	var builder build.BuildSystem
@@ -164,6 +288,39 @@ func runBuild(cmd *cobra.Command, _ []string) error {
 	if list {
 		return handleList(builder)
 	}
+	if err := syncTargetOptions(projectType); err != nil {
+		return err
+	}
+
+	projectScript, err := loadProjectScript()
+	if err != nil {
+		return err
+	}
+	if variants != nil {
+		return buildVariants(builder, buildOpts, projectScript, variants)
+	}
+	scriptArgs, err := projectScript.BuildArgs(script.BuildContext{
+		BuildSystem: builder.Name(),
+		Release:     release,
+		OptLevel:    optLevel,
+		Sanitizer:   sanitizer,
+	})
+	if err != nil {
+		return err
+	}
+	buildOpts.ExtraArgs = append(slices.Clone(profile.Args), scriptArgs...)
 
-	return builder.Build(context.Background(), buildOpts)
+	if pchReport, _ := cmd.Flags().GetBool("pch-report"); pchReport {
+		if err := runPCHReport(builder, buildOpts); err != nil {
+			return err
+		}
+	} else if err := builder.Build(context.Background(), buildOpts); err != nil {
+		return err
+	}
+
+	outputDir := filepath.Join(config.BinDir(), "native", build.GetOutputDir(release, optLevel, sanitizer))
+	if preset != "" {
+		outputDir = filepath.Join(config.BinDir(), "native", build.PresetOutputDir(preset))
+	}
+	return projectScript.PostArtifacts(outputDir)
 }
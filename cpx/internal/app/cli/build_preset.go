@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/spf13/cobra"
+)
+
+// presetConflicts are the build flags that pick the build type or flags a
+// CMake preset sets, so they cannot be combined with --preset.
+var presetConflicts = []string{"release", "debug", "opt", "sanitizer", "asan", "tsan", "msan", "ubsan", "profile", "toolchain", "variants", "pch-report"}
+
+// presetFromFlags returns the CMake preset --preset names, or "" when it is
+// not given.
+func presetFromFlags(cmd *cobra.Command, projectType ProjectType) (string, error) {
+	preset, _ := cmd.Flags().GetString("preset")
+	if preset == "" {
+		return "", nil
+	}
+	for _, flag := range presetConflicts {
+		if cmd.Flags().Lookup(flag) != nil && cmd.Flags().Changed(flag) {
+			return "", fmt.Errorf("--preset cannot be combined with --%s\n  hint: set it in the preset instead", flag)
+		}
+	}
+	if projectType != ProjectTypeVcpkg {
+		return "", fmt.Errorf("--preset selects a CMake preset; this is a %s project", projectType)
+	}
+	return preset, nil
+}
+
+// listPresets prints the CMake presets of the project in the current
+// directory.
+func listPresets() error {
+	presets, err := vcpkg.LoadCMakePresets(".")
+	if err != nil {
+		return err
+	}
+	printPresets := func(kind string, list []vcpkg.CMakePreset) {
+		first := true
+		for _, p := range list {
+			if p.Hidden {
+				continue
+			}
+			if first {
+				fmt.Fprintf(output.Stdout(), "%s%s presets:%s\n", colors.Cyan, kind, colors.Reset)
+				first = false
+			}
+			about := p.DisplayName
+			if p.Description != "" {
+				about = p.Description
+			}
+			if p.ConfigurePreset != "" {
+				about += fmt.Sprintf(" %s(configure preset %s)%s", colors.Gray, p.ConfigurePreset, colors.Reset)
+			}
+			fmt.Fprintf(output.Stdout(), "  %s%s%s  %s\n", colors.Green, p.Name, colors.Reset, about)
+		}
+	}
+	if len(presets.Names()) == 0 {
+		fmt.Fprintln(output.Stdout(), "No CMake presets found (CMakePresets.json, CMakeUserPresets.json).")
+		return nil
+	}
+	printPresets("Configure", presets.Configure)
+	printPresets("Build", presets.Build)
+	return nil
+}
+
+// completePresets completes the CMake presets of the project.
+func completePresets(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	presets, err := vcpkg.LoadCMakePresets(".")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(presets.Names(), toComplete, nil), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetFromFlags(t *testing.T) {
+	cmd := BuildCmd()
+	preset, err := presetFromFlags(cmd, ProjectTypeVcpkg)
+	require.NoError(t, err)
+	assert.Empty(t, preset)
+
+	require.NoError(t, cmd.Flags().Set("preset", "asan"))
+	preset, err = presetFromFlags(cmd, ProjectTypeVcpkg)
+	require.NoError(t, err)
+	assert.Equal(t, "asan", preset)
+
+	_, err = presetFromFlags(cmd, ProjectTypeMeson)
+	assert.ErrorContains(t, err, "this is a meson project")
+
+	require.NoError(t, cmd.Flags().Set("release", "true"))
+	_, err = presetFromFlags(cmd, ProjectTypeVcpkg)
+	assert.ErrorContains(t, err, "--preset cannot be combined with --release")
+}
+
+func TestCompletePresets(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("CMakePresets.json", []byte(templates.GenerateCMakePresets()), 0644))
+	assert.Equal(t, []string{"debug", "default"}, complete(t, BuildCmd(), "build", "--preset", "de"))
+}
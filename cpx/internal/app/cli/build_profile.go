@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// optLevels are the values of --opt and a profile's opt
+var optLevels = []string{"0", "1", "2", "3", "s", "fast"}
+
+// buildProfileFromFlags returns the profile of the project's cpx.yaml or
+// cpx-ci.yaml that --profile names, or an empty one. Its sanitizer is validated and
+// the project's sanitizer settings are loaded for it.
+func buildProfileFromFlags(cmd *cobra.Command) (config.BuildProfile, error) {
+	name, _ := cmd.Flags().GetString("profile")
+	if name == "" {
+		return config.BuildProfile{}, nil
+	}
+	profile, err := loadBuildProfile("cpx-ci.yaml", name)
+	if err != nil {
+		return config.BuildProfile{}, err
+	}
+	if profile.Opt != "" && !slices.Contains(optLevels, profile.Opt) {
+		return config.BuildProfile{}, fmt.Errorf("profile %q: invalid opt %q (use %s)", name, profile.Opt, strings.Join(optLevels, ", "))
+	}
+	if profile.Sanitizer != "" {
+		if profile.Sanitizer, err = build.ParseSanitizer(profile.Sanitizer); err != nil {
+			return config.BuildProfile{}, fmt.Errorf("profile %q: %w", name, err)
+		}
+		if err := loadSanitizerConfig("cpx-ci.yaml"); err != nil {
+			return config.BuildProfile{}, err
+		}
+	}
+	return profile, nil
+}
+
+// buildProfiles returns the profiles of the cpx-ci.yaml at path and of the
+// cpx.yaml next to it; those of cpx.yaml take precedence.
+func buildProfiles(path string) (map[string]config.BuildProfile, error) {
+	profiles := map[string]config.BuildProfile{}
+	ciConfig, err := config.LoadToolchains(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		maps.Copy(profiles, ciConfig.Profiles)
+	}
+	manifest, err := config.LoadManifest(filepath.Join(filepath.Dir(path), config.ManifestFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		maps.Copy(profiles, manifest.Profiles)
+	}
+	return profiles, nil
+}
+
+// loadBuildProfile returns the profile called name from the cpx.yaml or the
+// cpx-ci.yaml at path.
+func loadBuildProfile(path, name string) (config.BuildProfile, error) {
+	profiles, err := buildProfiles(path)
+	if err != nil {
+		return config.BuildProfile{}, err
+	}
+	if len(profiles) == 0 {
+		return config.BuildProfile{}, fmt.Errorf("profile %q not found: no profiles are defined\n  hint: define it under profiles: in %s or %s", name, config.ManifestFile, path)
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return config.BuildProfile{}, fmt.Errorf("profile %q not found in %s or %s\n  hint: available profiles: %s", name, config.ManifestFile, path, strings.Join(profileNames(profiles), ", "))
+	}
+	return profile, nil
+}
+
+// profileNames returns the names of profiles, sorted.
+func profileNames(profiles map[string]config.BuildProfile) []string {
+	names := slices.Sorted(maps.Keys(profiles))
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	return names
+}
+
+// completeProfiles completes profile names from cpx.yaml and cpx-ci.yaml.
+func completeProfiles(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	profiles, err := buildProfiles("cpx-ci.yaml")
+	if err != nil || len(profiles) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(profileNames(profiles), toComplete, nil), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesConfig = `profiles:
+  release-lto:
+    release: true
+    args: [-DCMAKE_INTERPROCEDURAL_OPTIMIZATION=ON]
+  asan-ci:
+    opt: "1"
+    sanitizer: ubsan,asan
+    defines: [CI_BUILD, LOG_LEVEL=2]
+  broken:
+    opt: "9"
+`
+
+func TestBuildProfileFromFlags(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(profilesConfig), 0644))
+
+	cmd := BuildCmd()
+	profile, err := buildProfileFromFlags(cmd)
+	require.NoError(t, err)
+	assert.Empty(t, profile, "no --profile")
+
+	require.NoError(t, cmd.Flags().Set("profile", "asan-ci"))
+	profile, err = buildProfileFromFlags(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "1", profile.Opt)
+	assert.Equal(t, "asan,ubsan", profile.Sanitizer)
+	assert.Equal(t, []string{"CI_BUILD", "LOG_LEVEL=2"}, profile.Defines)
+	assert.Equal(t, "O1-asan-ubsan", statsVariant(cmd))
+
+	require.NoError(t, cmd.Flags().Set("profile", "broken"))
+	_, err = buildProfileFromFlags(cmd)
+	assert.ErrorContains(t, err, `invalid opt "9"`)
+
+	require.NoError(t, cmd.Flags().Set("profile", "missing"))
+	_, err = buildProfileFromFlags(cmd)
+	assert.ErrorContains(t, err, "available profiles: asan-ci, broken, release-lto")
+}
+
+func TestBuildProfileWithoutConfig(t *testing.T) {
+	chdirTemp(t)
+	_, err := loadBuildProfile("cpx-ci.yaml", "dev")
+	assert.ErrorContains(t, err, `profile "dev" not found`)
+}
+
+func TestCompleteProfiles(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(profilesConfig), 0644))
+	assert.Equal(t, []string{"release-lto"}, complete(t, BuildCmd(), "build", "--profile", "rel"))
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runWithMessageFormat calls withMessageFormat and returns what fn and the
+// event stream wrote to stdout and stderr.
+func runWithMessageFormat(t *testing.T, format string, fn func() error) (stdout, stderr string, err error) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("message-format", "human", "")
+	require.NoError(t, cmd.Flags().Set("message-format", format))
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+	err = withMessageFormat(cmd, fn)
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+
+	var outBuf, errBuf bytes.Buffer
+	_, _ = outBuf.ReadFrom(outR)
+	_, _ = errBuf.ReadFrom(errR)
+	return outBuf.String(), errBuf.String(), err
+}
+
+func TestWithMessageFormatJSON(t *testing.T) {
+	stdout, stderr, err := runWithMessageFormat(t, "json", func() error {
+		fmt.Println("Building...")
+		return errors.New("build failed")
+	})
+	require.Error(t, err)
+	assert.JSONEq(t, `{"reason": "build-finished", "success": false, "error": "build failed"}`, stdout)
+	assert.Equal(t, "Building...\n", stderr)
+}
+
+func TestWithMessageFormatHuman(t *testing.T) {
+	stdout, _, err := runWithMessageFormat(t, "human", func() error {
+		fmt.Println("Building...")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Building...\n", stdout)
+}
+
+func TestWithMessageFormatUnknown(t *testing.T) {
+	called := false
+	_, _, err := runWithMessageFormat(t, "xml", func() error {
+		called = true
+		return nil
+	})
+	require.Error(t, err)
+	assert.False(t, called)
+	assert.Contains(t, err.Error(), "unknown message format")
+}
+
+func TestSanitizerFromFlags(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		addSanitizerFlags(cmd, "Build")
+		require.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	sanitizer, err := sanitizerFromFlags(newCmd())
+	require.NoError(t, err)
+	assert.Empty(t, sanitizer)
+
+	sanitizer, err = sanitizerFromFlags(newCmd("--ubsan", "--asan"))
+	require.NoError(t, err)
+	assert.Equal(t, "asan,ubsan", sanitizer)
+
+	sanitizer, err = sanitizerFromFlags(newCmd("--sanitizer", "ubsan", "--asan"))
+	require.NoError(t, err)
+	assert.Equal(t, "asan,ubsan", sanitizer)
+
+	_, err = sanitizerFromFlags(newCmd("--asan", "--tsan"))
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte("sanitizers:\n  bogus:\n    flags: [-x]\n"), 0644))
+	_, err = sanitizerFromFlags(newCmd("--asan"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid sanitizers in cpx-ci.yaml")
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/script"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// variantConflicts are the build flags that pick a single variant, so they
+// cannot be combined with --variants.
+var variantConflicts = []string{"release", "debug", "opt", "sanitizer", "asan", "tsan", "msan", "ubsan", "profile", "toolchain", "pch-report", "list", "preset"}
+
+// buildVariant is one of the variants built by cpx build --variants.
+type buildVariant struct {
+	// Name is the variant as given on the command line
+	Name string
+
+	config.BuildProfile
+}
+
+// Dir returns the directory of the variant under .cache/native and
+// .bin/native.
+func (v buildVariant) Dir() string {
+	return build.GetOutputDir(v.Release, v.Opt, v.Sanitizer)
+}
+
+// parseVariants parses the --variants list. A variant is a build type
+// (debug, release or an opt level such as O3) followed by sanitizers, all
+// joined with "-": release, asan (a debug build), release-asan-ubsan. A
+// profile of cpx.yaml or cpx-ci.yaml may be named too.
+func parseVariants(names []string, profiles map[string]config.BuildProfile) ([]buildVariant, error) {
+	var variants []buildVariant
+	seen := map[string]string{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		variant := buildVariant{Name: name}
+		if profile, ok := profiles[name]; ok {
+			variant.BuildProfile = profile
+			if variant.Sanitizer != "" {
+				sanitizer, err := build.ParseSanitizer(variant.Sanitizer)
+				if err != nil {
+					return nil, fmt.Errorf("profile %q: %w", name, err)
+				}
+				variant.Sanitizer = sanitizer
+			}
+		} else {
+			parts := strings.Split(strings.ToLower(name), "-")
+			switch {
+			case parts[0] == "debug":
+				parts = parts[1:]
+			case parts[0] == "release":
+				variant.Release = true
+				parts = parts[1:]
+			case strings.HasPrefix(parts[0], "o") && slices.Contains(optLevels, parts[0][1:]):
+				variant.Opt = parts[0][1:]
+				parts = parts[1:]
+			}
+			sanitizer, err := build.ParseSanitizer(strings.Join(parts, ","))
+			if err != nil {
+				return nil, fmt.Errorf("invalid variant %q: %w\n  hint: use debug, release or O<level>, then sanitizers, joined with '-' (e.g. release-asan)", name, err)
+			}
+			variant.Sanitizer = sanitizer
+		}
+		if other, ok := seen[variant.Dir()]; ok {
+			if other == name {
+				continue
+			}
+			return nil, fmt.Errorf("variants %q and %q both build into .cache/native/%s", other, name, variant.Dir())
+		}
+		seen[variant.Dir()] = name
+		variants = append(variants, variant)
+	}
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("--variants needs at least one variant, e.g. debug,release,asan")
+	}
+	return variants, nil
+}
+
+// variantsFromFlags returns the variants --variants names, or nil when it
+// is not given.
+func variantsFromFlags(cmd *cobra.Command) ([]buildVariant, error) {
+	names, _ := cmd.Flags().GetStringSlice("variants")
+	if !cmd.Flags().Changed("variants") {
+		return nil, nil
+	}
+	for _, flag := range variantConflicts {
+		if cmd.Flags().Lookup(flag) != nil && cmd.Flags().Changed(flag) {
+			return nil, fmt.Errorf("--variants cannot be combined with --%s", flag)
+		}
+	}
+
+	profiles, err := buildProfiles("cpx-ci.yaml")
+	if err != nil {
+		return nil, err
+	}
+	variants, err := parseVariants(names, profiles)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		if v.Sanitizer != "" {
+			if err := loadSanitizerConfig("cpx-ci.yaml"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return variants, nil
+}
+
+// variantResult is the outcome of building one variant.
+type variantResult struct {
+	Variant  buildVariant
+	Err      error
+	Duration time.Duration
+}
+
+// buildVariants builds each variant in turn with base as the common
+// options, in the .cache/native/<variant> directory of each, and prints a
+// summary. A failed variant does not stop the others.
+func buildVariants(builder build.BuildSystem, base build.BuildOptions, projectScript *script.Script, variants []buildVariant) error {
+	results := make([]variantResult, len(variants))
+	for i, v := range variants {
+		fmt.Fprintf(output.Stdout(), "\n%s  ▶ Variant%s %s%s%s %s(%d/%d)%s\n", colors.Cyan, colors.Reset, colors.Green, v.Name, colors.Reset, colors.Gray, i+1, len(variants), colors.Reset)
+
+		opts := base
+		opts.Release = v.Release
+		opts.OptLevel = v.Opt
+		opts.Sanitizer = v.Sanitizer
+		opts.Defines = v.Defines
+		start := time.Now()
+		err := func() error {
+			scriptArgs, err := projectScript.BuildArgs(script.BuildContext{
+				BuildSystem: builder.Name(),
+				Release:     v.Release,
+				OptLevel:    v.Opt,
+				Sanitizer:   v.Sanitizer,
+			})
+			if err != nil {
+				return err
+			}
+			opts.ExtraArgs = append(slices.Clone(v.Args), scriptArgs...)
+			if err := builder.Build(context.Background(), opts); err != nil {
+				return err
+			}
+			return projectScript.PostArtifacts(filepath.Join(config.BinDir(), "native", v.Dir()))
+		}()
+		results[i] = variantResult{Variant: v, Err: err, Duration: time.Since(start)}
+		if err != nil {
+			output.Warnf("Variant %s failed: %v", v.Name, err)
+		}
+	}
+
+	fmt.Fprintln(output.Stdout())
+	printVariantSummary(output.Stdout(), results)
+
+	var failed []string
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r.Variant.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d variant(s) failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// printVariantSummary prints a variant/status/time/output table.
+func printVariantSummary(w io.Writer, results []variantResult) {
+	width := len("Variant")
+	for _, r := range results {
+		width = max(width, len(r.Variant.Name))
+	}
+
+	fmt.Fprintf(w, "%s%-*s  %-6s  %-8s  %s%s\n", colors.Bold, width, "Variant", "Status", "Time", "Output", colors.Reset)
+	for _, r := range results {
+		status := colors.Green + "✓ pass" + colors.Reset
+		if r.Err != nil {
+			status = colors.Red + "✗ fail" + colors.Reset
+		}
+		fmt.Fprintf(w, "%-*s  %s  %-8s  %s\n", width, r.Variant.Name, status, r.Duration.Round(100*time.Millisecond), filepath.Join(config.BinDir(), "native", r.Variant.Dir()))
+	}
+}
+
+// completeVariants completes the variants of --variants: common build
+// types and sanitizers, and the profiles of cpx.yaml and cpx-ci.yaml.
+func completeVariants(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	candidates := []string{"debug", "release", "asan", "ubsan", "tsan", "msan", "asan-ubsan", "release-asan"}
+	if profiles, err := buildProfiles("cpx-ci.yaml"); err == nil {
+		for name := range profiles {
+			candidates = append(candidates, name)
+		}
+	}
+	// Complete the last element of a comma-separated list
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix, toComplete = toComplete[:i+1], toComplete[i+1:]
+	}
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, toComplete) {
+			matches = append(matches, prefix+c)
+		}
+	}
+	slices.Sort(matches)
+	return matches, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
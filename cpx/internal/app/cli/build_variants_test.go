@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVariants(t *testing.T) {
+	profiles := map[string]config.BuildProfile{
+		"lto": {Opt: "s", Args: []string{"-DCMAKE_INTERPROCEDURAL_OPTIMIZATION=ON"}},
+	}
+	variants, err := parseVariants([]string{"debug", "release", "asan", "release-ubsan-asan", "O3", "lto", "debug"}, profiles)
+	require.NoError(t, err)
+	var dirs []string
+	for _, v := range variants {
+		dirs = append(dirs, v.Dir())
+	}
+	assert.Equal(t, []string{"debug", "release", "debug-asan", "release-asan-ubsan", "O3"}, dirs[:5], "a repeated variant is built once")
+	assert.Equal(t, "lto", variants[5].Name)
+	assert.Equal(t, "Os", variants[5].Dir())
+	assert.Equal(t, []string{"-DCMAKE_INTERPROCEDURAL_OPTIMIZATION=ON"}, variants[5].Args)
+
+	_, err = parseVariants([]string{"release", "lto"}, map[string]config.BuildProfile{"lto": {Release: true}})
+	assert.ErrorContains(t, err, `variants "release" and "lto" both build into .cache/native/release`)
+	_, err = parseVariants([]string{"fast"}, nil)
+	assert.ErrorContains(t, err, `invalid variant "fast"`)
+	_, err = parseVariants([]string{"asan-tsan"}, nil)
+	assert.ErrorContains(t, err, "cannot be combined")
+	_, err = parseVariants([]string{" "}, nil)
+	assert.ErrorContains(t, err, "at least one variant")
+}
+
+func TestVariantsFromFlags(t *testing.T) {
+	chdirTemp(t)
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSlice("variants", nil, "")
+		cmd.Flags().Bool("release", false, "")
+		return cmd
+	}
+
+	cmd := newCmd()
+	variants, err := variantsFromFlags(cmd)
+	require.NoError(t, err)
+	assert.Nil(t, variants)
+
+	require.NoError(t, cmd.Flags().Set("variants", "debug,release"))
+	variants, err = variantsFromFlags(cmd)
+	require.NoError(t, err)
+	assert.Len(t, variants, 2)
+
+	cmd = newCmd()
+	require.NoError(t, cmd.Flags().Set("variants", "debug,release"))
+	require.NoError(t, cmd.Flags().Set("release", "true"))
+	_, err = variantsFromFlags(cmd)
+	assert.ErrorContains(t, err, "--variants cannot be combined with --release")
+}
+
+func TestPrintVariantSummary(t *testing.T) {
+	variants, err := parseVariants([]string{"debug", "release-asan"}, nil)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	printVariantSummary(&buf, []variantResult{
+		{Variant: variants[0]},
+		{Variant: variants[1], Err: errors.New("compile error")},
+	})
+	out := buf.String()
+	assert.Contains(t, out, "Variant")
+	assert.Regexp(t, `debug +.*pass.* +\S+ +\.bin/native/debug`, out)
+	assert.Regexp(t, `release-asan +.*fail.* +\S+ +\.bin/native/release-asan`, out)
+}
+
+func TestCompleteVariants(t *testing.T) {
+	chdirTemp(t)
+	matches, _ := completeVariants(nil, nil, "debug,re")
+	assert.Equal(t, []string{"debug,release", "debug,release-asan"}, matches)
+}
@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/cache"
+	"github.com/ozacod/cpx/internal/pkg/profile"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// CacheCmd creates the cache command
+func CacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Report and prune build caches",
+		Long: `Report how much disk space build caches use and prune them. Project caches
+are the build trees under .cache/native and .cache/ci (including the shared
+vcpkg_installed directory), build logs, packages, the Meson builddir and the
+Bazel output base. Global caches are the registries, templates and shared
+vcpkg_installed directories in the cpx cache directory, the vcpkg binary
+cache and vcpkg's downloads, buildtrees and packages directories.
+
+Without a subcommand, cpx cache prints the same report as cpx cache info.`,
+		Example: `  cpx cache                            # Report cache sizes
+  cpx cache clean native/debug         # Remove one build tree
+  cpx cache clean --global             # Remove all project and global caches
+  cpx cache gc --max-age 30d           # Remove caches unused for 30 days
+  cpx cache gc --max-size 20GB --global  # Keep the caches under 20 GB`,
+		Args: cobra.NoArgs,
+		RunE: runCacheInfo,
+	}
+
+	infoCmd := &cobra.Command{
+		Use:   "info",
+		Short: "Report the size of each cache",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheInfo,
+	}
+	cmd.AddCommand(infoCmd)
+
+	cleanCmd := &cobra.Command{
+		Use:   "clean [name...]",
+		Short: "Remove caches",
+		Long: `Remove the named caches, or all project caches when no name is given. Names
+are those printed by cpx cache info; a name also matches the caches below
+it, so "native" removes every build tree under .cache/native. Installed
+templates are never removed.`,
+		RunE: runCacheClean,
+	}
+	cleanCmd.Flags().Bool("global", false, "Also remove global caches")
+	cleanCmd.Flags().Bool("dry-run", false, "Show what would be removed")
+	cmd.AddCommand(cleanCmd)
+
+	gcCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Prune caches by age and total size",
+		Long: `Remove caches not used within --max-age, then the least recently used ones
+until the rest fit in --max-size. The vcpkg binary cache is pruned archive
+by archive; everything else is removed as a whole.`,
+		Args: cobra.NoArgs,
+		RunE: runCacheGC,
+	}
+	gcCmd.Flags().String("max-age", "30d", "Remove caches unused for longer than this (e.g. 30d, 2w, 12h; 0 to disable)")
+	gcCmd.Flags().String("max-size", "", "Remove least recently used caches until the total fits (e.g. 20GB)")
+	gcCmd.Flags().Bool("global", false, "Also prune global caches")
+	gcCmd.Flags().Bool("dry-run", false, "Show what would be removed")
+	cmd.AddCommand(gcCmd)
+
+	return cmd
+}
+
+func runCacheInfo(cmd *cobra.Command, _ []string) error {
+	entries, err := cacheEntries(true)
+	if err != nil {
+		return err
+	}
+	if jsonOutput(cmd) {
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No caches found")
+		return nil
+	}
+
+	now := time.Now()
+	var total int64
+	fmt.Printf("%-26s %-8s %10s %10s  %s\n", "NAME", "SCOPE", "SIZE", "LAST USED", "PATH")
+	for _, e := range entries {
+		total += e.Size
+		fmt.Printf("%-26s %-8s %10s %10s  %s%s%s\n", e.Name, e.Scope, profile.FormatBytes(e.Size),
+			formatCacheAge(now, e.LastUsed), colors.Gray, e.Path, colors.Reset)
+	}
+	fmt.Printf("%-26s %-8s %10s\n", "total", "", profile.FormatBytes(total))
+	return nil
+}
+
+func runCacheClean(cmd *cobra.Command, args []string) error {
+	global, _ := cmd.Flags().GetBool("global")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	entries, err := cacheEntries(global || len(args) > 0)
+	if err != nil {
+		return err
+	}
+
+	var selected []cache.Entry
+	for _, e := range entries {
+		if e.Keep || len(args) == 0 && e.Scope == cache.ScopeGlobal && !global {
+			continue
+		}
+		if len(args) > 0 && !slices.ContainsFunc(args, func(name string) bool { return cacheNameMatches(e.Name, name) }) {
+			continue
+		}
+		selected = append(selected, e)
+	}
+	if len(selected) == 0 {
+		if len(args) > 0 {
+			return fmt.Errorf("no cache named %s\n  hint: run 'cpx cache info' to list caches", strings.Join(args, ", "))
+		}
+		fmt.Println("Nothing to clean")
+		return nil
+	}
+
+	items := make([]cache.Item, 0, len(selected))
+	for _, e := range selected {
+		items = append(items, cache.Item{Path: e.Path, Size: e.Size, LastUsed: e.LastUsed})
+	}
+	return removeCacheItems(items, dryRun)
+}
+
+func runCacheGC(cmd *cobra.Command, _ []string) error {
+	maxAgeFlag, _ := cmd.Flags().GetString("max-age")
+	maxSizeFlag, _ := cmd.Flags().GetString("max-size")
+	global, _ := cmd.Flags().GetBool("global")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	var maxAge time.Duration
+	if maxAgeFlag != "" && maxAgeFlag != "0" {
+		var err error
+		if maxAge, err = cache.ParseAge(maxAgeFlag); err != nil {
+			return err
+		}
+	}
+	var maxSize int64
+	if maxSizeFlag != "" {
+		var err error
+		if maxSize, err = cache.ParseSize(maxSizeFlag); err != nil {
+			return err
+		}
+	}
+	if maxAge == 0 && maxSize == 0 {
+		return fmt.Errorf("nothing to do: set --max-age or --max-size")
+	}
+
+	entries, err := cacheEntries(global)
+	if err != nil {
+		return err
+	}
+	var items []cache.Item
+	for _, e := range entries {
+		switch {
+		case e.Keep:
+		case e.PerFile:
+			files, err := cache.Files(e.Path)
+			if err != nil {
+				return fmt.Errorf("failed to list %s: %w", e.Path, err)
+			}
+			items = append(items, files...)
+		default:
+			items = append(items, cache.Item{Path: e.Path, Size: e.Size, LastUsed: e.LastUsed})
+		}
+	}
+
+	remove := cache.GC(items, time.Now(), maxAge, maxSize)
+	if len(remove) == 0 {
+		output.Successf("Caches are within limits")
+		return nil
+	}
+	return removeCacheItems(remove, dryRun)
+}
+
+// removeCacheItems removes items, or only lists them with dryRun.
+func removeCacheItems(items []cache.Item, dryRun bool) error {
+	var freed int64
+	for _, item := range items {
+		if dryRun {
+			fmt.Printf("Would remove %s (%s)\n", item.Path, profile.FormatBytes(item.Size))
+		} else {
+			output.Stepf("Removing %s (%s)...", item.Path, profile.FormatBytes(item.Size))
+			if err := cache.Remove(item.Path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", item.Path, err)
+			}
+		}
+		freed += item.Size
+	}
+	if dryRun {
+		fmt.Printf("Would free %s\n", profile.FormatBytes(freed))
+	} else {
+		output.Successf("Freed %s", profile.FormatBytes(freed))
+	}
+	return nil
+}
+
+// cacheNameMatches reports whether the cache called name is selected by
+// pattern: the name itself or one of its parents ("native" selects
+// "native/debug").
+func cacheNameMatches(name, pattern string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	return name == pattern || strings.HasPrefix(name, pattern+"/")
+}
+
+// cacheEntries measures the project caches and, with global, the global
+// ones, project caches first and then by name.
+func cacheEntries(global bool) ([]cache.Entry, error) {
+	var entries []cache.Entry
+	if root, err := findProjectRoot(); err == nil {
+		entries = append(entries, projectCacheEntries(root)...)
+	}
+	if global {
+		globalEntries, err := globalCacheEntries()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, globalEntries...)
+	}
+
+	for i := range entries {
+		size, lastUsed, err := cache.Measure(entries[i].Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure %s: %w", entries[i].Path, err)
+		}
+		entries[i].Size, entries[i].LastUsed = size, lastUsed
+	}
+	slices.SortStableFunc(entries, func(a, b cache.Entry) int {
+		return cmp.Or(cmp.Compare(b.Scope, a.Scope), cmp.Compare(a.Name, b.Name))
+	})
+	return entries, nil
+}
+
+// projectCacheEntries lists the caches of the project at root.
+func projectCacheEntries(root string) []cache.Entry {
+	var entries []cache.Entry
+	add := func(name, path string) {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			entries = append(entries, cache.Entry{Name: name, Scope: cache.ScopeProject, Path: path})
+		}
+	}
+
+	cacheDir := config.DirsOf(root).Cache
+	for _, group := range []string{"native", "ci"} {
+		dirs, _ := os.ReadDir(filepath.Join(cacheDir, group))
+		for _, d := range dirs {
+			if d.IsDir() {
+				add(group+"/"+d.Name(), filepath.Join(cacheDir, group, d.Name()))
+			}
+		}
+	}
+	add("logs", filepath.Join(cacheDir, "logs"))
+	add("package", filepath.Join(cacheDir, "package"))
+	add("builddir", filepath.Join(root, "builddir"))
+	if outputBase := bazelOutputBase(root); outputBase != "" {
+		add("bazel", outputBase)
+	}
+	return entries
+}
+
+// bazelOutputBase finds the Bazel output base of the workspace at root
+// through the bazel-out convenience symlink, which points into
+// <output_base>/execroot.
+func bazelOutputBase(root string) string {
+	for _, link := range []string{".bazel-out", "bazel-out"} {
+		target, err := os.Readlink(filepath.Join(root, link))
+		if err != nil {
+			continue
+		}
+		target = filepath.ToSlash(target)
+		if i := strings.Index(target, "/execroot/"); i > 0 {
+			return filepath.FromSlash(target[:i])
+		}
+	}
+	return ""
+}
+
+// globalCacheEntries lists the caches shared by all projects.
+func globalCacheEntries() ([]cache.Entry, error) {
+	var entries []cache.Entry
+	add := func(entry cache.Entry) {
+		if info, err := os.Stat(entry.Path); err == nil && info.IsDir() {
+			entry.Scope = cache.ScopeGlobal
+			entries = append(entries, entry)
+		}
+	}
+
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	dirs, _ := os.ReadDir(cacheDir)
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		if d.Name() == "vcpkg_installed" {
+			// Shared installs, one per triplet and manifest, age separately
+			installs, _ := os.ReadDir(filepath.Join(cacheDir, d.Name()))
+			for _, install := range installs {
+				if install.IsDir() {
+					add(cache.Entry{Name: "vcpkg_installed/" + install.Name(), Path: filepath.Join(cacheDir, d.Name(), install.Name())})
+				}
+			}
+			continue
+		}
+		// Installed templates are configuration, not cache
+		add(cache.Entry{Name: d.Name(), Path: filepath.Join(cacheDir, d.Name()), Keep: d.Name() == "templates"})
+	}
+
+	if archives := vcpkgBinaryCacheDir(); archives != "" {
+		add(cache.Entry{Name: "vcpkg/archives", Path: archives, PerFile: true})
+	}
+	vcpkgRoot := os.Getenv("VCPKG_ROOT")
+	if cfg, err := projectConfig(); err == nil && cfg.VcpkgRoot != "" {
+		vcpkgRoot = cfg.VcpkgRoot
+	}
+	if vcpkgRoot != "" {
+		for _, name := range []string{"downloads", "buildtrees", "packages"} {
+			add(cache.Entry{Name: "vcpkg/" + name, Path: filepath.Join(vcpkgRoot, name)})
+		}
+	}
+	return entries, nil
+}
+
+// vcpkgBinaryCacheDir returns the default vcpkg binary cache location.
+func vcpkgBinaryCacheDir() string {
+	if dir := os.Getenv("VCPKG_DEFAULT_BINARY_CACHE"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, "vcpkg", "archives")
+		}
+		return ""
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "vcpkg", "archives")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "vcpkg", "archives")
+}
+
+// formatCacheAge formats how long ago t was, e.g. "3d ago".
+func formatCacheAge(now, t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Hour:
+		return "just now"
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
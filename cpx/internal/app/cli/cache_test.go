@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectCacheEntries(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".cache/native/debug", ".cache/native/vcpkg_installed", ".cache/ci/linux-amd64", ".cache/logs", "builddir"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	outputBase := filepath.Join(t.TempDir(), "_bazel_user", "abc123")
+	require.NoError(t, os.MkdirAll(filepath.Join(outputBase, "execroot", "_main", "bazel-out"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(outputBase, "execroot", "_main", "bazel-out"), filepath.Join(root, ".bazel-out")))
+
+	var names []string
+	for _, e := range projectCacheEntries(root) {
+		names = append(names, e.Name)
+		if e.Name == "bazel" {
+			assert.Equal(t, outputBase, e.Path)
+		}
+	}
+	assert.Equal(t, []string{"native/debug", "native/vcpkg_installed", "ci/linux-amd64", "logs", "builddir", "bazel"}, names)
+}
+
+func TestCacheNameMatches(t *testing.T) {
+	assert.True(t, cacheNameMatches("native/debug", "native/debug"))
+	assert.True(t, cacheNameMatches("native/debug", "native"))
+	assert.True(t, cacheNameMatches("native/debug", "native/"))
+	assert.False(t, cacheNameMatches("native/debug", "nat"))
+	assert.False(t, cacheNameMatches("ci/debug", "native"))
+}
@@ -1,19 +1,31 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/ozacod/cpx/internal/pkg/build/bazel"
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
 	"github.com/ozacod/cpx/internal/pkg/build/cmake"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
 	"github.com/ozacod/cpx/internal/pkg/build/meson"
+	"github.com/ozacod/cpx/internal/pkg/build/stats"
 	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/container"
+	"github.com/ozacod/cpx/internal/pkg/project"
+	"github.com/ozacod/cpx/internal/pkg/script"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/ozacod/cpx/pkg/config"
 )
 
@@ -24,6 +36,21 @@ type ToolchainBuildOptions struct {
 	RunTests          bool
 	RunBenchmarks     bool
 	Verbose           bool
+	Parallel          int // number of toolchains built concurrently (0/1 = serial)
+}
+
+// command names the cpx command the toolchain build runs for.
+func (o ToolchainBuildOptions) command() string {
+	switch {
+	case o.RunTests:
+		return "test"
+	case o.RunBenchmarks:
+		return "bench"
+	case o.ExecuteAfterBuild:
+		return "run"
+	default:
+		return "build"
+	}
 }
 
 func runToolchainBuild(options ToolchainBuildOptions) error {
@@ -31,22 +58,53 @@ func runToolchainBuild(options ToolchainBuildOptions) error {
 	if err != nil {
 		return fmt.Errorf("failed to load cpx-ci.yaml: %w\n  Create cpx-ci.yaml file or run 'cpx build' for local builds", err)
 	}
+	// catch mistakes now rather than after the first toolchains have built
+	if err := validateToolchainsFile("cpx-ci.yaml"); err != nil {
+		return err
+	}
+
+	if err := ciConfig.ExpandMatrix(); err != nil {
+		return fmt.Errorf("invalid toolchain matrix in cpx-ci.yaml: %w", err)
+	}
+	if err := applySanitizerConfig(ciConfig); err != nil {
+		return err
+	}
+	for i := range ciConfig.Toolchains {
+		tc := &ciConfig.Toolchains[i]
+		if tc.Sanitizer, err = build.ParseSanitizer(tc.Sanitizer); err != nil {
+			return fmt.Errorf("toolchain '%s': %w", tc.Name, err)
+		}
+	}
+
+	// Let cpx.star expand the toolchain matrix before selecting toolchains
+	projectScript, err := loadProjectScript()
+	if err != nil {
+		return err
+	}
+	if projectScript.HasHook(script.HookToolchainMatrix) {
+		ciConfig.Toolchains, err = projectScript.ToolchainMatrix(ciConfig.Toolchains)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(output.Stdout(), "%sToolchain matrix computed by %s%s\n", colors.Gray, script.FileName, colors.Reset)
+	}
 
 	// Get toolchains to run
 	toolchains := ciConfig.Toolchains
 	if options.ToolchainName != "" {
-		found := false
+		// A matrix toolchain's name selects all of its jobs
+		toolchains = nil
 		for _, t := range ciConfig.Toolchains {
-			if t.Name == options.ToolchainName {
-				toolchains = []config.Toolchain{t}
-				found = true
-				if !t.IsActive() {
-					fmt.Printf("%sWarning: Toolchain '%s' is marked as inactive%s\n", colors.Yellow, options.ToolchainName, colors.Reset)
-				}
-				break
+			if t.Name == options.ToolchainName || t.MatrixBase == options.ToolchainName {
+				toolchains = append(toolchains, t)
+			}
+		}
+		for _, t := range toolchains {
+			if !t.IsActive() {
+				output.Warnf("Toolchain '%s' is marked as inactive", t.Name)
 			}
 		}
-		if !found {
+		if len(toolchains) == 0 {
 			return fmt.Errorf("toolchain '%s' not found in cpx-ci.yaml", options.ToolchainName)
 		}
 	} else {
@@ -60,7 +118,7 @@ func runToolchainBuild(options ToolchainBuildOptions) error {
 			}
 		}
 		if skippedCount > 0 {
-			fmt.Printf("%sSkipping %d inactive toolchain(s)%s\n", colors.Yellow, skippedCount, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "%sSkipping %d inactive toolchain(s)%s\n", colors.Yellow, skippedCount, colors.Reset)
 		}
 		toolchains = activeToolchains
 	}
@@ -74,13 +132,17 @@ func runToolchainBuild(options ToolchainBuildOptions) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	fmt.Printf("%s Building %d toolchain(s)...%s\n", colors.Cyan, len(toolchains), colors.Reset)
+	output.Stepf(" Building %d toolchain(s)...", len(toolchains))
 
 	projectRoot, err := findProjectRoot()
 	if err != nil {
 		return fmt.Errorf("failed to get project root: %w", err)
 	}
 
+	if options.Parallel > 1 && len(toolchains) > 1 && !options.ExecuteAfterBuild {
+		return runToolchainsParallel(ciConfig, toolchains, projectRoot, options)
+	}
+
 	for i, tc := range toolchains {
 		// Resolve runner (contains compiler settings too)
 		runner := ciConfig.FindRunner(tc.Runner)
@@ -95,9 +157,9 @@ func runToolchainBuild(options ToolchainBuildOptions) error {
 		}
 
 		if options.ExecuteAfterBuild {
-			fmt.Printf("\n%s[%d/%d] Building and running: %s (%s)%s\n", colors.Cyan, i+1, len(toolchains), tc.Name, runnerType, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\n%s[%d/%d] Building and running: %s (%s)%s\n", colors.Cyan, i+1, len(toolchains), tc.Name, runnerType, colors.Reset)
 		} else {
-			fmt.Printf("\n%s[%d/%d] Building: %s (%s)%s\n", colors.Cyan, i+1, len(toolchains), tc.Name, runnerType, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\n%s[%d/%d] Building: %s (%s)%s\n", colors.Cyan, i+1, len(toolchains), tc.Name, runnerType, colors.Reset)
 		}
 
 		// Build environment with compiler settings from runner
@@ -114,75 +176,155 @@ func runToolchainBuild(options ToolchainBuildOptions) error {
 			}
 		}
 
+		// Let cpx.star add configure arguments for this toolchain
+		hookArgs, err := projectScript.BuildArgs(script.BuildContext{
+			BuildSystem: string(DetectProjectType()),
+			Release:     tc.BuildType == "" || tc.BuildType == "Release",
+			OptLevel:    tc.Optimization,
+			Sanitizer:   tc.Sanitizer,
+			Toolchain:   tc.Name,
+		})
+		if err != nil {
+			return err
+		}
+		tc.CMakeOptions = append(append([]string{}, tc.CMakeOptions...), hookArgs...)
+
+		// GPU architectures reach CMake through CUDAARCHS, which needs no
+		// quoting of its ';' separators in docker build scripts
+		mesonArgs := hookArgs
+		cudaArchs, err := build.ParseCUDAArchitectures(ciConfig.CUDAArchitectures(&tc))
+		if err != nil {
+			return fmt.Errorf("toolchain '%s': %w", tc.Name, err)
+		}
+		if len(cudaArchs) > 0 {
+			env["CUDAARCHS"] = build.CMakeCUDAArchitectures(cudaArchs)
+			mesonArgs = append(append([]string{}, hookArgs...), "-Dcuda_arch="+strings.Join(build.MesonCUDAArchitectures(cudaArchs), ","))
+		}
+
 		// Get CMake toolchain file if specified in runner
 		cmakeToolchainFile := ""
 		if runner != nil && runner.CMakeToolchainFile != "" {
 			cmakeToolchainFile = runner.CMakeToolchainFile
 		}
 
-		if runner == nil || runner.IsNative() {
-			if err := runNativeBuildNew(tc, runner, projectRoot, outputDir, options.RunTests, options.RunBenchmarks); err != nil {
-				return fmt.Errorf("failed to build '%s': %w", tc.Name, err)
-			}
-		} else if runner.IsDocker() {
-			imageName, err := resolveDockerImageNew(runner)
-			if err != nil {
-				return fmt.Errorf("failed to resolve Docker image for '%s': %w", tc.Name, err)
-			}
+		buildToolchain := func() error {
+			if tc.Type != "" {
+				if err := runPresetBuild(tc, runner, projectRoot, outputDir, env, hookArgs, options); err != nil {
+					return fmt.Errorf("failed to build '%s': %w", tc.Name, err)
+				}
+			} else if runner == nil || runner.IsNative() {
+				if err := setupCUDA(); err != nil {
+					return err
+				}
+				if err := runNativeBuildNew(tc, runner, projectRoot, outputDir, options.RunTests, options.RunBenchmarks); err != nil {
+					return fmt.Errorf("failed to build '%s': %w", tc.Name, err)
+				}
+			} else if runner.IsDocker() {
+				emulationEnv, err := prepareEmulation(runner, options.ExecuteAfterBuild || options.RunTests || options.RunBenchmarks)
+				if err != nil {
+					return fmt.Errorf("failed to set up emulation for '%s': %w", tc.Name, err)
+				}
+				for k, v := range emulationEnv {
+					env[k] = v
+				}
+				// Sanitizer options set in the toolchain's env take precedence
+				for k, v := range build.SanitizerOptions(tc.Sanitizer) {
+					if _, ok := env[k]; !ok {
+						env[k] = v
+					}
+				}
 
-			var dockerBuilder build.DockerBuilder
-			if _, err := os.Stat(filepath.Join(projectRoot, "MODULE.bazel")); err == nil {
-				dockerBuilder = bazel.New()
-			} else if _, err := os.Stat(filepath.Join(projectRoot, "meson.build")); err == nil {
-				dockerBuilder = meson.New()
-			} else {
-				dockerBuilder = vcpkg.New()
-			}
+				imageName, err := resolveDockerImageNew(runner, projectRoot, options.Rebuild, options.Verbose)
+				if err != nil {
+					return fmt.Errorf("failed to resolve Docker image for '%s': %w", tc.Name, err)
+				}
 
-			// Set defaults for optimization and jobs if not specified in toolchain
-			optLevel := tc.Optimization
-			if optLevel == "" {
-				optLevel = "2"
-			}
-			jobs := tc.Jobs
-
-			opts := build.DockerBuildOptions{
-				ImageName:         imageName,
-				ProjectRoot:       projectRoot,
-				OutputDir:         outputDir,
-				BuildType:         tc.BuildType,
-				Optimization:      optLevel,
-				CMakeArgs:         tc.CMakeOptions,
-				BuildArgs:         tc.BuildOptions,
-				Jobs:              jobs,
-				Env:               env,
-				ExecuteAfterBuild: options.ExecuteAfterBuild,
-				RunTests:          options.RunTests,
-				RunBenchmarks:     options.RunBenchmarks,
-				TargetName:        tc.Name,
-				Verbose:           options.Verbose,
-			}
+				// GPUs are only needed to run binaries, so images build
+				// without the NVIDIA Container Toolkit
+				gpus := ""
+				if options.ExecuteAfterBuild || options.RunTests || options.RunBenchmarks {
+					gpus = runner.GPUs
+				}
 
-			// Add toolchain file to CMake args if specified
-			if cmakeToolchainFile != "" {
-				opts.CMakeArgs = append(opts.CMakeArgs, "-DCMAKE_TOOLCHAIN_FILE="+cmakeToolchainFile)
-			}
+				var dockerBuilder build.DockerBuilder
+				switch project.Detect(projectRoot) {
+				case project.Bazel:
+					dockerBuilder = bazel.New()
+				case project.Meson:
+					dockerBuilder = meson.New()
+				default:
+					dockerBuilder = vcpkg.New()
+				}
 
-			if err := dockerBuilder.RunDockerBuild(context.Background(), opts); err != nil {
-				return fmt.Errorf("failed to build '%s': %w", tc.Name, err)
+				// Set defaults for optimization and jobs if not specified in toolchain
+				optLevel := tc.Optimization
+				if optLevel == "" {
+					optLevel = "2"
+				}
+				jobs := tc.Jobs
+
+				opts := build.DockerBuildOptions{
+					ImageName:         imageName,
+					Platform:          runner.Platform,
+					GPUs:              gpus,
+					ProjectRoot:       projectRoot,
+					OutputDir:         outputDir,
+					BuildType:         tc.BuildType,
+					Optimization:      optLevel,
+					Sanitizer:         tc.Sanitizer,
+					CMakeArgs:         tc.CMakeOptions,
+					MesonArgs:         mesonArgs,
+					BuildArgs:         tc.BuildOptions,
+					Jobs:              jobs,
+					Env:               env,
+					ExecuteAfterBuild: options.ExecuteAfterBuild,
+					RunTests:          options.RunTests,
+					RunBenchmarks:     options.RunBenchmarks,
+					TargetName:        tc.Name,
+					CacheVolumes:      runner.Cache == "volume",
+					Runtime:           containerRuntime(),
+					Verbose:           options.Verbose,
+				}
+
+				// Add toolchain file to CMake args if specified
+				if cmakeToolchainFile != "" {
+					opts.CMakeArgs = append(opts.CMakeArgs, "-DCMAKE_TOOLCHAIN_FILE="+cmakeToolchainFile)
+				}
+
+				if err := dockerBuilder.RunDockerBuild(context.Background(), opts); err != nil {
+					return fmt.Errorf("failed to build '%s': %w", tc.Name, err)
+				}
+			} else if runner.IsSSH() {
+				return fmt.Errorf("SSH runner not yet implemented for toolchain '%s'", tc.Name)
 			}
-		} else if runner.IsSSH() {
-			return fmt.Errorf("SSH runner not yet implemented for toolchain '%s'", tc.Name)
+			return nil
+		}
+
+		// Each toolchain gets its own log under .cache/logs
+		buildLog := buildlog.Start(projectRoot, "build", tc.Name)
+		start := time.Now()
+		err = buildToolchain()
+		buildLog.Close(err)
+		recordStats(projectRoot, stats.Record{
+			Time:       start,
+			Command:    options.command(),
+			Variant:    strings.ToLower(tc.BuildType),
+			Toolchain:  tc.Name,
+			DurationMS: time.Since(start).Milliseconds(),
+			Success:    err == nil,
+		}, buildLog.Path, filepath.Join(outputDir, tc.Name))
+		if err != nil {
+			return err
 		}
 
 		if !options.ExecuteAfterBuild {
-			fmt.Printf("%s Build '%s' succeeded%s\n", colors.Green, tc.Name, colors.Reset)
+			output.Successf(" Build '%s' succeeded", tc.Name)
 		}
 	}
 
 	if !options.ExecuteAfterBuild {
-		fmt.Printf("\n%s All builds completed successfully!%s\n", colors.Green, colors.Reset)
-		fmt.Printf("   Artifacts are in: %s\n", outputDir)
+		fmt.Fprintf(output.Stdout(), "\n%s All builds completed successfully!%s\n", colors.Green, colors.Reset)
+		fmt.Fprintf(output.Stdout(), "   Artifacts are in: %s\n", outputDir)
 	}
 	return nil
 }
@@ -192,40 +334,156 @@ func findProjectRoot() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return project.FindRoot(cwd), nil
+}
 
-	// Check for various project markers
-	markers := []string{"CMakeLists.txt", "vcpkg.json", "meson.build", "MODULE.bazel", ".git"}
-	dir := cwd
-	for {
-		for _, marker := range markers {
-			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
-				return dir, nil
-			}
-		}
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			break
-		}
-		dir = parent
+// dockerfileHashLabel is the image label recording which Dockerfile an image was built from
+const dockerfileHashLabel = "cpx.dockerfile.hash"
+
+// resolveDockerImageNew returns the Docker image for a runner. Runners with a
+// Dockerfile get their image built (or reused if up to date); the images of
+// other runners are pulled according to their pull policy, and refreshed
+// with rebuild.
+func resolveDockerImageNew(runner *config.Runner, projectRoot string, rebuild bool, verbose bool) (string, error) {
+	if runner.BuildsImage() {
+		return buildDockerImage(runner, projectRoot, rebuild, verbose)
 	}
-	return cwd, nil
-}
 
-// resolveDockerImageNew verifies the Docker image exists locally
-func resolveDockerImageNew(runner *config.Runner) (string, error) {
 	if runner.Image == "" {
 		return "", fmt.Errorf("Docker runner '%s' has no image specified", runner.Name)
 	}
 	imageName := runner.Image
 
-	// Check if image exists locally
-	cmd := exec.Command("docker", "images", "-q", imageName)
-	output, err := cmd.Output()
-	if err != nil || len(output) == 0 {
-		return "", fmt.Errorf("Docker image '%s' not found locally. Use 'docker pull %s' to download it first", imageName, imageName)
+	if err := pullRunnerImage(containerRuntime(), runner, imageName, rebuild); err != nil {
+		return "", err
 	}
 
-	fmt.Printf("  %s Using Docker image: %s%s\n", colors.Green, imageName, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "  %s Using Docker image: %s%s\n", colors.Green, imageName, colors.Reset)
+	return imageName, nil
+}
+
+// containerRuntime returns the container CLI that runs docker runners:
+// container_runtime of the global config, or the first one installed.
+func containerRuntime() container.Runtime {
+	return container.New(container.Detect(config.ContainerRuntime(), execLookPath), execCommand)
+}
+
+// dockerImageTag returns the tag for an image built from a runner's Dockerfile
+func dockerImageTag(runner *config.Runner) string {
+	if runner.Image != "" {
+		return runner.Image
+	}
+	name := strings.ToLower(runner.Name)
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, name)
+	return "cpx-" + name + ":latest"
+}
+
+// dockerfileHash hashes the Dockerfile, target platform and build args so
+// changes trigger a rebuild
+func dockerfileHash(dockerfile, platform string, buildArgs map[string]string) (string, error) {
+	data, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(data)
+	if platform != "" {
+		fmt.Fprintf(h, "\x00platform=%s", platform)
+	}
+	keys := make([]string, 0, len(buildArgs))
+	for k := range buildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, "\x00%s=%s", k, buildArgs[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// buildDockerImage builds a runner's image from its Dockerfile. The build is
+// skipped when an image built from the same Dockerfile already exists, and
+// Docker's layer cache is reused otherwise (unless rebuild is set).
+func buildDockerImage(runner *config.Runner, projectRoot string, rebuild bool, verbose bool) (string, error) {
+	imageName := dockerImageTag(runner)
+
+	dockerfile := runner.Dockerfile
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(projectRoot, dockerfile)
+	}
+	buildContext := runner.BuildContext
+	if buildContext == "" {
+		buildContext = projectRoot
+	} else if !filepath.IsAbs(buildContext) {
+		buildContext = filepath.Join(projectRoot, buildContext)
+	}
+
+	hash, err := dockerfileHash(dockerfile, runner.Platform, runner.BuildArgs)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Dockerfile for runner '%s': %w", runner.Name, err)
+	}
+
+	if !rebuild {
+		inspect := containerRuntime().Command("image", "inspect", "-f", "{{ index .Config.Labels \""+dockerfileHashLabel+"\" }}", imageName)
+		if out, err := inspect.Output(); err == nil && strings.TrimSpace(string(out)) == hash {
+			fmt.Fprintf(output.Stdout(), "  %s Using Docker image: %s (up to date)%s\n", colors.Green, imageName, colors.Reset)
+			return imageName, nil
+		}
+	}
+
+	// private base images need the runner's credentials
+	rt := containerRuntime()
+	if runner.Registry != nil {
+		if err := registryLogin(rt, runner, imageName); err != nil {
+			return "", err
+		}
+	}
+
+	fmt.Fprintf(output.Stdout(), "  %s Building Docker image %s from %s...%s\n", colors.Cyan, imageName, runner.Dockerfile, colors.Reset)
+
+	args := []string{"build", "-f", dockerfile, "-t", imageName, "--label", dockerfileHashLabel + "=" + hash}
+	if rebuild {
+		args = append(args, "--pull", "--no-cache")
+	} else {
+		// Reuse layers from the previous image even when the local build cache was pruned
+		args = append(args, "--cache-from", imageName)
+	}
+	if runner.Platform != "" {
+		args = append(args, "--platform", runner.Platform)
+	}
+	keys := make([]string, 0, len(runner.BuildArgs))
+	for k := range runner.BuildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--build-arg", k+"="+runner.BuildArgs[k])
+	}
+	args = append(args, buildContext)
+
+	cmd := rt.Command(args...)
+	var buf bytes.Buffer
+	if verbose {
+		cmd.Stdout = buildlog.Stdout()
+		cmd.Stderr = buildlog.Stderr()
+	} else {
+		out := io.MultiWriter(&buf, buildlog.Writer())
+		cmd.Stdout = out
+		cmd.Stderr = out
+	}
+	if err := cmd.Run(); err != nil {
+		if !verbose {
+			fmt.Print(buf.String())
+		}
+		return "", fmt.Errorf("docker build failed for runner '%s': %w", runner.Name, err)
+	}
+
+	fmt.Fprintf(output.Stdout(), "  %s Built Docker image: %s%s\n", colors.Green, imageName, colors.Reset)
 	return imageName, nil
 }
 
@@ -234,7 +492,7 @@ func runNativeBuildNew(tc config.Toolchain, runner *config.Runner, projectRoot,
 	projectType := DetectProjectType()
 	missing := WarnMissingBuildTools(projectType)
 	if len(missing) > 0 {
-		fmt.Printf("  %sNote: Native build may fail due to missing tools%s\n", colors.Yellow, colors.Reset)
+		fmt.Fprintf(output.Stdout(), "  %sNote: Native build may fail due to missing tools%s\n", colors.Yellow, colors.Reset)
 	}
 
 	targetOutputDir := filepath.Join(outputDir, tc.Name)
@@ -242,7 +500,7 @@ func runNativeBuildNew(tc config.Toolchain, runner *config.Runner, projectRoot,
 		return fmt.Errorf("failed to create target output directory: %w", err)
 	}
 
-	hostBuildDir := filepath.Join(projectRoot, ".cache", "ci", tc.Name)
+	hostBuildDir := filepath.Join(config.DirsOf(projectRoot).Cache, "ci", tc.Name)
 	if err := os.MkdirAll(hostBuildDir, 0755); err != nil {
 		return fmt.Errorf("failed to create build directory: %w", err)
 	}
@@ -275,7 +533,12 @@ func runNativeBuildNew(tc config.Toolchain, runner *config.Runner, projectRoot,
 		"-B", absBuildDir,
 		"-S", absProjectRoot,
 		"-DCMAKE_BUILD_TYPE=" + buildType,
-		"-DCMAKE_CXX_FLAGS=-O" + optLevel,
+	}
+
+	sanCFlags, sanLFlags := build.SanitizerFlags(tc.Sanitizer)
+	cmakeArgs = append(cmakeArgs, "-DCMAKE_CXX_FLAGS=-O"+optLevel+sanCFlags)
+	if sanLFlags != "" {
+		cmakeArgs = append(cmakeArgs, "-DCMAKE_EXE_LINKER_FLAGS="+sanLFlags, "-DCMAKE_SHARED_LINKER_FLAGS="+sanLFlags)
 	}
 
 	// Add toolchain file if specified in runner
@@ -307,16 +570,16 @@ func runNativeBuildNew(tc config.Toolchain, runner *config.Runner, projectRoot,
 		env = append(env, fmt.Sprintf("%s=%s", k, v))
 	}
 
-	fmt.Printf("  %s Configuring CMake (Ninja)...%s\n", colors.Yellow, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "  %s Configuring CMake (Ninja)...%s\n", colors.Yellow, colors.Reset)
 	cmd := exec.Command("cmake", cmakeArgs...)
 	cmd.Env = env
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = buildlog.Stdout()
+	cmd.Stderr = buildlog.Stderr()
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("cmake configure failed: %w", err)
 	}
 
-	fmt.Printf("  %s Building...%s\n", colors.Cyan, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "  %s Building...%s\n", colors.Cyan, colors.Reset)
 	buildArgs := []string{"--build", absBuildDir, "--config", buildType}
 	if tc.Jobs > 0 {
 		buildArgs = append(buildArgs, "--parallel", fmt.Sprintf("%d", tc.Jobs))
@@ -333,14 +596,14 @@ func runNativeBuildNew(tc config.Toolchain, runner *config.Runner, projectRoot,
 
 	cmd = exec.Command("cmake", buildArgs...)
 	cmd.Env = env
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = buildlog.Stdout()
+	cmd.Stderr = buildlog.Stderr()
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("cmake build failed: %w", err)
 	}
 
 	// Copy outputs
-	fmt.Printf("  %s Copying artifacts...%s\n", colors.Yellow, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "  %s Copying artifacts...%s\n", colors.Yellow, colors.Reset)
 
 	// Find executable
 	entries, err := os.ReadDir(absBuildDir)
@@ -361,7 +624,7 @@ func runNativeBuildNew(tc config.Toolchain, runner *config.Runner, projectRoot,
 			src := filepath.Join(absBuildDir, entry.Name())
 			dst := filepath.Join(absOutputDir, entry.Name())
 			if err := copyFile(src, dst); err != nil {
-				fmt.Printf("  %sWarning: failed to copy %s: %v%s\n", colors.Yellow, entry.Name(), err, colors.Reset)
+				output.Warnf("failed to copy %s: %v", entry.Name(), err)
 			}
 		}
 	}
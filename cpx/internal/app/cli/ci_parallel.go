@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// toolchainResult is the outcome of a single toolchain build.
+type toolchainResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// prefixColor returns the i-th of the colors cycled through to tell
+// interleaved toolchain output apart. It reads the colors when called, as
+// they are turned off after the package is initialized.
+func prefixColor(i int) string {
+	palette := []string{colors.Cyan, colors.Green, colors.Yellow, colors.Blue, colors.Magenta}
+	return palette[i%len(palette)]
+}
+
+// runToolchainsParallel builds toolchains concurrently, at most options.Parallel
+// at a time. Each toolchain runs in its own cpx process (so builders can keep
+// writing to stdout) and its output is multiplexed with a per-toolchain prefix.
+func runToolchainsParallel(ciConfig *config.ToolchainConfig, toolchains []config.Toolchain, projectRoot string, options ToolchainBuildOptions) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate cpx executable: %w", err)
+	}
+
+	// Resolve Docker images (and QEMU emulation) up front so concurrent
+	// builds sharing a runner don't race to set up the same thing
+	resolved := make(map[string]bool)
+	for _, tc := range toolchains {
+		runner := ciConfig.FindRunner(tc.Runner)
+		if runner == nil {
+			if tc.Runner != "" {
+				return fmt.Errorf("runner '%s' not found for toolchain '%s'", tc.Runner, tc.Name)
+			}
+			continue
+		}
+		if !runner.IsDocker() || resolved[runner.Name] {
+			continue
+		}
+		if _, err := prepareEmulation(runner, options.RunTests || options.RunBenchmarks); err != nil {
+			return fmt.Errorf("failed to set up emulation for '%s': %w", tc.Name, err)
+		}
+		if _, err := resolveDockerImageNew(runner, projectRoot, options.Rebuild, options.Verbose); err != nil {
+			return fmt.Errorf("failed to resolve Docker image for '%s': %w", tc.Name, err)
+		}
+		resolved[runner.Name] = true
+	}
+
+	width := 0
+	for _, tc := range toolchains {
+		width = max(width, len(tc.Name))
+	}
+
+	output.Stepf("Building %d toolchain(s), %d at a time...", len(toolchains), options.Parallel)
+
+	var outMu sync.Mutex
+	results := make([]toolchainResult, len(toolchains))
+	sem := make(chan struct{}, options.Parallel)
+	var wg sync.WaitGroup
+
+	for i, tc := range toolchains {
+		wg.Add(1)
+		go func(i int, tc config.Toolchain) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			color := prefixColor(i)
+			prefix := fmt.Sprintf("%s%-*s |%s ", color, width, tc.Name, colors.Reset)
+			out := newPrefixWriter(os.Stdout, prefix, &outMu)
+
+			args := []string{"build", "--toolchain", tc.Name}
+			if options.Verbose {
+				args = append(args, "--verbose")
+			}
+			cmd := execCommand(exe, args...)
+			cmd.Dir = projectRoot
+			cmd.Stdout = out
+			cmd.Stderr = out
+
+			start := time.Now()
+			err := cmd.Run()
+			out.Flush()
+			results[i] = toolchainResult{Name: tc.Name, Err: err, Duration: time.Since(start)}
+		}(i, tc)
+	}
+	wg.Wait()
+
+	fmt.Fprintln(output.Stdout())
+	printToolchainSummary(os.Stdout, results)
+
+	var failed []string
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d toolchain(s) failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+
+	fmt.Fprintf(output.Stdout(), "\n%s All builds completed successfully!%s\n", colors.Green, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "   Artifacts are in: %s\n", ciConfig.GetOutputDir())
+	return nil
+}
+
+// printToolchainSummary prints a pass/fail/time table for toolchain builds.
+func printToolchainSummary(w io.Writer, results []toolchainResult) {
+	width := len("Toolchain")
+	for _, r := range results {
+		width = max(width, len(r.Name))
+	}
+
+	fmt.Fprintf(w, "%s%-*s  %-6s  %s%s\n", colors.Bold, width, "Toolchain", "Status", "Time", colors.Reset)
+	for _, r := range results {
+		status := colors.Green + "✓ pass" + colors.Reset
+		if r.Err != nil {
+			status = colors.Red + "✗ fail" + colors.Reset
+		}
+		fmt.Fprintf(w, "%-*s  %s  %s\n", width, r.Name, status, r.Duration.Round(100*time.Millisecond))
+	}
+}
+
+// prefixWriter prefixes every complete line written to it and forwards it to
+// out while holding mu, so lines from concurrent writers never interleave.
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    bytes.Buffer
+}
+
+func newPrefixWriter(out io.Writer, prefix string, mu *sync.Mutex) *prefixWriter {
+	return &prefixWriter{out: out, prefix: prefix, mu: mu}
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf.Write(data)
+	for {
+		idx := bytes.IndexByte(p.buf.Bytes(), '\n')
+		if idx == -1 {
+			break
+		}
+		line := p.buf.Next(idx + 1)
+		// Progress output uses \r to redraw a line; keep only the final state
+		if i := bytes.LastIndexByte(line[:len(line)-1], '\r'); i != -1 {
+			line = line[i+1:]
+		}
+		p.writeLine(line)
+	}
+	return len(data), nil
+}
+
+// Flush writes any remaining partial line.
+func (p *prefixWriter) Flush() {
+	if p.buf.Len() > 0 {
+		p.writeLine(append(p.buf.Bytes(), '\n'))
+		p.buf.Reset()
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprint(p.out, p.prefix)
+	_, _ = p.out.Write(line)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	w := newPrefixWriter(&out, "[a] ", &mu)
+
+	_, _ = w.Write([]byte("first line\nsecond "))
+	_, _ = w.Write([]byte("line\n[1/2] step\r[2/2] step\ntrailing"))
+	assert.Equal(t, "[a] first line\n[a] second line\n[a] [2/2] step\n", out.String())
+
+	w.Flush()
+	assert.Equal(t, "[a] first line\n[a] second line\n[a] [2/2] step\n[a] trailing\n", out.String())
+}
+
+func TestPrefixColor(t *testing.T) {
+	assert.Equal(t, colors.Cyan, prefixColor(0))
+	assert.Equal(t, colors.Cyan, prefixColor(5), "colors are cycled")
+
+	saved := []*string{&colors.Reset, &colors.Red, &colors.Green, &colors.Yellow, &colors.Blue, &colors.Magenta, &colors.Cyan, &colors.Gray, &colors.Bold}
+	old := make([]string, len(saved))
+	for i, c := range saved {
+		old[i] = *c
+	}
+	t.Cleanup(func() {
+		for i, c := range saved {
+			*c = old[i]
+		}
+	})
+	colors.Disable()
+	assert.Empty(t, prefixColor(1), "NO_COLOR applies to prefixes too")
+}
+
+func TestPrintToolchainSummary(t *testing.T) {
+	var out bytes.Buffer
+	printToolchainSummary(&out, []toolchainResult{
+		{Name: "linux-release", Duration: 1500 * time.Millisecond},
+		{Name: "clang", Err: errors.New("exit status 1"), Duration: 2 * time.Second},
+	})
+	output := out.String()
+	assert.Contains(t, output, "linux-release")
+	assert.Contains(t, output, "✓ pass")
+	assert.Contains(t, output, "✗ fail")
+	assert.Contains(t, output, "1.5s")
+}
+
+func TestRunToolchainsParallel(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+
+	var mu sync.Mutex
+	var built []string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		toolchain := arg[len(arg)-1]
+		mu.Lock()
+		built = append(built, toolchain)
+		mu.Unlock()
+
+		helperCmd := "build"
+		if toolchain == "broken" {
+			helperCmd = "fail"
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", helperCmd)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		return cmd
+	}
+
+	ciConfig := &config.ToolchainConfig{}
+	toolchains := []config.Toolchain{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	err := runToolchainsParallel(ciConfig, toolchains, t.TempDir(), ToolchainBuildOptions{Parallel: 2})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, built)
+
+	toolchains = append(toolchains, config.Toolchain{Name: "broken"})
+	err = runToolchainsParallel(ciConfig, toolchains, t.TempDir(), ToolchainBuildOptions{Parallel: 4})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 4 toolchain(s) failed: broken")
+}
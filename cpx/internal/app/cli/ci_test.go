@@ -2,6 +2,7 @@ package cli
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -145,3 +146,90 @@ func TestRunnerTypes(t *testing.T) {
 	assert.False(t, sshRunner.IsNative())
 	assert.False(t, sshRunner.IsDocker())
 }
+
+func TestDockerImageTag(t *testing.T) {
+	assert.Equal(t, "my/image:1", dockerImageTag(&config.Runner{Name: "x", Image: "my/image:1"}))
+	assert.Equal(t, "cpx-gcc-13:latest", dockerImageTag(&config.Runner{Name: "GCC 13"}))
+}
+
+func TestDockerfileHash(t *testing.T) {
+	dockerfile := filepath.Join(t.TempDir(), "Dockerfile")
+	require.NoError(t, os.WriteFile(dockerfile, []byte("FROM ubuntu:22.04\n"), 0644))
+
+	h1, err := dockerfileHash(dockerfile, "", nil)
+	require.NoError(t, err)
+	h2, err := dockerfileHash(dockerfile, "", map[string]string{"GCC": "13"})
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h2)
+	h3, err := dockerfileHash(dockerfile, "linux/arm64", nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h3)
+
+	_, err = dockerfileHash(filepath.Join(t.TempDir(), "missing"), "", nil)
+	assert.Error(t, err)
+}
+
+// mockDockerCommands records docker invocations; inspectOutput is returned by "docker image inspect".
+func mockDockerCommands(t *testing.T, inspectOutput string) *[][]string {
+	t.Helper()
+	oldExecCommand := execCommand
+	t.Cleanup(func() { execCommand = oldExecCommand })
+
+	var calls [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		calls = append(calls, append([]string{name}, arg...))
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		if len(arg) > 1 && arg[0] == "image" && arg[1] == "inspect" {
+			cmd.Env = append(cmd.Env, "MOCK_OUTPUT="+inspectOutput)
+		}
+		return cmd
+	}
+	return &calls
+}
+
+func TestBuildDockerImage(t *testing.T) {
+	projectRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectRoot, "Dockerfile"), []byte("FROM gcc:13\n"), 0644))
+	runner := &config.Runner{
+		Name:       "gcc",
+		Type:       "docker",
+		Dockerfile: "Dockerfile",
+		BuildArgs:  map[string]string{"B": "2", "A": "1"},
+	}
+	require.True(t, runner.BuildsImage())
+
+	calls := mockDockerCommands(t, "")
+	image, err := resolveDockerImageNew(runner, projectRoot, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, "cpx-gcc:latest", image)
+
+	require.Len(t, *calls, 2)
+	build := (*calls)[1]
+	assert.Equal(t, []string{"docker", "build", "-f", filepath.Join(projectRoot, "Dockerfile"), "-t", "cpx-gcc:latest"}, build[:6])
+	assert.Contains(t, build, "--cache-from")
+	assert.Equal(t, []string{"--build-arg", "A=1", "--build-arg", "B=2", projectRoot}, build[len(build)-5:])
+}
+
+func TestBuildDockerImageUpToDate(t *testing.T) {
+	projectRoot := t.TempDir()
+	dockerfile := filepath.Join(projectRoot, "Dockerfile")
+	require.NoError(t, os.WriteFile(dockerfile, []byte("FROM gcc:13\n"), 0644))
+	runner := &config.Runner{Name: "gcc", Type: "docker", Dockerfile: "Dockerfile"}
+
+	hash, err := dockerfileHash(dockerfile, "", nil)
+	require.NoError(t, err)
+
+	calls := mockDockerCommands(t, hash)
+	_, err = resolveDockerImageNew(runner, projectRoot, false, false)
+	require.NoError(t, err)
+	assert.Len(t, *calls, 1, "up-to-date image should not be rebuilt")
+
+	// --rebuild always builds without cache
+	calls = mockDockerCommands(t, hash)
+	_, err = resolveDockerImageNew(runner, projectRoot, true, false)
+	require.NoError(t, err)
+	require.Len(t, *calls, 1)
+	assert.Contains(t, (*calls)[0], "--no-cache")
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func CICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Check the CI configuration",
+		Long:  "Check cpx-ci.yaml, the runners and toolchains of cpx build --toolchain and cpx build all.",
+	}
+
+	validateCmd := &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Validate cpx-ci.yaml",
+		Long: `Check cpx-ci.yaml (or file) against its schema and print each problem with
+its line and column: unknown or misspelled fields, values of the wrong type,
+invalid build types, optimization levels and sanitizers, docker runners
+without an image or Dockerfile, duplicate runner and toolchain names and
+toolchains using runners that do not exist.
+
+Toolchain builds run the same checks before starting.`,
+		Example: `  cpx ci validate
+  cpx ci validate ci/nightly.yaml`,
+		RunE: runCIValidate,
+		Args: cobra.MaximumNArgs(1),
+	}
+	cmd.AddCommand(validateCmd)
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON schema of cpx-ci.yaml",
+		Long: `Print the JSON schema of cpx-ci.yaml. Editors using yaml-language-server
+complete and check the file with a first line of:
+
+  # yaml-language-server: $schema=` + config.ToolchainsSchemaURL,
+		RunE: runCISchema,
+		Args: cobra.NoArgs,
+	}
+	cmd.AddCommand(schemaCmd)
+
+	return cmd
+}
+
+func runCIValidate(_ *cobra.Command, args []string) error {
+	path := "cpx-ci.yaml"
+	if len(args) > 0 {
+		path = args[0]
+	} else if root, err := findProjectRoot(); err == nil {
+		path = filepath.Join(root, path)
+	}
+	if err := validateToolchainsFile(path); err != nil {
+		return err
+	}
+	output.Successf("✓ %s is valid", path)
+	return nil
+}
+
+func runCISchema(_ *cobra.Command, _ []string) error {
+	schema, err := config.ToolchainsSchema()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(schema)
+	return err
+}
+
+// validateToolchainsFile checks the cpx-ci.yaml at path, printing each
+// problem as path:line:column, and fails when there is any that is not a
+// warning.
+func validateToolchainsFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	errs := 0
+	for _, e := range config.ValidateToolchains(data, filepath.Dir(path)) {
+		if e.Warning {
+			output.Warnf("%s:%s %s", path, validationLocation(e), e.Message)
+			continue
+		}
+		errs++
+		fmt.Fprintf(os.Stderr, "%s%s:%s%s %s\n", colors.Red, path, validationLocation(e), colors.Reset, e.Message)
+	}
+	if errs == 0 {
+		return nil
+	}
+	problems := "problem"
+	if errs > 1 {
+		problems += "s"
+	}
+	return fmt.Errorf("%s has %d %s\n  hint: the schema is printed by cpx ci schema", path, errs, problems)
+}
+
+// validationLocation formats the position of a problem, line:column: or
+// line: when the column is unknown.
+func validationLocation(e config.ValidationError) string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, "%d:", e.Column)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateToolchainsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cpx-ci.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte("toolchains:\n  - name: release\n    build_type: Release\n"), 0644))
+	assert.NoError(t, validateToolchainsFile(path))
+
+	require.NoError(t, os.WriteFile(path, []byte("toolchains:\n  - name: release\n    build_typ: Release\n    runner: box\n"), 0644))
+	err := validateToolchainsFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has 1 problem")
+
+	require.NoError(t, os.WriteFile(path, []byte("toolchains: []\nbuild:\n  type: Release\noutput: .bin/ci\n"), 0644))
+	assert.NoError(t, validateToolchainsFile(path), "deprecated fields are warnings")
+
+	assert.Error(t, validateToolchainsFile(filepath.Join(dir, "missing.yaml")))
+}
+
+func TestValidationLocation(t *testing.T) {
+	assert.Equal(t, "3:5:", validationLocation(config.ValidationError{Line: 3, Column: 5}))
+	assert.Equal(t, "3:", validationLocation(config.ValidationError{Line: 3}))
+	assert.Equal(t, "", validationLocation(config.ValidationError{}))
+}
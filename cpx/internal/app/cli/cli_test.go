@@ -40,6 +40,11 @@ func TestDetectProjectType(t *testing.T) {
 			files:    map[string]string{"MODULE.bazel": "# bazel", "vcpkg.json": "{}"},
 			expected: ProjectTypeVcpkg,
 		},
+		{
+			name:     "cpx.yaml build_system takes priority",
+			files:    map[string]string{"vcpkg.json": "{}", "meson.build": "", "cpx.yaml": "build_system: meson\n"},
+			expected: ProjectTypeMeson,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// withCommandHooks runs fn between the pre_<command> and post_<command>
+// hooks of cpx-ci.yaml. Post hooks are skipped when fn fails.
+func withCommandHooks(command string, fn func() error) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	ciConfig, err := config.LoadToolchains(filepath.Join(projectRoot, "cpx-ci.yaml"))
+	if os.IsNotExist(err) {
+		return fn()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := runCommandHooks(ciConfig.Hooks, "pre_"+command, projectRoot); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return runCommandHooks(ciConfig.Hooks, "post_"+command, projectRoot)
+}
+
+// runCommandHooks runs the commands of a hook in projectRoot, stopping at
+// the first that fails. They get CPX_HOOK and CPX_PROJECT_DIR in their
+// environment.
+func runCommandHooks(hooks *config.CommandHooks, name, projectRoot string) error {
+	env := append(os.Environ(), "CPX_HOOK="+name, "CPX_PROJECT_DIR="+projectRoot)
+	for _, hook := range hooks.Commands(name) {
+		output.Stepf("Running %s hook: %s", name, hook)
+		cmd := shellCommand(hook)
+		cmd.Dir = projectRoot
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s hook %q failed: %w", name, hook, err)
+		}
+	}
+	return nil
+}
+
+// shellCommand returns the command that runs line with the system shell.
+func shellCommand(line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return execCommand("cmd", "/C", line)
+	}
+	return execCommand("sh", "-c", line)
+}
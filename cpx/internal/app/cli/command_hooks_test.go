@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCommandHooks(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(test)\n"), 0644))
+
+	// Without cpx-ci.yaml the command just runs
+	ran := false
+	require.NoError(t, withCommandHooks("build", func() error { ran = true; return nil }))
+	assert.True(t, ran)
+
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`hooks:
+  pre_build:
+    - echo "pre $CPX_HOOK" >> hooks.log
+  post_build:
+    - echo post >> hooks.log
+  pre_test:
+    - exit 3
+`), 0644))
+
+	require.NoError(t, withCommandHooks("build", func() error {
+		return os.WriteFile("build.log", []byte("built\n"), 0644)
+	}))
+	log, err := os.ReadFile("hooks.log")
+	require.NoError(t, err)
+	assert.Equal(t, "pre pre_build\npost\n", string(log))
+
+	// Post hooks only run after success
+	require.NoError(t, os.Remove("hooks.log"))
+	err = withCommandHooks("build", func() error { return errors.New("build failed") })
+	assert.EqualError(t, err, "build failed")
+	log, err = os.ReadFile("hooks.log")
+	require.NoError(t, err)
+	assert.Equal(t, "pre pre_build\n", string(log))
+
+	// A failing pre hook stops the command
+	ran = false
+	err = withCommandHooks("test", func() error { ran = true; return nil })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `pre_test hook "exit 3" failed`)
+	assert.False(t, ran)
+}
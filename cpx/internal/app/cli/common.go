@@ -1,14 +1,17 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 
+	"github.com/ozacod/cpx/internal/pkg/project"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
-	"github.com/ozacod/cpx/pkg/config"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/spf13/cobra"
 )
 
 // Variables for mocking in tests
@@ -17,16 +20,29 @@ var (
 	execLookPath = exec.LookPath
 )
 
-// Version is the cpx version
-const Version = "1.2.0"
+// Version is the cpx version. Release and nightly builds set it with
+// -ldflags "-X github.com/ozacod/cpx/internal/app/cli.Version=...".
+var Version = "1.2.0"
 
 // DefaultServer is the default server URL
 const DefaultServer = "https://cpx-dev.vercel.app"
 
+// jsonOutput reports whether the global --json flag is set.
+func jsonOutput(cmd *cobra.Command) bool {
+	enabled, _ := cmd.Flags().GetBool("json")
+	return enabled
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
 // PrintError prints an error message
 func PrintError(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintf(os.Stderr, "%s✗ %s%s\n", colors.Red, msg, colors.Reset)
+	output.Errorf(format, args...)
 }
 
 // requireVcpkgProject ensures the current directory has a vcpkg.json manifest.
@@ -41,31 +57,26 @@ func requireVcpkgProject(cmdName string) error {
 }
 
 // ProjectType represents the type of C++ project
-type ProjectType string
+type ProjectType = project.BuildSystem
 
 const (
-	ProjectTypeVcpkg   ProjectType = "vcpkg"
-	ProjectTypeBazel   ProjectType = "bazel"
-	ProjectTypeMeson   ProjectType = "meson"
-	ProjectTypeUnknown ProjectType = "unknown"
+	ProjectTypeVcpkg   = project.Vcpkg
+	ProjectTypeBazel   = project.Bazel
+	ProjectTypeMeson   = project.Meson
+	ProjectTypeUnknown = project.Unknown
 )
 
-// DetectProjectType determines if current directory is vcpkg, bazel, meson, or unknown
+// DetectProjectType determines if current directory is vcpkg, bazel, meson,
+// or unknown, honoring --build-system and the build_system of cpx.yaml
 func DetectProjectType() ProjectType {
-	if _, err := os.Stat("vcpkg.json"); err == nil {
-		return ProjectTypeVcpkg
-	}
-	if _, err := os.Stat("MODULE.bazel"); err == nil {
-		return ProjectTypeBazel
-	}
-	if _, err := os.Stat("meson.build"); err == nil {
-		return ProjectTypeMeson
-	}
-	return ProjectTypeUnknown
+	return project.Detect(".")
 }
 
 // RequireProject ensures the current directory is a cpx project (vcpkg, bazel, or meson)
 func RequireProject(cmdName string) (ProjectType, error) {
+	if err := project.Check("."); err != nil {
+		return ProjectTypeUnknown, err
+	}
 	pt := DetectProjectType()
 	if pt == ProjectTypeUnknown {
 		return pt, fmt.Errorf("%s requires a cpx project (vcpkg.json, MODULE.bazel, or meson.build not found)\n  hint: create one with cpx new", cmdName)
@@ -82,13 +93,13 @@ type Spinner struct {
 
 // Tick advances the spinner and prints the current frame
 func (s *Spinner) Tick() {
-	fmt.Printf("\r%s%s%s %s", colors.Cyan, s.frames[s.current], colors.Reset, s.message)
+	fmt.Fprintf(output.Stdout(), "\r%s%s%s %s", colors.Cyan, s.frames[s.current], colors.Reset, s.message)
 	s.current = (s.current + 1) % len(s.frames)
 }
 
 // Done finishes the spinner with a success message
 func (s *Spinner) Done(message string) {
-	fmt.Printf("\r%s✓ %s%s\n", colors.Green, message, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "\r%s✓ %s%s\n", colors.Green, message, colors.Reset)
 }
 
 // Fail finishes the spinner with an error message
@@ -119,7 +130,7 @@ func CheckBuildToolsForProject(projectType ProjectType) []string {
 		// Check for vcpkg: 1) cpx config, 2) VCPKG_ROOT env, 3) PATH
 		vcpkgFound := false
 		// First check cpx config
-		if cfg, err := config.LoadGlobal(); err == nil && cfg.VcpkgRoot != "" {
+		if cfg, err := projectConfig(); err == nil && cfg.VcpkgRoot != "" {
 			// Verify vcpkg executable exists at config path
 			vcpkgPath := filepath.Join(cfg.VcpkgRoot, "vcpkg")
 			if runtime.GOOS == "windows" {
@@ -144,7 +155,7 @@ func CheckBuildToolsForProject(projectType ProjectType) []string {
 			vcpkgFound = true
 		}
 		if !vcpkgFound {
-			missing = append(missing, "vcpkg (run 'cpx config set-vcpkg-root <path>' or set VCPKG_ROOT)")
+			missing = append(missing, "vcpkg (run 'cpx setup vcpkg', 'cpx config set-vcpkg-root <path>' or set VCPKG_ROOT)")
 		}
 		if !CheckCommandExists("cmake") {
 			missing = append(missing, "cmake")
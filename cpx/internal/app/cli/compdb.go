@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/quality"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/spf13/cobra"
+)
+
+// CompdbCmd creates the compdb command
+func CompdbCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compdb",
+		Short: "Generate compile_commands.json for clangd and clang-tidy",
+		Long: `Generate or refresh compile_commands.json with the project's build system
+and link it into the project root, where clangd and other tools find it:
+
+   CMake/vcpkg  - configured with CMAKE_EXPORT_COMPILE_COMMANDS in .cache/native/debug
+   Meson        - written by meson setup in builddir
+   Bazel        - written to the root by the hedron compile commands extractor,
+                  which MODULE.bazel must declare
+
+Where links cannot be created (Windows without developer mode) the file is
+copied instead; run cpx compdb again after changing the build.`,
+		Example: `  cpx compdb
+  cpx compdb --no-link   # Only regenerate the database in the build directory`,
+		Args: cobra.NoArgs,
+		RunE: runCompdb,
+	}
+
+	cmd.Flags().Bool("no-link", false, "Do not link compile_commands.json into the project root")
+
+	return cmd
+}
+
+func runCompdb(cmd *cobra.Command, _ []string) error {
+	if _, err := RequireProject("cpx compdb"); err != nil {
+		return err
+	}
+	noLink, _ := cmd.Flags().GetBool("no-link")
+
+	compileDb, err := quality.GenerateCompileDatabase(true, vcpkg.New())
+	if err != nil {
+		return err
+	}
+	if noLink {
+		output.Successf("✓ Generated %s", compileDb)
+		return nil
+	}
+	if err := quality.LinkCompileDatabase(compileDb); err != nil {
+		return fmt.Errorf("failed to link compile_commands.json: %w", err)
+	}
+	output.Successf("✓ compile_commands.json -> %s", compileDb)
+	return nil
+}
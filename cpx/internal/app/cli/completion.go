@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// Shell completion for values that depend on the project: build targets,
+// declared dependencies, and toolchains and runners from cpx-ci.yaml.
+// Errors yield no suggestions rather than failing the shell.
+
+// completeTargets completes build targets from the project's build system.
+func completeTargets(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	builder, err := projectBuilder()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	targets, err := builder.ListTargets(context.Background())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(targets, toComplete, nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDependencies completes the project's declared dependencies,
+// skipping ones already given as arguments.
+func completeDependencies(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	builder, err := projectBuilder()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	deps, err := builder.ListDependencies(context.Background())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, dep := range deps {
+		names = append(names, dep.Name)
+	}
+	return filterCompletions(names, toComplete, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeToolchains completes toolchain names from cpx-ci.yaml.
+func completeToolchains(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, tc := range ciConfig.Toolchains {
+		names = append(names, tc.Name)
+	}
+	return filterCompletions(names, toComplete, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRunners completes runner names from cpx-ci.yaml.
+func completeRunners(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, r := range ciConfig.Runners {
+		names = append(names, r.Name)
+	}
+	return filterCompletions(names, toComplete, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// filterCompletions returns the candidates starting with prefix, leaving
+// out any in exclude.
+func filterCompletions(candidates []string, prefix string, exclude []string) []string {
+	skip := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		skip[e] = true
+	}
+	var matches []string
+	for _, c := range candidates {
+		if !skip[c] && strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
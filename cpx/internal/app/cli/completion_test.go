@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chdirTemp switches to a new temporary directory for the test.
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+	require.NoError(t, os.Chdir(t.TempDir()))
+}
+
+// complete runs cobra's hidden __complete command and returns the
+// suggestions, without the trailing directive line.
+func complete(t *testing.T, sub *cobra.Command, args ...string) []string {
+	t.Helper()
+	root := &cobra.Command{Use: "cpx"}
+	root.AddCommand(sub)
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+	require.NoError(t, root.Execute())
+
+	var suggestions []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line != "" && !strings.HasPrefix(line, ":") {
+			suggestions = append(suggestions, line)
+		}
+	}
+	return suggestions
+}
+
+const completionCIYAML = `runners:
+  - name: ubuntu
+    type: docker
+    image: ubuntu:24.04
+  - name: alpine
+    type: docker
+    image: alpine:3
+toolchains:
+  - name: linux-gcc
+    runner: ubuntu
+  - name: linux-clang
+    runner: ubuntu
+  - name: alpine-musl
+    runner: alpine
+`
+
+func TestCompleteToolchains(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(completionCIYAML), 0644))
+
+	assert.Equal(t, []string{"linux-gcc", "linux-clang"}, complete(t, BuildCmd(), "build", "--toolchain", "linux"))
+	assert.Equal(t, []string{"linux-gcc", "linux-clang", "alpine-musl"}, complete(t, TestCmd(), "test", "--toolchain", ""))
+	// names already given are not suggested again
+	assert.Equal(t, []string{"linux-clang", "alpine-musl"}, complete(t, RmToolchainCmd(), "rm-toolchain", "linux-gcc", ""))
+	assert.Equal(t, []string{"alpine"}, complete(t, RmRunnerCmd(), "rm-runner", "a"))
+}
+
+func TestCompleteToolchainsWithoutConfig(t *testing.T) {
+	chdirTemp(t)
+	assert.Empty(t, complete(t, RunCmd(), "run", "--toolchain", ""))
+}
+
+func TestCompleteDependencies(t *testing.T) {
+	chdirTemp(t)
+	manifest := `{"name": "app", "dependencies": ["fmt", "spdlog", {"name": "gtest"}]}`
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(manifest), 0644))
+
+	assert.Equal(t, []string{"fmt", "spdlog", "gtest"}, complete(t, RemoveCmd(), "remove", ""))
+	assert.Equal(t, []string{"spdlog"}, complete(t, RemoveCmd(), "remove", "fmt", "s"))
+}
+
+func TestFilterCompletions(t *testing.T) {
+	assert.Equal(t, []string{"app", "app_test"}, filterCompletions([]string{"app", "lib", "app_test"}, "app", nil))
+	assert.Equal(t, []string{"lib"}, filterCompletions([]string{"app", "lib"}, "", []string{"app"}))
+	assert.Nil(t, filterCompletions(nil, "", nil))
+}
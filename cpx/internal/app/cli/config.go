@@ -1,12 +1,17 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 
+	"github.com/ozacod/cpx/internal/app/cli/tui"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -20,14 +25,41 @@ func ConfigCmd() *cobra.Command {
 	}
 
 	getCmd := &cobra.Command{
-		Use:   "get",
-		Short: "Get config value",
-		Long:  "Get a configuration value by key.",
-		RunE:  runConfigGet,
-		Args:  cobra.ExactArgs(1),
+		Use:               "get",
+		Short:             "Get config value",
+		Long:              "Get a configuration value by key.",
+		RunE:              runConfigGet,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
 	}
 	cmd.AddCommand(getCmd)
 
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set config value",
+		Long: `Set a configuration value by key. Values are validated: paths must exist,
+commands must be on PATH and enum keys take one of their values. An empty
+value unsets the key.`,
+		Example: `  cpx config set jobs 8
+  cpx config set upgrade_channel nightly
+  cpx config set compiler_launcher ""`,
+		RunE:              runConfigSet,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeConfigKeys,
+	}
+	cmd.AddCommand(setCmd)
+
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the configuration interactively",
+		Long: `Open an editor listing every configuration key with its description and
+value. Values are validated as they are entered; s saves them. A config
+file with unknown keys can be edited and is saved without them.`,
+		RunE: runConfigEdit,
+		Args: cobra.NoArgs,
+	}
+	cmd.AddCommand(editCmd)
+
 	setVcpkgRootCmd := &cobra.Command{
 		Use:   "set-vcpkg-root",
 		Short: "Set vcpkg root directory",
@@ -55,6 +87,102 @@ func ConfigCmd() *cobra.Command {
 	}
 	cmd.AddCommand(setWrapdbRootCmd)
 
+	setCMakeGeneratorCmd := &cobra.Command{
+		Use:   "set-cmake-generator",
+		Short: "Set CMake generator for native builds",
+		Long: `Set the CMake generator used for native builds of vcpkg projects.
+
+  auto      Detect (default). On Windows, uses MSVC with Ninja from a Developer
+            Command Prompt, otherwise the Visual Studio generator if installed
+  ninja     Ninja with the compiler found by CMake
+  vs        Visual Studio (Windows)
+  clang-cl  Ninja with clang-cl (Windows)
+
+Any other value is passed to CMake as a generator name, e.g. "Ninja Multi-Config".`,
+		RunE: runConfigSetCMakeGenerator,
+		Args: cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(setCMakeGeneratorCmd)
+
+	setSharedVcpkgInstalledCmd := &cobra.Command{
+		Use:   "set-shared-vcpkg-installed <on|off>",
+		Short: "Share vcpkg dependencies between projects",
+		Long: `Install the dependencies of vcpkg projects in a global directory keyed by
+triplet and manifest hash, <cache dir>/vcpkg_installed/<triplet>-<hash>,
+instead of each project's .cache/native/vcpkg_installed. Projects with the
+same dependencies then build them once.
+
+A project keeps its own directory with "vcpkg_installed: local" in its
+cpx-ci.yaml, or opts in alone with "vcpkg_installed: shared".`,
+		RunE:      runConfigSetSharedVcpkgInstalled,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"on", "off"},
+	}
+	cmd.AddCommand(setSharedVcpkgInstalledCmd)
+
+	setNotifyAfterCmd := &cobra.Command{
+		Use:   "set-notify-after <seconds|off>",
+		Short: "Notify when long builds finish",
+		Long: `Send a desktop notification when a build, test run or toolchain build
+that took at least this many seconds finishes, with its result and duration.
+Notifications use osascript on macOS, notify-send on Linux and a PowerShell
+toast on Windows. "off" (or 0) turns them off.`,
+		Example: `  cpx config set-notify-after 60   # Notify for anything over a minute
+  cpx config set-notify-after off`,
+		RunE: runConfigSetNotifyAfter,
+		Args: cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(setNotifyAfterCmd)
+
+	setCacheDirCmd := &cobra.Command{
+		Use:   "set-cache-dir <dir|default>",
+		Short: "Move the build cache of every project",
+		Long: `Put the cache directory of every project (build trees, logs and reports)
+somewhere other than .cache in the project root, e.g. on a scratch disk.
+An absolute directory holds one subdirectory per project; a relative one
+is relative to each project root. A project's cpx.yaml cache_dir takes
+precedence. "default" goes back to .cache.`,
+		Example: `  cpx config set-cache-dir /scratch/cpx
+  cpx config set-cache-dir default`,
+		RunE: runConfigSetCacheDir,
+		Args: cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(setCacheDirCmd)
+
+	setBinDirCmd := &cobra.Command{
+		Use:   "set-bin-dir <dir|default>",
+		Short: "Move the build artifacts of every project",
+		Long: `Put the artifact directory of every project somewhere other than .bin in
+the project root. An absolute directory holds one subdirectory per project;
+a relative one is relative to each project root. A project's cpx.yaml
+bin_dir takes precedence. "default" goes back to .bin.`,
+		RunE: runConfigSetBinDir,
+		Args: cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(setBinDirCmd)
+
+	setJobsCmd := &cobra.Command{
+		Use:   "set-jobs <n|auto>",
+		Short: "Set the default number of parallel build jobs",
+		Long: `Set how many parallel jobs cpx build uses when -j is not given. "auto"
+leaves it to the build tool.`,
+		RunE: runConfigSetJobs,
+		Args: cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(setJobsCmd)
+
+	setCompilerLauncherCmd := &cobra.Command{
+		Use:   "set-compiler-launcher <command|none>",
+		Short: "Wrap compiler invocations, e.g. with ccache",
+		Long: `Set a command that CMake builds run the compiler through, such as ccache
+or sccache (CMAKE_C_COMPILER_LAUNCHER and CMAKE_CXX_COMPILER_LAUNCHER).
+"none" removes it.`,
+		Example: `  cpx config set-compiler-launcher ccache`,
+		RunE:    runConfigSetCompilerLauncher,
+		Args:    cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(setCompilerLauncherCmd)
+
 	return cmd
 }
 
@@ -66,6 +194,26 @@ func runConfigGet(_ *cobra.Command, args []string) error {
 	return getConfig(args[0])
 }
 
+func runConfigSet(_ *cobra.Command, args []string) error {
+	return setConfig(args[0], args[1])
+}
+
+func runConfigEdit(_ *cobra.Command, _ []string) error {
+	return editConfig()
+}
+
+// completeConfigKeys completes the key argument of config get and set.
+func completeConfigKeys(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, k := range config.ConfigKeys {
+		keys = append(keys, k.Name+"\t"+k.Description)
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
 func runConfigSetVcpkgRoot(_ *cobra.Command, args []string) error {
 	return setVcpkgRoot(args[0])
 }
@@ -78,6 +226,44 @@ func runConfigSetWrapdbRoot(_ *cobra.Command, args []string) error {
 	return setWrapdbRoot(args[0])
 }
 
+func runConfigSetCMakeGenerator(_ *cobra.Command, args []string) error {
+	return setCMakeGenerator(args[0])
+}
+
+func runConfigSetSharedVcpkgInstalled(_ *cobra.Command, args []string) error {
+	return setSharedVcpkgInstalled(args[0])
+}
+
+func runConfigSetNotifyAfter(_ *cobra.Command, args []string) error {
+	return setNotifyAfter(args[0])
+}
+
+func runConfigSetCacheDir(_ *cobra.Command, args []string) error {
+	return setProjectDir("cache_dir", args[0])
+}
+
+func runConfigSetBinDir(_ *cobra.Command, args []string) error {
+	return setProjectDir("bin_dir", args[0])
+}
+
+func runConfigSetJobs(_ *cobra.Command, args []string) error {
+	return setJobs(args[0])
+}
+
+func runConfigSetCompilerLauncher(_ *cobra.Command, args []string) error {
+	return setCompilerLauncher(args[0])
+}
+
+// projectConfig returns the global config with the overrides of the
+// current project's cpx.yaml applied.
+func projectConfig() (*config.GlobalConfig, error) {
+	root, err := findProjectRoot()
+	if err != nil {
+		root = "."
+	}
+	return config.LoadGlobalFor(root)
+}
+
 func showConfig() error {
 	configPath, err := config.GetConfigPath()
 	if err != nil {
@@ -88,7 +274,7 @@ func showConfig() error {
 	if err != nil {
 		fmt.Printf("%sCpx Configuration%s\n", colors.Bold, colors.Reset)
 		fmt.Printf("  Config file: %s\n", configPath)
-		fmt.Printf("  %sError: %s%s\n", colors.Red, err, colors.Reset)
+		output.Errorf("%s", err)
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
@@ -97,28 +283,182 @@ func showConfig() error {
 	fmt.Printf("  vcpkg_root:  %s\n", cfg.VcpkgRoot)
 	fmt.Printf("  bcr_root:    %s\n", cfg.BcrRoot)
 	fmt.Printf("  wrapdb_root: %s\n", cfg.WrapdbRoot)
+	if cfg.CMakeGenerator != "" {
+		fmt.Printf("  cmake_generator: %s\n", cfg.CMakeGenerator)
+	}
+	if cfg.SharedVcpkgInstalled {
+		fmt.Printf("  shared_vcpkg_installed: true\n")
+	}
+	if cfg.NotifyAfter > 0 {
+		fmt.Printf("  notify_after: %ds\n", cfg.NotifyAfter)
+	}
+	if cfg.CacheDir != "" {
+		fmt.Printf("  cache_dir: %s\n", cfg.CacheDir)
+	}
+	if cfg.BinDir != "" {
+		fmt.Printf("  bin_dir: %s\n", cfg.BinDir)
+	}
+	if cfg.Jobs > 0 {
+		fmt.Printf("  jobs: %d\n", cfg.Jobs)
+	}
+	if cfg.CompilerLauncher != "" {
+		fmt.Printf("  compiler_launcher: %s\n", cfg.CompilerLauncher)
+	}
+	if cfg.DockerRegistry != "" {
+		fmt.Printf("  docker_registry: %s\n", cfg.DockerRegistry)
+	}
+	if cfg.ContainerRuntime != "" {
+		fmt.Printf("  container_runtime: %s\n", cfg.ContainerRuntime)
+	}
+	showConfigOverrides()
 	return nil
 }
 
+// showConfigOverrides prints the global keys the current project's cpx.yaml
+// overrides.
+func showConfigOverrides() {
+	root, err := findProjectRoot()
+	if err != nil {
+		return
+	}
+	manifest := config.ProjectManifest(root)
+	if manifest == nil || manifest.Config == nil {
+		return
+	}
+	o := manifest.Config
+	fmt.Printf("\n%sProject overrides%s (%s)\n", colors.Bold, colors.Reset, filepath.Join(root, config.ManifestFile))
+	for _, kv := range []struct{ key, value string }{
+		{"vcpkg_root", o.VcpkgRoot},
+		{"bcr_root", o.BcrRoot},
+		{"wrapdb_root", o.WrapdbRoot},
+		{"compiler_launcher", o.CompilerLauncher},
+	} {
+		if kv.value != "" {
+			fmt.Printf("  %s: %s\n", kv.key, kv.value)
+		}
+	}
+	if o.Jobs > 0 {
+		fmt.Printf("  jobs: %d\n", o.Jobs)
+	}
+}
+
+// getConfig prints a config value as it applies in the current project,
+// including the overrides of its cpx.yaml.
 func getConfig(key string) error {
+	cfg, err := projectConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	k, err := config.LookupConfigKey(key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(k.Get(cfg))
+	return nil
+}
+
+// setConfig sets a key of the global config after validating the value.
+func setConfig(key, value string) error {
+	k, err := config.LookupConfigKey(key)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	if err := k.Set(cfg, value); err != nil {
+		return err
+	}
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if value == "" {
+		output.Successf("✓ Unset %s", k.Name)
+	} else {
+		output.Successf("✓ Set %s to %s", k.Name, value)
+	}
+	return nil
+}
+
+// loadGlobalForUpdate loads the global config to change and save. Unknown
+// keys are reported and dropped when it is saved, as cpx config edit does.
+func loadGlobalForUpdate() (*config.GlobalConfig, error) {
 	cfg, err := config.LoadGlobal()
+	var unknown *config.UnknownKeysError
+	if errors.As(err, &unknown) {
+		output.Warnf("Unknown keys in the global config are removed when saving: %s", strings.Join(unknown.Keys, ", "))
+		return unknown.Config, nil
+	}
 	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+// editConfig edits the global config in the interactive editor. A config
+// with unknown keys can be edited; they are dropped when it is saved.
+func editConfig() error {
+	if !stdinIsTerminal() {
+		return fmt.Errorf("cpx config edit is interactive and stdin is not a terminal\n  hint: use cpx config set <key> <value>")
+	}
+	cfg, err := config.LoadGlobal()
+	notice := ""
+	var unknown *config.UnknownKeysError
+	if errors.As(err, &unknown) {
+		cfg = unknown.Config
+		notice = "unknown keys are removed when saving: " + strings.Join(unknown.Keys, ", ")
+	} else if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	switch key {
-	case "vcpkg_root", "vcpkg-root":
-		fmt.Println(cfg.VcpkgRoot)
-		return nil
-	case "bcr_root", "bcr-root":
-		fmt.Println(cfg.BcrRoot)
-		return nil
-	case "wrapdb_root", "wrapdb-root":
-		fmt.Println(cfg.WrapdbRoot)
+	fields := configFields(cfg)
+	edited, saved, err := tui.RunConfigEditor(fields, validateConfigValue, notice)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		output.Infof("No changes saved.")
 		return nil
-	default:
-		return fmt.Errorf("unknown config key: %s", key)
 	}
+	for _, f := range edited {
+		k, err := config.LookupConfigKey(f.Key)
+		if err != nil {
+			return err
+		}
+		if err := k.Set(cfg, f.Value); err != nil {
+			return err
+		}
+	}
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	path, _ := config.GetConfigPath()
+	output.Successf("✓ Saved %s", path)
+	return nil
+}
+
+// configFields returns the keys of the global config for the editor.
+func configFields(cfg *config.GlobalConfig) []tui.ConfigField {
+	var fields []tui.ConfigField
+	for i := range config.ConfigKeys {
+		k := &config.ConfigKeys[i]
+		options := k.Values
+		if k.Kind == config.KindBool {
+			options = []string{"true", "false"}
+		}
+		fields = append(fields, tui.ConfigField{Key: k.Name, Description: k.Description, Value: k.Get(cfg), Options: options})
+	}
+	return fields
+}
+
+func validateConfigValue(key, value string) error {
+	k, err := config.LookupConfigKey(key)
+	if err != nil {
+		return err
+	}
+	return k.Validate(value)
 }
 
 func setVcpkgRoot(path string) error {
@@ -133,13 +473,12 @@ func setVcpkgRoot(path string) error {
 		vcpkgExe += ".exe"
 	}
 	if _, err := os.Stat(vcpkgExe); os.IsNotExist(err) {
-		fmt.Printf("%s Warning: %s does not appear to be a vcpkg directory%s\n", colors.Yellow, path, colors.Reset)
-		fmt.Printf("  (vcpkg executable not found at %s)\n", vcpkgExe)
+		output.Warnf("%s does not appear to be a vcpkg directory (vcpkg executable not found at %s)", path, vcpkgExe)
 	}
 
-	cfg, err := config.LoadGlobal()
+	cfg, err := loadGlobalForUpdate()
 	if err != nil {
-		cfg = &config.GlobalConfig{}
+		return err
 	}
 
 	// Convert to absolute path
@@ -154,7 +493,7 @@ func setVcpkgRoot(path string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("%s Set vcpkg_root to %s%s\n", colors.Green, absPath, colors.Reset)
+	output.Successf("✓ Set vcpkg_root to %s", absPath)
 	return nil
 }
 
@@ -167,13 +506,12 @@ func setBcrRoot(path string) error {
 	// Check if it looks like a BCR directory
 	modulesDir := filepath.Join(path, "modules")
 	if _, err := os.Stat(modulesDir); os.IsNotExist(err) {
-		fmt.Printf("%s Warning: %s does not appear to be a BCR directory%s\n", colors.Yellow, path, colors.Reset)
-		fmt.Printf("  (modules directory not found at %s)\n", modulesDir)
+		output.Warnf("%s does not appear to be a BCR directory (modules directory not found at %s)", path, modulesDir)
 	}
 
-	cfg, err := config.LoadGlobal()
+	cfg, err := loadGlobalForUpdate()
 	if err != nil {
-		cfg = &config.GlobalConfig{}
+		return err
 	}
 
 	// Convert to absolute path
@@ -188,7 +526,7 @@ func setBcrRoot(path string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("%s✓ Set bcr_root to %s%s\n", colors.Green, absPath, colors.Reset)
+	output.Successf("✓ Set bcr_root to %s", absPath)
 	return nil
 }
 
@@ -198,9 +536,9 @@ func setWrapdbRoot(path string) error {
 		return fmt.Errorf("path does not exist: %s", path)
 	}
 
-	cfg, err := config.LoadGlobal()
+	cfg, err := loadGlobalForUpdate()
 	if err != nil {
-		cfg = &config.GlobalConfig{}
+		return err
 	}
 
 	// Convert to absolute path
@@ -215,6 +553,165 @@ func setWrapdbRoot(path string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("%s✓ Set wrapdb_root to %s%s\n", colors.Green, absPath, colors.Reset)
+	output.Successf("✓ Set wrapdb_root to %s", absPath)
+	return nil
+}
+
+func setCMakeGenerator(generator string) error {
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+
+	if generator == "auto" {
+		generator = ""
+	}
+	cfg.CMakeGenerator = generator
+
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if generator == "" {
+		output.Successf("✓ CMake generator will be detected automatically")
+	} else {
+		output.Successf("✓ Set cmake_generator to %s", generator)
+	}
+	return nil
+}
+
+func setSharedVcpkgInstalled(value string) error {
+	var shared bool
+	switch value {
+	case "on", "true":
+		shared = true
+	case "off", "false":
+	default:
+		return fmt.Errorf("invalid value %q (use on or off)", value)
+	}
+
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	cfg.SharedVcpkgInstalled = shared
+
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if shared {
+		output.Successf("✓ vcpkg projects now share dependencies in the cpx cache directory")
+	} else {
+		output.Successf("✓ vcpkg projects now install dependencies in .cache/native/vcpkg_installed")
+	}
+	return nil
+}
+
+func setNotifyAfter(value string) error {
+	seconds := 0
+	if value != "off" {
+		n, err := strconv.Atoi(strings.TrimSuffix(value, "s"))
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value %q (use a number of seconds or off)", value)
+		}
+		seconds = n
+	}
+
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	cfg.NotifyAfter = seconds
+
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if seconds == 0 {
+		output.Successf("✓ Build notifications turned off")
+	} else {
+		output.Successf("✓ cpx will notify when builds and tests take %ds or more", seconds)
+	}
+	return nil
+}
+
+// setProjectDir sets the global cache_dir or bin_dir. Absolute and ~/ paths
+// are kept as given; "default" clears the setting.
+func setProjectDir(key, dir string) error {
+	if dir == "default" {
+		dir = ""
+	}
+
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	if key == "cache_dir" {
+		cfg.CacheDir = dir
+	} else {
+		cfg.BinDir = dir
+	}
+
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if dir == "" {
+		output.Successf("✓ %s reset to the project default", key)
+	} else {
+		output.Successf("✓ Set %s to %s", key, dir)
+	}
+	return nil
+}
+
+func setJobs(value string) error {
+	jobs := 0
+	if value != "auto" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value %q (use a number of jobs or auto)", value)
+		}
+		jobs = n
+	}
+
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	cfg.Jobs = jobs
+
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if jobs == 0 {
+		output.Successf("✓ The build tool picks the number of jobs")
+	} else {
+		output.Successf("✓ Set jobs to %d", jobs)
+	}
+	return nil
+}
+
+func setCompilerLauncher(launcher string) error {
+	if launcher == "none" {
+		launcher = ""
+	}
+
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	cfg.CompilerLauncher = launcher
+
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if launcher == "" {
+		output.Successf("✓ Removed the compiler launcher")
+	} else {
+		output.Successf("✓ Set compiler_launcher to %s", launcher)
+	}
 	return nil
 }
@@ -369,3 +369,137 @@ func TestSetWrapdbRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestSetCMakeGenerator(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	require.NoError(t, setCMakeGenerator("clang-cl"))
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, "clang-cl", cfg.CMakeGenerator)
+
+	require.NoError(t, setCMakeGenerator("auto"))
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.CMakeGenerator)
+}
+
+func TestSetSharedVcpkgInstalled(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	require.NoError(t, setSharedVcpkgInstalled("on"))
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.True(t, cfg.SharedVcpkgInstalled)
+
+	require.NoError(t, setSharedVcpkgInstalled("off"))
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.False(t, cfg.SharedVcpkgInstalled)
+
+	assert.Error(t, setSharedVcpkgInstalled("maybe"))
+}
+
+func TestSetNotifyAfter(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	require.NoError(t, setNotifyAfter("90"))
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, 90, cfg.NotifyAfter)
+
+	require.NoError(t, setNotifyAfter("off"))
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.NotifyAfter)
+
+	assert.Error(t, setNotifyAfter("soon"))
+	assert.Error(t, setNotifyAfter("-5"))
+}
+
+func TestSetProjectDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	require.NoError(t, setProjectDir("cache_dir", "/scratch/cpx"))
+	require.NoError(t, setProjectDir("bin_dir", "~/artifacts"))
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, "/scratch/cpx", cfg.CacheDir)
+	assert.Equal(t, "~/artifacts", cfg.BinDir)
+
+	require.NoError(t, setProjectDir("cache_dir", "default"))
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.CacheDir)
+	assert.Equal(t, "~/artifacts", cfg.BinDir)
+}
+
+func TestSetJobsAndCompilerLauncher(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	require.NoError(t, setJobs("8"))
+	require.NoError(t, setCompilerLauncher("ccache"))
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, 8, cfg.Jobs)
+	assert.Equal(t, "ccache", cfg.CompilerLauncher)
+
+	require.NoError(t, setJobs("auto"))
+	require.NoError(t, setCompilerLauncher("none"))
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Jobs)
+	assert.Empty(t, cfg.CompilerLauncher)
+
+	assert.Error(t, setJobs("many"))
+}
+
+func TestProjectConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	chdirTemp(t)
+	require.NoError(t, config.SaveGlobal(&config.GlobalConfig{VcpkgRoot: "/opt/vcpkg"}))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(config.ManifestFile, []byte("config:\n  vcpkg_root: /work/vcpkg\n"), 0644))
+
+	cfg, err := projectConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "/work/vcpkg", cfg.VcpkgRoot)
+}
+
+func TestSetConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	require.NoError(t, setConfig("upgrade-channel", "nightly"))
+	require.NoError(t, setConfig("notify_after", "30"))
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, "nightly", cfg.UpgradeChannel)
+	assert.Equal(t, 30, cfg.NotifyAfter)
+
+	assert.ErrorContains(t, setConfig("upgrade_channel", "beta"), "use stable or nightly")
+	assert.ErrorContains(t, setConfig("vcpkg_root", filepath.Join(t.TempDir(), "missing")), "does not exist")
+	assert.ErrorContains(t, setConfig("job", "4"), `did you mean "jobs"?`)
+
+	require.NoError(t, setConfig("notify_after", ""))
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.NotifyAfter)
+}
+
+func TestSetConfigUnknownKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configPath, err := config.GetConfigPath()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(configPath), 0755))
+	require.NoError(t, os.WriteFile(configPath, []byte("job: 4\nnotify_after: 10\n"), 0644))
+
+	var unknown *config.UnknownKeysError
+	_, err = config.LoadGlobal()
+	require.ErrorAs(t, err, &unknown)
+
+	require.NoError(t, setConfig("jobs", "4"), "unknown keys do not block setting a key")
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err, "the unknown key is dropped")
+	assert.Equal(t, 4, cfg.Jobs)
+	assert.Equal(t, 10, cfg.NotifyAfter)
+}
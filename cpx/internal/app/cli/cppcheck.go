@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/ozacod/cpx/internal/pkg/quality"
 	"github.com/spf13/cobra"
 )
@@ -9,20 +12,42 @@ func CppcheckCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "cppcheck",
 		Short: "Run Cppcheck static analysis for C/C++",
-		Long:  "Run Cppcheck static analysis for C/C++. Performs static code analysis on C/C++ code.",
-		RunE:  runCppcheck,
-		Args:  cobra.ArbitraryArgs,
+		Long: `Run Cppcheck static analysis for C/C++. Performs static code analysis on C/C++ code.
+
+Without arguments, cpx generates a cppcheck project from the build's
+compile_commands.json, so the include paths and defines of the real build are
+used; run 'cpx build' first. Files or directories given as arguments are
+scanned directly. A checked-in .cppcheck-suppressions file is applied
+automatically.
+
+--baseline records the current findings in .cppcheck-baseline.json. While that
+file exists, runs fail only on findings that are not in it.`,
+		Example: `  cpx cppcheck                          # Analyze the sources of compile_commands.json
+  cpx cppcheck src/                     # Scan a directory
+  cpx cppcheck --severity error,warning # Only report errors and warnings
+  cpx cppcheck --baseline               # Accept the current findings
+  cpx cppcheck --sarif --output cppcheck.sarif`,
+		RunE: runCppcheck,
+		Args: cobra.ArbitraryArgs,
 	}
 
 	cmd.Flags().String("enable", "all", "Enable checks (all, style, performance, portability, information, unusedFunction, missingInclude)")
-	cmd.Flags().String("output", "", "Output file path (for XML/CSV output)")
+	cmd.Flags().String("output", "", "Output file path (for XML/CSV/SARIF output)")
 	cmd.Flags().Bool("xml", false, "Output results in XML format")
 	cmd.Flags().Bool("csv", false, "Output results in CSV format")
-	cmd.Flags().Bool("quiet", false, "Quiet mode (suppress progress messages)")
+	cmd.Flags().Bool("sarif", false, "Output results in SARIF format (for code scanning)")
 	cmd.Flags().Bool("force", false, "Force checking of all configurations")
 	cmd.Flags().Bool("inline-suppr", false, "Enable inline suppressions")
 	cmd.Flags().String("platform", "", "Target platform (unix32, unix64, win32A, win32W, win64, avr8, etc.)")
 	cmd.Flags().String("std", "", "C/C++ standard (c89, c99, c11, c++03, c++11, c++14, c++17, c++20)")
+	cmd.Flags().String("project", "", "Cppcheck project file or compile_commands.json to analyze (default: generated from compile_commands.json)")
+	cmd.Flags().String("suppressions", "", "Suppressions file (default: "+quality.CppcheckSuppressionsFile+" if present)")
+	cmd.Flags().String("severity", "", "Only report these severities, comma-separated (error, warning, style, performance, portability, information)")
+
+	cmd.Flags().Bool("baseline", false, "Record the current findings as the baseline")
+	cmd.Flags().String("baseline-file", quality.CppcheckBaselineFile, "Baseline file")
+
+	cmd.MarkFlagsMutuallyExclusive("xml", "csv", "sarif")
 
 	return cmd
 }
@@ -32,17 +57,53 @@ func runCppcheck(cmd *cobra.Command, args []string) error {
 	output, _ := cmd.Flags().GetString("output")
 	xml, _ := cmd.Flags().GetBool("xml")
 	csv, _ := cmd.Flags().GetBool("csv")
+	sarif, _ := cmd.Flags().GetBool("sarif")
 	quiet, _ := cmd.Flags().GetBool("quiet")
 	force, _ := cmd.Flags().GetBool("force")
 	inlineSuppr, _ := cmd.Flags().GetBool("inline-suppr")
 	platform, _ := cmd.Flags().GetString("platform")
 	std, _ := cmd.Flags().GetString("std")
+	project, _ := cmd.Flags().GetString("project")
+	suppressions, _ := cmd.Flags().GetString("suppressions")
+	severity, _ := cmd.Flags().GetString("severity")
+	baseline, _ := cmd.Flags().GetBool("baseline")
+	baselineFile, _ := cmd.Flags().GetString("baseline-file")
+
+	if project != "" && len(args) > 0 {
+		return fmt.Errorf("--project cannot be combined with files or directories to scan")
+	}
+
+	format := "text"
+	switch {
+	case xml:
+		format = "xml"
+	case csv:
+		format = "csv"
+	case sarif:
+		format = "sarif"
+	}
 
-	// Get remaining args as target directories/files (default to current directory)
-	targets := args
-	if len(targets) == 0 {
-		targets = []string{"."}
+	var severities []string
+	for _, s := range strings.Split(severity, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			severities = append(severities, s)
+		}
 	}
 
-	return quality.RunCppcheck(enable, output, xml, csv, quiet, force, inlineSuppr, platform, std, targets)
+	return quality.RunCppcheck(quality.CppcheckOptions{
+		Enable:         enable,
+		Output:         output,
+		Format:         format,
+		Quiet:          quiet,
+		Force:          force,
+		InlineSuppr:    inlineSuppr,
+		Platform:       platform,
+		Std:            std,
+		Project:        project,
+		Suppressions:   suppressions,
+		Severities:     severities,
+		Targets:        args,
+		Baseline:       baselineFile,
+		RecordBaseline: baseline,
+	})
 }
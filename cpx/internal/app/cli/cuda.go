@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// cudaArchitecturesFromConfig returns the top-level CUDA architectures of a
+// cpx-ci.yaml, or nil when the project sets none.
+func cudaArchitecturesFromConfig(path string) ([]string, error) {
+	ciConfig, err := config.LoadToolchains(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	archs, err := build.ParseCUDAArchitectures(ciConfig.CUDAArchitectures(nil))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return archs, nil
+}
+
+// cudaBuildFileRe matches the build files of projects with CUDA sources.
+var cudaBuildFileRe = regexp.MustCompile(`(?i)LANGUAGES[^)]*\bCUDA\b|enable_language\(\s*CUDA|add_languages\([^)]*'cuda'|project\([^)]*'cuda'`)
+
+// projectUsesCUDA reports whether the CMake or Meson project in dir compiles
+// CUDA sources.
+func projectUsesCUDA(dir string) bool {
+	for _, name := range []string{"CMakeLists.txt", "meson.build"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil && cudaBuildFileRe.Match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// cudaToolkitDirs are the default install locations of the CUDA toolkit,
+// which installers do not always put on PATH.
+var cudaToolkitDirs = []string{"/usr/local/cuda", "/opt/cuda"}
+
+// setupCUDA makes nvcc findable by CMake and Meson when the project uses
+// CUDA: if it is not on PATH, the bin directory of $CUDA_PATH, $CUDA_HOME or
+// a default toolkit location is added to it.
+func setupCUDA() error {
+	if !projectUsesCUDA(".") || os.Getenv("CUDACXX") != "" {
+		return nil
+	}
+	if _, err := execLookPath("nvcc"); err == nil {
+		return nil
+	}
+	nvcc := "nvcc"
+	if runtime.GOOS == "windows" {
+		nvcc += ".exe"
+	}
+	var roots []string
+	for _, env := range []string{"CUDA_PATH", "CUDA_HOME"} {
+		if root := os.Getenv(env); root != "" {
+			roots = append(roots, root)
+		}
+	}
+	for _, root := range append(roots, cudaToolkitDirs...) {
+		bin := filepath.Join(root, "bin")
+		if _, err := os.Stat(filepath.Join(bin, nvcc)); err == nil {
+			return os.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+		}
+	}
+	return fmt.Errorf("nvcc not found; this project compiles CUDA sources\n  hint: install the CUDA toolkit (https://developer.nvidia.com/cuda-downloads), set CUDA_PATH, or build in a CUDA docker toolchain (cpx build --toolchain cuda)")
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectUsesCUDA(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    bool
+	}{
+		{name: "cmake project", file: "CMakeLists.txt", content: "project(app VERSION 0.1.0 LANGUAGES CXX CUDA)\n", want: true},
+		{name: "cmake enable_language", file: "CMakeLists.txt", content: "project(app)\nenable_language(CUDA)\n", want: true},
+		{name: "cmake without cuda", file: "CMakeLists.txt", content: "project(app VERSION 0.1.0 LANGUAGES CXX)\n"},
+		{name: "meson project", file: "meson.build", content: "project('app', ['cpp', 'cuda'])\n", want: true},
+		{name: "meson add_languages", file: "meson.build", content: "project('app', 'cpp')\nadd_languages('cuda')\n", want: true},
+		{name: "meson without cuda", file: "meson.build", content: "project('app', 'cpp')\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0644))
+			assert.Equal(t, tt.want, projectUsesCUDA(dir))
+		})
+	}
+}
+
+func TestCUDAArchitecturesFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cpx-ci.yaml")
+
+	archs, err := cudaArchitecturesFromConfig(path)
+	require.NoError(t, err)
+	assert.Nil(t, archs)
+
+	require.NoError(t, os.WriteFile(path, []byte("cuda:\n  architectures: [sm_75, \"8.6\"]\n"), 0644))
+	archs, err = cudaArchitecturesFromConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"75", "86"}, archs)
+
+	require.NoError(t, os.WriteFile(path, []byte("cuda:\n  architectures: [ampere]\n"), 0644))
+	_, err = cudaArchitecturesFromConfig(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid CUDA architecture 'ampere'")
+}
+
+func TestSetupCUDA(t *testing.T) {
+	dir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(app LANGUAGES CXX CUDA)\n"), 0644))
+
+	oldExecLookPath := execLookPath
+	defer func() { execLookPath = oldExecLookPath }()
+	execLookPath = func(file string) (string, error) { return "", errors.New("not found") }
+
+	oldDirs := cudaToolkitDirs
+	defer func() { cudaToolkitDirs = oldDirs }()
+	cudaToolkitDirs = nil
+
+	t.Setenv("CUDACXX", "")
+	t.Setenv("CUDA_HOME", "")
+	t.Setenv("PATH", "/usr/bin")
+
+	t.Run("not installed", func(t *testing.T) {
+		t.Setenv("CUDA_PATH", "")
+		err := setupCUDA()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nvcc not found")
+	})
+
+	t.Run("toolkit off PATH", func(t *testing.T) {
+		toolkit := filepath.Join(dir, "cuda")
+		require.NoError(t, os.MkdirAll(filepath.Join(toolkit, "bin"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(toolkit, "bin", "nvcc"), nil, 0755))
+		t.Setenv("CUDA_PATH", toolkit)
+
+		require.NoError(t, setupCUDA())
+		assert.True(t, strings.HasPrefix(os.Getenv("PATH"), filepath.Join(toolkit, "bin")+string(os.PathListSeparator)))
+	})
+}
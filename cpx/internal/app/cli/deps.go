@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/spf13/cobra"
+)
+
+// DepsCmd creates the deps command
+func DepsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deps",
+		Short: "Manage dependencies interactively",
+		Long: `Open an interactive dependency manager. It lists the project dependencies
+and whether newer versions exist, searches the registry inline and shows the
+details of the package under the cursor.
+
+  /      search the registry        tab    switch installed/results
+  space  select                     a      add the selected results
+  d      remove the selected deps   u      upgrade the selected deps
+
+Changes run cpx add and cpx remove, whose output is shown in the lower pane.`,
+		Args: cobra.NoArgs,
+		RunE: runDeps,
+	}
+	return cmd
+}
+
+func runDeps(_ *cobra.Command, _ []string) error {
+	projectType, err := RequireProject("cpx deps")
+	if err != nil {
+		return err
+	}
+	if !stdinIsTerminal() {
+		return fmt.Errorf("cpx deps is interactive and stdin is not a terminal\n  hint: use cpx list, cpx search, cpx add and cpx remove")
+	}
+	root, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	p := tea.NewProgram(tui.NewDepsModel(depsFuncs(projectType, root, exe)), tea.WithAltScreen())
+	_, err = p.Run()
+	return err
+}
+
+// depsFuncs manages the dependencies of the project at root, changing them
+// with the cpx binary exe.
+func depsFuncs(projectType ProjectType, root, exe string) tui.DepsFuncs {
+	ctx := context.Background()
+	cpx := func(args ...string) *exec.Cmd {
+		cmd := exec.Command(exe, append(args, "--no-color")...)
+		cmd.Dir = root
+		return cmd
+	}
+	dashboard := dashboardFuncs(projectType, root, exe)
+
+	return tui.DepsFuncs{
+		List:   dashboard.Dependencies,
+		Latest: dashboard.LatestVersion,
+		Search: func(query string) ([]tui.SearchResult, error) {
+			builder, err := projectBuilder()
+			if err != nil {
+				return nil, err
+			}
+			deps, err := builder.SearchDependencies(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			var results []tui.SearchResult
+			for _, dep := range deps {
+				results = append(results, tui.SearchResult{Name: dep.Name, Version: dep.Version, Description: dep.Description})
+			}
+			return results, nil
+		},
+		Info: func(name string) (tui.PackageInfo, error) {
+			builder, err := projectBuilder()
+			if err != nil {
+				return tui.PackageInfo{}, err
+			}
+			info, err := builder.DependencyInfo(ctx, name)
+			if err != nil {
+				return tui.PackageInfo{}, err
+			}
+			return tui.PackageInfo(*info), nil
+		},
+		Add: func(name string) *exec.Cmd {
+			return cpx("add", name)
+		},
+		Remove: func(names []string) *exec.Cmd {
+			return cpx(append([]string{"remove"}, names...)...)
+		},
+		Upgrade: func(name, version string) []*exec.Cmd {
+			return depsUpgrade(projectType, root, cpx, name, version)
+		},
+	}
+}
+
+// depsUpgrade returns the commands that move dependency name to version.
+// Bazel rewrites the bazel_dep in place and Meson updates the wrap. A vcpkg
+// dependency with a pinned version is added again without the pin, so it
+// follows the builtin-baseline.
+func depsUpgrade(projectType ProjectType, root string, cpx func(args ...string) *exec.Cmd, name, version string) []*exec.Cmd {
+	switch projectType {
+	case ProjectTypeBazel:
+		return []*exec.Cmd{cpx("add", name, version)}
+	case ProjectTypeMeson:
+		cmd := exec.Command("meson", "wrap", "update", name)
+		cmd.Dir = root
+		return []*exec.Cmd{cmd}
+	default:
+		return []*exec.Cmd{cpx("remove", name), cpx("add", name)}
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepsUpgrade(t *testing.T) {
+	cpx := func(args ...string) *exec.Cmd {
+		return exec.Command("cpx", args...)
+	}
+	argsOf := func(cmds []*exec.Cmd) [][]string {
+		var out [][]string
+		for _, c := range cmds {
+			out = append(out, c.Args)
+		}
+		return out
+	}
+
+	assert.Equal(t, [][]string{{"cpx", "add", "abseil-cpp", "20240116.2"}},
+		argsOf(depsUpgrade(ProjectTypeBazel, "/p", cpx, "abseil-cpp", "20240116.2")))
+	assert.Equal(t, [][]string{{"cpx", "remove", "fmt"}, {"cpx", "add", "fmt"}},
+		argsOf(depsUpgrade(ProjectTypeVcpkg, "/p", cpx, "fmt", "11.0.2")))
+
+	cmds := depsUpgrade(ProjectTypeMeson, "/p", cpx, "spdlog", "1.14.1")
+	require.Len(t, cmds, 1)
+	assert.Equal(t, []string{"meson", "wrap", "update", "spdlog"}, cmds[0].Args)
+	assert.Equal(t, "/p", cmds[0].Dir)
+}
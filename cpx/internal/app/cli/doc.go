@@ -8,7 +8,8 @@ import (
 	"regexp"
 	"runtime"
 
-	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -30,8 +31,17 @@ func runDoc(cmd *cobra.Command, _ []string) error {
 	return generateDocs(open)
 }
 
-// getProjectInfo reads project name and version from CMakeLists.txt or vcpkg.json
+// getProjectInfo reads project name and version from cpx.yaml, or else from
+// CMakeLists.txt or vcpkg.json
 func getProjectInfo() (name string, version string) {
+	if manifest := config.ProjectManifest("."); manifest != nil && manifest.Name != "" {
+		name, version = manifest.Name, manifest.Version
+		if version == "" {
+			version = "0.1.0"
+		}
+		return name, version
+	}
+
 	// Default values
 	name = "Project"
 	version = "0.1.0"
@@ -74,7 +84,7 @@ func generateDocs(openBrowser bool) error {
 
 	projectName, projectVersion := getProjectInfo()
 
-	fmt.Printf("%s Generating documentation...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf(" Generating documentation...")
 
 	// Create Doxyfile if it doesn't exist
 	if _, err := os.Stat("Doxyfile"); os.IsNotExist(err) {
@@ -105,7 +115,7 @@ USE_MDFILE_AS_MAINPAGE = README.md
 	}
 
 	indexPath := "docs/html/index.html"
-	fmt.Printf("%s Documentation generated at %s%s\n", colors.Green, indexPath, colors.Reset)
+	output.Successf(" Documentation generated at %s", indexPath)
 
 	if openBrowser {
 		var openCmd string
@@ -0,0 +1,583 @@
+package cli
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/container"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// defaultRuntimeImage is the base of the runtime stage: glibc, libstdc++
+// and nothing else, matching the Debian 12 builder stage.
+const defaultRuntimeImage = "gcr.io/distroless/cc-debian12"
+
+// defaultPublishPlatforms are the platforms cpx docker publish builds
+// without --platforms.
+var defaultPublishPlatforms = []string{"linux/amd64", "linux/arm64"}
+
+// buildxBuilder is the buildx builder cpx creates for multi-platform builds,
+// which the default docker driver cannot do.
+const buildxBuilder = "cpx"
+
+// DockerCmd creates the docker command group
+func DockerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "docker",
+		Short: "Ship the project as a container image",
+	}
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Generate a multi-stage Dockerfile for the project",
+		Long: `Generate a Dockerfile with two stages: a builder stage compiling the project
+in Release mode and a minimal runtime stage holding only the executable,
+which is the image's entrypoint. A .dockerignore keeping the caches, build
+trees and .git out of the build context is written too, unless there is one.
+
+The builder stage uses the image of --toolchain, a toolchain in cpx-ci.yaml
+with a docker runner, and its CMake options; by default it is Debian 12 with
+CMake, Ninja, Meson and vcpkg. The runtime image must have the C and C++
+runtime libraries the executable was linked against; the default,
+gcr.io/distroless/cc-debian12, matches the default builder stage.
+
+With --platforms, the Dockerfile has a builder stage per platform for
+docker buildx (see cpx docker publish). A platform is cross-compiled on the
+build host by the toolchain of cpx-ci.yaml whose runner has it as
+target_platform, built in the image of a toolchain whose runner has it as
+platform, or else built in the default builder stage under emulation.
+
+The executable is release.binary of cpx-ci.yaml, else the project name.`,
+		Example: `  cpx docker init
+  cpx docker init --toolchain linux-gcc --runtime-image ubuntu:24.04
+  cpx docker init --platforms linux/amd64,linux/arm64 --force`,
+		Args: cobra.NoArgs,
+		RunE: runDockerInit,
+	}
+	initCmd.Flags().String("toolchain", "", "Build in the image of this docker toolchain from cpx-ci.yaml")
+	initCmd.Flags().StringSlice("platforms", nil, "Write a builder stage per platform for docker buildx (e.g. linux/amd64,linux/arm64)")
+	initCmd.Flags().String("runtime-image", defaultRuntimeImage, "Base image of the runtime stage")
+	initCmd.Flags().StringP("file", "f", "Dockerfile", "Path of the Dockerfile")
+	initCmd.Flags().Bool("force", false, "Overwrite an existing Dockerfile")
+	cmd.AddCommand(initCmd)
+
+	buildCmd := &cobra.Command{
+		Use:   "build",
+		Short: "Build the image of the project's Dockerfile",
+		Long: `Build the image of the Dockerfile written by cpx docker init, with the
+project root as the build context. The image is tagged <project>:latest
+unless --tag says otherwise.`,
+		Example: `  cpx docker build
+  cpx docker build --tag registry.example.com/acme/mylib:1.2.0`,
+		Args: cobra.NoArgs,
+		RunE: runDockerBuild,
+	}
+	buildCmd.Flags().StringArrayP("tag", "t", nil, "Name and tag of the image (repeatable; default <project>:latest)")
+	buildCmd.Flags().StringP("file", "f", "Dockerfile", "Path of the Dockerfile")
+	cmd.AddCommand(buildCmd)
+
+	publishCmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Build a multi-platform image and push it to the registry",
+		Long: `Build the image of the project's Dockerfile for every platform of
+--platforms with docker buildx and push it, as one multi-platform image, to
+the registry of --registry or of docker_registry in the global config
+(cpx config set docker_registry ghcr.io/acme). The image is
+<registry>/<project>, tagged with the project version and latest unless
+--tag says otherwise.
+
+Builds run on a docker-container buildx builder named cpx, created on first
+use. QEMU emulation is registered for foreign platforms, which the builder
+stages of a Dockerfile without cross-compiling toolchains run under; write
+one with cpx docker init --platforms to reuse the cross-compile toolchains
+of cpx-ci.yaml instead. Log in to the registry with docker login first.`,
+		Example: `  cpx docker publish
+  cpx docker publish --platforms linux/amd64,linux/arm64,linux/arm/v7
+  cpx docker publish --registry ghcr.io/acme --tag 1.2.0 --tag stable`,
+		Args: cobra.NoArgs,
+		RunE: runDockerPublish,
+	}
+	publishCmd.Flags().StringSlice("platforms", defaultPublishPlatforms, "Platforms of the image")
+	publishCmd.Flags().String("registry", "", "Registry and namespace to push to (default: docker_registry of the global config)")
+	publishCmd.Flags().StringArrayP("tag", "t", nil, "Tag of the image (repeatable; default the project version and latest)")
+	publishCmd.Flags().StringP("file", "f", "Dockerfile", "Path of the Dockerfile")
+	publishCmd.Flags().Bool("dry-run", false, "Print the docker buildx command without running it")
+	cmd.AddCommand(publishCmd)
+
+	return cmd
+}
+
+// dockerfileSpec is what cpx docker init puts in a Dockerfile.
+type dockerfileSpec struct {
+	BuildSystem  ProjectType
+	Binary       string
+	Builders     []dockerBuilder // one, for any platform, or one per platform
+	RuntimeImage string
+}
+
+// dockerBuilder is the builder stage of one platform, or of any platform
+// when Arch is empty.
+type dockerBuilder struct {
+	Arch               string // TARGETARCH of buildx: amd64, arm64, ...
+	Image              string // empty for the built-in Debian stage
+	Cross              bool   // runs on the build platform and cross-compiles
+	CMakeOptions       []string
+	CMakeToolchainFile string
+	CC, CXX            string
+}
+
+// stage returns the name of the builder stage.
+func (b dockerBuilder) stage() string {
+	if b.Arch == "" {
+		return "builder"
+	}
+	return "builder-" + b.Arch
+}
+
+// multiPlatform reports whether the Dockerfile has a builder per platform.
+func (s dockerfileSpec) multiPlatform() bool {
+	return len(s.Builders) > 1 || len(s.Builders) == 1 && s.Builders[0].Arch != ""
+}
+
+// toolchainStage returns the built-in Debian stage called name.
+func (s dockerfileSpec) toolchainStage(name string) string {
+	vcpkg := ""
+	if s.BuildSystem == ProjectTypeVcpkg {
+		vcpkg = `
+RUN git clone https://github.com/microsoft/vcpkg.git /opt/vcpkg && \
+    /opt/vcpkg/bootstrap-vcpkg.sh -disableMetrics
+ENV VCPKG_ROOT=/opt/vcpkg
+`
+	}
+	return `FROM debian:bookworm AS ` + name + `
+
+ENV DEBIAN_FRONTEND=noninteractive
+
+RUN apt-get update && apt-get install -y --no-install-recommends \
+    build-essential \
+    cmake \
+    ninja-build \
+    meson \
+    pkg-config \
+    ca-certificates \
+    git \
+    curl \
+    zip \
+    unzip \
+    tar \
+    && rm -rf /var/lib/apt/lists/*
+` + vcpkg
+}
+
+// vcpkgArchs are the vcpkg names of docker architectures, for the Linux
+// triplets of cross builds.
+var vcpkgArchs = map[string]string{"amd64": "x64", "386": "x86", "arm64": "arm64", "arm": "arm", "ppc64le": "ppc64le", "s390x": "s390x", "riscv64": "riscv64"}
+
+// buildCommands returns the shell commands building the project in /src
+// into build.
+func (s dockerfileSpec) buildCommands(b dockerBuilder) []string {
+	if s.BuildSystem == ProjectTypeMeson {
+		return []string{"meson setup build --buildtype=release", "meson compile -C build"}
+	}
+	configure := "cmake -G Ninja -S . -B build -DCMAKE_BUILD_TYPE=Release" +
+		` -DCMAKE_TOOLCHAIN_FILE="${VCPKG_ROOT:-/opt/vcpkg}/scripts/buildsystems/vcpkg.cmake"`
+	if b.Cross {
+		configure += " -DVCPKG_TARGET_TRIPLET=" + vcpkgArchs[b.Arch] + "-linux"
+	}
+	if b.CMakeToolchainFile != "" {
+		configure += " " + shellQuote("-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE="+b.CMakeToolchainFile)
+	}
+	for _, option := range b.CMakeOptions {
+		configure += " " + shellQuote(option)
+	}
+	return []string{configure, "cmake --build build"}
+}
+
+// render returns the Dockerfile.
+func (s dockerfileSpec) render() string {
+	var b strings.Builder
+	b.WriteString("# syntax=docker/dockerfile:1\n")
+	b.WriteString("# Generated by cpx docker init; edit freely, cpx does not overwrite it.\n\n")
+
+	multi := s.multiPlatform()
+	if multi {
+		b.WriteString("ARG TARGETARCH\n\n")
+		for _, builder := range s.Builders {
+			if builder.Image == "" {
+				b.WriteString(s.toolchainStage("toolchain") + "\n")
+				break
+			}
+		}
+	}
+	for i, builder := range s.Builders {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch {
+		case !multi && builder.Image == "":
+			b.WriteString(s.toolchainStage(builder.stage()))
+		case builder.Cross:
+			fmt.Fprintf(&b, "FROM --platform=$BUILDPLATFORM %s AS %s\n", builder.Image, builder.stage())
+		default:
+			fmt.Fprintf(&b, "FROM %s AS %s\n", cmp.Or(builder.Image, "toolchain"), builder.stage())
+		}
+		if builder.CC != "" || builder.CXX != "" {
+			var env []string
+			if builder.CC != "" {
+				env = append(env, "CC="+builder.CC)
+			}
+			if builder.CXX != "" {
+				env = append(env, "CXX="+builder.CXX)
+			}
+			fmt.Fprintf(&b, "ENV %s\n", strings.Join(env, " "))
+		}
+		commands := append(s.buildCommands(builder),
+			fmt.Sprintf("bin=$(find build -maxdepth 3 -type f -name %s -perm -u+x | head -n 1)", shellQuote(s.Binary)),
+			fmt.Sprintf(`if [ -z "$bin" ]; then echo "executable %s not found in build" >&2; exit 1; fi`, s.Binary),
+			fmt.Sprintf(`install -D "$bin" /out/%s`, s.Binary))
+		fmt.Fprintf(&b, "\nWORKDIR /src\nCOPY . .\nRUN %s\n", strings.Join(commands, " && \\\n    "))
+	}
+	if multi {
+		b.WriteString("\nFROM builder-${TARGETARCH} AS builder\n")
+	}
+
+	fmt.Fprintf(&b, "\nFROM %s\n", s.RuntimeImage)
+	fmt.Fprintf(&b, "COPY --from=builder /out/%[1]s /usr/local/bin/%[1]s\n", s.Binary)
+	b.WriteString("USER 65532:65532\n")
+	fmt.Fprintf(&b, "ENTRYPOINT [\"/usr/local/bin/%s\"]\n", s.Binary)
+	return b.String()
+}
+
+// dockerIgnore keeps the caches, artifacts and build trees of the host out
+// of the build context.
+const dockerIgnore = `.git
+.cache
+.bin
+build
+builddir
+dist
+`
+
+// dockerProjectName returns the name of the project in root, which names
+// its executable and image: the name of its cpx.yaml, else the one of its
+// build files, else the name of root.
+func dockerProjectName(root string, buildSystem ProjectType) string {
+	if manifest := config.ProjectManifest(root); manifest != nil && manifest.Name != "" {
+		return manifest.Name
+	}
+	name, _ := buildFileProjectInfo(root, buildSystem)
+	return cmp.Or(name, filepath.Base(root))
+}
+
+// dockerProjectVersion returns the version of the project in root, which
+// tags its image: the version of its cpx.yaml, else the one of its build
+// files; empty if it has none.
+func dockerProjectVersion(root string, buildSystem ProjectType) string {
+	if manifest := config.ProjectManifest(root); manifest != nil && manifest.Version != "" {
+		return manifest.Version
+	}
+	_, version := buildFileProjectInfo(root, buildSystem)
+	return version
+}
+
+// toolchainBuilder returns the builder stage of the docker toolchain tc.
+func toolchainBuilder(ciConfig *config.ToolchainConfig, tc *config.Toolchain) (dockerBuilder, error) {
+	runner := ciConfig.FindRunner(tc.Runner)
+	if tc.Type != "" || runner == nil || !runner.IsDocker() {
+		return dockerBuilder{}, fmt.Errorf("toolchain '%s' does not build in a docker runner\n  hint: pick a plain toolchain whose runner has type: docker", tc.Name)
+	}
+	builder := dockerBuilder{
+		Image:              runner.Image,
+		CMakeOptions:       tc.CMakeOptions,
+		CMakeToolchainFile: runner.CMakeToolchainFile,
+		CC:                 runner.CC,
+		CXX:                runner.CXX,
+	}
+	if runner.BuildsImage() {
+		builder.Image = dockerImageTag(runner)
+	}
+	return builder, nil
+}
+
+// planDockerfile works out the Dockerfile of the project in root: built in
+// the image of toolchain if it is not empty and, given platforms, with a
+// builder stage per platform.
+func planDockerfile(root, toolchain, runtimeImage string, platforms []string) (dockerfileSpec, error) {
+	buildSystem := DetectProjectType()
+	if buildSystem != ProjectTypeVcpkg && buildSystem != ProjectTypeMeson {
+		return dockerfileSpec{}, fmt.Errorf("cpx docker init supports CMake (vcpkg) and Meson projects")
+	}
+	spec := dockerfileSpec{
+		BuildSystem:  buildSystem,
+		Binary:       dockerProjectName(root, buildSystem),
+		RuntimeImage: runtimeImage,
+	}
+
+	ciConfig, err := config.LoadToolchains(filepath.Join(root, "cpx-ci.yaml"))
+	if err != nil && !os.IsNotExist(err) {
+		return spec, err
+	}
+	if ciConfig == nil {
+		ciConfig = &config.ToolchainConfig{}
+	}
+	if ciConfig.Release != nil && ciConfig.Release.Binary != "" {
+		spec.Binary = ciConfig.Release.Binary
+	}
+
+	fallback := dockerBuilder{}
+	if toolchain != "" {
+		tc := ciConfig.FindToolchain(toolchain)
+		if tc == nil {
+			return spec, fmt.Errorf("toolchain '%s' not found in cpx-ci.yaml", toolchain)
+		}
+		if fallback, err = toolchainBuilder(ciConfig, tc); err != nil {
+			return spec, err
+		}
+		if runner := ciConfig.FindRunner(tc.Runner); runner.TargetPlatform != "" {
+			return spec, fmt.Errorf("toolchain '%s' cross-compiles for %s, which the runtime image cannot run\n  hint: cpx docker init --platforms %s picks it for that platform", toolchain, runner.TargetPlatform, runner.TargetPlatform)
+		}
+	}
+	if len(platforms) == 0 {
+		spec.Builders = []dockerBuilder{fallback}
+		return spec, nil
+	}
+
+	seen := make(map[string]string)
+	for _, platform := range platforms {
+		arch := platformArch(platform)
+		if !strings.HasPrefix(platform, "linux/") || vcpkgArchs[arch] == "" {
+			return spec, fmt.Errorf("unsupported platform '%s'\n  hint: images are built for linux/<arch>, e.g. linux/amd64 or linux/arm64", platform)
+		}
+		if other, ok := seen[arch]; ok {
+			return spec, fmt.Errorf("platforms '%s' and '%s' have the same architecture", other, platform)
+		}
+		seen[arch] = platform
+
+		builder := fallback
+		builder.Arch = arch
+		for i := range ciConfig.Toolchains {
+			tc := &ciConfig.Toolchains[i]
+			runner := ciConfig.FindRunner(tc.Runner)
+			if tc.Type != "" || !tc.IsActive() || runner == nil || !runner.IsDocker() {
+				continue
+			}
+			// Meson cross builds need a cross file, which toolchains lack
+			cross := platformArch(runner.TargetPlatform) == arch
+			if cross && buildSystem == ProjectTypeMeson {
+				continue
+			}
+			if cross || runner.TargetPlatform == "" && platformArch(runner.Platform) == arch {
+				if builder, err = toolchainBuilder(ciConfig, tc); err != nil {
+					return spec, err
+				}
+				builder.Arch, builder.Cross = arch, cross
+				break
+			}
+		}
+		spec.Builders = append(spec.Builders, builder)
+	}
+	return spec, nil
+}
+
+func runDockerInit(cmd *cobra.Command, _ []string) error {
+	if _, err := RequireProject("cpx docker init"); err != nil {
+		return err
+	}
+	root, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	toolchain, _ := cmd.Flags().GetString("toolchain")
+	platforms, _ := cmd.Flags().GetStringSlice("platforms")
+	runtimeImage, _ := cmd.Flags().GetString("runtime-image")
+	file, _ := cmd.Flags().GetString("file")
+	force, _ := cmd.Flags().GetBool("force")
+
+	spec, err := planDockerfile(root, toolchain, runtimeImage, platforms)
+	if err != nil {
+		return err
+	}
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, file)
+	}
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists\n  hint: pass --force to overwrite it", file)
+	}
+	if err := os.WriteFile(path, []byte(spec.render()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	output.Successf(" Wrote %s (runs %s on %s)", file, spec.Binary, spec.RuntimeImage)
+
+	ignore := filepath.Join(root, ".dockerignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		if err := os.WriteFile(ignore, []byte(dockerIgnore), 0644); err != nil {
+			return fmt.Errorf("failed to write .dockerignore: %w", err)
+		}
+		output.Successf(" Wrote .dockerignore")
+	}
+	return nil
+}
+
+// dockerBuildArgs returns the docker build arguments building the image of
+// dockerfile in root with tags.
+func dockerBuildArgs(root, dockerfile string, tags []string) []string {
+	args := []string{"build", "-f", dockerfile}
+	for _, tag := range tags {
+		args = append(args, "-t", tag)
+	}
+	return append(args, root)
+}
+
+// projectDockerfile returns the path of the Dockerfile file of the project
+// in root, which must exist.
+func projectDockerfile(root, file, runtime string) (string, error) {
+	dockerfile := file
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(root, file)
+	}
+	if _, err := os.Stat(dockerfile); err != nil {
+		return "", fmt.Errorf("%s not found\n  hint: generate one with cpx docker init", file)
+	}
+	if _, err := execLookPath(runtime); err != nil {
+		return "", fmt.Errorf("%s not found in PATH", runtime)
+	}
+	return dockerfile, nil
+}
+
+func runDockerBuild(cmd *cobra.Command, _ []string) error {
+	buildSystem, err := RequireProject("cpx docker build")
+	if err != nil {
+		return err
+	}
+	root, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	tags, _ := cmd.Flags().GetStringArray("tag")
+	file, _ := cmd.Flags().GetString("file")
+
+	rt := containerRuntime()
+	dockerfile, err := projectDockerfile(root, file, rt.Name())
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		tags = []string{strings.ToLower(dockerProjectName(root, buildSystem)) + ":latest"}
+	}
+
+	output.Stepf(" Building %s", strings.Join(tags, ", "))
+	build := rt.Command(dockerBuildArgs(root, dockerfile, tags)...)
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		return fmt.Errorf("%s build failed: %w", rt.Name(), err)
+	}
+	output.Successf(" Built %s", strings.Join(tags, ", "))
+	return nil
+}
+
+// imageReferences returns the references of image in registry with tags.
+func imageReferences(registry, image string, tags []string) []string {
+	var refs []string
+	for _, tag := range tags {
+		refs = append(refs, strings.TrimSuffix(registry, "/")+"/"+image+":"+tag)
+	}
+	return refs
+}
+
+// buildxPublishArgs returns the docker buildx arguments building the image
+// of dockerfile in root for platforms and pushing it as refs.
+func buildxPublishArgs(root, dockerfile string, platforms, refs []string) []string {
+	args := []string{"buildx", "build", "--builder", buildxBuilder, "--platform", strings.Join(platforms, ","), "-f", dockerfile}
+	for _, ref := range refs {
+		args = append(args, "-t", ref)
+	}
+	return append(args, "--push", root)
+}
+
+// ensureBuildxBuilder creates the docker-container builder cpx publishes
+// with, unless it exists.
+func ensureBuildxBuilder() error {
+	if out, err := execCommand("docker", "buildx", "version").CombinedOutput(); err != nil {
+		return fmt.Errorf("docker buildx is not available: %s\n  hint: install the docker buildx plugin", strings.TrimSpace(string(out)))
+	}
+	if err := execCommand("docker", "buildx", "inspect", buildxBuilder).Run(); err == nil {
+		return nil
+	}
+	output.Stepf(" Creating the buildx builder %s", buildxBuilder)
+	if out, err := execCommand("docker", "buildx", "create", "--name", buildxBuilder, "--driver", "docker-container").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create the buildx builder %s: %w\n%s", buildxBuilder, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func runDockerPublish(cmd *cobra.Command, _ []string) error {
+	buildSystem, err := RequireProject("cpx docker publish")
+	if err != nil {
+		return err
+	}
+	root, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	platforms, _ := cmd.Flags().GetStringSlice("platforms")
+	registry, _ := cmd.Flags().GetString("registry")
+	tags, _ := cmd.Flags().GetStringArray("tag")
+	file, _ := cmd.Flags().GetString("file")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if registry == "" {
+		if cfg, err := config.LoadGlobalFor(root); err == nil {
+			registry = cfg.DockerRegistry
+		}
+	}
+	if registry == "" {
+		return fmt.Errorf("no registry to publish to\n  hint: pass --registry or run cpx config set docker_registry ghcr.io/<owner>")
+	}
+	if len(tags) == 0 {
+		if version := dockerProjectVersion(root, buildSystem); version != "" {
+			tags = append(tags, version)
+		}
+		tags = append(tags, "latest")
+	}
+	// multi-platform images are built with buildx, which only docker has
+	if rt := containerRuntime().Name(); rt != container.Docker {
+		return fmt.Errorf("cpx docker publish needs docker buildx, not %s\n  hint: install docker and run cpx config set container_runtime docker", rt)
+	}
+	dockerfile, err := projectDockerfile(root, file, container.Docker)
+	if err != nil {
+		return err
+	}
+	refs := imageReferences(registry, strings.ToLower(dockerProjectName(root, buildSystem)), tags)
+	args := buildxPublishArgs(root, dockerfile, platforms, refs)
+	if dryRun {
+		fmt.Println("docker " + strings.Join(args, " "))
+		return nil
+	}
+
+	if err := ensureBuildxBuilder(); err != nil {
+		return err
+	}
+	for _, platform := range platforms {
+		if arch := platformArch(platform); arch != hostArch {
+			if err := ensureBinfmt(arch); err != nil {
+				return err
+			}
+		}
+	}
+	output.Stepf(" Publishing %s for %s", strings.Join(refs, ", "), strings.Join(platforms, ", "))
+	publish := execCommand("docker", args...)
+	publish.Stdout = os.Stdout
+	publish.Stderr = os.Stderr
+	if err := publish.Run(); err != nil {
+		return fmt.Errorf("docker buildx build failed: %w\n  hint: log in to the registry with docker login", err)
+	}
+	output.Successf(" Published %s", strings.Join(refs, ", "))
+	return nil
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerfileSpec(t *testing.T) {
+	spec := dockerfileSpec{BuildSystem: ProjectTypeVcpkg, Binary: "server", Builders: []dockerBuilder{{}}, RuntimeImage: defaultRuntimeImage}
+	dockerfile := spec.render()
+	assert.Contains(t, dockerfile, "FROM debian:bookworm AS builder\n")
+	assert.Contains(t, dockerfile, "ENV VCPKG_ROOT=/opt/vcpkg\n")
+	assert.Contains(t, dockerfile, `
+WORKDIR /src
+COPY . .
+RUN cmake -G Ninja -S . -B build -DCMAKE_BUILD_TYPE=Release -DCMAKE_TOOLCHAIN_FILE="${VCPKG_ROOT:-/opt/vcpkg}/scripts/buildsystems/vcpkg.cmake" && \
+    cmake --build build && \
+    bin=$(find build -maxdepth 3 -type f -name 'server' -perm -u+x | head -n 1) && \
+    if [ -z "$bin" ]; then echo "executable server not found in build" >&2; exit 1; fi && \
+    install -D "$bin" /out/server
+
+FROM gcr.io/distroless/cc-debian12
+COPY --from=builder /out/server /usr/local/bin/server
+USER 65532:65532
+ENTRYPOINT ["/usr/local/bin/server"]
+`)
+
+	spec = dockerfileSpec{BuildSystem: ProjectTypeMeson, Binary: "server", Builders: []dockerBuilder{{Image: "gcc:14"}}, RuntimeImage: "debian:bookworm-slim"}
+	dockerfile = spec.render()
+	assert.Contains(t, dockerfile, "FROM gcc:14 AS builder\n\nWORKDIR /src\n")
+	assert.Contains(t, dockerfile, "RUN meson setup build --buildtype=release && \\\n    meson compile -C build && \\\n")
+	assert.NotContains(t, dockerfile, "vcpkg")
+	assert.NotContains(t, dockerfile, "TARGETARCH")
+
+	// one builder per platform, cross-compiling or emulated
+	spec = dockerfileSpec{BuildSystem: ProjectTypeVcpkg, Binary: "server", RuntimeImage: defaultRuntimeImage, Builders: []dockerBuilder{
+		{Arch: "amd64"},
+		{Arch: "arm64", Image: "cross", Cross: true, CMakeToolchainFile: "/opt/arm64.cmake", CC: "aarch64-linux-gnu-gcc", CXX: "aarch64-linux-gnu-g++"},
+	}}
+	dockerfile = spec.render()
+	assert.Contains(t, dockerfile, "ARG TARGETARCH\n\nFROM debian:bookworm AS toolchain\n")
+	assert.Contains(t, dockerfile, "FROM toolchain AS builder-amd64\n\nWORKDIR /src\n")
+	assert.Contains(t, dockerfile, `FROM --platform=$BUILDPLATFORM cross AS builder-arm64
+ENV CC=aarch64-linux-gnu-gcc CXX=aarch64-linux-gnu-g++
+
+WORKDIR /src
+COPY . .
+RUN cmake -G Ninja -S . -B build -DCMAKE_BUILD_TYPE=Release -DCMAKE_TOOLCHAIN_FILE="${VCPKG_ROOT:-/opt/vcpkg}/scripts/buildsystems/vcpkg.cmake" -DVCPKG_TARGET_TRIPLET=arm64-linux '-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE=/opt/arm64.cmake' && \
+`)
+	assert.Contains(t, dockerfile, "\nFROM builder-${TARGETARCH} AS builder\n\nFROM gcr.io/distroless/cc-debian12\n")
+}
+
+func TestDockerInit(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(server VERSION 1.0.0)\nadd_executable(server main.cpp)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "server"}`), 0644))
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`runners:
+  - name: gcc
+    type: docker
+    image: gcc:14
+  - name: arm
+    type: docker
+    image: cross
+    target_platform: linux/arm64
+  - name: local
+toolchains:
+  - name: linux
+    runner: gcc
+    cmake_options: ["-DWITH_TLS=ON"]
+  - name: linux-arm
+    runner: arm
+  - name: host
+    runner: local
+`), 0644))
+
+	spec, err := planDockerfile(".", "linux", defaultRuntimeImage, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "server", spec.Binary)
+	assert.Equal(t, []dockerBuilder{{Image: "gcc:14", CMakeOptions: []string{"-DWITH_TLS=ON"}}}, spec.Builders)
+
+	// platforms pick the cross-compiling toolchains
+	spec, err = planDockerfile(".", "", defaultRuntimeImage, []string{"linux/amd64", "linux/arm64"})
+	require.NoError(t, err)
+	assert.Equal(t, []dockerBuilder{{Arch: "amd64"}, {Arch: "arm64", Image: "cross", Cross: true}}, spec.Builders)
+	_, err = planDockerfile(".", "", defaultRuntimeImage, []string{"windows/amd64"})
+	assert.ErrorContains(t, err, "unsupported platform 'windows/amd64'")
+	_, err = planDockerfile(".", "", defaultRuntimeImage, []string{"linux/arm64", "linux/arm64/v8"})
+	assert.ErrorContains(t, err, "have the same architecture")
+
+	_, err = planDockerfile(".", "host", defaultRuntimeImage, nil)
+	assert.ErrorContains(t, err, "toolchain 'host' does not build in a docker runner")
+	_, err = planDockerfile(".", "linux-arm", defaultRuntimeImage, nil)
+	assert.ErrorContains(t, err, "cross-compiles for linux/arm64")
+	_, err = planDockerfile(".", "missing", defaultRuntimeImage, nil)
+	assert.ErrorContains(t, err, "toolchain 'missing' not found")
+
+	cmd := DockerCmd()
+	cmd.SetArgs([]string{"init"})
+	require.NoError(t, cmd.Execute())
+	dockerfile, err := os.ReadFile("Dockerfile")
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), `ENTRYPOINT ["/usr/local/bin/server"]`)
+	assert.FileExists(t, ".dockerignore")
+
+	// an existing Dockerfile is kept unless forced
+	cmd = DockerCmd()
+	cmd.SetArgs([]string{"init", "--toolchain", "linux"})
+	assert.ErrorContains(t, cmd.Execute(), "hint: pass --force")
+	cmd = DockerCmd()
+	cmd.SetArgs([]string{"init", "--toolchain", "linux", "--force"})
+	require.NoError(t, cmd.Execute())
+	dockerfile, err = os.ReadFile("Dockerfile")
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), "FROM gcc:14 AS builder")
+
+	assert.Equal(t, []string{"build", "-f", "Dockerfile", "-t", "server:latest", "-t", "server:1.0.0", "."},
+		dockerBuildArgs(".", "Dockerfile", []string{"server:latest", "server:1.0.0"}))
+}
+
+func TestDockerProjectInfo(t *testing.T) {
+	chdirTemp(t)
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "CMakeLists.txt"), []byte("project(Server VERSION 1.4.0)\n"), 0644))
+	assert.Equal(t, "Server", dockerProjectName(root, ProjectTypeVcpkg), "the CMakeLists.txt of root, not of the current directory")
+	assert.Equal(t, "1.4.0", dockerProjectVersion(root, ProjectTypeVcpkg))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "cpx.yaml"), []byte("name: api\nversion: 2.0.0\n"), 0644))
+	assert.Equal(t, "api", dockerProjectName(root, ProjectTypeVcpkg), "cpx.yaml takes precedence")
+	assert.Equal(t, "2.0.0", dockerProjectVersion(root, ProjectTypeVcpkg))
+
+	bazelRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bazelRoot, "MODULE.bazel"), []byte("module(name = \"mymod\", version = \"3.1.0\")\n"), 0644))
+	assert.Equal(t, "mymod", dockerProjectName(bazelRoot, ProjectTypeBazel))
+	assert.Equal(t, "3.1.0", dockerProjectVersion(bazelRoot, ProjectTypeBazel))
+}
+
+func TestDockerPublish(t *testing.T) {
+	chdirTemp(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(Server VERSION 1.4.0)\nadd_executable(Server main.cpp)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "server"}`), 0644))
+	assert.Equal(t, "1.4.0", dockerProjectVersion(".", ProjectTypeVcpkg))
+
+	refs := imageReferences("ghcr.io/acme/", "server", []string{"1.4.0", "latest"})
+	assert.Equal(t, []string{"ghcr.io/acme/server:1.4.0", "ghcr.io/acme/server:latest"}, refs)
+	assert.Equal(t, []string{"buildx", "build", "--builder", "cpx", "--platform", "linux/amd64,linux/arm64", "-f", "Dockerfile",
+		"-t", "ghcr.io/acme/server:1.4.0", "-t", "ghcr.io/acme/server:latest", "--push", "."},
+		buildxPublishArgs(".", "Dockerfile", defaultPublishPlatforms, refs))
+
+	cmd := DockerCmd()
+	cmd.SetArgs([]string{"publish"})
+	assert.ErrorContains(t, cmd.Execute(), "hint: pass --registry or run cpx config set docker_registry")
+	cmd = DockerCmd()
+	cmd.SetArgs([]string{"publish", "--registry", "ghcr.io/acme"})
+	assert.ErrorContains(t, cmd.Execute(), "hint: generate one with cpx docker init")
+}
@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/container"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// DoctorCmd creates the doctor command
+func DoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the development environment",
+		Long: `Check that the tools cpx uses are installed and configured: build systems
+and compilers, vcpkg and its bootstrap, the paths in the global config,
+the Docker daemon and the code quality tools. Each problem comes with a
+fix. Tools the current project needs are errors when missing, the others
+are warnings; cpx doctor fails if there are errors.`,
+		Args: cobra.NoArgs,
+		RunE: runDoctor,
+	}
+	return cmd
+}
+
+// Doctor check statuses
+const (
+	doctorOK      = "ok"
+	doctorWarning = "warning"
+	doctorError   = "error"
+)
+
+// doctorCheck is the result of one cpx doctor check.
+type doctorCheck struct {
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// doctorTool describes a tool cpx doctor looks for.
+type doctorTool struct {
+	name         string   // shown in the report
+	commands     []string // any of them will do
+	versionArgs  []string // arguments printing the version, if it is cheap to ask
+	fix          string
+	projectTypes []ProjectType // projects that cannot build without it
+}
+
+var doctorBuildTools = []doctorTool{
+	{
+		name:         "cmake",
+		commands:     []string{"cmake"},
+		versionArgs:  []string{"--version"},
+		fix:          "install CMake 3.20+ from https://cmake.org/download or your package manager",
+		projectTypes: []ProjectType{ProjectTypeVcpkg},
+	},
+	{
+		name:         "ninja/make",
+		commands:     []string{"ninja", "make"},
+		versionArgs:  []string{"--version"},
+		fix:          "install ninja (e.g. apt install ninja-build, brew install ninja)",
+		projectTypes: []ProjectType{ProjectTypeVcpkg, ProjectTypeMeson},
+	},
+	{
+		name:         "C compiler",
+		commands:     []string{"cc", "gcc", "clang", "cl"},
+		fix:          "install GCC or Clang (e.g. apt install build-essential, xcode-select --install)",
+		projectTypes: []ProjectType{ProjectTypeVcpkg, ProjectTypeMeson},
+	},
+	{
+		name:         "C++ compiler",
+		commands:     []string{"c++", "g++", "clang++", "cl"},
+		versionArgs:  []string{"--version"},
+		fix:          "install GCC or Clang (e.g. apt install build-essential, xcode-select --install)",
+		projectTypes: []ProjectType{ProjectTypeVcpkg, ProjectTypeMeson},
+	},
+	{
+		name:         "meson",
+		commands:     []string{"meson"},
+		versionArgs:  []string{"--version"},
+		fix:          "install Meson: pip install meson",
+		projectTypes: []ProjectType{ProjectTypeMeson},
+	},
+	{
+		name:         "bazel",
+		commands:     []string{"bazelisk", "bazel"},
+		fix:          "install Bazelisk from https://github.com/bazelbuild/bazelisk",
+		projectTypes: []ProjectType{ProjectTypeBazel},
+	},
+}
+
+var doctorQualityTools = []doctorTool{
+	{name: "git", commands: []string{"git"}, versionArgs: []string{"--version"}, fix: "install git from https://git-scm.com"},
+	{name: "clang-format", commands: []string{"clang-format"}, versionArgs: []string{"--version"}, fix: "install clang-format (LLVM) for cpx fmt"},
+	{name: "clang-tidy", commands: []string{"clang-tidy"}, fix: "install clang-tidy (LLVM) for cpx lint"},
+	{name: "cppcheck", commands: []string{"cppcheck"}, versionArgs: []string{"--version"}, fix: "install cppcheck for cpx cppcheck and cpx analyze"},
+	{name: "flawfinder", commands: []string{"flawfinder"}, fix: "install flawfinder: pip install flawfinder"},
+	{name: "doxygen", commands: []string{"doxygen"}, versionArgs: []string{"--version"}, fix: "install doxygen for cpx doc"},
+}
+
+func runDoctor(cmd *cobra.Command, _ []string) error {
+	projectType := DetectProjectType()
+
+	var checks []doctorCheck
+	for _, tool := range doctorBuildTools {
+		checks = append(checks, checkDoctorTool("Build tools", tool, projectType))
+	}
+	checks = append(checks, checkDoctorVcpkg(projectType)...)
+	checks = append(checks, checkDoctorConfig()...)
+	checks = append(checks, checkDoctorDocker())
+	for _, tool := range doctorQualityTools {
+		checks = append(checks, checkDoctorTool("Code quality", tool, projectType))
+	}
+
+	problems := 0
+	for _, c := range checks {
+		if c.Status == doctorError {
+			problems++
+		}
+	}
+
+	if jsonOutput(cmd) {
+		if err := printJSON(checks); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(checks)
+	}
+	if problems > 0 {
+		return fmt.Errorf("cpx doctor found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// printDoctorReport prints the checks grouped by section.
+func printDoctorReport(checks []doctorCheck) {
+	section := ""
+	for _, c := range checks {
+		if c.Section != section {
+			if section != "" {
+				fmt.Println()
+			}
+			section = c.Section
+			fmt.Printf("%s%s%s\n", colors.Cyan, section, colors.Reset)
+		}
+		symbol, color := "✓", colors.Green
+		switch c.Status {
+		case doctorWarning:
+			symbol, color = "⚠", colors.Yellow
+		case doctorError:
+			symbol, color = "✗", colors.Red
+		}
+		fmt.Printf("  %s%s%s %-14s %s%s%s\n", color, symbol, colors.Reset, c.Name, colors.Gray, c.Detail, colors.Reset)
+		if c.Fix != "" {
+			fmt.Printf("    %s→ %s%s\n", colors.Gray, c.Fix, colors.Reset)
+		}
+	}
+}
+
+// checkDoctorTool looks for tool on PATH. It is an error for it to be
+// missing when the project needs it.
+func checkDoctorTool(section string, tool doctorTool, projectType ProjectType) doctorCheck {
+	c := doctorCheck{Section: section, Name: tool.name}
+	for _, command := range tool.commands {
+		path, err := execLookPath(command)
+		if err != nil {
+			continue
+		}
+		c.Status = doctorOK
+		c.Detail = path
+		if version := toolVersion(command, tool.versionArgs); version != "" {
+			c.Detail = version + " (" + path + ")"
+		}
+		return c
+	}
+
+	c.Status = doctorWarning
+	c.Detail = "not found"
+	for _, pt := range tool.projectTypes {
+		if pt == projectType {
+			c.Status = doctorError
+			c.Detail = fmt.Sprintf("not found, required by this %s project", projectType)
+		}
+	}
+	c.Fix = tool.fix
+	return c
+}
+
+// toolVersion returns the first line printed by command with versionArgs,
+// or "" when there are none or it fails.
+func toolVersion(command string, versionArgs []string) string {
+	if len(versionArgs) == 0 {
+		return ""
+	}
+	out, err := execCommand(command, versionArgs...).Output()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line)
+}
+
+// checkDoctorVcpkg checks that vcpkg is configured and bootstrapped.
+func checkDoctorVcpkg(projectType ProjectType) []doctorCheck {
+	missing := doctorWarning
+	if projectType == ProjectTypeVcpkg {
+		missing = doctorError
+	}
+
+	root, source := os.Getenv("VCPKG_ROOT"), "VCPKG_ROOT"
+	if cfg, err := projectConfig(); err == nil && cfg.VcpkgRoot != "" {
+		root, source = cfg.VcpkgRoot, "vcpkg_root"
+	}
+	if root == "" {
+		return []doctorCheck{{
+			Section: "vcpkg", Name: "vcpkg_root", Status: missing, Detail: "not set",
+			Fix: "run: cpx setup vcpkg (or cpx config set-vcpkg-root <path> for an existing clone)",
+		}}
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return []doctorCheck{{
+			Section: "vcpkg", Name: "vcpkg_root", Status: missing, Detail: fmt.Sprintf("%s (%s) does not exist", root, source),
+			Fix: "run: cpx config set-vcpkg-root <path>",
+		}}
+	}
+	checks := []doctorCheck{{Section: "vcpkg", Name: "vcpkg_root", Status: doctorOK, Detail: fmt.Sprintf("%s (%s)", root, source)}}
+
+	exe := filepath.Join(root, "vcpkg")
+	bootstrap := "./bootstrap-vcpkg.sh"
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+		bootstrap = `.\bootstrap-vcpkg.bat`
+	}
+	if _, err := os.Stat(exe); err != nil {
+		checks = append(checks, doctorCheck{
+			Section: "vcpkg", Name: "bootstrap", Status: missing, Detail: exe + " not found",
+			Fix: fmt.Sprintf("cd %s && %s", root, bootstrap),
+		})
+	} else {
+		detail := exe
+		if version := toolVersion(exe, []string{"version"}); version != "" {
+			detail = version
+		}
+		checks = append(checks, doctorCheck{Section: "vcpkg", Name: "bootstrap", Status: doctorOK, Detail: detail})
+	}
+	return checks
+}
+
+// checkDoctorConfig checks that the paths in the global config exist.
+func checkDoctorConfig() []doctorCheck {
+	path, err := config.GetConfigPath()
+	if err != nil {
+		return []doctorCheck{{Section: "Config", Name: "config", Status: doctorError, Detail: err.Error()}}
+	}
+	cfg, err := projectConfig()
+	if err != nil {
+		return []doctorCheck{{
+			Section: "Config", Name: "config", Status: doctorError, Detail: err.Error(),
+			Fix: "fix or remove " + path,
+		}}
+	}
+	checks := []doctorCheck{{Section: "Config", Name: "config", Status: doctorOK, Detail: path}}
+
+	paths := []struct{ key, value, fix string }{
+		{"bcr_root", cfg.BcrRoot, "run: cpx bcr sync"},
+		{"wrapdb_root", cfg.WrapdbRoot, "clear or fix wrapdb_root in " + path},
+	}
+	for _, name := range sortedTemplateNames(cfg.Templates) {
+		tmpl := cfg.Templates[name]
+		fix := "run: cpx template remove " + name
+		if tmpl.Dir != tmpl.Source {
+			fix = "run: cpx template add --force " + name + " " + tmpl.Source
+		}
+		paths = append(paths, struct{ key, value, fix string }{"template " + name, tmpl.Dir, fix})
+	}
+	for _, p := range paths {
+		if p.value == "" {
+			continue
+		}
+		if _, err := os.Stat(p.value); err != nil {
+			checks = append(checks, doctorCheck{Section: "Config", Name: p.key, Status: doctorWarning, Detail: p.value + " does not exist", Fix: p.fix})
+		} else {
+			checks = append(checks, doctorCheck{Section: "Config", Name: p.key, Status: doctorOK, Detail: p.value})
+		}
+	}
+	return checks
+}
+
+// checkDoctorDocker checks that the container runtime answers. It is only
+// needed for toolchain builds, so problems are warnings.
+func checkDoctorDocker() doctorCheck {
+	rt := containerRuntime()
+	c := doctorCheck{Section: "Docker", Name: rt.Name(), Status: doctorWarning}
+	if _, err := execLookPath(rt.Name()); err != nil {
+		c.Detail = "not found (needed for Docker toolchains)"
+		c.Fix = "install Docker from https://docs.docker.com/get-docker, or Podman"
+		return c
+	}
+	// podman has no daemon, its info reports the engine version instead
+	format := "{{.ServerVersion}}"
+	if rt.Name() == container.Podman {
+		format = "{{.Version.Version}}"
+	}
+	out, err := rt.Command("info", "--format", format).CombinedOutput()
+	if err != nil {
+		c.Detail = "daemon not reachable: " + firstLine(string(out))
+		c.Fix = "start Docker Desktop or the docker service (sudo systemctl start docker); on Linux, add yourself to the docker group"
+		if rt.Name() != container.Docker {
+			c.Fix = "check the " + rt.Name() + " setup with " + rt.Name() + " info"
+		}
+		return c
+	}
+	c.Status = doctorOK
+	c.Detail = "daemon " + strings.TrimSpace(string(out))
+	if rt.Name() == container.Podman {
+		c.Detail = "podman " + strings.TrimSpace(string(out))
+	}
+	return c
+}
+
+// firstLine returns the first non-empty line of s.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
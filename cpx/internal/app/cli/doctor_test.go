@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDoctorTool(t *testing.T) {
+	oldExecLookPath := execLookPath
+	defer func() { execLookPath = oldExecLookPath }()
+	execLookPath = func(file string) (string, error) {
+		if file == "make" {
+			return "/usr/bin/make", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	ninja := doctorTool{name: "ninja/make", commands: []string{"ninja", "make"}, fix: "install ninja", projectTypes: []ProjectType{ProjectTypeMeson}}
+	c := checkDoctorTool("Build tools", ninja, ProjectTypeMeson)
+	assert.Equal(t, doctorCheck{Section: "Build tools", Name: "ninja/make", Status: doctorOK, Detail: "/usr/bin/make"}, c, "any of the commands will do")
+
+	meson := doctorTool{name: "meson", commands: []string{"meson"}, fix: "pip install meson", projectTypes: []ProjectType{ProjectTypeMeson}}
+	c = checkDoctorTool("Build tools", meson, ProjectTypeMeson)
+	assert.Equal(t, doctorError, c.Status, "the project needs it")
+	assert.Equal(t, "pip install meson", c.Fix)
+
+	c = checkDoctorTool("Build tools", meson, ProjectTypeBazel)
+	assert.Equal(t, doctorWarning, c.Status)
+}
+
+func TestCheckDoctorVcpkg(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("VCPKG_ROOT", "")
+
+	checks := checkDoctorVcpkg(ProjectTypeVcpkg)
+	require.Len(t, checks, 1)
+	assert.Equal(t, doctorError, checks[0].Status)
+	assert.Equal(t, doctorWarning, checkDoctorVcpkg(ProjectTypeMeson)[0].Status)
+
+	// A clone that was not bootstrapped
+	root := filepath.Join(tmpDir, "vcpkg")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	t.Setenv("VCPKG_ROOT", root)
+	checks = checkDoctorVcpkg(ProjectTypeVcpkg)
+	require.Len(t, checks, 2)
+	assert.Equal(t, doctorOK, checks[0].Status)
+	assert.Equal(t, "bootstrap", checks[1].Name)
+	assert.Equal(t, doctorError, checks[1].Status)
+	assert.Contains(t, checks[1].Fix, "bootstrap-vcpkg")
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultEnvFiles are loaded by cpx run, test and bench when they exist;
+// .env.local, for a developer's own values, overrides .env
+var defaultEnvFiles = []string{".env", ".env.local"}
+
+// addEnvFileFlag registers the --env-file flag of commands that run the
+// project's executables.
+func addEnvFileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArray("env-file", nil, "Load environment variables from this file instead of .env and .env.local (repeatable)")
+}
+
+// loadEnvFiles sets the variables of the --env-file files, or of .env and
+// .env.local, in the environment executables are run with. Variables
+// already set in the environment are kept, so a shell can override a file.
+func loadEnvFiles(cmd *cobra.Command) error {
+	files, _ := cmd.Flags().GetStringArray("env-file")
+	explicit := len(files) > 0
+	if !explicit {
+		files = defaultEnvFiles
+	}
+
+	vars := map[string]string{}
+	var names []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) && !explicit {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read env file: %w", err)
+		}
+		parsed, err := parseEnvFile(string(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for _, v := range parsed {
+			if _, ok := vars[v.Name]; !ok {
+				names = append(names, v.Name)
+			}
+			vars[v.Name] = v.Value
+		}
+	}
+
+	for _, name := range names {
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+		if err := os.Setenv(name, vars[name]); err != nil {
+			return fmt.Errorf("failed to set %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// envNameRe matches the variable names of env files
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// parseEnvFile parses the NAME=value lines of an env file in the common
+// dotenv syntax: "export " prefixes, # comments, single-quoted literal
+// values and double-quoted values with \n, \t, \" and \\ escapes, which may
+// span lines.
+func parseEnvFile(data string) ([]envVar, error) {
+	var vars []envVar
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !envNameRe.MatchString(name) {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNo)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'"):
+			quote := value[:1]
+			start := lineNo
+			// A quoted value ends at the first unescaped closing quote
+			for closingQuote(value[1:], quote) < 0 {
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("line %d: unterminated %s value", start, quote)
+				}
+				lineNo++
+				value += "\n" + scanner.Text()
+			}
+			end := closingQuote(value[1:], quote) + 1
+			if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected text after the closing quote", lineNo)
+			}
+			value = value[1:end]
+			if quote == `"` {
+				value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value)
+			}
+		default:
+			// Unquoted values end at a comment
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars = append(vars, envVar{Name: name, Value: value})
+	}
+	return vars, scanner.Err()
+}
+
+// closingQuote returns the index of the first quote in s that is not
+// escaped with a backslash (in double quotes), or -1.
+func closingQuote(s, quote string) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == `"` && s[i] == '\\':
+			i++
+		case s[i] == quote[0]:
+			return i
+		}
+	}
+	return -1
+}
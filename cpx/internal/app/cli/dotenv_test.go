@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	vars, err := parseEnvFile(`# API settings
+API_KEY=abc123
+export REGION = eu-west-1
+EMPTY=
+URL=http://localhost:8080/#home
+LEVEL=debug # inline comment
+SINGLE='literal \n $HOME'
+DOUBLE="tab\there \"quoted\""
+MULTI="first
+second"
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []envVar{
+		{"API_KEY", "abc123"},
+		{"REGION", "eu-west-1"},
+		{"EMPTY", ""},
+		{"URL", "http://localhost:8080/#home"},
+		{"LEVEL", "debug"},
+		{"SINGLE", `literal \n $HOME`},
+		{"DOUBLE", "tab\there \"quoted\""},
+		{"MULTI", "first\nsecond"},
+	}, vars)
+
+	_, err = parseEnvFile("API_KEY\n")
+	assert.ErrorContains(t, err, "line 1")
+	_, err = parseEnvFile("A=1\nB=\"open\n")
+	assert.ErrorContains(t, err, "line 2: unterminated")
+	_, err = parseEnvFile(`A="x" y`)
+	assert.ErrorContains(t, err, "after the closing quote")
+}
+
+func TestLoadEnvFiles(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile(".env", []byte("CPX_TEST_A=env\nCPX_TEST_B=env\nCPX_TEST_C=env\n"), 0644))
+	require.NoError(t, os.WriteFile(".env.local", []byte("CPX_TEST_B=local\n"), 0644))
+	require.NoError(t, os.WriteFile("ci.env", []byte("CPX_TEST_D=ci\n"), 0644))
+	for _, name := range []string{"CPX_TEST_A", "CPX_TEST_B", "CPX_TEST_D"} {
+		t.Setenv(name, "")
+		require.NoError(t, os.Unsetenv(name))
+	}
+	t.Setenv("CPX_TEST_C", "shell")
+
+	cmd := &cobra.Command{}
+	addEnvFileFlag(cmd)
+	require.NoError(t, loadEnvFiles(cmd))
+	assert.Equal(t, "env", os.Getenv("CPX_TEST_A"))
+	assert.Equal(t, "local", os.Getenv("CPX_TEST_B"), ".env.local overrides .env")
+	assert.Equal(t, "shell", os.Getenv("CPX_TEST_C"), "the environment overrides the files")
+
+	cmd = &cobra.Command{}
+	addEnvFileFlag(cmd)
+	require.NoError(t, cmd.Flags().Set("env-file", "ci.env"))
+	require.NoError(t, loadEnvFiles(cmd))
+	assert.Equal(t, "ci", os.Getenv("CPX_TEST_D"))
+
+	require.NoError(t, cmd.Flags().Set("env-file", "missing.env"))
+	assert.Error(t, loadEnvFiles(cmd), "a file given with --env-file must exist")
+}
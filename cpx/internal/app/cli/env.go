@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// EnvCmd creates the env command
+func EnvCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Print the environment cpx builds with",
+		Long: `Print the environment cpx resolves for builds: vcpkg variables, the
+CMake toolchain file, generator and compiler, and the build and output
+directories of a variant or toolchain, so the build tools can be run by hand.`,
+		Example: `  cpx env                       # NAME=value lines
+  eval "$(cpx env --sh)"        # Export into the current shell
+  cpx env --release --asan      # Directories of another variant
+  cpx env --toolchain linux-gcc # Settings of a cpx-ci.yaml toolchain
+  cpx env --json`,
+		Args: cobra.NoArgs,
+		RunE: runEnv,
+	}
+
+	cmd.Flags().Bool("sh", false, "Print export statements for eval in a POSIX shell")
+	addEnvFlags(cmd, "Show")
+
+	return cmd
+}
+
+// addEnvFlags registers the flags selecting the build variant or toolchain
+// whose environment is resolved. verb starts the descriptions.
+func addEnvFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().BoolP("release", "r", false, verb+" the release variant")
+	cmd.Flags().StringP("opt", "O", "", verb+" the variant of an optimization level: 0,1,2,3,s,fast")
+	cmd.Flags().String("toolchain", "", verb+" the settings of a toolchain (from cpx-ci.yaml)")
+	addSanitizerFlags(cmd, verb+" the variant built")
+	addLibraryTypeFlags(cmd, verb+" the variant with")
+	_ = cmd.RegisterFlagCompletionFunc("toolchain", completeToolchains)
+}
+
+// envVar is a variable printed by cpx env.
+type envVar struct {
+	Name  string
+	Value string
+}
+
+func runEnv(cmd *cobra.Command, _ []string) error {
+	vars, err := resolveEnv(cmd, "cpx env")
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput(cmd) {
+		env := make(map[string]string, len(vars))
+		for _, v := range vars {
+			env[v.Name] = v.Value
+		}
+		return printJSON(env)
+	}
+	sh, _ := cmd.Flags().GetBool("sh")
+	for _, v := range vars {
+		if sh {
+			fmt.Printf("export %s=%s\n", v.Name, shellQuote(v.Value))
+		} else {
+			fmt.Printf("%s=%s\n", v.Name, v.Value)
+		}
+	}
+	return nil
+}
+
+// resolveEnv returns the environment of the variant or toolchain selected
+// by the flags of addEnvFlags; unset variables are left out.
+func resolveEnv(cmd *cobra.Command, cmdName string) ([]envVar, error) {
+	projectType, err := RequireProject(cmdName)
+	if err != nil {
+		return nil, err
+	}
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []envVar{
+		{"CPX_PROJECT_DIR", projectRoot},
+		{"CPX_BUILD_SYSTEM", string(projectType)},
+	}
+	if projectType == ProjectTypeVcpkg {
+		vcpkgVars, err := vcpkgEnv()
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, vcpkgVars...)
+	}
+
+	if toolchain, _ := cmd.Flags().GetString("toolchain"); toolchain != "" {
+		tcVars, err := toolchainEnv(filepath.Join(projectRoot, "cpx-ci.yaml"), toolchain)
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, tcVars...)
+	} else {
+		sanitizer, err := sanitizerFromFlags(cmd)
+		if err != nil {
+			return nil, err
+		}
+		release, _ := cmd.Flags().GetBool("release")
+		optLevel, _ := cmd.Flags().GetString("opt")
+		variant := build.GetOutputDir(release, optLevel, sanitizer)
+
+		buildDir := filepath.Join(config.CacheDir(), "native", variant)
+		switch projectType {
+		case ProjectTypeVcpkg:
+			if libraryType := libraryTypeFromFlags(cmd); libraryType != "" {
+				buildDir += "-" + libraryType
+			}
+		case ProjectTypeMeson:
+			buildDir = "builddir"
+		case ProjectTypeBazel:
+			buildDir = "bazel-bin"
+		}
+		vars = append(vars,
+			envVar{"CPX_BUILD_DIR", filepath.Join(projectRoot, buildDir)},
+			envVar{"CPX_OUTPUT_DIR", filepath.Join(config.DirsOf(projectRoot).Bin, "native", variant)},
+		)
+		for _, entry := range build.SanitizerEnv(sanitizer) {
+			name, value, _ := strings.Cut(entry, "=")
+			vars = append(vars, envVar{name, value})
+		}
+	}
+
+	// Later settings (a toolchain's compiler) replace earlier ones
+	index := make(map[string]int, len(vars))
+	var resolved []envVar
+	for _, v := range vars {
+		if i, ok := index[v.Name]; ok {
+			resolved[i].Value = v.Value
+			continue
+		}
+		index[v.Name] = len(resolved)
+		resolved = append(resolved, v)
+	}
+	return slices.DeleteFunc(resolved, func(v envVar) bool { return v.Value == "" }), nil
+}
+
+// vcpkgEnv returns the vcpkg variables cpx sets for vcpkg and CMake, and
+// the generator and compiler of native CMake builds. Variables already in
+// the environment are kept.
+func vcpkgEnv() ([]envVar, error) {
+	builder := vcpkg.New()
+	if err := builder.SetupEnv(); err != nil {
+		output.Warnf("%v", err)
+	}
+	vars := []envVar{
+		{"VCPKG_ROOT", os.Getenv("VCPKG_ROOT")},
+		{"VCPKG_FEATURE_FLAGS", os.Getenv("VCPKG_FEATURE_FLAGS")},
+		{"VCPKG_DISABLE_REGISTRY_UPDATE", os.Getenv("VCPKG_DISABLE_REGISTRY_UPDATE")},
+	}
+	if root := os.Getenv("VCPKG_ROOT"); root != "" {
+		vars = append(vars, envVar{"CMAKE_TOOLCHAIN_FILE", filepath.Join(root, "scripts", "buildsystems", "vcpkg.cmake")})
+	}
+	installedDir, err := builder.InstalledDir()
+	if err != nil {
+		return nil, err
+	}
+	vars = append(vars, envVar{"VCPKG_INSTALLED_DIR", installedDir})
+
+	generator, compiler, err := builder.CMakeToolchain()
+	if err != nil {
+		return nil, err
+	}
+	if generator != "" {
+		vars = append(vars, envVar{"CMAKE_GENERATOR", generator})
+	}
+	cc, cxx := os.Getenv("CC"), os.Getenv("CXX")
+	if compiler != "" {
+		cc, cxx = compiler, compiler
+	}
+	if cc != "" {
+		vars = append(vars, envVar{"CC", cc})
+	}
+	if cxx != "" {
+		vars = append(vars, envVar{"CXX", cxx})
+	}
+	return vars, nil
+}
+
+// toolchainEnv returns the settings of a cpx-ci.yaml toolchain and its
+// runner, and the environment variables it sets.
+func toolchainEnv(path, name string) ([]envVar, error) {
+	ciConfig, err := config.LoadToolchains(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cpx-ci.yaml: %w", err)
+	}
+	if err := ciConfig.ExpandMatrix(); err != nil {
+		return nil, err
+	}
+	tc := ciConfig.FindToolchain(name)
+	if tc == nil {
+		return nil, fmt.Errorf("toolchain '%s' not found in cpx-ci.yaml", name)
+	}
+
+	projectRoot := filepath.Dir(path)
+	vars := []envVar{
+		{"CPX_TOOLCHAIN", tc.Name},
+		{"CPX_RUNNER", tc.Runner},
+		{"CMAKE_BUILD_TYPE", tc.BuildType},
+		{"CPX_BUILD_DIR", filepath.Join(config.DirsOf(projectRoot).Cache, "ci", tc.Name)},
+		{"CPX_OUTPUT_DIR", filepath.Join(projectRoot, ciConfig.GetOutputDir(), tc.Name)},
+	}
+	if runner := ciConfig.FindRunner(tc.Runner); runner != nil {
+		if runner.IsDocker() {
+			vars = append(vars, envVar{"CPX_DOCKER_IMAGE", runner.Image})
+		}
+		if runner.CC != "" {
+			vars = append(vars, envVar{"CC", runner.CC})
+		}
+		if runner.CXX != "" {
+			vars = append(vars, envVar{"CXX", runner.CXX})
+		}
+		if runner.CMakeToolchainFile != "" {
+			vars = append(vars, envVar{"CMAKE_TOOLCHAIN_FILE", runner.CMakeToolchainFile})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(tc.Env)) {
+		vars = append(vars, envVar{key, tc.Env[key]})
+	}
+	return vars, nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
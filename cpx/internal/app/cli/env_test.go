@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolchainEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "cpx-ci.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`runners:
+  - name: ubuntu
+    type: docker
+    image: ubuntu:24.04
+    cc: gcc-13
+    cxx: g++-13
+toolchains:
+  - name: linux
+    runner: ubuntu
+    env:
+      ZZ: last
+      AA: first
+`), 0644))
+
+	vars, err := toolchainEnv(path, "linux")
+	require.NoError(t, err)
+	assert.Equal(t, []envVar{
+		{"CPX_TOOLCHAIN", "linux"},
+		{"CPX_RUNNER", "ubuntu"},
+		{"CMAKE_BUILD_TYPE", "Release"},
+		{"CPX_BUILD_DIR", filepath.Join(tmpDir, ".cache", "ci", "linux")},
+		{"CPX_OUTPUT_DIR", filepath.Join(tmpDir, ".bin", "ci", "linux")},
+		{"CPX_DOCKER_IMAGE", "ubuntu:24.04"},
+		{"CC", "gcc-13"},
+		{"CXX", "g++-13"},
+		{"AA", "first"},
+		{"ZZ", "last"},
+	}, vars)
+
+	_, err = toolchainEnv(path, "missing")
+	assert.Error(t, err)
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "'/opt/vcpkg'", shellQuote("/opt/vcpkg"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, "''", shellQuote(""))
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// ExecCmd creates the exec command
+func ExecCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec [flags] -- <command> [args...]",
+		Short: "Run a command in the project's build environment",
+		Long: `Run a command with the environment printed by "cpx env" exported and the
+output directory of the variant (.bin/native/<variant>) or toolchain first
+on PATH, to run cmake, ctest, gdb or the built programs by hand with the
+same setup cpx uses. The command's exit code is passed through.`,
+		Example: `  cpx exec -- ctest --test-dir "$CPX_BUILD_DIR"
+  cpx exec --asan -- my_app --input data.txt
+  cpx exec --release -- gdb --args my_app`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runExec,
+	}
+	// Flags after the command belong to it
+	cmd.Flags().SetInterspersed(false)
+	addEnvFlags(cmd, "Use")
+
+	return cmd
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	vars, err := resolveEnv(cmd, "cpx exec")
+	if err != nil {
+		return err
+	}
+
+	env := os.Environ()
+	path := os.Getenv("PATH")
+	for _, v := range vars {
+		env = append(env, v.Name+"="+v.Value)
+		if v.Name == "CPX_OUTPUT_DIR" {
+			path = v.Value + string(filepath.ListSeparator) + path
+		}
+	}
+	env = append(env, "PATH="+path)
+
+	// Look the command up on the new PATH
+	if err := os.Setenv("PATH", path); err != nil {
+		return err
+	}
+	c := execCommand(args[0], args[1:]...)
+	c.Env = env
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	err = c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+	t.Setenv("PATH", os.Getenv("PATH"))
+
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('app', 'cpp')\n"), 0644))
+	binDir := filepath.Join(tmpDir, ".bin", "native", "release")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "app"), []byte("#!/bin/sh\necho \"$CPX_BUILD_SYSTEM $CPX_BUILD_DIR $1\" > out.txt\n"), 0755))
+
+	// Flags after the command are passed to it
+	cmd := ExecCmd()
+	cmd.SetArgs([]string{"--release", "app", "--release"})
+	require.NoError(t, cmd.Execute())
+
+	out, err := os.ReadFile("out.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "meson "+filepath.Join(tmpDir, "builddir")+" --release\n", string(out))
+}
@@ -9,9 +9,16 @@ func FlawfinderCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "flawfinder",
 		Short: "Run Flawfinder security analysis for C/C++",
-		Long:  "Run Flawfinder security analysis for C/C++. Scans C/C++ code for security vulnerabilities.",
-		RunE:  runFlawfinder,
-		Args:  cobra.ArbitraryArgs,
+		Long: `Run Flawfinder security analysis for C/C++. Scans C/C++ code for security vulnerabilities.
+
+--baseline records the current findings in .flawfinder-baseline.json. While
+that file exists, runs fail only on findings that are not in it, so the tool
+can be adopted on an existing codebase without fixing everything first.`,
+		Example: `  cpx flawfinder                # Scan the project
+  cpx flawfinder --baseline     # Accept the current findings
+  cpx flawfinder --minlevel 3`,
+		RunE: runFlawfinder,
+		Args: cobra.ArbitraryArgs,
 	}
 
 	cmd.Flags().Int("minlevel", 1, "Minimum risk level to report (0-5, default: 1)")
@@ -19,9 +26,10 @@ func FlawfinderCmd() *cobra.Command {
 	cmd.Flags().Bool("html", false, "Output results in HTML format")
 	cmd.Flags().String("output", "", "Output file path (required for HTML/CSV output)")
 	cmd.Flags().Bool("dataflow", false, "Enable dataflow analysis")
-	cmd.Flags().Bool("quiet", false, "Quiet mode (minimal output)")
 	cmd.Flags().Bool("singleline", false, "Single line output format")
 	cmd.Flags().Int("context", 2, "Number of lines of context to show")
+	cmd.Flags().Bool("baseline", false, "Record the current findings as the baseline")
+	cmd.Flags().String("baseline-file", quality.FlawfinderBaselineFile, "Baseline file")
 
 	return cmd
 }
@@ -35,6 +43,8 @@ func runFlawfinder(cmd *cobra.Command, args []string) error {
 	quiet, _ := cmd.Flags().GetBool("quiet")
 	singleline, _ := cmd.Flags().GetBool("singleline")
 	context, _ := cmd.Flags().GetInt("context")
+	baseline, _ := cmd.Flags().GetBool("baseline")
+	baselineFile, _ := cmd.Flags().GetString("baseline-file")
 
 	// Get remaining args as target directories/files (default to current directory)
 	targets := args
@@ -42,5 +52,17 @@ func runFlawfinder(cmd *cobra.Command, args []string) error {
 		targets = []string{"."}
 	}
 
-	return quality.RunFlawfinder(minLevel, csv, html, output, dataflow, quiet, singleline, context, targets)
+	return quality.RunFlawfinder(quality.FlawfinderOptions{
+		MinLevel:       minLevel,
+		CSV:            csv,
+		HTML:           html,
+		Output:         output,
+		Dataflow:       dataflow,
+		Quiet:          quiet,
+		SingleLine:     singleline,
+		Context:        context,
+		Targets:        targets,
+		Baseline:       baselineFile,
+		RecordBaseline: baseline,
+	})
 }
@@ -1,25 +1,156 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+
 	"github.com/ozacod/cpx/internal/pkg/quality"
+	"github.com/ozacod/cpx/internal/pkg/utils/git"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/spf13/cobra"
 )
 
 func FmtCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "fmt",
+		Use:     "fmt [ref]",
 		Aliases: []string{"format"},
-		Short:   "Format code with clang-format",
-		Long:    "Format code with clang-format. Use --check to verify formatting without modifying files.",
-		RunE:    runFmt,
+		Short:   "Format code with clang-format and build files",
+		Long: `Format code with clang-format. Use --check to verify formatting without modifying files.
+
+Build files are formatted too: CMakeLists.txt and *.cmake with cmake-format,
+BUILD.bazel, MODULE.bazel and *.bzl with buildifier, meson.build with meson
+format (Meson 1.5+). Kinds whose tool is not installed are skipped.
+
+--staged formats only the files staged for commit and stages the
+result again, which keeps pre-commit hooks fast on big repositories. Files
+that also have unstaged changes are formatted but left for you to stage.
+--diff formats only the files changed since ref (default HEAD).`,
+		Example: `  cpx fmt
+  cpx fmt --check
+  cpx fmt --staged
+  cpx fmt --diff main`,
+		Args: changedFilesArgs,
+		RunE: runFmt,
 	}
 
 	cmd.Flags().Bool("check", false, "Check formatting without modifying files")
+	addChangedFilesFlags(cmd)
 
 	return cmd
 }
 
-func runFmt(cmd *cobra.Command, _ []string) error {
+func runFmt(cmd *cobra.Command, args []string) error {
 	check, _ := cmd.Flags().GetBool("check")
-	return quality.FormatCode(check)
+	files, restricted, err := changedFiles(cmd, args, func(file string) bool {
+		return git.IsCppFile(file) || quality.IsBuildFile(file)
+	})
+	if err != nil || (restricted && len(files) == 0) {
+		return err
+	}
+	var code, buildFiles []string
+	if restricted {
+		for _, file := range files {
+			if quality.IsBuildFile(file) {
+				buildFiles = append(buildFiles, file)
+			} else {
+				code = append(code, file)
+			}
+		}
+	} else {
+		buildFiles = quality.FindBuildFiles()
+	}
+
+	staged, _ := cmd.Flags().GetBool("staged")
+	var unstaged []string
+	if staged && !check {
+		// Taken before formatting, which leaves its own unstaged changes
+		if unstaged, err = git.UnstagedFiles(); err != nil {
+			return err
+		}
+	}
+	var errs []error
+	if !restricted || len(code) > 0 {
+		errs = append(errs, quality.FormatCode(check, code))
+	}
+	if len(buildFiles) > 0 {
+		errs = append(errs, quality.FormatBuildFiles(check, buildFiles))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if staged && !check {
+		return restage(append(code, buildFiles...), unstaged)
+	}
+	return nil
+}
+
+// restage adds the formatted files back to the index, except those that
+// had unstaged changes, which would be committed by accident.
+func restage(files, unstaged []string) error {
+	var add []string
+	for _, file := range files {
+		if slices.Contains(unstaged, file) {
+			output.Warnf("%s has unstaged changes; stage its formatting yourself", file)
+		} else {
+			add = append(add, file)
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+	if out, err := execCommand("git", append([]string{"add", "--"}, add...)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage formatted files: %w: %s", err, out)
+	}
+	return nil
+}
+
+// addChangedFilesFlags adds the flags that restrict fmt and lint to the
+// files changed in git.
+func addChangedFilesFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("staged", false, "Only files staged for commit")
+	cmd.Flags().Bool("diff", false, "Only files changed since ref (default HEAD)")
+	cmd.MarkFlagsMutuallyExclusive("staged", "diff")
+}
+
+// changedFilesArgs accepts the ref of --diff.
+func changedFilesArgs(cmd *cobra.Command, args []string) error {
+	if diff, _ := cmd.Flags().GetBool("diff"); diff {
+		return cobra.MaximumNArgs(1)(cmd, args)
+	}
+	return cobra.NoArgs(cmd, args)
+}
+
+// changedFiles returns the files --staged or --diff restrict the command
+// to, those keep accepts. restricted is false when neither is given and the
+// whole project is processed; when none of the changed files is kept it
+// says so.
+func changedFiles(cmd *cobra.Command, args []string, keep func(string) bool) (files []string, restricted bool, err error) {
+	staged, _ := cmd.Flags().GetBool("staged")
+	diff, _ := cmd.Flags().GetBool("diff")
+	var changed []string
+	switch {
+	case staged:
+		changed, err = git.StagedFiles()
+	case diff:
+		ref := "HEAD"
+		if len(args) > 0 {
+			ref = args[0]
+		}
+		changed, err = git.ChangedFiles(ref)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	for _, file := range changed {
+		if keep(file) {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		output.Successf("No changed files to check")
+	}
+	return files, true, nil
 }
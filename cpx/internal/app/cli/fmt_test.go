@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestage(t *testing.T) {
+	t.Chdir(t.TempDir())
+	git := func(args ...string) string {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile("a.cpp", []byte("int a;\n"), 0644))
+	require.NoError(t, os.WriteFile("b.cpp", []byte("int b;\n"), 0644))
+	git("add", ".")
+
+	// Formatting changed both; b.cpp had unstaged work before it
+	require.NoError(t, os.WriteFile("a.cpp", []byte("int a; // formatted\n"), 0644))
+	require.NoError(t, os.WriteFile("b.cpp", []byte("int b; // formatted\nint wip;\n"), 0644))
+	require.NoError(t, restage([]string{"a.cpp", "b.cpp"}, []string{"b.cpp"}))
+
+	assert.Equal(t, "b.cpp", strings.TrimSpace(git("diff", "--name-only")))
+	assert.Equal(t, "", strings.TrimSpace(git("diff", "--name-only", "--", "a.cpp")))
+}
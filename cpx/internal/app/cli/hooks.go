@@ -1,7 +1,12 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/ozacod/cpx/internal/pkg/utils/git"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -19,15 +24,74 @@ func HooksCmd() *cobra.Command {
 	installCmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install git hooks",
-		Long:  "Install git hooks with default configuration (fmt, lint for pre-commit; test for pre-push).",
-		RunE:  runHooksInstall,
+		Long: `Install git hooks with default configuration (fmt, lint for pre-commit; test for pre-push).
+
+--commit-msg also installs a commit-msg hook that rejects messages that are
+not Conventional Commits ("feat(parser): support arrays"). The accepted
+types and scopes can be narrowed in cpx-ci.yaml:
+
+  commit_msg:
+    types: [feat, fix, docs, chore]
+    scopes: [core, cli]`,
+		Example: `  cpx hooks install
+  cpx hooks install --commit-msg
+  cpx hooks install --pre-commit fmt,cppcheck --pre-push test`,
+		RunE: runHooksInstall,
 	}
+	installCmd.Flags().StringSlice("pre-commit", []string{"fmt", "lint"}, "Pre-commit checks: fmt, lint, cppcheck, flawfinder, check, test")
+	installCmd.Flags().StringSlice("pre-push", []string{"test"}, "Pre-push checks: test, lint, cppcheck, flawfinder, check")
+	installCmd.Flags().Bool("commit-msg", false, "Install a commit-msg hook that validates Conventional Commits")
 	cmd.AddCommand(installCmd)
 
+	commitMsgCmd := &cobra.Command{
+		Use:   "commit-msg <file>",
+		Short: "Check a commit message against the Conventional Commits rules",
+		Long: `Check the commit message in file, as the commit-msg hook does, against the
+types and scopes in cpx-ci.yaml (default: any scope and the standard types
+feat, fix, docs, style, refactor, perf, test, build, ci, chore, revert).`,
+		Args: cobra.ExactArgs(1),
+		RunE: runHooksCommitMsg,
+	}
+	cmd.AddCommand(commitMsgCmd)
+
 	return cmd
 }
 
-func runHooksInstall(_ *cobra.Command, _ []string) error {
-	// Use default hooks - no cpx.yaml needed
-	return git.InstallHooksWithConfig([]string{"fmt", "lint"}, []string{"test"})
+func runHooksInstall(cmd *cobra.Command, _ []string) error {
+	preCommit, _ := cmd.Flags().GetStringSlice("pre-commit")
+	prePush, _ := cmd.Flags().GetStringSlice("pre-push")
+	commitMsg, _ := cmd.Flags().GetBool("commit-msg")
+	return git.InstallHooksWithConfig(preCommit, prePush, commitMsg)
+}
+
+func runHooksCommitMsg(_ *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read commit message: %w", err)
+	}
+	rules, err := commitMsgRules()
+	if err != nil {
+		return err
+	}
+	return git.CheckCommitMessage(string(data), rules.Types, rules.Scopes)
+}
+
+// commitMsgRules returns the commit_msg settings of the project's
+// cpx-ci.yaml, empty when there are none.
+func commitMsgRules() (config.CommitMsgConfig, error) {
+	root, err := findProjectRoot()
+	if err != nil {
+		return config.CommitMsgConfig{}, nil
+	}
+	ciConfig, err := config.LoadToolchains(filepath.Join(root, "cpx-ci.yaml"))
+	if os.IsNotExist(err) {
+		return config.CommitMsgConfig{}, nil
+	}
+	if err != nil {
+		return config.CommitMsgConfig{}, err
+	}
+	if ciConfig.CommitMsg == nil {
+		return config.CommitMsgConfig{}, nil
+	}
+	return *ciConfig.CommitMsg, nil
 }
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooksCommitMsg(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.WriteFile("CMakeLists.txt", nil, 0644))
+	msgFile := filepath.Join(dir, "COMMIT_EDITMSG")
+
+	check := func(msg string) error {
+		require.NoError(t, os.WriteFile(msgFile, []byte(msg), 0644))
+		return runHooksCommitMsg(nil, []string{msgFile})
+	}
+
+	assert.NoError(t, check("docs(anything): explain the cache"))
+	assert.Error(t, check("Explain the cache"))
+
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte("commit_msg:\n  types: [feat, fix]\n  scopes: [core]\n"), 0644))
+	assert.NoError(t, check("feat(core): add arrays"))
+	assert.Error(t, check("docs(core): explain the cache"))
+	assert.Error(t, check("feat(cli): add a flag"))
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/ide"
+	"github.com/ozacod/cpx/internal/pkg/quality"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// IdeCmd creates the ide command
+func IdeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ide",
+		Short: "Generate editor and IDE settings for the project",
+		Long:  "Generate editor and IDE settings that use the project's cpx build.",
+	}
+
+	vscodeCmd := &cobra.Command{
+		Use:   "vscode",
+		Short: "Write VS Code settings: IntelliSense, build tasks and debug configurations",
+		Long: `Write the .vscode settings of the project:
+
+   c_cpp_properties.json - IntelliSense reads compile_commands.json
+   tasks.json            - cpx build, build --release, test, run and clean
+   launch.json           - a gdb or lldb debug configuration per built executable,
+                           which builds first
+
+Entries cpx wrote before, named "cpx: ...", are replaced; your own entries
+are kept. Executables are found in .bin/native/debug, so run 'cpx build'
+first, and 'cpx compdb' if there is no compile_commands.json yet.`,
+		Example: `  cpx ide vscode
+  cpx ide vscode --debugger lldb`,
+		Args: cobra.NoArgs,
+		RunE: runIdeVSCode,
+	}
+	vscodeCmd.Flags().String("debugger", defaultDebugger(), "Debugger for launch configurations: gdb or lldb")
+	cmd.AddCommand(vscodeCmd)
+
+	clangdCmd := &cobra.Command{
+		Use:   "clangd",
+		Short: "Write a .clangd file matching cpx lint",
+		Long: `Write the .clangd file of the project, so editor diagnostics match 'cpx lint':
+
+   CompilationDatabase - the directory with compile_commands.json
+   Add                 - the project's C++ standard, vcpkg's installed headers and
+                         the compiler's system headers, as cpx lint passes them
+   Remove              - GCC-only flags clangd rejects
+
+clangd also reads the project's .clang-tidy, as cpx lint does. A .clangd that
+cpx did not write is only replaced with --force.`,
+		Example: `  cpx ide clangd
+  cpx ide clangd --force`,
+		Args: cobra.NoArgs,
+		RunE: runIdeClangd,
+	}
+	clangdCmd.Flags().Bool("force", false, "Replace a .clangd that cpx did not write")
+	cmd.AddCommand(clangdCmd)
+
+	clionCmd := &cobra.Command{
+		Use:   "clion",
+		Short: "Write CLion CMake profiles that share cpx's build directories",
+		Long: `Write CMake profiles to .idea/cmake.xml for CLion: "cpx: debug" and
+"cpx: release" configure in .cache/native/debug and .cache/native/release
+with the toolchain file, vcpkg_installed directory, generator and flags
+cpx build uses, so CLion and cpx build the same trees instead of two.
+
+Profiles cpx wrote before are replaced; your own are kept. Only CMake
+(vcpkg) projects are supported.`,
+		Example: `  cpx ide clion`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return writeCMakeProfiles("cpx ide clion", ide.WriteCLion)
+		},
+	}
+	cmd.AddCommand(clionCmd)
+
+	qtCreatorCmd := &cobra.Command{
+		Use:   "qtcreator",
+		Short: "Write CMake user presets that share cpx's build directories",
+		Long: `Write cpx-debug and cpx-release presets to CMakeUserPresets.json, which Qt
+Creator (and CLion and Visual Studio) offer as build configurations. They
+configure in .cache/native/debug and .cache/native/release with the
+toolchain file, vcpkg_installed directory, generator and flags cpx build
+uses; "cmake --preset cpx-debug" works from the command line too.
+
+CMakeUserPresets.json holds machine-specific paths: keep it out of version
+control. Presets cpx wrote before are replaced; your own are kept. Only
+CMake (vcpkg) projects are supported.`,
+		Example: `  cpx ide qtcreator`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return writeCMakeProfiles("cpx ide qtcreator", ide.WriteUserPresets)
+		},
+	}
+	cmd.AddCommand(qtCreatorCmd)
+
+	return cmd
+}
+
+// defaultDebugger is lldb on macOS, where gdb is rarely installed, and gdb
+// elsewhere
+func defaultDebugger() string {
+	if runtime.GOOS == "darwin" {
+		return "lldb"
+	}
+	return "gdb"
+}
+
+func runIdeVSCode(cmd *cobra.Command, _ []string) error {
+	if _, err := RequireProject("cpx ide vscode"); err != nil {
+		return err
+	}
+	debugger, _ := cmd.Flags().GetString("debugger")
+	if debugger != "gdb" && debugger != "lldb" {
+		return fmt.Errorf("unknown debugger %q (use gdb or lldb)", debugger)
+	}
+
+	compileDb := quality.FindCompileDatabase()
+	if compileDb == "" {
+		compileDb = "compile_commands.json"
+		output.Warnf("compile_commands.json not found; run 'cpx compdb' for IntelliSense to work")
+	}
+	executables, err := builtExecutables()
+	if err != nil {
+		return err
+	}
+	if len(executables) == 0 {
+		output.Warnf("No executables in %s; run 'cpx build' and then 'cpx ide vscode' again for debug configurations", debugOutputDir())
+	}
+
+	written, err := ide.WriteVSCode(".", ide.VSCodeOptions{
+		CompileDatabase: compileDb,
+		Executables:     executables,
+		Debugger:        debugger,
+	})
+	for _, file := range written {
+		output.Successf("✓ Wrote %s", file)
+	}
+	return err
+}
+
+func runIdeClangd(cmd *cobra.Command, _ []string) error {
+	projectType, err := RequireProject("cpx ide clangd")
+	if err != nil {
+		return err
+	}
+	force, _ := cmd.Flags().GetBool("force")
+
+	compileDbDir := "."
+	if compileDb := quality.FindCompileDatabase(); compileDb != "" {
+		compileDbDir = filepath.Dir(compileDb)
+	} else {
+		output.Warnf("compile_commands.json not found; run 'cpx compdb' for clangd to work")
+	}
+
+	var includes []string
+	if projectType == ProjectTypeVcpkg {
+		if installedDir, err := vcpkg.New().InstalledDir(); err == nil {
+			includes = ide.VcpkgIncludeDirs(installedDir)
+		}
+	}
+	includes = append(includes, quality.GetSystemIncludePaths()...)
+
+	path, err := ide.WriteClangd(".", ide.ClangdOptions{
+		CompilationDatabase: compileDbDir,
+		CppStandard:         ide.DetectCppStandard("."),
+		SystemIncludes:      includes,
+	}, force)
+	if err != nil {
+		return err
+	}
+	output.Successf("✓ Wrote %s", path)
+	return nil
+}
+
+// writeCMakeProfiles writes the debug and release CMake configurations of
+// cpx build with write.
+func writeCMakeProfiles(cmdName string, write func(root string, profiles []vcpkg.CMakeProfile) (string, error)) error {
+	projectType, err := RequireProject(cmdName)
+	if err != nil {
+		return err
+	}
+	if projectType != ProjectTypeVcpkg {
+		return fmt.Errorf("%s requires a CMake (vcpkg) project", cmdName)
+	}
+
+	builder := vcpkg.New()
+	var profiles []vcpkg.CMakeProfile
+	for _, release := range []bool{false, true} {
+		profile, err := builder.CMakeProfile(release, "", "")
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, profile)
+	}
+	path, err := write(".", profiles)
+	if err != nil {
+		return err
+	}
+	output.Successf("✓ Wrote %s", path)
+	return nil
+}
+
+// debugOutputDir is where cpx build leaves debug executables
+func debugOutputDir() string {
+	return filepath.Join(config.BinDir(), "native", "debug")
+}
+
+// builtExecutables returns the executables of the last debug build.
+func builtExecutables() ([]string, error) {
+	return artifacts.Find(artifacts.Rule{Dir: debugOutputDir(), Executables: true})
+}
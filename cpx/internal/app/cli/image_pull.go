@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ozacod/cpx/internal/pkg/container"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// loggedIn remembers the registries cpx logged in to, so toolchains sharing
+// a registry log in once.
+var loggedIn sync.Map
+
+// registryLogin logs rt in to the registry of image with the runner's
+// credentials. Runners without credentials rely on docker login.
+func registryLogin(rt container.Runtime, runner *config.Runner, image string) error {
+	auth := runner.Registry
+	if auth == nil {
+		return nil
+	}
+	server := cmp.Or(auth.Server, container.Registry(image))
+	key := rt.Name() + "\x00" + server + "\x00" + auth.Username
+	if _, ok := loggedIn.Load(key); ok {
+		return nil
+	}
+	password := os.Getenv(auth.PasswordEnv)
+	if password == "" {
+		return fmt.Errorf("runner '%s' logs in to %s with the password in $%s, which is not set", runner.Name, server, auth.PasswordEnv)
+	}
+	if err := rt.Login(server, auth.Username, password); err != nil {
+		return err
+	}
+	loggedIn.Store(key, true)
+	return nil
+}
+
+// pullRunnerImage makes the image of a docker runner available according to
+// its pull policy, logging in to the registry first. refresh pulls even an
+// existing image, as --rebuild does.
+func pullRunnerImage(rt container.Runtime, runner *config.Runner, image string, refresh bool) error {
+	pull := cmp.Or(runner.Pull, "missing")
+	if refresh && pull != "never" {
+		pull = "always"
+	}
+	exists := rt.ImageExists(image)
+	switch {
+	case pull == "never" && !exists:
+		return fmt.Errorf("Docker image '%s' not found locally. Use '%s pull %s' to download it first, or remove pull: never from runner '%s'", image, rt.Name(), image, runner.Name)
+	case pull == "never", pull == "missing" && exists:
+		return nil
+	}
+
+	if err := registryLogin(rt, runner, image); err != nil {
+		return err
+	}
+	fmt.Fprintf(output.Stdout(), "  %s Pulling Docker image %s...%s\n", colors.Cyan, image, colors.Reset)
+	if err := rt.Pull(image, runner.Platform, os.Stdout); err != nil {
+		if exists {
+			// keep building with the image we have, e.g. offline
+			fmt.Fprintf(output.Stdout(), "  %s Could not refresh %s, using the local image%s\n", colors.Yellow, image, colors.Reset)
+			return nil
+		}
+		return fmt.Errorf("%w\n  hint: for a private registry, run '%s login' or set registry credentials on runner '%s'", err, rt.Name(), runner.Name)
+	}
+	return nil
+}
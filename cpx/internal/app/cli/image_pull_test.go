@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+func TestPullRunnerImage(t *testing.T) {
+	oldExecCommand, oldExecLookPath := execCommand, execLookPath
+	t.Cleanup(func() { execCommand, execLookPath = oldExecCommand, oldExecLookPath })
+	execLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	loggedIn.Clear()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+
+	exists, pullFails := false, false
+	var calls []string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		call := name + " " + strings.Join(arg, " ")
+		calls = append(calls, call)
+		switch {
+		case strings.HasPrefix(call, "docker images -q") && exists:
+			return exec.Command("echo", "0123456789ab")
+		case strings.HasPrefix(call, "docker images -q"), strings.HasPrefix(call, "docker pull") && pullFails:
+			return exec.Command("false")
+		}
+		return exec.Command("true")
+	}
+	rt := containerRuntime()
+	image := "ghcr.io/acme/gcc:14"
+
+	// missing images are pulled for the runner's platform, after logging in
+	t.Setenv("GHCR_TOKEN", "secret")
+	runner := &config.Runner{Name: "gcc", Type: "docker", Image: image, Platform: "linux/arm64",
+		Registry: &config.RegistryAuth{Username: "bot", PasswordEnv: "GHCR_TOKEN"}}
+	require.NoError(t, pullRunnerImage(rt, runner, image, false))
+	assert.Equal(t, []string{
+		"docker images -q " + image,
+		"docker login ghcr.io --username bot --password-stdin",
+		"docker pull --platform linux/arm64 " + image,
+	}, calls)
+
+	// existing images are used, unless refreshed; the login is remembered
+	exists, calls = true, nil
+	require.NoError(t, pullRunnerImage(rt, runner, image, false))
+	require.NoError(t, pullRunnerImage(rt, runner, image, true))
+	assert.Equal(t, []string{"docker images -q " + image, "docker images -q " + image, "docker pull --platform linux/arm64 " + image}, calls)
+
+	// a failed refresh falls back to the local image
+	pullFails = true
+	require.NoError(t, pullRunnerImage(rt, &config.Runner{Name: "gcc", Pull: "always"}, "gcc:14", false))
+	exists = false
+	assert.ErrorContains(t, pullRunnerImage(rt, &config.Runner{Name: "gcc"}, "gcc:14", false), "hint: for a private registry, run 'docker login'")
+
+	// pull: never leaves it to the user
+	calls = nil
+	err := pullRunnerImage(rt, &config.Runner{Name: "gcc", Pull: "never"}, "gcc:14", true)
+	assert.ErrorContains(t, err, "Use 'docker pull gcc:14' to download it first, or remove pull: never from runner 'gcc'")
+	assert.Len(t, calls, 1)
+
+	t.Setenv("QUAY_TOKEN", "")
+	err = pullRunnerImage(rt, &config.Runner{Name: "quay", Registry: &config.RegistryAuth{Username: "bot", PasswordEnv: "QUAY_TOKEN"}}, "quay.io/acme/gcc", false)
+	assert.ErrorContains(t, err, "runner 'quay' logs in to quay.io with the password in $QUAY_TOKEN, which is not set")
+}
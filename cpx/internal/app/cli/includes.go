@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/includes"
+	"github.com/ozacod/cpx/internal/pkg/profile"
+	"github.com/ozacod/cpx/internal/pkg/quality"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/spf13/cobra"
+)
+
+// IncludesCmd creates the includes command
+func IncludesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "includes",
+		Short: "Analyze the include graph for cycles and expensive headers",
+		Long: `Build the include graph of the project from compile_commands.json and the
+headers it reaches, report include cycles and rank headers by their cost:
+the number of translation units that include them times their size. Trimming
+the includes of the top headers usually shortens builds the most.
+
+Run 'cpx build' first to generate the compile database. Conditional includes
+are counted as taken; headers that are not on the include path, such as the
+standard library, are not followed.`,
+		Example: `  cpx includes                     # Report cycles and the most expensive headers
+  cpx includes --graph out.dot     # Also write the graph (dot -Tsvg out.dot -o out.svg)
+  cpx includes --top 30 --external`,
+		RunE: runIncludes,
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().String("graph", "", "Write the include graph in Graphviz DOT format to this file")
+	cmd.Flags().Int("top", 15, "Number of expensive headers to list")
+	cmd.Flags().String("compile-db", "", "compile_commands.json to read (default: found in the build directories)")
+	cmd.Flags().Bool("external", false, "Include headers outside the project (dependencies) in the graph")
+
+	return cmd
+}
+
+func runIncludes(cmd *cobra.Command, _ []string) error {
+	graphFile, _ := cmd.Flags().GetString("graph")
+	top, _ := cmd.Flags().GetInt("top")
+	compileDb, _ := cmd.Flags().GetString("compile-db")
+	external, _ := cmd.Flags().GetBool("external")
+
+	if compileDb == "" {
+		compileDb = quality.FindCompileDatabase()
+		if compileDb == "" {
+			return fmt.Errorf("compile_commands.json not found\n  hint: run 'cpx build' first, or pass --compile-db")
+		}
+	}
+	cmds, err := includes.LoadCompileDatabase(compileDb)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Analyzing includes of %s...%s\n", colors.Cyan, compileDb, colors.Reset)
+	graph, err := includes.Build(cmds, ".")
+	if err != nil {
+		return err
+	}
+	headers := graph.Headers()
+	edges := 0
+	for _, tos := range graph.Edges {
+		edges += len(tos)
+	}
+	fmt.Printf("   %d translation units, %d headers, %d include edges", len(graph.Units), len(headers), edges)
+	if graph.Unresolved > 0 {
+		fmt.Printf(" (%d system or unresolved includes not followed)", graph.Unresolved)
+	}
+	fmt.Println()
+
+	cycles := graph.Cycles()
+	if len(cycles) > 0 {
+		fmt.Printf("\n%s Include cycles (%d):%s\n", colors.Yellow, len(cycles), colors.Reset)
+		for _, cycle := range cycles {
+			names := make([]string, len(cycle))
+			for i, path := range cycle {
+				names[i] = graph.Display(path)
+			}
+			fmt.Printf("   %s\n", strings.Join(names, " -> "))
+		}
+	} else {
+		fmt.Printf("%s No include cycles%s\n", colors.Green, colors.Reset)
+	}
+
+	if len(headers) > 0 && top > 0 {
+		fmt.Printf("\n%s Most expensive headers (translation units x size):%s\n", colors.Cyan, colors.Reset)
+		fmt.Printf("   %10s  %10s  %5s  %s\n", "COST", "SIZE", "TUS", "HEADER")
+		for _, h := range headers[:min(top, len(headers))] {
+			name := graph.Display(h.Path)
+			if h.External {
+				name = colors.Gray + name + " (external)" + colors.Reset
+			}
+			fmt.Printf("   %10s  %10s  %5d  %s\n", profile.FormatBytes(h.Cost()), profile.FormatBytes(h.Size), h.Included, name)
+		}
+	}
+
+	if graphFile != "" {
+		f, err := os.Create(graphFile)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", graphFile, err)
+		}
+		defer f.Close()
+		if err := graph.WriteDOT(f, external); err != nil {
+			return fmt.Errorf("failed to write %s: %w", graphFile, err)
+		}
+		fmt.Printf("\n%s Include graph written to %s%s\n", colors.Green, graphFile, colors.Reset)
+	}
+	return nil
+}
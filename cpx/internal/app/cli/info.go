@@ -2,9 +2,7 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/ozacod/cpx/internal/pkg/build/bazel"
@@ -19,15 +17,13 @@ func InfoCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "info <package>",
 		Short: "Show detailed library information",
-		Long:  "Show detailed library information for a vcpkg package.",
+		Long:  "Show detailed library information for a vcpkg package, Bazel module or Meson wrap.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runInfo(cmd, args)
 		},
 		Args: cobra.MinimumNArgs(1),
 	}
 
-	cmd.Flags().Bool("json", false, "Output in JSON format")
-
 	return cmd
 }
 
@@ -49,7 +45,6 @@ type PackageInfo struct {
 }
 
 func runInfo(cmd *cobra.Command, args []string) error {
-	jsonOutput, _ := cmd.Flags().GetBool("json")
 	packageName := args[0]
 
 	projectType := DetectProjectType()
@@ -72,10 +67,8 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if jsonOutput {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(info)
+	if jsonOutput(cmd) {
+		return printJSON(info)
 	}
 
 	// Print formatted output
@@ -97,6 +90,16 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		fmt.Printf("%s📄 License:%s  %s\n", colors.Bold, colors.Reset, info.License)
 	}
 
+	if len(info.Versions) > 0 {
+		versions := info.Versions
+		suffix := ""
+		if len(versions) > 5 {
+			suffix = fmt.Sprintf(" (+%d more)", len(versions)-5)
+			versions = versions[:5]
+		}
+		fmt.Printf("%s🏷  Versions:%s %s%s\n", colors.Bold, colors.Reset, strings.Join(versions, ", "), suffix)
+	}
+
 	// Dependencies
 	if len(info.Dependencies) > 0 {
 		fmt.Printf("\n%s📚 Dependencies:%s\n", colors.Bold, colors.Reset)
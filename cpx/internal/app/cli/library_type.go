@@ -0,0 +1,26 @@
+package cli
+
+import (
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/spf13/cobra"
+)
+
+// addLibraryTypeFlags registers the --shared and --static flags, which
+// choose the type of the project's libraries.
+func addLibraryTypeFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().Bool("shared", false, verb+" libraries as shared libraries")
+	cmd.Flags().Bool("static", false, verb+" libraries as static libraries")
+	cmd.MarkFlagsMutuallyExclusive("shared", "static")
+}
+
+// libraryTypeFromFlags returns the library type chosen with --shared or
+// --static, or "" for the project's default.
+func libraryTypeFromFlags(cmd *cobra.Command) string {
+	if shared, _ := cmd.Flags().GetBool("shared"); shared {
+		return build.LibraryShared
+	}
+	if static, _ := cmd.Flags().GetBool("static"); static {
+		return build.LibraryStatic
+	}
+	return ""
+}
@@ -1,27 +1,83 @@
 package cli
 
 import (
+	"fmt"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
 	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
 	"github.com/ozacod/cpx/internal/pkg/quality"
+	"github.com/ozacod/cpx/internal/pkg/utils/git"
 	"github.com/spf13/cobra"
 )
 
 func LintCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "lint",
+		Use:   "lint [ref]",
 		Short: "Run clang-tidy static analysis",
-		Long:  "Run clang-tidy static analysis. Use --fix to automatically fix issues.",
+		Long: `Run clang-tidy static analysis. Use --fix to automatically fix issues.
+
+In a terminal, --fix shows each proposed fix-it as a diff to accept or
+reject before any file is written; --no-review applies them all, as it
+always happens when stdin is not a terminal.
+
+--staged lints only the C/C++ files staged for commit and --diff only the
+files changed since ref (default HEAD), e.g. the base branch of a pull
+request.`,
+		Example: `  cpx lint
+  cpx lint --fix
+  cpx lint --staged
+  cpx lint --diff origin/main`,
+		Args: changedFilesArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runLint(cmd, args)
 		},
 	}
 
 	cmd.Flags().Bool("fix", false, "Automatically fix issues")
+	cmd.Flags().Bool("no-review", false, "With --fix, apply every fix without reviewing them")
+	addChangedFilesFlags(cmd)
 
 	return cmd
 }
 
 func runLint(cmd *cobra.Command, args []string) error {
 	fix, _ := cmd.Flags().GetBool("fix")
-	return quality.LintCode(fix, vcpkg.New())
+	files, restricted, err := changedFiles(cmd, args, git.IsCppFile)
+	if err != nil || (restricted && len(files) == 0) {
+		return err
+	}
+	opts := quality.LintOptions{Fix: fix, Files: files}
+	if noReview, _ := cmd.Flags().GetBool("no-review"); fix && !noReview && stdinIsTerminal() {
+		opts.Review = reviewFixes
+	}
+	return quality.LintCode(opts, vcpkg.New())
+}
+
+// reviewFixes shows the fixes clang-tidy proposes one by one and returns
+// those the user accepts.
+func reviewFixes(fixes []quality.Fix) ([]quality.Fix, error) {
+	items := make([]tui.FixItem, len(fixes))
+	for i, f := range fixes {
+		diff, err := f.Diff()
+		if err != nil {
+			diff = []string{fmt.Sprintf("(cannot show the change: %v)", err)}
+		}
+		items[i] = tui.FixItem{
+			Check:    f.Check,
+			Message:  f.Message,
+			Location: f.Location(),
+			Diff:     diff,
+		}
+	}
+	accepted, err := tui.RunFixReview(items)
+	if err != nil {
+		return nil, err
+	}
+	var out []quality.Fix
+	for i, ok := range accepted {
+		if ok {
+			out = append(out, fixes[i])
+		}
+	}
+	return out, nil
 }
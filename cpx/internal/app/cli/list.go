@@ -9,6 +9,7 @@ import (
 	"github.com/ozacod/cpx/internal/pkg/build/meson"
 	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/spf13/cobra"
 )
 
@@ -28,39 +29,50 @@ func ListCmd() *cobra.Command {
 	return cmd
 }
 
-func runList(cmd *cobra.Command, _ []string) error {
-	projectType := DetectProjectType()
-	var builder build.BuildSystem
+// TargetsCmd creates the targets command, a shorthand for "list --targets"
+func TargetsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "targets",
+		Short: "List build targets",
+		Long:  "List the build targets of the project.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			builder, err := projectBuilder()
+			if err != nil {
+				return err
+			}
+			return listTargets(cmd, builder)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	return cmd
+}
 
+// projectBuilder returns the build system of the project in the current directory.
+func projectBuilder() (build.BuildSystem, error) {
+	projectType := DetectProjectType()
 	switch projectType {
 	case ProjectTypeBazel:
-		builder = bazel.New()
+		return bazel.New(), nil
 	case ProjectTypeMeson:
-		builder = meson.New()
+		return meson.New(), nil
 	case ProjectTypeVcpkg:
-		builder = vcpkg.New()
+		return vcpkg.New(), nil
 	default:
-		return fmt.Errorf("unknown project type: %s", projectType)
+		return nil, fmt.Errorf("unknown project type: %s", projectType)
+	}
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	builder, err := projectBuilder()
+	if err != nil {
+		return err
 	}
 
 	showTargets, _ := cmd.Flags().GetBool("targets")
 
 	if showTargets {
-		targets, err := builder.ListTargets(context.Background())
-		if err != nil {
-			return fmt.Errorf("failed to list targets: %w", err)
-		}
-
-		if len(targets) == 0 {
-			fmt.Printf("No targets found for %s.\n", builder.Name())
-			return nil
-		}
-
-		fmt.Printf("%sTargets (%s):%s\n", colors.Cyan, builder.Name(), colors.Reset)
-		for _, t := range targets {
-			fmt.Printf("  %s\n", t)
-		}
-		return nil
+		return listTargets(cmd, builder)
 	}
 
 	// List dependencies (default)
@@ -68,20 +80,55 @@ func runList(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to list dependencies: %w", err)
 	}
+	if root, err := findProjectRoot(); err == nil {
+		deps = withSubmodules(root, deps)
+	}
+
+	if jsonOutput(cmd) {
+		if deps == nil {
+			deps = []build.Dependency{}
+		}
+		return printJSON(deps)
+	}
 
 	if len(deps) == 0 {
-		fmt.Println("No dependencies found.")
+		output.Infof("No dependencies found.")
 		return nil
 	}
 
-	fmt.Printf("%sDependencies (%s):%s\n", colors.Cyan, builder.Name(), colors.Reset)
+	fmt.Fprintf(output.Stdout(), "%sDependencies (%s):%s\n", colors.Cyan, builder.Name(), colors.Reset)
 	for _, dep := range deps {
 		version := dep.Version
 		if version == "" {
 			version = "latest/unknown"
 		}
-		fmt.Printf("  - %s%s%s @ %s%s%s\n", colors.Green, dep.Name, colors.Reset, colors.Yellow, version, colors.Reset)
+		fmt.Fprintf(output.Stdout(), "  - %s%s%s @ %s%s%s\n", colors.Green, dep.Name, colors.Reset, colors.Yellow, version, colors.Reset)
 	}
 
 	return nil
 }
+
+func listTargets(cmd *cobra.Command, builder build.BuildSystem) error {
+	targets, err := builder.ListTargets(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list targets: %w", err)
+	}
+
+	if jsonOutput(cmd) {
+		if targets == nil {
+			targets = []string{}
+		}
+		return printJSON(targets)
+	}
+
+	if len(targets) == 0 {
+		output.Infof("No targets found for %s.", builder.Name())
+		return nil
+	}
+
+	fmt.Fprintf(output.Stdout(), "%sTargets (%s):%s\n", colors.Cyan, builder.Name(), colors.Reset)
+	for _, t := range targets {
+		fmt.Fprintf(output.Stdout(), "  %s\n", t)
+	}
+	return nil
+}
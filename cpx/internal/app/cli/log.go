@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	"github.com/ozacod/cpx/internal/pkg/build/stats"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// followInterval is how often --follow checks a log for new output.
+var followInterval = 200 * time.Millisecond
+
+// LogCmd creates the log command
+func LogCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log [file]",
+		Short: "Show build logs",
+		Long: `Show the captured output of configure, build and test steps.
+
+Every build, test, run and bench writes its full output to a timestamped
+file under .cache/logs, even when the progress bar hides it. Toolchain
+builds get one log per toolchain.`,
+		Example: `  cpx log                        # Show the last log
+  cpx log --list                 # List logs with their status
+  cpx log --toolchain linux-gcc  # Show the last log of a toolchain
+  cpx log --grep "error:"        # Show matching lines
+  cpx log --tail 50              # Show the last 50 lines
+  cpx log --follow               # Follow a running build`,
+		RunE: runLog,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.Flags().Bool("last", false, "Show the most recent log (default)")
+	cmd.Flags().String("toolchain", "", "Show the most recent log of a toolchain")
+	cmd.Flags().Bool("list", false, "List available logs")
+	cmd.Flags().String("grep", "", "Only show lines matching a regular expression")
+	cmd.Flags().IntP("tail", "n", 0, "Only show the last N lines")
+	cmd.Flags().BoolP("follow", "f", false, "Keep showing new output until the command finishes")
+
+	_ = cmd.RegisterFlagCompletionFunc("toolchain", completeToolchains)
+
+	return cmd
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	toolchain, _ := cmd.Flags().GetString("toolchain")
+	list, _ := cmd.Flags().GetBool("list")
+	pattern, _ := cmd.Flags().GetString("grep")
+	tail, _ := cmd.Flags().GetInt("tail")
+	follow, _ := cmd.Flags().GetBool("follow")
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	logDir := buildlog.Dir(projectRoot)
+	entries, err := buildlog.List(logDir)
+	if err != nil {
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+
+	if list {
+		printLogList(os.Stdout, entries)
+		return nil
+	}
+
+	var path string
+	switch {
+	case len(args) == 1:
+		path = args[0]
+		if _, err := os.Stat(path); err != nil {
+			path = filepath.Join(logDir, args[0])
+		}
+	case toolchain != "":
+		for _, e := range entries {
+			if e.Toolchain == toolchain {
+				path = e.Path
+				break
+			}
+		}
+		if path == "" {
+			return fmt.Errorf("no logs for toolchain '%s'\n  hint: run 'cpx log --list' to see available logs", toolchain)
+		}
+	default:
+		if len(entries) == 0 {
+			return fmt.Errorf("no build logs in %s\n  hint: logs are written by cpx build, test, run and bench", logDir)
+		}
+		path = entries[0].Path
+	}
+
+	var re *regexp.Regexp
+	if pattern != "" {
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s==> %s <==%s\n", colors.Gray, path, colors.Reset)
+	offset, err := printLog(os.Stdout, path, re, tail)
+	if err != nil {
+		return err
+	}
+	if follow {
+		return followLog(os.Stdout, path, re, offset)
+	}
+	return nil
+}
+
+// printLogList prints one line per log: time, command, toolchain and status.
+func printLogList(w io.Writer, entries []buildlog.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No build logs found.")
+		return
+	}
+	for _, e := range entries {
+		status := colors.Yellow + "running" + colors.Reset
+		switch {
+		case e.Status == "ok":
+			status = colors.Green + "ok" + colors.Reset
+		case e.Status != "":
+			status = colors.Red + "failed" + colors.Reset
+		}
+		name := e.Command
+		if e.Toolchain != "" {
+			name += " " + e.Toolchain
+		}
+		fmt.Fprintf(w, "  %s  %-30s %-8s %s%s%s\n", e.Time.Format("2006-01-02 15:04:05"), name, status,
+			colors.Gray, filepath.Base(e.Path), colors.Reset)
+	}
+}
+
+// printLog writes the log at path, optionally filtered by re and limited to
+// the last tail lines, and returns the offset it read up to.
+func printLog(w io.Writer, path string, re *regexp.Regexp, tail int) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+
+	var lines []string
+	var offset int64
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" && strings.HasSuffix(line, "\n") {
+			offset += int64(len(line))
+			line = strings.TrimSuffix(line, "\n")
+			if re == nil || re.MatchString(line) {
+				lines = append(lines, line)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	return offset, nil
+}
+
+// followLog prints lines appended to the log after offset until the
+// command that writes it finishes.
+func followLog(w io.Writer, path string, re *regexp.Regexp, offset int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(f)
+	var partial string
+	for {
+		line, err := r.ReadString('\n')
+		partial += line
+		if err == nil {
+			text := strings.TrimSuffix(partial, "\n")
+			partial = ""
+			if re == nil || re.MatchString(text) {
+				fmt.Fprintln(w, text)
+			}
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		if buildlog.Finished(path) {
+			return nil
+		}
+		time.Sleep(followInterval)
+	}
+}
+
+// withBuildLog runs fn with its output captured to a build log. Toolchain
+// builds are skipped here since they log each toolchain separately.
+func withBuildLog(cmd *cobra.Command, command string, fn func() error) error {
+	if toolchain, _ := cmd.Flags().GetString("toolchain"); toolchain != "" {
+		return fn()
+	}
+	buildLog := buildlog.Start(".", command, "")
+	start := time.Now()
+	err := fn()
+	buildLog.Close(err)
+
+	variant := statsVariant(cmd)
+	artifactDir := ""
+	if command == "build" {
+		artifactDir = filepath.Join(config.BinDir(), "native", variant)
+	}
+	recordStats(".", stats.Record{
+		Time:       start,
+		Command:    command,
+		Variant:    variant,
+		DurationMS: time.Since(start).Milliseconds(),
+		Success:    err == nil,
+	}, buildLog.Path, artifactDir)
+	return err
+}
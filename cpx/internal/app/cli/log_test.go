@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestLog(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "20261018-120000-build.log")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestPrintLog(t *testing.T) {
+	path := writeTestLog(t, "# cpx build\nline 1\nsrc/a.cpp:1: error: x\nline 3\nsrc/b.cpp:2: error: y\n# exit: ok\n")
+
+	var out bytes.Buffer
+	_, err := printLog(&out, path, regexp.MustCompile("error:"), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "src/a.cpp:1: error: x\nsrc/b.cpp:2: error: y\n", out.String())
+
+	out.Reset()
+	_, err = printLog(&out, path, nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "src/b.cpp:2: error: y\n# exit: ok\n", out.String())
+}
+
+func TestFollowLog(t *testing.T) {
+	oldInterval := followInterval
+	t.Cleanup(func() { followInterval = oldInterval })
+	followInterval = time.Millisecond
+
+	path := writeTestLog(t, "# cpx build\nfirst\n")
+	var out bytes.Buffer
+	offset, err := printLog(&out, path, nil, 0)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	var followed bytes.Buffer
+	go func() { done <- followLog(&followed, path, nil, offset) }()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, _ = f.WriteString("second\n# exit: ok\n")
+	require.NoError(t, f.Close())
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("followLog did not stop after the log finished")
+	}
+	assert.Equal(t, "second\n# exit: ok\n", followed.String())
+}
+
+func TestPrintLogList(t *testing.T) {
+	var out bytes.Buffer
+	printLogList(&out, []buildlog.Entry{
+		{Path: "/p/b.log", Command: "build", Toolchain: "wasm", Status: "failed: boom"},
+		{Path: "/p/a.log", Command: "test", Status: "ok"},
+	})
+	assert.Contains(t, out.String(), "build wasm")
+	assert.Contains(t, out.String(), "failed")
+	assert.Contains(t, out.String(), "a.log")
+
+	out.Reset()
+	printLogList(&out, nil)
+	assert.Contains(t, out.String(), "No build logs found.")
+}
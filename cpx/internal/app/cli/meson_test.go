@@ -35,6 +35,12 @@ func TestHelperProcess(t *testing.T) {
 
 	cmd, args := args[0], args[1:]
 	switch cmd {
+	case "docker":
+		fmt.Print(os.Getenv("MOCK_OUTPUT"))
+		os.Exit(0)
+	case "fail":
+		fmt.Println("simulated failure")
+		os.Exit(1)
 	case "meson":
 		if len(args) > 0 && args[0] == "wrap" && args[1] == "install" {
 			pkg := args[2]
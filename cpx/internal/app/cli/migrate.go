@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build/bazel"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/build/meson"
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/ide"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// MigrateCmd creates the migrate command
+func MigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Create or upgrade the project's cpx.yaml manifest",
+		Long: `Create the cpx.yaml manifest of an existing project, or upgrade an older one
+to the current manifest version.
+
+The name, version and C++ standard are read from the build files
+(CMakeLists.txt, meson.build, MODULE.bazel), the dependencies from
+vcpkg.json, the wraps or MODULE.bazel, and the build profiles are copied
+from cpx-ci.yaml. Values already in cpx.yaml are kept, so migrate can be run
+again to fill in what is missing. From then on, cpx reads the project's
+name, version and standard from cpx.yaml.
+
+Fields cpx-ci.yaml no longer has, such as the top-level build and output
+of older projects, are removed from it.`,
+		Example: `  cpx migrate            # Write cpx.yaml
+  cpx migrate --dry-run  # Print it instead`,
+		Args: cobra.NoArgs,
+		RunE: runMigrate,
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Print the manifest instead of writing it")
+
+	return cmd
+}
+
+func runMigrate(cmd *cobra.Command, _ []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	manifest, err := config.LoadManifest(config.ManifestFile)
+	if os.IsNotExist(err) {
+		manifest, err = &config.Manifest{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	from := manifest.ManifestVersion
+
+	projectType, err := RequireProject("cpx migrate")
+	if err != nil {
+		return err
+	}
+	builder, err := projectBuilder()
+	if err != nil {
+		return err
+	}
+	deps, err := builder.ListDependencies(context.Background())
+	if err != nil {
+		output.Warnf("Could not list dependencies: %v", err)
+	}
+	profiles, err := buildProfiles("cpx-ci.yaml")
+	if err != nil {
+		return err
+	}
+	migrateManifest(manifest, projectType, deps, profiles)
+
+	if dryRun {
+		data, err := yaml.Marshal(manifest)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(output.Stdout(), string(data))
+		return nil
+	}
+	if err := config.SaveManifest(config.ManifestFile, manifest); err != nil {
+		return fmt.Errorf("failed to write %s: %w", config.ManifestFile, err)
+	}
+	if from == config.ManifestVersion {
+		output.Successf("✓ %s is up to date (manifest version %d)", config.ManifestFile, config.ManifestVersion)
+	} else {
+		output.Successf("✓ Wrote %s (manifest version %d)", config.ManifestFile, config.ManifestVersion)
+	}
+	return migrateToolchainsFile("cpx-ci.yaml")
+}
+
+// migrateToolchainsFile removes the deprecated fields of the cpx-ci.yaml at
+// path, keeping the rest of the file as is.
+func migrateToolchainsFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	migrated, removed, err := config.RemoveDeprecatedToolchainFields(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := os.WriteFile(path, migrated, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	output.Successf("✓ Removed the deprecated %s from %s", strings.Join(removed, " and "), path)
+	return nil
+}
+
+// migrateManifest fills in the fields manifest lacks from the build files
+// of the project in the current directory, its dependencies and profiles,
+// and sets the current manifest version.
+func migrateManifest(manifest *config.Manifest, projectType ProjectType, deps []build.Dependency, profiles map[string]config.BuildProfile) {
+	manifest.ManifestVersion = config.ManifestVersion
+	if manifest.BuildSystem == "" {
+		manifest.BuildSystem = string(projectType)
+	}
+	name, version := buildFileProjectInfo(".", projectType)
+	if manifest.Name == "" {
+		manifest.Name = name
+	}
+	if manifest.Version == "" {
+		manifest.Version = version
+	}
+	if manifest.CxxStandard == 0 {
+		manifest.CxxStandard = ide.DetectCppStandard(".")
+	}
+	if len(manifest.Dependencies) == 0 {
+		for _, dep := range deps {
+			manifest.Dependencies = append(manifest.Dependencies, config.ManifestDependency{Name: dep.Name, Version: dep.Version})
+		}
+	}
+	if len(profiles) > 0 {
+		if manifest.Profiles == nil {
+			manifest.Profiles = map[string]config.BuildProfile{}
+		}
+		for name, profile := range profiles {
+			if _, ok := manifest.Profiles[name]; !ok {
+				manifest.Profiles[name] = profile
+			}
+		}
+	}
+}
+
+// buildFileProjectInfo returns the name and version of the project in root
+// as its build files declare them.
+func buildFileProjectInfo(root string, projectType ProjectType) (name, version string) {
+	switch projectType {
+	case ProjectTypeVcpkg:
+		return vcpkg.CMakeProjectInfo(root)
+	case ProjectTypeMeson:
+		return meson.GetProjectNameFromMesonBuild(root), meson.GetProjectVersionFromMesonBuild(root)
+	case ProjectTypeBazel:
+		return bazel.ModuleInfo(root)
+	}
+	return "", ""
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("cmake_minimum_required(VERSION 3.20)\nproject(mylib VERSION 1.4.0 LANGUAGES CXX)\nset(CMAKE_CXX_STANDARD 20)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "mylib", "dependencies": ["fmt", {"name": "spdlog", "version>=": "1.12.0"}]}`), 0644))
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(profilesConfig), 0644))
+	// A cpx.yaml from before the manifest was versioned
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("build_system: vcpkg\nprofiles:\n  asan-ci:\n    sanitizer: asan\n"), 0644))
+
+	cmd := MigrateCmd()
+	cmd.SetArgs(nil)
+	require.NoError(t, cmd.Execute())
+
+	manifest, err := config.LoadManifest("cpx.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, config.ManifestVersion, manifest.ManifestVersion)
+	assert.Equal(t, "mylib", manifest.Name)
+	assert.Equal(t, "1.4.0", manifest.Version)
+	assert.Equal(t, 20, manifest.CxxStandard)
+	assert.Equal(t, "vcpkg", manifest.BuildSystem)
+	var deps []string
+	for _, dep := range manifest.Dependencies {
+		deps = append(deps, dep.Name)
+	}
+	assert.Equal(t, []string{"fmt", "spdlog"}, deps)
+	assert.Len(t, manifest.Profiles, 3, "the profiles of cpx-ci.yaml are copied")
+	assert.Equal(t, "asan", manifest.Profiles["asan-ci"].Sanitizer, "existing values are kept")
+
+	// Values of the manifest are kept when migrating again
+	manifest.Name = "renamed"
+	require.NoError(t, config.SaveManifest("cpx.yaml", manifest))
+	require.NoError(t, MigrateCmd().Execute())
+	manifest, err = config.LoadManifest("cpx.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", manifest.Name)
+}
+
+func TestMigrateToolchainsFile(t *testing.T) {
+	chdirTemp(t)
+	legacy := "toolchains:\n  - name: release\n\n# Global build configuration\nbuild:\n  type: Release\n  jobs: 0\n\n# Output directory for artifacts\noutput: .bin/ci\n"
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(legacy), 0644))
+
+	require.NoError(t, migrateToolchainsFile("cpx-ci.yaml"))
+	data, err := os.ReadFile("cpx-ci.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "toolchains:\n  - name: release\n", string(data))
+	assert.NoError(t, validateToolchainsFile("cpx-ci.yaml"))
+
+	require.NoError(t, os.Remove("cpx-ci.yaml"))
+	assert.NoError(t, migrateToolchainsFile("cpx-ci.yaml"), "projects without cpx-ci.yaml have nothing to migrate")
+}
+
+func TestBuildFileProjectInfo(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('tool', 'cpp',\n  version : '0.2.0',\n  default_options : ['cpp_std=c++17'])\n"), 0644))
+	name, version := buildFileProjectInfo(".", ProjectTypeMeson)
+	assert.Equal(t, "tool", name)
+	assert.Equal(t, "0.2.0", version)
+
+	require.NoError(t, os.WriteFile("MODULE.bazel", []byte("module(\n    name = \"mymod\",\n    version = \"3.1.0\",\n)\n"), 0644))
+	name, version = buildFileProjectInfo(".", ProjectTypeBazel)
+	assert.Equal(t, "mymod", name)
+	assert.Equal(t, "3.1.0", version)
+}
+
+func TestBuildProfilesFromManifest(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(profilesConfig), 0644))
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("profiles:\n  asan-ci:\n    sanitizer: tsan\n  fast:\n    opt: fast\n"), 0644))
+
+	profile, err := loadBuildProfile("cpx-ci.yaml", "asan-ci")
+	require.NoError(t, err)
+	assert.Equal(t, "tsan", profile.Sanitizer, "cpx.yaml takes precedence over cpx-ci.yaml")
+	_, err = loadBuildProfile("cpx-ci.yaml", "missing")
+	assert.ErrorContains(t, err, "available profiles: asan-ci, broken, fast, release-lto")
+}
@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/project"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// mingwImage is the tag of the built-in MinGW-w64 image.
+const mingwImage = "cpx-mingw:latest"
+
+// mingwDockerfile builds the built-in MinGW-w64 image, used when a mingw
+// toolchain has no docker runner and no local cross compiler.
+const mingwDockerfile = `FROM ubuntu:24.04
+
+ENV DEBIAN_FRONTEND=noninteractive
+
+RUN apt-get update && apt-get install -y \
+    mingw-w64 \
+    cmake \
+    ninja-build \
+    meson \
+    pkg-config \
+    git \
+    curl \
+    zip \
+    unzip \
+    tar \
+    && rm -rf /var/lib/apt/lists/*
+
+# Use the POSIX thread model so std::thread and friends are available
+RUN update-alternatives --set x86_64-w64-mingw32-g++ /usr/bin/x86_64-w64-mingw32-g++-posix && \
+    update-alternatives --set x86_64-w64-mingw32-gcc /usr/bin/x86_64-w64-mingw32-gcc-posix && \
+    update-alternatives --set i686-w64-mingw32-g++ /usr/bin/i686-w64-mingw32-g++-posix && \
+    update-alternatives --set i686-w64-mingw32-gcc /usr/bin/i686-w64-mingw32-gcc-posix
+
+RUN git clone https://github.com/microsoft/vcpkg.git /opt/vcpkg && \
+    /opt/vcpkg/bootstrap-vcpkg.sh -disableMetrics
+
+ENV VCPKG_ROOT=/opt/vcpkg
+
+WORKDIR /workspace
+`
+
+// mingwArchs maps the supported architectures to their vcpkg triplets.
+var mingwArchs = map[string]string{
+	"x86_64": "x64-mingw-static",
+	"i686":   "x86-mingw-static",
+}
+
+// mingwToolchainFile returns a CMake toolchain file for MinGW-w64. Binaries
+// link the runtime statically so they run without the MinGW DLLs.
+func mingwToolchainFile(arch string) string {
+	return fmt.Sprintf(`set(CMAKE_SYSTEM_NAME Windows)
+set(CMAKE_SYSTEM_PROCESSOR %[1]s)
+
+set(TOOLCHAIN_PREFIX %[1]s-w64-mingw32)
+set(CMAKE_C_COMPILER ${TOOLCHAIN_PREFIX}-gcc)
+set(CMAKE_CXX_COMPILER ${TOOLCHAIN_PREFIX}-g++)
+set(CMAKE_RC_COMPILER ${TOOLCHAIN_PREFIX}-windres)
+
+set(CMAKE_FIND_ROOT_PATH /usr/${TOOLCHAIN_PREFIX})
+set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)
+set(CMAKE_FIND_ROOT_PATH_MODE_LIBRARY ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_INCLUDE ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_PACKAGE ONLY)
+
+set(CMAKE_EXE_LINKER_FLAGS_INIT "-static -static-libgcc -static-libstdc++")
+
+# Run tests under Wine when it is available
+find_program(WINE_EXECUTABLE NAMES wine64 wine)
+if(WINE_EXECUTABLE)
+    set(CMAKE_CROSSCOMPILING_EMULATOR ${WINE_EXECUTABLE})
+endif()
+`, arch)
+}
+
+// mingwCrossFile returns a Meson cross file for MinGW-w64.
+func mingwCrossFile(arch string) string {
+	cpuFamily := arch
+	if arch == "i686" {
+		cpuFamily = "x86"
+	}
+	return fmt.Sprintf(`[binaries]
+c = '%[1]s-w64-mingw32-gcc'
+cpp = '%[1]s-w64-mingw32-g++'
+ar = '%[1]s-w64-mingw32-ar'
+strip = '%[1]s-w64-mingw32-strip'
+windres = '%[1]s-w64-mingw32-windres'
+exe_wrapper = 'wine'
+
+[built-in options]
+cpp_link_args = ['-static', '-static-libgcc', '-static-libstdc++']
+
+[host_machine]
+system = 'windows'
+cpu_family = '%[2]s'
+cpu = '%[1]s'
+endian = 'little'
+`, arch, cpuFamily)
+}
+
+// mingwBuild describes a mingw build. Paths are as seen by the build, i.e.
+// inside the container for docker builds.
+type mingwBuild struct {
+	Meson     bool
+	Toolchain config.Toolchain
+	MesonArgs []string
+	Arch      string
+	SourceDir string
+	CacheDir  string // holds the build directory, toolchain and cross files
+	OutputDir string
+	RunTests  bool
+	Execute   bool
+}
+
+// script returns the shell script that cross-compiles the project and
+// collects the .exe/.dll artifacts.
+func (m mingwBuild) script() string {
+	tc := m.Toolchain
+	buildType := tc.BuildType
+	if buildType == "" {
+		buildType = "Release"
+	}
+	buildDir := m.CacheDir + "/" + tc.Name
+
+	var s strings.Builder
+	s.WriteString("set -e\n")
+	fmt.Fprintf(&s, "mkdir -p %q %q\n", buildDir, m.OutputDir)
+
+	if m.Meson {
+		mesonBuildType := "release"
+		if strings.EqualFold(buildType, "Debug") {
+			mesonBuildType = "debug"
+		}
+		setupArgs := append([]string{"--cross-file", m.CacheDir + "/mingw-" + m.Arch + ".ini", "--buildtype=" + mesonBuildType}, m.MesonArgs...)
+		fmt.Fprintf(&s, "if [ -f %q ]; then\n", buildDir+"/build.ninja")
+		fmt.Fprintf(&s, "    meson setup --reconfigure %s %s %s\n", buildDir, m.SourceDir, strings.Join(setupArgs, " "))
+		s.WriteString("else\n")
+		fmt.Fprintf(&s, "    meson setup %s %s %s\n", buildDir, m.SourceDir, strings.Join(setupArgs, " "))
+		s.WriteString("fi\n")
+		compileArgs := []string{"-C", buildDir}
+		if tc.Jobs > 0 {
+			compileArgs = append(compileArgs, "-j", fmt.Sprintf("%d", tc.Jobs))
+		}
+		compileArgs = append(compileArgs, tc.BuildOptions...)
+		fmt.Fprintf(&s, "meson compile %s\n", strings.Join(compileArgs, " "))
+		if m.RunTests {
+			fmt.Fprintf(&s, "if command -v wine >/dev/null; then meson test -C %s --print-errorlogs; else echo \"  wine not found; skipping tests\"; fi\n", buildDir)
+		}
+	} else {
+		toolchainFile := m.CacheDir + "/mingw-" + m.Arch + ".cmake"
+		optLevel := tc.Optimization
+		if optLevel == "" {
+			optLevel = "2"
+		}
+		cmakeArgs := []string{
+			"-GNinja",
+			"-B", buildDir,
+			"-S", m.SourceDir,
+			"-DCMAKE_BUILD_TYPE=" + buildType,
+			"-DCMAKE_CXX_FLAGS=-O" + optLevel,
+		}
+		if m.RunTests {
+			cmakeArgs = append(cmakeArgs, "-DBUILD_TESTING=ON", "-DENABLE_TESTING=ON")
+		}
+		cmakeArgs = append(cmakeArgs, tc.CMakeOptions...)
+
+		// vcpkg dependencies are built for the static mingw triplet, with
+		// the MinGW toolchain chain-loaded from the vcpkg toolchain
+		fmt.Fprintf(&s, "TOOLCHAIN_ARGS='-DCMAKE_TOOLCHAIN_FILE=%s'\n", toolchainFile)
+		fmt.Fprintf(&s, "if [ -n \"$VCPKG_ROOT\" ] && [ -f %q ]; then\n", m.SourceDir+"/vcpkg.json")
+		fmt.Fprintf(&s, "    TOOLCHAIN_ARGS='-DCMAKE_TOOLCHAIN_FILE=\"$VCPKG_ROOT/scripts/buildsystems/vcpkg.cmake\" "+
+			"-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE=%s -DVCPKG_TARGET_TRIPLET=%s'\n", toolchainFile, mingwArchs[m.Arch])
+		s.WriteString("fi\n")
+		fmt.Fprintf(&s, "eval cmake %s $TOOLCHAIN_ARGS\n", strings.Join(cmakeArgs, " "))
+
+		buildArgs := []string{"--build", buildDir}
+		if tc.Jobs > 0 {
+			buildArgs = append(buildArgs, "--parallel", fmt.Sprintf("%d", tc.Jobs))
+		}
+		buildArgs = append(buildArgs, tc.BuildOptions...)
+		fmt.Fprintf(&s, "cmake %s\n", strings.Join(buildArgs, " "))
+		if m.RunTests {
+			fmt.Fprintf(&s, "if command -v wine >/dev/null; then ctest --test-dir %s --output-on-failure; else echo \"  wine not found; skipping tests\"; fi\n", buildDir)
+		}
+	}
+
+	fmt.Fprintf(&s, `find %s -maxdepth 2 -type f \( -name "*.exe" -o -name "*.dll" \) ! -path "*/CMakeFiles/*" ! -path "*.p/*" -exec cp {} %s/ \;`+"\n", buildDir, m.OutputDir)
+
+	if m.Execute {
+		fmt.Fprintf(&s, `if command -v wine >/dev/null; then
+    for exe in %s/*.exe; do
+        case "$exe" in *_test.exe|*_bench.exe) continue ;; esac
+        echo "  Executing: wine $exe"
+        wine "$exe"
+        break
+    done
+else
+    echo "  wine not found; skipping run"
+fi
+`, m.OutputDir)
+	}
+	return s.String()
+}
+
+// runMinGWBuild cross-compiles a toolchain of type mingw for Windows. It uses
+// the runner's docker image if one is configured, otherwise a local MinGW-w64
+// compiler, falling back to the built-in MinGW image.
+func runMinGWBuild(tc config.Toolchain, runner *config.Runner, projectRoot, outputDir string, env map[string]string, mesonArgs []string, options ToolchainBuildOptions) error {
+	m := mingwBuild{
+		Toolchain: tc,
+		MesonArgs: mesonArgs,
+		Arch:      "x86_64",
+		RunTests:  options.RunTests,
+		Execute:   options.ExecuteAfterBuild,
+	}
+	if tc.MinGW != nil && tc.MinGW.Arch != "" {
+		m.Arch = tc.MinGW.Arch
+	}
+	if _, ok := mingwArchs[m.Arch]; !ok {
+		return fmt.Errorf("unknown MinGW architecture '%s' (supported: x86_64, i686)", m.Arch)
+	}
+	if project.Detect(projectRoot) == project.Meson {
+		m.Meson = true
+	} else if _, err := os.Stat(filepath.Join(projectRoot, "CMakeLists.txt")); err != nil {
+		return fmt.Errorf("mingw toolchains support CMake and Meson projects")
+	}
+
+	cacheDir := filepath.Join(config.DirsOf(projectRoot).Cache, "mingw")
+	targetOutputDir := filepath.Join(projectRoot, outputDir, tc.Name)
+	for _, dir := range []string{cacheDir, targetOutputDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	files := map[string]string{
+		"mingw-" + m.Arch + ".cmake": mingwToolchainFile(m.Arch),
+		"mingw-" + m.Arch + ".ini":   mingwCrossFile(m.Arch),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(cacheDir, name), []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	image := ""
+	switch {
+	case runner != nil && runner.IsDocker():
+		resolved, err := resolveDockerImageNew(runner, projectRoot, options.Rebuild, options.Verbose)
+		if err != nil {
+			return err
+		}
+		image = resolved
+	case runner != nil && runner.IsSSH():
+		return fmt.Errorf("SSH runners are not supported for mingw toolchains")
+	default:
+		if _, err := execLookPath(m.Arch + "-w64-mingw32-g++"); err != nil {
+			if runner != nil {
+				return fmt.Errorf("%s-w64-mingw32-g++ not found in PATH\n  hint: install MinGW-w64 (e.g. 'apt install mingw-w64' or 'brew install mingw-w64')", m.Arch)
+			}
+			resolved, err := buildMinGWImage(projectRoot, options)
+			if err != nil {
+				return err
+			}
+			image = resolved
+		}
+	}
+
+	if image == "" {
+		fmt.Fprintf(output.Stdout(), "  %s Building with local MinGW-w64 (%s)...%s\n", colors.Cyan, m.Arch, colors.Reset)
+	} else {
+		fmt.Fprintf(output.Stdout(), "  %s Building with MinGW-w64 (%s) in %s...%s\n", colors.Cyan, m.Arch, image, colors.Reset)
+	}
+	err := runBuildScript(image, projectRoot, cacheDir, targetOutputDir, env, func(p scriptPaths) string {
+		m.SourceDir, m.CacheDir, m.OutputDir = p.Source, p.Cache, p.Output
+		return m.script()
+	})
+	if err != nil {
+		return fmt.Errorf("mingw build failed: %w", err)
+	}
+	return nil
+}
+
+// buildMinGWImage builds the built-in MinGW image from mingwDockerfile. It is
+// only rebuilt when the Dockerfile changes.
+func buildMinGWImage(projectRoot string, options ToolchainBuildOptions) (string, error) {
+	contextDir := filepath.Join(config.DirsOf(projectRoot).Cache, "mingw", "image")
+	if err := os.MkdirAll(contextDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", contextDir, err)
+	}
+	dockerfile := filepath.Join(contextDir, "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte(mingwDockerfile), 0644); err != nil {
+		return "", fmt.Errorf("failed to write MinGW Dockerfile: %w", err)
+	}
+	runner := &config.Runner{
+		Name:         "mingw",
+		Type:         "docker",
+		Image:        mingwImage,
+		Dockerfile:   dockerfile,
+		BuildContext: contextDir,
+	}
+	return resolveDockerImageNew(runner, projectRoot, options.Rebuild, options.Verbose)
+}
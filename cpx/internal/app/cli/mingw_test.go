@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinGWBuildScriptCMake(t *testing.T) {
+	m := mingwBuild{
+		Toolchain: config.Toolchain{Name: "windows-x64", BuildType: "Release"},
+		Arch:      "x86_64",
+		SourceDir: "/workspace",
+		CacheDir:  "/tmp/cache",
+		OutputDir: "/output",
+		RunTests:  true,
+		Execute:   true,
+	}
+	script := m.script()
+
+	assert.Contains(t, script, "TOOLCHAIN_ARGS='-DCMAKE_TOOLCHAIN_FILE=/tmp/cache/mingw-x86_64.cmake'")
+	assert.Contains(t, script, "-DVCPKG_TARGET_TRIPLET=x64-mingw-static")
+	assert.Contains(t, script, "eval cmake -GNinja -B /tmp/cache/windows-x64 -S /workspace -DCMAKE_BUILD_TYPE=Release -DCMAKE_CXX_FLAGS=-O2 -DBUILD_TESTING=ON")
+	assert.Contains(t, script, "ctest --test-dir /tmp/cache/windows-x64")
+	assert.Contains(t, script, `-name "*.exe" -o -name "*.dll"`)
+	assert.Contains(t, script, `wine "$exe"`)
+}
+
+func TestMinGWBuildScriptMeson(t *testing.T) {
+	m := mingwBuild{
+		Meson:     true,
+		Toolchain: config.Toolchain{Name: "win32", BuildType: "Debug"},
+		Arch:      "i686",
+		SourceDir: "/src",
+		CacheDir:  "/cache",
+		OutputDir: "/out",
+	}
+	script := m.script()
+	assert.Contains(t, script, "meson setup /cache/win32 /src --cross-file /cache/mingw-i686.ini --buildtype=debug")
+	assert.NotContains(t, script, "wine")
+}
+
+func TestMinGWFiles(t *testing.T) {
+	assert.Contains(t, mingwToolchainFile("x86_64"), "set(CMAKE_CXX_COMPILER ${TOOLCHAIN_PREFIX}-g++)")
+	assert.Contains(t, mingwToolchainFile("x86_64"), "set(TOOLCHAIN_PREFIX x86_64-w64-mingw32)")
+	assert.Contains(t, mingwCrossFile("i686"), "cpu_family = 'x86'")
+	assert.Contains(t, mingwCrossFile("i686"), "cpp = 'i686-w64-mingw32-g++'")
+}
+
+func TestRunMinGWBuildUsesBuiltinImage(t *testing.T) {
+	projectRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectRoot, "CMakeLists.txt"), nil, 0644))
+
+	oldExecLookPath := execLookPath
+	t.Cleanup(func() { execLookPath = oldExecLookPath })
+	execLookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	calls := mockDockerCommands(t, "")
+
+	tc := config.Toolchain{Name: "windows-x64", Type: config.ToolchainTypeMinGW}
+	err := runMinGWBuild(tc, nil, projectRoot, filepath.Join(".bin", "ci"), map[string]string{}, nil, ToolchainBuildOptions{})
+	require.NoError(t, err)
+
+	// image inspect -> docker build -> docker run
+	require.Len(t, *calls, 3)
+	assert.Equal(t, []string{"docker", "build"}, (*calls)[1][:2])
+	assert.Contains(t, (*calls)[1], mingwImage)
+	run := (*calls)[2]
+	assert.Contains(t, run, filepath.Join(projectRoot, ".bin", "ci", "windows-x64")+":/output")
+	assert.Contains(t, run, mingwImage)
+
+	assert.FileExists(t, filepath.Join(projectRoot, ".cache", "mingw", "mingw-x86_64.cmake"))
+	assert.FileExists(t, filepath.Join(projectRoot, ".cache", "mingw", "image", "Dockerfile"))
+}
+
+func TestRunMinGWBuildRejectsUnknownArch(t *testing.T) {
+	tc := config.Toolchain{Name: "win", MinGW: &config.MinGWConfig{Arch: "arm64"}}
+	err := runMinGWBuild(tc, nil, t.TempDir(), ".bin/ci", nil, nil, ToolchainBuildOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown MinGW architecture")
+}
@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/quality"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// mutateDir is the build directory of cpx mutate, next to the report.
+func mutateDir() string {
+	return filepath.Join(config.CacheDir(), "mutate")
+}
+
+// MutateCmd creates the mutate command
+func MutateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mutate",
+		Short: "Run mutation testing with mull",
+		Long: `Run mutation testing with mull: the tests are built with clang and mull's
+compiler plugin, which puts small bugs (mutants) into the code, then run once
+per mutant. A mutant that no test catches survives and points at untested
+behavior.
+
+The surviving mutants are listed per file as file:line:column, with the
+mutation score: the percentage of mutants the tests caught. The run fails when
+the score is under --threshold, or mutate.threshold in cpx-ci.yaml:
+
+  mutate:
+    threshold: 80
+    mutators: [cxx_arithmetic, cxx_comparison]
+    exclude: ['.*/generated/.*']
+    timeout: 5s
+
+Tests and dependencies are never mutated. The build lives in .cache/mutate,
+with the full report (mutation.json, in the mutation-testing-elements format).
+CMake projects only.`,
+		Example: `  cpx mutate                      # Run with mull's default mutators
+  cpx mutate --threshold 75       # Fail under a 75% mutation score
+  cpx mutate --mutators cxx_arithmetic,cxx_boundary`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withBuildLog(cmd, "mutate", func() error { return runMutate(cmd) })
+		},
+	}
+
+	cmd.Flags().Float64("threshold", 0, "Minimum mutation score in percent (default: mutate.threshold in cpx-ci.yaml)")
+	cmd.Flags().StringSlice("mutators", nil, "Mull mutators or mutator groups to apply (default: mull's)")
+	cmd.Flags().StringArray("exclude", nil, "Regexp of source paths not to mutate (repeatable)")
+	cmd.Flags().Duration("timeout", 0, "Time limit of the tests for each mutant")
+	cmd.Flags().Bool("verbose", false, "Show full build output")
+
+	return cmd
+}
+
+func runMutate(cmd *cobra.Command) error {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	projectType, err := RequireProject("cpx mutate")
+	if err != nil {
+		return err
+	}
+	if projectType != ProjectTypeVcpkg {
+		return fmt.Errorf("cpx mutate supports CMake projects only\n  hint: mull instruments the clang build that CMake configures")
+	}
+
+	cfg, err := mutateConfig("cpx-ci.yaml")
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("threshold") {
+		cfg.Threshold, _ = cmd.Flags().GetFloat64("threshold")
+	}
+	if cmd.Flags().Changed("mutators") {
+		cfg.Mutators, _ = cmd.Flags().GetStringSlice("mutators")
+	}
+	exclude, _ := cmd.Flags().GetStringArray("exclude")
+	cfg.Exclude = append(cfg.Exclude, exclude...)
+	timeout, err := mutateTimeout(cmd, cfg.Timeout)
+	if err != nil {
+		return err
+	}
+
+	mull, err := quality.FindMull()
+	if err != nil {
+		return err
+	}
+	output.Stepf(" Using mull for LLVM %d (%s)", mull.Version, mull.CXX)
+
+	if err := os.MkdirAll(mutateDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", mutateDir(), err)
+	}
+	mullConfig, err := filepath.Abs(filepath.Join(mutateDir(), "mull.yml"))
+	if err != nil {
+		return err
+	}
+	if err := quality.WriteMullConfig(mullConfig, cfg.Mutators, cfg.Exclude, int(timeout.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to write %s: %w", mullConfig, err)
+	}
+	if err := os.Setenv("MULL_CONFIG", mullConfig); err != nil {
+		return err
+	}
+
+	testExe, err := vcpkg.New().BuildMutationTests(vcpkg.MutationBuild{
+		BuildDir: mutateDir(),
+		CC:       mull.CC,
+		CXX:      mull.CXX,
+		Flags:    mull.CompileFlags(),
+		Verbose:  verbose,
+	})
+	if err != nil {
+		return err
+	}
+
+	output.Stepf(" Running the tests against each mutant...")
+	runnerArgs := []string{"--reporters", "Elements", "--report-dir", mutateDir(), "--report-name", "mutation", "--allow-surviving"}
+	if timeout > 0 {
+		runnerArgs = append(runnerArgs, "--timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+	}
+	runner := execCommand(mull.Runner, append(runnerArgs, testExe)...)
+	runner.Stdout = os.Stdout
+	runner.Stderr = os.Stderr
+	if err := runner.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", mull.Runner, err)
+	}
+
+	reportPath := filepath.Join(mutateDir(), "mutation.json")
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return fmt.Errorf("mull wrote no report: %w", err)
+	}
+	report, err := quality.ParseMutationReport(data)
+	if err != nil {
+		return err
+	}
+	relativizeMutationPaths(report)
+
+	fmt.Fprintln(output.Stdout())
+	report.Print(output.Stdout())
+	fmt.Fprintln(output.Stdout())
+
+	score := report.Score()
+	if cfg.Threshold > 0 && score < cfg.Threshold {
+		return fmt.Errorf("mutation score %.1f%% is under the threshold of %.1f%%\n  hint: add tests that catch the surviving mutants above", score, cfg.Threshold)
+	}
+	output.Successf(" Mutation score %.1f%% (report: %s)", score, reportPath)
+	return nil
+}
+
+// mutateConfig returns the mutate section of a cpx-ci.yaml, empty when
+// there is none.
+func mutateConfig(path string) (*config.MutateConfig, error) {
+	ciConfig, err := config.LoadToolchains(path)
+	if os.IsNotExist(err) {
+		return &config.MutateConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ciConfig.Mutate == nil {
+		return &config.MutateConfig{}, nil
+	}
+	return ciConfig.Mutate, nil
+}
+
+// mutateTimeout returns the per-mutant time limit: --timeout, or the one
+// of the config, 0 for mull's default.
+func mutateTimeout(cmd *cobra.Command, configured string) (time.Duration, error) {
+	if cmd.Flags().Changed("timeout") {
+		return cmd.Flags().GetDuration("timeout")
+	}
+	if configured == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(configured)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid mutate.timeout %q (use e.g. 5s)", configured)
+	}
+	return timeout, nil
+}
+
+// relativizeMutationPaths makes the absolute source paths mull reports
+// relative to the project.
+func relativizeMutationPaths(report *quality.MutationReport) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	for i, f := range report.Files {
+		if rel, err := filepath.Rel(wd, f.Path); err == nil && filepath.IsAbs(f.Path) && filepath.IsLocal(rel) {
+			report.Files[i].Path = rel
+		}
+	}
+}
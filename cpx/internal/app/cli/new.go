@@ -6,10 +6,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-
+	"regexp"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
 	"github.com/ozacod/cpx/internal/app/cli/tui"
 	"github.com/ozacod/cpx/internal/pkg/build/bazel"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
@@ -19,29 +21,103 @@ import (
 	"github.com/ozacod/cpx/internal/pkg/templates/project_templates"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
 	"github.com/ozacod/cpx/internal/pkg/utils/git"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
-// NewCmd creates the new command with interactive TUI
+// NewCmd creates the new command: the interactive TUI, or non-interactive
+// project creation when a name is given
 func NewCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "new",
-		Short: "Create a new C++ project (interactive)",
-		Long:  "Create a new C++ project using an interactive TUI. This will guide you through the project configuration.",
+		Use:   "new [name]",
+		Short: "Create a new C++ project",
+		Long: `Create a new C++ project. Without a name, an interactive TUI guides you
+through the project configuration. With a name, the project is created from
+flags without prompting, for scripts and CI.`,
 		Example: `  cpx new            # launch the interactive creator
+  cpx new myproj --lib --std 20 --pm bazel --test googletest --bench google-benchmark --no-git
+  cpx new myproj --template gRPC --pm meson
+  cpx new --shared   # libraries default to shared instead of static
+  cpx new --pch      # add a precompiled header
+  cpx new --modules  # C++20 modules instead of headers (CMake 3.28+)
+  cpx new --template mytpl  # from a template added with cpx template add
+  cpx new --no-hooks # skip the template's post-generate commands
   cpx new --help    # view options`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runNew(cmd, args)
 		},
-		Args: cobra.NoArgs,
-	}
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	// Non-interactive options, used when a name is given
+	cmd.Flags().Bool("lib", false, "Create a library instead of an executable")
+	cmd.Flags().Bool("header-only", false, "Create a header-only library")
+	cmd.Flags().Int("std", 17, "C++ standard (11, 14, 17, 20 or 23)")
+	cmd.Flags().String("pm", "vcpkg", "Build system / package manager: vcpkg, bazel or meson")
+	cmd.Flags().String("test", "googletest", "Test framework: googletest, catch2, doctest or none")
+	cmd.Flags().String("bench", "none", "Benchmark framework: google-benchmark, nanobench, catch2-benchmark or none")
+	cmd.Flags().String("clang-format", "Google", "clang-format style: Google, LLVM, Chromium, Mozilla or WebKit")
+	cmd.Flags().Bool("no-git", false, "Do not initialize a git repository")
+
+	addLibraryTypeFlags(cmd, "Default to building")
+	cmd.Flags().Bool("pch", false, "Add a precompiled header (src/pch/pch.hpp) to the build")
+	cmd.Flags().Bool("modules", false, "Use C++20 modules (src/<name>.cppm) instead of headers; needs CMake 3.28+")
+	cmd.Flags().String("template", "", "Create the project from a built-in or user template (see cpx template list)")
+	cmd.Flags().Bool("no-hooks", false, "Do not run the template's post-generate commands")
+	cmd.MarkFlagsMutuallyExclusive("pch", "modules")
 
 	return cmd
 }
 
-func runNew(_ *cobra.Command, _ []string) error {
+// nonInteractiveNewFlags are the options the TUI asks for, only accepted
+// with a project name.
+var nonInteractiveNewFlags = []string{"lib", "header-only", "std", "pm", "test", "bench", "clang-format", "no-git"}
+
+// stdinIsTerminal reports whether the TUI can read keys; a variable for
+// tests.
+var stdinIsTerminal = func() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+func runNew(cmd *cobra.Command, args []string) error {
+	registerUserTemplates()
+
+	templateName, _ := cmd.Flags().GetString("template")
+	if templateName != "" {
+		if _, ok := project_templates.GetTemplateByName(templateName); !ok {
+			return fmt.Errorf("template '%s' not found\n  hint: available templates: %s", templateName, strings.Join(project_templates.GetTemplateNames(), ", "))
+		}
+	}
+
+	if len(args) == 1 && args[0] == "." {
+		return newInPlace(cmd)
+	}
+	if len(args) == 1 {
+		config, err := projectConfigFromFlags(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return createProject(config)
+	}
+
+	for _, flag := range nonInteractiveNewFlags {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s needs a project name: cpx new <name> --%s ...", flag, flag)
+		}
+	}
+	if !stdinIsTerminal() {
+		return fmt.Errorf("cpx new is interactive and stdin is not a terminal\n  hint: pass a project name to create it from flags, e.g. cpx new myproj --lib --std 20 --pm meson --no-git")
+	}
+
+	model := tui.InitialModel()
+	if templateName != "" {
+		model = model.WithTemplate(templateName)
+	}
+
 	// Initialize and run the TUI
-	p := tea.NewProgram(tui.InitialModel())
+	p := tea.NewProgram(model)
 	m, err := p.Run()
 	if err != nil {
 		return fmt.Errorf("failed to run TUI: %w", err)
@@ -60,9 +136,210 @@ func runNew(_ *cobra.Command, _ []string) error {
 
 	// Get the configuration
 	config := finalModel.GetConfig()
+	config.SharedLibrary = libraryTypeFromFlags(cmd) == build.LibraryShared
+	config.PCH, _ = cmd.Flags().GetBool("pch")
+	config.Modules, _ = cmd.Flags().GetBool("modules")
+	config.NoTemplateHooks, _ = cmd.Flags().GetBool("no-hooks")
 
 	// Create the project with the configuration
-	return createProjectFromTUI(config)
+	return createProject(config)
+}
+
+// projectConfigFromFlags returns the configuration of a project created
+// without the TUI, with the TUI's defaults for options not given.
+func projectConfigFromFlags(cmd *cobra.Command, name string) (tui.ProjectConfig, error) {
+	flags := cmd.Flags()
+	if !tui.IsValidProjectName(name) {
+		return tui.ProjectConfig{}, fmt.Errorf("invalid project name '%s': use letters, numbers, hyphens and underscores", name)
+	}
+
+	config := tui.ProjectConfig{Name: name, VCS: "git"}
+	config.IsLibrary, _ = flags.GetBool("lib")
+	config.HeaderOnly, _ = flags.GetBool("header-only")
+	config.IsLibrary = config.IsLibrary || config.HeaderOnly
+	config.CppStandard, _ = flags.GetInt("std")
+	config.PackageManager, _ = flags.GetString("pm")
+	config.TestFramework, _ = flags.GetString("test")
+	config.Benchmark, _ = flags.GetString("bench")
+	config.ClangFormat, _ = flags.GetString("clang-format")
+	if noGit, _ := flags.GetBool("no-git"); noGit {
+		config.VCS = "none"
+	}
+	config.SharedLibrary = libraryTypeFromFlags(cmd) == build.LibraryShared
+	config.PCH, _ = flags.GetBool("pch")
+	config.Modules, _ = flags.GetBool("modules")
+	config.NoTemplateHooks, _ = flags.GetBool("no-hooks")
+	config.PackageManager = strings.ToLower(config.PackageManager)
+
+	choices := []struct {
+		flag, value string
+		allowed     []string
+	}{
+		{"--pm", config.PackageManager, []string{"vcpkg", "bazel", "meson"}},
+		{"--test", config.TestFramework, []string{"googletest", "catch2", "doctest", "none"}},
+		{"--bench", config.Benchmark, []string{"google-benchmark", "nanobench", "catch2-benchmark", "none"}},
+		{"--clang-format", config.ClangFormat, []string{"Google", "LLVM", "Chromium", "Mozilla", "WebKit"}},
+	}
+	for _, c := range choices {
+		if !slices.Contains(c.allowed, c.value) {
+			return tui.ProjectConfig{}, fmt.Errorf("invalid %s '%s': use one of %s", c.flag, c.value, strings.Join(c.allowed, ", "))
+		}
+	}
+	if !slices.Contains([]int{11, 14, 17, 20, 23}, config.CppStandard) {
+		return tui.ProjectConfig{}, fmt.Errorf("invalid --std %d: use 11, 14, 17, 20 or 23", config.CppStandard)
+	}
+
+	if templateName, _ := flags.GetString("template"); templateName != "" {
+		template, _ := project_templates.GetTemplateByName(templateName)
+		managers := project_templates.PackageManagers(template)
+		if !flags.Changed("pm") {
+			config.PackageManager = managers[0]
+		} else if !slices.Contains(managers, config.PackageManager) {
+			return tui.ProjectConfig{}, fmt.Errorf("template '%s' supports --pm %s, not %s", templateName, strings.Join(managers, ", "), config.PackageManager)
+		}
+		config.UseTemplate = true
+		config.TemplateName = templateName
+	}
+	return config, nil
+}
+
+// repoBoilerplateRe matches what a freshly created git hosting repository
+// may already hold; cpx new . keeps these files instead of overwriting them.
+var repoBoilerplateRe = regexp.MustCompile(`(?i)^(\.git|\.github|\.gitignore|\.gitattributes|(README|LICENSE|LICENCE|COPYING|CONTRIBUTING|CODE_OF_CONDUCT|SECURITY)(\.[a-z]+)?)$`)
+
+// newInPlace scaffolds a project, named after the current directory, into
+// the current directory, which must be empty apart from repository
+// boilerplate (a clone of a new GitHub repository, say). The project is
+// generated in a staging directory and moved into place.
+func newInPlace(cmd *cobra.Command) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	name := filepath.Base(cwd)
+	if !tui.IsValidProjectName(name) {
+		return fmt.Errorf("the current directory name '%s' is not a valid project name\n  hint: use letters, numbers, hyphens and underscores, or run cpx new <name> to create a subdirectory", name)
+	}
+
+	entries, err := os.ReadDir(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to read current directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !repoBoilerplateRe.MatchString(entry.Name()) {
+			return fmt.Errorf("the current directory is not empty (found %s)\n  hint: cpx new . only scaffolds into an empty directory or a fresh clone; run cpx new <name> to create a subdirectory", entry.Name())
+		}
+	}
+
+	config, err := projectConfigFromFlags(cmd, name)
+	if err != nil {
+		return err
+	}
+
+	staging, err := os.MkdirTemp(cwd, ".cpx-new-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+
+	if err := os.Chdir(staging); err != nil {
+		return err
+	}
+	err = createProjectFromTUI(config)
+	if chdirErr := os.Chdir(cwd); err == nil {
+		err = chdirErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := moveIntoPlace(filepath.Join(staging, name), cwd); err != nil {
+		return err
+	}
+	return runTemplateHooks(config, cwd)
+}
+
+// moveIntoPlace moves the entries of the generated project in src to dst,
+// keeping entries dst already has.
+func moveIntoPlace(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("failed to read generated project: %w", err)
+	}
+	for _, entry := range entries {
+		target := filepath.Join(dst, entry.Name())
+		if _, err := os.Lstat(target); err == nil {
+			if entry.Name() != ".git" {
+				output.Warnf("Kept the existing %s", entry.Name())
+			}
+			continue
+		}
+		if err := os.Rename(filepath.Join(src, entry.Name()), target); err != nil {
+			return fmt.Errorf("failed to move %s into place: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// createProject creates the project in a new directory and runs its
+// template's post-generate commands.
+func createProject(config tui.ProjectConfig) error {
+	if err := createProjectFromTUI(config); err != nil {
+		return err
+	}
+	return runTemplateHooks(config, config.Name)
+}
+
+// templateConfigFor returns the template configuration of a project.
+func templateConfigFor(config tui.ProjectConfig) project_templates.TemplateConfig {
+	cppStandard := config.CppStandard
+	if cppStandard == 0 {
+		cppStandard = 17
+	}
+	return project_templates.TemplateConfig{
+		ProjectName:    config.Name,
+		PackageManager: config.PackageManager,
+		CppStandard:    cppStandard,
+	}
+}
+
+// runTemplateHooks runs the post-generate commands of the project's
+// template, if any, in projectDir.
+func runTemplateHooks(config tui.ProjectConfig, projectDir string) error {
+	if !config.UseTemplate {
+		return nil
+	}
+	template, ok := project_templates.GetTemplateByName(config.TemplateName)
+	if !ok {
+		return nil
+	}
+	hooks := project_templates.PostGenerateHooks(template)
+	if len(hooks) == 0 {
+		return nil
+	}
+	if config.NoTemplateHooks {
+		output.Warnf("Skipped %d post-generate command(s) of template '%s' (--no-hooks)", len(hooks), config.TemplateName)
+		return nil
+	}
+	return runPostGenerateHooks(hooks, templateConfigFor(config), config.TemplateName, projectDir)
+}
+
+// writeProjectManifest writes the cpx.yaml of a new project in dir.
+func writeProjectManifest(dir string, cfg *tui.ProjectConfig) error {
+	cppStandard := cfg.CppStandard
+	if cppStandard == 0 {
+		cppStandard = 17
+	}
+	manifest := &config.Manifest{
+		Name:        cfg.Name,
+		Version:     "0.1.0",
+		CxxStandard: cppStandard,
+		BuildSystem: cfg.PackageManager,
+	}
+	if err := config.SaveManifest(filepath.Join(dir, config.ManifestFile), manifest); err != nil {
+		return fmt.Errorf("failed to write %s: %w", config.ManifestFile, err)
+	}
+	return nil
 }
 
 func createProjectFromTUI(config tui.ProjectConfig) error {
@@ -80,18 +357,29 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 			return fmt.Errorf("template '%s' not found", config.TemplateName)
 		}
 
-		cppStandard := config.CppStandard
-		if cppStandard == 0 {
-			cppStandard = 17
-		}
+		return template.Generate(templateConfigFor(config))
+	}
 
-		templateConfig := project_templates.TemplateConfig{
-			ProjectName:    projectName,
-			PackageManager: config.PackageManager,
-			CppStandard:    cppStandard,
+	// C++20 modules rely on CMake's module support
+	if config.Modules {
+		if config.PackageManager != "" && config.PackageManager != "vcpkg" {
+			return fmt.Errorf("C++20 modules projects need CMake (vcpkg); %s has no stable module support yet", config.PackageManager)
+		}
+		if out, err := exec.Command("cmake", "--version").Output(); err == nil && !cmakeSupportsModules(string(out)) {
+			output.Warnf("C++20 modules need CMake 3.28 or newer; %s", strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0])
 		}
+	}
 
-		return template.Generate(templateConfig)
+	// Header-only libraries have no sources to compile or link
+	if config.HeaderOnly {
+		switch {
+		case config.SharedLibrary:
+			return fmt.Errorf("header-only libraries are not built, so --shared does not apply")
+		case config.PCH:
+			return fmt.Errorf("header-only libraries have no sources to precompile a header for; drop --pch")
+		case config.Modules:
+			return fmt.Errorf("C++20 modules need a compiled library; pick Library instead of Header-only library")
+		}
 	}
 
 	// Custom project creation flow
@@ -113,7 +401,12 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 		GitHooks:       config.GitHooks,
 		PreCommit:      config.PreCommit,
 		PrePush:        config.PrePush,
+		CommitMsg:      config.CommitMsg,
 		Benchmark:      config.Benchmark,
+		SharedLibrary:  config.SharedLibrary,
+		PCH:            config.PCH,
+		Modules:        config.Modules,
+		HeaderOnly:     config.HeaderOnly,
 	}
 
 	// Set hooks
@@ -150,6 +443,9 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 	if cppStandard == 0 {
 		cppStandard = 17
 	}
+	if cfg.Modules && cppStandard < templates.ModulesMinCppStandard {
+		cppStandard = templates.ModulesMinCppStandard
+	}
 
 	projectVersion := "0.1.0"
 
@@ -159,11 +455,13 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 	// Create directory structure
 	dirs := []string{
 		"include/" + projectName,
-		"src",
 		"tests",
 		"scripts",
 		"docs",
 	}
+	if !cfg.HeaderOnly {
+		dirs = append(dirs, "src")
+	}
 	if benchSources != nil {
 		dirs = append(dirs, "bench")
 	}
@@ -193,6 +491,10 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 		CppStandard:   cppStandard,
 		TestFramework: cfg.TestFramework,
 		Benchmark:     cfg.Benchmark,
+		SharedLibrary: cfg.SharedLibrary,
+		PCH:           cfg.PCH,
+		Modules:       cfg.Modules,
+		HeaderOnly:    cfg.HeaderOnly,
 	}
 
 	// Generate build system files
@@ -206,30 +508,62 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 		return fmt.Errorf("failed to write version.hpp: %w", err)
 	}
 
-	// Generate header file
-	libHeader := templates.GenerateLibHeader(projectName)
-	if err := os.WriteFile(filepath.Join(projectName, "include/"+projectName+"/"+projectName+".hpp"), []byte(libHeader), 0644); err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
+	// Generate header file, or the module interface unit that replaces it
+	if cfg.Modules {
+		moduleInterface := templates.GenerateModuleInterface(projectName)
+		if err := os.WriteFile(filepath.Join(projectName, "src/"+projectName+".cppm"), []byte(moduleInterface), 0644); err != nil {
+			return fmt.Errorf("failed to write module interface: %w", err)
+		}
+	} else {
+		libHeader := templates.GenerateLibHeader(projectName)
+		if cfg.HeaderOnly {
+			libHeader = templates.GenerateHeaderOnlyLibHeader(projectName)
+		}
+		if err := os.WriteFile(filepath.Join(projectName, "include/"+projectName+"/"+projectName+".hpp"), []byte(libHeader), 0644); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+	}
+
+	// Generate the precompiled header
+	if cfg.PCH {
+		if err := os.MkdirAll(filepath.Join(projectName, "src", "pch"), 0755); err != nil {
+			return fmt.Errorf("failed to create src/pch: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(projectName, "src", "pch", "pch.hpp"), []byte(templates.GeneratePCHHeader()), 0644); err != nil {
+			return fmt.Errorf("failed to write pch.hpp: %w", err)
+		}
 	}
 
 	// Generate main.cpp for executables
 	if !cfg.IsLibrary {
 		mainCpp := templates.GenerateMainCpp(projectName)
+		if cfg.Modules {
+			mainCpp = templates.GenerateModuleMainCpp(projectName)
+		}
 		if err := os.WriteFile(filepath.Join(projectName, "src/main.cpp"), []byte(mainCpp), 0644); err != nil {
 			return fmt.Errorf("failed to write main.cpp: %w", err)
 		}
 	}
 
 	// Generate library source file
-	libSource := templates.GenerateLibSource(projectName)
-	if err := os.WriteFile(filepath.Join(projectName, "src/"+projectName+".cpp"), []byte(libSource), 0644); err != nil {
-		return fmt.Errorf("failed to write source: %w", err)
+	if !cfg.HeaderOnly {
+		libSource := templates.GenerateLibSource(projectName)
+		if cfg.Modules {
+			libSource = templates.GenerateModuleSource(projectName)
+		}
+		if err := os.WriteFile(filepath.Join(projectName, "src/"+projectName+".cpp"), []byte(libSource), 0644); err != nil {
+			return fmt.Errorf("failed to write source: %w", err)
+		}
 	}
 
 	// Generate benchmark files if enabled
 	if benchSources != nil {
 		benchPath := filepath.Join(projectName, "bench", "bench_main.cpp")
-		if err := os.WriteFile(benchPath, []byte(benchSources.Main), 0644); err != nil {
+		benchMain := benchSources.Main
+		if cfg.Modules {
+			benchMain = templates.ImportModule(benchMain, projectName)
+		}
+		if err := os.WriteFile(benchPath, []byte(benchMain), 0644); err != nil {
 			return fmt.Errorf("failed to write bench_main.cpp: %w", err)
 		}
 
@@ -278,6 +612,9 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 		}
 
 		testMain := templates.GenerateTestMain(projectName, cfg.TestFramework)
+		if cfg.Modules {
+			testMain = templates.ImportModule(testMain, projectName)
+		}
 		if err := os.WriteFile(filepath.Join(projectName, "tests/test_main.cpp"), []byte(testMain), 0644); err != nil {
 			return fmt.Errorf("failed to write tests/test_main.cpp: %w", err)
 		}
@@ -288,6 +625,9 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 	if err := os.WriteFile(filepath.Join(projectName, "cpx-ci.yaml"), []byte(cpxCI), 0644); err != nil {
 		return fmt.Errorf("failed to write cpx-ci.yaml: %w", err)
 	}
+	if err := writeProjectManifest(projectName, cfg); err != nil {
+		return err
+	}
 
 	// Setup vcpkg if enabled (skip for bazel)
 	if cfg.PackageManager == "vcpkg" {
@@ -311,13 +651,13 @@ func createProjectFromTUI(config tui.ProjectConfig) error {
 		gitInitCmd.Dir = projectName
 		if err := gitInitCmd.Run(); err == nil {
 			// Install hooks if configured
-			if cfg.UseHooks && (len(cfg.PreCommit) > 0 || len(cfg.PrePush) > 0) {
+			if cfg.UseHooks && (len(cfg.PreCommit) > 0 || len(cfg.PrePush) > 0 || cfg.CommitMsg) {
 				// Change to project directory to install hooks
 				originalDir, _ := os.Getwd()
 				_ = os.Chdir(projectName)
-				if err := git.InstallHooksWithConfig(cfg.PreCommit, cfg.PrePush); err != nil {
+				if err := git.InstallHooksWithConfig(cfg.PreCommit, cfg.PrePush, cfg.CommitMsg); err != nil {
 					// Non-fatal: just skip hooks if installation fails
-					fmt.Printf("%sWarning: Could not install git hooks: %v%s\n", colors.Yellow, err, colors.Reset)
+					output.Warnf("Could not install git hooks: %v", err)
 				}
 				_ = os.Chdir(originalDir)
 			}
@@ -383,7 +723,7 @@ func setupVcpkgProject(builder *vcpkg.Builder, targetDir, _ string, _ bool, depe
 	}
 
 	if len(dependencies) > 0 {
-		fmt.Printf("%s Adding dependencies from template...%s\n", colors.Cyan, colors.Reset)
+		output.Stepf(" Adding dependencies from template...")
 		for _, dep := range dependencies {
 			if dep == "" {
 				continue
@@ -404,3 +744,17 @@ func setupVcpkgProject(builder *vcpkg.Builder, targetDir, _ string, _ bool, depe
 
 	return nil
 }
+
+// cmakeSupportsModules reports whether the output of cmake --version is of
+// CMake 3.28 or newer, the first release with C++20 module support.
+func cmakeSupportsModules(versionOutput string) bool {
+	fields := strings.Fields(versionOutput)
+	if len(fields) < 3 {
+		return false
+	}
+	var major, minor int
+	if _, err := fmt.Sscanf(fields[2], "%d.%d", &major, &minor); err != nil {
+		return false
+	}
+	return major > 3 || (major == 3 && minor >= 28)
+}
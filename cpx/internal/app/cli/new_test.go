@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateModulesProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	config := tui.ProjectConfig{
+		Name:           "modapp",
+		PackageManager: "vcpkg",
+		CppStandard:    17,
+		TestFramework:  "googletest",
+		VCS:            "none",
+		Modules:        true,
+	}
+	require.NoError(t, createProjectFromTUI(config))
+
+	assert.FileExists(t, "modapp/src/modapp.cppm")
+	assert.FileExists(t, "modapp/include/modapp/version.hpp")
+	assert.NoFileExists(t, "modapp/include/modapp/modapp.hpp")
+
+	cmake, err := os.ReadFile("modapp/CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(cmake), "cmake_minimum_required(VERSION 3.28)")
+	assert.Contains(t, string(cmake), "set(CMAKE_CXX_STANDARD 20)", "modules raise the standard to C++20")
+	assert.Contains(t, string(cmake), "FILE_SET CXX_MODULES FILES src/modapp.cppm")
+
+	mainCpp, err := os.ReadFile("modapp/src/main.cpp")
+	require.NoError(t, err)
+	assert.Contains(t, string(mainCpp), "import modapp;")
+
+	testMain, err := os.ReadFile("modapp/tests/test_main.cpp")
+	require.NoError(t, err)
+	assert.Contains(t, string(testMain), "import modapp;")
+	assert.NotContains(t, string(testMain), "modapp.hpp")
+
+	testCMake, err := os.ReadFile("modapp/tests/CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(testCMake), "target_link_libraries(modapp_tests PRIVATE modapp_lib)")
+	assert.NotContains(t, string(testCMake), "../src/modapp.cpp")
+}
+
+func TestCreateModulesProjectNeedsCMake(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	err = createProjectFromTUI(tui.ProjectConfig{Name: "modlib", PackageManager: "meson", Modules: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "need CMake")
+	assert.NoDirExists(t, "modlib")
+}
+
+func TestCMakeSupportsModules(t *testing.T) {
+	assert.True(t, cmakeSupportsModules("cmake version 3.28.1\n\nCMake suite maintained by Kitware"))
+	assert.True(t, cmakeSupportsModules("cmake version 4.0.0"))
+	assert.False(t, cmakeSupportsModules("cmake version 3.27.9"))
+	assert.False(t, cmakeSupportsModules("garbage"))
+}
+
+func TestNewNonInteractive(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	oldStdin := stdinIsTerminal
+	defer func() { stdinIsTerminal = oldStdin }()
+	stdinIsTerminal = func() bool { return false }
+
+	cmd := NewCmd()
+	cmd.SetArgs([]string{"mylib", "--header-only", "--std", "20", "--pm", "meson", "--test", "none", "--no-git"})
+	require.NoError(t, cmd.Execute())
+	assert.FileExists(t, "mylib/include/mylib/mylib.hpp")
+	assert.NoDirExists(t, "mylib/src")
+	assert.NoDirExists(t, "mylib/.git")
+	mesonBuild, err := os.ReadFile("mylib/meson.build")
+	require.NoError(t, err)
+	assert.Contains(t, string(mesonBuild), "'cpp_std=c++20'")
+	manifest, err := config.LoadManifest("mylib/cpx.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, config.Manifest{ManifestVersion: config.ManifestVersion, Name: "mylib", Version: "0.1.0", CxxStandard: 20, BuildSystem: "meson"}, *manifest)
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{}, "stdin is not a terminal"},
+		{[]string{"--lib"}, "--lib needs a project name"},
+		{[]string{"app", "--pm", "cmake"}, "invalid --pm 'cmake'"},
+		{[]string{"app", "--std", "18"}, "invalid --std 18"},
+		{[]string{"app", "--test", "boost"}, "invalid --test 'boost'"},
+		{[]string{"bad name"}, "invalid project name"},
+		{[]string{"app", "--template", "CUDA", "--pm", "bazel"}, "template 'CUDA' supports --pm vcpkg, meson, not bazel"},
+		{[]string{"app", "--template", "nope"}, "template 'nope' not found"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd := NewCmd()
+			cmd.SetArgs(tt.args)
+			cmd.SilenceUsage = true
+			err := cmd.Execute()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.NoDirExists(t, "app")
+		})
+	}
+}
+
+func TestNewInPlace(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "my-repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("# my-repo\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "LICENSE"), []byte("MIT\n"), 0644))
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(repo))
+
+	cmd := NewCmd()
+	cmd.SetArgs([]string{".", "--test", "none"})
+	require.NoError(t, cmd.Execute())
+
+	assert.FileExists(t, "CMakeLists.txt")
+	assert.FileExists(t, "src/main.cpp")
+	assert.FileExists(t, "include/my-repo/my-repo.hpp")
+	readme, err := os.ReadFile("README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# my-repo\n", string(readme), "existing files are kept")
+	entries, err := os.ReadDir(".")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".cpx-new", "the staging directory is removed")
+	}
+
+	// Not empty any more
+	cmd = NewCmd()
+	cmd.SetArgs([]string{"."})
+	cmd.SilenceUsage = true
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the current directory is not empty")
+}
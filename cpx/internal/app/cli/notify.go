@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/notify"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// sendNotification is replaced in tests
+var sendNotification = notify.Send
+
+// withNotify runs fn and, when notify_after is set in the global config and
+// fn took at least that long, sends a desktop notification with the result.
+func withNotify(command string, fn func() error) error {
+	cfg, err := config.LoadGlobal()
+	if err != nil || cfg.NotifyAfter <= 0 {
+		return fn()
+	}
+
+	start := time.Now()
+	err = fn()
+	elapsed := time.Since(start)
+	if elapsed < time.Duration(cfg.NotifyAfter)*time.Second {
+		return err
+	}
+
+	title, message := notification(command, projectDirName(), elapsed, err)
+	if nerr := sendNotification(title, message); nerr != nil {
+		output.Debugf("notification failed: %v", nerr)
+	}
+	return err
+}
+
+// notification returns the title and message announcing that command
+// finished in project after elapsed, with err as its result.
+func notification(command, project string, elapsed time.Duration, err error) (string, string) {
+	title := "cpx " + command
+	if project != "" {
+		title += " · " + project
+	}
+	elapsed = elapsed.Round(time.Second)
+	if err != nil {
+		return title, fmt.Sprintf("✗ Failed after %s: %v", elapsed, err)
+	}
+	return title, fmt.Sprintf("✓ Succeeded in %s", elapsed)
+}
+
+// projectDirName names the project in the current directory.
+func projectDirName() string {
+	if root, err := findProjectRoot(); err == nil {
+		return filepath.Base(root)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		return filepath.Base(cwd)
+	}
+	return ""
+}
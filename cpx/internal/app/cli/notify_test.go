@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotification(t *testing.T) {
+	title, message := notification("build", "myapp", 83*time.Second+400*time.Millisecond, nil)
+	assert.Equal(t, "cpx build · myapp", title)
+	assert.Equal(t, "✓ Succeeded in 1m23s", message)
+
+	_, message = notification("test", "", 5*time.Second, errors.New("tests failed"))
+	assert.Equal(t, "✗ Failed after 5s: tests failed", message)
+}
+
+func TestWithNotify(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var sent []string
+	old := sendNotification
+	sendNotification = func(title, message string) error {
+		sent = append(sent, title+": "+message)
+		return nil
+	}
+	defer func() { sendNotification = old }()
+
+	// Off by default
+	require.NoError(t, withNotify("build", func() error { return nil }))
+	assert.Empty(t, sent)
+
+	require.NoError(t, config.SaveGlobal(&config.GlobalConfig{NotifyAfter: 3600}))
+	require.NoError(t, withNotify("build", func() error { return nil }))
+	assert.Empty(t, sent, "faster than notify_after")
+
+	// A run longer than notify_after notifies with its result
+	require.NoError(t, config.SaveGlobal(&config.GlobalConfig{NotifyAfter: 1}))
+	err := withNotify("build", func() error {
+		time.Sleep(1100 * time.Millisecond)
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0], "Failed after 1s: boom")
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/spf13/cobra"
+)
+
+// PackageCmd creates the package command
+func PackageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "package",
+		Short: "Package a library for consumers",
+		Long: `Build the library in release mode, install it with its CMake install()
+rules and archive the installed tree (headers, libraries and the exported
+<name>Config.cmake with its version file) as a relocatable tarball.
+
+Consumers extract the archive, add it to CMAKE_PREFIX_PATH and use
+find_package(<name> CONFIG) with target_link_libraries(app <name>::<name>).`,
+		Example: `  cpx package              # Writes dist/<name>-<version>-<os>-<arch>.tar.gz
+  cpx package -o out       # Write the archive to out/`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withBuildLog(cmd, "package", func() error { return runPackage(cmd, args) })
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().StringP("output", "o", "dist", "Directory to write the archive to")
+	cmd.Flags().Bool("verbose", false, "Show full build output")
+
+	return cmd
+}
+
+func runPackage(cmd *cobra.Command, _ []string) error {
+	outputDir, _ := cmd.Flags().GetString("output")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	if _, err := RequireProject("cpx package"); err != nil {
+		return err
+	}
+	builder, err := projectBuilder()
+	if err != nil {
+		return err
+	}
+	packager, ok := builder.(build.Packager)
+	if !ok {
+		return fmt.Errorf("cpx package is not supported for %s projects yet\n  hint: packaging uses the CMake install() rules of vcpkg/CMake projects", builder.Name())
+	}
+
+	_, err = packager.Package(context.Background(), build.PackageOptions{
+		OutputDir: outputDir,
+		Verbose:   verbose,
+	})
+	return err
+}
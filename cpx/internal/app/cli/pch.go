@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// pchFromConfig returns the "pch" setting of a cpx-ci.yaml, or nil when
+// the project has none.
+func pchFromConfig(path string) (*bool, error) {
+	ciConfig, err := config.LoadToolchains(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ciConfig.PCH, nil
+}
+
+// runPCHReport does a clean build without and then with the precompiled
+// header and reports the time saved. The project is left built with it.
+func runPCHReport(builder build.BuildSystem, opts build.BuildOptions) error {
+	var times []time.Duration
+	for _, enabled := range []bool{false, true} {
+		enabled := enabled
+		opts.PCH = &enabled
+		opts.Clean = true
+		if enabled {
+			output.Stepf("Clean build with the precompiled header...")
+		} else {
+			output.Stepf("Clean build without the precompiled header...")
+		}
+		start := time.Now()
+		if err := builder.Build(context.Background(), opts); err != nil {
+			return err
+		}
+		times = append(times, time.Since(start))
+	}
+	fmt.Print(formatPCHReport(times[0], times[1]))
+	return nil
+}
+
+// formatPCHReport formats the build times without and with the precompiled
+// header.
+func formatPCHReport(without, with time.Duration) string {
+	saved := without - with
+	percent := 0.0
+	if without > 0 {
+		percent = 100 * float64(saved) / float64(without)
+	}
+	round := func(d time.Duration) time.Duration { return d.Round(100 * time.Millisecond) }
+
+	s := fmt.Sprintf("\n%sPrecompiled header (clean builds)%s\n", colors.Bold, colors.Reset)
+	s += fmt.Sprintf("  without PCH  %s\n", round(without))
+	s += fmt.Sprintf("  with PCH     %s\n", round(with))
+	if saved >= 0 {
+		s += fmt.Sprintf("  %ssaved        %s (%.1f%%)%s\n", colors.Green, round(saved), percent, colors.Reset)
+	} else {
+		s += fmt.Sprintf("  %sslower by    %s (%.1f%%)%s\n", colors.Yellow, round(-saved), -percent, colors.Reset)
+		s += fmt.Sprintf("  %shint: keep only widely used, rarely changed headers in the PCH, or turn it off with \"pch: false\" in cpx-ci.yaml%s\n", colors.Gray, colors.Reset)
+	}
+	return s
+}
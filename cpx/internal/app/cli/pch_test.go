@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPCHFromConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	pch, err := pchFromConfig(filepath.Join(dir, "cpx-ci.yaml"))
+	require.NoError(t, err)
+	assert.Nil(t, pch, "no cpx-ci.yaml keeps the default")
+
+	path := filepath.Join(dir, "cpx-ci.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("toolchains: []\n"), 0644))
+	pch, err = pchFromConfig(path)
+	require.NoError(t, err)
+	assert.Nil(t, pch)
+
+	require.NoError(t, os.WriteFile(path, []byte("pch: false\n"), 0644))
+	pch, err = pchFromConfig(path)
+	require.NoError(t, err)
+	require.NotNil(t, pch)
+	assert.False(t, *pch)
+}
+
+func TestFormatPCHReport(t *testing.T) {
+	report := formatPCHReport(40*time.Second, 30*time.Second)
+	assert.Contains(t, report, "without PCH  40s")
+	assert.Contains(t, report, "with PCH     30s")
+	assert.Contains(t, report, "saved        10s (25.0%)")
+
+	report = formatPCHReport(10*time.Second, 11*time.Second)
+	assert.Contains(t, report, "slower by    1s (10.0%)")
+	assert.Contains(t, report, "pch: false")
+}
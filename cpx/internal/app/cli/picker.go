@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// lastTargetsFile remembers the target last picked for each command, so the
+// picker starts on it.
+func lastTargetsFile() string {
+	return filepath.Join(config.CacheDir(), "last-targets.json")
+}
+
+// allTests is the picker entry that runs every test.
+const allTests = "(all tests)"
+
+// targetPicker returns the picker cpx command offers when the project has
+// several targets and none was given, or nil when stdin is not a terminal.
+// With all, the picker starts with an entry for keeping the default.
+func targetPicker(command, all string) build.TargetPicker {
+	if !stdinIsTerminal() {
+		return nil
+	}
+	return func(candidates []string) (string, error) {
+		if all != "" {
+			candidates = append([]string{all}, candidates...)
+		}
+		choice, err := tui.RunTargetPicker("Which target should cpx "+command+" use?", candidates, loadLastTarget(command))
+		if err != nil {
+			return "", err
+		}
+		saveLastTarget(command, choice)
+		if choice == all {
+			return "", nil
+		}
+		return choice, nil
+	}
+}
+
+// loadLastTargets reads the remembered targets, if any.
+func loadLastTargets() map[string]string {
+	targets := map[string]string{}
+	if data, err := os.ReadFile(lastTargetsFile()); err == nil {
+		_ = json.Unmarshal(data, &targets)
+	}
+	return targets
+}
+
+func loadLastTarget(command string) string {
+	return loadLastTargets()[command]
+}
+
+// saveLastTarget remembers choice for command. Failing to is not an error:
+// the picker just starts at the top next time.
+func saveLastTarget(command, choice string) {
+	targets := loadLastTargets()
+	targets[command] = choice
+	data, err := json.MarshalIndent(targets, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(lastTargetsFile()), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(lastTargetsFile(), append(data, '\n'), 0644)
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastTarget(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	assert.Empty(t, loadLastTarget("run"))
+	saveLastTarget("run", "server")
+	saveLastTarget("test", allTests)
+	assert.Equal(t, "server", loadLastTarget("run"))
+	assert.Equal(t, allTests, loadLastTarget("test"))
+	assert.FileExists(t, lastTargetsFile())
+}
+
+func TestTargetPickerWithoutTerminal(t *testing.T) {
+	old := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	defer func() { stdinIsTerminal = old }()
+
+	assert.Nil(t, targetPicker("run", ""))
+}
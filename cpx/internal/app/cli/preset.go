@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// runPresetBuild builds a toolchain of one of the built-in types.
+func runPresetBuild(tc config.Toolchain, runner *config.Runner, projectRoot, outputDir string, env map[string]string, mesonArgs []string, options ToolchainBuildOptions) error {
+	switch tc.Type {
+	case config.ToolchainTypeWasm:
+		return runWasmBuild(tc, runner, projectRoot, env, mesonArgs, options)
+	case config.ToolchainTypeAndroid:
+		return runAndroidBuild(tc, runner, projectRoot, env, mesonArgs, options)
+	case config.ToolchainTypeMinGW:
+		return runMinGWBuild(tc, runner, projectRoot, outputDir, env, mesonArgs, options)
+	}
+	return fmt.Errorf("unknown toolchain type '%s' (supported: wasm, android, mingw)", tc.Type)
+}
+
+// scriptPaths are the directories a build script of a built-in toolchain type
+// works with, as seen by the script (on the host, or inside the container).
+type scriptPaths struct {
+	Source string
+	Cache  string
+	Output string
+}
+
+// runBuildScript runs the bash script returned by script, either on the host
+// (image == "") or in a container of image with the project mounted read-only
+// and cacheDir/outputDir mounted writable.
+func runBuildScript(image, projectRoot, cacheDir, outputDir string, env map[string]string, script func(scriptPaths) string) error {
+	var args []string
+	if image == "" {
+		if _, ok := env["VCPKG_ROOT"]; !ok {
+			if cfg, err := projectConfig(); err == nil && cfg.VcpkgRoot != "" {
+				env["VCPKG_ROOT"] = cfg.VcpkgRoot
+			}
+		}
+		args = []string{"bash", "-c", script(scriptPaths{Source: projectRoot, Cache: cacheDir, Output: outputDir})}
+	} else {
+		args = []string{containerRuntime().Name(), "run", "--rm",
+			"-v", projectRoot + ":/workspace:ro",
+			"-v", cacheDir + ":/tmp/cache",
+			"-v", outputDir + ":/output",
+			"-w", "/workspace",
+		}
+		keys := make([]string, 0, len(env))
+		for k := range env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			args = append(args, "-e", k+"="+env[k])
+		}
+		args = append(args, image, "bash", "-c", script(scriptPaths{Source: "/workspace", Cache: "/tmp/cache", Output: "/output"}))
+	}
+
+	cmd := execCommand(args[0], args[1:]...)
+	cmd.Dir = projectRoot
+	cmd.Env = os.Environ()
+	if image == "" {
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	cmd.Stdout = buildlog.Stdout()
+	cmd.Stderr = buildlog.Stderr()
+	return cmd.Run()
+}
+
+// ensureDockerImage pulls image if it is not available locally.
+func ensureDockerImage(image string) error {
+	rt := containerRuntime()
+	if rt.ImageExists(image) {
+		return nil
+	}
+	fmt.Fprintf(output.Stdout(), "  %s Pulling %s...%s\n", colors.Cyan, image, colors.Reset)
+	return rt.Pull(image, "", os.Stdout)
+}
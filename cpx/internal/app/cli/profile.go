@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/profile"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// ProfileCmd creates the profile command
+func ProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Profile the program and render a flamegraph",
+		Long: `Build the project in release mode and run it under the platform's sampling
+profiler: perf on Linux, sample on macOS. The samples are folded and rendered
+as a flamegraph SVG under .cache/profiles/<target>-<timestamp>, next to the raw
+profile and the folded stacks (which FlameGraph and speedscope also read).
+
+Arguments after -- are passed to the program.`,
+		Example: `  cpx profile                      # Profile the main executable
+  cpx profile --target app -- --size 1000
+  cpx profile --bench              # Profile the benchmarks`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withBuildLog(cmd, "profile", func() error { return runProfile(cmd, args) })
+		},
+	}
+
+	cmd.Flags().String("target", "", "Executable (or benchmark with --bench) to profile")
+	cmd.Flags().Bool("bench", false, "Profile the benchmark run instead of the program")
+	cmd.Flags().Int("frequency", 999, "Sampling frequency in Hz (perf)")
+	cmd.Flags().Int("duration", 10, "Seconds to sample for at most (macOS sample)")
+	cmd.Flags().Bool("verbose", false, "Show full build output")
+
+	_ = cmd.RegisterFlagCompletionFunc("target", completeTargets)
+
+	return cmd
+}
+
+func runProfile(cmd *cobra.Command, args []string) error {
+	target, _ := cmd.Flags().GetString("target")
+	bench, _ := cmd.Flags().GetBool("bench")
+	frequency, _ := cmd.Flags().GetInt("frequency")
+	duration, _ := cmd.Flags().GetInt("duration")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	if _, err := RequireProject("cpx profile"); err != nil {
+		return err
+	}
+	builder, err := projectBuilder()
+	if err != nil {
+		return err
+	}
+
+	name := target
+	if name == "" {
+		name = "main"
+		if bench {
+			name = "bench"
+		}
+	}
+	name = filepath.Base(name)
+	dir := filepath.Join(config.CacheDir(), "profiles", name+"-"+time.Now().Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	profiler, err := profile.New(runtime.GOOS, profile.Options{
+		Dir:       dir,
+		Frequency: frequency,
+		Duration:  duration,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	output.Stepf("Profiling with %s...", profiler.Name)
+	var runErr error
+	if bench {
+		runErr = builder.Bench(ctx, build.BenchOptions{
+			Target:  target,
+			Verbose: verbose,
+			Wrapper: profiler.Wrapper,
+		})
+	} else {
+		runErr = builder.Run(ctx, build.RunOptions{
+			Release: true,
+			Target:  target,
+			Args:    args,
+			Verbose: verbose,
+			Wrapper: profiler.Wrapper,
+		})
+	}
+
+	// A program that exits with an error still leaves a useful profile
+	stacks, err := profiler.Collect()
+	if err != nil {
+		if runErr != nil {
+			return runErr
+		}
+		return err
+	}
+	svg, err := profile.Write(dir, stacks, name)
+	if err != nil {
+		return err
+	}
+
+	output.Successf("✓ Flamegraph written to %s", svg)
+	fmt.Fprintf(output.Stdout(), "  %sOpen it in a browser to zoom into frames; folded stacks are in %s%s\n",
+		colors.Gray, filepath.Join(dir, "stacks.folded"), colors.Reset)
+	return runErr
+}
+
+// heapSites is how many allocation sites cpx run --heap-profile prints.
+const heapSites = 10
+
+// runHeapProfile runs the program under a heap profiler, keeping its profile
+// in .cache/profiles, and prints the sites that allocated the most memory.
+func runHeapProfile(opts build.RunOptions) error {
+	builder, err := projectBuilder()
+	if err != nil {
+		return err
+	}
+
+	name := "main"
+	if opts.Target != "" {
+		name = filepath.Base(opts.Target)
+	}
+	dir := filepath.Join(config.CacheDir(), "profiles", "heap-"+name+"-"+time.Now().Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	profiler, err := profile.NewHeap(runtime.GOOS, profile.Options{Dir: dir})
+	if err != nil {
+		return err
+	}
+	opts.Wrapper = profiler.Wrapper
+
+	output.Stepf("Heap profiling with %s...", profiler.Name)
+	runErr := builder.Run(context.Background(), opts)
+
+	sites, err := profiler.TopSites(heapSites)
+	if err != nil {
+		if runErr != nil {
+			return runErr
+		}
+		return err
+	}
+
+	fmt.Println()
+	output.Stepf("Top allocation sites:")
+	for i, site := range sites {
+		calls := ""
+		if site.Calls > 0 {
+			calls = fmt.Sprintf(" %sin %d allocations%s", colors.Gray, site.Calls, colors.Reset)
+		}
+		fmt.Printf("  %2d. %10s  %s%s\n", i+1, profile.FormatBytes(site.Bytes), site.Location, calls)
+	}
+	output.Successf("✓ Heap profile written to %s", profiler.Output)
+	return runErr
+}
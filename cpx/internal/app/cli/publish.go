@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/spf13/cobra"
+)
+
+// PublishCmd creates the publish command
+func PublishCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish a library to a vcpkg registry or as a Conan recipe",
+		Long: `Generate a vcpkg port (portfile.cmake and vcpkg.json) for the current
+library, pinned to the SHA512 of its source archive, and commit it with the
+matching version database entries to a branch of a git registry.
+
+The branch is pushed and, for GitHub registries with the gh CLI installed, a
+pull request is opened. The source archive defaults to the GitHub archive of
+the v<version> tag of the origin remote, so tag and push the release first.
+
+With --conan, write a conanfile.py and test_package/ into the project instead,
+with the vcpkg dependencies mapped to ConanCenter requirements. Package the
+library for Conan users with "conan create .".`,
+		Example: `  cpx publish --registry git@github.com:acme/vcpkg-registry.git
+  cpx publish --registry <git-url> --source-url https://example.com/mylib-1.2.0.tar.gz
+  cpx publish --registry <git-url> --no-push   # Keep the commit in a local clone
+  cpx publish --conan                          # Write a Conan recipe`,
+		RunE: runPublish,
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().String("registry", "", "Git URL of the vcpkg registry repository")
+	cmd.Flags().String("source-url", "", "URL of the source archive the port downloads")
+	cmd.Flags().String("branch", "", "Registry branch to commit to (default: cpx/<name>-<version>)")
+	cmd.Flags().Bool("no-push", false, "Commit to a local clone of the registry without pushing")
+	cmd.Flags().Bool("conan", false, "Write a Conan recipe (conanfile.py and test_package/) instead")
+	cmd.MarkFlagsMutuallyExclusive("conan", "registry")
+
+	return cmd
+}
+
+func runPublish(cmd *cobra.Command, _ []string) error {
+	registry, _ := cmd.Flags().GetString("registry")
+	sourceURL, _ := cmd.Flags().GetString("source-url")
+	branch, _ := cmd.Flags().GetString("branch")
+	noPush, _ := cmd.Flags().GetBool("no-push")
+	conan, _ := cmd.Flags().GetBool("conan")
+
+	if registry == "" && !conan {
+		return fmt.Errorf("no registry given\n  hint: cpx publish --registry <git-url>, or --conan for a Conan recipe")
+	}
+
+	if _, err := RequireProject("cpx publish"); err != nil {
+		return err
+	}
+	builder, err := projectBuilder()
+	if err != nil {
+		return err
+	}
+	publisher, ok := builder.(build.Publisher)
+	if !ok {
+		return fmt.Errorf("cpx publish is not supported for %s projects yet\n  hint: publishing generates a vcpkg port or Conan recipe for vcpkg/CMake libraries", builder.Name())
+	}
+
+	return publisher.Publish(context.Background(), build.PublishOptions{
+		Registry:  registry,
+		SourceURL: sourceURL,
+		Branch:    branch,
+		NoPush:    noPush,
+		Conan:     conan,
+	})
+}
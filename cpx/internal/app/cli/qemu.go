@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// hostArch is the docker architecture of the host.
+var hostArch = runtime.GOARCH
+
+// binfmtDir is where the kernel lists registered binfmt_misc handlers.
+var binfmtDir = "/proc/sys/fs/binfmt_misc"
+
+// binfmtImage registers QEMU binfmt handlers on the host.
+const binfmtImage = "tonistiigi/binfmt"
+
+// qemuArchs maps docker architectures to QEMU's names for them.
+var qemuArchs = map[string]string{
+	"amd64":   "x86_64",
+	"arm64":   "aarch64",
+	"arm":     "arm",
+	"386":     "i386",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+	"riscv64": "riscv64",
+}
+
+// gnuTriples maps docker architectures to the GNU triples used by Debian/Ubuntu
+// cross toolchains, which install the target sysroot to /usr/<triple>.
+var gnuTriples = map[string]string{
+	"amd64":   "x86_64-linux-gnu",
+	"arm64":   "aarch64-linux-gnu",
+	"arm":     "arm-linux-gnueabihf",
+	"386":     "i686-linux-gnu",
+	"ppc64le": "powerpc64le-linux-gnu",
+	"s390x":   "s390x-linux-gnu",
+	"riscv64": "riscv64-linux-gnu",
+}
+
+// platformArch returns the architecture of a docker platform
+// ("linux/arm64/v8" -> "arm64").
+func platformArch(platform string) string {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// prepareEmulation makes sure foreign-architecture code of a docker runner can
+// run on this host. A runner whose container platform is foreign always needs
+// QEMU; cross-built binaries (target_platform) only when they are executed.
+// It returns extra container environment for running cross-built binaries.
+func prepareEmulation(runner *config.Runner, execute bool) (map[string]string, error) {
+	containerArch := platformArch(runner.Platform)
+	if containerArch == "" {
+		containerArch = hostArch
+	}
+	if containerArch != hostArch {
+		if err := ensureBinfmt(containerArch); err != nil {
+			return nil, err
+		}
+	}
+
+	targetArch := platformArch(runner.TargetPlatform)
+	if !execute || targetArch == "" || targetArch == containerArch {
+		return nil, nil
+	}
+	if targetArch != hostArch {
+		if err := ensureBinfmt(targetArch); err != nil {
+			return nil, err
+		}
+	}
+
+	// Cross-built binaries are dynamically linked against the target's loader,
+	// which lives in the cross toolchain's sysroot rather than at /lib
+	sysroot := runner.Sysroot
+	if sysroot == "" {
+		triple, ok := gnuTriples[targetArch]
+		if !ok {
+			return nil, fmt.Errorf("unknown sysroot for %s; set 'sysroot' on runner '%s'", runner.TargetPlatform, runner.Name)
+		}
+		sysroot = "/usr/" + triple
+	}
+	fmt.Fprintf(output.Stdout(), "  %s Running %s binaries under QEMU (sysroot %s)%s\n", colors.Cyan, targetArch, sysroot, colors.Reset)
+	return map[string]string{"QEMU_LD_PREFIX": sysroot}, nil
+}
+
+// ensureBinfmt registers a QEMU user-mode handler for arch with the kernel
+// if there is none yet, so foreign binaries run transparently in containers.
+func ensureBinfmt(arch string) error {
+	qemuArch, ok := qemuArchs[arch]
+	if !ok {
+		return fmt.Errorf("cannot emulate unsupported architecture '%s'", arch)
+	}
+	// Docker Desktop ships its VM with QEMU handlers registered
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(binfmtDir, "qemu-"+qemuArch)); err == nil {
+		return nil
+	}
+
+	fmt.Fprintf(output.Stdout(), "  %s Registering QEMU emulation for %s...%s\n", colors.Cyan, arch, colors.Reset)
+	rt := containerRuntime()
+	cmd := rt.Command("run", "--privileged", "--rm", binfmtImage, "--install", arch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		fmt.Print(string(out))
+		return fmt.Errorf("failed to register QEMU emulation for %s: %w\n  hint: install qemu-user-static and binfmt-support, or run '%s run --privileged --rm %s --install %s'", arch, err, rt.Name(), binfmtImage, arch)
+	}
+	return nil
+}
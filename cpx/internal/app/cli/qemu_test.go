@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlatformArch(t *testing.T) {
+	assert.Equal(t, "arm64", platformArch("linux/arm64"))
+	assert.Equal(t, "arm", platformArch("linux/arm/v7"))
+	assert.Equal(t, "", platformArch(""))
+	assert.Equal(t, "", platformArch("arm64"))
+}
+
+// withHost fakes the host architecture and its registered binfmt handlers.
+func withHost(t *testing.T, arch string, handlers ...string) {
+	t.Helper()
+	oldArch, oldDir := hostArch, binfmtDir
+	t.Cleanup(func() { hostArch, binfmtDir = oldArch, oldDir })
+
+	hostArch = arch
+	binfmtDir = t.TempDir()
+	for _, h := range handlers {
+		require.NoError(t, os.WriteFile(filepath.Join(binfmtDir, h), []byte("enabled\n"), 0644))
+	}
+}
+
+func TestPrepareEmulationNative(t *testing.T) {
+	withHost(t, "amd64")
+	calls := mockDockerCommands(t, "")
+
+	env, err := prepareEmulation(&config.Runner{Name: "gcc", Type: "docker", Platform: "linux/amd64"}, true)
+	require.NoError(t, err)
+	assert.Empty(t, env)
+	assert.Empty(t, *calls)
+}
+
+func TestPrepareEmulationForeignContainer(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("binfmt registration is only checked on Linux")
+	}
+	withHost(t, "amd64")
+	calls := mockDockerCommands(t, "")
+
+	env, err := prepareEmulation(&config.Runner{Name: "arm", Type: "docker", Platform: "linux/arm64"}, false)
+	require.NoError(t, err)
+	assert.Empty(t, env, "binaries built inside the target container need no sysroot")
+	require.Len(t, *calls, 1)
+	assert.Equal(t, []string{"docker", "run", "--privileged", "--rm", binfmtImage, "--install", "arm64"}, (*calls)[0])
+
+	// Already registered handlers are reused
+	withHost(t, "amd64", "qemu-aarch64")
+	calls = mockDockerCommands(t, "")
+	_, err = prepareEmulation(&config.Runner{Name: "arm", Type: "docker", Platform: "linux/arm64"}, true)
+	require.NoError(t, err)
+	assert.Empty(t, *calls)
+}
+
+func TestPrepareEmulationCrossCompiled(t *testing.T) {
+	withHost(t, "amd64", "qemu-aarch64")
+	mockDockerCommands(t, "")
+	runner := &config.Runner{Name: "cross", Type: "docker", TargetPlatform: "linux/arm64"}
+
+	env, err := prepareEmulation(runner, false)
+	require.NoError(t, err)
+	assert.Empty(t, env, "nothing to do when binaries are not executed")
+
+	env, err = prepareEmulation(runner, true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"QEMU_LD_PREFIX": "/usr/aarch64-linux-gnu"}, env)
+
+	runner.Sysroot = "/opt/sysroot"
+	env, err = prepareEmulation(runner, true)
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/sysroot", env["QEMU_LD_PREFIX"])
+}
+
+func TestEnsureBinfmtUnsupported(t *testing.T) {
+	err := ensureBinfmt("sparc")
+	assert.Error(t, err)
+}
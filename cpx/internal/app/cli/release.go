@@ -1,43 +1,401 @@
 package cli
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ozacod/cpx/internal/pkg/templates"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/git"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
 func ReleaseCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "release",
-		Short: "Bump version number",
-		Long:  "Bump version number (major, minor, or patch) in CMakeLists.txt. Defaults to patch if not specified.",
-		RunE:  runRelease,
-		Args:  cobra.MaximumNArgs(1),
+		Use:   "release [major|minor|patch]",
+		Short: "Bump the version and release the project",
+		Long: `Bump the version number (major, minor, or patch; default patch) in
+CMakeLists.txt and include/<project>/version.hpp, and add a section listing the
+commits since the last tag to CHANGELOG.md if the project has one.
+
+The release section of cpx-ci.yaml adds more steps:
+
+  release:
+    changelog: docs/CHANGELOG.md  # created if missing
+    tag: true                     # commit the release and tag it v<version>
+    tag_prefix: v
+    push: true                    # push the commit and tag (implies tag)
+    remote: origin
+    artifacts: true               # build and archive every active toolchain
+    toolchains: [linux, windows]  # only these toolchains (default: all active)
+    dist: dist
+    publisher: Acme               # package metadata, default from vcpkg.json
+    download_url: https://example.com/{tag}/{file}  # default: the GitHub release
+    brew:                         # Homebrew formula of the archives
+      tap: acme/homebrew-tools    # push it to this tap
+    winget:                       # winget manifests of the Windows archives
+      package_identifier: Acme.MyLib
+      pull_request: true          # submit them to microsoft/winget-pkgs
+    chocolatey:                   # Chocolatey package of the Windows archives
+      id: mylib
+
+With artifacts (or --artifacts), the release builds the CI toolchains of
+cpx-ci.yaml after the version bump and archives the artifacts of each as
+dist/<project>-<version>-<os>-<arch>.tar.gz, or .zip for Windows, next to a
+checksums.txt, ready for upload. The platform comes from the toolchain: its
+type, the docker platform of its runner or the host. With signing.macos in
+cpx-ci.yaml, the binaries of macOS toolchains are signed with the Developer ID
+and, given a keychain_profile, notarized before they are archived; with
+signing.windows, the .exe and .dll files of Windows toolchains are signed with
+signtool, or osslsigncode on other hosts.
+
+With brew (or --brew, which implies --artifacts), the release writes
+dist/<project>.rb, a Homebrew formula with the URL and SHA-256 of every macOS
+and Linux archive. Description, homepage and license come from vcpkg.json.
+Given a tap, the formula is committed to its Formula directory and pushed
+last, after the post_release hooks, which can upload the archives.
+
+With winget (or --winget) and chocolatey (or --chocolatey), which imply
+--artifacts too, the release writes winget manifests of the Windows archives to
+dist/winget/manifests and a Chocolatey package, a nuspec with an install
+script, to dist/chocolatey/<id>. Both need a publisher and a description,
+winget a license as well. With pull_request, the manifests are pushed to a
+fork of microsoft/winget-pkgs and a pull request is opened with gh, last.
+
+--dry-run prints every step in order, with the changelog section and the
+hooks that would run, and changes nothing; with --json the plan is printed as
+JSON for release automation to review.`,
+		Example: `  cpx release minor --dry-run
+  cpx release --dry-run --json
+  cpx release patch --tag --push
+  cpx release minor --artifacts
+  cpx release --brew --dry-run
+  cpx release --winget --chocolatey --dry-run`,
+		RunE: runRelease,
+		Args: cobra.MaximumNArgs(1),
 	}
 
+	cmd.Flags().Bool("dry-run", false, "Print what the release would do without doing it")
+	cmd.Flags().Bool("tag", false, "Commit the release and tag it (release.tag)")
+	cmd.Flags().Bool("push", false, "Push the release commit and tag (release.push, implies --tag)")
+	cmd.Flags().Bool("artifacts", false, "Build the CI toolchains and archive their artifacts into dist (release.artifacts)")
+	cmd.Flags().Bool("brew", false, "Write a Homebrew formula of the archives (release.brew, implies --artifacts)")
+	cmd.Flags().Bool("winget", false, "Write winget manifests of the Windows archives (release.winget, implies --artifacts)")
+	cmd.Flags().Bool("chocolatey", false, "Write a Chocolatey package of the Windows archives (release.chocolatey, implies --artifacts)")
+
 	return cmd
 }
 
-func runRelease(_ *cobra.Command, args []string) error {
+// releaseStep is one thing cpx release does. Steps run in order; a dry run
+// prints them instead.
+type releaseStep struct {
+	Kind        string   `json:"kind"`
+	Description string   `json:"description"`
+	Details     []string `json:"details,omitempty"`
+
+	run func() error
+}
+
+// releasePlan is everything a release does.
+type releasePlan struct {
+	Project    string        `json:"project"`
+	Version    string        `json:"version"`
+	NewVersion string        `json:"new_version"`
+	Tag        string        `json:"tag,omitempty"`
+	Artifacts  []string      `json:"artifacts,omitempty"`
+	Steps      []releaseStep `json:"steps"`
+}
+
+func (p *releasePlan) add(kind, description string, details []string, run func() error) {
+	p.Steps = append(p.Steps, releaseStep{Kind: kind, Description: description, Details: details, run: run})
+}
+
+// print writes the plan as a numbered list of steps.
+func (p *releasePlan) print(w io.Writer) {
+	fmt.Fprintf(w, "%sRelease plan for %s: %s → %s%s %s(dry run, nothing is changed)%s\n\n",
+		colors.Cyan, p.Project, p.Version, p.NewVersion, colors.Reset, colors.Gray, colors.Reset)
+	for i, step := range p.Steps {
+		fmt.Fprintf(w, "  %2d. %-9s %s\n", i+1, step.Kind, step.Description)
+		for _, line := range step.Details {
+			fmt.Fprintf(w, "                %s%s%s\n", colors.Gray, line, colors.Reset)
+		}
+	}
+}
+
+func (p *releasePlan) run() error {
+	for _, step := range p.Steps {
+		if err := step.run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseNow is the time releases are dated with, a variable for tests.
+var releaseNow = time.Now
+
+func runRelease(cmd *cobra.Command, args []string) error {
 	bumpType := "patch"
 	if len(args) > 0 {
 		bumpType = args[0]
 	}
-	return bumpVersion(bumpType)
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	tag, _ := cmd.Flags().GetBool("tag")
+	push, _ := cmd.Flags().GetBool("push")
+	artifacts, _ := cmd.Flags().GetBool("artifacts")
+	flags := config.ReleaseConfig{Tag: tag, Push: push, Artifacts: artifacts}
+	if brew, _ := cmd.Flags().GetBool("brew"); brew {
+		flags.Brew = &config.BrewConfig{}
+	}
+	if winget, _ := cmd.Flags().GetBool("winget"); winget {
+		flags.Winget = &config.WingetConfig{}
+	}
+	if chocolatey, _ := cmd.Flags().GetBool("chocolatey"); chocolatey {
+		flags.Chocolatey = &config.ChocolateyConfig{}
+	}
+
+	plan, err := planRelease(bumpType, flags)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		output.Stepf(" Bumping version: %s → %s", plan.Version, plan.NewVersion)
+		return plan.run()
+	}
+	if jsonOutput(cmd) {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+	plan.print(os.Stdout)
+	return nil
+}
+
+// planRelease works out the steps of a release of the project in the
+// current directory. flags turn on steps on top of the release section of
+// cpx-ci.yaml.
+func planRelease(bumpType string, flags config.ReleaseConfig) (*releasePlan, error) {
+	root, err := findProjectRoot()
+	if err != nil {
+		return nil, err
+	}
+	var hooks *config.CommandHooks
+	settings := flags
+	ciConfig, err := config.LoadToolchains(filepath.Join(root, "cpx-ci.yaml"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if ciConfig != nil {
+		hooks = ciConfig.Hooks
+		if ciConfig.Release != nil {
+			settings = *ciConfig.Release
+			settings.Tag = settings.Tag || flags.Tag
+			settings.Push = settings.Push || flags.Push
+			settings.Artifacts = settings.Artifacts || flags.Artifacts
+			settings.Brew = cmp.Or(settings.Brew, flags.Brew)
+			settings.Winget = cmp.Or(settings.Winget, flags.Winget)
+			settings.Chocolatey = cmp.Or(settings.Chocolatey, flags.Chocolatey)
+		}
+	}
+	settings.Tag = settings.Tag || settings.Push
+	settings.Artifacts = settings.Artifacts || settings.Brew != nil || settings.Winget != nil || settings.Chocolatey != nil
+
+	bump, err := planVersionBump(bumpType)
+	if err != nil {
+		return nil, err
+	}
+	plan := &releasePlan{Project: bump.project, Version: bump.version, NewVersion: bump.newVersion}
+
+	if commands := hooks.Commands("pre_release"); len(commands) > 0 {
+		plan.add("hook", "run the pre_release hooks", commands, func() error {
+			return runCommandHooks(hooks, "pre_release", root)
+		})
+	}
+
+	changed := []string{}
+	for _, edit := range bump.edits {
+		plan.add("version", fmt.Sprintf("%s: version %s → %s", edit.path, bump.version, bump.newVersion), nil, func() error {
+			if err := os.WriteFile(edit.path, []byte(edit.content), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", edit.path, err)
+			}
+			output.Successf(" Version updated to %s in %s", bump.newVersion, edit.path)
+			return nil
+		})
+		changed = append(changed, edit.path)
+	}
+
+	changelog := settings.Changelog
+	if changelog == "" {
+		if _, err := os.Stat("CHANGELOG.md"); err == nil {
+			changelog = "CHANGELOG.md"
+		}
+	}
+	if changelog != "" {
+		subjects, since := releaseCommits(root)
+		section := git.ChangelogSection(bump.newVersion, releaseNow().Format("2006-01-02"), subjects)
+		description := fmt.Sprintf("%s: add the %s section (%d commits", changelog, bump.newVersion, len(subjects))
+		if since != "" {
+			description += " since " + since
+		}
+		plan.add("changelog", description+")", strings.Split(strings.TrimSuffix(section, "\n"), "\n"), func() error {
+			data, err := os.ReadFile(changelog)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to read %s: %w", changelog, err)
+			}
+			if err := os.WriteFile(changelog, []byte(git.InsertChangelogSection(string(data), section)), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", changelog, err)
+			}
+			output.Successf(" Added the %s section to %s", bump.newVersion, changelog)
+			return nil
+		})
+		changed = append(changed, changelog)
+	}
+
+	formula := ""
+	var winget *wingetRelease
+	if settings.Artifacts {
+		archives, err := planReleaseArtifacts(plan, settings, ciConfig)
+		if err != nil {
+			return nil, err
+		}
+		if settings.Brew != nil {
+			if formula, err = planReleaseBrew(plan, root, settings, archives); err != nil {
+				return nil, err
+			}
+		}
+		if settings.Winget != nil || settings.Chocolatey != nil {
+			if winget, err = planWindowsPackages(plan, root, settings, archives); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if settings.Tag {
+		if err := planReleaseTag(plan, root, settings, changed); err != nil {
+			return nil, err
+		}
+	}
+
+	if commands := hooks.Commands("post_release"); len(commands) > 0 {
+		plan.add("hook", "run the post_release hooks", commands, func() error {
+			return runCommandHooks(hooks, "post_release", root)
+		})
+	}
+
+	// last, so post_release hooks can upload the archives the formula
+	// and manifests point to
+	if formula != "" && settings.Brew.Tap != "" {
+		planBrewTap(plan, root, settings.Brew.Tap, formula)
+	}
+	if winget != nil && settings.Winget.PullRequest {
+		if err := planWingetPullRequest(plan, root, winget); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// planReleaseTag adds the steps committing the changed files, tagging the
+// commit and, with settings.Push, pushing both.
+func planReleaseTag(plan *releasePlan, root string, settings config.ReleaseConfig, changed []string) error {
+	if _, err := runGit(root, "rev-parse", "--verify", "HEAD"); err != nil {
+		return fmt.Errorf("release.tag needs a git repository with a commit")
+	}
+	plan.Tag = releaseTag(settings, plan.NewVersion)
+	if _, err := runGit(root, "rev-parse", "--verify", "--quiet", "refs/tags/"+plan.Tag); err == nil {
+		return fmt.Errorf("tag %s already exists\n  hint: bump to another version or delete the tag", plan.Tag)
+	}
+	message := "Release " + plan.NewVersion
+
+	plan.add("commit", fmt.Sprintf("commit %s: %q", strings.Join(changed, ", "), message), nil, func() error {
+		if _, err := runGit(".", append([]string{"add", "--"}, changed...)...); err != nil {
+			return err
+		}
+		if _, err := runGit(".", "commit", "-m", message); err != nil {
+			return err
+		}
+		output.Successf(" Committed %s", message)
+		return nil
+	})
+	plan.add("tag", "create the annotated tag "+plan.Tag, nil, func() error {
+		if _, err := runGit(".", "tag", "-a", plan.Tag, "-m", message); err != nil {
+			return err
+		}
+		output.Successf(" Tagged %s", plan.Tag)
+		return nil
+	})
+
+	if !settings.Push {
+		return nil
+	}
+	remote := settings.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+	url, err := runGit(root, "remote", "get-url", remote)
+	if err != nil {
+		return fmt.Errorf("unknown git remote '%s'\n  hint: set release.remote in cpx-ci.yaml", remote)
+	}
+	plan.add("upload", fmt.Sprintf("push the release commit and %s to %s", plan.Tag, remote), []string{url}, func() error {
+		if _, err := runGit(".", "push", remote, "HEAD", plan.Tag); err != nil {
+			return err
+		}
+		output.Successf(" Pushed %s to %s", plan.Tag, remote)
+		return nil
+	})
+	return nil
+}
+
+// releaseTag returns the name of the tag of version.
+func releaseTag(settings config.ReleaseConfig, version string) string {
+	return cmp.Or(settings.TagPrefix, "v") + version
 }
 
-func bumpVersion(bumpType string) error {
+// releaseCommits returns the subjects of the commits since the last tag,
+// newest first, and that tag; all commits when there is none.
+func releaseCommits(root string) (subjects []string, since string) {
+	revs := "HEAD"
+	if tag, err := runGit(root, "describe", "--tags", "--abbrev=0"); err == nil {
+		since, revs = tag, tag+"..HEAD"
+	}
+	log, err := runGit(root, "log", "--format=%s", revs)
+	if err != nil || log == "" {
+		return nil, since
+	}
+	return strings.Split(log, "\n"), since
+}
+
+// fileEdit is new content for a file.
+type fileEdit struct {
+	path    string
+	content string
+}
+
+// versionBump is a version change and the files it rewrites.
+type versionBump struct {
+	project    string
+	version    string
+	newVersion string
+	edits      []fileEdit
+}
+
+// planVersionBump works out the new version and the content of the files
+// that hold it.
+func planVersionBump(bumpType string) (*versionBump, error) {
 	// Read version from CMakeLists.txt
 	cmakeContent, err := os.ReadFile("CMakeLists.txt")
 	if err != nil {
-		return fmt.Errorf("failed to read CMakeLists.txt: %w", err)
+		return nil, fmt.Errorf("failed to read CMakeLists.txt: %w", err)
 	}
 
 	// Find VERSION in project() declaration
@@ -45,7 +403,7 @@ func bumpVersion(bumpType string) error {
 	matches := projectRegex.FindStringSubmatch(string(cmakeContent))
 
 	if len(matches) < 3 {
-		return fmt.Errorf("could not find VERSION in CMakeLists.txt project() declaration")
+		return nil, fmt.Errorf("could not find VERSION in CMakeLists.txt project() declaration")
 	}
 
 	projectName := matches[1]
@@ -73,12 +431,11 @@ func bumpVersion(bumpType string) error {
 	case "patch":
 		patch++
 	default:
-		return fmt.Errorf("invalid bump type: %s (use major, minor, or patch)", bumpType)
+		return nil, fmt.Errorf("invalid bump type: %s (use major, minor, or patch)", bumpType)
 	}
 
 	newVersion := fmt.Sprintf("%d.%d.%d", major, minor, patch)
-
-	fmt.Printf("%s Bumping version: %s → %s%s\n", colors.Cyan, version, newVersion, colors.Reset)
+	bump := &versionBump{project: projectName, version: version, newVersion: newVersion}
 
 	// Replace version in CMakeLists.txt
 	newContent := projectRegex.ReplaceAllStringFunc(string(cmakeContent), func(match string) string {
@@ -88,24 +445,15 @@ func bumpVersion(bumpType string) error {
 		}
 		return strings.Replace(match, subMatches[2], newVersion, 1)
 	})
-
-	if err := os.WriteFile("CMakeLists.txt", []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write CMakeLists.txt: %w", err)
-	}
-
-	fmt.Printf("%s Version updated to %s in CMakeLists.txt%s\n", colors.Green, newVersion, colors.Reset)
+	bump.edits = append(bump.edits, fileEdit{path: "CMakeLists.txt", content: newContent})
 
 	// Update version.hpp if it exists
 	versionHeaderPath := filepath.Join("include", projectName, "version.hpp")
 	if _, err := os.Stat(versionHeaderPath); err == nil {
-		versionHpp := templates.GenerateVersionHpp(projectName, newVersion)
-		if err := os.WriteFile(versionHeaderPath, []byte(versionHpp), 0644); err != nil {
-			return fmt.Errorf("failed to write %s: %w", versionHeaderPath, err)
-		}
-		fmt.Printf("%s Version updated to %s in %s%s\n", colors.Green, newVersion, versionHeaderPath, colors.Reset)
+		bump.edits = append(bump.edits, fileEdit{path: versionHeaderPath, content: templates.GenerateVersionHpp(projectName, newVersion)})
 	} else if !os.IsNotExist(err) {
-		return fmt.Errorf("failed to access %s: %w", versionHeaderPath, err)
+		return nil, fmt.Errorf("failed to access %s: %w", versionHeaderPath, err)
 	}
 
-	return nil
+	return bump, nil
 }
@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// releaseHostOS is the OS cpx release runs on, a variable for tests.
+var releaseHostOS = runtime.GOOS
+
+// defaultDistDir is where cpx release puts the release archives.
+const defaultDistDir = "dist"
+
+// releaseTarget is the output of a toolchain build for one platform, which
+// becomes one release archive.
+type releaseTarget struct {
+	Toolchain string
+	Dir       string // artifacts of the build
+	OS, Arch  string // in Go's naming, e.g. linux/amd64
+}
+
+// archiveName returns the file name of the release archive of target:
+// <project>-<version>-<os>-<arch>.tar.gz, or .zip for Windows.
+func (t releaseTarget) archiveName(project, version string) string {
+	name := fmt.Sprintf("%s-%s-%s-%s", project, version, t.OS, t.Arch)
+	if t.OS == "windows" {
+		return name + ".zip"
+	}
+	return name + ".tar.gz"
+}
+
+// goArchs maps the architecture names of compilers, docker platforms and
+// Android ABIs to Go's.
+var goArchs = map[string]string{
+	"x86_64":      "amd64",
+	"x64":         "amd64",
+	"aarch64":     "arm64",
+	"arm64-v8a":   "arm64",
+	"armeabi-v7a": "arm",
+	"armv7":       "arm",
+	"i686":        "386",
+	"i386":        "386",
+	"x86":         "386",
+}
+
+func goArch(arch string) string {
+	if a, ok := goArchs[arch]; ok {
+		return a
+	}
+	return arch
+}
+
+// releaseTargets returns the targets of the toolchains in names, or of all
+// active toolchains when names is empty. ciConfig's matrix must be expanded;
+// a matrix toolchain's name selects all of its jobs.
+func releaseTargets(ciConfig *config.ToolchainConfig, names []string, root string) ([]releaseTarget, error) {
+	selected := make(map[string]bool)
+	for _, name := range names {
+		found := false
+		for _, tc := range ciConfig.Toolchains {
+			if tc.Name == name || tc.MatrixBase == name {
+				selected[tc.Name] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("release.toolchains: toolchain '%s' not found in cpx-ci.yaml", name)
+		}
+	}
+
+	var targets []releaseTarget
+	for _, tc := range ciConfig.Toolchains {
+		if len(names) > 0 && !selected[tc.Name] || len(names) == 0 && !tc.IsActive() {
+			continue
+		}
+		switch tc.Type {
+		case config.ToolchainTypeWasm:
+			targets = append(targets, releaseTarget{Toolchain: tc.Name, Dir: wasmOutputDir(root), OS: "emscripten", Arch: "wasm32"})
+		case config.ToolchainTypeAndroid:
+			abis := defaultAndroidABIs
+			if tc.Android != nil && len(tc.Android.ABIs) > 0 {
+				abis = tc.Android.ABIs
+			}
+			for _, abi := range abis {
+				targets = append(targets, releaseTarget{Toolchain: tc.Name, Dir: filepath.Join(androidOutputDir(root), abi), OS: "android", Arch: goArch(abi)})
+			}
+		case config.ToolchainTypeMinGW:
+			arch := "x86_64"
+			if tc.MinGW != nil && tc.MinGW.Arch != "" {
+				arch = tc.MinGW.Arch
+			}
+			targets = append(targets, releaseTarget{Toolchain: tc.Name, Dir: toolchainOutputDir(root, tc.Name), OS: "windows", Arch: goArch(arch)})
+		default:
+			runner := ciConfig.FindRunner(tc.Runner)
+			if runner == nil && tc.Runner != "" {
+				return nil, fmt.Errorf("runner '%s' not found for toolchain '%s'", tc.Runner, tc.Name)
+			}
+			target := releaseTarget{Toolchain: tc.Name, Dir: toolchainOutputDir(root, tc.Name), OS: releaseHostOS, Arch: runtime.GOARCH}
+			if runner != nil && runner.IsDocker() {
+				// containers run Linux of the host's architecture unless told otherwise
+				target.OS = "linux"
+				platform := runner.Platform
+				if runner.TargetPlatform != "" {
+					platform = runner.TargetPlatform
+				}
+				if platform != "" {
+					parts := strings.Split(platform, "/")
+					target.OS = parts[0]
+					if len(parts) > 1 {
+						target.Arch = goArch(parts[1])
+					}
+				}
+			}
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no active toolchains to release in cpx-ci.yaml")
+	}
+
+	// every platform is released once
+	seen := make(map[string]string)
+	for _, t := range targets {
+		platform := t.OS + "/" + t.Arch
+		if other, ok := seen[platform]; ok && other != t.Toolchain {
+			return nil, fmt.Errorf("toolchains '%s' and '%s' both build for %s\n  hint: list the toolchains to release under release.toolchains in cpx-ci.yaml", other, t.Toolchain, platform)
+		}
+		seen[platform] = t.Toolchain
+	}
+	return targets, nil
+}
+
+// toolchainOutputDir is where the build of the named plain or mingw
+// toolchain puts its artifacts.
+func toolchainOutputDir(root, name string) string {
+	return filepath.Join(config.DirsOf(root).Bin, "ci", name)
+}
+
+// planReleaseArtifacts adds the steps building every toolchain to release
+// with the CI toolchains, signing the macOS and Windows binaries, archiving
+// their artifacts into the dist directory and writing the checksums of the
+// archives, which it returns. Like the other steps, they run in the project
+// directory.
+func planReleaseArtifacts(plan *releasePlan, settings config.ReleaseConfig, ciConfig *config.ToolchainConfig) ([]releaseArchive, error) {
+	if ciConfig == nil {
+		return nil, fmt.Errorf("release.artifacts needs toolchains in cpx-ci.yaml\n  hint: add one with cpx toolchain add")
+	}
+	if err := ciConfig.ExpandMatrix(); err != nil {
+		return nil, fmt.Errorf("invalid toolchain matrix in cpx-ci.yaml: %w", err)
+	}
+	targets, err := releaseTargets(ciConfig, settings.Toolchains, ".")
+	if err != nil {
+		return nil, err
+	}
+	dist := settings.Dist
+	if dist == "" {
+		dist = defaultDistDir
+	}
+
+	built := make(map[string]bool)
+	for _, t := range targets {
+		if built[t.Toolchain] {
+			continue
+		}
+		built[t.Toolchain] = true
+		plan.add("build", "build toolchain "+t.Toolchain, nil, func() error {
+			return runToolchainBuild(ToolchainBuildOptions{ToolchainName: t.Toolchain})
+		})
+	}
+
+	developerID, authenticode := ciConfig.Signing.DeveloperID(), ciConfig.Signing.Authenticode()
+	for _, t := range targets {
+		switch {
+		case t.OS == "darwin" && developerID != nil:
+			err = planMacOSSigning(plan, t, developerID)
+		case t.OS == "windows" && authenticode != nil:
+			err = planWindowsSigning(plan, t, authenticode)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var archives []releaseArchive
+	var paths []string
+	for _, t := range targets {
+		name := t.archiveName(plan.Project, plan.NewVersion)
+		dest := filepath.Join(dist, name)
+		archives = append(archives, releaseArchive{releaseTarget: t, Path: dest})
+		paths = append(paths, dest)
+		plan.add("archive", fmt.Sprintf("%s from %s", dest, t.Dir), nil, func() error {
+			entries, err := os.ReadDir(t.Dir)
+			if err != nil || len(entries) == 0 {
+				return fmt.Errorf("toolchain '%s' produced no artifacts in %s", t.Toolchain, t.Dir)
+			}
+			prefix := strings.TrimSuffix(strings.TrimSuffix(name, ".zip"), ".tar.gz")
+			if t.OS == "windows" {
+				err = artifacts.Zip(t.Dir, dest, prefix)
+			} else {
+				err = artifacts.Tarball(t.Dir, dest, prefix)
+			}
+			if err != nil {
+				return err
+			}
+			output.Successf(" Archived %s", dest)
+			return nil
+		})
+	}
+	plan.Artifacts = append(plan.Artifacts, paths...)
+
+	checksums := filepath.Join(dist, "checksums.txt")
+	plan.add("archive", checksums+": SHA-256 of the archives", nil, func() error {
+		return writeChecksums(checksums, paths)
+	})
+	plan.Artifacts = append(plan.Artifacts, checksums)
+	return archives, nil
+}
+
+// releaseArchive is the release archive of a target.
+type releaseArchive struct {
+	releaseTarget
+	Path string
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeChecksums writes the SHA-256 of files to path in the format of
+// sha256sum, by file name.
+func writeChecksums(path string, files []string) error {
+	var lines []string
+	for _, file := range files {
+		sum, err := fileSHA256(file)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, sum+"  "+filepath.Base(file)+"\n")
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	output.Successf(" Wrote %s", path)
+	return nil
+}
+
+// packageMetadata is what the packages of a release say about the project.
+type packageMetadata struct {
+	Binary      string
+	Description string
+	Homepage    string
+	License     string
+	Publisher   string
+}
+
+// releaseMetadata returns the package metadata of the release section,
+// falling back to the project's vcpkg.json.
+func releaseMetadata(settings config.ReleaseConfig, project string) packageMetadata {
+	var manifest struct {
+		Description any    `json:"description"` // a string or lines
+		Homepage    string `json:"homepage"`
+		License     string `json:"license"`
+	}
+	if data, err := os.ReadFile("vcpkg.json"); err == nil {
+		_ = json.Unmarshal(data, &manifest)
+	}
+	description, _ := manifest.Description.(string)
+	if lines, ok := manifest.Description.([]any); ok && len(lines) > 0 {
+		description, _ = lines[0].(string)
+	}
+	return packageMetadata{
+		Binary:      cmp.Or(settings.Binary, project),
+		Description: cmp.Or(settings.Description, description),
+		Homepage:    cmp.Or(settings.Homepage, manifest.Homepage),
+		License:     cmp.Or(settings.License, manifest.License),
+		Publisher:   settings.Publisher,
+	}
+}
+
+// releaseDownloadURL returns a function mapping a release archive to the
+// URL it is downloaded from: release.download_url, or the release assets
+// of the remote on GitHub.
+func releaseDownloadURL(root string, settings config.ReleaseConfig, version string) (func(archive string) string, error) {
+	template := settings.DownloadURL
+	if template == "" {
+		remote := cmp.Or(settings.Remote, "origin")
+		url, err := runGit(root, "remote", "get-url", remote)
+		if err != nil {
+			return nil, fmt.Errorf("the packages need the download URL of the archives\n  hint: set release.download_url, e.g. https://example.com/releases/{tag}/{file}")
+		}
+		m := githubRepoRe.FindStringSubmatch(url)
+		if m == nil {
+			return nil, fmt.Errorf("remote %s (%s) is not on GitHub, so cpx cannot tell where the archives are downloaded from\n  hint: set release.download_url, e.g. https://example.com/releases/{tag}/{file}", remote, url)
+		}
+		template = fmt.Sprintf("https://github.com/%s/%s/releases/download/{tag}/{file}", m[1], m[2])
+	}
+	tag := releaseTag(settings, version)
+	return func(archive string) string {
+		return strings.NewReplacer("{tag}", tag, "{version}", version, "{file}", filepath.Base(archive)).Replace(template)
+	}, nil
+}
+
+// githubRepoRe matches GitHub remotes in https and ssh form and captures
+// the owner and repository.
+var githubRepoRe = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+func TestReleaseTargets(t *testing.T) {
+	inactive := false
+	ciConfig := &config.ToolchainConfig{
+		Runners: []config.Runner{
+			{Name: "gcc", Type: "docker", Image: "gcc:14"},
+			{Name: "arm", Type: "docker", Image: "cross", TargetPlatform: "linux/arm64"},
+			{Name: "local"},
+		},
+		Toolchains: []config.Toolchain{
+			{Name: "linux", Runner: "gcc"},
+			{Name: "linux-arm", Runner: "arm"},
+			{Name: "windows", Type: config.ToolchainTypeMinGW, MinGW: &config.MinGWConfig{Arch: "i686"}},
+			{Name: "web", Type: config.ToolchainTypeWasm},
+			{Name: "android", Type: config.ToolchainTypeAndroid, Android: &config.AndroidConfig{ABIs: []string{"arm64-v8a", "armeabi-v7a"}}},
+			{Name: "host", Runner: "local", Active: &inactive},
+		},
+	}
+
+	targets, err := releaseTargets(ciConfig, nil, ".")
+	require.NoError(t, err)
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Toolchain+" "+target.archiveName("app", "1.0.0"))
+	}
+	assert.Equal(t, []string{
+		"linux app-1.0.0-linux-" + runtime.GOARCH + ".tar.gz",
+		"linux-arm app-1.0.0-linux-arm64.tar.gz",
+		"windows app-1.0.0-windows-386.zip",
+		"web app-1.0.0-emscripten-wasm32.tar.gz",
+		"android app-1.0.0-android-arm64.tar.gz",
+		"android app-1.0.0-android-arm.tar.gz",
+	}, names)
+	assert.Equal(t, filepath.Join(".bin", "ci", "linux"), targets[0].Dir)
+	assert.Equal(t, filepath.Join(".bin", "android", "armeabi-v7a"), targets[5].Dir)
+
+	// listed toolchains are released even when inactive
+	targets, err = releaseTargets(ciConfig, []string{"host"}, ".")
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, runtime.GOOS, targets[0].OS)
+
+	_, err = releaseTargets(ciConfig, []string{"missing"}, ".")
+	assert.ErrorContains(t, err, "toolchain 'missing' not found")
+
+	ciConfig.Toolchains[5].Active = nil
+	ciConfig.Runners[2] = config.Runner{Name: "local", Type: "docker", Image: "clang"}
+	_, err = releaseTargets(ciConfig, nil, ".")
+	assert.ErrorContains(t, err, "toolchains 'linux' and 'host' both build for linux/"+runtime.GOARCH)
+}
+
+func TestPlanReleaseArtifacts(t *testing.T) {
+	releaseProject(t)
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`runners:
+  - name: arm
+    type: docker
+    image: cross
+    platform: linux/arm64
+toolchains:
+  - name: linux-arm
+    runner: arm
+  - name: windows
+    type: mingw
+release:
+  artifacts: true
+  dist: out
+`), 0644))
+
+	plan, err := planRelease("minor", config.ReleaseConfig{})
+	require.NoError(t, err)
+	var steps []string
+	for _, step := range plan.Steps {
+		steps = append(steps, step.Kind+" "+step.Description)
+	}
+	assert.Equal(t, []string{
+		"build build toolchain linux-arm",
+		"build build toolchain windows",
+		"archive " + filepath.Join("out", "mylib-1.3.0-linux-arm64.tar.gz") + " from " + filepath.Join(".bin", "ci", "linux-arm"),
+		"archive " + filepath.Join("out", "mylib-1.3.0-windows-amd64.zip") + " from " + filepath.Join(".bin", "ci", "windows"),
+		"archive " + filepath.Join("out", "checksums.txt") + ": SHA-256 of the archives",
+	}, steps[3:])
+	assert.Equal(t, []string{
+		filepath.Join("out", "mylib-1.3.0-linux-arm64.tar.gz"),
+		filepath.Join("out", "mylib-1.3.0-windows-amd64.zip"),
+		filepath.Join("out", "checksums.txt"),
+	}, plan.Artifacts)
+
+	// archive what the builds would have left behind
+	for _, name := range []string{"linux-arm", "windows"} {
+		dir := filepath.Join(".bin", "ci", name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "mylib"), []byte(name), 0755))
+	}
+	for _, step := range plan.Steps[5:] {
+		require.NoError(t, step.run())
+	}
+	for _, file := range plan.Artifacts {
+		assert.FileExists(t, file)
+	}
+	checksums, err := os.ReadFile(filepath.Join("out", "checksums.txt"))
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{64}  mylib-1.3.0-linux-arm64.tar.gz\n[0-9a-f]{64}  mylib-1.3.0-windows-amd64.zip\n$`, string(checksums))
+
+	require.NoError(t, os.RemoveAll(filepath.Join(".bin", "ci", "windows")))
+	assert.ErrorContains(t, plan.Steps[6].run(), "toolchain 'windows' produced no artifacts")
+}
+
+func TestPlanReleaseSigning(t *testing.T) {
+	releaseProject(t)
+	old := releaseHostOS
+	t.Cleanup(func() { releaseHostOS = old })
+	releaseHostOS = "darwin"
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`toolchains:
+  - name: mac
+  - name: windows
+    type: mingw
+signing:
+  macos:
+    identity: "Developer ID Application: Example (T1)"
+    keychain_profile: notary
+release:
+  artifacts: true
+`), 0644))
+
+	plan, err := planRelease("patch", config.ReleaseConfig{})
+	require.NoError(t, err)
+	var steps []string
+	for _, step := range plan.Steps[3:] {
+		steps = append(steps, step.Kind+" "+step.Description)
+	}
+	dir := filepath.Join(".bin", "ci", "mac")
+	assert.Equal(t, []string{
+		"build build toolchain mac",
+		"build build toolchain windows",
+		`sign sign the binaries in ` + dir + ` as "Developer ID Application: Example (T1)"`,
+		"notarize notarize the binaries in " + dir + " with notarytool (profile notary)",
+		"archive " + filepath.Join("dist", "mylib-1.2.4-darwin-"+runtime.GOARCH+".tar.gz") + " from " + dir,
+		"archive " + filepath.Join("dist", "mylib-1.2.4-windows-amd64.zip") + " from " + filepath.Join(".bin", "ci", "windows"),
+		"archive " + filepath.Join("dist", "checksums.txt") + ": SHA-256 of the archives",
+	}, steps)
+
+	// codesign only runs on macOS
+	releaseHostOS = "linux"
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`runners:
+  - name: osxcross
+    type: docker
+    image: osxcross
+    target_platform: darwin/arm64
+toolchains:
+  - name: mac
+    runner: osxcross
+signing:
+  macos:
+    identity: "Developer ID Application: Example (T1)"
+release:
+  artifacts: true
+`), 0644))
+	_, err = planRelease("patch", config.ReleaseConfig{})
+	assert.ErrorContains(t, err, "toolchain 'mac' builds for macOS, and signing it needs codesign")
+}
+
+func TestPlanReleaseWindowsSigning(t *testing.T) {
+	releaseProject(t)
+	old := releaseHostOS
+	t.Cleanup(func() { releaseHostOS = old })
+	releaseHostOS = "linux"
+	t.Setenv(config.WindowsSigningThumbprintEnv, "")
+	t.Setenv(config.WindowsSigningPFXEnv, "")
+	ci := `toolchains:
+  - name: windows
+    type: mingw
+signing:
+  windows:
+    certificate_thumbprint: ab12
+release:
+  artifacts: true
+`
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(ci), 0644))
+
+	// the certificate store is only on Windows
+	_, err := planRelease("patch", config.ReleaseConfig{})
+	assert.ErrorContains(t, err, "certificate is in the Windows certificate store")
+	releaseHostOS = "windows"
+	plan, err := planRelease("patch", config.ReleaseConfig{})
+	require.NoError(t, err)
+	dir := filepath.Join(".bin", "ci", "windows")
+	assert.Equal(t, "sign the .exe and .dll files in "+dir+" with signtool (certificate ab12)", plan.Steps[4].Description)
+
+	// CI passes a PFX file in the environment
+	releaseHostOS = "linux"
+	t.Setenv(config.WindowsSigningPFXEnv, "/secrets/cert.pfx")
+	plan, err = planRelease("patch", config.ReleaseConfig{})
+	require.NoError(t, err)
+	var kinds []string
+	for _, step := range plan.Steps[3:] {
+		kinds = append(kinds, step.Kind)
+	}
+	assert.Equal(t, []string{"build", "sign", "archive", "archive"}, kinds)
+	assert.Equal(t, "sign the .exe and .dll files in "+dir+" with osslsigncode (/secrets/cert.pfx)", plan.Steps[4].Description)
+
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	assert.ErrorContains(t, plan.Steps[4].run(), "toolchain 'windows' produced no .exe or .dll files")
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// brewFormula is a Homebrew formula installing the project's executable
+// from the release archives.
+type brewFormula struct {
+	Name        string
+	Description string
+	Homepage    string
+	License     string
+	Version     string
+	Binary      string
+	Test        string // shell command; empty runs the binary with --version
+	Archives    []brewArchive
+}
+
+// brewArchive is the archive of one platform in a formula.
+type brewArchive struct {
+	OS, Arch string // darwin or linux, arm64 or amd64
+	URL      string
+	SHA256   string
+}
+
+// brewOSes and brewArchs are the platforms Homebrew installs on, by Go
+// name, with the formula blocks selecting them.
+var (
+	brewOSes  = map[string]string{"darwin": "on_macos", "linux": "on_linux"}
+	brewArchs = map[string]string{"arm64": "on_arm", "amd64": "on_intel"}
+)
+
+var nonAlnumRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// brewClassName returns the Ruby class name Homebrew expects for a formula
+// named name: my-tool becomes MyTool.
+func brewClassName(name string) string {
+	var b strings.Builder
+	for _, part := range nonAlnumRe.Split(name, -1) {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// rubyString quotes s as a Ruby string literal; with interpolate, #{...}
+// in s is kept, so tests can use #{bin}.
+func rubyString(s string, interpolate bool) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+	if !interpolate {
+		s = strings.ReplaceAll(s, "#{", `\#{`)
+	}
+	return `"` + s + `"`
+}
+
+// render returns the formula as Ruby.
+func (f brewFormula) render() string {
+	var b strings.Builder
+	b.WriteString("# Generated by cpx release, the next release overwrites changes.\n")
+	fmt.Fprintf(&b, "class %s < Formula\n", brewClassName(f.Name))
+	if f.Description != "" {
+		fmt.Fprintf(&b, "  desc %s\n", rubyString(f.Description, false))
+	}
+	if f.Homepage != "" {
+		fmt.Fprintf(&b, "  homepage %s\n", rubyString(f.Homepage, false))
+	}
+	fmt.Fprintf(&b, "  version %s\n", rubyString(f.Version, false))
+	if f.License != "" {
+		fmt.Fprintf(&b, "  license %s\n", rubyString(f.License, false))
+	}
+
+	for _, osName := range []string{"darwin", "linux"} {
+		var blocks []string
+		for _, arch := range []string{"arm64", "amd64"} {
+			for _, a := range f.Archives {
+				if a.OS == osName && a.Arch == arch {
+					blocks = append(blocks, fmt.Sprintf("    %s do\n      url %s\n      sha256 %s\n    end\n",
+						brewArchs[arch], rubyString(a.URL, false), rubyString(a.SHA256, false)))
+				}
+			}
+		}
+		if len(blocks) > 0 {
+			fmt.Fprintf(&b, "\n  %s do\n%s  end\n", brewOSes[osName], strings.Join(blocks, "\n"))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n  def install\n    bin.install %s\n  end\n", rubyString(f.Binary, false))
+	test := fmt.Sprintf(`"#{bin}/%s", "--version"`, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(f.Binary))
+	if f.Test != "" {
+		test = rubyString(f.Test, true)
+	}
+	fmt.Fprintf(&b, "\n  test do\n    system %s\n  end\nend\n", test)
+	return b.String()
+}
+
+// planReleaseBrew adds the step writing the Homebrew formula of the macOS
+// and Linux archives to the dist directory and returns its path.
+func planReleaseBrew(plan *releasePlan, root string, settings config.ReleaseConfig, archives []releaseArchive) (string, error) {
+	downloadURL, err := releaseDownloadURL(root, settings, plan.NewVersion)
+	if err != nil {
+		return "", err
+	}
+	meta := releaseMetadata(settings, plan.Project)
+	formula := brewFormula{
+		Name:        plan.Project,
+		Description: meta.Description,
+		Homepage:    meta.Homepage,
+		License:     meta.License,
+		Version:     plan.NewVersion,
+		Binary:      meta.Binary,
+		Test:        settings.Brew.Test,
+	}
+
+	var paths, platforms, urls []string
+	for _, a := range archives {
+		if brewOSes[a.OS] == "" || brewArchs[a.Arch] == "" {
+			continue
+		}
+		url := downloadURL(a.Path)
+		formula.Archives = append(formula.Archives, brewArchive{OS: a.OS, Arch: a.Arch, URL: url})
+		paths = append(paths, a.Path)
+		platforms = append(platforms, a.OS+"/"+a.Arch)
+		urls = append(urls, url)
+	}
+	if len(formula.Archives) == 0 {
+		return "", fmt.Errorf("release.brew needs a macOS or Linux toolchain for arm64 or amd64 to put in the formula")
+	}
+
+	path := filepath.Join(filepath.Dir(archives[0].Path), strings.ToLower(plan.Project)+".rb")
+	plan.add("brew", fmt.Sprintf("%s: Homebrew formula for %s", path, strings.Join(platforms, ", ")), urls, func() error {
+		for i := range formula.Archives {
+			sum, err := fileSHA256(paths[i])
+			if err != nil {
+				return err
+			}
+			formula.Archives[i].SHA256 = sum
+		}
+		if err := os.WriteFile(path, []byte(formula.render()), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		output.Successf(" Wrote the Homebrew formula %s", path)
+		return nil
+	})
+	plan.Artifacts = append(plan.Artifacts, path)
+	return path, nil
+}
+
+var githubShorthandRe = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// brewTapURL returns the git URL of a tap: owner/homebrew-name on GitHub
+// or a URL as is.
+func brewTapURL(tap string) string {
+	if githubShorthandRe.MatchString(tap) {
+		return "https://github.com/" + tap + ".git"
+	}
+	return tap
+}
+
+// planBrewTap adds the step committing the formula at path to the tap and
+// pushing it.
+func planBrewTap(plan *releasePlan, root, tap, path string) {
+	url := brewTapURL(tap)
+	plan.add("upload", fmt.Sprintf("push Formula/%s to the tap %s", filepath.Base(path), tap), []string{url}, func() error {
+		dir := filepath.Join(config.DirsOf(root).Cache, "brew-tap")
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		if _, err := runGit(root, "clone", "--quiet", "--depth", "1", url, dir); err != nil {
+			return fmt.Errorf("failed to clone the tap %s: %w", tap, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formula := filepath.Join(dir, "Formula", filepath.Base(path))
+		if err := os.MkdirAll(filepath.Dir(formula), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(formula, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", formula, err)
+		}
+		message := fmt.Sprintf("%s %s", strings.ToLower(plan.Project), plan.NewVersion)
+		if _, err := runGit(dir, "add", "Formula"); err != nil {
+			return err
+		}
+		if _, err := runGit(dir, "commit", "--quiet", "-m", message); err != nil {
+			return err
+		}
+		if _, err := runGit(dir, "push", "--quiet", "origin", "HEAD"); err != nil {
+			return fmt.Errorf("failed to push to the tap %s: %w", tap, err)
+		}
+		output.Successf(" Pushed %s to %s", message, tap)
+		return nil
+	})
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+func TestBrewFormula(t *testing.T) {
+	formula := brewFormula{
+		Name:        "my-tool",
+		Description: `Say "hi" to #{name}`,
+		Homepage:    "https://example.com",
+		License:     "MIT",
+		Version:     "1.3.0",
+		Binary:      "my-tool",
+		Archives: []brewArchive{
+			{OS: "linux", Arch: "amd64", URL: "https://example.com/l.tar.gz", SHA256: "aa"},
+			{OS: "darwin", Arch: "amd64", URL: "https://example.com/x.tar.gz", SHA256: "bb"},
+			{OS: "darwin", Arch: "arm64", URL: "https://example.com/a.tar.gz", SHA256: "cc"},
+		},
+	}
+	assert.Equal(t, `# Generated by cpx release, the next release overwrites changes.
+class MyTool < Formula
+  desc "Say \"hi\" to \#{name}"
+  homepage "https://example.com"
+  version "1.3.0"
+  license "MIT"
+
+  on_macos do
+    on_arm do
+      url "https://example.com/a.tar.gz"
+      sha256 "cc"
+    end
+
+    on_intel do
+      url "https://example.com/x.tar.gz"
+      sha256 "bb"
+    end
+  end
+
+  on_linux do
+    on_intel do
+      url "https://example.com/l.tar.gz"
+      sha256 "aa"
+    end
+  end
+
+  def install
+    bin.install "my-tool"
+  end
+
+  test do
+    system "#{bin}/my-tool", "--version"
+  end
+end
+`, formula.render())
+
+	formula.Test = `#{bin}/my-tool --help | grep "Usage"`
+	assert.Contains(t, formula.render(), `    system "#{bin}/my-tool --help | grep \"Usage\""`)
+}
+
+func TestBrewNames(t *testing.T) {
+	assert.Equal(t, "MyTool", brewClassName("my-tool"))
+	assert.Equal(t, "Cpx2d", brewClassName("cpx_2d"))
+	assert.Equal(t, "https://github.com/acme/homebrew-tools.git", brewTapURL("acme/homebrew-tools"))
+	assert.Equal(t, "git@example.com:acme/tap.git", brewTapURL("git@example.com:acme/tap.git"))
+}
+
+func TestPlanReleaseBrew(t *testing.T) {
+	releaseProject(t)
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "mylib", "description": ["A library", "more"], "license": "MIT"}`), 0644))
+	tap := filepath.Join(t.TempDir(), "tap.git")
+	out, err := exec.Command("git", "init", "--quiet", "--bare", tap).CombinedOutput()
+	require.NoError(t, err, string(out))
+	_, err = runGit(".", "remote", "add", "origin", "git@github.com:acme/mylib.git")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`runners:
+  - name: amd64
+    type: docker
+    image: gcc
+    platform: linux/amd64
+  - name: arm64
+    type: docker
+    image: gcc
+    platform: linux/arm64
+toolchains:
+  - name: linux
+    runner: amd64
+  - name: linux-arm
+    runner: arm64
+  - name: windows
+    type: mingw
+hooks:
+  post_release: ["true"]
+release:
+  brew:
+    tap: `+tap+`
+`), 0644))
+
+	plan, err := planRelease("minor", config.ReleaseConfig{})
+	require.NoError(t, err)
+	var kinds []string
+	for _, step := range plan.Steps {
+		kinds = append(kinds, step.Kind)
+	}
+	// brew implies the artifacts, and the tap is pushed last
+	assert.Equal(t, []string{"version", "version", "changelog", "build", "build", "build", "archive", "archive", "archive", "archive", "brew", "hook", "upload"}, kinds)
+	brew := plan.Steps[10]
+	assert.Equal(t, filepath.Join("dist", "mylib.rb")+": Homebrew formula for linux/amd64, linux/arm64", brew.Description)
+	assert.Equal(t, []string{
+		"https://github.com/acme/mylib/releases/download/v1.3.0/mylib-1.3.0-linux-amd64.tar.gz",
+		"https://github.com/acme/mylib/releases/download/v1.3.0/mylib-1.3.0-linux-arm64.tar.gz",
+	}, brew.Details)
+	assert.Contains(t, plan.Artifacts, filepath.Join("dist", "mylib.rb"))
+
+	// write the formula of fake archives and push it
+	for _, name := range []string{"mylib-1.3.0-linux-amd64.tar.gz", "mylib-1.3.0-linux-arm64.tar.gz"} {
+		require.NoError(t, os.MkdirAll("dist", 0755))
+		require.NoError(t, os.WriteFile(filepath.Join("dist", name), []byte(name), 0644))
+	}
+	require.NoError(t, brew.run())
+	formula, err := os.ReadFile(filepath.Join("dist", "mylib.rb"))
+	require.NoError(t, err)
+	assert.Contains(t, string(formula), `desc "A library"`)
+	assert.Contains(t, string(formula), `sha256 "`)
+	assert.NotContains(t, string(formula), "on_macos")
+
+	require.NoError(t, plan.Steps[12].run())
+	pushed, err := runGit(tap, "show", "HEAD:Formula/mylib.rb")
+	require.NoError(t, err)
+	assert.Equal(t, string(formula), pushed+"\n")
+	subject, err := runGit(tap, "log", "-1", "--format=%s")
+	require.NoError(t, err)
+	assert.Equal(t, "mylib 1.3.0", subject)
+
+	// without a GitHub remote the download URL must be configured
+	_, err = runGit(".", "remote", "set-url", "origin", "https://example.com/mylib.git")
+	require.NoError(t, err)
+	_, err = planRelease("minor", config.ReleaseConfig{})
+	assert.ErrorContains(t, err, "hint: set release.download_url")
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// releaseProject creates a CMake project with a version header in a git
+// repository tagged v1.2.3, with two commits after the tag.
+func releaseProject(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	chdirTemp(t)
+	old := releaseNow
+	t.Cleanup(func() { releaseNow = old })
+	releaseNow = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("cmake_minimum_required(VERSION 3.20)\nproject(mylib VERSION 1.2.3 LANGUAGES CXX)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "mylib"}`), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join("include", "mylib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("include", "mylib", "version.hpp"), []byte("#pragma once\n"), 0644))
+	require.NoError(t, os.WriteFile("CHANGELOG.md", []byte("# Changelog\n\n## [1.2.3] - 2026-01-01\n\n- first\n"), 0644))
+
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com", "-c", "tag.gpgSign=false", "-c", "commit.gpgSign=false"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-qm", "initial")
+	git("tag", "v1.2.3")
+	git("commit", "-q", "--allow-empty", "-m", "feat(parser): support arrays")
+	git("commit", "-q", "--allow-empty", "-m", "fix: crash on empty input")
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@example.com")
+}
+
+func TestPlanRelease(t *testing.T) {
+	releaseProject(t)
+
+	plan, err := planRelease("minor", config.ReleaseConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "mylib", plan.Project)
+	assert.Equal(t, "1.2.3", plan.Version)
+	assert.Equal(t, "1.3.0", plan.NewVersion)
+	assert.Empty(t, plan.Tag)
+
+	var kinds []string
+	for _, step := range plan.Steps {
+		kinds = append(kinds, step.Kind)
+	}
+	assert.Equal(t, []string{"version", "version", "changelog"}, kinds)
+	assert.Equal(t, "CMakeLists.txt: version 1.2.3 → 1.3.0", plan.Steps[0].Description)
+	assert.Equal(t, "CHANGELOG.md: add the 1.3.0 section (2 commits since v1.2.3)", plan.Steps[2].Description)
+	assert.Contains(t, plan.Steps[2].Details, "## [1.3.0] - 2026-10-18")
+	assert.Contains(t, plan.Steps[2].Details, "- **parser:** support arrays")
+
+	// planning changes nothing
+	data, err := os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "VERSION 1.2.3")
+
+	var out strings.Builder
+	plan.print(&out)
+	assert.Contains(t, out.String(), "dry run, nothing is changed")
+	assert.Contains(t, out.String(), "3. changelog")
+
+	_, err = planRelease("huge", config.ReleaseConfig{})
+	assert.ErrorContains(t, err, "invalid bump type")
+}
+
+func TestReleaseWithTag(t *testing.T) {
+	releaseProject(t)
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte("release:\n  tag: true\n  tag_prefix: release-\nhooks:\n  post_release: [\"true\"]\n"), 0644))
+
+	plan, err := planRelease("patch", config.ReleaseConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "release-1.2.4", plan.Tag)
+	var kinds []string
+	for _, step := range plan.Steps {
+		kinds = append(kinds, step.Kind)
+	}
+	assert.Equal(t, []string{"version", "version", "changelog", "commit", "tag", "hook"}, kinds)
+
+	require.NoError(t, plan.run())
+	data, err := os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "VERSION 1.2.4")
+	data, err = os.ReadFile("CHANGELOG.md")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "# Changelog\n\n## [1.2.4] - 2026-10-18\n"))
+	tagged, err := runGit(".", "rev-parse", "release-1.2.4^{commit}")
+	require.NoError(t, err)
+	head, err := runGit(".", "rev-parse", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, head, tagged)
+	status, err := runGit(".", "status", "--porcelain", "--untracked-files=no")
+	require.NoError(t, err)
+	assert.Empty(t, status)
+
+	// the tag exists now
+	_, err = planRelease("patch", config.ReleaseConfig{})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(mylib VERSION 1.2.3)\n"), 0644))
+	_, err = planRelease("patch", config.ReleaseConfig{})
+	assert.ErrorContains(t, err, "tag release-1.2.4 already exists")
+
+	// pushing needs the remote
+	_, err = planRelease("minor", config.ReleaseConfig{Push: true})
+	assert.ErrorContains(t, err, "unknown git remote 'origin'")
+}
@@ -0,0 +1,378 @@
+package cli
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// wingetManifestVersion is the version of the winget manifest schema the
+// manifests are written in.
+const wingetManifestVersion = "1.6.0"
+
+// wingetRepo is the community repository of winget manifests.
+const wingetRepo = "microsoft/winget-pkgs"
+
+// windowsPackage is the Windows archives of a release, with what the
+// winget and Chocolatey packages say about them.
+type windowsPackage struct {
+	Name     string
+	Version  string
+	Meta     packageMetadata
+	Archives []windowsArchive
+}
+
+// windowsArchive is the zip of one architecture.
+type windowsArchive struct {
+	Arch   string // Go name: amd64, 386, arm64
+	Prefix string // top-level directory in the zip
+	URL    string
+	SHA256 string
+}
+
+// wingetArchs are the winget names of Go architectures.
+var wingetArchs = map[string]string{"amd64": "x64", "386": "x86", "arm64": "arm64", "arm": "arm"}
+
+// wingetManifests returns the version, installer and default locale
+// manifests of id by file name.
+func (p windowsPackage) wingetManifests(id string) (map[string]string, error) {
+	type nestedFile struct {
+		RelativeFilePath     string `yaml:"RelativeFilePath"`
+		PortableCommandAlias string `yaml:"PortableCommandAlias"`
+	}
+	type installer struct {
+		Architecture         string       `yaml:"Architecture"`
+		InstallerURL         string       `yaml:"InstallerUrl"`
+		InstallerSha256      string       `yaml:"InstallerSha256"`
+		NestedInstallerFiles []nestedFile `yaml:"NestedInstallerFiles"`
+	}
+	var installers []installer
+	for _, a := range p.Archives {
+		installers = append(installers, installer{
+			Architecture:    wingetArchs[a.Arch],
+			InstallerURL:    a.URL,
+			InstallerSha256: strings.ToUpper(a.SHA256),
+			NestedInstallerFiles: []nestedFile{{
+				RelativeFilePath:     a.Prefix + `\` + p.Meta.Binary + ".exe",
+				PortableCommandAlias: p.Meta.Binary,
+			}},
+		})
+	}
+
+	manifests := []struct {
+		kind string
+		file string
+		data any
+	}{
+		{"version", id + ".yaml", struct {
+			PackageIdentifier string `yaml:"PackageIdentifier"`
+			PackageVersion    string `yaml:"PackageVersion"`
+			DefaultLocale     string `yaml:"DefaultLocale"`
+			ManifestType      string `yaml:"ManifestType"`
+			ManifestVersion   string `yaml:"ManifestVersion"`
+		}{id, p.Version, "en-US", "version", wingetManifestVersion}},
+		{"installer", id + ".installer.yaml", struct {
+			PackageIdentifier   string      `yaml:"PackageIdentifier"`
+			PackageVersion      string      `yaml:"PackageVersion"`
+			InstallerType       string      `yaml:"InstallerType"`
+			NestedInstallerType string      `yaml:"NestedInstallerType"`
+			Installers          []installer `yaml:"Installers"`
+			ManifestType        string      `yaml:"ManifestType"`
+			ManifestVersion     string      `yaml:"ManifestVersion"`
+		}{id, p.Version, "zip", "portable", installers, "installer", wingetManifestVersion}},
+		{"defaultLocale", id + ".locale.en-US.yaml", struct {
+			PackageIdentifier string `yaml:"PackageIdentifier"`
+			PackageVersion    string `yaml:"PackageVersion"`
+			PackageLocale     string `yaml:"PackageLocale"`
+			Publisher         string `yaml:"Publisher"`
+			PackageName       string `yaml:"PackageName"`
+			PackageURL        string `yaml:"PackageUrl,omitempty"`
+			License           string `yaml:"License"`
+			ShortDescription  string `yaml:"ShortDescription"`
+			ManifestType      string `yaml:"ManifestType"`
+			ManifestVersion   string `yaml:"ManifestVersion"`
+		}{id, p.Version, "en-US", p.Meta.Publisher, p.Name, p.Meta.Homepage, p.Meta.License, p.Meta.Description, "defaultLocale", wingetManifestVersion}},
+	}
+
+	files := make(map[string]string)
+	for _, m := range manifests {
+		data, err := yaml.Marshal(m.data)
+		if err != nil {
+			return nil, err
+		}
+		files[m.file] = fmt.Sprintf("# Created with cpx release\n# yaml-language-server: $schema=https://aka.ms/winget-manifest.%s.%s.schema.json\n\n%s",
+			m.kind, wingetManifestVersion, data)
+	}
+	return files, nil
+}
+
+// wingetManifestDir returns where the manifests of version of id go in
+// winget-pkgs: manifests/a/Acme/Tool/1.0.0 for Acme.Tool.
+func wingetManifestDir(id, version string) string {
+	parts := append([]string{"manifests", strings.ToLower(id[:1])}, strings.Split(id, ".")...)
+	return filepath.Join(append(parts, version)...)
+}
+
+// chocolateyPackage returns the nuspec and install script of the
+// Chocolatey package id. Chocolatey only tells 32- from 64-bit x86.
+func (p windowsPackage) chocolateyPackage(id string) (nuspec, install string, err error) {
+	type metadata struct {
+		ID          string `xml:"id"`
+		Version     string `xml:"version"`
+		Title       string `xml:"title"`
+		Authors     string `xml:"authors"`
+		ProjectURL  string `xml:"projectUrl,omitempty"`
+		Description string `xml:"description"`
+	}
+	type file struct {
+		Src    string `xml:"src,attr"`
+		Target string `xml:"target,attr"`
+	}
+	pkg := struct {
+		XMLName  xml.Name `xml:"package"`
+		XMLNS    string   `xml:"xmlns,attr"`
+		Metadata metadata `xml:"metadata"`
+		Files    []file   `xml:"files>file"`
+	}{
+		XMLNS:    "http://schemas.microsoft.com/packaging/2015/06/nuspec.xsd",
+		Metadata: metadata{id, p.Version, p.Name, p.Meta.Publisher, p.Meta.Homepage, p.Meta.Description},
+		Files:    []file{{Src: `tools\**`, Target: "tools"}},
+	}
+	data, err := xml.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	nuspec = xml.Header + string(data) + "\n"
+
+	var args []string
+	for _, a := range p.Archives {
+		switch a.Arch {
+		case "386":
+			args = append(args, fmt.Sprintf("  url            = '%s'", a.URL), fmt.Sprintf("  checksum       = '%s'", a.SHA256), "  checksumType   = 'sha256'")
+		case "amd64":
+			args = append(args, fmt.Sprintf("  url64bit       = '%s'", a.URL), fmt.Sprintf("  checksum64     = '%s'", a.SHA256), "  checksumType64 = 'sha256'")
+		}
+	}
+	install = `$ErrorActionPreference = 'Stop'
+$toolsDir = "$(Split-Path -Parent $MyInvocation.MyCommand.Definition)"
+
+$packageArgs = @{
+  packageName    = $env:ChocolateyPackageName
+  unzipLocation  = $toolsDir
+` + strings.Join(args, "\n") + `
+}
+Install-ChocolateyZipPackage @packageArgs
+`
+	return nuspec, install, nil
+}
+
+// wingetRelease is where the winget manifests of a release are written.
+type wingetRelease struct {
+	ID   string // package identifier
+	Path string // directory in winget-pkgs
+	Dir  string // directory in dist
+}
+
+// planWindowsPackages adds the steps writing the winget manifests and the
+// Chocolatey package of the Windows archives into the dist directory. It
+// returns where the winget manifests go, nil without winget.
+func planWindowsPackages(plan *releasePlan, root string, settings config.ReleaseConfig, archives []releaseArchive) (*wingetRelease, error) {
+	downloadURL, err := releaseDownloadURL(root, settings, plan.NewVersion)
+	if err != nil {
+		return nil, err
+	}
+	pkg := windowsPackage{Name: plan.Project, Version: plan.NewVersion, Meta: releaseMetadata(settings, plan.Project)}
+	var paths, platforms, urls []string
+	for _, a := range archives {
+		if a.OS != "windows" || wingetArchs[a.Arch] == "" {
+			continue
+		}
+		if settings.Winget == nil && a.Arch != "amd64" && a.Arch != "386" {
+			continue
+		}
+		url := downloadURL(a.Path)
+		pkg.Archives = append(pkg.Archives, windowsArchive{Arch: a.Arch, Prefix: strings.TrimSuffix(filepath.Base(a.Path), ".zip"), URL: url})
+		paths = append(paths, a.Path)
+		platforms = append(platforms, a.OS+"/"+a.Arch)
+		urls = append(urls, url)
+	}
+	if len(pkg.Archives) == 0 {
+		return nil, fmt.Errorf("winget and Chocolatey packages need a Windows toolchain, such as one of type mingw")
+	}
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"publisher", pkg.Meta.Publisher},
+		{"description", pkg.Meta.Description},
+		{"license", pkg.Meta.License},
+	} {
+		if field.value == "" && (field.name != "license" || settings.Winget != nil) {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the Windows packages need the project's %s\n  hint: set them in the release section of cpx-ci.yaml", strings.Join(missing, ", "))
+	}
+
+	// the checksums are only known once the archives are written
+	checksums := func() error {
+		for i := range pkg.Archives {
+			sum, err := fileSHA256(paths[i])
+			if err != nil {
+				return err
+			}
+			pkg.Archives[i].SHA256 = sum
+		}
+		return nil
+	}
+	dist := filepath.Dir(archives[0].Path)
+
+	var winget *wingetRelease
+	if settings.Winget != nil {
+		id := settings.Winget.PackageIdentifier
+		if id == "" {
+			id = nonAlnumRe.ReplaceAllString(pkg.Meta.Publisher, "") + "." + plan.Project
+		}
+		path := wingetManifestDir(id, plan.NewVersion)
+		dir := filepath.Join(dist, "winget", path)
+		winget = &wingetRelease{ID: id, Path: path, Dir: dir}
+		plan.add("winget", fmt.Sprintf("%s: winget manifests of %s for %s", dir, id, strings.Join(platforms, ", ")), urls, func() error {
+			if err := checksums(); err != nil {
+				return err
+			}
+			files, err := pkg.wingetManifests(id)
+			if err != nil {
+				return err
+			}
+			if err := writeFiles(dir, files); err != nil {
+				return err
+			}
+			output.Successf(" Wrote the winget manifests of %s to %s", id, dir)
+			return nil
+		})
+		plan.Artifacts = append(plan.Artifacts, dir)
+	}
+
+	if settings.Chocolatey != nil {
+		id := cmp.Or(settings.Chocolatey.ID, strings.ToLower(plan.Project))
+		dir := filepath.Join(dist, "chocolatey", id)
+		plan.add("choco", fmt.Sprintf("%s: Chocolatey package %s", dir, id), nil, func() error {
+			if err := checksums(); err != nil {
+				return err
+			}
+			nuspec, install, err := pkg.chocolateyPackage(id)
+			if err != nil {
+				return err
+			}
+			files := map[string]string{id + ".nuspec": nuspec, filepath.Join("tools", "chocolateyinstall.ps1"): install}
+			if err := writeFiles(dir, files); err != nil {
+				return err
+			}
+			output.Successf(" Wrote the Chocolatey package %s to %s", id, dir)
+			return nil
+		})
+		plan.Artifacts = append(plan.Artifacts, dir)
+	}
+	return winget, nil
+}
+
+// writeFiles writes files, by path relative to dir, below dir.
+func writeFiles(dir string, files map[string]string) error {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// planWingetPullRequest adds the step submitting the winget manifests to
+// winget-pkgs: from a fork of the gh user, brought up to date first, on a
+// new branch.
+func planWingetPullRequest(plan *releasePlan, root string, winget *wingetRelease) error {
+	if _, err := execLookPath("gh"); err != nil {
+		return fmt.Errorf("release.winget.pull_request needs the GitHub CLI\n  hint: install gh and run gh auth login")
+	}
+	title := fmt.Sprintf("New version: %s version %s", winget.ID, plan.NewVersion)
+	plan.add("upload", "open a pull request to "+wingetRepo+": "+title, nil, func() error {
+		gh := func(args ...string) (string, error) {
+			out, err := execCommand("gh", args...).CombinedOutput()
+			if err != nil {
+				return "", fmt.Errorf("gh %s failed: %w\n%s", args[0], err, strings.TrimSpace(string(out)))
+			}
+			return strings.TrimSpace(string(out)), nil
+		}
+		user, err := gh("api", "user", "--jq", ".login")
+		if err != nil {
+			return err
+		}
+		fork := user + "/winget-pkgs"
+		if _, err := gh("repo", "fork", wingetRepo, "--clone=false"); err != nil {
+			return err
+		}
+		if _, err := gh("repo", "sync", fork); err != nil {
+			return err
+		}
+
+		// a sparse, shallow clone of the fork: winget-pkgs is large
+		dir := filepath.Join(config.DirsOf(root).Cache, "winget-pkgs")
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		if _, err := runGit(root, "clone", "--quiet", "--depth", "1", "--filter=blob:none", "--sparse", "https://github.com/"+fork+".git", dir); err != nil {
+			return err
+		}
+		slashDir := filepath.ToSlash(winget.Path)
+		branch := fmt.Sprintf("%s-%s", winget.ID, plan.NewVersion)
+		for _, args := range [][]string{
+			{"sparse-checkout", "set", "--no-cone", slashDir},
+			{"checkout", "--quiet", "-b", branch},
+		} {
+			if _, err := runGit(dir, args...); err != nil {
+				return err
+			}
+		}
+		files := make(map[string]string)
+		entries, err := os.ReadDir(winget.Dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			data, err := os.ReadFile(filepath.Join(winget.Dir, e.Name()))
+			if err != nil {
+				return err
+			}
+			files[e.Name()] = string(data)
+		}
+		if err := writeFiles(filepath.Join(dir, winget.Path), files); err != nil {
+			return err
+		}
+		for _, args := range [][]string{
+			{"add", "--sparse", slashDir},
+			{"commit", "--quiet", "-m", title},
+			{"push", "--quiet", "origin", branch},
+		} {
+			if _, err := runGit(dir, args...); err != nil {
+				return err
+			}
+		}
+		url, err := gh("pr", "create", "--repo", wingetRepo, "--head", user+":"+branch, "--title", title, "--body", "Created with cpx release.")
+		if err != nil {
+			return err
+		}
+		output.Successf(" Opened %s", url)
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+func TestWindowsPackageManifests(t *testing.T) {
+	pkg := windowsPackage{
+		Name:    "mylib",
+		Version: "1.3.0",
+		Meta:    packageMetadata{Binary: "mylib", Description: "A library", Homepage: "https://example.com", License: "MIT", Publisher: "Acme"},
+		Archives: []windowsArchive{
+			{Arch: "amd64", Prefix: "mylib-1.3.0-windows-amd64", URL: "https://example.com/x64.zip", SHA256: "ab12"},
+			{Arch: "386", Prefix: "mylib-1.3.0-windows-386", URL: "https://example.com/x86.zip", SHA256: "cd34"},
+		},
+	}
+
+	files, err := pkg.wingetManifests("Acme.mylib")
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+	assert.Contains(t, files["Acme.mylib.yaml"], "$schema=https://aka.ms/winget-manifest.version.1.6.0.schema.json")
+	assert.Contains(t, files["Acme.mylib.installer.yaml"], `InstallerType: zip
+NestedInstallerType: portable
+Installers:
+    - Architecture: x64
+      InstallerUrl: https://example.com/x64.zip
+      InstallerSha256: AB12
+      NestedInstallerFiles:
+        - RelativeFilePath: mylib-1.3.0-windows-amd64\mylib.exe
+          PortableCommandAlias: mylib
+`)
+	assert.Contains(t, files["Acme.mylib.locale.en-US.yaml"], "Publisher: Acme\nPackageName: mylib\nPackageUrl: https://example.com\nLicense: MIT\nShortDescription: A library\n")
+	assert.Equal(t, filepath.Join("manifests", "a", "Acme", "mylib", "1.3.0"), wingetManifestDir("Acme.mylib", "1.3.0"))
+
+	nuspec, install, err := pkg.chocolateyPackage("mylib")
+	require.NoError(t, err)
+	assert.Contains(t, nuspec, "<id>mylib</id>")
+	assert.Contains(t, nuspec, "<authors>Acme</authors>")
+	assert.Contains(t, nuspec, `<file src="tools\**" target="tools"></file>`)
+	assert.Contains(t, install, "  url64bit       = 'https://example.com/x64.zip'\n  checksum64     = 'ab12'\n")
+	assert.Contains(t, install, "  url            = 'https://example.com/x86.zip'\n")
+}
+
+func TestPlanReleaseWindowsPackages(t *testing.T) {
+	releaseProject(t)
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "mylib", "description": "A library", "license": "MIT"}`), 0644))
+	_, err := runGit(".", "remote", "add", "origin", "https://github.com/acme/mylib.git")
+	require.NoError(t, err)
+	ci := `toolchains:
+  - name: windows
+    type: mingw
+release:
+  publisher: Acme Inc.
+  winget:
+    pull_request: true
+  chocolatey: {}
+`
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(ci), 0644))
+
+	oldExecLookPath := execLookPath
+	t.Cleanup(func() { execLookPath = oldExecLookPath })
+	execLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	plan, err := planRelease("minor", config.ReleaseConfig{})
+	require.NoError(t, err)
+	var kinds []string
+	for _, step := range plan.Steps {
+		kinds = append(kinds, step.Kind)
+	}
+	// the packages imply the artifacts, and the pull request comes last
+	assert.Equal(t, []string{"version", "version", "changelog", "build", "archive", "archive", "winget", "choco", "upload"}, kinds)
+	manifests := filepath.Join("dist", "winget", "manifests", "a", "AcmeInc", "mylib", "1.3.0")
+	assert.Equal(t, manifests+": winget manifests of AcmeInc.mylib for windows/amd64", plan.Steps[6].Description)
+	assert.Equal(t, []string{"https://github.com/acme/mylib/releases/download/v1.3.0/mylib-1.3.0-windows-amd64.zip"}, plan.Steps[6].Details)
+	assert.Equal(t, "open a pull request to microsoft/winget-pkgs: New version: AcmeInc.mylib version 1.3.0", plan.Steps[8].Description)
+
+	// write the packages of a fake archive
+	require.NoError(t, os.MkdirAll("dist", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("dist", "mylib-1.3.0-windows-amd64.zip"), []byte("zip"), 0644))
+	require.NoError(t, plan.Steps[6].run())
+	require.NoError(t, plan.Steps[7].run())
+	installer, err := os.ReadFile(filepath.Join(manifests, "AcmeInc.mylib.installer.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(installer), "InstallerSha256: 4A70FE9AA6436E02C2DEA340FBD1E352E4EF2D8CE6CA52AD25D4B95471FC8BF2\n")
+	assert.FileExists(t, filepath.Join("dist", "chocolatey", "mylib", "mylib.nuspec"))
+	assert.FileExists(t, filepath.Join("dist", "chocolatey", "mylib", "tools", "chocolateyinstall.ps1"))
+
+	// the pull request needs gh
+	execLookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	_, err = planRelease("minor", config.ReleaseConfig{})
+	assert.ErrorContains(t, err, "hint: install gh")
+
+	// Chocolatey does without the license, winget does not
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "mylib", "description": "A library"}`), 0644))
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte("toolchains:\n  - name: windows\n    type: mingw\nrelease:\n  publisher: Acme\n"), 0644))
+	_, err = planRelease("minor", config.ReleaseConfig{Chocolatey: &config.ChocolateyConfig{}})
+	require.NoError(t, err)
+	_, err = planRelease("minor", config.ReleaseConfig{Winget: &config.WingetConfig{}})
+	assert.ErrorContains(t, err, "the Windows packages need the project's license")
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// VerifyReproCmd creates the verify-repro command
+func VerifyReproCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-repro",
+		Short: "Check that two builds of the same commit are bit-identical",
+		Long: `Build HEAD twice with cpx build --reproducible, each time from scratch in its
+own git worktree under .cache/repro, and compare the artifacts byte for byte.
+The worktrees are at different paths, so paths leaking into the binaries show
+up as differences too. Uncommitted changes are not part of the check.
+
+Differences usually come from __DATE__ and __TIME__ in code the compiler does
+not rewrite, absolute paths embedded by the build files, or generated files
+written in an unstable order. --keep keeps both worktrees, so tools such as
+diffoscope can show what differs.`,
+		Example: `  cpx verify-repro
+  cpx verify-repro --release
+  cpx verify-repro -O3 --keep`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withBuildLog(cmd, "verify-repro", func() error { return runVerifyRepro(cmd, args) })
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().BoolP("release", "r", false, "Verify a release build (-O2). Default is debug")
+	cmd.Flags().StringP("opt", "O", "", "Override optimization level: 0,1,2,3,s,fast")
+	cmd.Flags().Bool("keep", false, "Keep the worktrees of both builds")
+	cmd.Flags().Bool("verbose", false, "Show full build output")
+
+	return cmd
+}
+
+// setupReproducible exports the environment of a reproducible build of the
+// project at root to the build tools cpx runs.
+func setupReproducible(root string) error {
+	epoch, err := build.SourceDateEpoch(root)
+	if err != nil {
+		return err
+	}
+	for _, kv := range build.ReproducibleEnv(epoch) {
+		key, value, _ := strings.Cut(kv, "=")
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	output.Infof("%sReproducible build, SOURCE_DATE_EPOCH=%d%s", colors.Gray, epoch, colors.Reset)
+	return nil
+}
+
+func runVerifyRepro(cmd *cobra.Command, _ []string) error {
+	release, _ := cmd.Flags().GetBool("release")
+	optLevel, _ := cmd.Flags().GetString("opt")
+	keep, _ := cmd.Flags().GetBool("keep")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	if _, err := RequireProject("cpx verify-repro"); err != nil {
+		return err
+	}
+	builder, err := projectBuilder()
+	if err != nil {
+		return err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	head, err := runGit(root, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return fmt.Errorf("no commit to verify\n  hint: cpx verify-repro builds HEAD; commit the project first")
+	}
+	if status, err := runGit(root, "status", "--porcelain", "--untracked-files=no"); err == nil && status != "" {
+		output.Warnf("uncommitted changes are not part of the check, it builds %s", head)
+	}
+	if err := setupReproducible(root); err != nil {
+		return err
+	}
+
+	opts := build.BuildOptions{Release: release, OptLevel: optLevel, Verbose: verbose, Clean: true, Reproducible: true}
+	workDir := filepath.Join(config.DirsOf(root).Cache, "repro")
+	var outputs []string
+	for i, name := range []string{"first", "second"} {
+		worktree := filepath.Join(workDir, name)
+		_, _ = runGit(root, "worktree", "remove", "--force", worktree)
+		if _, err := runGit(root, "worktree", "add", "--force", "--detach", worktree, "HEAD"); err != nil {
+			return err
+		}
+		if !keep {
+			defer func() { _, _ = runGit(root, "worktree", "remove", "--force", worktree) }()
+		}
+		if _, err := os.Stat(filepath.Join(worktree, ".gitmodules")); err == nil {
+			if _, err := runGit(worktree, "submodule", "update", "--init", "--recursive"); err != nil {
+				return err
+			}
+		}
+
+		output.Stepf("Building %s (%d/2)...", head, i+1)
+		if err := os.Chdir(worktree); err != nil {
+			return err
+		}
+		err := builder.Build(context.Background(), opts)
+		if chdirErr := os.Chdir(root); chdirErr != nil && err == nil {
+			err = chdirErr
+		}
+		if err != nil {
+			return fmt.Errorf("build %d of %s failed: %w", i+1, head, err)
+		}
+		outputs = append(outputs, filepath.Join(config.DirsOf(worktree).Bin, "native", build.GetOutputDir(release, optLevel, "")))
+	}
+
+	total, diffs, err := compareArtifacts(outputs[0], outputs[1])
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		return fmt.Errorf("the builds produced no artifacts in %s", outputs[0])
+	}
+	fmt.Fprintln(output.Stdout())
+	for _, d := range diffs {
+		output.Errorf("%s", d)
+	}
+	if len(diffs) > 0 {
+		hint := "run with --keep and compare the files with diffoscope"
+		if keep {
+			hint = "compare the files with diffoscope, e.g. diffoscope " + filepath.Join(outputs[0], "<file>") + " " + filepath.Join(outputs[1], "<file>")
+		}
+		return fmt.Errorf("%d of %d artifacts differ between two builds of %s\n  hint: %s", len(diffs), total, head, hint)
+	}
+	output.Successf("✓ %d artifacts of %s are bit-identical", total, head)
+	return nil
+}
+
+// compareArtifacts compares the files below dirs a and b and returns how
+// many there are and a description of each that differs, in path order.
+// Symlinks are compared by their target.
+func compareArtifacts(a, b string) (int, []string, error) {
+	first, err := artifactFiles(a)
+	if err != nil {
+		return 0, nil, err
+	}
+	second, err := artifactFiles(b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	names := make(map[string]bool)
+	for name := range first {
+		names[name] = true
+	}
+	for name := range second {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var diffs []string
+	for _, name := range sorted {
+		x, inFirst := first[name]
+		y, inSecond := second[name]
+		switch {
+		case !inSecond:
+			diffs = append(diffs, name+": only in the first build")
+		case !inFirst:
+			diffs = append(diffs, name+": only in the second build")
+		case x != y:
+			diffs = append(diffs, name+": differs")
+		}
+	}
+	return len(sorted), diffs, nil
+}
+
+// artifactFiles returns the SHA-256 of the files below dir by slash
+// separated relative path; that of a symlink is of its target.
+func artifactFiles(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			h.Write([]byte("symlink:" + target))
+		} else {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(h, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		files[filepath.ToSlash(rel)] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifacts: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareArtifacts(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0755))
+	}
+	write(first, "app", "binary")
+	write(second, "app", "binary")
+	write(first, "static/libcore.a", "archive")
+	write(second, "static/libcore.a", "archive")
+
+	total, diffs, err := compareArtifacts(first, second)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Empty(t, diffs)
+
+	write(second, "static/libcore.a", "archive with a timestamp")
+	write(first, "tool", "only here")
+	write(second, "extra", "only there")
+	total, diffs, err = compareArtifacts(first, second)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []string{
+		"extra: only in the second build",
+		"static/libcore.a: differs",
+		"tool: only in the first build",
+	}, diffs)
+}
+
+func TestCompareArtifactsSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	first, second := t.TempDir(), t.TempDir()
+	require.NoError(t, os.Symlink("libcore.so.1", filepath.Join(first, "libcore.so")))
+	require.NoError(t, os.Symlink("libcore.so.2", filepath.Join(second, "libcore.so")))
+
+	_, diffs, err := compareArtifacts(first, second)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"libcore.so: differs"}, diffs)
+}
+
+func TestSetupReproducible(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	t.Setenv("ZERO_AR_DATE", "")
+	require.NoError(t, setupReproducible(t.TempDir()))
+	assert.Equal(t, "1700000000", os.Getenv("SOURCE_DATE_EPOCH"))
+	assert.Equal(t, "1", os.Getenv("ZERO_AR_DATE"))
+}
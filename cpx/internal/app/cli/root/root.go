@@ -4,6 +4,8 @@ import (
 	"os"
 
 	"github.com/ozacod/cpx/internal/app/cli"
+	"github.com/ozacod/cpx/internal/pkg/project"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +19,23 @@ Generate, build, lint, test, and ship CMake/vcpkg-based C++ projects with sensib
 	// Don't show usage on errors by default
 	SilenceUsage:  true,
 	SilenceErrors: true, // handle printing ourselves in Execute
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		noColor, _ := cmd.Flags().GetBool("no-color")
+		output.Init(quiet, noColor)
+		buildSystem, _ := cmd.Flags().GetString("build-system")
+		return project.SetOverride(buildSystem)
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only print warnings, errors and command output")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output (also NO_COLOR=1)")
+	rootCmd.PersistentFlags().Bool("json", false, "Output machine-readable JSON (list, search, info, targets, toolchain list|show|doctor)")
+	rootCmd.PersistentFlags().String("build-system", "", "Use this build system (vcpkg, bazel, meson) instead of the build_system of cpx.yaml or the detected one")
+	_ = rootCmd.RegisterFlagCompletionFunc("build-system", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"vcpkg", "bazel", "meson"}, cobra.ShellCompDirectiveNoFileComp
+	})
 }
 
 // Execute runs the root command
@@ -19,24 +19,50 @@ func RunCmd() *cobra.Command {
   - vcpkg/CMake projects: Builds with CMake and runs the binary
   - Bazel projects: Uses bazel run
 
+When the project builds several executables and --target is not given, a
+fuzzy-searchable picker asks which one to run (if stdin is a terminal). The
+choice is remembered in .cache/last-targets.json.
+
+With --all, or --target given several times, the project is built once and
+the executables run one after another, or -j at a time with their output
+prefixed by name; a table of exit statuses follows, and the command fails
+if any of them did. --all skips test and benchmark executables.
+
+--timeout kills an executable that runs longer, for programs that can hang
+in CI; with --dump-on-timeout the stacks of its threads are printed first,
+by attaching gdb or lldb (or a core file is written with gcore). With Bazel
+the limit includes the build, since bazel run does both.
+
 Arguments after -- are passed to the binary.`,
 		Example: `  cpx run                 # Debug build by default
   cpx run --release        # Release build, then run
   cpx run --asan           # Run with AddressSanitizer
-  cpx run --target app -- --flag value`,
+  cpx run --sanitizer asan,ubsan
+  cpx run --target app -- --flag value
+  cpx run --all            # Run every executable in turn
+  cpx run --target gen --target check -j 2
+  cpx run --heap-profile   # Record heap allocations and print the top sites
+  cpx run --timeout 60s --dump-on-timeout`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runRun(cmd, args)
+			return withBuildLog(cmd, "run", func() error { return runRun(cmd, args) })
 		},
 	}
 
 	cmd.Flags().Bool("release", false, "Build in release mode (-O2). Default is debug")
 	cmd.Flags().String("toolchain", "", "Toolchain to run in Docker (from cpx-ci.yaml)")
+	cmd.Flags().StringArray("target", nil, "Executable to run when the project has several (repeatable)")
+	cmd.Flags().Bool("all", false, "Run every executable the project builds, except tests and benchmarks")
+	cmd.Flags().IntP("jobs", "j", 1, "Run up to this many executables at once with --all or several --target")
+	cmd.Flags().Duration("timeout", 0, "Kill the executable when it runs longer, e.g. 60s")
+	cmd.Flags().Bool("dump-on-timeout", false, "Print the stacks of a timed out executable's threads before killing it")
 	cmd.Flags().StringP("opt", "O", "", "Override optimization level: 0,1,2,3,s,fast")
 	cmd.Flags().Bool("verbose", false, "Show full build output")
-	cmd.Flags().Bool("asan", false, "Run with AddressSanitizer")
-	cmd.Flags().Bool("tsan", false, "Run with ThreadSanitizer")
-	cmd.Flags().Bool("msan", false, "Run with MemorySanitizer")
-	cmd.Flags().Bool("ubsan", false, "Run with UndefinedBehaviorSanitizer")
+	addSanitizerFlags(cmd, "Run")
+	cmd.Flags().Bool("heap-profile", false, "Record heap allocations (heaptrack, malloc stack logging or massif) into .cache/profiles")
+	addEnvFileFlag(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("target", completeTargets)
+	_ = cmd.RegisterFlagCompletionFunc("toolchain", completeToolchains)
 
 	return cmd
 }
@@ -46,8 +72,34 @@ func runRun(cmd *cobra.Command, args []string) error {
 	toolchain, _ := cmd.Flags().GetString("toolchain")
 	optLevel, _ := cmd.Flags().GetString("opt")
 	verbose, _ := cmd.Flags().GetBool("verbose")
+	targets, _ := cmd.Flags().GetStringArray("target")
+	all, _ := cmd.Flags().GetBool("all")
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	heapProfile, _ := cmd.Flags().GetBool("heap-profile")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	dumpOnTimeout, _ := cmd.Flags().GetBool("dump-on-timeout")
+	multiple := all || len(targets) > 1
+	if dumpOnTimeout && timeout <= 0 {
+		return fmt.Errorf("--dump-on-timeout requires --timeout")
+	}
+	if all && len(targets) > 0 {
+		return fmt.Errorf("--all cannot be combined with --target")
+	}
+	if jobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	var target string
+	if len(targets) == 1 {
+		target = targets[0]
+	}
 
 	if toolchain != "" {
+		if multiple {
+			return fmt.Errorf("--all and several --target cannot be combined with --toolchain")
+		}
+		if heapProfile {
+			return fmt.Errorf("--heap-profile cannot be combined with --toolchain")
+		}
 		return runToolchainBuild(ToolchainBuildOptions{
 			ToolchainName:     toolchain,
 			Rebuild:           false,
@@ -58,57 +110,61 @@ func runRun(cmd *cobra.Command, args []string) error {
 		})
 	}
 
-	asan, _ := cmd.Flags().GetBool("asan")
-	tsan, _ := cmd.Flags().GetBool("tsan")
-	msan, _ := cmd.Flags().GetBool("msan")
-	ubsan, _ := cmd.Flags().GetBool("ubsan")
-
-	sanitizer := ""
-	sanitizerCount := 0
-	if asan {
-		sanitizer = "asan"
-		sanitizerCount++
+	sanitizer, err := sanitizerFromFlags(cmd)
+	if err != nil {
+		return err
 	}
-	if tsan {
-		sanitizer = "tsan"
-		sanitizerCount++
+	if heapProfile && sanitizer != "" {
+		return fmt.Errorf("--heap-profile cannot be combined with sanitizers, which replace the allocator")
 	}
-	if msan {
-		sanitizer = "msan"
-		sanitizerCount++
-	}
-	if ubsan {
-		sanitizer = "ubsan"
-		sanitizerCount++
-	}
-	if sanitizerCount > 1 {
-		return fmt.Errorf("only one sanitizer can be used at a time (got %d)", sanitizerCount)
+	if heapProfile && multiple {
+		return fmt.Errorf("--heap-profile runs a single executable; use --target")
 	}
 
+	if err := loadEnvFiles(cmd); err != nil {
+		return err
+	}
 	projectType := DetectProjectType()
 
 	WarnMissingBuildTools(projectType)
+	if err := setupCUDA(); err != nil {
+		return err
+	}
+	if err := syncTargetOptions(projectType); err != nil {
+		return err
+	}
 
 	opts := build.RunOptions{
-		Release:   release,
-		OptLevel:  optLevel,
-		Sanitizer: sanitizer,
-		Target:    "",
-		Args:      args,
-		Verbose:   verbose,
+		Release:       release,
+		OptLevel:      optLevel,
+		Sanitizer:     sanitizer,
+		Target:        target,
+		Args:          args,
+		Verbose:       verbose,
+		Timeout:       timeout,
+		DumpOnTimeout: dumpOnTimeout,
+	}
+	if target == "" {
+		opts.Pick = targetPicker("run", "")
+	}
+
+	if heapProfile {
+		return runHeapProfile(opts)
 	}
 
+	var builder build.BuildSystem
 	switch projectType {
 	case ProjectTypeBazel:
-		builder := bazel.New()
-		return builder.Run(context.Background(), opts)
+		builder = bazel.New()
 	case ProjectTypeMeson:
-		builder := meson.New()
-		return builder.Run(context.Background(), opts)
+		builder = meson.New()
 	case ProjectTypeVcpkg:
-		builder := vcpkg.New()
-		return builder.Run(context.Background(), opts)
+		builder = vcpkg.New()
 	default:
 		return fmt.Errorf("unsupported project type")
 	}
+	if multiple {
+		return runTargets(builder, opts, targets, jobs)
+	}
+	return builder.Run(context.Background(), opts)
 }
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// runResult is the outcome of one executable run by cpx run --all.
+type runResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// runTargets builds the project once and runs several of its executables,
+// all of them when targets is empty, at most jobs at a time, then reports
+// the exit status of each.
+func runTargets(builder build.BuildSystem, opts build.RunOptions, targets []string, jobs int) error {
+	if err := builder.Build(context.Background(), build.BuildOptions{
+		Release:   opts.Release,
+		OptLevel:  opts.OptLevel,
+		Sanitizer: opts.Sanitizer,
+		Verbose:   opts.Verbose,
+	}); err != nil {
+		return err
+	}
+
+	dir := filepath.Join(config.BinDir(), "native", build.GetOutputDir(opts.Release, opts.OptLevel, opts.Sanitizer))
+	executables, err := targetExecutables(dir, targets)
+	if err != nil {
+		return err
+	}
+	if len(executables) == 0 {
+		return fmt.Errorf("no executable found in %s. Make sure the project builds an executable", dir)
+	}
+
+	var env []string
+	if opts.Sanitizer != "" {
+		env = append(os.Environ(), build.SanitizerEnv(opts.Sanitizer)...)
+	}
+	results := runExecutables(executables, opts, env, jobs)
+
+	fmt.Fprintln(output.Stdout())
+	printRunSummary(output.Stdout(), results)
+
+	var failed []string
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d target(s) failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// targetExecutables returns the executables of targets in dir, or every
+// executable there except tests and benchmarks when targets is empty.
+func targetExecutables(dir string, targets []string) ([]string, error) {
+	if len(targets) == 0 {
+		return artifacts.Find(artifacts.Rule{
+			Dir:         dir,
+			Executables: true,
+			Skip:        []string{"*_test", "*_tests", "*_test.exe", "*_tests.exe", "*_bench", "*_bench.exe"},
+		})
+	}
+	executables := make([]string, len(targets))
+	for i, target := range targets {
+		name := target
+		if runtime.GOOS == "windows" && !strings.HasSuffix(strings.ToLower(name), ".exe") {
+			name += ".exe"
+		}
+		executables[i] = filepath.Join(dir, name)
+		if _, err := os.Stat(executables[i]); err != nil {
+			return nil, fmt.Errorf("target executable '%s' not found in %s", target, dir)
+		}
+	}
+	return executables, nil
+}
+
+// runExecutables runs executables with the arguments and time limit of
+// opts, at most jobs at a time. One at a time they get the terminal;
+// concurrently their output is prefixed with their name.
+func runExecutables(executables []string, opts build.RunOptions, env []string, jobs int) []runResult {
+	jobs = max(jobs, 1)
+	width := 0
+	for _, exe := range executables {
+		width = max(width, len(filepath.Base(exe)))
+	}
+
+	var outMu sync.Mutex
+	results := make([]runResult, len(executables))
+	run := func(i int, exe string) {
+		name := filepath.Base(exe)
+		cmd := execCommand(exe, opts.Args...)
+		cmd.Env = env
+		var out *prefixWriter
+		if jobs == 1 {
+			fmt.Fprintf(output.Stdout(), "\n%s  ▶ Run%s %s%s%s\n", colors.Cyan, colors.Reset, colors.Green, name, colors.Reset)
+			fmt.Fprintln(output.Stdout(), strings.Repeat("─", 40))
+			cmd.Stdin = os.Stdin
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+		} else {
+			color := prefixColor(i)
+			out = newPrefixWriter(os.Stdout, fmt.Sprintf("%s%-*s |%s ", color, width, name, colors.Reset), &outMu)
+			cmd.Stdout = out
+			cmd.Stderr = out
+		}
+
+		start := time.Now()
+		err := build.RunProgram(cmd, opts.Timeout, opts.DumpOnTimeout)
+		if out != nil {
+			out.Flush()
+		}
+		if crash := build.CrashOf(cmd, err); crash != nil {
+			// Keep the backtrace in one piece among the output of the others
+			outMu.Lock()
+			build.ReportCrash(crash)
+			outMu.Unlock()
+		}
+		results[i] = runResult{Name: name, Err: err, Duration: time.Since(start)}
+	}
+
+	if jobs == 1 {
+		for i, exe := range executables {
+			run(i, exe)
+		}
+		return results
+	}
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, exe := range executables {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			run(i, exe)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// exitStatus describes how a run ended: its exit code, or the signal or
+// error that stopped it.
+func exitStatus(err error) string {
+	if err == nil {
+		return "exit 0"
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return fmt.Sprintf("exit %d", exitErr.ExitCode())
+	}
+	var timeoutErr *build.RunTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return "timeout"
+	}
+	return err.Error()
+}
+
+// printRunSummary prints a pass/fail/exit status/time table for runs.
+func printRunSummary(w io.Writer, results []runResult) {
+	width := len("Target")
+	for _, r := range results {
+		width = max(width, len(r.Name))
+	}
+
+	fmt.Fprintf(w, "%s%-*s  %-6s  %-8s  %s%s\n", colors.Bold, width, "Target", "Status", "Exit", "Time", colors.Reset)
+	for _, r := range results {
+		status := colors.Green + "✓ pass" + colors.Reset
+		if r.Err != nil {
+			status = colors.Red + "✗ fail" + colors.Reset
+		}
+		fmt.Fprintf(w, "%-*s  %s  %-8s  %s\n", width, r.Name, status, exitStatus(r.Err), r.Duration.Round(10*time.Millisecond))
+	}
+}
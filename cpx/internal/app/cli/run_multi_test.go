@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes an executable shell script to dir.
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755))
+	return path
+}
+
+func TestTargetExecutables(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as executables")
+	}
+	dir := t.TempDir()
+	gen := writeScript(t, dir, "gen", "exit 0")
+	check := writeScript(t, dir, "check", "exit 0")
+	writeScript(t, dir, "myapp_tests", "exit 0")
+	writeScript(t, dir, "myapp_bench", "exit 0")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "libmyapp.a"), nil, 0644))
+
+	all, err := targetExecutables(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{check, gen}, all, "tests, benchmarks and libraries are skipped")
+
+	picked, err := targetExecutables(dir, []string{"gen", "myapp_tests"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{gen, filepath.Join(dir, "myapp_tests")}, picked, "named targets run in the given order")
+
+	_, err = targetExecutables(dir, []string{"gen", "missing"})
+	assert.ErrorContains(t, err, "target executable 'missing' not found")
+}
+
+func TestRunExecutables(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as executables")
+	}
+	dir := t.TempDir()
+	ok := writeScript(t, dir, "ok", `echo "ok $1"`)
+	fail := writeScript(t, dir, "fail", "exit 3")
+	killed := writeScript(t, dir, "killed", "kill -9 $$")
+
+	for _, jobs := range []int{1, 3} {
+		results := runExecutables([]string{ok, fail, killed}, build.RunOptions{Args: []string{"arg"}}, nil, jobs)
+		require.Len(t, results, 3)
+		assert.Equal(t, "ok", results[0].Name)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "exit 3", exitStatus(results[1].Err))
+		assert.Contains(t, exitStatus(results[2].Err), "killed")
+	}
+
+	hang := writeScript(t, dir, "hang", "exec sleep 30")
+	results := runExecutables([]string{hang}, build.RunOptions{Timeout: 100 * time.Millisecond}, nil, 1)
+	assert.Equal(t, "timeout", exitStatus(results[0].Err))
+}
+
+func TestPrintRunSummary(t *testing.T) {
+	var buf bytes.Buffer
+	printRunSummary(&buf, []runResult{
+		{Name: "gen"},
+		{Name: "check", Err: errors.New("signal: killed")},
+	})
+	out := buf.String()
+	assert.Contains(t, out, "Target")
+	assert.Regexp(t, `gen +.*pass.* +exit 0`, out)
+	assert.Regexp(t, `check +.*fail.* +signal: killed`, out)
+}
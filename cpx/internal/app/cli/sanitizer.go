@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// addSanitizerFlags registers --sanitizer and its --asan, --tsan, --msan and
+// --ubsan shortcuts. verb completes the flag descriptions ("Build", "Run").
+func addSanitizerFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().String("sanitizer", "", verb+" with sanitizers, comma-separated: asan,ubsan,tsan,msan")
+	cmd.Flags().Bool("asan", false, verb+" with AddressSanitizer")
+	cmd.Flags().Bool("tsan", false, verb+" with ThreadSanitizer")
+	cmd.Flags().Bool("msan", false, verb+" with MemorySanitizer")
+	cmd.Flags().Bool("ubsan", false, verb+" with UndefinedBehaviorSanitizer")
+
+	_ = cmd.RegisterFlagCompletionFunc("sanitizer", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"asan", "ubsan", "tsan", "msan", "asan,ubsan"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// sanitizerFromFlags combines --sanitizer and the shortcut flags into one
+// sanitizer list and loads the project's sanitizer settings for it.
+func sanitizerFromFlags(cmd *cobra.Command) (string, error) {
+	spec, _ := cmd.Flags().GetString("sanitizer")
+	names := []string{spec}
+	for _, name := range []string{"asan", "tsan", "msan", "ubsan"} {
+		if on, _ := cmd.Flags().GetBool(name); on {
+			names = append(names, name)
+		}
+	}
+
+	sanitizer, err := build.ParseSanitizer(strings.Join(names, ","))
+	if err != nil {
+		return "", err
+	}
+	if sanitizer != "" {
+		if err := loadSanitizerConfig("cpx-ci.yaml"); err != nil {
+			return "", err
+		}
+	}
+	return sanitizer, nil
+}
+
+// loadSanitizerConfig applies the "sanitizers" section of a cpx-ci.yaml,
+// if the project has one.
+func loadSanitizerConfig(path string) error {
+	ciConfig, err := config.LoadToolchains(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return applySanitizerConfig(ciConfig)
+}
+
+// applySanitizerConfig passes the sanitizer settings of cpx-ci.yaml to the
+// build systems.
+func applySanitizerConfig(ciConfig *config.ToolchainConfig) error {
+	cfg := make(map[string]build.SanitizerConfig, len(ciConfig.Sanitizers))
+	for name, s := range ciConfig.Sanitizers {
+		cfg[name] = build.SanitizerConfig{Flags: s.Flags, Options: s.Options}
+	}
+	if err := build.SetSanitizerConfig(cfg); err != nil {
+		return fmt.Errorf("invalid sanitizers in cpx-ci.yaml: %w", err)
+	}
+	return nil
+}
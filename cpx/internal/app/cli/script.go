@@ -0,0 +1,15 @@
+package cli
+
+import (
+	"github.com/ozacod/cpx/internal/pkg/script"
+)
+
+// loadProjectScript loads the optional cpx.star script from the project root.
+// It returns nil if the project has no script.
+func loadProjectScript() (*script.Script, error) {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return nil, err
+	}
+	return script.Load(projectRoot)
+}
@@ -15,7 +15,9 @@ func SearchCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "search [query]",
 		Short: "Search for libraries interactively",
-		Long:  "Search for libraries using an interactive TUI. Select packages to add them to your project.",
+		Long: `Search for libraries using an interactive TUI. Select packages to add them to your project.
+
+With --json, the results for the query are printed instead of opening the TUI.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runSearch(cmd, args)
 		},
@@ -25,7 +27,7 @@ func SearchCmd() *cobra.Command {
 	return cmd
 }
 
-func runSearch(_ *cobra.Command, args []string) error {
+func runSearch(cmd *cobra.Command, args []string) error {
 	query := ""
 	if len(args) > 0 {
 		query = args[0]
@@ -46,6 +48,17 @@ func runSearch(_ *cobra.Command, args []string) error {
 		builder = vcpkg.New()
 	}
 
+	if jsonOutput(cmd) {
+		deps, err := builder.SearchDependencies(context.Background(), query)
+		if err != nil {
+			return err
+		}
+		if deps == nil {
+			deps = []build.Dependency{}
+		}
+		return printJSON(deps)
+	}
+
 	// Adapter for search
 	searchFunc := func(q string) ([]tui.SearchResult, error) {
 		deps, err := builder.SearchDependencies(context.Background(), q)
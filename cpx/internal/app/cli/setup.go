@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// vcpkgRepoURL is the upstream vcpkg repository.
+const vcpkgRepoURL = "https://github.com/microsoft/vcpkg.git"
+
+func init() {
+	// Builds of vcpkg projects offer to set vcpkg up when it is not configured
+	vcpkg.SetupPrompt = promptVcpkgSetup
+}
+
+// SetupCmd creates the setup command
+func SetupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "setup",
+		Short: "Install the tools cpx needs",
+		Long:  "Install and configure the tools cpx needs.",
+	}
+
+	vcpkgCmd := &cobra.Command{
+		Use:   "vcpkg",
+		Short: "Clone and bootstrap vcpkg",
+		Long: `Clone microsoft/vcpkg into the cpx cache directory (or --dir), run
+bootstrap-vcpkg and set vcpkg_root in the global config. An existing clone
+at that location is reused, so the command can be rerun to repair a setup.
+
+Builds of vcpkg projects offer to do this when vcpkg_root is not set.`,
+		Example: `  cpx setup vcpkg                # Into the cpx cache directory
+  cpx setup vcpkg --dir ~/vcpkg  # Into a custom location`,
+		Args: cobra.NoArgs,
+		RunE: runSetupVcpkg,
+	}
+	vcpkgCmd.Flags().String("dir", "", "Clone location (default: <cache dir>/vcpkg)")
+	vcpkgCmd.Flags().String("url", vcpkgRepoURL, "vcpkg git URL")
+	cmd.AddCommand(vcpkgCmd)
+
+	return cmd
+}
+
+func runSetupVcpkg(cmd *cobra.Command, _ []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	url, _ := cmd.Flags().GetString("url")
+	if dir == "" {
+		var err error
+		if dir, err = defaultVcpkgDir(); err != nil {
+			return err
+		}
+	}
+	_, err := setupVcpkg(dir, url)
+	return err
+}
+
+// defaultVcpkgDir returns where cpx setup vcpkg clones vcpkg by default.
+func defaultVcpkgDir() (string, error) {
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "vcpkg"), nil
+}
+
+// setupVcpkg clones vcpkg into dir unless it is already there, bootstraps
+// it and sets vcpkg_root. It returns the absolute vcpkg root.
+func setupVcpkg(dir, url string) (string, error) {
+	if !CheckCommandExists("git") {
+		return "", fmt.Errorf("git is required to set up vcpkg")
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(absDir, ".git")); err == nil {
+		output.Stepf("Using the vcpkg clone in %s", absDir)
+	} else {
+		if entries, err := os.ReadDir(absDir); err == nil && len(entries) > 0 {
+			return "", fmt.Errorf("%s exists and is not a git checkout\n  hint: remove it or use --dir to choose another location", absDir)
+		}
+		if err := os.MkdirAll(filepath.Dir(absDir), 0755); err != nil {
+			return "", fmt.Errorf("failed to create directory: %w", err)
+		}
+		// Full history: manifests pin versions through builtin-baseline commits
+		output.Stepf("Cloning vcpkg to %s...", absDir)
+		gitCmd := execCommand("git", "clone", url, absDir)
+		gitCmd.Stdout = os.Stdout
+		gitCmd.Stderr = os.Stderr
+		if err := gitCmd.Run(); err != nil {
+			return "", fmt.Errorf("git clone failed: %w", err)
+		}
+	}
+
+	exe, bootstrap := filepath.Join(absDir, "vcpkg"), []string{"./bootstrap-vcpkg.sh", "-disableMetrics"}
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+		bootstrap = []string{"cmd", "/c", "bootstrap-vcpkg.bat", "-disableMetrics"}
+	}
+	if _, err := os.Stat(exe); err != nil {
+		output.Stepf("Bootstrapping vcpkg...")
+		bootstrapCmd := execCommand(bootstrap[0], bootstrap[1:]...)
+		bootstrapCmd.Dir = absDir
+		bootstrapCmd.Stdout = os.Stdout
+		bootstrapCmd.Stderr = os.Stderr
+		if err := bootstrapCmd.Run(); err != nil {
+			return "", fmt.Errorf("bootstrap-vcpkg failed: %w", err)
+		}
+	}
+
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return "", err
+	}
+	cfg.VcpkgRoot = absDir
+	if err := config.SaveGlobal(cfg); err != nil {
+		return "", fmt.Errorf("failed to save config: %w", err)
+	}
+	output.Successf("✓ Set vcpkg_root to: %s", absDir)
+	fmt.Printf("  %sUpdate it later with: cpx upgrade vcpkg%s\n", colors.Gray, colors.Reset)
+	return absDir, nil
+}
+
+// promptVcpkgSetup asks whether to set vcpkg up now. It returns "" when
+// stdin is not a terminal or the user declines.
+func promptVcpkgSetup() (string, error) {
+	if !stdinIsTerminal() {
+		return "", nil
+	}
+	dir, err := defaultVcpkgDir()
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("%svcpkg is not configured.%s Clone and bootstrap it into %s? [Y/n] ", colors.Yellow, colors.Reset, dir)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return setupVcpkg(dir, vcpkgRepoURL)
+	default:
+		return "", nil
+	}
+}
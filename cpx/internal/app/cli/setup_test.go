@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupVcpkg(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	vcpkgDir := filepath.Join(tmpDir, "vcpkg")
+
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+	execLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	var calls [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		calls = append(calls, append([]string{name}, arg...))
+		// Simulate the clone
+		require.NoError(t, os.MkdirAll(filepath.Join(vcpkgDir, ".git"), 0755))
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		return cmd
+	}
+
+	root, err := setupVcpkg(vcpkgDir, vcpkgRepoURL)
+	require.NoError(t, err)
+	assert.Equal(t, vcpkgDir, root)
+	require.Len(t, calls, 2)
+	assert.Equal(t, []string{"git", "clone", vcpkgRepoURL, vcpkgDir}, calls[0])
+	assert.Contains(t, calls[1], "-disableMetrics")
+
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, vcpkgDir, cfg.VcpkgRoot)
+
+	// Rerunning reuses the clone and the bootstrapped binary
+	require.NoError(t, os.WriteFile(filepath.Join(vcpkgDir, "vcpkg"), nil, 0755))
+	if _, err := os.Stat(filepath.Join(vcpkgDir, "vcpkg.exe")); os.IsNotExist(err) {
+		require.NoError(t, os.WriteFile(filepath.Join(vcpkgDir, "vcpkg.exe"), nil, 0755))
+	}
+	calls = nil
+	_, err = setupVcpkg(vcpkgDir, vcpkgRepoURL)
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+}
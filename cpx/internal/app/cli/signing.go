@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// developerIDFromConfig returns the Developer ID settings of a cpx-ci.yaml,
+// or nil when the project signs ad hoc.
+func developerIDFromConfig(path string) (*config.MacOSSigningConfig, error) {
+	ciConfig, err := config.LoadToolchains(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ciConfig.Signing.DeveloperID(), nil
+}
+
+// planMacOSSigning adds the steps signing the macOS binaries of target with
+// the Developer ID and, with a keychain profile, notarizing them.
+func planMacOSSigning(plan *releasePlan, target releaseTarget, developerID *config.MacOSSigningConfig) error {
+	if releaseHostOS != "darwin" {
+		return fmt.Errorf("toolchain '%s' builds for macOS, and signing it needs codesign, which only runs on macOS\n  hint: release it from a Mac or remove signing.macos from cpx-ci.yaml", target.Toolchain)
+	}
+	plan.add("sign", fmt.Sprintf("sign the binaries in %s as %q", target.Dir, developerID.Identity), nil, func() error {
+		files, err := artifacts.MachOFiles(target.Dir)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("toolchain '%s' produced no macOS binaries in %s", target.Toolchain, target.Dir)
+		}
+		for _, file := range files {
+			if err := artifacts.Codesign(developerID.Identity, developerID.Entitlements, file); err != nil {
+				return err
+			}
+		}
+		output.Successf(" Signed %d binaries of %s", len(files), target.Toolchain)
+		return nil
+	})
+	if developerID.KeychainProfile == "" {
+		return nil
+	}
+	plan.add("notarize", fmt.Sprintf("notarize the binaries in %s with notarytool (profile %s)", target.Dir, developerID.KeychainProfile), nil, func() error {
+		output.Stepf(" Waiting for notarization of %s...", target.Toolchain)
+		if err := artifacts.Notarize(developerID.KeychainProfile, target.Dir); err != nil {
+			return err
+		}
+		output.Successf(" Notarized %s", target.Toolchain)
+		return nil
+	})
+	return nil
+}
+
+// planWindowsSigning adds the step signing the executables and DLLs of
+// target with the Authenticode certificate.
+func planWindowsSigning(plan *releasePlan, target releaseTarget, cert *config.WindowsSigningConfig) error {
+	tool := "signtool"
+	if releaseHostOS != "windows" {
+		if cert.PFX == "" {
+			return fmt.Errorf("toolchain '%s' builds for Windows, and its certificate is in the Windows certificate store, which only signtool on Windows can use\n  hint: set signing.windows.pfx or %s to sign with osslsigncode", target.Toolchain, config.WindowsSigningPFXEnv)
+		}
+		tool = "osslsigncode"
+	}
+	certificate := "certificate " + cert.CertificateThumbprint
+	if cert.PFX != "" {
+		certificate = cert.PFX
+	}
+	plan.add("sign", fmt.Sprintf("sign the .exe and .dll files in %s with %s (%s)", target.Dir, tool, certificate), nil, func() error {
+		files, err := artifacts.PEFiles(target.Dir)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("toolchain '%s' produced no .exe or .dll files in %s", target.Toolchain, target.Dir)
+		}
+		authenticode := artifacts.Authenticode{
+			Thumbprint:   cert.CertificateThumbprint,
+			PFX:          cert.PFX,
+			Password:     os.Getenv(config.WindowsSigningPasswordEnv),
+			TimestampURL: cert.TimestampURL,
+		}
+		if err := artifacts.SignWindows(authenticode, files); err != nil {
+			return err
+		}
+		output.Successf(" Signed %d binaries of %s", len(files), target.Toolchain)
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/binsize"
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/profile"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// SizeCmd creates the size command
+func SizeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "size",
+		Short: "Report the section and symbol sizes of built binaries",
+		Long: `Build the project in release mode and report how large the binaries in
+.bin/native/<variant> are, per section and per symbol. bloaty is used when it
+is installed; otherwise the ELF, Mach-O or PE file is read directly.
+
+--baseline records the current sizes in .size-baseline.json. While that file
+exists, binaries that grew by more than --threshold percent fail the command,
+so size regressions are caught in CI.`,
+		Example: `  cpx size                         # All executables and shared libraries
+  cpx size --target app -O s       # One binary, optimized for size
+  cpx size --baseline              # Record the current sizes
+  cpx size --threshold 2           # Fail on more than 2% growth`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withBuildLog(cmd, "size", func() error { return runSize(cmd, args) })
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().String("target", "", "Binary to report (default: all executables and shared libraries)")
+	cmd.Flags().StringP("opt", "O", "", "Optimization level of the build: 0,1,2,3,s,fast (default: release)")
+	cmd.Flags().Bool("no-build", false, "Report the existing binaries without building")
+	cmd.Flags().Int("sections", 10, "Number of sections to list per binary")
+	cmd.Flags().Int("symbols", 10, "Number of symbols to list per binary")
+	cmd.Flags().Bool("baseline", false, "Record the current sizes as the baseline")
+	cmd.Flags().String("baseline-file", binsize.BaselineFile, "Size baseline file")
+	cmd.Flags().Float64("threshold", 5, "Growth over the baseline, in percent, that fails the command")
+	cmd.Flags().Bool("json", false, "Print the reports as JSON")
+	cmd.Flags().Bool("verbose", false, "Show full build output")
+
+	_ = cmd.RegisterFlagCompletionFunc("target", completeTargets)
+
+	return cmd
+}
+
+func runSize(cmd *cobra.Command, _ []string) error {
+	target, _ := cmd.Flags().GetString("target")
+	optLevel, _ := cmd.Flags().GetString("opt")
+	noBuild, _ := cmd.Flags().GetBool("no-build")
+	sections, _ := cmd.Flags().GetInt("sections")
+	symbols, _ := cmd.Flags().GetInt("symbols")
+	record, _ := cmd.Flags().GetBool("baseline")
+	baselineFile, _ := cmd.Flags().GetString("baseline-file")
+	threshold, _ := cmd.Flags().GetFloat64("threshold")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	if _, err := RequireProject("cpx size"); err != nil {
+		return err
+	}
+
+	if !noBuild {
+		builder, err := projectBuilder()
+		if err != nil {
+			return err
+		}
+		if err := builder.Build(context.Background(), build.BuildOptions{
+			Release:  true,
+			OptLevel: optLevel,
+			Target:   target,
+			Verbose:  verbose,
+		}); err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Join(config.BinDir(), "native", build.GetOutputDir(true, optLevel, ""))
+	binaries, err := sizeBinaries(dir, target)
+	if err != nil {
+		return err
+	}
+
+	var reports []*binsize.Report
+	for _, bin := range binaries {
+		report, err := binsize.Analyze(bin, symbols)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+
+	var changes []binsize.Change
+	baseline, err := binsize.LoadBaseline(baselineFile)
+	switch {
+	case err == nil:
+		changes = baseline.Compare(reports, threshold)
+	case os.IsNotExist(err):
+		baseline = nil
+	default:
+		return err
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(output.Stdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range reports {
+			printSizeReport(r, sections, symbols, changes)
+		}
+	}
+
+	if record {
+		if baseline == nil {
+			baseline = &binsize.Baseline{}
+		}
+		baseline.Update(reports)
+		if err := baseline.Save(baselineFile); err != nil {
+			return err
+		}
+		output.Successf("✓ Recorded the size of %d binaries in %s", len(reports), baselineFile)
+		return nil
+	}
+
+	var regressions []string
+	for _, c := range changes {
+		if c.Regression && c.Section == "" {
+			regressions = append(regressions, fmt.Sprintf("%s grew by %s (%+.1f%%)", c.Binary, profile.FormatBytes(c.Delta()), c.Percent()))
+		}
+	}
+	if len(regressions) > 0 {
+		return fmt.Errorf("size regression over %.1f%%:\n  %s\n  hint: accept the new sizes with cpx size --baseline", threshold, strings.Join(regressions, "\n  "))
+	}
+	return nil
+}
+
+// sizeBinaries returns the binaries in dir to report: target, or every
+// executable and shared library.
+func sizeBinaries(dir, target string) ([]string, error) {
+	if target != "" {
+		name := filepath.Base(target)
+		if runtime.GOOS == "windows" && filepath.Ext(name) == "" {
+			name += ".exe"
+		}
+		for _, path := range []string{filepath.Join(dir, name), filepath.Join(dir, build.LibraryShared, name)} {
+			if _, err := os.Stat(path); err == nil {
+				return []string{path}, nil
+			}
+		}
+		return nil, fmt.Errorf("binary '%s' not found in %s\n  hint: build it with cpx build --release --target %s", name, dir, target)
+	}
+
+	files, err := artifacts.Find(
+		artifacts.Rule{Dir: dir, Executables: true},
+		artifacts.Rule{Dir: filepath.Join(dir, build.LibraryShared), Extensions: []string{".so", ".dylib", ".dll"}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var binaries []string
+	for _, f := range files {
+		// Versioned library symlinks point at a binary that is listed too
+		if info, err := os.Lstat(f); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		binaries = append(binaries, f)
+	}
+	if len(binaries) == 0 {
+		return nil, fmt.Errorf("no binaries found in %s\n  hint: build the project in release mode first", dir)
+	}
+	return binaries, nil
+}
+
+func printSizeReport(r *binsize.Report, sections, symbols int, changes []binsize.Change) {
+	var delta string
+	sectionChanges := map[string]binsize.Change{}
+	for _, c := range changes {
+		if c.Binary != filepath.Base(r.Binary) {
+			continue
+		}
+		if c.Section != "" {
+			sectionChanges[c.Section] = c
+			continue
+		}
+		delta = formatSizeChange(c)
+	}
+
+	fmt.Fprintf(output.Stdout(), "\n%s%s%s  %s%s %s(%s)%s\n", colors.Bold, filepath.Base(r.Binary), colors.Reset,
+		profile.FormatBytes(r.Size), delta, colors.Gray, r.Tool, colors.Reset)
+
+	fmt.Fprintf(output.Stdout(), "  %-28s %12s %12s\n", "SECTION", "FILE", "VM")
+	for i, s := range r.Sections {
+		if i == sections {
+			fmt.Fprintf(output.Stdout(), "  %s... %d more%s\n", colors.Gray, len(r.Sections)-sections, colors.Reset)
+			break
+		}
+		change := ""
+		if c, ok := sectionChanges[s.Name]; ok {
+			change = formatSizeChange(c)
+		}
+		fmt.Fprintf(output.Stdout(), "  %-28s %12s %12s%s\n", s.Name, profile.FormatBytes(s.FileSize), profile.FormatBytes(s.VMSize), change)
+	}
+
+	if symbols > 0 {
+		if len(r.Symbols) == 0 {
+			output.Infof("  %sNo symbol sizes (stripped binary)%s", colors.Gray, colors.Reset)
+			return
+		}
+		fmt.Fprintf(output.Stdout(), "  %12s  %s\n", "SIZE", "SYMBOL")
+		for _, s := range r.Symbols {
+			name := s.Name
+			if len(name) > 80 {
+				name = name[:77] + "..."
+			}
+			fmt.Fprintf(output.Stdout(), "  %12s  %s\n", profile.FormatBytes(s.FileSize), name)
+		}
+	}
+}
+
+// formatSizeChange formats a change from the baseline, in red when it is a
+// regression.
+func formatSizeChange(c binsize.Change) string {
+	if c.Delta() == 0 {
+		return fmt.Sprintf("  %s(unchanged)%s", colors.Gray, colors.Reset)
+	}
+	color := colors.Green
+	sign := "-"
+	delta := -c.Delta()
+	if c.Delta() > 0 {
+		color, sign, delta = colors.Yellow, "+", c.Delta()
+		if c.Regression {
+			color = colors.Red
+		}
+	}
+	return fmt.Sprintf("  %s%s%s (%+.1f%%)%s", color, sign, profile.FormatBytes(delta), c.Percent(), colors.Reset)
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/build/stats"
+	"github.com/ozacod/cpx/internal/pkg/cache"
+	"github.com/ozacod/cpx/internal/pkg/profile"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/spf13/cobra"
+)
+
+// StatsCmd creates the stats command
+func StatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show local build statistics",
+		Long: `Show how long builds, tests and other commands take in this project, from
+the runs cpx records in .cache/stats.jsonl: average, fastest, slowest and
+last duration per command and variant, whether they are getting slower,
+how often the build had nothing to do, the slowest cpx-ci.yaml toolchains
+and how the size of the build output changes. Nothing leaves the machine.`,
+		Example: `  cpx stats               # The last 30 days
+  cpx stats --since 7d
+  cpx stats --since all --json
+  cpx stats --clear       # Forget the recorded runs`,
+		Args: cobra.NoArgs,
+		RunE: runStats,
+	}
+	cmd.Flags().String("since", "30d", `Only runs newer than this age ("all" for every run)`)
+	cmd.Flags().Bool("clear", false, "Delete the recorded runs")
+	return cmd
+}
+
+// statsView is the --json output of cpx stats
+type statsView struct {
+	Runs       int             `json:"runs"`
+	Commands   []stats.Summary `json:"commands"`
+	Toolchains []stats.Summary `json:"toolchains"`
+}
+
+func runStats(cmd *cobra.Command, _ []string) error {
+	root, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	if clearRuns, _ := cmd.Flags().GetBool("clear"); clearRuns {
+		if err := os.Remove(stats.File(root)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", stats.File(root), err)
+		}
+		output.Successf("✓ Cleared build statistics")
+		return nil
+	}
+
+	since, _ := cmd.Flags().GetString("since")
+	records, err := stats.Load(root)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", stats.File(root), err)
+	}
+	if since != "all" {
+		age, err := cache.ParseAge(since)
+		if err != nil {
+			return fmt.Errorf("invalid --since %q: %w", since, err)
+		}
+		records = recordsSince(records, time.Now().Add(-age))
+	}
+
+	view := statsView{
+		Runs:       len(records),
+		Commands:   stats.Summarize(records, statsCommandKey),
+		Toolchains: stats.Summarize(records, func(r stats.Record) string { return r.Toolchain }),
+	}
+	if jsonOutput(cmd) {
+		return printJSON(view)
+	}
+	if len(records) == 0 {
+		output.Infof("No runs recorded yet")
+		output.Infof("  %sBuild, test or run the project and they show up here%s", colors.Gray, colors.Reset)
+		return nil
+	}
+
+	fmt.Fprintf(output.Stdout(), "%s%d runs%s", colors.Bold, len(records), colors.Reset)
+	if since != "all" {
+		fmt.Fprintf(output.Stdout(), " %sin the last %s%s", colors.Gray, since, colors.Reset)
+	}
+	fmt.Fprintln(output.Stdout())
+	fmt.Fprintln(output.Stdout())
+	fmt.Fprintf(output.Stdout(), "%-24s %5s %5s %9s %9s %9s %9s %7s %10s %10s\n", "COMMAND", "RUNS", "FAIL", "AVERAGE", "FASTEST", "SLOWEST", "LAST", "TREND", "UP TO DATE", "CACHE HITS")
+	for _, s := range view.Commands {
+		fmt.Fprintf(output.Stdout(), "%-24s %5d %5s %9s %9s %9s %9s %s %10s %10s\n", s.Key, s.Runs, formatCount(s.Failures),
+			formatStatsDuration(s.Average), formatStatsDuration(s.Fastest), formatStatsDuration(s.Slowest),
+			formatStatsDuration(s.Last), formatTrend(s.Trend), fmt.Sprintf("%d%%", s.UpToDate*100/s.Runs), formatCount(s.CacheHits))
+	}
+
+	if len(view.Toolchains) > 0 {
+		fmt.Fprintf(output.Stdout(), "\n%-24s %5s %5s %9s %9s\n", "TOOLCHAIN", "RUNS", "FAIL", "AVERAGE", "LAST")
+		for _, s := range view.Toolchains {
+			fmt.Fprintf(output.Stdout(), "%-24s %5d %5s %9s %9s\n", s.Key, s.Runs, formatCount(s.Failures),
+				formatStatsDuration(s.Average), formatStatsDuration(s.Last))
+		}
+	}
+
+	var sized []stats.Summary
+	for _, s := range view.Commands {
+		if s.LastSize > 0 {
+			sized = append(sized, s)
+		}
+	}
+	if len(sized) > 0 {
+		fmt.Fprintf(output.Stdout(), "\n%-24s %10s %10s\n", "OUTPUT SIZE", "NOW", "CHANGE")
+		for _, s := range sized {
+			change := s.LastSize - s.FirstSize
+			changeText := "-"
+			if change != 0 {
+				sign := "+"
+				if change < 0 {
+					sign, change = "-", -change
+				}
+				changeText = sign + profile.FormatBytes(change)
+			}
+			fmt.Fprintf(output.Stdout(), "%-24s %10s %10s\n", s.Key, profile.FormatBytes(s.LastSize), changeText)
+		}
+	}
+	return nil
+}
+
+// statsCommandKey groups runs by command and variant, leaving toolchain
+// builds to their own table.
+func statsCommandKey(r stats.Record) string {
+	if r.Toolchain != "" {
+		return ""
+	}
+	if r.Variant == "" {
+		return r.Command
+	}
+	return r.Command + " " + r.Variant
+}
+
+func recordsSince(records []stats.Record, since time.Time) []stats.Record {
+	var out []stats.Record
+	for _, r := range records {
+		if !r.Time.Before(since) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// formatCount formats a count, "-" for none.
+func formatCount(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+// formatTrend pads the trend to 7 columns, red when runs got slower.
+func formatTrend(percent float64) string {
+	text := stats.FormatTrend(percent)
+	padded := fmt.Sprintf("%7s", text)
+	switch {
+	case text == "":
+		return fmt.Sprintf("%7s", "-")
+	case percent > 0:
+		return colors.Red + padded + colors.Reset
+	default:
+		return colors.Green + padded + colors.Reset
+	}
+}
+
+// formatStatsDuration rounds d for display: 850ms, 12.3s, 4m05s.
+func formatStatsDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	default:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+}
+
+// statsVariant names the build variant of a build, run or test command
+// from its flags and build profile, as its output directory is named.
+func statsVariant(cmd *cobra.Command) string {
+	if preset, _ := cmd.Flags().GetString("preset"); preset != "" {
+		return build.PresetOutputDir(preset)
+	}
+	release, _ := cmd.Flags().GetBool("release")
+	opt, _ := cmd.Flags().GetString("opt")
+	names := []string{}
+	if spec, _ := cmd.Flags().GetString("sanitizer"); spec != "" {
+		names = append(names, spec)
+	}
+	for _, name := range []string{"asan", "tsan", "msan", "ubsan"} {
+		if on, _ := cmd.Flags().GetBool(name); on {
+			names = append(names, name)
+		}
+	}
+	sanitizer, _ := build.ParseSanitizer(strings.Join(names, ","))
+	if name, _ := cmd.Flags().GetString("profile"); name != "" {
+		if profile, err := loadBuildProfile("cpx-ci.yaml", name); err == nil {
+			release = release || profile.Release
+			if opt == "" {
+				opt = profile.Opt
+			}
+			if sanitizer == "" {
+				sanitizer, _ = build.ParseSanitizer(profile.Sanitizer)
+			}
+		}
+	}
+	return build.GetOutputDir(release, opt, sanitizer)
+}
+
+// recordStats completes r from the log of the run and the size of
+// artifactDir, if given, and appends it to the project statistics. Failing
+// to record never fails the command.
+func recordStats(root string, r stats.Record, logPath, artifactDir string) {
+	if logPath != "" {
+		r.UpToDate, r.CacheHits = stats.ScanLog(logPath)
+	}
+	if artifactDir != "" {
+		r.ArtifactBytes = stats.DirSize(artifactDir)
+	}
+	if err := stats.Append(root, r); err != nil {
+		output.Debugf("could not record build statistics: %v", err)
+	}
+}
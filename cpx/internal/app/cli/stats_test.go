@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/build/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsVariant(t *testing.T) {
+	cmd := BuildCmd()
+	assert.Equal(t, "debug", statsVariant(cmd))
+
+	require.NoError(t, cmd.Flags().Set("release", "true"))
+	require.NoError(t, cmd.Flags().Set("asan", "true"))
+	assert.Equal(t, "release-asan", statsVariant(cmd))
+
+	// Commands without build flags
+	assert.Equal(t, "debug", statsVariant(BenchCmd()))
+}
+
+func TestStatsCommandKey(t *testing.T) {
+	assert.Equal(t, "build release", statsCommandKey(stats.Record{Command: "build", Variant: "release"}))
+	assert.Equal(t, "package", statsCommandKey(stats.Record{Command: "package"}))
+	assert.Empty(t, statsCommandKey(stats.Record{Command: "build", Toolchain: "linux-gcc"}))
+}
+
+func TestFormatStatsDuration(t *testing.T) {
+	assert.Equal(t, "850ms", formatStatsDuration(850*time.Millisecond))
+	assert.Equal(t, "12.3s", formatStatsDuration(12300*time.Millisecond))
+	assert.Equal(t, "4m05s", formatStatsDuration(4*time.Minute+5*time.Second))
+}
+
+func TestRecordsSince(t *testing.T) {
+	now := time.Now()
+	records := []stats.Record{{Time: now.Add(-48 * time.Hour)}, {Time: now.Add(-time.Hour)}}
+	assert.Len(t, recordsSince(records, now.Add(-24*time.Hour)), 1)
+}
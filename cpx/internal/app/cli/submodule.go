@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build/bazel"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/build/meson"
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// submoduleDir is where cpx add --submodule checks libraries out
+const submoduleDir = "third_party"
+
+// addSubmoduleDependency checks the repository at url out as a git
+// submodule under third_party/, wires it into the build and records it in
+// cpx-ci.yaml. name defaults to the repository name.
+func addSubmoduleDependency(projectType ProjectType, url, name string) error {
+	if name == "" {
+		name = submoduleName(url)
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("cannot name a dependency after %q\n  hint: choose a name with --name", url)
+	}
+
+	var adder build.SubmoduleDependencyAdder
+	switch projectType {
+	case ProjectTypeVcpkg:
+		adder = vcpkg.New()
+	case ProjectTypeBazel:
+		adder = bazel.New()
+	case ProjectTypeMeson:
+		adder = meson.New()
+	default:
+		return fmt.Errorf("unsupported project type")
+	}
+
+	dir := path.Join(submoduleDir, name)
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	}
+	output.Stepf("Adding %s as a submodule in %s...", url, dir)
+	gitCmd := execCommand("git", "submodule", "add", url, dir)
+	gitCmd.Stdout = os.Stdout
+	gitCmd.Stderr = os.Stderr
+	if err := gitCmd.Run(); err != nil {
+		return fmt.Errorf("git submodule add failed: %w\n  hint: the project must be a git repository (git init)", err)
+	}
+
+	if err := adder.AddSubmoduleDependency(context.Background(), name, dir); err != nil {
+		return fmt.Errorf("%s was checked out in %s but could not be added to the build: %w\n  hint: remove it with git rm -f %s", url, dir, err, dir)
+	}
+	if err := saveSubmodule("cpx-ci.yaml", config.Submodule{Name: name, URL: url, Path: dir}); err != nil {
+		return err
+	}
+	output.Successf("✓ Recorded %s in cpx-ci.yaml", name)
+	return nil
+}
+
+// submoduleName returns the repository name of a git URL:
+// https://github.com/fmtlib/fmt.git and git@github.com:fmtlib/fmt are fmt.
+func submoduleName(url string) string {
+	url = strings.TrimRight(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return url
+}
+
+// saveSubmodule adds s to the submodules of the cpx-ci.yaml at path,
+// replacing one with the same name.
+func saveSubmodule(path string, s config.Submodule) error {
+	ciConfig, err := config.LoadToolchains(path)
+	if os.IsNotExist(err) {
+		ciConfig, err = &config.ToolchainConfig{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range ciConfig.Submodules {
+		if ciConfig.Submodules[i].Name == s.Name {
+			ciConfig.Submodules[i], replaced = s, true
+		}
+	}
+	if !replaced {
+		ciConfig.Submodules = append(ciConfig.Submodules, s)
+	}
+	return config.SaveToolchains(ciConfig, path)
+}
+
+// withSubmodules adds the submodules recorded in the project's cpx-ci.yaml
+// to deps. Those the build system already lists, as path dependencies, are
+// marked as submodules instead; those whose checkout is gone are skipped.
+func withSubmodules(root string, deps []build.Dependency) []build.Dependency {
+	ciConfig, err := config.LoadToolchains(filepath.Join(root, "cpx-ci.yaml"))
+	if err != nil {
+		return deps
+	}
+	for _, s := range ciConfig.Submodules {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(s.Path))); err != nil {
+			continue
+		}
+		dep := build.Dependency{Name: s.Name, Version: "submodule", Description: s.URL}
+		found := false
+		for i := range deps {
+			if deps[i].Name == s.Name {
+				deps[i], found = dep, true
+			}
+		}
+		if !found {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmoduleName(t *testing.T) {
+	assert.Equal(t, "fmt", submoduleName("https://github.com/fmtlib/fmt.git"))
+	assert.Equal(t, "fmt", submoduleName("https://github.com/fmtlib/fmt/"))
+	assert.Equal(t, "json", submoduleName("git@github.com:nlohmann/json.git"))
+	assert.Equal(t, "lib", submoduleName("../lib"))
+}
+
+func TestAddSubmoduleDependency(t *testing.T) {
+	t.Chdir(t.TempDir())
+	appCMake := "project(app LANGUAGES CXX)\nadd_executable(app src/main.cpp)\n"
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte(appCMake), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"dependencies": []}`), 0644))
+
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	var calls [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		calls = append(calls, append([]string{name}, arg...))
+		// Simulate the checkout
+		dir := arg[len(arg)-1]
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "CMakeLists.txt"), []byte("project(fmt)\n"), 0644))
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		return cmd
+	}
+
+	require.NoError(t, addSubmoduleDependency(ProjectTypeVcpkg, "https://github.com/fmtlib/fmt.git", ""))
+	assert.Equal(t, [][]string{{"git", "submodule", "add", "https://github.com/fmtlib/fmt.git", "third_party/fmt"}}, calls)
+
+	data, err := os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "add_subdirectory(third_party/fmt ")
+
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []config.Submodule{{Name: "fmt", URL: "https://github.com/fmtlib/fmt.git", Path: "third_party/fmt"}}, ciConfig.Submodules)
+
+	assert.Error(t, addSubmoduleDependency(ProjectTypeVcpkg, "https://github.com/fmtlib/fmt.git", ""), "already exists")
+	assert.Error(t, addSubmoduleDependency(ProjectTypeVcpkg, "https://example.com/", "../x"))
+}
+
+func TestWithSubmodules(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "third_party", "json"), 0755))
+	path := filepath.Join(root, "cpx-ci.yaml")
+	require.NoError(t, saveSubmodule(path, config.Submodule{Name: "json", URL: "old", Path: "third_party/json"}))
+	require.NoError(t, saveSubmodule(path, config.Submodule{Name: "json", URL: "https://github.com/nlohmann/json", Path: "third_party/json"}))
+	require.NoError(t, saveSubmodule(path, config.Submodule{Name: "gone", URL: "https://example.com/gone", Path: "third_party/gone"}))
+
+	deps := withSubmodules(root, []build.Dependency{{Name: "fmt", Version: "11.0.2"}, {Name: "json", Version: "path"}})
+	assert.Equal(t, []build.Dependency{
+		{Name: "fmt", Version: "11.0.2"},
+		{Name: "json", Version: "submodule", Description: "https://github.com/nlohmann/json"},
+	}, deps)
+
+	deps = withSubmodules(root, nil)
+	assert.Equal(t, []build.Dependency{{Name: "json", Version: "submodule", Description: "https://github.com/nlohmann/json"}}, deps)
+}
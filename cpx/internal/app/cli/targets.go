@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// syncTargetOptions brings the target options block of CMakeLists.txt in
+// line with the "targets" of cpx-ci.yaml before a build. Meson and Bazel
+// targets cannot be changed after they are declared, so other projects are
+// only warned about.
+func syncTargetOptions(projectType ProjectType) error {
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	if os.IsNotExist(err) {
+		ciConfig = &config.ToolchainConfig{}
+	} else if err != nil {
+		return err
+	}
+	if projectType != ProjectTypeVcpkg {
+		if len(ciConfig.Targets) > 0 {
+			output.Warnf("\"targets\" in cpx-ci.yaml only applies to CMake projects; ignoring it")
+		}
+		return nil
+	}
+	// Without targets a block left from earlier ones is still removed
+	changed, err := vcpkg.SyncTargetOptions("CMakeLists.txt", ciConfig.Targets)
+	if err != nil {
+		return err
+	}
+	if changed {
+		output.Successf("✓ Updated target options in CMakeLists.txt from cpx-ci.yaml")
+	}
+	return nil
+}
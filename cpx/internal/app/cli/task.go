@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// TaskCmd creates the task command
+func TaskCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "task [name]",
+		Short: "Run a task of cpx.yaml",
+		Long: `Run a named shell command from the tasks: section of cpx.yaml, in the
+project root, or list the tasks when no name is given:
+
+  tasks:
+    gen:
+      run: python3 tools/gen_tables.py > src/tables.inc
+      description: Regenerate the lookup tables
+    serve-docs:
+      run: python3 -m http.server -d docs/html
+
+The task runs with CPX_TASK and CPX_PROJECT_DIR set, and its exit code is
+passed through.`,
+		Example: `  cpx task        # List the tasks
+  cpx task gen    # Run the gen task`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeTasks,
+		RunE:              runTask,
+	}
+
+	return cmd
+}
+
+func runTask(_ *cobra.Command, args []string) error {
+	root, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	tasks, err := projectTasks(root)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		printTasks(tasks)
+		return nil
+	}
+	name := args[0]
+	task, ok := tasks[name]
+	if !ok {
+		return fmt.Errorf("task %q not found in %s\n  hint: available tasks: %s", name, config.ManifestFile, strings.Join(taskNames(tasks), ", "))
+	}
+	if task.Run == "" {
+		return fmt.Errorf("task %q has no run command", name)
+	}
+
+	output.Stepf("Running task %s: %s", name, task.Run)
+	cmd := shellCommand(task.Run)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "CPX_TASK="+name, "CPX_PROJECT_DIR="+root)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
+}
+
+// projectTasks returns the tasks of the cpx.yaml in root.
+func projectTasks(root string) (map[string]config.Task, error) {
+	manifest, err := config.LoadManifest(filepath.Join(root, config.ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no %s in %s\n  hint: define tasks under tasks: in %s (cpx migrate creates it)", config.ManifestFile, root, config.ManifestFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Tasks, nil
+}
+
+// taskNames returns the names of tasks, sorted.
+func taskNames(tasks map[string]config.Task) []string {
+	names := slices.Sorted(maps.Keys(tasks))
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	return names
+}
+
+// printTasks lists tasks with their descriptions.
+func printTasks(tasks map[string]config.Task) {
+	if len(tasks) == 0 {
+		fmt.Fprintf(output.Stdout(), "No tasks defined in %s.\n", config.ManifestFile)
+		return
+	}
+	width := 0
+	for name := range tasks {
+		width = max(width, len(name))
+	}
+	fmt.Fprintf(output.Stdout(), "%sTasks:%s\n", colors.Cyan, colors.Reset)
+	for _, name := range taskNames(tasks) {
+		about := tasks[name].Description
+		if about == "" {
+			about = tasks[name].Run
+		}
+		fmt.Fprintf(output.Stdout(), "  %s%-*s%s  %s\n", colors.Green, width, name, colors.Reset, about)
+	}
+}
+
+// completeTasks completes the task names of cpx.yaml.
+func completeTasks(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	root, err := findProjectRoot()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	tasks, err := projectTasks(root)
+	if err != nil || len(tasks) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(taskNames(tasks), toComplete, nil), cobra.ShellCompDirectiveNoFileComp
+}
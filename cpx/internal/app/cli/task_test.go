@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("CMakeLists.txt", nil, 0644))
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte(`tasks:
+  gen:
+    run: echo "$CPX_TASK" > generated.txt
+    description: Generate a file
+  noop:
+    run: "true"
+`), 0644))
+
+	cmd := TaskCmd()
+	cmd.SetArgs([]string{"gen"})
+	require.NoError(t, cmd.Execute())
+	data, err := os.ReadFile("generated.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "gen\n", string(data))
+
+	cmd = TaskCmd()
+	cmd.SetArgs([]string{"missing"})
+	cmd.SilenceUsage = true
+	assert.ErrorContains(t, cmd.Execute(), "available tasks: gen, noop")
+
+	assert.Equal(t, []string{"gen"}, complete(t, TaskCmd(), "task", "g"))
+}
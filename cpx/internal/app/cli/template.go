@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/ozacod/cpx/internal/pkg/templates/project_templates"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/naming"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// TemplateCmd creates the template command
+func TemplateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage user project templates",
+		Long: `Manage your own project templates for "cpx new --template <name>".
+
+A template is a directory, local or in a git repository. Its files and file
+names are Go templates: {{.Name}} is the project name, {{.SafeName}} the name
+as a C++ identifier, {{.CppStandard}} and {{.PackageManager}} the chosen
+C++ standard and build system. An optional cpx-template.yaml sets a
+description, vcpkg dependencies, "verbatim" globs of files to copy
+without rendering and "post_generate" shell commands that cpx new runs in
+the new project, with CPX_PROJECT_NAME, CPX_PROJECT_SAFE_NAME,
+CPX_PROJECT_DIR, CPX_CPP_STANDARD, CPX_PACKAGE_MANAGER and CPX_TEMPLATE set
+(skip them with cpx new --no-hooks).`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <name> <dir|git-url>",
+		Short: "Register a template directory or git repository",
+		Example: `  cpx template add mytpl ~/templates/mytpl
+  cpx template add mytpl https://github.com/me/tpl`,
+		Args: cobra.ExactArgs(2),
+		RunE: runTemplateAdd,
+	}
+	addCmd.Flags().Bool("force", false, "Replace an existing template of the same name")
+	cmd.AddCommand(addCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List user templates",
+		Args:  cobra.NoArgs,
+		RunE:  runTemplateList,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove <name>",
+		Short: "Unregister a template (and delete its clone)",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTemplateRemove,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "update [name...]",
+		Short: "Pull the latest version of git templates",
+		RunE:  runTemplateUpdate,
+	})
+
+	return cmd
+}
+
+// templateNameRe matches valid user template names.
+var templateNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// isGitTemplateSource reports whether source names a git repository rather
+// than a local directory.
+func isGitTemplateSource(source string) bool {
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return false
+	}
+	return regexp.MustCompile(`^(https?|ssh|git|file)://|^[\w.-]+@[\w.-]+:|\.git$`).MatchString(source)
+}
+
+// userTemplatesDir returns where git templates are cloned.
+func userTemplatesDir() (string, error) {
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "templates"), nil
+}
+
+func runTemplateAdd(cmd *cobra.Command, args []string) error {
+	name, source := args[0], args[1]
+	force, _ := cmd.Flags().GetBool("force")
+
+	if !templateNameRe.MatchString(name) {
+		return fmt.Errorf("invalid template name '%s': use letters, digits, '.', '_' and '-'", name)
+	}
+	if t, ok := project_templates.GetTemplateByName(name); ok {
+		if _, user := t.(*project_templates.UserTemplate); !user {
+			return fmt.Errorf("'%s' is a built-in template; choose another name", name)
+		}
+	}
+
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	if old, ok := cfg.Templates[name]; ok {
+		if !force {
+			return fmt.Errorf("template '%s' already exists (%s)\n  hint: use --force to replace it", name, old.Source)
+		}
+		removeTemplateClone(old)
+	}
+
+	tmpl := config.UserTemplate{Source: source}
+	if isGitTemplateSource(source) {
+		if !CheckCommandExists("git") {
+			return fmt.Errorf("git is required to add a template from a repository")
+		}
+		dir, err := userTemplatesDir()
+		if err != nil {
+			return err
+		}
+		tmpl.Dir = filepath.Join(dir, name)
+		if err := os.RemoveAll(tmpl.Dir); err != nil {
+			return fmt.Errorf("failed to clean %s: %w", tmpl.Dir, err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+		output.Stepf("Cloning %s...", source)
+		if _, err := runGit(dir, "clone", "--depth", "1", source, tmpl.Dir); err != nil {
+			return err
+		}
+	} else {
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		tmpl.Source = abs
+		tmpl.Dir = abs
+	}
+
+	if _, err := project_templates.LoadUserTemplate(name, tmpl.Dir); err != nil {
+		removeTemplateClone(tmpl)
+		return err
+	}
+
+	if cfg.Templates == nil {
+		cfg.Templates = map[string]config.UserTemplate{}
+	}
+	cfg.Templates[name] = tmpl
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	output.Successf("✓ Added template '%s'", name)
+	output.Infof("  %sUse it with: cpx new --template %s%s", colors.Gray, name, colors.Reset)
+	return nil
+}
+
+func runTemplateList(_ *cobra.Command, _ []string) error {
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		return err
+	}
+	if len(cfg.Templates) == 0 {
+		output.Infof("No user templates. Add one with: cpx template add <name> <dir|git-url>")
+		return nil
+	}
+	for _, name := range sortedTemplateNames(cfg.Templates) {
+		tmpl := cfg.Templates[name]
+		description := ""
+		if t, err := project_templates.LoadUserTemplate(name, tmpl.Dir); err != nil {
+			description = colors.Red + err.Error() + colors.Reset
+		} else {
+			description = t.Description()
+		}
+		fmt.Fprintf(output.Stdout(), "  %s%-20s%s %s\n", colors.Cyan, name, colors.Reset, tmpl.Source)
+		fmt.Fprintf(output.Stdout(), "  %-20s %s%s%s\n", "", colors.Gray, description, colors.Reset)
+	}
+	return nil
+}
+
+func runTemplateRemove(_ *cobra.Command, args []string) error {
+	name := args[0]
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	tmpl, ok := cfg.Templates[name]
+	if !ok {
+		return fmt.Errorf("template '%s' not found", name)
+	}
+	removeTemplateClone(tmpl)
+	delete(cfg.Templates, name)
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	output.Successf("✓ Removed template '%s'", name)
+	return nil
+}
+
+func runTemplateUpdate(_ *cobra.Command, args []string) error {
+	cfg, err := loadGlobalForUpdate()
+	if err != nil {
+		return err
+	}
+	names := args
+	if len(names) == 0 {
+		names = sortedTemplateNames(cfg.Templates)
+	}
+	for _, name := range names {
+		tmpl, ok := cfg.Templates[name]
+		if !ok {
+			return fmt.Errorf("template '%s' not found", name)
+		}
+		if tmpl.Dir == tmpl.Source {
+			continue // local directories are used in place
+		}
+		output.Stepf("Updating %s...", name)
+		if _, err := runGit(tmpl.Dir, "pull", "--ff-only"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeTemplateClone deletes the clone of a git template; local template
+// directories are left alone.
+func removeTemplateClone(tmpl config.UserTemplate) {
+	if tmpl.Dir != "" && tmpl.Dir != tmpl.Source {
+		_ = os.RemoveAll(tmpl.Dir)
+	}
+}
+
+func sortedTemplateNames(templates map[string]config.UserTemplate) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registerUserTemplates makes the user templates of the global config
+// available to cpx new, warning about ones that cannot be loaded.
+func registerUserTemplates() {
+	cfg, err := config.LoadGlobal()
+	if err != nil || len(cfg.Templates) == 0 {
+		return
+	}
+	dirs := make(map[string]string, len(cfg.Templates))
+	for name, tmpl := range cfg.Templates {
+		dirs[name] = tmpl.Dir
+	}
+	for _, err := range project_templates.RegisterUserTemplates(dirs) {
+		output.Warnf("%v", err)
+	}
+}
+
+// postGenerateEnv returns the environment of post-generate commands: the
+// project's settings as CPX_* variables.
+func postGenerateEnv(cfg project_templates.TemplateConfig, templateName, projectDir string) []string {
+	packageManager := cfg.PackageManager
+	if packageManager == "" {
+		packageManager = "vcpkg"
+	}
+	return append(os.Environ(),
+		"CPX_PROJECT_NAME="+cfg.ProjectName,
+		"CPX_PROJECT_SAFE_NAME="+naming.SafeIdent(cfg.ProjectName),
+		"CPX_PROJECT_DIR="+projectDir,
+		"CPX_CPP_STANDARD="+strconv.Itoa(cfg.CppStandard),
+		"CPX_PACKAGE_MANAGER="+packageManager,
+		"CPX_TEMPLATE="+templateName,
+	)
+}
+
+// runPostGenerateHooks runs a template's post-generate commands with the
+// shell, in the generated project, stopping at the first that fails.
+func runPostGenerateHooks(hooks []string, cfg project_templates.TemplateConfig, templateName, projectDir string) error {
+	projectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	env := postGenerateEnv(cfg, templateName, projectDir)
+	for _, hook := range hooks {
+		output.Stepf("Running post-generate command: %s", hook)
+		cmd := shellCommand(hook)
+		cmd.Dir = projectDir
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("post-generate command %q failed: %w\n  hint: the project was created in %s; run it there by hand or recreate with --no-hooks", hook, err, projectDir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsGitTemplateSource(t *testing.T) {
+	assert.True(t, isGitTemplateSource("https://github.com/me/tpl"))
+	assert.True(t, isGitTemplateSource("git@github.com:me/tpl.git"))
+	assert.True(t, isGitTemplateSource("../tpl.git"))
+	assert.False(t, isGitTemplateSource(t.TempDir()))
+	assert.False(t, isGitTemplateSource("templates/mytpl"))
+}
+
+func TestUserTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// A template with rendered file names and contents, a verbatim file and
+	// a manifest that is not copied
+	tplDir := filepath.Join(tmpDir, "tpl")
+	require.NoError(t, os.MkdirAll(filepath.Join(tplDir, "include", "{{.Name}}"), 0755))
+	files := map[string]string{
+		"cpx-template.yaml":             "description: Test template\nverbatim: [\"src/*.cpp\"]\npost_generate: [\"echo $CPX_PROJECT_SAFE_NAME $CPX_CPP_STANDARD > hook.txt\"]\n",
+		"CMakeLists.txt":                "project({{.Name}})\nset(CMAKE_CXX_STANDARD {{.CppStandard}})\n",
+		"include/{{.Name}}/api.hpp":     "namespace {{.SafeName}} {}\n",
+		"src/main.cpp":                  "int main() { int a[1][1] = {{0}}; return a[0][0]; }\n",
+		"build-{{.PackageManager}}.txt": "",
+	}
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(tplDir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tplDir, path), []byte(content), 0644))
+	}
+
+	cmd := TemplateCmd()
+	cmd.SetArgs([]string{"add", "usertpl-test", tplDir})
+	require.NoError(t, cmd.Execute())
+
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, config.UserTemplate{Source: tplDir, Dir: tplDir}, cfg.Templates["usertpl-test"])
+
+	cmd = TemplateCmd()
+	cmd.SetArgs([]string{"add", "usertpl-test", tplDir})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+
+	cmd = TemplateCmd()
+	cmd.SetArgs([]string{"add", "gRPC", tplDir})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "built-in template")
+
+	registerUserTemplates()
+	require.NoError(t, createProject(tui.ProjectConfig{
+		Name:         "my-app",
+		CppStandard:  20,
+		UseTemplate:  true,
+		TemplateName: "usertpl-test",
+	}))
+
+	cmake, err := os.ReadFile("my-app/CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "project(my-app)\nset(CMAKE_CXX_STANDARD 20)\n", string(cmake))
+	header, err := os.ReadFile("my-app/include/my-app/api.hpp")
+	require.NoError(t, err)
+	assert.Equal(t, "namespace my_app {}\n", string(header))
+	mainCpp, err := os.ReadFile("my-app/src/main.cpp")
+	require.NoError(t, err)
+	assert.Contains(t, string(mainCpp), "{{0}}")
+	assert.FileExists(t, "my-app/build-vcpkg.txt")
+	assert.NoFileExists(t, "my-app/cpx-template.yaml")
+	hook, err := os.ReadFile("my-app/hook.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "my_app 20\n", string(hook), "post-generate commands run in the project with its settings")
+
+	require.NoError(t, createProject(tui.ProjectConfig{
+		Name:            "no-hooks",
+		UseTemplate:     true,
+		TemplateName:    "usertpl-test",
+		NoTemplateHooks: true,
+	}))
+	assert.FileExists(t, "no-hooks/CMakeLists.txt")
+	assert.NoFileExists(t, "no-hooks/hook.txt")
+
+	cmd = TemplateCmd()
+	cmd.SetArgs([]string{"remove", "usertpl-test"})
+	require.NoError(t, cmd.Execute())
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Templates)
+	assert.DirExists(t, tplDir, "local templates are not deleted")
+}
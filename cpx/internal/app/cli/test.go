@@ -2,13 +2,18 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/ozacod/cpx/internal/pkg/build/bazel"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
 	"github.com/ozacod/cpx/internal/pkg/build/meson"
 	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
-	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -16,18 +21,56 @@ func TestCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "test",
 		Short: "Build and run tests",
-		Long:  "Build the project tests and run them. Detects vcpkg/CMake or Bazel projects automatically.",
+		Long: `Build the project tests and run them. Detects vcpkg/CMake or Bazel projects automatically.
+
+Without --filter and with stdin a terminal, a fuzzy-searchable picker offers
+every test, or all of them, when there are several. The choice is
+remembered in .cache/last-targets.json.
+
+--label and --exclude-label select tests by ctest label, Bazel tag or Meson
+suite, so unit and integration tests can be run separately whatever the
+build system; --exclude skips tests whose names match a regular expression.
+
+--failed runs only the tests that failed last time: ctest --rerun-failed
+for CMake, the failures in Meson's test log, and for Bazel its cache of
+passing results, so only failed or changed tests run again.
+
+A "test" section of cpx-ci.yaml limits how long tests may run:
+
+  test:
+    timeout: 60s          # each test: ctest --timeout, bazel --test_timeout,
+                          # meson -t (a multiple of Meson's 30s default)
+    session_timeout: 20m  # the whole run, which is then stopped
+
+--timeout overrides the per-test limit.`,
 		Example: `  cpx test                 # Build + run all tests
   cpx test --verbose       # Show verbose output
-  cpx test --filter MySuite.*`,
+  cpx test --filter MySuite.*
+  cpx test --label integration
+  cpx test --failed        # Only the tests that failed last time
+  cpx test --exclude-label slow --exclude 'Network\.'
+  cpx test --sanitizer asan,ubsan`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runTest(cmd, args)
+			return withNotify("test", func() error {
+				return withBuildLog(cmd, "test", func() error {
+					return withCommandHooks("test", func() error { return runTest(cmd, args) })
+				})
+			})
 		},
 	}
 
 	cmd.Flags().BoolP("verbose", "v", false, "Show verbose test output")
 	cmd.Flags().String("filter", "", "Filter tests by name (ctest regex or bazel target)")
+	cmd.Flags().StringSlice("label", nil, "Only run tests with this ctest label, Bazel tag or Meson suite (repeatable)")
+	cmd.Flags().StringSlice("exclude-label", nil, "Skip tests with this ctest label, Bazel tag or Meson suite (repeatable)")
+	cmd.Flags().String("exclude", "", "Skip tests whose names match this regular expression")
+	cmd.Flags().Bool("failed", false, "Only run the tests that failed in the last run")
+	cmd.Flags().Duration("timeout", 0, "Time limit of each test, e.g. 90s (default: test.timeout of cpx-ci.yaml)")
 	cmd.Flags().String("toolchain", "", "Toolchain to run tests in (from cpx-ci.yaml)")
+	addSanitizerFlags(cmd, "Test")
+	addEnvFileFlag(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("toolchain", completeToolchains)
 
 	return cmd
 }
@@ -36,10 +79,14 @@ func runTest(cmd *cobra.Command, _ []string) error {
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	filter, _ := cmd.Flags().GetString("filter")
 	toolchain, _ := cmd.Flags().GetString("toolchain")
+	labels, _ := cmd.Flags().GetStringSlice("label")
+	excludeLabels, _ := cmd.Flags().GetStringSlice("exclude-label")
+	exclude, _ := cmd.Flags().GetString("exclude")
+	failed, _ := cmd.Flags().GetBool("failed")
 
 	if toolchain != "" {
-		if filter != "" {
-			fmt.Printf("%sWarning: --filter is currently ignored when running with --toolchain%s\n", colors.Yellow, colors.Reset)
+		if filter != "" || len(labels) > 0 || len(excludeLabels) > 0 || exclude != "" || failed {
+			output.Warnf("--filter, --label, --exclude-label, --exclude and --failed are currently ignored when running with --toolchain")
 		}
 		return runToolchainBuild(ToolchainBuildOptions{
 			ToolchainName:     toolchain,
@@ -51,7 +98,21 @@ func runTest(cmd *cobra.Command, _ []string) error {
 		})
 	}
 
+	sanitizer, err := sanitizerFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := loadEnvFiles(cmd); err != nil {
+		return err
+	}
 	projectType := DetectProjectType()
+	if err := setupCUDA(); err != nil {
+		return err
+	}
+	if err := syncTargetOptions(projectType); err != nil {
+		return err
+	}
 
 	var builder build.BuildSystem
 
@@ -66,10 +127,62 @@ func runTest(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("could not detect project type (no MODULE.bazel, meson.build, or vcpkg.json found)")
 	}
 
+	timeout, sessionTimeout, err := testTimeouts("cpx-ci.yaml")
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("timeout") {
+		timeout, _ = cmd.Flags().GetDuration("timeout")
+	}
+
 	opts := build.TestOptions{
-		Verbose: verbose,
-		Filter:  filter,
+		Verbose:        verbose,
+		Filter:         filter,
+		Labels:         labels,
+		ExcludeLabels:  excludeLabels,
+		Exclude:        exclude,
+		RerunFailed:    failed,
+		Timeout:        timeout,
+		SessionTimeout: sessionTimeout,
+		Sanitizer:      sanitizer,
+	}
+	// The picker offers every test, so it is left out when tests are selected
+	if filter == "" && len(labels) == 0 && len(excludeLabels) == 0 && exclude == "" && !failed {
+		opts.Pick = targetPicker("test", allTests)
+	}
+
+	start := time.Now()
+	err = builder.Test(context.Background(), opts)
+	if err != nil {
+		for _, crash := range build.FindCrashes(start, testCrashDirs()) {
+			build.ReportCrash(crash)
+		}
 	}
+	var sessionErr *build.SessionTimeoutError
+	switch {
+	case errors.As(err, &sessionErr):
+		output.Warnf("⏱ Timed out: the test run was stopped after %s (test.session_timeout in cpx-ci.yaml)", sessionErr.Timeout)
+	case err != nil && timeout > 0:
+		output.Warnf("⏱ Tests running longer than %s are stopped and count as timed out; check the results above", timeout)
+	}
+	return err
+}
+
+// testCrashDirs are searched for the core dumps of test executables that
+// crashed: the working directories ctest and meson run them in.
+func testCrashDirs() []string {
+	return []string{filepath.Join(config.CacheDir(), "native"), "builddir"}
+}
 
-	return builder.Test(context.Background(), opts)
+// testTimeouts returns the per-test and session time limits of the "test"
+// section of a cpx-ci.yaml, 0 when unset.
+func testTimeouts(path string) (test, session time.Duration, err error) {
+	ciConfig, err := config.LoadToolchains(path)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return ciConfig.Test.Timeouts()
 }
@@ -2,18 +2,172 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/ozacod/cpx/internal/app/cli/tui"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
+// ToolchainCmd creates the toolchain command group
+func ToolchainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "toolchain",
+		Short: "Manage toolchains in cpx-ci.yaml",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List toolchains with their status, and runners, from cpx-ci.yaml",
+		RunE:  runToolchainList,
+		Args:  cobra.NoArgs,
+	})
+	cmd.AddCommand(toolchainAddCmd())
+	cmd.AddCommand(&cobra.Command{
+		Use:               "remove [name...]",
+		Aliases:           []string{"rm"},
+		Short:             "Remove toolchain(s) from cpx-ci.yaml",
+		RunE:              runRemoveToolchainCmd,
+		ValidArgsFunction: completeToolchains,
+	})
+	cmd.AddCommand(toolchainEditCmd())
+	cmd.AddCommand(toolchainEnableCmd(true))
+	cmd.AddCommand(toolchainEnableCmd(false))
+	cmd.AddCommand(toolchainShowCmd())
+	cmd.AddCommand(toolchainDoctorCmd())
+
+	return cmd
+}
+
+// toolchainListing is the --json output of "cpx toolchain list".
+type toolchainListing struct {
+	Toolchains []toolchainEntry `json:"toolchains"`
+	Runners    []runnerEntry    `json:"runners"`
+}
+
+type toolchainEntry struct {
+	Name         string `json:"name"`
+	Type         string `json:"type,omitempty"`
+	Runner       string `json:"runner,omitempty"`
+	Active       bool   `json:"active"`
+	BuildType    string `json:"build_type,omitempty"`
+	Optimization string `json:"optimization,omitempty"`
+	Sanitizer    string `json:"sanitizer,omitempty"`
+	// Problem keeps the toolchain from building, such as a missing runner
+	Problem string `json:"problem,omitempty"`
+}
+
+type runnerEntry struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Image    string `json:"image,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Platform string `json:"platform,omitempty"`
+}
+
+func runToolchainList(cmd *cobra.Command, _ []string) error {
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to load cpx-ci.yaml: %w", err)
+		}
+		ciConfig = &config.ToolchainConfig{}
+	}
+
+	listing := toolchainListing{Toolchains: []toolchainEntry{}, Runners: []runnerEntry{}}
+	for _, t := range ciConfig.Toolchains {
+		problem := ""
+		if status := toolchainStatus(ciConfig, &t); status != "enabled" && status != "disabled" {
+			problem = status
+		}
+		listing.Toolchains = append(listing.Toolchains, toolchainEntry{
+			Name:         t.Name,
+			Type:         t.Type,
+			Runner:       t.Runner,
+			Active:       t.IsActive(),
+			BuildType:    t.BuildType,
+			Optimization: t.Optimization,
+			Sanitizer:    t.Sanitizer,
+			Problem:      problem,
+		})
+	}
+	for _, r := range ciConfig.Runners {
+		runnerType := r.Type
+		if r.IsNative() {
+			runnerType = "native"
+		}
+		listing.Runners = append(listing.Runners, runnerEntry{
+			Name:     r.Name,
+			Type:     runnerType,
+			Image:    r.Image,
+			Host:     r.Host,
+			Platform: r.Platform,
+		})
+	}
+
+	if jsonOutput(cmd) {
+		return printJSON(listing)
+	}
+
+	if len(listing.Toolchains) == 0 && len(listing.Runners) == 0 {
+		fmt.Printf("%sNo toolchains in cpx-ci.yaml%s\n", colors.Yellow, colors.Reset)
+		fmt.Printf("  Add one with: cpx toolchain add\n")
+		return nil
+	}
+
+	if len(listing.Toolchains) > 0 {
+		fmt.Printf("%sToolchains:%s\n", colors.Cyan, colors.Reset)
+		for _, t := range listing.Toolchains {
+			details := []string{t.BuildType}
+			if t.Type != "" {
+				details = append([]string{t.Type}, details...)
+			}
+			if t.Runner != "" {
+				details = append(details, "runner: "+t.Runner)
+			}
+			if t.Sanitizer != "" {
+				details = append(details, "sanitizer: "+t.Sanitizer)
+			}
+			status := ""
+			switch {
+			case t.Problem != "":
+				status = fmt.Sprintf(" %s(%s)%s", colors.Red, t.Problem, colors.Reset)
+			case !t.Active:
+				status = fmt.Sprintf(" %s(disabled)%s", colors.Gray, colors.Reset)
+			}
+			fmt.Printf("  %s%s%s (%s)%s\n", colors.Green, t.Name, colors.Reset, strings.Join(details, ", "), status)
+		}
+	}
+
+	if len(listing.Runners) > 0 {
+		fmt.Printf("%sRunners:%s\n", colors.Cyan, colors.Reset)
+		for _, r := range listing.Runners {
+			target := r.Image
+			if r.Host != "" {
+				target = r.Host
+			}
+			if target != "" {
+				fmt.Printf("  %s%s%s (%s: %s)\n", colors.Green, r.Name, colors.Reset, r.Type, target)
+			} else {
+				fmt.Printf("  %s%s%s (%s)\n", colors.Green, r.Name, colors.Reset, r.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// AddToolchainCmd creates the add-toolchain command, which is kept for
+// scripts written before cpx toolchain add
 func AddToolchainCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "add-toolchain",
-		Short: "Add a build configuration (toolchain) to cpx-ci.yaml",
-		RunE:  runAddToolchainCmd,
+		Use:        "add-toolchain",
+		Short:      "Add a build configuration (toolchain) to cpx-ci.yaml",
+		Deprecated: "use cpx toolchain add",
+		RunE:       runAddToolchainCmd,
 	}
 	return cmd
 }
@@ -27,12 +181,15 @@ func AddRunnerCmd() *cobra.Command {
 	return cmd
 }
 
-// RmToolchainCmd creates the rm-toolchain command
+// RmToolchainCmd creates the rm-toolchain command, which is kept for
+// scripts written before cpx toolchain remove
 func RmToolchainCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "rm-toolchain [name...]",
-		Short: "Remove toolchain(s) from cpx-ci.yaml",
-		RunE:  runRemoveToolchainCmd,
+		Use:               "rm-toolchain [name...]",
+		Short:             "Remove toolchain(s) from cpx-ci.yaml",
+		Deprecated:        "use cpx toolchain remove",
+		RunE:              runRemoveToolchainCmd,
+		ValidArgsFunction: completeToolchains,
 	}
 	return cmd
 }
@@ -40,9 +197,10 @@ func RmToolchainCmd() *cobra.Command {
 // RmRunnerCmd creates the rm-runner command
 func RmRunnerCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "rm-runner [name...]",
-		Short: "Remove runner(s) from cpx-ci.yaml",
-		RunE:  runRemoveRunnerCmd,
+		Use:               "rm-runner [name...]",
+		Short:             "Remove runner(s) from cpx-ci.yaml",
+		RunE:              runRemoveRunnerCmd,
+		ValidArgsFunction: completeRunners,
 	}
 	return cmd
 }
@@ -86,7 +244,7 @@ func runAddToolchainCmd(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
-	fmt.Printf("\n%s✓ Added toolchain: %s%s\n", colors.Green, result.Name, colors.Reset)
+	output.Successf("\n✓ Added toolchain: %s", result.Name)
 	return nil
 }
 
@@ -113,6 +271,8 @@ func runAddRunnerCmd(_ *cobra.Command, _ []string) error {
 		Name:               result.Name,
 		Type:               result.Type,
 		Image:              result.Image,
+		Dockerfile:         result.Dockerfile,
+		BuildContext:       result.BuildContext,
 		Host:               result.Host,
 		User:               result.User,
 		CC:                 result.CC,
@@ -142,7 +302,7 @@ func runRemoveToolchainCmd(_ *cobra.Command, args []string) error {
 	}
 
 	if len(args) == 0 {
-		fmt.Printf("%sUsage: cpx rm-toolchain <name...>%s\n", colors.Yellow, colors.Reset)
+		fmt.Printf("%sUsage: cpx toolchain remove <name...>%s\n", colors.Yellow, colors.Reset)
 		fmt.Printf("\nAvailable toolchains:\n")
 		for _, t := range ciConfig.Toolchains {
 			fmt.Printf("  - %s\n", t.Name)
@@ -166,17 +326,21 @@ func runRemoveToolchainCmd(_ *cobra.Command, args []string) error {
 	}
 
 	if len(removed) == 0 {
-		fmt.Printf("%sNo matching toolchains found%s\n", colors.Yellow, colors.Reset)
+		output.Warnf("No matching toolchains found")
 		return nil
 	}
 
 	ciConfig.Toolchains = newItems
+	if ciConfig.Release != nil {
+		// cpx release must not look for the removed toolchains
+		ciConfig.Release.Toolchains = slices.DeleteFunc(ciConfig.Release.Toolchains, func(name string) bool { return toRemove[name] })
+	}
 	if err := config.SaveToolchains(ciConfig, "cpx-ci.yaml"); err != nil {
 		return err
 	}
 
 	for _, name := range removed {
-		fmt.Printf("%s✗ Removed toolchain: %s%s\n", colors.Red, name, colors.Reset)
+		output.Successf("✓ Removed toolchain: %s", name)
 	}
 	return nil
 }
@@ -0,0 +1,318 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/container"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// toolchainDoctorColumns are the checks of cpx toolchain doctor, in the
+// order of the matrix.
+var toolchainDoctorColumns = []string{"image", "tools", "platform", "ssh"}
+
+func toolchainDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor [name]",
+		Short: "Check that toolchains can build",
+		Long: `Check every toolchain in cpx-ci.yaml, or the named one, and print a matrix
+of the results: the runner's docker image exists locally or can be pulled,
+the build tools of the project are installed in the image, on the SSH host
+or on this machine, foreign platforms can run under QEMU, and SSH runners
+are reachable. Problems come with a fix; the command fails if there are
+any.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runToolchainDoctor,
+	}
+}
+
+func runToolchainDoctor(cmd *cobra.Command, args []string) error {
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no cpx-ci.yaml in the current directory\n  hint: add a toolchain with cpx toolchain add")
+		}
+		return fmt.Errorf("failed to load cpx-ci.yaml: %w", err)
+	}
+	toolchains := ciConfig.Toolchains
+	if len(args) == 1 {
+		tc := ciConfig.FindToolchain(args[0])
+		if tc == nil {
+			return fmt.Errorf("toolchain '%s' not found in cpx-ci.yaml", args[0])
+		}
+		toolchains = []config.Toolchain{*tc}
+	}
+
+	d := &toolchainDoctor{rt: containerRuntime(), projectType: string(DetectProjectType()), runners: map[string][]doctorCheck{}}
+	var checks []doctorCheck
+	for _, tc := range toolchains {
+		checks = append(checks, d.check(ciConfig, tc)...)
+	}
+
+	problems := 0
+	for _, c := range checks {
+		if c.Status == doctorError {
+			problems++
+		}
+	}
+	if jsonOutput(cmd) {
+		if err := printJSON(checks); err != nil {
+			return err
+		}
+	} else {
+		printToolchainMatrix(toolchains, checks)
+	}
+	if problems > 0 {
+		return fmt.Errorf("cpx toolchain doctor found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// toolchainDoctor checks toolchains, once per runner.
+type toolchainDoctor struct {
+	rt          container.Runtime
+	projectType string
+	runners     map[string][]doctorCheck
+}
+
+// check returns the checks of tc, with the toolchain as their section.
+func (d *toolchainDoctor) check(ciConfig *config.ToolchainConfig, tc config.Toolchain) []doctorCheck {
+	var checks []doctorCheck
+	runner := ciConfig.FindRunner(tc.Runner)
+	switch {
+	case tc.Runner != "" && runner == nil:
+		checks = []doctorCheck{{Name: "image", Status: doctorError, Detail: fmt.Sprintf("runner '%s' is not defined", tc.Runner), Fix: "add it with cpx add-runner"}}
+	case runner == nil && tc.Type != "":
+		// wasm, android and mingw toolchains find their tools when building
+	case runner == nil:
+		checks = []doctorCheck{d.checkTools("this machine", func(command string) bool {
+			_, err := execLookPath(command)
+			return err == nil
+		})}
+	default:
+		if _, ok := d.runners[runner.Name]; !ok {
+			d.runners[runner.Name] = d.checkRunner(runner)
+		}
+		checks = slices.Clone(d.runners[runner.Name])
+	}
+	for i := range checks {
+		checks[i].Section = tc.Name
+	}
+	return checks
+}
+
+func (d *toolchainDoctor) checkRunner(runner *config.Runner) []doctorCheck {
+	switch {
+	case runner.IsDocker():
+		image := d.checkImage(runner)
+		checks := []doctorCheck{image}
+		if image.Status == doctorOK && image.Detail == "local" {
+			checks = append(checks, d.checkImageTools(runner))
+		}
+		return append(checks, checkRunnerPlatform(runner)...)
+	case runner.IsSSH():
+		return d.checkSSH(runner)
+	default:
+		return []doctorCheck{d.checkTools("this machine", func(command string) bool {
+			_, err := execLookPath(command)
+			return err == nil
+		})}
+	}
+}
+
+// checkImage checks that the image of a docker runner exists locally or
+// can be pulled. Dockerfile runners are checked once their image is built.
+func (d *toolchainDoctor) checkImage(runner *config.Runner) doctorCheck {
+	c := doctorCheck{Name: "image", Status: doctorError}
+	if _, err := execLookPath(d.rt.Name()); err != nil {
+		c.Detail = d.rt.Name() + " not found"
+		c.Fix = "install Docker or Podman, see cpx doctor"
+		return c
+	}
+	image := dockerImageTag(runner)
+	if d.rt.ImageExists(image) {
+		c.Status, c.Detail = doctorOK, "local"
+		return c
+	}
+	if runner.BuildsImage() {
+		if _, err := os.Stat(runner.Dockerfile); err != nil {
+			c.Detail = runner.Dockerfile + " not found"
+			c.Fix = "fix dockerfile of runner '" + runner.Name + "'"
+			return c
+		}
+		c.Status, c.Detail = doctorWarning, "not built yet"
+		c.Fix = "cpx build --toolchain builds it from " + runner.Dockerfile
+		return c
+	}
+	if d.rt.Name() == container.Nerdctl {
+		c.Status, c.Detail = doctorWarning, "not local, pulled on the first build"
+		return c
+	}
+	if err := registryLogin(d.rt, runner, image); err != nil {
+		c.Detail = err.Error()
+		return c
+	}
+	if out, err := d.rt.Command("manifest", "inspect", image).CombinedOutput(); err != nil {
+		c.Detail = "cannot pull " + image + ": " + firstLine(string(out))
+		c.Fix = "check the image name, or log in with " + d.rt.Name() + " login " + container.Registry(image)
+		return c
+	}
+	c.Status, c.Detail = doctorOK, "pullable"
+	return c
+}
+
+// checkImageTools checks the build tools in the image of a docker runner,
+// asking a single container.
+func (d *toolchainDoctor) checkImageTools(runner *config.Runner) doctorCheck {
+	args := []string{"run", "--rm", "--entrypoint", "sh"}
+	if runner.Platform != "" {
+		args = append(args, "--platform", runner.Platform)
+	}
+	args = append(args, dockerImageTag(runner), "-c", probeScript())
+	out, err := d.rt.Command(args...).CombinedOutput()
+	if err != nil {
+		return doctorCheck{Name: "tools", Status: doctorError, Detail: "cannot run the image: " + firstLine(string(out)),
+			Fix: "the image needs a shell (sh)"}
+	}
+	found := strings.Fields(string(out))
+	return d.checkTools("the image", func(command string) bool { return slices.Contains(found, command) })
+}
+
+// probeScript prints the build tools found on PATH.
+func probeScript() string {
+	return "for c in " + strings.Join(tui.BuildToolCommands, " ") + `; do command -v "$c" >/dev/null 2>&1 && echo "$c"; done; true`
+}
+
+// checkTools checks that the project's build tools are found on where.
+func (d *toolchainDoctor) checkTools(where string, has func(string) bool) doctorCheck {
+	missing := tui.MissingBuildTools(d.projectType, has)
+	if len(missing) > 0 {
+		return doctorCheck{Name: "tools", Status: doctorError, Detail: "missing " + strings.Join(missing, ", "),
+			Fix: "install them on " + where}
+	}
+	return doctorCheck{Name: "tools", Status: doctorOK, Detail: "found on " + where}
+}
+
+// checkRunnerPlatform checks that the container and target platforms of a
+// docker runner run on this host, natively or under QEMU.
+func checkRunnerPlatform(runner *config.Runner) []doctorCheck {
+	var foreign []string
+	for _, arch := range []string{platformArch(runner.Platform), platformArch(runner.TargetPlatform)} {
+		if arch != "" && arch != hostArch && !slices.Contains(foreign, arch) {
+			foreign = append(foreign, arch)
+		}
+	}
+	if len(foreign) == 0 {
+		if runner.Platform == "" && runner.TargetPlatform == "" {
+			return nil
+		}
+		return []doctorCheck{{Name: "platform", Status: doctorOK, Detail: "native " + hostArch}}
+	}
+
+	c := doctorCheck{Name: "platform", Status: doctorOK, Detail: "QEMU for " + strings.Join(foreign, ", ")}
+	// Docker Desktop ships its VM with QEMU handlers registered
+	if runtime.GOOS != "linux" {
+		return []doctorCheck{c}
+	}
+	for _, arch := range foreign {
+		qemuArch, ok := qemuArchs[arch]
+		if !ok {
+			c.Status, c.Detail = doctorError, "cannot emulate "+arch
+			c.Fix = "use a runner for a supported architecture"
+			return []doctorCheck{c}
+		}
+		if _, err := os.Stat(filepath.Join(binfmtDir, "qemu-"+qemuArch)); err != nil {
+			c.Status, c.Detail = doctorWarning, "no QEMU handler for "+arch
+			c.Fix = fmt.Sprintf("cpx registers it on the first build, or run 'docker run --privileged --rm %s --install %s'", binfmtImage, arch)
+		}
+	}
+	return []doctorCheck{c}
+}
+
+// checkSSH connects to the host of an ssh runner and checks its build
+// tools in the same session.
+func (d *toolchainDoctor) checkSSH(runner *config.Runner) []doctorCheck {
+	target := runner.Host
+	if runner.User != "" {
+		target = runner.User + "@" + runner.Host
+	}
+	out, err := execCommand("ssh", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10", target, probeScript()).CombinedOutput()
+	if err != nil {
+		return []doctorCheck{{Name: "ssh", Status: doctorError, Detail: "cannot connect to " + target + ": " + firstLine(string(out)),
+			Fix: "check the host and set up key-based login (ssh-copy-id " + target + ")"}}
+	}
+	found := strings.Fields(string(out))
+	return []doctorCheck{
+		d.checkTools(runner.Host, func(command string) bool { return slices.Contains(found, command) }),
+		{Name: "ssh", Status: doctorOK, Detail: "connected to " + target},
+	}
+}
+
+// printToolchainMatrix prints a row of results per toolchain, followed by
+// the problems and their fixes.
+func printToolchainMatrix(toolchains []config.Toolchain, checks []doctorCheck) {
+	width := len("Toolchain")
+	for _, tc := range toolchains {
+		width = max(width, len(tc.Name))
+	}
+	fmt.Printf("%s%-*s", colors.Cyan, width, "Toolchain")
+	for j, column := range toolchainDoctorColumns {
+		if j == len(toolchainDoctorColumns)-1 {
+			fmt.Printf("  %s", column)
+		} else {
+			fmt.Printf("  %-8s", column)
+		}
+	}
+	fmt.Printf("%s\n", colors.Reset)
+
+	for _, tc := range toolchains {
+		fmt.Printf("%-*s", width, tc.Name)
+		for j, column := range toolchainDoctorColumns {
+			i := slices.IndexFunc(checks, func(c doctorCheck) bool { return c.Section == tc.Name && c.Name == column })
+			symbol, color := "-", colors.Gray
+			if i >= 0 {
+				switch checks[i].Status {
+				case doctorOK:
+					symbol, color = "✓", colors.Green
+				case doctorWarning:
+					symbol, color = "⚠", colors.Yellow
+				case doctorError:
+					symbol, color = "✗", colors.Red
+				}
+			}
+			pad := strings.Repeat(" ", 7)
+			if j == len(toolchainDoctorColumns)-1 {
+				pad = ""
+			}
+			fmt.Printf("  %s%s%s%s", color, symbol, colors.Reset, pad)
+		}
+		fmt.Println()
+	}
+
+	first := true
+	for _, c := range checks {
+		if c.Status == doctorOK {
+			continue
+		}
+		if first {
+			fmt.Println()
+			first = false
+		}
+		symbol, color := "⚠", colors.Yellow
+		if c.Status == doctorError {
+			symbol, color = "✗", colors.Red
+		}
+		fmt.Printf("%s%s%s %s %s: %s\n", color, symbol, colors.Reset, c.Section, c.Name, c.Detail)
+		if c.Fix != "" {
+			fmt.Printf("  %s→ %s%s\n", colors.Gray, c.Fix, colors.Reset)
+		}
+	}
+}
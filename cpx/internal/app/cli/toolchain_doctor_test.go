@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+func TestToolchainDoctor(t *testing.T) {
+	chdirTemp(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app"}`), 0644))
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`runners:
+  - name: gcc
+    type: docker
+    image: gcc:14
+  - name: arm
+    type: docker
+    image: ghcr.io/acme/arm:1
+    platform: linux/riscv64
+  - name: box
+    type: ssh
+    host: build.example.com
+    user: ci
+toolchains:
+  - name: linux
+    runner: gcc
+  - name: linux-debug
+    runner: gcc
+  - name: riscv
+    runner: arm
+  - name: remote
+    runner: box
+  - name: host
+  - name: web
+    type: wasm
+`), 0644))
+
+	oldExecCommand, oldExecLookPath, oldHostArch, oldBinfmtDir := execCommand, execLookPath, hostArch, binfmtDir
+	t.Cleanup(func() {
+		execCommand, execLookPath, hostArch, binfmtDir = oldExecCommand, oldExecLookPath, oldHostArch, oldBinfmtDir
+	})
+	hostArch, binfmtDir = "amd64", t.TempDir()
+	execLookPath = func(file string) (string, error) {
+		if file == "docker" || file == "cmake" || file == "ninja" || file == "gcc" {
+			return "/usr/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+	var calls []string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		call := name + " " + strings.Join(arg, " ")
+		calls = append(calls, call)
+		switch {
+		case call == "docker images -q gcc:14":
+			return exec.Command("echo", "0123456789ab")
+		case strings.HasPrefix(call, "docker run --rm --entrypoint sh gcc:14"):
+			return exec.Command("printf", "cmake\nninja\ngcc\ng++\n")
+		case strings.HasPrefix(call, "ssh "):
+			return exec.Command("sh", "-c", "echo 'ssh: connect to host build.example.com port 22: Connection refused' >&2; exit 255")
+		case strings.HasPrefix(call, "docker manifest inspect"):
+			return exec.Command("true")
+		}
+		return exec.Command("false")
+	}
+
+	cmd := ToolchainCmd()
+	cmd.SetArgs([]string{"doctor"})
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	// the host lacks a C++ compiler and the SSH host is down
+	assert.EqualError(t, cmd.Execute(), "cpx toolchain doctor found 2 problem(s)")
+	assert.Equal(t, 1, strings.Count(strings.Join(calls, "\n"), "docker run"), "runners are checked once")
+
+	d := &toolchainDoctor{rt: containerRuntime(), projectType: "vcpkg", runners: map[string][]doctorCheck{}}
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []doctorCheck{
+		{Section: "linux", Name: "image", Status: doctorOK, Detail: "local"},
+		{Section: "linux", Name: "tools", Status: doctorOK, Detail: "found on the image"},
+	}, d.check(ciConfig, *ciConfig.FindToolchain("linux")))
+	assert.Equal(t, []doctorCheck{
+		{Section: "riscv", Name: "image", Status: doctorOK, Detail: "pullable"},
+		{Section: "riscv", Name: "platform", Status: doctorWarning, Detail: "no QEMU handler for riscv64",
+			Fix: "cpx registers it on the first build, or run 'docker run --privileged --rm tonistiigi/binfmt --install riscv64'"},
+	}, d.check(ciConfig, *ciConfig.FindToolchain("riscv")))
+	remote := d.check(ciConfig, *ciConfig.FindToolchain("remote"))
+	require.Len(t, remote, 1)
+	assert.Equal(t, "cannot connect to ci@build.example.com: ssh: connect to host build.example.com port 22: Connection refused", remote[0].Detail)
+	assert.Equal(t, []doctorCheck{
+		{Section: "host", Name: "tools", Status: doctorError, Detail: "missing C++ compiler", Fix: "install them on this machine"},
+	}, d.check(ciConfig, *ciConfig.FindToolchain("host")))
+	assert.Empty(t, d.check(ciConfig, *ciConfig.FindToolchain("web")))
+
+	// a registered handler makes the platform pass
+	require.NoError(t, os.WriteFile(filepath.Join(binfmtDir, "qemu-riscv64"), nil, 0644))
+	assert.Equal(t, doctorOK, checkRunnerPlatform(ciConfig.FindRunner("arm"))[0].Status)
+
+	cmd = ToolchainCmd()
+	cmd.SetArgs([]string{"doctor", "missing"})
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	assert.EqualError(t, cmd.Execute(), "toolchain 'missing' not found in cpx-ci.yaml")
+}
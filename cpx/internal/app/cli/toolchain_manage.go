@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func toolchainAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add [name]",
+		Short: "Add a toolchain to cpx-ci.yaml",
+		Long: `Add a toolchain to cpx-ci.yaml. Without a name, an interactive wizard asks
+for it; with one, the toolchain is added from the flags, e.g.
+
+  cpx toolchain add linux-asan --runner ubuntu --build-type Debug --sanitizer asan`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runToolchainAdd,
+	}
+	addToolchainFieldFlags(cmd)
+	cmd.Flags().Bool("disabled", false, "Add the toolchain disabled")
+	return cmd
+}
+
+func toolchainEditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Change a toolchain in cpx-ci.yaml",
+		Long: `Change the fields of a toolchain given as flags and leave the others as they
+are. An empty value clears a field, e.g. --runner "" builds on this machine.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              runToolchainEdit,
+		ValidArgsFunction: completeToolchains,
+	}
+	addToolchainFieldFlags(cmd)
+	cmd.Flags().String("name", "", "Rename the toolchain")
+	return cmd
+}
+
+func toolchainEnableCmd(active bool) *cobra.Command {
+	use, short := "enable", "Enable toolchain(s), so builds include them"
+	if !active {
+		use, short = "disable", "Disable toolchain(s), so builds skip them"
+	}
+	return &cobra.Command{
+		Use:               use + " <name...>",
+		Short:             short,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeToolchains,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return setToolchainsActive(args, active)
+		},
+	}
+}
+
+func toolchainShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "show <name>",
+		Short:             "Show a toolchain and its runner",
+		Args:              cobra.ExactArgs(1),
+		RunE:              runToolchainShow,
+		ValidArgsFunction: completeToolchains,
+	}
+}
+
+// addToolchainFieldFlags adds the flags that set fields of a toolchain.
+func addToolchainFieldFlags(cmd *cobra.Command) {
+	cmd.Flags().String("runner", "", "Runner to build on (default: this machine)")
+	cmd.Flags().String("type", "", "Built-in toolchain type: wasm, android or mingw")
+	cmd.Flags().String("build-type", "", "Debug, Release, RelWithDebInfo or MinSizeRel (default: Release)")
+	cmd.Flags().String("optimization", "", "Optimization level: 0, 1, 2, 3, s or fast")
+	cmd.Flags().String("sanitizer", "", "Sanitizer(s), e.g. asan or asan,ubsan")
+	cmd.Flags().Int("jobs", 0, "Number of parallel jobs")
+	cmd.Flags().StringArray("cmake-option", nil, "CMake option (repeatable; replaces the list, '' clears it)")
+	cmd.Flags().StringArray("build-option", nil, "Build option (repeatable; replaces the list, '' clears it)")
+	cmd.Flags().StringArray("env", nil, "Environment variable as KEY=VALUE (repeatable)")
+	cmd.Flags().StringArray("unset-env", nil, "Environment variable to remove (repeatable)")
+	_ = cmd.RegisterFlagCompletionFunc("runner", completeRunners)
+}
+
+// applyToolchainFlags sets the fields of tc given as flags and reports
+// whether there were any.
+func applyToolchainFlags(cmd *cobra.Command, tc *config.Toolchain) (bool, error) {
+	flags := cmd.Flags()
+	changed := false
+	for flag, field := range map[string]*string{
+		"runner":       &tc.Runner,
+		"type":         &tc.Type,
+		"build-type":   &tc.BuildType,
+		"optimization": &tc.Optimization,
+		"sanitizer":    &tc.Sanitizer,
+	} {
+		if flags.Changed(flag) {
+			*field, _ = flags.GetString(flag)
+			changed = true
+		}
+	}
+	if flags.Changed("jobs") {
+		tc.Jobs, _ = flags.GetInt("jobs")
+		changed = true
+	}
+	for flag, field := range map[string]*[]string{"cmake-option": &tc.CMakeOptions, "build-option": &tc.BuildOptions} {
+		if flags.Changed(flag) {
+			values, _ := flags.GetStringArray(flag)
+			*field = slices.DeleteFunc(values, func(v string) bool { return v == "" })
+			changed = true
+		}
+	}
+	if flags.Changed("env") {
+		env, _ := flags.GetStringArray("env")
+		for _, kv := range env {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok || key == "" {
+				return false, fmt.Errorf("invalid --env %q (use KEY=VALUE)", kv)
+			}
+			if tc.Env == nil {
+				tc.Env = map[string]string{}
+			}
+			tc.Env[key] = value
+		}
+		changed = true
+	}
+	if flags.Changed("unset-env") {
+		keys, _ := flags.GetStringArray("unset-env")
+		for _, key := range keys {
+			delete(tc.Env, key)
+		}
+		if len(tc.Env) == 0 {
+			tc.Env = nil
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// checkToolchainEntry validates tc as it would be written to cpx-ci.yaml.
+// Runners only matter by name, so problems of the runner itself don't stop
+// editing its toolchains.
+func checkToolchainEntry(ciConfig *config.ToolchainConfig, tc config.Toolchain) error {
+	doc := config.ToolchainConfig{Toolchains: []config.Toolchain{tc}}
+	if runner := ciConfig.FindRunner(tc.Runner); runner != nil {
+		doc.Runners = []config.Runner{{Name: runner.Name}}
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var messages []string
+	for _, e := range config.ValidateToolchains(data, ".") {
+		if !e.Warning {
+			messages = append(messages, e.Message)
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	msg := strings.Join(messages, "; ")
+	if tc.Runner != "" && doc.Runners == nil {
+		msg += "\n  hint: add it with cpx add-runner"
+	}
+	return errors.New(msg)
+}
+
+// loadToolchainConfig loads cpx-ci.yaml for commands that change or show
+// existing toolchains.
+func loadToolchainConfig() (*config.ToolchainConfig, error) {
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no cpx-ci.yaml in the current directory\n  hint: add a toolchain with cpx toolchain add")
+		}
+		return nil, fmt.Errorf("failed to load cpx-ci.yaml: %w", err)
+	}
+	return ciConfig, nil
+}
+
+func runToolchainAdd(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return runAddToolchainCmd(cmd, args)
+	}
+	ciConfig, err := loadOrCreateConfig()
+	if err != nil {
+		return err
+	}
+	name := args[0]
+	if ciConfig.FindToolchain(name) != nil {
+		return fmt.Errorf("toolchain '%s' already exists in cpx-ci.yaml\n  hint: change it with cpx toolchain edit %s", name, name)
+	}
+
+	tc := config.Toolchain{Name: name, BuildType: "Release"}
+	if _, err := applyToolchainFlags(cmd, &tc); err != nil {
+		return err
+	}
+	if disabled, _ := cmd.Flags().GetBool("disabled"); disabled {
+		tc.Active = new(bool)
+	}
+	if err := checkToolchainEntry(ciConfig, tc); err != nil {
+		return err
+	}
+
+	ciConfig.Toolchains = append(ciConfig.Toolchains, tc)
+	if err := config.SaveToolchains(ciConfig, "cpx-ci.yaml"); err != nil {
+		return err
+	}
+	output.Successf("✓ Added toolchain: %s", name)
+	return nil
+}
+
+func runToolchainEdit(cmd *cobra.Command, args []string) error {
+	ciConfig, err := loadToolchainConfig()
+	if err != nil {
+		return err
+	}
+	tc := ciConfig.FindToolchain(args[0])
+	if tc == nil {
+		return fmt.Errorf("toolchain '%s' not found in cpx-ci.yaml", args[0])
+	}
+
+	edited := *tc
+	changed, err := applyToolchainFlags(cmd, &edited)
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("name") {
+		edited.Name, _ = cmd.Flags().GetString("name")
+		if edited.Name != tc.Name && ciConfig.FindToolchain(edited.Name) != nil {
+			return fmt.Errorf("toolchain '%s' already exists in cpx-ci.yaml", edited.Name)
+		}
+		changed = true
+	}
+	if !changed {
+		return fmt.Errorf("nothing to change\n  hint: pass the fields to change, e.g. cpx toolchain edit %s --build-type Debug", tc.Name)
+	}
+	if err := checkToolchainEntry(ciConfig, edited); err != nil {
+		return err
+	}
+
+	if edited.Name != tc.Name && ciConfig.Release != nil {
+		// keep the toolchains released by cpx release
+		for i, name := range ciConfig.Release.Toolchains {
+			if name == tc.Name {
+				ciConfig.Release.Toolchains[i] = edited.Name
+			}
+		}
+	}
+	*tc = edited
+	if err := config.SaveToolchains(ciConfig, "cpx-ci.yaml"); err != nil {
+		return err
+	}
+	output.Successf("✓ Updated toolchain: %s", edited.Name)
+	return nil
+}
+
+// setToolchainsActive enables or disables the named toolchains. Enabled
+// toolchains drop the active field, as that is the default.
+func setToolchainsActive(names []string, active bool) error {
+	ciConfig, err := loadToolchainConfig()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		tc := ciConfig.FindToolchain(name)
+		if tc == nil {
+			return fmt.Errorf("toolchain '%s' not found in cpx-ci.yaml", name)
+		}
+		tc.Active = nil
+		if !active {
+			tc.Active = new(bool)
+		}
+	}
+	if err := config.SaveToolchains(ciConfig, "cpx-ci.yaml"); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if active {
+			output.Successf("✓ Enabled toolchain: %s", name)
+		} else {
+			output.Successf("✓ Disabled toolchain: %s", name)
+		}
+	}
+	return nil
+}
+
+// toolchainDetails is the --json output of "cpx toolchain show", with the
+// fields as they are named in cpx-ci.yaml.
+type toolchainDetails struct {
+	Toolchain map[string]any `json:"toolchain"`
+	Runner    map[string]any `json:"runner,omitempty"`
+	Status    string         `json:"status"`
+}
+
+func runToolchainShow(cmd *cobra.Command, args []string) error {
+	ciConfig, err := loadToolchainConfig()
+	if err != nil {
+		return err
+	}
+	tc := ciConfig.FindToolchain(args[0])
+	if tc == nil {
+		return fmt.Errorf("toolchain '%s' not found in cpx-ci.yaml", args[0])
+	}
+	runner := ciConfig.FindRunner(tc.Runner)
+	status := toolchainStatus(ciConfig, tc)
+
+	if jsonOutput(cmd) {
+		details := toolchainDetails{Status: status}
+		if err := yamlToMap(tc, &details.Toolchain); err != nil {
+			return err
+		}
+		if runner != nil {
+			if err := yamlToMap(runner, &details.Runner); err != nil {
+				return err
+			}
+		}
+		return printJSON(details)
+	}
+
+	data, err := yaml.Marshal(tc)
+	if err != nil {
+		return err
+	}
+	w := output.Stdout()
+	fmt.Fprintf(w, "%sToolchain %s%s (%s)\n", colors.Cyan, tc.Name, colors.Reset, status)
+	fmt.Fprint(w, indentLines(string(data), "  "))
+	switch {
+	case runner != nil:
+		data, err := yaml.Marshal(runner)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%sRunner %s%s\n", colors.Cyan, runner.Name, colors.Reset)
+		fmt.Fprint(w, indentLines(string(data), "  "))
+	case tc.Runner == "":
+		fmt.Fprintf(w, "%sRunner%s\n  this machine\n", colors.Cyan, colors.Reset)
+	}
+	return nil
+}
+
+// toolchainStatus describes whether tc is built: enabled, disabled, or
+// the problem keeping it from building.
+func toolchainStatus(ciConfig *config.ToolchainConfig, tc *config.Toolchain) string {
+	switch {
+	case tc.Runner != "" && ciConfig.FindRunner(tc.Runner) == nil:
+		return fmt.Sprintf("runner '%s' is not defined", tc.Runner)
+	case !tc.IsActive():
+		return "disabled"
+	}
+	return "enabled"
+}
+
+// yamlToMap converts v to a map keyed by its yaml field names.
+func yamlToMap(v any, out *map[string]any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+// indentLines prefixes every line of s with indent.
+func indentLines(s, indent string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// runToolchainListCapture runs "toolchain list" with the given root flags and
+// returns its stdout.
+func runToolchainListCapture(t *testing.T, args ...string) string {
+	root := &cobra.Command{Use: "cpx"}
+	root.PersistentFlags().Bool("json", false, "")
+	root.AddCommand(ToolchainCmd())
+	root.SetArgs(append([]string{"toolchain", "list"}, args...))
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := root.Execute()
+	w.Close()
+	os.Stdout = oldStdout
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	return buf.String()
+}
+
+func TestToolchainListJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	ciYAML := `runners:
+  - name: ubuntu
+    type: docker
+    image: ubuntu:24.04
+toolchains:
+  - name: linux-gcc
+    runner: ubuntu
+    build_type: Debug
+    sanitizer: asan
+  - name: web
+    type: wasm
+    active: false
+`
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(ciYAML), 0644))
+
+	var listing toolchainListing
+	require.NoError(t, json.Unmarshal([]byte(runToolchainListCapture(t, "--json")), &listing))
+
+	require.Len(t, listing.Toolchains, 2)
+	assert.Equal(t, toolchainEntry{Name: "linux-gcc", Runner: "ubuntu", Active: true, BuildType: "Debug", Sanitizer: "asan"}, listing.Toolchains[0])
+	assert.Equal(t, "wasm", listing.Toolchains[1].Type)
+	assert.False(t, listing.Toolchains[1].Active)
+	require.Len(t, listing.Runners, 1)
+	assert.Equal(t, runnerEntry{Name: "ubuntu", Type: "docker", Image: "ubuntu:24.04"}, listing.Runners[0])
+
+	text := runToolchainListCapture(t)
+	assert.Contains(t, text, "linux-gcc")
+	assert.Contains(t, text, "(disabled)")
+}
+
+func TestToolchainListJSONWithoutConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	out := runToolchainListCapture(t, "--json")
+	assert.JSONEq(t, `{"toolchains": [], "runners": []}`, out)
+}
+
+// runToolchain runs "cpx toolchain" with args.
+func runToolchain(args ...string) error {
+	root := &cobra.Command{Use: "cpx", SilenceUsage: true, SilenceErrors: true}
+	root.PersistentFlags().Bool("json", false, "")
+	root.AddCommand(ToolchainCmd())
+	root.SetArgs(append([]string{"toolchain"}, args...))
+	return root.Execute()
+}
+
+func TestToolchainManage(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`runners:
+  - name: ubuntu
+    type: docker
+    image: ubuntu:24.04
+toolchains:
+  - name: linux
+    runner: ubuntu
+release:
+  toolchains: [linux]
+`), 0644))
+	load := func() *config.ToolchainConfig {
+		ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+		require.NoError(t, err)
+		return ciConfig
+	}
+
+	require.NoError(t, runToolchain("add", "asan", "--runner", "ubuntu", "--build-type", "Debug", "--sanitizer", "asan", "--env", "ASAN_OPTIONS=detect_leaks=1", "--disabled"))
+	assert.Equal(t, config.Toolchain{Name: "asan", Runner: "ubuntu", Active: new(bool), BuildType: "Debug", Sanitizer: "asan",
+		Env: map[string]string{"ASAN_OPTIONS": "detect_leaks=1"}}, *load().FindToolchain("asan"))
+	assert.ErrorContains(t, runToolchain("add", "asan"), "toolchain 'asan' already exists in cpx-ci.yaml")
+	assert.EqualError(t, runToolchain("add", "bad", "--sanitizer", "lsan"), `unknown sanitizer "lsan" (use asan, ubsan, tsan, msan, or a list like asan,ubsan)`)
+	assert.ErrorContains(t, runToolchain("add", "arm", "--runner", "pi"), `toolchain "arm" uses runner "pi", which is not defined in runners`)
+	assert.Nil(t, load().FindToolchain("bad"))
+
+	// edit changes only the given fields; renaming keeps the release list
+	require.NoError(t, runToolchain("edit", "linux", "--name", "linux-gcc", "--optimization", "2", "--cmake-option", "-DFOO=ON"))
+	ciConfig := load()
+	assert.Equal(t, config.Toolchain{Name: "linux-gcc", Runner: "ubuntu", BuildType: "Release", Optimization: "2", CMakeOptions: []string{"-DFOO=ON"}},
+		*ciConfig.FindToolchain("linux-gcc"))
+	assert.Equal(t, []string{"linux-gcc"}, ciConfig.Release.Toolchains)
+	require.NoError(t, runToolchain("edit", "asan", "--runner", "", "--unset-env", "ASAN_OPTIONS"))
+	assert.Equal(t, config.Toolchain{Name: "asan", Active: new(bool), BuildType: "Debug", Sanitizer: "asan"}, *load().FindToolchain("asan"))
+	assert.ErrorContains(t, runToolchain("edit", "asan"), "nothing to change")
+	assert.EqualError(t, runToolchain("edit", "asan", "--name", "linux-gcc"), "toolchain 'linux-gcc' already exists in cpx-ci.yaml")
+	assert.EqualError(t, runToolchain("edit", "missing", "--jobs", "4"), "toolchain 'missing' not found in cpx-ci.yaml")
+
+	require.NoError(t, runToolchain("enable", "asan"))
+	assert.Nil(t, load().FindToolchain("asan").Active, "enabled is the default")
+	require.NoError(t, runToolchain("disable", "asan", "linux-gcc"))
+	assert.False(t, load().FindToolchain("linux-gcc").IsActive())
+	assert.EqualError(t, runToolchain("disable", "missing"), "toolchain 'missing' not found in cpx-ci.yaml")
+
+	require.NoError(t, runToolchain("rm", "linux-gcc"))
+	ciConfig = load()
+	assert.Nil(t, ciConfig.FindToolchain("linux-gcc"))
+	assert.Empty(t, ciConfig.Release.Toolchains)
+}
+
+func TestToolchainShowJSON(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte(`runners:
+  - name: ubuntu
+    type: docker
+    image: ubuntu:24.04
+toolchains:
+  - name: linux
+    runner: ubuntu
+  - name: arm
+    runner: pi
+`), 0644))
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := runToolchain("show", "linux", "--json")
+	w.Close()
+	os.Stdout = oldStdout
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	assert.JSONEq(t, `{
+		"toolchain": {"name": "linux", "runner": "ubuntu", "build_type": "Release"},
+		"runner": {"name": "ubuntu", "type": "docker", "image": "ubuntu:24.04"},
+		"status": "enabled"
+	}`, buf.String())
+
+	ciConfig, err := config.LoadToolchains("cpx-ci.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "runner 'pi' is not defined", toolchainStatus(ciConfig, ciConfig.FindToolchain("arm")))
+}
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,6 +11,8 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ozacod/cpx/internal/pkg/container"
+	"github.com/ozacod/cpx/internal/pkg/project"
 )
 
 // ToolchainStep represents the current step in the target creation flow
@@ -30,22 +33,7 @@ const (
 )
 
 // DockerImage represents a Docker image with its metadata
-type DockerImage struct {
-	Repository   string
-	Tag          string
-	ID           string
-	Size         string
-	Created      string
-	Architecture string
-}
-
-// FullName returns the full image name (repo:tag)
-func (d DockerImage) FullName() string {
-	if d.Tag == "" || d.Tag == "<none>" {
-		return d.Repository
-	}
-	return d.Repository + ":" + d.Tag
-}
+type DockerImage = container.Image
 
 // ImageCheckResult is the result of async image checking
 type ImageCheckResult struct {
@@ -286,90 +274,12 @@ func (m ToolchainModel) isTextInputStep() bool {
 		m.step == ToolchainStepDockerImageSelect
 }
 
-// checkDockerImageExists checks if a Docker image exists locally
-func checkDockerImageExists(image string) bool {
-	cmd := exec.Command("docker", "images", "-q", image)
-	output, err := cmd.Output()
-	if err != nil {
-		return false
-	}
-	return len(strings.TrimSpace(string(output))) > 0
-}
-
 // listDockerImages returns a list of available local Docker images
 func listDockerImages() []DockerImage {
-	cmd := exec.Command("docker", "images", "--format", "{{.Repository}}\t{{.Tag}}\t{{.ID}}\t{{.Size}}\t{{.CreatedSince}}")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-
-	var images []DockerImage
-	var imageIDs []string
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, "\t")
-		if len(parts) >= 5 {
-			// Skip <none> repositories
-			if parts[0] == "<none>" {
-				continue
-			}
-			images = append(images, DockerImage{
-				Repository: parts[0],
-				Tag:        parts[1],
-				ID:         parts[2],
-				Size:       parts[3],
-				Created:    parts[4],
-			})
-			imageIDs = append(imageIDs, parts[2])
-		}
-	}
-
-	// Fetch architectures for all images in one call
-	if len(imageIDs) > 0 {
-		archMap := getImageArchitectures(imageIDs)
-		for i := range images {
-			if arch, ok := archMap[images[i].ID]; ok {
-				images[i].Architecture = arch
-			}
-		}
-	}
-
+	images, _ := container.Default().Images()
 	return images
 }
 
-// getImageArchitectures fetches architecture info for multiple images
-func getImageArchitectures(imageIDs []string) map[string]string {
-	archMap := make(map[string]string)
-
-	// Use docker inspect to get architecture for all images at once
-	args := append([]string{"inspect", "--format", "{{.Id}}\t{{.Architecture}}"}, imageIDs...)
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.Output()
-	if err != nil {
-		return archMap
-	}
-
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		parts := strings.Split(line, "\t")
-		if len(parts) >= 2 {
-			// Extract short ID from full ID (sha256:xxxx...)
-			fullID := parts[0]
-			shortID := fullID
-			if strings.HasPrefix(fullID, "sha256:") {
-				shortID = fullID[7:19] // Get first 12 chars after sha256:
-			}
-			archMap[shortID] = parts[1]
-		}
-	}
-
-	return archMap
-}
-
 // filterImages filters images based on a search string
 func filterImages(images []DockerImage, filter string) []DockerImage {
 	if filter == "" {
@@ -400,15 +310,12 @@ func checkCommandExists(command string) bool {
 
 // detectProjectType returns "vcpkg", "Bazel", "meson", or "CMake"
 func detectProjectType() string {
-	if checkFileExists("vcpkg.json") {
-		return "vcpkg"
+	if bs := project.Detect("."); bs != project.Unknown {
+		return string(bs)
 	}
-	if checkFileExists("BUILD.bazel") || checkFileExists("WORKSPACE") || checkFileExists("MODULE.bazel") {
+	if checkFileExists("BUILD.bazel") || checkFileExists("WORKSPACE") {
 		return "bazel"
 	}
-	if checkFileExists("meson.build") {
-		return "meson"
-	}
 	if checkFileExists("CMakeLists.txt") {
 		return "cmake"
 	}
@@ -466,7 +373,7 @@ func checkBuildToolsForProject(projectType string) []string {
 
 // checkDockerImageHasCommand checks if a command exists inside a Docker image (with timeout)
 func checkDockerImageHasCommand(image, command string) bool {
-	cmd := exec.Command("docker", "run", "--rm", "--entrypoint", "which", image, command)
+	cmd := container.Default().Command("run", "--rm", "--entrypoint", "which", image, command)
 	done := make(chan error, 1)
 	go func() {
 		done <- cmd.Run()
@@ -483,22 +390,30 @@ func checkDockerImageHasCommand(image, command string) bool {
 
 // checkBuildToolsInDockerImage checks if build tools are available inside a Docker image
 func checkBuildToolsInDockerImage(image string, projectType string) []string {
+	return MissingBuildTools(projectType, func(command string) bool {
+		return checkDockerImageHasCommand(image, command)
+	})
+}
+
+// MissingBuildTools returns the build tools a project of projectType needs
+// that has does not find.
+func MissingBuildTools(projectType string, has func(command string) bool) []string {
 	var missing []string
 
 	switch projectType {
 	case "vcpkg", "cmake":
-		if !checkDockerImageHasCommand(image, "cmake") {
+		if !has("cmake") {
 			missing = append(missing, "cmake")
 		}
-		hasMake := checkDockerImageHasCommand(image, "make")
-		hasNinja := checkDockerImageHasCommand(image, "ninja")
+		hasMake := has("make")
+		hasNinja := has("ninja")
 		if !hasMake && !hasNinja {
 			missing = append(missing, "make or ninja")
 		}
-		hasGCC := checkDockerImageHasCommand(image, "gcc")
-		hasClang := checkDockerImageHasCommand(image, "clang")
-		hasGPP := checkDockerImageHasCommand(image, "g++")
-		hasClangPP := checkDockerImageHasCommand(image, "clang++")
+		hasGCC := has("gcc")
+		hasClang := has("clang")
+		hasGPP := has("g++")
+		hasClangPP := has("clang++")
 		if !hasGCC && !hasClang {
 			missing = append(missing, "C compiler")
 		}
@@ -506,22 +421,22 @@ func checkBuildToolsInDockerImage(image string, projectType string) []string {
 			missing = append(missing, "C++ compiler")
 		}
 	case "bazel":
-		hasBazel := checkDockerImageHasCommand(image, "bazel")
-		hasBazelisk := checkDockerImageHasCommand(image, "bazelisk")
+		hasBazel := has("bazel")
+		hasBazelisk := has("bazelisk")
 		if !hasBazel && !hasBazelisk {
 			missing = append(missing, "bazel or bazelisk")
 		}
 	case "meson":
-		if !checkDockerImageHasCommand(image, "meson") {
+		if !has("meson") {
 			missing = append(missing, "meson")
 		}
-		if !checkDockerImageHasCommand(image, "ninja") {
+		if !has("ninja") {
 			missing = append(missing, "ninja")
 		}
-		hasGCC := checkDockerImageHasCommand(image, "gcc")
-		hasClang := checkDockerImageHasCommand(image, "clang")
-		hasGPP := checkDockerImageHasCommand(image, "g++")
-		hasClangPP := checkDockerImageHasCommand(image, "clang++")
+		hasGCC := has("gcc")
+		hasClang := has("clang")
+		hasGPP := has("g++")
+		hasClangPP := has("clang++")
 		if !hasGCC && !hasClang {
 			missing = append(missing, "C compiler")
 		}
@@ -533,6 +448,9 @@ func checkBuildToolsInDockerImage(image string, projectType string) []string {
 	return missing
 }
 
+// BuildToolCommands are the commands MissingBuildTools asks about.
+var BuildToolCommands = []string{"cmake", "make", "ninja", "gcc", "clang", "g++", "clang++", "bazel", "bazelisk", "meson"}
+
 // ImageCheckPhase represents different phases of checking
 type ImageCheckPhase int
 
@@ -541,14 +459,17 @@ const (
 	CheckPhaseChecking
 )
 
-// checkImageCmd verifies the Docker image exists locally
+// checkImageCmd makes sure the Docker image exists locally, pulling it if
+// needed
 func checkImageCmd(image, platform string) tea.Cmd {
 	return func() tea.Msg {
-		// Just check if image exists locally
-		if !checkDockerImageExists(image) {
-			return ImageCheckResult{
-				Success: false,
-				Error:   fmt.Sprintf("Docker image not found locally: %s. Use 'docker pull %s' to download it first.", image, image),
+		if runtime := container.Default(); !runtime.ImageExists(image) {
+			var out bytes.Buffer
+			if err := runtime.Pull(image, platform, &out); err != nil {
+				return ImageCheckResult{
+					Success: false,
+					Error:   fmt.Sprintf("Could not pull %s: %s. Log in with '%s login' for a private registry.", image, lastLine(out.String()), runtime.Name()),
+				}
 			}
 		}
 		// Image exists, now check tools
@@ -556,6 +477,13 @@ func checkImageCmd(image, platform string) tea.Cmd {
 	}
 }
 
+// lastLine returns the last non-empty line of s, where CLIs print their
+// error.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
 // checkImageAsync runs the image validation asynchronously
 func checkImageAsync(image, platform string) tea.Cmd {
 	return checkImageCmd(image, platform)
@@ -589,7 +517,7 @@ func (m ToolchainModel) handleEnter() (tea.Model, tea.Cmd) {
 			m.errorMsg = "Target name cannot be empty"
 			return m, nil
 		}
-		if !isValidProjectName(name) {
+		if !IsValidProjectName(name) {
 			m.errorMsg = "Target name can only contain letters, numbers, hyphens, and underscores"
 			return m, nil
 		}
@@ -630,8 +558,8 @@ func (m ToolchainModel) handleEnter() (tea.Model, tea.Cmd) {
 		})
 
 		if m.runner == "docker" {
-			if !checkCommandExists("docker") {
-				m.errorMsg = "Docker is not installed or not in PATH"
+			if runtime := container.Default().Name(); !checkCommandExists(runtime) {
+				m.errorMsg = runtime + " is not installed or not in PATH"
 				return m, nil
 			}
 			// Go directly to image selection (no mode choice needed)
@@ -874,7 +802,7 @@ func (m ToolchainModel) View() string {
 	var s strings.Builder
 
 	// Header
-	s.WriteString(dimStyle.Render("cpx add-toolchain") + "\n\n")
+	s.WriteString(dimStyle.Render("cpx toolchain add") + "\n\n")
 
 	// Title
 	s.WriteString(cyanBold.Render("Add Toolchain") + "\n\n")
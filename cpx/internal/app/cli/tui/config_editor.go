@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ConfigField is one key of the config editor
+type ConfigField struct {
+	Key         string
+	Description string
+	Value       string
+
+	// Options are the values tab cycles through while editing
+	Options []string
+}
+
+// ConfigEditorModel lists config keys and edits their values one at a time
+type ConfigEditorModel struct {
+	fields   []ConfigField
+	validate func(key, value string) error
+	notice   string
+
+	cursor    int
+	editing   bool
+	option    int
+	input     textinput.Model
+	err       string
+	modified  bool
+	saved     bool
+	cancelled bool
+}
+
+// NewConfigEditorModel creates an editor of fields. validate checks a value
+// before it is accepted; notice, if any, is shown above the keys.
+func NewConfigEditorModel(fields []ConfigField, validate func(key, value string) error, notice string) ConfigEditorModel {
+	ti := textinput.New()
+	ti.CharLimit = 256
+	ti.Width = 50
+	ti.PromptStyle = inputPromptStyle
+	ti.TextStyle = inputTextStyle
+	ti.Cursor.Style = cursorStyle
+	return ConfigEditorModel{fields: fields, validate: validate, notice: notice, input: ti}
+}
+
+// Init does nothing
+func (m ConfigEditorModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles keys
+func (m ConfigEditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		if m.editing {
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+	if key.String() == "ctrl+c" {
+		m.cancelled = true
+		return m, tea.Quit
+	}
+	if m.editing {
+		return m.updateEditing(key)
+	}
+
+	switch key.String() {
+	case "esc", "q":
+		m.cancelled = true
+		return m, tea.Quit
+	case "s":
+		m.saved = true
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j", "tab":
+		m.cursor = min(m.cursor+1, len(m.fields)-1)
+	case "enter", "e":
+		m.editing, m.err, m.option = true, "", -1
+		m.input.SetValue(m.fields[m.cursor].Value)
+		m.input.CursorEnd()
+		m.input.Focus()
+		return m, textinput.Blink
+	case "d", "backspace", "delete":
+		if m.fields[m.cursor].Value != "" {
+			m.fields[m.cursor].Value = ""
+			m.modified = true
+		}
+	}
+	return m, nil
+}
+
+// updateEditing handles keys while a value is edited: enter accepts it if
+// valid, esc drops the edit and tab cycles through the key's options.
+func (m ConfigEditorModel) updateEditing(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	field := &m.fields[m.cursor]
+	switch key.String() {
+	case "esc":
+		m.editing, m.err = false, ""
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		if m.validate != nil {
+			if err := m.validate(field.Key, value); err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+		}
+		if value != field.Value {
+			field.Value = value
+			m.modified = true
+		}
+		m.editing, m.err = false, ""
+		m.input.Blur()
+		return m, nil
+	case "tab":
+		if len(field.Options) > 0 {
+			m.option = (m.option + 1) % len(field.Options)
+			m.input.SetValue(field.Options[m.option])
+			m.input.CursorEnd()
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(key)
+	return m, cmd
+}
+
+// View renders the keys, or the value being edited
+func (m ConfigEditorModel) View() string {
+	if m.saved || m.cancelled {
+		return ""
+	}
+	var b strings.Builder
+	title := "Edit cpx configuration"
+	if m.modified {
+		title += " (modified)"
+	}
+	b.WriteString(questionMark.Render("?") + " " + questionStyle.Render(title) + "\n")
+	if m.notice != "" {
+		b.WriteString(errorStyle.Render("  "+m.notice) + "\n")
+	}
+	b.WriteString("\n")
+
+	width := 0
+	for _, f := range m.fields {
+		width = max(width, len(f.Key))
+	}
+	for i, f := range m.fields {
+		value := f.Value
+		if value == "" {
+			value = dimStyle.Render("(not set)")
+		}
+		line := fmt.Sprintf("%-*s  %s", width, f.Key, value)
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("❯ ") + cyanBold.Render(fmt.Sprintf("%-*s", width, f.Key)) + "  " + value + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	field := m.fields[m.cursor]
+	b.WriteString("\n" + dimStyle.Render("  "+field.Description) + "\n")
+	if m.editing {
+		b.WriteString("\n" + cyanBold.Render(field.Key) + " " + m.input.View() + "\n")
+		if m.err != "" {
+			b.WriteString(errorStyle.Render("  "+m.err) + "\n")
+		}
+		hint := "  enter accept • esc cancel • empty unsets"
+		if len(field.Options) > 0 {
+			hint += " • tab " + strings.Join(field.Options, "/")
+		}
+		b.WriteString(dimStyle.Render(hint))
+		return b.String()
+	}
+	b.WriteString("\n" + dimStyle.Render("  ↑/↓ move • enter edit • d unset • s save • q quit without saving"))
+	return b.String()
+}
+
+// Fields returns the fields with their edited values.
+func (m ConfigEditorModel) Fields() []ConfigField {
+	return m.fields
+}
+
+// RunConfigEditor lets the user edit fields and returns them with the
+// edited values. saved is false when the user quit without saving.
+func RunConfigEditor(fields []ConfigField, validate func(key, value string) error, notice string) (edited []ConfigField, saved bool, err error) {
+	p := tea.NewProgram(NewConfigEditorModel(fields, validate, notice))
+	final, err := p.Run()
+	if err != nil {
+		return nil, false, err
+	}
+	m := final.(ConfigEditorModel)
+	return m.Fields(), m.saved, nil
+}
@@ -0,0 +1,471 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DashboardProject describes the project shown in the dashboard header
+type DashboardProject struct {
+	Name        string
+	Version     string
+	BuildSystem string
+	Root        string
+}
+
+// DashboardDependency is a project dependency and its latest version
+type DashboardDependency struct {
+	Name    string
+	Version string
+	Latest  string
+}
+
+// DashboardToolchain is a cpx-ci.yaml toolchain
+type DashboardToolchain struct {
+	Name   string
+	Runner string
+}
+
+// DashboardRun is a past build, test or lint run, from its log
+type DashboardRun struct {
+	Command   string
+	Toolchain string
+	Time      time.Time
+	Status    string // "ok", "failed: ..." or "" while running
+}
+
+// DashboardFuncs load the dashboard data and start commands. Each may be
+// slow; they run outside the UI loop.
+type DashboardFuncs struct {
+	Project       func() DashboardProject
+	Dependencies  func() ([]DashboardDependency, error)
+	LatestVersion func(name string) (string, error)
+	Targets       func() ([]string, error)
+	Toolchains    func() ([]DashboardToolchain, error)
+	Runs          func() ([]DashboardRun, error)
+	// Command returns the process for a dashboard action: build, test or lint
+	Command func(action string) *exec.Cmd
+}
+
+// dashboardActions maps keys to the commands they run
+var dashboardActions = []struct{ key, action string }{
+	{"b", "build"},
+	{"t", "test"},
+	{"l", "lint"},
+}
+
+// Messages of the dashboard
+type (
+	dashboardDataMsg struct {
+		project    DashboardProject
+		deps       []DashboardDependency
+		targets    []string
+		toolchains []DashboardToolchain
+		runs       []DashboardRun
+		errs       []string
+	}
+	dashboardLatestMsg struct {
+		name   string
+		latest string
+	}
+	dashboardRunsMsg   []DashboardRun
+	dashboardLineMsg   string
+	dashboardExitMsg   struct{ err error }
+	dashboardStartMsg  struct{ lines <-chan string }
+	dashboardFailedMsg struct{ err error }
+)
+
+// DashboardModel is the cpx ui dashboard
+type DashboardModel struct {
+	funcs   DashboardFuncs
+	spinner spinner.Model
+	width   int
+	height  int
+	loading bool
+
+	project    DashboardProject
+	deps       []DashboardDependency
+	targets    []string
+	toolchains []DashboardToolchain
+	runs       []DashboardRun
+	errs       []string
+
+	// The running or last command and its output
+	action  string
+	running bool
+	proc    *exec.Cmd
+	lines   <-chan string
+	output  []string
+	scroll  int // lines scrolled up from the bottom of the output
+	lastErr error
+}
+
+// NewDashboardModel creates the dashboard
+func NewDashboardModel(funcs DashboardFuncs) DashboardModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = spinnerStyle
+	return DashboardModel{funcs: funcs, spinner: s, loading: true, width: 100, height: 30}
+}
+
+// Init loads the data
+func (m DashboardModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.load())
+}
+
+// load reads everything but the latest versions, which follow one by one.
+func (m DashboardModel) load() tea.Cmd {
+	funcs := m.funcs
+	return func() tea.Msg {
+		var msg dashboardDataMsg
+		note := func(what string, err error) {
+			if err != nil {
+				msg.errs = append(msg.errs, fmt.Sprintf("%s: %v", what, err))
+			}
+		}
+		msg.project = funcs.Project()
+		var err error
+		msg.deps, err = funcs.Dependencies()
+		note("dependencies", err)
+		msg.targets, err = funcs.Targets()
+		note("targets", err)
+		msg.toolchains, err = funcs.Toolchains()
+		note("toolchains", err)
+		msg.runs, err = funcs.Runs()
+		note("runs", err)
+		return msg
+	}
+}
+
+func (m DashboardModel) loadLatest(name string) tea.Cmd {
+	latestVersion := m.funcs.LatestVersion
+	return func() tea.Msg {
+		latest, err := latestVersion(name)
+		if err != nil {
+			latest = "?"
+		}
+		return dashboardLatestMsg{name: name, latest: latest}
+	}
+}
+
+func (m DashboardModel) loadRuns() tea.Cmd {
+	runs := m.funcs.Runs
+	return func() tea.Msg {
+		r, _ := runs()
+		return dashboardRunsMsg(r)
+	}
+}
+
+// startCommand starts proc and streams its combined output.
+func startCommand(proc *exec.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		pr, pw := io.Pipe()
+		proc.Stdout = pw
+		proc.Stderr = pw
+		if err := proc.Start(); err != nil {
+			return dashboardFailedMsg{err: err}
+		}
+		lines := make(chan string, 64)
+		go func() {
+			sc := bufio.NewScanner(pr)
+			sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+			for sc.Scan() {
+				// Progress bars redraw with carriage returns: keep the last state
+				line := sc.Text()
+				if i := strings.LastIndex(line, "\r"); i >= 0 {
+					line = line[i+1:]
+				}
+				lines <- line
+			}
+			close(lines)
+		}()
+		go func() {
+			err := proc.Wait()
+			pw.CloseWithError(err)
+		}()
+		return dashboardStartMsg{lines: lines}
+	}
+}
+
+// waitLine reads the next output line; at the end it waits for the exit.
+func waitLine(lines <-chan string, proc *exec.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		if line, ok := <-lines; ok {
+			return dashboardLineMsg(line)
+		}
+		var err error
+		if proc.ProcessState != nil && !proc.ProcessState.Success() {
+			err = fmt.Errorf("%s", proc.ProcessState)
+		}
+		return dashboardExitMsg{err: err}
+	}
+}
+
+// Update handles keys, data and command output
+func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case tea.KeyMsg:
+		switch key := msg.String(); key {
+		case "ctrl+c", "q", "esc":
+			if m.running && m.proc.Process != nil {
+				// Stop the command first; quit on the next press
+				_ = m.proc.Process.Kill()
+				return m, nil
+			}
+			return m, tea.Quit
+		case "r":
+			if !m.loading {
+				m.loading = true
+				return m, m.load()
+			}
+		case "up", "k", "pgup":
+			step := 1
+			if key == "pgup" {
+				step = m.outputHeight()
+			}
+			m.scroll = min(m.scroll+step, max(len(m.output)-m.outputHeight(), 0))
+		case "down", "j", "pgdown":
+			step := 1
+			if key == "pgdown" {
+				step = m.outputHeight()
+			}
+			m.scroll = max(m.scroll-step, 0)
+		default:
+			for _, a := range dashboardActions {
+				if key == a.key && !m.running {
+					m.action, m.running, m.output, m.scroll, m.lastErr = a.action, true, nil, 0, nil
+					m.proc = m.funcs.Command(a.action)
+					return m, startCommand(m.proc)
+				}
+			}
+		}
+
+	case dashboardDataMsg:
+		m.loading = false
+		m.project, m.deps, m.targets, m.toolchains, m.runs, m.errs = msg.project, msg.deps, msg.targets, msg.toolchains, msg.runs, msg.errs
+		var cmds []tea.Cmd
+		for _, d := range m.deps {
+			cmds = append(cmds, m.loadLatest(d.Name))
+		}
+		return m, tea.Batch(cmds...)
+
+	case dashboardLatestMsg:
+		for i := range m.deps {
+			if m.deps[i].Name == msg.name {
+				m.deps[i].Latest = msg.latest
+			}
+		}
+
+	case dashboardRunsMsg:
+		m.runs = msg
+
+	case dashboardStartMsg:
+		m.lines = msg.lines
+		return m, waitLine(m.lines, m.proc)
+
+	case dashboardFailedMsg:
+		m.running, m.lastErr = false, msg.err
+
+	case dashboardLineMsg:
+		m.output = append(m.output, string(msg))
+		if m.scroll > 0 {
+			// Keep the scrolled view in place while lines arrive
+			m.scroll++
+		}
+		return m, waitLine(m.lines, m.proc)
+
+	case dashboardExitMsg:
+		m.running, m.lastErr = false, msg.err
+		return m, m.loadRuns()
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+var (
+	dashboardPanel = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(dimGray).
+			Padding(0, 1)
+	dashboardTitle = lipgloss.NewStyle().Foreground(cyan).Bold(true)
+	warnStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD700"))
+)
+
+// panelLines is how many entries the upper panels show.
+const panelLines = 5
+
+// outputHeight is how many output lines fit below the panels.
+func (m DashboardModel) outputHeight() int {
+	// header, two rows of panels with borders and titles, the output
+	// border and title, help
+	return max(m.height-1-2*(panelLines+3)-3-1, 3)
+}
+
+// View renders the dashboard
+func (m DashboardModel) View() string {
+	var b strings.Builder
+
+	header := dashboardTitle.Render("cpx ui")
+	if m.loading {
+		header += " " + m.spinner.View() + dimStyle.Render(" loading...")
+	} else {
+		p := m.project
+		header += "  " + questionStyle.Render(p.Name)
+		if p.Version != "" {
+			header += " " + dimStyle.Render(p.Version)
+		}
+		header += dimStyle.Render("  " + p.BuildSystem + "  " + p.Root)
+	}
+	b.WriteString(header + "\n")
+
+	colWidth := max(m.width/2, 30)
+	left := lipgloss.JoinVertical(lipgloss.Left,
+		m.panel("Dependencies", m.dependencyLines(), colWidth),
+		m.panel("Targets", m.targets, colWidth),
+	)
+	right := lipgloss.JoinVertical(lipgloss.Left,
+		m.panel("Toolchains", m.toolchainLines(), colWidth),
+		m.panel("Recent runs", m.runLines(), colWidth),
+	)
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, left, right) + "\n")
+
+	b.WriteString(m.outputPanel(max(m.width-2, 40)) + "\n")
+
+	var help []string
+	for _, a := range dashboardActions {
+		help = append(help, a.key+" "+a.action)
+	}
+	help = append(help, "r refresh", "↑/↓ scroll", "q quit")
+	b.WriteString(dimStyle.Render(strings.Join(help, " • ")))
+	return b.String()
+}
+
+// panel renders a titled box with at most panelLines lines.
+func (m DashboardModel) panel(title string, lines []string, width int) string {
+	body := make([]string, 0, panelLines)
+	for i, line := range lines {
+		if i == panelLines-1 && len(lines) > panelLines {
+			body = append(body, dimStyle.Render(fmt.Sprintf("… %d more", len(lines)-i)))
+			break
+		}
+		body = append(body, truncate(line, width-4))
+	}
+	if len(body) == 0 {
+		body = append(body, dimStyle.Render("none"))
+	}
+	for len(body) < panelLines {
+		body = append(body, "")
+	}
+	content := dashboardTitle.Render(title) + "\n" + strings.Join(body, "\n")
+	return dashboardPanel.Width(width - 2).Render(content)
+}
+
+func (m DashboardModel) dependencyLines() []string {
+	var lines []string
+	for _, d := range m.deps {
+		version := d.Version
+		if version == "" {
+			version = "*"
+		}
+		status := m.spinner.View()
+		switch {
+		case d.Latest == "":
+		case d.Latest == "?":
+			status = dimStyle.Render("?")
+		case d.Version == "" || d.Version == d.Latest:
+			status = greenStyle.Render("✓ " + d.Latest)
+		default:
+			status = warnStyle.Render("↑ " + d.Latest)
+		}
+		lines = append(lines, fmt.Sprintf("%-20s %-10s %s", d.Name, version, status))
+	}
+	return lines
+}
+
+func (m DashboardModel) toolchainLines() []string {
+	var lines []string
+	for _, tc := range m.toolchains {
+		lines = append(lines, fmt.Sprintf("%-24s %s", tc.Name, dimStyle.Render(tc.Runner)))
+	}
+	return lines
+}
+
+func (m DashboardModel) runLines() []string {
+	var lines []string
+	for _, r := range m.runs {
+		name := r.Command
+		if r.Toolchain != "" {
+			name += " " + r.Toolchain
+		}
+		status := dimStyle.Render("running")
+		switch {
+		case r.Status == "ok":
+			status = greenStyle.Render("✓ ok")
+		case r.Status != "":
+			status = errorStyle.Render("✗ " + r.Status)
+		}
+		lines = append(lines, fmt.Sprintf("%s  %-18s %s", dimStyle.Render(r.Time.Format("01-02 15:04")), name, status))
+	}
+	return lines
+}
+
+// outputPanel renders the output of the running or last command.
+func (m DashboardModel) outputPanel(width int) string {
+	title := "Output"
+	switch {
+	case m.running:
+		title = fmt.Sprintf("cpx %s %s", m.action, m.spinner.View())
+	case m.action != "" && m.lastErr != nil:
+		title = fmt.Sprintf("cpx %s %s", m.action, errorStyle.Render("✗ "+m.lastErr.Error()))
+	case m.action != "":
+		title = fmt.Sprintf("cpx %s %s", m.action, greenStyle.Render("✓ done"))
+	}
+
+	height := m.outputHeight()
+	var body []string
+	switch {
+	case len(m.errs) > 0 && m.action == "":
+		for _, e := range m.errs {
+			body = append(body, errorStyle.Render(truncate(e, width-4)))
+		}
+	case len(m.output) == 0 && m.action == "":
+		body = append(body, dimStyle.Render("Press b to build, t to test or l to lint"))
+	default:
+		end := len(m.output) - m.scroll
+		start := max(end-height, 0)
+		for _, line := range m.output[start:end] {
+			body = append(body, truncate(line, width-4))
+		}
+	}
+	for len(body) < height {
+		body = append(body, "")
+	}
+	if len(body) > height {
+		body = body[len(body)-height:]
+	}
+	return dashboardPanel.Width(width).Render(dashboardTitle.Render(title) + "\n" + strings.Join(body, "\n"))
+}
+
+// truncate shortens s, which may contain styles, to width columns.
+func truncate(s string, width int) string {
+	if width <= 1 {
+		return s
+	}
+	return ansi.Truncate(s, width, "…")
+}
@@ -0,0 +1,596 @@
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PackageInfo describes a registry package
+type PackageInfo struct {
+	Name         string
+	Version      string
+	Description  string
+	Homepage     string
+	License      string
+	Dependencies []string
+	Versions     []string
+}
+
+// DepsFuncs list, search and change the project dependencies. Commands
+// that change the project run as processes so their output can be shown.
+type DepsFuncs struct {
+	List   func() ([]DashboardDependency, error)
+	Latest func(name string) (string, error)
+	Search SearchFunc
+	Info   func(name string) (PackageInfo, error)
+	Add    func(name string) *exec.Cmd
+	Remove func(names []string) *exec.Cmd
+	// Upgrade returns the processes, run in order, that move name to version
+	Upgrade func(name, version string) []*exec.Cmd
+}
+
+// Panes of the dependency manager
+const (
+	depsInstalled = iota
+	depsResults
+)
+
+// Messages of the dependency manager
+type (
+	depsListMsg struct {
+		deps []DashboardDependency
+		err  error
+	}
+	depsSearchMsg struct {
+		query   string
+		results []SearchResult
+		err     error
+	}
+	depsInfoMsg struct {
+		name string
+		info PackageInfo
+		err  error
+	}
+)
+
+// depsStep is a queued command and what it does
+type depsStep struct {
+	title string
+	proc  *exec.Cmd
+}
+
+// DepsModel is the cpx deps dependency manager
+type DepsModel struct {
+	funcs   DepsFuncs
+	spinner spinner.Model
+	input   textinput.Model
+	width   int
+	height  int
+
+	pane      int
+	deps      []DashboardDependency
+	loading   bool
+	listErr   error
+	results   []SearchResult
+	searching bool
+	searchErr error
+	query     string
+	cursor    [2]int
+	selected  [2]map[string]bool
+
+	info    map[string]PackageInfo
+	infoErr map[string]error
+
+	// The running or last command and its output
+	title   string
+	queue   []depsStep
+	running bool
+	proc    *exec.Cmd
+	lines   <-chan string
+	output  []string
+	lastErr error
+}
+
+// NewDepsModel creates the dependency manager
+func NewDepsModel(funcs DepsFuncs) DepsModel {
+	ti := textinput.New()
+	ti.Placeholder = "press / to search the registry"
+	ti.CharLimit = 64
+	ti.Width = 40
+	ti.PromptStyle = inputPromptStyle
+	ti.TextStyle = inputTextStyle
+	ti.Cursor.Style = cursorStyle
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = spinnerStyle
+
+	return DepsModel{
+		funcs:    funcs,
+		spinner:  s,
+		input:    ti,
+		width:    100,
+		height:   30,
+		loading:  true,
+		selected: [2]map[string]bool{{}, {}},
+		info:     map[string]PackageInfo{},
+		infoErr:  map[string]error{},
+	}
+}
+
+// Init loads the dependencies
+func (m DepsModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.load())
+}
+
+func (m DepsModel) load() tea.Cmd {
+	list := m.funcs.List
+	return func() tea.Msg {
+		deps, err := list()
+		return depsListMsg{deps: deps, err: err}
+	}
+}
+
+func (m DepsModel) loadLatest(name string) tea.Cmd {
+	latest := m.funcs.Latest
+	return func() tea.Msg {
+		v, err := latest(name)
+		if err != nil {
+			v = "?"
+		}
+		return dashboardLatestMsg{name: name, latest: v}
+	}
+}
+
+func (m DepsModel) search(query string) tea.Cmd {
+	search := m.funcs.Search
+	return func() tea.Msg {
+		results, err := search(query)
+		return depsSearchMsg{query: query, results: results, err: err}
+	}
+}
+
+// loadInfo fetches the info of the package under the cursor once.
+func (m DepsModel) loadInfo() tea.Cmd {
+	name := m.current()
+	if name == "" {
+		return nil
+	}
+	if _, ok := m.info[name]; ok {
+		return nil
+	}
+	if _, ok := m.infoErr[name]; ok {
+		return nil
+	}
+	infoFunc := m.funcs.Info
+	return func() tea.Msg {
+		info, err := infoFunc(name)
+		return depsInfoMsg{name: name, info: info, err: err}
+	}
+}
+
+// names returns the names in the current pane.
+func (m DepsModel) names() []string {
+	var names []string
+	if m.pane == depsInstalled {
+		for _, d := range m.deps {
+			names = append(names, d.Name)
+		}
+	} else {
+		for _, r := range m.results {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// current returns the name under the cursor.
+func (m DepsModel) current() string {
+	names := m.names()
+	if c := m.cursor[m.pane]; c < len(names) {
+		return names[c]
+	}
+	return ""
+}
+
+// targets returns the selected names of the current pane, or the one under
+// the cursor when none is selected.
+func (m DepsModel) targets() []string {
+	var names []string
+	for _, name := range m.names() {
+		if m.selected[m.pane][name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		if name := m.current(); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// run queues steps and starts the first one.
+func (m DepsModel) run(steps ...depsStep) (DepsModel, tea.Cmd) {
+	if len(steps) == 0 {
+		return m, nil
+	}
+	m.queue, m.output, m.lastErr = steps, nil, nil
+	m.selected[m.pane] = map[string]bool{}
+	return m.next()
+}
+
+func (m DepsModel) next() (DepsModel, tea.Cmd) {
+	step := m.queue[0]
+	m.queue = m.queue[1:]
+	m.title, m.proc, m.running = step.title, step.proc, true
+	m.output = append(m.output, dimStyle.Render("$ "+strings.Join(step.proc.Args, " ")))
+	return m, startCommand(m.proc)
+}
+
+// Update handles keys, data and command output
+func (m DepsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case tea.KeyMsg:
+		if m.input.Focused() {
+			return m.updateInput(msg)
+		}
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			if m.running && m.proc.Process != nil {
+				// Stop the command first; quit on the next press
+				m.queue = nil
+				_ = m.proc.Process.Kill()
+				return m, nil
+			}
+			return m, tea.Quit
+		case "/":
+			m.input.Focus()
+			return m, textinput.Blink
+		case "tab":
+			m.pane = 1 - m.pane
+			return m, m.loadInfo()
+		case "up", "k":
+			m.cursor[m.pane] = max(m.cursor[m.pane]-1, 0)
+			return m, m.loadInfo()
+		case "down", "j":
+			m.cursor[m.pane] = max(min(m.cursor[m.pane]+1, len(m.names())-1), 0)
+			return m, m.loadInfo()
+		case " ":
+			if name := m.current(); name != "" {
+				m.selected[m.pane][name] = !m.selected[m.pane][name]
+			}
+		case "r":
+			if !m.loading && !m.running {
+				m.loading = true
+				return m, m.load()
+			}
+		case "a", "enter":
+			if m.pane == depsResults && !m.running {
+				var steps []depsStep
+				for _, name := range m.targets() {
+					steps = append(steps, depsStep{title: "add " + name, proc: m.funcs.Add(name)})
+				}
+				return m.run(steps...)
+			}
+		case "d", "x":
+			if m.pane == depsInstalled && !m.running {
+				if names := m.targets(); len(names) > 0 {
+					return m.run(depsStep{title: "remove " + strings.Join(names, " "), proc: m.funcs.Remove(names)})
+				}
+			}
+		case "u":
+			if m.pane == depsInstalled && !m.running {
+				return m.run(m.upgradeSteps()...)
+			}
+		}
+
+	case depsListMsg:
+		m.loading, m.deps, m.listErr = false, msg.deps, msg.err
+		m.cursor[depsInstalled] = max(min(m.cursor[depsInstalled], len(m.deps)-1), 0)
+		cmds := []tea.Cmd{m.loadInfo()}
+		for _, d := range m.deps {
+			cmds = append(cmds, m.loadLatest(d.Name))
+		}
+		return m, tea.Batch(cmds...)
+
+	case dashboardLatestMsg:
+		for i := range m.deps {
+			if m.deps[i].Name == msg.name {
+				m.deps[i].Latest = msg.latest
+			}
+		}
+
+	case depsSearchMsg:
+		if msg.query != m.query {
+			// A newer search is running
+			return m, nil
+		}
+		m.searching, m.results, m.searchErr = false, msg.results, msg.err
+		m.cursor[depsResults] = 0
+		m.selected[depsResults] = map[string]bool{}
+		m.pane = depsResults
+		return m, m.loadInfo()
+
+	case depsInfoMsg:
+		if msg.err != nil {
+			m.infoErr[msg.name] = msg.err
+		} else {
+			m.info[msg.name] = msg.info
+		}
+
+	case dashboardStartMsg:
+		m.lines = msg.lines
+		return m, waitLine(m.lines, m.proc)
+
+	case dashboardFailedMsg:
+		m.running, m.lastErr, m.queue = false, msg.err, nil
+
+	case dashboardLineMsg:
+		m.output = append(m.output, string(msg))
+		return m, waitLine(m.lines, m.proc)
+
+	case dashboardExitMsg:
+		m.running, m.lastErr = false, msg.err
+		if msg.err == nil && len(m.queue) > 0 {
+			return m.next()
+		}
+		m.queue = nil
+		m.loading = true
+		return m, m.load()
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m DepsModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		m.input.Blur()
+		query := strings.TrimSpace(m.input.Value())
+		if query == "" {
+			return m, nil
+		}
+		m.query, m.searching, m.searchErr = query, true, nil
+		return m, m.search(query)
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// upgradeSteps upgrades the targeted dependencies that have a newer version.
+func (m DepsModel) upgradeSteps() []depsStep {
+	var steps []depsStep
+	for _, name := range m.targets() {
+		for _, d := range m.deps {
+			if d.Name != name || !d.outdated() {
+				continue
+			}
+			for _, proc := range m.funcs.Upgrade(d.Name, d.Latest) {
+				steps = append(steps, depsStep{title: fmt.Sprintf("upgrade %s to %s", d.Name, d.Latest), proc: proc})
+			}
+		}
+	}
+	return steps
+}
+
+// outdated reports whether a newer version than the pinned one is known.
+func (d DashboardDependency) outdated() bool {
+	return d.Version != "" && d.Latest != "" && d.Latest != "?" && d.Version != d.Latest
+}
+
+// depsOutputLines is how many output lines the bottom pane shows.
+const depsOutputLines = 6
+
+// listHeight is how many entries fit in the list panes.
+func (m DepsModel) listHeight() int {
+	// header, search line, pane border and title, output pane, help
+	return max(m.height-1-1-3-(depsOutputLines+3)-1, 3)
+}
+
+// View renders the dependency manager
+func (m DepsModel) View() string {
+	var b strings.Builder
+
+	header := dashboardTitle.Render("cpx deps")
+	if m.loading {
+		header += " " + m.spinner.View() + dimStyle.Render(" loading...")
+	} else {
+		header += dimStyle.Render(fmt.Sprintf("  %d dependencies", len(m.deps)))
+	}
+	b.WriteString(header + "\n")
+
+	search := m.input.View()
+	if m.searching {
+		search += " " + m.spinner.View()
+	}
+	b.WriteString(search + "\n")
+
+	colWidth := max(m.width/2, 30)
+	var list string
+	if m.pane == depsInstalled {
+		list = m.listPane("Installed", m.installedLines(), colWidth)
+	} else {
+		list = m.listPane(fmt.Sprintf("Results for %q", m.query), m.resultLines(), colWidth)
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, list, m.infoPane(colWidth)) + "\n")
+	b.WriteString(m.outputPane(max(m.width-2, 40)) + "\n")
+
+	help := "tab installed/results • / search • space select • "
+	if m.pane == depsInstalled {
+		help += "d remove • u upgrade • r refresh • q quit"
+	} else {
+		help += "a add • q quit"
+	}
+	if m.input.Focused() {
+		help = "enter search • esc cancel"
+	}
+	b.WriteString(dimStyle.Render(help))
+	return b.String()
+}
+
+func (m DepsModel) installedLines() []string {
+	if m.listErr != nil {
+		return []string{errorStyle.Render(m.listErr.Error())}
+	}
+	var lines []string
+	for _, d := range m.deps {
+		version := d.Version
+		if version == "" {
+			version = "*"
+		}
+		status := m.spinner.View()
+		switch {
+		case d.Latest == "":
+		case d.Latest == "?":
+			status = dimStyle.Render("?")
+		case d.outdated():
+			status = warnStyle.Render("↑ " + d.Latest)
+		default:
+			status = greenStyle.Render("✓ " + d.Latest)
+		}
+		lines = append(lines, fmt.Sprintf("%-20s %-10s %s", d.Name, version, status))
+	}
+	return lines
+}
+
+func (m DepsModel) resultLines() []string {
+	if m.searchErr != nil {
+		return []string{errorStyle.Render(m.searchErr.Error())}
+	}
+	var lines []string
+	for _, r := range m.results {
+		lines = append(lines, fmt.Sprintf("%-24s %s", r.Name, dimStyle.Render(r.Version)))
+	}
+	return lines
+}
+
+// listPane renders lines with the cursor and selection of the current pane,
+// scrolled to keep the cursor visible.
+func (m DepsModel) listPane(title string, lines []string, width int) string {
+	height := m.listHeight()
+	cursor := m.cursor[m.pane]
+	names := m.names()
+	start := max(min(cursor-height/2, len(lines)-height), 0)
+
+	var body []string
+	for i := start; i < len(lines) && len(body) < height; i++ {
+		mark, check := " ", " "
+		if i == cursor && !m.input.Focused() {
+			mark = selectedStyle.Render("❯")
+		}
+		if i < len(names) && m.selected[m.pane][names[i]] {
+			check = greenStyle.Render("✓")
+		}
+		body = append(body, truncate(mark+check+" "+lines[i], width-4))
+	}
+	if len(body) == 0 {
+		body = append(body, dimStyle.Render("none"))
+	}
+	for len(body) < height {
+		body = append(body, "")
+	}
+	return dashboardPanel.Width(width - 2).Render(dashboardTitle.Render(title) + "\n" + strings.Join(body, "\n"))
+}
+
+// infoPane renders the info of the package under the cursor.
+func (m DepsModel) infoPane(width int) string {
+	height := m.listHeight()
+	name := m.current()
+	var body []string
+	info, ok := m.info[name]
+	switch {
+	case name == "":
+	case m.infoErr[name] != nil:
+		body = append(body, errorStyle.Render(m.infoErr[name].Error()))
+	case !ok:
+		body = append(body, m.spinner.View()+dimStyle.Render(" loading..."))
+	default:
+		body = append(body, questionStyle.Render(info.Name)+" "+dimStyle.Render(info.Version))
+		if info.Description != "" {
+			body = append(body, wrap(info.Description, width-4)...)
+		}
+		field := func(label, value string) {
+			if value != "" {
+				body = append(body, dimStyle.Render(label+": ")+value)
+			}
+		}
+		field("License", info.License)
+		field("Homepage", info.Homepage)
+		field("Depends on", strings.Join(info.Dependencies, ", "))
+		if len(info.Versions) > 0 {
+			field("Versions", strings.Join(info.Versions[:min(len(info.Versions), 8)], ", "))
+		}
+	}
+	for i := range body {
+		body[i] = truncate(body[i], width-4)
+	}
+	if len(body) > height {
+		body = body[:height]
+	}
+	for len(body) < height {
+		body = append(body, "")
+	}
+	return dashboardPanel.Width(width - 2).Render(dashboardTitle.Render("Info") + "\n" + strings.Join(body, "\n"))
+}
+
+// outputPane renders the output of the running or last command.
+func (m DepsModel) outputPane(width int) string {
+	title := "Output"
+	switch {
+	case m.running:
+		title = m.title + " " + m.spinner.View()
+	case m.title != "" && m.lastErr != nil:
+		title = m.title + " " + errorStyle.Render("✗ "+m.lastErr.Error())
+	case m.title != "":
+		title = m.title + " " + greenStyle.Render("✓ done")
+	}
+	body := m.output[max(len(m.output)-depsOutputLines, 0):]
+	body = append([]string(nil), body...)
+	for i := range body {
+		body[i] = truncate(body[i], width-4)
+	}
+	for len(body) < depsOutputLines {
+		body = append(body, "")
+	}
+	return dashboardPanel.Width(width).Render(dashboardTitle.Render(title) + "\n" + strings.Join(body, "\n"))
+}
+
+// wrap breaks s into lines of at most width columns at spaces.
+func wrap(s string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fixDiffLines is how many diff lines of a fix are shown.
+const fixDiffLines = 20
+
+// FixItem is one proposed fix as the review shows it
+type FixItem struct {
+	Check    string
+	Message  string
+	Location string   // file:line
+	Diff     []string // lines starting with "@@", "-" or "+"
+}
+
+type fixDecision int
+
+const (
+	fixUndecided fixDecision = iota
+	fixAccepted
+	fixRejected
+)
+
+// FixReviewModel steps through proposed fixes, accepting or rejecting each
+type FixReviewModel struct {
+	fixes     []FixItem
+	decisions []fixDecision
+	current   int
+	done      bool
+	cancelled bool
+}
+
+// NewFixReviewModel creates a review of fixes, all undecided.
+func NewFixReviewModel(fixes []FixItem) FixReviewModel {
+	return FixReviewModel{fixes: fixes, decisions: make([]fixDecision, len(fixes))}
+}
+
+// Init does nothing
+func (m FixReviewModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles keys
+func (m FixReviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "ctrl+c", "esc":
+		m.cancelled = true
+		return m, tea.Quit
+	case "q":
+		m.done = true
+		return m, tea.Quit
+	case "y", "enter":
+		return m.decide(fixAccepted, false)
+	case "n":
+		return m.decide(fixRejected, false)
+	case "a":
+		return m.decide(fixAccepted, true)
+	case "s":
+		return m.decide(fixRejected, true)
+	case "left", "h", "p":
+		m.current = max(m.current-1, 0)
+	case "right", "l", "tab":
+		m.current = min(m.current+1, len(m.fixes)-1)
+	}
+	return m, nil
+}
+
+// decide records d for the current fix, or for it and every undecided fix
+// after it with rest, and moves to the next undecided fix. The review ends
+// when none is left.
+func (m FixReviewModel) decide(d fixDecision, rest bool) (tea.Model, tea.Cmd) {
+	m.decisions[m.current] = d
+	if rest {
+		for i := m.current + 1; i < len(m.decisions); i++ {
+			if m.decisions[i] == fixUndecided {
+				m.decisions[i] = d
+			}
+		}
+	}
+	for i := range len(m.fixes) {
+		next := (m.current + 1 + i) % len(m.fixes)
+		if m.decisions[next] == fixUndecided {
+			m.current = next
+			return m, nil
+		}
+	}
+	m.done = true
+	return m, tea.Quit
+}
+
+// View renders the current fix
+func (m FixReviewModel) View() string {
+	if m.done || m.cancelled || len(m.fixes) == 0 {
+		return ""
+	}
+	fix := m.fixes[m.current]
+	var b strings.Builder
+	b.WriteString(questionMark.Render("?") + " " + questionStyle.Render(fmt.Sprintf("Apply fix %d of %d?", m.current+1, len(m.fixes))))
+	switch m.decisions[m.current] {
+	case fixAccepted:
+		b.WriteString(" " + greenStyle.Render("accepted"))
+	case fixRejected:
+		b.WriteString(" " + errorStyle.Render("rejected"))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(cyanBold.Render(fix.Check) + " " + dimStyle.Render(fix.Location) + "\n")
+	b.WriteString(fix.Message + "\n\n")
+
+	for i, line := range fix.Diff {
+		if i == fixDiffLines {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("… %d more lines", len(fix.Diff)-fixDiffLines)) + "\n")
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			b.WriteString(dimStyle.Render(line) + "\n")
+		case strings.HasPrefix(line, "-"):
+			b.WriteString(errorStyle.Render(line) + "\n")
+		case strings.HasPrefix(line, "+"):
+			b.WriteString(greenStyle.Render(line) + "\n")
+		default:
+			b.WriteString(line + "\n")
+		}
+	}
+
+	accepted, rejected := 0, 0
+	for _, d := range m.decisions {
+		switch d {
+		case fixAccepted:
+			accepted++
+		case fixRejected:
+			rejected++
+		}
+	}
+	b.WriteString("\n" + dimStyle.Render(fmt.Sprintf("  %d accepted • %d rejected • %d left", accepted, rejected, len(m.fixes)-accepted-rejected)) + "\n")
+	b.WriteString(dimStyle.Render("  y accept • n reject • a accept all remaining • s reject all remaining • ←/→ move • q apply accepted • esc cancel"))
+	return b.String()
+}
+
+// Accepted reports, for each fix, whether it was accepted.
+func (m FixReviewModel) Accepted() []bool {
+	accepted := make([]bool, len(m.decisions))
+	for i, d := range m.decisions {
+		accepted[i] = d == fixAccepted
+	}
+	return accepted
+}
+
+// RunFixReview asks the user to accept or reject each fix and reports which
+// were accepted. It returns an error when the user cancels, so nothing is
+// applied.
+func RunFixReview(fixes []FixItem) ([]bool, error) {
+	p := tea.NewProgram(NewFixReviewModel(fixes))
+	final, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+	m := final.(FixReviewModel)
+	if m.cancelled {
+		return nil, fmt.Errorf("fix review cancelled, no files changed")
+	}
+	return m.Accepted(), nil
+}
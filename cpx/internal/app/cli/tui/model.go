@@ -27,6 +27,7 @@ const (
 	StepGitHooks
 	StepPreCommit
 	StepPrePush
+	StepCommitMsg
 	StepCreating
 	StepDone
 )
@@ -40,18 +41,24 @@ type Question struct {
 
 // ProjectConfig holds the user's choices
 type ProjectConfig struct {
-	Name           string
-	IsLibrary      bool
-	CppStandard    int
-	TestFramework  string
-	Benchmark      string
-	ClangFormat    string
-	PackageManager string // "vcpkg", "meson", "bazel", or "none"
-	VCS            string // "git" or "none"
-	UseHooks       bool
-	GitHooks       []string
-	PreCommit      []string
-	PrePush        []string
+	Name            string
+	IsLibrary       bool
+	CppStandard     int
+	TestFramework   string
+	Benchmark       string
+	ClangFormat     string
+	PackageManager  string // "vcpkg", "meson", "bazel", or "none"
+	VCS             string // "git" or "none"
+	UseHooks        bool
+	GitHooks        []string
+	PreCommit       []string
+	PrePush         []string
+	CommitMsg       bool // Install the commit-msg hook for Conventional Commits
+	SharedLibrary   bool // Set by cpx new --shared; libraries are static otherwise
+	PCH             bool // Set by cpx new --pch
+	Modules         bool // Set by cpx new --modules: C++20 modules instead of headers
+	HeaderOnly      bool // Library with no sources, only include/
+	NoTemplateHooks bool // Set by cpx new --no-hooks: skip post-generate commands
 	// Template fields
 	UseTemplate  bool   // True if using a template
 	TemplateName string // Selected template name
@@ -130,7 +137,7 @@ func InitialModel() Model {
 		currentQuestion:       "What will your project be called?",
 		projectModeOptions:    []string{"Use Template", "Custom Project"},
 		templateOptions:       templateNames,
-		projectTypeOptions:    []string{"Executable", "Library"},
+		projectTypeOptions:    []string{"Executable", "Library", "Header-only library"},
 		cppStandardOptions:    []int{11, 14, 17, 20, 23},
 		testFrameworkOptions:  []string{"GoogleTest", "Catch2", "doctest", "None"},
 		benchmarkOptions:      []string{"Google Benchmark", "nanobench", "Catch2 benchmark", "None"},
@@ -240,7 +247,7 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			m.errorMsg = "Project name cannot be empty"
 			return m, nil
 		}
-		if !isValidProjectName(name) {
+		if !IsValidProjectName(name) {
 			m.errorMsg = "Project name can only contain letters, numbers, hyphens, and underscores"
 			return m, nil
 		}
@@ -254,6 +261,11 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			Complete: true,
 		})
 
+		// cpx new --template skips choosing how to create the project
+		if m.config.UseTemplate {
+			return m.startTemplate()
+		}
+
 		m.currentQuestion = "How would you like to create your project?"
 		m.step = StepProjectMode
 		m.cursor = 0
@@ -290,13 +302,11 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			Complete: true,
 		})
 
-		// Templates always use vcpkg, start creating immediately
-		m.config.PackageManager = "vcpkg"
-		m.step = StepCreating
-		return m, tickCreation()
+		return m.startTemplate()
 
 	case StepProjectType:
-		m.config.IsLibrary = m.cursor == 1
+		m.config.IsLibrary = m.cursor >= 1
+		m.config.HeaderOnly = m.cursor == 2
 		answer := m.projectTypeOptions[m.cursor]
 
 		m.questions = append(m.questions, Question{
@@ -368,17 +378,8 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		m.cursor = 0
 
 	case StepPackageManager:
-		switch m.cursor {
-		case 0:
-			m.config.PackageManager = "vcpkg"
-		case 1:
-			m.config.PackageManager = "bazel"
-		case 2:
-			m.config.PackageManager = "meson"
-		default:
-			m.config.PackageManager = "vcpkg"
-		}
 		answer := m.packageManagerOptions[m.cursor]
+		m.config.PackageManager = strings.ToLower(answer)
 
 		m.questions = append(m.questions, Question{
 			Question: m.currentQuestion,
@@ -481,6 +482,23 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			Complete: true,
 		})
 
+		m.currentQuestion = "Validate commit messages (Conventional Commits)?"
+		m.step = StepCommitMsg
+		m.cursor = 1 // Default to No
+
+	case StepCommitMsg:
+		m.config.CommitMsg = m.cursor == 0
+		answer := "No"
+		if m.config.CommitMsg {
+			answer = "Yes"
+		}
+
+		m.questions = append(m.questions, Question{
+			Question: m.currentQuestion,
+			Answer:   answer,
+			Complete: true,
+		})
+
 		// Start creating
 		m.step = StepCreating
 		return m, tickCreation()
@@ -508,7 +526,7 @@ func (m Model) getMaxCursor() int {
 		return len(m.benchmarkOptions) - 1
 	case StepPackageManager:
 		return len(m.packageManagerOptions) - 1
-	case StepGitHooks:
+	case StepGitHooks, StepCommitMsg:
 		return 1 // Yes or No
 	case StepPreCommit:
 		return len(m.preCommitOptions) - 1
@@ -665,7 +683,7 @@ func (m Model) View() string {
 				s.WriteString(fmt.Sprintf("  %s %s\n", cursor, opt))
 			}
 
-		case StepGitHooks:
+		case StepGitHooks, StepCommitMsg:
 			answer := "Yes"
 			if m.cursor == 1 {
 				answer = "No"
@@ -715,8 +733,9 @@ func (m Model) IsCancelled() bool {
 	return m.cancelled
 }
 
-// Helper function to validate project name
-func isValidProjectName(name string) bool {
+// IsValidProjectName reports whether name only uses letters, digits, hyphens
+// and underscores
+func IsValidProjectName(name string) bool {
 	if name == "" {
 		return false
 	}
@@ -728,3 +747,44 @@ func isValidProjectName(name string) bool {
 	}
 	return true
 }
+
+// packageManagerLabels returns the display labels of build systems
+func packageManagerLabels(managers []string) []string {
+	labels := make([]string, len(managers))
+	for i, pm := range managers {
+		switch pm {
+		case "bazel":
+			labels[i] = "Bazel"
+		case "meson":
+			labels[i] = "Meson"
+		default:
+			labels[i] = pm
+		}
+	}
+	return labels
+}
+
+// WithTemplate returns the model for creating a project from the named
+// template, which skips the project mode and template questions.
+func (m Model) WithTemplate(name string) Model {
+	m.config.UseTemplate = true
+	m.config.TemplateName = name
+	return m
+}
+
+// startTemplate continues once the template is chosen: the build system is
+// asked for only when the template supports several.
+func (m Model) startTemplate() (tea.Model, tea.Cmd) {
+	m.config.PackageManager = "vcpkg"
+	if tmpl, ok := project_templates.GetTemplateByName(m.config.TemplateName); ok {
+		if managers := project_templates.PackageManagers(tmpl); len(managers) > 1 {
+			m.packageManagerOptions = packageManagerLabels(managers)
+			m.currentQuestion = "Which build system would you like to use?"
+			m.step = StepPackageManager
+			m.cursor = 0
+			return m, nil
+		}
+	}
+	m.step = StepCreating
+	return m, tickCreation()
+}
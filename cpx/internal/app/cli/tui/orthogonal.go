@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -245,7 +246,11 @@ type AddRunnerStep int
 const (
 	RunnerStepName AddRunnerStep = iota
 	RunnerStepType
+	RunnerStepDockerSource
 	RunnerStepDockerImage
+	RunnerStepDockerfile
+	RunnerStepBuildContext
+	RunnerStepImageTag
 	RunnerStepCheckingImage
 	RunnerStepCompilerCC
 	RunnerStepCompilerCXX
@@ -267,13 +272,17 @@ type AddRunnerModel struct {
 	existingNames    map[string]bool
 	name             string
 	runnerType       string
+	dockerSource     string
 	image            string
+	dockerfile       string
+	buildContext     string
 	host             string
 	user             string
 	cc               string
 	cxx              string
 	cmakeToolchain   string
 	typeOptions      []string
+	sourceOptions    []string
 	availableImages  []DockerImage
 	filteredImages   []DockerImage
 	imageCursor      int
@@ -285,6 +294,8 @@ type AddRunnerResult struct {
 	Name           string
 	Type           string
 	Image          string
+	Dockerfile     string
+	BuildContext   string
 	Host           string
 	User           string
 	CC             string
@@ -317,6 +328,7 @@ func NewAddRunnerModel(existingNames []string) AddRunnerModel {
 		spinner:          s,
 		existingNames:    existing,
 		typeOptions:      []string{"docker", "ssh"},
+		sourceOptions:    []string{"image", "dockerfile"},
 		availableImages:  images,
 		filteredImages:   images,
 		maxVisibleImages: 6,
@@ -370,10 +382,10 @@ func (m AddRunnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			return m.handleEnter()
 		case "up", "k":
-			if m.step == RunnerStepType {
+			if m.step == RunnerStepType || m.step == RunnerStepDockerSource {
 				m.cursor--
 				if m.cursor < 0 {
-					m.cursor = len(m.typeOptions) - 1
+					m.cursor = m.optionCount() - 1
 				}
 				return m, nil
 			} else if m.step == RunnerStepDockerImage && len(m.filteredImages) > 0 {
@@ -386,9 +398,9 @@ func (m AddRunnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		case "down", "j":
-			if m.step == RunnerStepType {
+			if m.step == RunnerStepType || m.step == RunnerStepDockerSource {
 				m.cursor++
-				if m.cursor >= len(m.typeOptions) {
+				if m.cursor >= m.optionCount() {
 					m.cursor = 0
 				}
 				return m, nil
@@ -411,7 +423,7 @@ func (m AddRunnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Update text input and filter images
-	if m.step == RunnerStepDockerImage || m.step == RunnerStepName || m.step == RunnerStepSSHHost || m.step == RunnerStepSSHUser || m.step == RunnerStepCompilerCC || m.step == RunnerStepCompilerCXX || m.step == RunnerStepCMakeToolchain {
+	if m.step == RunnerStepDockerImage || m.step == RunnerStepDockerfile || m.step == RunnerStepBuildContext || m.step == RunnerStepImageTag || m.step == RunnerStepName || m.step == RunnerStepSSHHost || m.step == RunnerStepSSHUser || m.step == RunnerStepCompilerCC || m.step == RunnerStepCompilerCXX || m.step == RunnerStepCMakeToolchain {
 		var cmd tea.Cmd
 		oldValue := m.textInput.Value()
 		m.textInput, cmd = m.textInput.Update(msg)
@@ -453,10 +465,8 @@ func (m AddRunnerModel) handleEnter() (tea.Model, tea.Cmd) {
 	case RunnerStepType:
 		m.runnerType = m.typeOptions[m.cursor]
 		if m.runnerType == "docker" {
-			m.step = RunnerStepDockerImage
-			m.textInput.Reset()
-			m.textInput.Placeholder = "gcc:13"
-			m.textInput.Focus()
+			m.step = RunnerStepDockerSource
+			m.cursor = 0
 		} else if m.runnerType == "ssh" {
 			m.step = RunnerStepSSHHost
 			m.textInput.Reset()
@@ -464,6 +474,57 @@ func (m AddRunnerModel) handleEnter() (tea.Model, tea.Cmd) {
 			m.textInput.Focus()
 		}
 
+	case RunnerStepDockerSource:
+		m.dockerSource = m.sourceOptions[m.cursor]
+		m.textInput.Reset()
+		m.textInput.Focus()
+		if m.dockerSource == "dockerfile" {
+			m.step = RunnerStepDockerfile
+			m.textInput.Placeholder = "Dockerfile"
+		} else {
+			m.step = RunnerStepDockerImage
+			m.textInput.Placeholder = "gcc:13"
+		}
+
+	case RunnerStepDockerfile:
+		if value == "" {
+			value = "Dockerfile"
+		}
+		if _, err := os.Stat(value); err != nil {
+			m.errorMsg = fmt.Sprintf("Dockerfile not found: %s", value)
+			return m, nil
+		}
+		m.dockerfile = value
+		m.step = RunnerStepBuildContext
+		m.textInput.Reset()
+		m.textInput.Placeholder = "."
+		m.textInput.Focus()
+
+	case RunnerStepBuildContext:
+		if value == "" {
+			value = "."
+		}
+		if info, err := os.Stat(value); err != nil || !info.IsDir() {
+			m.errorMsg = fmt.Sprintf("Build context directory not found: %s", value)
+			return m, nil
+		}
+		m.buildContext = value
+		m.step = RunnerStepImageTag
+		m.textInput.Reset()
+		m.textInput.Placeholder = "cpx-" + strings.ToLower(m.name) + ":latest"
+		m.textInput.Focus()
+
+	case RunnerStepImageTag:
+		if value == "" {
+			value = m.textInput.Placeholder
+		}
+		m.image = value
+		// The image is built on first use, so there is nothing to check yet
+		m.step = RunnerStepCompilerCC
+		m.textInput.Reset()
+		m.textInput.Placeholder = "(optional, e.g. gcc-13)"
+		m.textInput.Focus()
+
 	case RunnerStepDockerImage:
 		if len(m.filteredImages) > 0 && m.imageCursor < len(m.filteredImages) {
 			m.image = m.filteredImages[m.imageCursor].FullName()
@@ -544,6 +605,12 @@ func (m AddRunnerModel) View() string {
 	if m.runnerType != "" {
 		s.WriteString("  " + successStyle.Render("✓") + " Runner type: " + m.runnerType + "\n")
 	}
+	if m.dockerfile != "" {
+		s.WriteString("  " + successStyle.Render("✓") + " Dockerfile: " + m.dockerfile + "\n")
+	}
+	if m.buildContext != "" {
+		s.WriteString("  " + successStyle.Render("✓") + " Build context: " + m.buildContext + "\n")
+	}
 	if m.image != "" {
 		s.WriteString("  " + successStyle.Render("✓") + " Docker image: " + m.image + "\n")
 	}
@@ -575,6 +642,35 @@ func (m AddRunnerModel) View() string {
 			s.WriteString("  " + cursor + opt + desc + "\n")
 		}
 
+	case RunnerStepDockerSource:
+		s.WriteString("\n  " + questionStyle.Render("? Docker image source") + "\n")
+		for i, opt := range m.sourceOptions {
+			cursor := "  "
+			if m.cursor == i {
+				cursor = selectedStyle.Render("❯ ")
+			}
+			desc := ""
+			if opt == "image" {
+				desc = dimStyle.Render(" - use an existing image")
+			} else if opt == "dockerfile" {
+				desc = dimStyle.Render(" - build the image from a Dockerfile")
+			}
+			s.WriteString("  " + cursor + opt + desc + "\n")
+		}
+
+	case RunnerStepDockerfile:
+		s.WriteString("\n  " + questionStyle.Render("? Dockerfile path") + "\n")
+		s.WriteString("  " + m.textInput.View() + "\n")
+		s.WriteString("\n" + dimStyle.Render("  (e.g., Dockerfile, dockerfiles/Dockerfile.ubuntu)"))
+
+	case RunnerStepBuildContext:
+		s.WriteString("\n  " + questionStyle.Render("? Build context directory") + "\n")
+		s.WriteString("  " + m.textInput.View() + "\n")
+
+	case RunnerStepImageTag:
+		s.WriteString("\n  " + questionStyle.Render("? Image tag") + " " + dimStyle.Render("(Enter for default)") + "\n")
+		s.WriteString("  " + m.textInput.View() + "\n")
+
 	case RunnerStepDockerImage:
 		s.WriteString("\n  " + questionStyle.Render("? Docker image") + " " + dimStyle.Render("(type to filter, ↑↓ to select, Tab to complete)") + "\n")
 		s.WriteString("  " + m.textInput.View() + "\n")
@@ -666,6 +762,8 @@ func (m AddRunnerModel) GetResult() *AddRunnerResult {
 		Name:           m.name,
 		Type:           m.runnerType,
 		Image:          m.image,
+		Dockerfile:     m.dockerfile,
+		BuildContext:   m.buildContext,
 		Host:           m.host,
 		User:           m.user,
 		CC:             m.cc,
@@ -674,6 +772,14 @@ func (m AddRunnerModel) GetResult() *AddRunnerResult {
 	}
 }
 
+// optionCount returns the number of options for the current selection step
+func (m AddRunnerModel) optionCount() int {
+	if m.step == RunnerStepDockerSource {
+		return len(m.sourceOptions)
+	}
+	return len(m.typeOptions)
+}
+
 func RunAddRunnerTUI(existingNames []string) (*AddRunnerResult, error) {
 	m := NewAddRunnerModel(existingNames)
 	p := tea.NewProgram(m)
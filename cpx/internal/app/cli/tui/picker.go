@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pickerLines is how many candidates the picker shows at once.
+const pickerLines = 10
+
+// PickerModel is a fuzzy-searchable list to pick one target from
+type PickerModel struct {
+	title      string
+	candidates []string
+	input      textinput.Model
+	matches    []string
+	cursor     int
+	choice     string
+	cancelled  bool
+}
+
+// NewPickerModel creates a picker with the cursor on initial, if present.
+func NewPickerModel(title string, candidates []string, initial string) PickerModel {
+	ti := textinput.New()
+	ti.Placeholder = "type to filter"
+	ti.Focus()
+	ti.CharLimit = 64
+	ti.Width = 40
+	ti.PromptStyle = inputPromptStyle
+	ti.TextStyle = inputTextStyle
+	ti.Cursor.Style = cursorStyle
+
+	m := PickerModel{title: title, candidates: candidates, input: ti, matches: candidates}
+	for i, c := range candidates {
+		if c == initial {
+			m.cursor = i
+		}
+	}
+	return m
+}
+
+// Init starts the cursor blinking
+func (m PickerModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles keys
+func (m PickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.cancelled = true
+			return m, tea.Quit
+		case "enter":
+			if m.cursor < len(m.matches) {
+				m.choice = m.matches[m.cursor]
+				return m, tea.Quit
+			}
+			return m, nil
+		case "up", "ctrl+p":
+			m.cursor = max(m.cursor-1, 0)
+			return m, nil
+		case "down", "ctrl+n", "tab":
+			m.cursor = max(min(m.cursor+1, len(m.matches)-1), 0)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	query := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != query {
+		m.matches = fuzzyFilter(m.candidates, m.input.Value())
+		m.cursor = 0
+	}
+	return m, cmd
+}
+
+// View renders the picker
+func (m PickerModel) View() string {
+	if m.choice != "" || m.cancelled {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(questionMark.Render("?") + " " + questionStyle.Render(m.title) + "\n")
+	b.WriteString(m.input.View() + "\n")
+
+	start := max(min(m.cursor-pickerLines/2, len(m.matches)-pickerLines), 0)
+	for i := start; i < len(m.matches) && i < start+pickerLines; i++ {
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("❯ "+m.matches[i]) + "\n")
+		} else {
+			b.WriteString("  " + m.matches[i] + "\n")
+		}
+	}
+	if len(m.matches) == 0 {
+		b.WriteString(dimStyle.Render("  no match") + "\n")
+	}
+	b.WriteString(dimStyle.Render(fmt.Sprintf("  %d/%d • ↑/↓ move • enter select • esc cancel", len(m.matches), len(m.candidates))))
+	return b.String()
+}
+
+// fuzzyFilter returns the candidates that contain the characters of query in
+// order, best matches first: those where the characters are closest together
+// and start earliest. An empty query keeps all candidates.
+func fuzzyFilter(candidates []string, query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return candidates
+	}
+	type match struct {
+		s     string
+		score int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if score, ok := fuzzyScore(strings.ToLower(c), query); ok {
+			matches = append(matches, match{c, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.s
+	}
+	return out
+}
+
+// fuzzyScore matches query as a subsequence of s. Lower scores are better:
+// twice the span of the match plus where it starts. Spaces in query are
+// ignored.
+func fuzzyScore(s, query string) (int, bool) {
+	first, last, qi := -1, -1, 0
+	q := []rune(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, query))
+	for i, r := range []rune(s) {
+		if qi < len(q) && r == q[qi] {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			qi++
+		}
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return 2*(last-first) + first, true
+}
+
+// RunTargetPicker asks the user to pick one of candidates. It returns an
+// error when the user cancels.
+func RunTargetPicker(title string, candidates []string, initial string) (string, error) {
+	p := tea.NewProgram(NewPickerModel(title, candidates, initial))
+	final, err := p.Run()
+	if err != nil {
+		return "", err
+	}
+	m := final.(PickerModel)
+	if m.cancelled {
+		return "", fmt.Errorf("no target selected")
+	}
+	return m.choice, nil
+}
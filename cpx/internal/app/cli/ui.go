@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	"github.com/ozacod/cpx/internal/pkg/build/meson"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// dashboardRuns is how many recent runs the dashboard lists.
+const dashboardRuns = 10
+
+// UICmd creates the ui command
+func UICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ui",
+		Short: "Interactive project dashboard",
+		Long: `Open an interactive dashboard of the project: its dependencies and whether
+newer versions exist, build targets, cpx-ci.yaml toolchains and the results
+of recent builds and tests. b, t and l run cpx build, test and lint and
+stream their output in the lower pane; r reloads, q quits.`,
+		Args: cobra.NoArgs,
+		RunE: runUI,
+	}
+	return cmd
+}
+
+func runUI(_ *cobra.Command, _ []string) error {
+	projectType, err := RequireProject("cpx ui")
+	if err != nil {
+		return err
+	}
+	if !stdinIsTerminal() {
+		return fmt.Errorf("cpx ui is interactive and stdin is not a terminal")
+	}
+	root, err := findProjectRoot()
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	p := tea.NewProgram(tui.NewDashboardModel(dashboardFuncs(projectType, root, exe)), tea.WithAltScreen())
+	_, err = p.Run()
+	return err
+}
+
+// dashboardFuncs loads the dashboard data of the project at root and runs
+// its commands with the cpx binary exe.
+func dashboardFuncs(projectType ProjectType, root, exe string) tui.DashboardFuncs {
+	ctx := context.Background()
+	return tui.DashboardFuncs{
+		Project: func() tui.DashboardProject {
+			return dashboardProject(projectType, root)
+		},
+		Dependencies: func() ([]tui.DashboardDependency, error) {
+			builder, err := projectBuilder()
+			if err != nil {
+				return nil, err
+			}
+			deps, err := builder.ListDependencies(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var out []tui.DashboardDependency
+			for _, d := range deps {
+				out = append(out, tui.DashboardDependency{Name: d.Name, Version: d.Version})
+			}
+			return out, nil
+		},
+		LatestVersion: func(name string) (string, error) {
+			builder, err := projectBuilder()
+			if err != nil {
+				return "", err
+			}
+			info, err := builder.DependencyInfo(ctx, name)
+			if err != nil {
+				return "", err
+			}
+			return info.Version, nil
+		},
+		Targets: func() ([]string, error) {
+			builder, err := projectBuilder()
+			if err != nil {
+				return nil, err
+			}
+			return builder.ListTargets(ctx)
+		},
+		Toolchains: func() ([]tui.DashboardToolchain, error) {
+			return dashboardToolchains(filepath.Join(root, "cpx-ci.yaml"))
+		},
+		Runs: func() ([]tui.DashboardRun, error) {
+			return dashboardRunsFromLogs(buildlog.Dir(root))
+		},
+		Command: func(action string) *exec.Cmd {
+			cmd := exec.Command(exe, action, "--no-color")
+			cmd.Dir = root
+			return cmd
+		},
+	}
+}
+
+// dashboardProject reads the project name and version.
+func dashboardProject(projectType ProjectType, root string) tui.DashboardProject {
+	p := tui.DashboardProject{Name: filepath.Base(root), BuildSystem: string(projectType), Root: root}
+	switch projectType {
+	case ProjectTypeVcpkg:
+		p.Name, p.Version = getProjectInfo()
+	case ProjectTypeMeson:
+		if name := meson.ProjectName(root); name != "" {
+			p.Name = name
+		}
+	}
+	return p
+}
+
+// dashboardToolchains lists the toolchains of a cpx-ci.yaml, if any.
+func dashboardToolchains(path string) ([]tui.DashboardToolchain, error) {
+	ciConfig, err := config.LoadToolchains(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []tui.DashboardToolchain
+	for _, tc := range ciConfig.Toolchains {
+		runner := tc.Runner
+		if tc.Active != nil && !*tc.Active {
+			runner += " (inactive)"
+		}
+		out = append(out, tui.DashboardToolchain{Name: tc.Name, Runner: runner})
+	}
+	return out, nil
+}
+
+// dashboardRunsFromLogs lists the most recent runs recorded in dir.
+func dashboardRunsFromLogs(dir string) ([]tui.DashboardRun, error) {
+	entries, err := buildlog.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var runs []tui.DashboardRun
+	for _, e := range entries {
+		if len(runs) == dashboardRuns {
+			break
+		}
+		runs = append(runs, tui.DashboardRun{Command: e.Command, Toolchain: e.Toolchain, Time: e.Time, Status: e.Status})
+	}
+	return runs, nil
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardToolchains(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cpx-ci.yaml")
+	toolchains, err := dashboardToolchains(path)
+	require.NoError(t, err)
+	assert.Empty(t, toolchains)
+
+	require.NoError(t, os.WriteFile(path, []byte(`runners:
+  - name: local
+    type: native
+toolchains:
+  - name: linux-gcc
+    runner: local
+  - name: linux-clang
+    runner: local
+    active: false
+`), 0644))
+	toolchains, err = dashboardToolchains(path)
+	require.NoError(t, err)
+	require.Len(t, toolchains, 2)
+	assert.Equal(t, "linux-gcc", toolchains[0].Name)
+	assert.Equal(t, "local", toolchains[0].Runner)
+	assert.Equal(t, "local (inactive)", toolchains[1].Runner)
+}
+
+func TestDashboardRunsFromLogs(t *testing.T) {
+	root := t.TempDir()
+	buildlog.Start(root, "build", "").Close(nil)
+
+	runs, err := dashboardRunsFromLogs(buildlog.Dir(root))
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "build", runs[0].Command)
+	assert.Equal(t, "ok", runs[0].Status)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/spf13/cobra"
 )
 
@@ -40,11 +41,11 @@ func updateDependencies(specificLib string) error {
 	}
 
 	if len(deps) == 0 {
-		fmt.Printf("%s No dependencies to update%s\n", colors.Green, colors.Reset)
+		output.Successf(" No dependencies to update")
 		return nil
 	}
 
-	fmt.Printf("%s Checking for updates...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf(" Checking for updates...")
 	fmt.Printf("%s  Use 'vcpkg upgrade' to update vcpkg packages%s\n", colors.Yellow, colors.Reset)
 	fmt.Printf("   Dependencies in vcpkg.json:\n")
 	for _, dep := range deps {
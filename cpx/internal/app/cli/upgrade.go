@@ -1,6 +1,11 @@
 package cli
 
 import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,17 +17,81 @@ import (
 	"strings"
 
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
+// Release channels of cpx upgrade
+const (
+	channelStable  = "stable"
+	channelNightly = "nightly"
+)
+
+// releasesAPI is the GitHub API endpoint of the cpx releases; a variable
+// for tests.
+var releasesAPI = "https://api.github.com/repos/ozacod/cpx/releases"
+
+// upgradePublicKey is the base64 ed25519 public key the checksums of
+// release binaries are signed with. Release builds set it with
+// -ldflags "-X github.com/ozacod/cpx/internal/app/cli.upgradePublicKey=...";
+// without it only the checksums are verified.
+var upgradePublicKey = ""
+
+// githubRelease is the part of a GitHub release cpx upgrade uses.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	HTMLURL string `json:"html_url"`
+	Body    string `json:"body"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// version returns the cpx version a release contains. Nightly releases
+// share the "nightly" tag and carry the version in their name.
+func (r *githubRelease) version(channel string) string {
+	if channel == channelNightly {
+		return r.Name
+	}
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// assetURL returns the download URL of the release asset called name.
+func (r *githubRelease) assetURL(name string) (string, error) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL, nil
+		}
+	}
+	return "", fmt.Errorf("release %s has no %s", r.TagName, name)
+}
+
 func UpgradeCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "upgrade",
 		Short: "Upgrade cpx to the latest version",
-		Long:  "Upgrade cpx to the latest version from GitHub releases.",
-		RunE:  runUpgrade,
+		Long: `Upgrade cpx to the latest version from GitHub releases.
+
+The changelog of the new version is shown before it is installed. The
+downloaded binary is checked against the release's checksums.txt, whose
+ed25519 signature is verified in release builds of cpx. The replaced
+binary is kept, and --rollback restores it.
+
+--channel nightly follows the nightly builds of the main branch; the
+choice is remembered in the global config.`,
+		Example: `  cpx upgrade                    # Latest stable release
+  cpx upgrade --channel nightly  # Switch to nightly builds
+  cpx upgrade --channel stable   # Back to stable releases
+  cpx upgrade --rollback         # Restore the previous binary`,
+		Args: cobra.NoArgs,
+		RunE: runUpgrade,
 	}
+	cmd.Flags().String("channel", "", "Release channel: stable or nightly (default: the configured channel, stable)")
+	cmd.Flags().BoolP("yes", "y", false, "Install without asking for confirmation")
+	cmd.Flags().Bool("rollback", false, "Restore the cpx binary replaced by the last upgrade")
 
 	vcpkgCmd := &cobra.Command{
 		Use:   "vcpkg",
@@ -35,133 +104,308 @@ func UpgradeCmd() *cobra.Command {
 	return cmd
 }
 
-func runUpgrade(_ *cobra.Command, args []string) error {
-	Upgrade(args)
-	return nil
+func runUpgrade(cmd *cobra.Command, _ []string) error {
+	channel, _ := cmd.Flags().GetString("channel")
+	yes, _ := cmd.Flags().GetBool("yes")
+	rollback, _ := cmd.Flags().GetBool("rollback")
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(execPath); err == nil {
+		execPath = resolved
+	}
+
+	if rollback {
+		if err := rollbackUpgrade(execPath); err != nil {
+			return err
+		}
+		output.Successf("✓ Restored the previous cpx binary")
+		output.Infof("  Run %scpx version%s to check it.", colors.Cyan, colors.Reset)
+		return nil
+	}
+
+	channel, err = upgradeChannel(channel)
+	if err != nil {
+		return err
+	}
+	return Upgrade(execPath, channel, yes)
+}
+
+// upgradeChannel validates channel and remembers it in the global config,
+// or returns the configured channel when channel is empty.
+func upgradeChannel(channel string) (string, error) {
+	cfg, loadErr := config.LoadGlobal()
+	if loadErr != nil {
+		// a config this cpx cannot read, e.g. with keys of a newer cpx, must
+		// not keep it from upgrading; the channel is not remembered then
+		cfg = &config.GlobalConfig{}
+	}
+	if channel == "" {
+		if cfg.UpgradeChannel != "" {
+			return cfg.UpgradeChannel, nil
+		}
+		return channelStable, nil
+	}
+	if channel != channelStable && channel != channelNightly {
+		return "", fmt.Errorf("unknown channel %q (use %s or %s)", channel, channelStable, channelNightly)
+	}
+	if loadErr == nil && cfg.UpgradeChannel != channel {
+		cfg.UpgradeChannel = channel
+		if err := config.SaveGlobal(cfg); err != nil {
+			return "", fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+	return channel, nil
 }
 
-func Upgrade(_ []string) {
-	fmt.Printf("%s Checking for updates...%s\n", colors.Cyan, colors.Reset)
+// Upgrade replaces the cpx binary at execPath with the latest release of
+// channel, after showing its changelog and, unless yes, asking to confirm.
+func Upgrade(execPath, channel string, yes bool) error {
+	output.Stepf(" Checking for updates (%s)...", channel)
+
+	release, err := fetchRelease(channel)
+	if err != nil {
+		return err
+	}
+	if release == nil {
+		output.Warnf("No %s release found.", channel)
+		return nil
+	}
+
+	latestVersion := release.version(channel)
+	if latestVersion == Version {
+		output.Successf(" You're already running the latest version (%s)", Version)
+		return nil
+	}
+
+	output.Infof("%s New version available: %s → %s%s", colors.Yellow, Version, latestVersion, colors.Reset)
+	output.Infof("   Release: %s", release.HTMLURL)
+	printChangelog(release)
+
+	if !yes && stdinIsTerminal() {
+		fmt.Printf("Install cpx %s? [Y/n] ", latestVersion)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "" && a != "y" && a != "yes" {
+			output.Infof("Upgrade cancelled")
+			return nil
+		}
+	}
+
+	binaryName, err := upgradeBinaryName(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	binaryData, err := downloadReleaseAsset(release, binaryName)
+	if err != nil {
+		return err
+	}
+	if err := verifyReleaseBinary(release, binaryName, binaryData); err != nil {
+		return err
+	}
+
+	if err := installBinary(execPath, binaryData); err != nil {
+		return err
+	}
+	output.Successf(" Successfully upgraded to %s!", latestVersion)
+	output.Infof("  Run %scpx version%s to verify, or %scpx upgrade --rollback%s to go back.", colors.Cyan, colors.Reset, colors.Cyan, colors.Reset)
+	return nil
+}
 
-	// Get latest version from GitHub releases API
-	resp, err := http.Get("https://api.github.com/repos/ozacod/cpx/releases/latest")
+// fetchRelease returns the newest release of channel, or nil when there is
+// none.
+func fetchRelease(channel string) (*githubRelease, error) {
+	url := releasesAPI + "/latest"
+	if channel == channelNightly {
+		url = releasesAPI + "/tags/nightly"
+	}
+	resp, err := http.Get(url)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to check for updates: %v\n", colors.Red, colors.Reset, err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		fmt.Printf("%s  No releases found. This may be the first version.%s\n", colors.Yellow, colors.Reset)
-		fmt.Printf("   Repository: https://github.com/ozacod/cpx\n")
-		return
+		return nil, nil
 	}
-
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to check for updates (status %d): %s\n", colors.Red, colors.Reset, resp.StatusCode, string(body))
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to check for updates (status %d): %s", resp.StatusCode, string(body))
 	}
 
-	var release struct {
-		TagName string `json:"tag_name"`
-		HTMLURL string `json:"html_url"`
-	}
+	var release githubRelease
 	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to parse release info: %v\n", colors.Red, colors.Reset, err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to parse release info: %w", err)
 	}
+	return &release, nil
+}
 
-	latestVersion := strings.TrimPrefix(release.TagName, "v")
-	currentVersion := Version
+// maxChangelogLines bounds the changelog printed before upgrading.
+const maxChangelogLines = 30
 
-	if latestVersion == currentVersion {
-		fmt.Printf("%s You're already running the latest version (%s)%s\n", colors.Green, currentVersion, colors.Reset)
+// printChangelog prints the release notes of release.
+func printChangelog(release *githubRelease) {
+	body := strings.TrimSpace(strings.ReplaceAll(release.Body, "\r\n", "\n"))
+	if body == "" {
 		return
 	}
+	w := output.Stdout()
+	fmt.Fprintf(w, "\n%sChangelog%s\n", colors.Bold, colors.Reset)
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if i == maxChangelogLines {
+			fmt.Fprintf(w, "  %s... %d more lines at %s%s\n", colors.Gray, len(lines)-i, release.HTMLURL, colors.Reset)
+			break
+		}
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	fmt.Fprintln(w)
+}
 
-	fmt.Printf("%s New version available: %s  %s%s\n", colors.Yellow, currentVersion, latestVersion, colors.Reset)
-	fmt.Printf("   Release: %s\n", release.HTMLURL)
-
-	// Determine platform and architecture
-	goos := runtime.GOOS
-	goarch := runtime.GOARCH
-
-	var binaryName string
+// upgradeBinaryName returns the release asset name of the cpx binary for a
+// platform.
+func upgradeBinaryName(goos, goarch string) (string, error) {
 	switch goos {
-	case "darwin":
-		binaryName = fmt.Sprintf("cpx-darwin-%s", goarch)
-	case "linux":
-		binaryName = fmt.Sprintf("cpx-linux-%s", goarch)
+	case "darwin", "linux":
+		return fmt.Sprintf("cpx-%s-%s", goos, goarch), nil
 	case "windows":
-		binaryName = fmt.Sprintf("cpx-windows-%s.exe", goarch)
+		return fmt.Sprintf("cpx-windows-%s.exe", goarch), nil
 	default:
-		fmt.Fprintf(os.Stderr, "%sError:%s Unsupported platform: %s\n", colors.Red, colors.Reset, goos)
-		os.Exit(1)
+		return "", fmt.Errorf("unsupported platform: %s", goos)
 	}
+}
 
-	downloadURL := fmt.Sprintf("https://github.com/ozacod/cpx/releases/download/%s/%s", release.TagName, binaryName)
-	fmt.Printf("%s Downloading %s...%s\n", colors.Cyan, binaryName, colors.Reset)
-
-	// Download the new binary
-	resp, err = http.Get(downloadURL)
+// downloadReleaseAsset downloads the asset called name of release.
+func downloadReleaseAsset(release *githubRelease, name string) ([]byte, error) {
+	url, err := release.assetURL(name)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to download: %v\n", colors.Red, colors.Reset, err)
-		os.Exit(1)
+		return nil, err
+	}
+	output.Stepf(" Downloading %s...", name)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
 	}
 	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		fmt.Fprintf(os.Stderr, "%sError:%s Download failed with status %d\n", colors.Red, colors.Reset, resp.StatusCode)
-		os.Exit(1)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download of %s failed with status %d", name, resp.StatusCode)
 	}
-
-	binaryData, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to read download: %v\n", colors.Red, colors.Reset, err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
 	}
+	return data, nil
+}
 
-	// Get current executable path
-	execPath, err := os.Executable()
+// verifyReleaseBinary checks data against the release's checksums.txt
+// and, when cpx was built with upgradePublicKey, the signature of that
+// file in checksums.txt.sig.
+func verifyReleaseBinary(release *githubRelease, binaryName string, data []byte) error {
+	checksums, err := downloadReleaseAsset(release, "checksums.txt")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to get executable path: %v\n", colors.Red, colors.Reset, err)
-		os.Exit(1)
-	}
-	execPath, _ = filepath.EvalSymlinks(execPath)
-
-	// Write to temp file first
-	tempPath := execPath + ".new"
-	if err := os.WriteFile(tempPath, binaryData, 0755); err != nil {
-		// Try writing to temp directory instead
-		tempPath = filepath.Join(os.TempDir(), "cpx-new")
-		if err := os.WriteFile(tempPath, binaryData, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "%sError:%s Failed to write binary: %v\n", colors.Red, colors.Reset, err)
-			os.Exit(1)
+		return fmt.Errorf("cannot verify the download: %w", err)
+	}
+	if upgradePublicKey != "" {
+		signature, err := downloadReleaseAsset(release, "checksums.txt.sig")
+		if err != nil {
+			return fmt.Errorf("cannot verify the download: %w", err)
 		}
-		fmt.Printf("%s Downloaded to %s%s\n", colors.Green, tempPath, colors.Reset)
-		fmt.Printf("\nTo complete the upgrade, run:\n")
-		fmt.Printf("  sudo mv %s %s\n", tempPath, execPath)
-		return
+		if err := verifyChecksumsSignature(checksums, signature, upgradePublicKey); err != nil {
+			return err
+		}
+	} else {
+		output.Warnf("This cpx build has no release signing key; only the checksum is verified")
+	}
+	return verifyChecksum(checksums, binaryName, data)
+}
+
+// verifyChecksumsSignature checks the ed25519 signature of checksums.
+// The signature may be raw or base64 encoded.
+func verifyChecksumsSignature(checksums, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release signing key in this cpx build")
+	}
+	if len(signature) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+		if err != nil {
+			return fmt.Errorf("malformed checksums.txt.sig")
+		}
+		signature = decoded
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, signature) {
+		return fmt.Errorf("signature verification of checksums.txt failed; not installing the download")
+	}
+	return nil
+}
+
+// verifyChecksum checks data against the sha256sum line for name in
+// checksums.
+func verifyChecksum(checksums []byte, name string, data []byte) error {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum mismatch for %s; not installing the download", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("checksums.txt has no entry for %s", name)
+}
+
+// installBinary replaces the binary at execPath with data and keeps the
+// old one as <execPath>.previous for --rollback.
+func installBinary(execPath string, data []byte) error {
+	newPath := execPath + ".new"
+	if err := os.WriteFile(newPath, data, 0755); err != nil {
+		// Not writable: leave the last step to the user
+		tempPath := filepath.Join(os.TempDir(), "cpx-new")
+		if err := os.WriteFile(tempPath, data, 0755); err != nil {
+			return fmt.Errorf("failed to write binary: %w", err)
+		}
+		return fmt.Errorf("%s is not writable; the verified binary was saved to %s\n  hint: complete the upgrade with: sudo mv %s %s", filepath.Dir(execPath), tempPath, tempPath, execPath)
 	}
 
-	// Remove old binary and rename new one
-	os.Remove(execPath)
-	if err := os.Rename(tempPath, execPath); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to replace binary: %v\n", colors.Red, colors.Reset, err)
-		fmt.Printf("\nTo complete manually, run:\n")
-		fmt.Printf("  sudo mv %s %s\n", tempPath, execPath)
-		os.Exit(1)
+	previous := execPath + ".previous"
+	_ = os.Remove(previous)
+	// Renaming works for running binaries, also on Windows
+	if err := os.Rename(execPath, previous); err != nil {
+		_ = os.Remove(newPath)
+		return fmt.Errorf("failed to keep the current binary: %w", err)
 	}
+	if err := os.Rename(newPath, execPath); err != nil {
+		_ = os.Rename(previous, execPath)
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
+	return nil
+}
 
-	fmt.Printf("%s Successfully upgraded to %s!%s\n", colors.Green, latestVersion, colors.Reset)
-	fmt.Printf("  Run %scpx version%s to verify.\n", colors.Cyan, colors.Reset)
+// rollbackUpgrade swaps the binary at execPath with the one the last
+// upgrade replaced, so a second rollback undoes the first.
+func rollbackUpgrade(execPath string) error {
+	previous := execPath + ".previous"
+	if _, err := os.Stat(previous); err != nil {
+		return fmt.Errorf("no previous cpx binary to restore (%s not found)", previous)
+	}
+	swap := execPath + ".rollback"
+	if err := os.Rename(execPath, swap); err != nil {
+		return fmt.Errorf("failed to move the current binary: %w", err)
+	}
+	if err := os.Rename(previous, execPath); err != nil {
+		_ = os.Rename(swap, execPath)
+		return fmt.Errorf("failed to restore the previous binary: %w", err)
+	}
+	return os.Rename(swap, previous)
 }
 
 // runUpgradeVcpkg updates vcpkg by running git pull in its directory
 func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
-	// Load global config to get vcpkg root
-	cfg, err := config.LoadGlobal()
+	// Load the config to get vcpkg root, as overridden by the project
+	cfg, err := projectConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -173,7 +417,7 @@ func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
 	}
 
 	if vcpkgRoot == "" {
-		return fmt.Errorf("vcpkg root not configured. Run 'cpx config set-vcpkg-root <path>' or set VCPKG_ROOT environment variable")
+		return fmt.Errorf("vcpkg root not configured. Run 'cpx setup vcpkg', 'cpx config set-vcpkg-root <path>' or set VCPKG_ROOT environment variable")
 	}
 
 	// Check if directory exists
@@ -187,7 +431,7 @@ func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("vcpkg directory is not a git repository: %s", vcpkgRoot)
 	}
 
-	fmt.Printf("%s Updating vcpkg in %s...%s\n", colors.Cyan, vcpkgRoot, colors.Reset)
+	output.Stepf(" Updating vcpkg in %s...", vcpkgRoot)
 
 	// Run git pull
 	cmd := exec.Command("git", "pull")
@@ -200,7 +444,7 @@ func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
 	}
 
 	// Run bootstrap to ensure vcpkg binary is up to date
-	fmt.Printf("%s Running bootstrap...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf(" Running bootstrap...")
 
 	var bootstrapCmd *exec.Cmd
 	if runtime.GOOS == "windows" {
@@ -213,9 +457,9 @@ func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
 	bootstrapCmd.Stderr = os.Stderr
 
 	if err := bootstrapCmd.Run(); err != nil {
-		fmt.Printf("%s Bootstrap failed (vcpkg may still work): %v%s\n", colors.Yellow, err, colors.Reset)
+		output.Warnf("Bootstrap failed (vcpkg may still work): %v", err)
 	}
 
-	fmt.Printf("%s vcpkg updated successfully!%s\n", colors.Green, colors.Reset)
+	output.Successf(" vcpkg updated successfully!")
 	return nil
 }
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			fmt.Fprint(w, `{"tag_name": "v1.3.0", "name": "v1.3.0", "body": "- faster builds"}`)
+		case "/releases/tags/nightly":
+			fmt.Fprint(w, `{"tag_name": "nightly", "name": "nightly-20261018-abc1234"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	oldAPI := releasesAPI
+	defer func() { releasesAPI = oldAPI }()
+	releasesAPI = server.URL + "/releases"
+
+	release, err := fetchRelease(channelStable)
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0", release.version(channelStable))
+	assert.Equal(t, "- faster builds", release.Body)
+
+	release, err = fetchRelease(channelNightly)
+	require.NoError(t, err)
+	assert.Equal(t, "nightly-20261018-abc1234", release.version(channelNightly))
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("cpx binary")
+	sum := sha256.Sum256(data)
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  cpx-linux-amd64\n" + "00  cpx-darwin-arm64\n")
+
+	assert.NoError(t, verifyChecksum(checksums, "cpx-linux-amd64", data))
+	assert.Error(t, verifyChecksum(checksums, "cpx-linux-amd64", []byte("tampered")))
+	assert.Error(t, verifyChecksum(checksums, "cpx-windows-amd64.exe", data))
+}
+
+func TestVerifyChecksumsSignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key := base64.StdEncoding.EncodeToString(public)
+	checksums := []byte("abc  cpx-linux-amd64\n")
+	signature := ed25519.Sign(private, checksums)
+
+	assert.NoError(t, verifyChecksumsSignature(checksums, signature, key))
+	assert.NoError(t, verifyChecksumsSignature(checksums, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), key))
+	assert.Error(t, verifyChecksumsSignature([]byte("def  cpx-linux-amd64\n"), signature, key))
+	assert.Error(t, verifyChecksumsSignature(checksums, signature, "not-a-key"))
+}
+
+func TestInstallBinaryAndRollback(t *testing.T) {
+	execPath := filepath.Join(t.TempDir(), "cpx")
+	require.NoError(t, os.WriteFile(execPath, []byte("old"), 0755))
+
+	require.NoError(t, installBinary(execPath, []byte("new")))
+	assertFile := func(path, want string) {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+	}
+	assertFile(execPath, "new")
+	assertFile(execPath+".previous", "old")
+
+	require.NoError(t, rollbackUpgrade(execPath))
+	assertFile(execPath, "old")
+	assertFile(execPath+".previous", "new")
+
+	require.NoError(t, os.Remove(execPath+".previous"))
+	assert.Error(t, rollbackUpgrade(execPath))
+}
+
+func TestUpgradeChannel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	channel, err := upgradeChannel("")
+	require.NoError(t, err)
+	assert.Equal(t, channelStable, channel)
+
+	_, err = upgradeChannel(channelNightly)
+	require.NoError(t, err)
+	channel, err = upgradeChannel("")
+	require.NoError(t, err)
+	assert.Equal(t, channelNightly, channel)
+
+	_, err = upgradeChannel("beta")
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/project"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// emsdkImage is used for wasm toolchains when emcc is not installed locally.
+const emsdkImage = "emscripten/emsdk:latest"
+
+// wasmOutputDir is where wasm toolchains put the .wasm/.js artifacts of the
+// project in root.
+func wasmOutputDir(root string) string {
+	return filepath.Join(config.DirsOf(root).Bin, "wasm")
+}
+
+// wasmCrossFile is the Meson cross file for Emscripten.
+const wasmCrossFile = `[binaries]
+c = 'emcc'
+cpp = 'em++'
+ar = 'emar'
+ranlib = 'emranlib'
+exe_wrapper = 'node'
+
+[host_machine]
+system = 'emscripten'
+cpu_family = 'wasm32'
+cpu = 'wasm32'
+endian = 'little'
+`
+
+// wasmBuild describes a wasm build. Paths are as seen by the build, i.e.
+// inside the container for docker builds.
+type wasmBuild struct {
+	Meson     bool
+	Toolchain config.Toolchain
+	MesonArgs []string
+	SourceDir string
+	CacheDir  string // holds the build directory, cross file and Emscripten cache
+	OutputDir string
+	RunTests  bool
+	Execute   bool
+}
+
+func (w wasmBuild) buildDir() string {
+	return w.CacheDir + "/" + w.Toolchain.Name
+}
+
+// script returns the shell script that configures, builds and collects the
+// artifacts of the project with Emscripten.
+func (w wasmBuild) script() string {
+	tc := w.Toolchain
+	buildType := tc.BuildType
+	if buildType == "" {
+		buildType = "Release"
+	}
+	optLevel := tc.Optimization
+	if optLevel == "" {
+		optLevel = "2"
+	}
+	buildDir := w.buildDir()
+
+	var s strings.Builder
+	s.WriteString("set -e\n")
+	fmt.Fprintf(&s, "export EM_CACHE=%q\n", w.CacheDir+"/emcache")
+	fmt.Fprintf(&s, "mkdir -p %q %q\n", buildDir, w.OutputDir)
+
+	if w.Meson {
+		mesonBuildType := "release"
+		if strings.EqualFold(buildType, "Debug") {
+			mesonBuildType = "debug"
+		}
+		setupArgs := []string{"--cross-file", w.CacheDir + "/emscripten.ini", "--buildtype=" + mesonBuildType}
+		setupArgs = append(setupArgs, w.MesonArgs...)
+		fmt.Fprintf(&s, "if [ -f %q ]; then\n", buildDir+"/build.ninja")
+		fmt.Fprintf(&s, "    meson setup --reconfigure %s %s %s\n", buildDir, w.SourceDir, strings.Join(setupArgs, " "))
+		s.WriteString("else\n")
+		fmt.Fprintf(&s, "    meson setup %s %s %s\n", buildDir, w.SourceDir, strings.Join(setupArgs, " "))
+		s.WriteString("fi\n")
+		compileArgs := []string{"-C", buildDir}
+		if tc.Jobs > 0 {
+			compileArgs = append(compileArgs, "-j", fmt.Sprintf("%d", tc.Jobs))
+		}
+		compileArgs = append(compileArgs, tc.BuildOptions...)
+		fmt.Fprintf(&s, "meson compile %s\n", strings.Join(compileArgs, " "))
+		if w.RunTests {
+			fmt.Fprintf(&s, "meson test -C %s --print-errorlogs\n", buildDir)
+		}
+	} else {
+		cmakeArgs := []string{
+			"-GNinja",
+			"-B", buildDir,
+			"-S", w.SourceDir,
+			"-DCMAKE_BUILD_TYPE=" + buildType,
+			"-DCMAKE_CXX_FLAGS=-O" + optLevel,
+		}
+		if w.RunTests {
+			cmakeArgs = append(cmakeArgs, "-DBUILD_TESTING=ON", "-DENABLE_TESTING=ON")
+		}
+		cmakeArgs = append(cmakeArgs, tc.CMakeOptions...)
+
+		// vcpkg dependencies are built for the wasm32-emscripten triplet,
+		// with Emscripten chain-loaded from the vcpkg toolchain
+		vcpkgArgs := `-DCMAKE_TOOLCHAIN_FILE="$VCPKG_ROOT/scripts/buildsystems/vcpkg.cmake" ` +
+			`-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE="$(dirname "$(which emcc)")/cmake/Modules/Platform/Emscripten.cmake" ` +
+			`-DVCPKG_TARGET_TRIPLET=wasm32-emscripten`
+		s.WriteString("VCPKG_ARGS=\"\"\n")
+		fmt.Fprintf(&s, "if [ -n \"$VCPKG_ROOT\" ] && [ -f %q ]; then\n", w.SourceDir+"/vcpkg.json")
+		fmt.Fprintf(&s, "    VCPKG_ARGS='%s'\n", strings.ReplaceAll(vcpkgArgs, "'", `'\''`))
+		s.WriteString("fi\n")
+		fmt.Fprintf(&s, "eval emcmake cmake %s $VCPKG_ARGS\n", strings.Join(cmakeArgs, " "))
+
+		buildArgs := []string{"--build", buildDir}
+		if tc.Jobs > 0 {
+			buildArgs = append(buildArgs, "--parallel", fmt.Sprintf("%d", tc.Jobs))
+		}
+		buildArgs = append(buildArgs, tc.BuildOptions...)
+		fmt.Fprintf(&s, "cmake %s\n", strings.Join(buildArgs, " "))
+		if w.RunTests {
+			// Emscripten.cmake sets node as the cross-compiling emulator
+			fmt.Fprintf(&s, "ctest --test-dir %s --output-on-failure\n", buildDir)
+		}
+	}
+
+	fmt.Fprintf(&s, `find %s -maxdepth 2 -type f \( -name "*.wasm" -o -name "*.js" -o -name "*.html" -o -name "*.css" -o -name "*.data" \) `+
+		`! -path "*/CMakeFiles/*" ! -path "*.p/*" ! -path "*/meson-*" -exec cp {} %s/ \;`+"\n", buildDir, w.OutputDir)
+
+	if w.Execute {
+		fmt.Fprintf(&s, `for js in %s/*.js; do
+    if [ -f "${js%%.js}.wasm" ]; then
+        echo "  Executing: node $js"
+        node "$js"
+        break
+    fi
+done
+`, w.OutputDir)
+	}
+	return s.String()
+}
+
+// runWasmBuild builds a toolchain of type wasm. It uses the runner's docker
+// image if one is configured, otherwise a local emcc, falling back to the
+// official emsdk image.
+func runWasmBuild(tc config.Toolchain, runner *config.Runner, projectRoot string, env map[string]string, mesonArgs []string, options ToolchainBuildOptions) error {
+	w := wasmBuild{
+		Toolchain: tc,
+		MesonArgs: mesonArgs,
+		RunTests:  options.RunTests,
+		Execute:   options.ExecuteAfterBuild,
+	}
+	if project.Detect(projectRoot) == project.Meson {
+		w.Meson = true
+	} else if _, err := os.Stat(filepath.Join(projectRoot, "CMakeLists.txt")); err != nil {
+		return fmt.Errorf("wasm toolchains support CMake and Meson projects")
+	}
+
+	cacheDir := filepath.Join(config.DirsOf(projectRoot).Cache, "wasm")
+	outputDir := wasmOutputDir(projectRoot)
+	for _, dir := range []string{cacheDir, outputDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	if w.Meson {
+		if err := os.WriteFile(filepath.Join(cacheDir, "emscripten.ini"), []byte(wasmCrossFile), 0644); err != nil {
+			return fmt.Errorf("failed to write Meson cross file: %w", err)
+		}
+	}
+
+	image := ""
+	switch {
+	case runner != nil && runner.IsDocker():
+		resolved, err := resolveDockerImageNew(runner, projectRoot, options.Rebuild, options.Verbose)
+		if err != nil {
+			return err
+		}
+		image = resolved
+	case runner != nil && runner.IsSSH():
+		return fmt.Errorf("SSH runners are not supported for wasm toolchains")
+	default:
+		if _, err := execLookPath("emcc"); err != nil {
+			if runner != nil {
+				return fmt.Errorf("emcc not found in PATH\n  hint: install and activate the Emscripten SDK (https://emscripten.org/docs/getting_started/downloads.html)")
+			}
+			image = emsdkImage
+			if err := ensureDockerImage(image); err != nil {
+				return err
+			}
+		}
+	}
+
+	if image == "" {
+		fmt.Fprintf(output.Stdout(), "  %s Building with local Emscripten...%s\n", colors.Cyan, colors.Reset)
+	} else {
+		fmt.Fprintf(output.Stdout(), "  %s Building with Emscripten in %s...%s\n", colors.Cyan, image, colors.Reset)
+	}
+	err := runBuildScript(image, projectRoot, cacheDir, outputDir, env, func(p scriptPaths) string {
+		w.SourceDir, w.CacheDir, w.OutputDir = p.Source, p.Cache, p.Output
+		return w.script()
+	})
+	if err != nil {
+		return fmt.Errorf("wasm build failed: %w", err)
+	}
+
+	fmt.Fprintf(output.Stdout(), "  %s Artifacts are in: %s%s\n", colors.Green, wasmOutputDir("."), colors.Reset)
+	return nil
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWasmBuildScriptCMake(t *testing.T) {
+	w := wasmBuild{
+		Toolchain: config.Toolchain{Name: "wasm", BuildType: "Debug", Jobs: 4, CMakeOptions: []string{"-DFOO=ON"}},
+		SourceDir: "/workspace",
+		CacheDir:  "/tmp/wasm",
+		OutputDir: "/output",
+		RunTests:  true,
+		Execute:   true,
+	}
+	script := w.script()
+
+	assert.Contains(t, script, `export EM_CACHE="/tmp/wasm/emcache"`)
+	assert.Contains(t, script, "eval emcmake cmake -GNinja -B /tmp/wasm/wasm -S /workspace -DCMAKE_BUILD_TYPE=Debug -DCMAKE_CXX_FLAGS=-O2 -DBUILD_TESTING=ON -DENABLE_TESTING=ON -DFOO=ON $VCPKG_ARGS")
+	assert.Contains(t, script, "-DVCPKG_TARGET_TRIPLET=wasm32-emscripten")
+	assert.Contains(t, script, "cmake --build /tmp/wasm/wasm --parallel 4")
+	assert.Contains(t, script, "ctest --test-dir /tmp/wasm/wasm --output-on-failure")
+	assert.Contains(t, script, `-name "*.wasm"`)
+	assert.Contains(t, script, `node "$js"`)
+	assert.NotContains(t, script, "meson setup")
+}
+
+func TestWasmBuildScriptMeson(t *testing.T) {
+	w := wasmBuild{
+		Meson:     true,
+		Toolchain: config.Toolchain{Name: "web", BuildType: "Release"},
+		MesonArgs: []string{"-Dfoo=true"},
+		SourceDir: "/src",
+		CacheDir:  "/cache",
+		OutputDir: "/out",
+	}
+	script := w.script()
+
+	assert.Contains(t, script, "meson setup /cache/web /src --cross-file /cache/emscripten.ini --buildtype=release -Dfoo=true")
+	assert.Contains(t, script, "meson compile -C /cache/web")
+	assert.NotContains(t, script, "meson test")
+	assert.NotContains(t, script, "emcmake")
+	assert.NotContains(t, script, "node")
+}
+
+func TestRunWasmBuildFallsBackToEmsdkImage(t *testing.T) {
+	projectRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectRoot, "meson.build"), []byte("project('web', 'cpp')\n"), 0644))
+
+	oldExecLookPath := execLookPath
+	t.Cleanup(func() { execLookPath = oldExecLookPath })
+	execLookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	calls := mockDockerCommands(t, "")
+
+	tc := config.Toolchain{Name: "wasm", Type: config.ToolchainTypeWasm}
+	err := runWasmBuild(tc, nil, projectRoot, map[string]string{"FOO": "1"}, nil, ToolchainBuildOptions{})
+	require.NoError(t, err)
+
+	// image check (missing) -> pull -> run
+	require.Len(t, *calls, 3)
+	assert.Equal(t, []string{"docker", "pull", emsdkImage}, (*calls)[1])
+	run := (*calls)[2]
+	assert.Equal(t, []string{"docker", "run", "--rm"}, run[:3])
+	assert.Contains(t, run, projectRoot+":/workspace:ro")
+	assert.Contains(t, run, "FOO=1")
+	assert.Contains(t, run, emsdkImage)
+
+	crossFile, err := os.ReadFile(filepath.Join(projectRoot, ".cache", "wasm", "emscripten.ini"))
+	require.NoError(t, err)
+	assert.Contains(t, string(crossFile), "system = 'emscripten'")
+	assert.DirExists(t, filepath.Join(projectRoot, ".bin", "wasm"))
+}
+
+func TestRunWasmBuildRequiresCMakeOrMeson(t *testing.T) {
+	err := runWasmBuild(config.Toolchain{Name: "wasm"}, nil, t.TempDir(), nil, nil, ToolchainBuildOptions{})
+	assert.Error(t, err)
+}
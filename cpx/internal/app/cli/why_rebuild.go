@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ozacod/cpx/internal/pkg/build/bazel"
+	"github.com/ozacod/cpx/internal/pkg/build/explain"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/build/meson"
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/spf13/cobra"
+)
+
+// maxCulprits limits how many files are listed per root cause.
+const maxCulprits = 5
+
+// WhyRebuildCmd creates the why-rebuild command
+func WhyRebuildCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "why-rebuild",
+		Short: "Explain why targets are rebuilt",
+		Long: `Explain why targets were considered dirty and summarize the root causes
+(changed flags, touched headers, modified sources, clock skew, ...).
+
+  - vcpkg/CMake and Meson projects: parses ninja -d explain
+  - Bazel projects: parses bazel --explain --verbose_explanations`,
+		Example: `  cpx why-rebuild                # Explain the debug build
+  cpx why-rebuild --release      # Explain the release build
+  cpx why-rebuild --target app   # Only explain a single target
+  cpx why-rebuild --dry-run      # Do not build, only report (ninja only)`,
+		RunE: runWhyRebuild,
+	}
+
+	cmd.Flags().BoolP("release", "r", false, "Explain the release build variant")
+	cmd.Flags().StringP("opt", "O", "", "Optimization level variant: 0,1,2,3,s,fast")
+	cmd.Flags().String("sanitizer", "", "Sanitizer variant, comma-separated: asan,ubsan,tsan,msan")
+	cmd.Flags().String("target", "", "Only explain a specific target")
+	cmd.Flags().BoolP("dry-run", "n", false, "Report what would be rebuilt without building")
+	cmd.Flags().Bool("verbose", false, "Show raw explanation output")
+
+	_ = cmd.RegisterFlagCompletionFunc("target", completeTargets)
+
+	return cmd
+}
+
+func runWhyRebuild(cmd *cobra.Command, _ []string) error {
+	release, _ := cmd.Flags().GetBool("release")
+	optLevel, _ := cmd.Flags().GetString("opt")
+	target, _ := cmd.Flags().GetString("target")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	sanitizer, err := sanitizerFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	var builder build.BuildSystem
+	switch DetectProjectType() {
+	case ProjectTypeBazel:
+		builder = bazel.New()
+	case ProjectTypeMeson:
+		builder = meson.New()
+	case ProjectTypeVcpkg:
+		builder = vcpkg.New()
+	default:
+		return fmt.Errorf("unsupported project type")
+	}
+
+	explainer, ok := builder.(build.RebuildExplainer)
+	if !ok {
+		return fmt.Errorf("%s does not support rebuild explanations", builder.Name())
+	}
+
+	fmt.Printf("%sCollecting rebuild explanations (%s)...%s\n", colors.Cyan, builder.Name(), colors.Reset)
+	reasons, err := explainer.ExplainRebuild(context.Background(), build.ExplainOptions{
+		Release:   release,
+		OptLevel:  optLevel,
+		Sanitizer: sanitizer,
+		Target:    target,
+		DryRun:    dryRun,
+		Verbose:   verbose,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRebuildSummary(reasons)
+	return nil
+}
+
+func printRebuildSummary(reasons []build.RebuildReason) {
+	if len(reasons) == 0 {
+		fmt.Printf("%s✓ Nothing to rebuild - all targets are up to date%s\n", colors.Green, colors.Reset)
+		return
+	}
+
+	fmt.Printf("\n%s%d target(s) considered dirty%s\n\n", colors.Bold, len(reasons), colors.Reset)
+	fmt.Printf("%sRoot causes:%s\n", colors.Bold, colors.Reset)
+	for _, s := range explain.Summarize(reasons) {
+		color := colors.Yellow
+		if s.Cause == explain.CauseClockSkew {
+			color = colors.Red
+		}
+		fmt.Printf("  %s%-24s%s %d\n", color, s.Cause, colors.Reset, s.Count)
+		for i, c := range s.Culprits {
+			if i == maxCulprits {
+				fmt.Printf("    %s... and %d more%s\n", colors.Gray, len(s.Culprits)-maxCulprits, colors.Reset)
+				break
+			}
+			fmt.Printf("    %s%s%s (%d)\n", colors.Gray, c.Name, colors.Reset, c.Count)
+		}
+		if s.Cause == explain.CauseClockSkew {
+			fmt.Printf("    %shint: some inputs have timestamps in the future; check the system clock%s\n", colors.Gray, colors.Reset)
+		}
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -42,7 +42,7 @@ func runGenerateGitHub(_ *cobra.Command, _ []string) error {
 	if err := generateGitHubActionsWorkflow(); err != nil {
 		return err
 	}
-	fmt.Printf("%s✓ Created GitHub Actions workflow: .github/workflows/ci.yml%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ Created GitHub Actions workflow: .github/workflows/ci.yml")
 	return nil
 }
 
@@ -50,7 +50,7 @@ func runGenerateGitLab(_ *cobra.Command, _ []string) error {
 	if err := generateGitLabCI(); err != nil {
 		return err
 	}
-	fmt.Printf("%s✓ Created GitLab CI configuration: .gitlab-ci.yml%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ Created GitLab CI configuration: .gitlab-ci.yml")
 	return nil
 }
 
@@ -67,8 +67,8 @@ func generateGitHubActionsWorkflow() error {
 	ciConfig, err := config.LoadToolchains(ciConfigPath)
 	outputDir := "out"
 	if err != nil {
-		fmt.Printf("%s Warning: cpx-ci.yaml not found. Creating basic workflow.%s\n", colors.Yellow, colors.Reset)
-		fmt.Printf("  Create cpx-ci.yaml to customize build targets and configuration.\n")
+		output.Warnf("cpx-ci.yaml not found. Creating basic workflow.")
+		output.Infof("  Create cpx-ci.yaml to customize build targets and configuration.")
 	} else {
 		outputDir = ciConfig.GetOutputDir()
 	}
@@ -141,8 +141,8 @@ func generateGitLabCI() error {
 	ciConfig, err := config.LoadToolchains(ciConfigPath)
 	outputDir := "out"
 	if err != nil {
-		fmt.Printf("%s Warning: cpx-ci.yaml not found. Creating basic CI configuration.%s\n", colors.Yellow, colors.Reset)
-		fmt.Printf("  Create cpx-ci.yaml to customize build targets and configuration.\n")
+		output.Warnf("cpx-ci.yaml not found. Creating basic CI configuration.")
+		output.Infof("  Create cpx-ci.yaml to customize build targets and configuration.")
 	} else {
 		outputDir = ciConfig.GetOutputDir()
 	}
@@ -0,0 +1,217 @@
+// Package abi compares the ABI of two builds of a shared library with
+// abidiff (libabigail) or abi-compliance-checker.
+package abi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	execCommand  = exec.Command
+	execLookPath = exec.LookPath
+)
+
+// Supported tools.
+const (
+	ToolAbidiff    = "abidiff"
+	ToolCompliance = "abi-compliance-checker"
+)
+
+// Library is a build of a shared library.
+type Library struct {
+	// Path is the shared library.
+	Path string
+
+	// Headers is the public header directory; when set, only changes to
+	// types reachable from these headers are reported.
+	Headers string
+
+	// Version labels the build in reports.
+	Version string
+}
+
+// Result is the outcome of comparing two builds of a library.
+type Result struct {
+	// Library is the file name of the library.
+	Library string
+
+	// Tool is the tool that compared the builds.
+	Tool string
+
+	// Changed is set when the ABI differs at all.
+	Changed bool
+
+	// Breaking is set when the changes break existing binaries.
+	Breaking bool
+
+	// Report is the tool's report: its output for abidiff, the path of
+	// the HTML report for abi-compliance-checker.
+	Report string
+}
+
+// FindTool returns the tool to compare with: name if given, otherwise the
+// first installed of abidiff and abi-compliance-checker.
+func FindTool(name string) (string, error) {
+	switch name {
+	case "", "auto":
+		if _, err := execLookPath(ToolAbidiff); err == nil {
+			return ToolAbidiff, nil
+		}
+		if _, err := execLookPath(ToolCompliance); err == nil {
+			if _, err := execLookPath("abi-dumper"); err == nil {
+				return ToolCompliance, nil
+			}
+		}
+		return "", fmt.Errorf("no ABI checker found\n  hint: install libabigail (apt install abigail-tools, dnf install libabigail) or abi-compliance-checker and abi-dumper")
+	case ToolAbidiff:
+		if _, err := execLookPath(ToolAbidiff); err != nil {
+			return "", fmt.Errorf("abidiff not found\n  hint: install libabigail (apt install abigail-tools, dnf install libabigail)")
+		}
+		return name, nil
+	case ToolCompliance:
+		for _, tool := range []string{ToolCompliance, "abi-dumper"} {
+			if _, err := execLookPath(tool); err != nil {
+				return "", fmt.Errorf("%s not found\n  hint: install abi-compliance-checker and abi-dumper", tool)
+			}
+		}
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown ABI checker '%s' (supported: %s, %s)", name, ToolAbidiff, ToolCompliance)
+}
+
+// Compare compares the older and newer builds of a library with tool, keeping
+// intermediate files and reports in workDir.
+func Compare(tool string, older, newer Library, workDir string) (*Result, error) {
+	switch tool {
+	case ToolAbidiff:
+		return compareAbidiff(older, newer)
+	case ToolCompliance:
+		return compareCompliance(older, newer, workDir)
+	}
+	return nil, fmt.Errorf("unknown ABI checker '%s'", tool)
+}
+
+func compareAbidiff(older, newer Library) (*Result, error) {
+	var args []string
+	if older.Headers != "" && newer.Headers != "" {
+		args = append(args, "--headers-dir1", older.Headers, "--headers-dir2", newer.Headers)
+	}
+	args = append(args, older.Path, newer.Path)
+
+	cmd := execCommand(ToolAbidiff, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+
+	code := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to run abidiff: %w", err)
+	}
+	changed, breaking, err := abidiffStatus(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, strings.TrimSpace(out.String()))
+	}
+	return &Result{
+		Library:  filepath.Base(newer.Path),
+		Tool:     ToolAbidiff,
+		Changed:  changed,
+		Breaking: breaking,
+		Report:   strings.TrimSpace(out.String()),
+	}, nil
+}
+
+// abidiffStatus decodes abidiff's exit status, a bit field: 1 is an error,
+// 2 a usage error, 4 an ABI change and 8 an incompatible ABI change.
+func abidiffStatus(code int) (changed, breaking bool, err error) {
+	if code&1 != 0 || code&2 != 0 {
+		return false, false, fmt.Errorf("abidiff failed (exit status %d)", code)
+	}
+	return code&4 != 0, code&8 != 0, nil
+}
+
+// complianceRe matches the compatibility verdicts of abi-compliance-checker.
+var complianceRe = regexp.MustCompile(`(Binary|Source) compatibility: ([\d.]+)%`)
+
+func compareCompliance(older, newer Library, workDir string) (*Result, error) {
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", workDir, err)
+	}
+	name := libraryName(newer.Path)
+
+	dump := func(lib Library, label string) (string, error) {
+		path := filepath.Join(workDir, name+"-"+label+".dump")
+		args := []string{lib.Path, "-o", path, "-lver", lib.Version}
+		if lib.Headers != "" {
+			args = append(args, "-public-headers", lib.Headers)
+		}
+		if out, err := execCommand("abi-dumper", args...).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("abi-dumper failed on %s: %w\n%s", lib.Path, err, strings.TrimSpace(string(out)))
+		}
+		return path, nil
+	}
+	oldDump, err := dump(older, "old")
+	if err != nil {
+		return nil, err
+	}
+	newDump, err := dump(newer, "new")
+	if err != nil {
+		return nil, err
+	}
+
+	report := filepath.Join(workDir, name+"-report.html")
+	cmd := execCommand(ToolCompliance, "-l", name, "-old", oldDump, "-new", newDump, "-report-path", report)
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	breaking := false
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// Exit status 1 means incompatible
+		breaking = true
+	default:
+		return nil, fmt.Errorf("abi-compliance-checker failed: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	changed := breaking
+	for _, m := range complianceRe.FindAllStringSubmatch(string(out), -1) {
+		if m[2] != "100" {
+			changed = true
+		}
+	}
+	return &Result{
+		Library:  filepath.Base(newer.Path),
+		Tool:     ToolCompliance,
+		Changed:  changed,
+		Breaking: breaking,
+		Report:   report,
+	}, nil
+}
+
+// libraryName returns the name of a shared library without the lib prefix,
+// extension and version: libfoo.so.1.2 -> foo.
+func libraryName(path string) string {
+	name := LibraryKey(path)
+	name = strings.TrimPrefix(name, "lib")
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// versionSuffixRe matches the version suffixes of shared libraries:
+// .so.1.2 and .1.2.dylib.
+var versionSuffixRe = regexp.MustCompile(`(\.so)(\.\d+)+$|(\.\d+)+(\.dylib)$`)
+
+// LibraryKey identifies a shared library across versions by dropping its
+// version suffix: libfoo.so.1.2 -> libfoo.so.
+func LibraryKey(path string) string {
+	return versionSuffixRe.ReplaceAllString(filepath.Base(path), "$1$4")
+}
@@ -0,0 +1,106 @@
+package abi
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbidiffStatus(t *testing.T) {
+	changed, breaking, err := abidiffStatus(0)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.False(t, breaking)
+
+	changed, breaking, err = abidiffStatus(4)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.False(t, breaking)
+
+	changed, breaking, err = abidiffStatus(12)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, breaking)
+
+	_, _, err = abidiffStatus(1)
+	assert.Error(t, err)
+	_, _, err = abidiffStatus(3)
+	assert.Error(t, err)
+}
+
+func TestLibraryKey(t *testing.T) {
+	assert.Equal(t, "libfoo.so", LibraryKey("/x/libfoo.so.1.2.3"))
+	assert.Equal(t, "libfoo.so", LibraryKey("libfoo.so"))
+	assert.Equal(t, "libfoo.dylib", LibraryKey("libfoo.1.2.dylib"))
+	assert.Equal(t, "libfoo2.so", LibraryKey("libfoo2.so"))
+	assert.Equal(t, "foo", libraryName("lib/libfoo.so.1"))
+}
+
+func TestFindTool(t *testing.T) {
+	old := execLookPath
+	defer func() { execLookPath = old }()
+
+	installed := map[string]bool{}
+	execLookPath = func(file string) (string, error) {
+		if installed[file] {
+			return "/usr/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+
+	_, err := FindTool("auto")
+	assert.ErrorContains(t, err, "no ABI checker found")
+
+	installed[ToolCompliance] = true
+	_, err = FindTool("")
+	assert.Error(t, err, "abi-compliance-checker needs abi-dumper")
+	installed["abi-dumper"] = true
+	tool, err := FindTool("")
+	require.NoError(t, err)
+	assert.Equal(t, ToolCompliance, tool)
+
+	installed[ToolAbidiff] = true
+	tool, err = FindTool("auto")
+	require.NoError(t, err)
+	assert.Equal(t, ToolAbidiff, tool, "abidiff is preferred")
+
+	_, err = FindTool("abi-dumper")
+	assert.ErrorContains(t, err, "unknown ABI checker")
+}
+
+func TestCompareAbidiff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	old := execCommand
+	defer func() { execCommand = old }()
+
+	var args []string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		args = append([]string{name}, arg...)
+		return exec.Command("sh", "-c", "echo 'Functions changes summary: 1 Removed'; exit 12")
+	}
+
+	result, err := Compare(ToolAbidiff,
+		Library{Path: "old/libfoo.so", Headers: "old/include"},
+		Library{Path: "new/libfoo.so", Headers: "new/include"}, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abidiff", "--headers-dir1", "old/include", "--headers-dir2", "new/include", "old/libfoo.so", "new/libfoo.so"}, args)
+	assert.Equal(t, &Result{
+		Library:  "libfoo.so",
+		Tool:     ToolAbidiff,
+		Changed:  true,
+		Breaking: true,
+		Report:   "Functions changes summary: 1 Removed",
+	}, result)
+
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", "echo 'cannot read'; exit 1")
+	}
+	_, err = Compare(ToolAbidiff, Library{Path: "a"}, Library{Path: "b"}, t.TempDir())
+	assert.ErrorContains(t, err, "cannot read")
+}
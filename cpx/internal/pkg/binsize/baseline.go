@@ -0,0 +1,143 @@
+package binsize
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BaselineFile is the checked-in baseline of cpx size, at the project root.
+const BaselineFile = ".size-baseline.json"
+
+// Baseline is the recorded size of each binary, by file name.
+type Baseline struct {
+	Binaries map[string]BinarySize `json:"binaries"`
+}
+
+// BinarySize is the recorded size of a binary and its sections.
+type BinarySize struct {
+	Size     int64            `json:"size"`
+	Sections map[string]int64 `json:"sections"`
+}
+
+// Change is the difference in size of a binary, or of one of its sections,
+// from the baseline.
+type Change struct {
+	Binary string
+	// Section is empty for the binary as a whole.
+	Section string
+	Old     int64
+	New     int64
+	// Regression is set when the growth exceeds the threshold.
+	Regression bool
+}
+
+// Delta returns the growth in bytes.
+func (c Change) Delta() int64 {
+	return c.New - c.Old
+}
+
+// Percent returns the growth relative to the baseline.
+func (c Change) Percent() float64 {
+	if c.Old == 0 {
+		return 100
+	}
+	return 100 * float64(c.New-c.Old) / float64(c.Old)
+}
+
+// NewBaseline records the sizes of reports.
+func NewBaseline(reports []*Report) *Baseline {
+	b := &Baseline{Binaries: map[string]BinarySize{}}
+	for _, r := range reports {
+		size := BinarySize{Size: r.Size, Sections: map[string]int64{}}
+		for _, s := range r.Sections {
+			size.Sections[s.Name] = s.FileSize
+		}
+		b.Binaries[filepath.Base(r.Binary)] = size
+	}
+	return b
+}
+
+// LoadBaseline reads a baseline file.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse size baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to path.
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write size baseline %s: %w", path, err)
+	}
+	return nil
+}
+
+// Update records reports in the baseline, keeping the other binaries.
+func (b *Baseline) Update(reports []*Report) {
+	if b.Binaries == nil {
+		b.Binaries = map[string]BinarySize{}
+	}
+	for name, size := range NewBaseline(reports).Binaries {
+		b.Binaries[name] = size
+	}
+}
+
+// Compare returns the size changes of reports against the baseline: one
+// for each binary in the baseline, followed by the sections that changed.
+// Growth of more than threshold percent is a regression; new sections are
+// not.
+func (b *Baseline) Compare(reports []*Report, threshold float64) []Change {
+	regressed := func(c Change) bool {
+		return c.Old > 0 && c.New > c.Old && c.Percent() > threshold
+	}
+
+	var changes []Change
+	for _, r := range reports {
+		name := filepath.Base(r.Binary)
+		old, ok := b.Binaries[name]
+		if !ok {
+			continue
+		}
+		total := Change{Binary: name, Old: old.Size, New: r.Size}
+		total.Regression = regressed(total)
+		changes = append(changes, total)
+
+		var sections []Change
+		seen := map[string]bool{}
+		for _, s := range r.Sections {
+			seen[s.Name] = true
+			if s.FileSize == old.Sections[s.Name] {
+				continue
+			}
+			c := Change{Binary: name, Section: s.Name, Old: old.Sections[s.Name], New: s.FileSize}
+			c.Regression = regressed(c)
+			sections = append(sections, c)
+		}
+		for section, size := range old.Sections {
+			if !seen[section] && size != 0 {
+				sections = append(sections, Change{Binary: name, Section: section, Old: size})
+			}
+		}
+		sort.Slice(sections, func(i, j int) bool {
+			if sections[i].Delta() != sections[j].Delta() {
+				return sections[i].Delta() > sections[j].Delta()
+			}
+			return sections[i].Section < sections[j].Section
+		})
+		changes = append(changes, sections...)
+	}
+	return changes
+}
@@ -0,0 +1,291 @@
+// Package binsize reports where the bytes of built binaries go, per section
+// and per symbol, and compares binaries against a recorded baseline.
+package binsize
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	execCommand  = exec.Command
+	execLookPath = exec.LookPath
+)
+
+// Entry is the size of a section or symbol.
+type Entry struct {
+	Name     string `json:"name"`
+	FileSize int64  `json:"file_size"`
+	VMSize   int64  `json:"vm_size"`
+}
+
+// Report is the size breakdown of a binary.
+type Report struct {
+	// Binary is the path of the analyzed file.
+	Binary string `json:"binary"`
+
+	// Tool is what produced the breakdown: "bloaty" or the object format.
+	Tool string `json:"tool"`
+
+	// Size is the size of the file.
+	Size int64 `json:"size"`
+
+	// Sections are sorted by file size, largest first.
+	Sections []Entry `json:"sections"`
+
+	// Symbols are the largest symbols, largest first. Stripped binaries
+	// have none.
+	Symbols []Entry `json:"symbols,omitempty"`
+}
+
+// Analyze breaks down the size of the binary at path, listing at most
+// symbols symbols. It uses bloaty when it is installed and reads the object
+// file directly otherwise.
+func Analyze(path string, symbols int) (*Report, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report *Report
+	if _, err := execLookPath("bloaty"); err == nil {
+		report, err = analyzeBloaty(path, symbols)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		report, err = analyzeObject(path, symbols)
+		if err != nil {
+			return nil, err
+		}
+		demangle(report.Symbols)
+	}
+	report.Binary = path
+	report.Size = info.Size()
+	return report, nil
+}
+
+// analyzeBloaty runs bloaty for the sections and symbols of path.
+func analyzeBloaty(path string, symbols int) (*Report, error) {
+	report := &Report{Tool: "bloaty"}
+
+	out, err := runBloaty("sections", 0, path)
+	if err != nil {
+		return nil, err
+	}
+	if report.Sections, err = parseBloatyCSV(out); err != nil {
+		return nil, err
+	}
+
+	if symbols > 0 {
+		out, err := runBloaty("symbols", symbols, path)
+		if err != nil {
+			return nil, err
+		}
+		if report.Symbols, err = parseBloatyCSV(out); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func runBloaty(source string, rows int, path string) ([]byte, error) {
+	cmd := execCommand("bloaty", "--csv", "--demangle=full", "-n", strconv.Itoa(rows), "-d", source, path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("bloaty failed on %s: %s", path, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// parseBloatyCSV parses bloaty's --csv output (name,vmsize,filesize),
+// dropping the rows that lump together what did not fit (-n).
+func parseBloatyCSV(data []byte) ([]Entry, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bloaty output: %w", err)
+	}
+	var entries []Entry
+	for i, rec := range records {
+		if i == 0 || len(rec) < 3 {
+			continue
+		}
+		if strings.HasPrefix(rec[0], "[") && strings.Contains(rec[0], " Others]") {
+			continue
+		}
+		vm, _ := strconv.ParseInt(rec[1], 10, 64)
+		file, _ := strconv.ParseInt(rec[2], 10, 64)
+		entries = append(entries, Entry{Name: rec[0], VMSize: vm, FileSize: file})
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// analyzeObject reads the section and symbol tables of an ELF, Mach-O or PE
+// file.
+func analyzeObject(path string, symbols int) (*Report, error) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		return analyzeELF(f, symbols), nil
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		return analyzeMachO(f, symbols), nil
+	}
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		return analyzePE(f, symbols), nil
+	}
+	return nil, fmt.Errorf("%s is not an ELF, Mach-O or PE binary", path)
+}
+
+func analyzeELF(f *elf.File, symbols int) *Report {
+	report := &Report{Tool: "elf"}
+	for _, s := range f.Sections {
+		if s.Type == elf.SHT_NULL {
+			continue
+		}
+		e := Entry{Name: s.Name}
+		if s.Type != elf.SHT_NOBITS {
+			e.FileSize = int64(s.FileSize)
+		}
+		if s.Flags&elf.SHF_ALLOC != 0 {
+			e.VMSize = int64(s.Size)
+		}
+		report.Sections = append(report.Sections, e)
+	}
+	sortEntries(report.Sections)
+
+	syms, err := f.Symbols()
+	if err != nil {
+		// Stripped binaries keep only the dynamic symbols
+		syms, _ = f.DynamicSymbols()
+	}
+	sizes := map[string]int64{}
+	for _, s := range syms {
+		typ := elf.ST_TYPE(s.Info)
+		if s.Size == 0 || (typ != elf.STT_FUNC && typ != elf.STT_OBJECT) {
+			continue
+		}
+		sizes[s.Name] += int64(s.Size)
+	}
+	report.Symbols = topSymbols(sizes, symbols)
+	return report
+}
+
+func analyzeMachO(f *macho.File, symbols int) *Report {
+	report := &Report{Tool: "macho"}
+	for _, s := range f.Sections {
+		e := Entry{Name: s.Seg + "," + s.Name, VMSize: int64(s.Size)}
+		// Zero-fill sections take no space in the file
+		if s.Offset != 0 {
+			e.FileSize = int64(s.Size)
+		}
+		report.Sections = append(report.Sections, e)
+	}
+	sortEntries(report.Sections)
+
+	if f.Symtab == nil {
+		return report
+	}
+	// Mach-O symbols have no size: a symbol extends to the next one in its
+	// section, or to the end of the section.
+	var syms []macho.Symbol
+	for _, s := range f.Symtab.Syms {
+		if s.Sect > 0 && int(s.Sect) <= len(f.Sections) && s.Type&0x0e == 0x0e {
+			syms = append(syms, s)
+		}
+	}
+	sort.Slice(syms, func(i, j int) bool { return syms[i].Value < syms[j].Value })
+	sizes := map[string]int64{}
+	for i, s := range syms {
+		sect := f.Sections[s.Sect-1]
+		end := sect.Addr + sect.Size
+		if i+1 < len(syms) && syms[i+1].Sect == s.Sect {
+			end = syms[i+1].Value
+		}
+		if end > s.Value {
+			// Mach-O prefixes C symbols with an underscore
+			sizes[strings.TrimPrefix(s.Name, "_")] += int64(end - s.Value)
+		}
+	}
+	report.Symbols = topSymbols(sizes, symbols)
+	return report
+}
+
+func analyzePE(f *pe.File, symbols int) *Report {
+	report := &Report{Tool: "pe"}
+	for _, s := range f.Sections {
+		report.Sections = append(report.Sections, Entry{Name: s.Name, FileSize: int64(s.Size), VMSize: int64(s.VirtualSize)})
+	}
+	sortEntries(report.Sections)
+	// PE images carry no symbol sizes; symbols need bloaty with a PDB
+	return report
+}
+
+// topSymbols returns the n largest symbols.
+func topSymbols(sizes map[string]int64, n int) []Entry {
+	if n <= 0 {
+		return nil
+	}
+	entries := make([]Entry, 0, len(sizes))
+	for name, size := range sizes {
+		entries = append(entries, Entry{Name: name, FileSize: size, VMSize: size})
+	}
+	sortEntries(entries)
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+func sortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].FileSize != entries[j].FileSize {
+			return entries[i].FileSize > entries[j].FileSize
+		}
+		if entries[i].VMSize != entries[j].VMSize {
+			return entries[i].VMSize > entries[j].VMSize
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// demangle replaces C++ symbol names with their demangled form when
+// c++filt is available.
+func demangle(entries []Entry) {
+	if len(entries) == 0 {
+		return
+	}
+	if _, err := execLookPath("c++filt"); err != nil {
+		return
+	}
+	var in bytes.Buffer
+	for _, e := range entries {
+		in.WriteString(e.Name + "\n")
+	}
+	cmd := execCommand("c++filt")
+	cmd.Stdin = &in
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	names := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(names) != len(entries) {
+		return
+	}
+	for i := range entries {
+		entries[i].Name = names[i]
+	}
+}
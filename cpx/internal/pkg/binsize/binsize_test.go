@@ -0,0 +1,87 @@
+package binsize
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBloatyCSV(t *testing.T) {
+	out := "sections,vmsize,filesize\n" +
+		".text,41234,41234\n" +
+		".bss,512,0\n" +
+		"[2 Others],300,280\n" +
+		".rodata,9000,9000\n"
+	entries, err := parseBloatyCSV([]byte(out))
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Name: ".text", VMSize: 41234, FileSize: 41234},
+		{Name: ".rodata", VMSize: 9000, FileSize: 9000},
+		{Name: ".bss", VMSize: 512},
+	}, entries)
+}
+
+func TestAnalyzeObject(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("reads the ELF or Mach-O test binary")
+	}
+	lookPath := execLookPath
+	execLookPath = func(string) (string, error) { return "", errors.New("not found") }
+	defer func() { execLookPath = lookPath }()
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	report, err := Analyze(exe, 5)
+	require.NoError(t, err)
+
+	info, err := os.Stat(exe)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), report.Size)
+	assert.Contains(t, []string{"elf", "macho"}, report.Tool)
+	require.NotEmpty(t, report.Sections)
+	assert.GreaterOrEqual(t, report.Sections[0].FileSize, report.Sections[len(report.Sections)-1].FileSize, "sections are sorted by size")
+	assert.LessOrEqual(t, len(report.Symbols), 5)
+
+	_, err = Analyze(filepath.Join("testdata", "missing"), 5)
+	assert.Error(t, err)
+
+	notBinary := filepath.Join(t.TempDir(), "script.sh")
+	require.NoError(t, os.WriteFile(notBinary, []byte("#!/bin/sh\n"), 0755))
+	_, err = Analyze(notBinary, 5)
+	assert.ErrorContains(t, err, "not an ELF, Mach-O or PE binary")
+}
+
+func TestBaselineCompare(t *testing.T) {
+	old := []*Report{
+		{Binary: ".bin/native/release/app", Size: 1000, Sections: []Entry{{Name: ".text", FileSize: 600}, {Name: ".data", FileSize: 100}, {Name: ".gone", FileSize: 10}}},
+		{Binary: ".bin/native/release/tool", Size: 500},
+	}
+	path := filepath.Join(t.TempDir(), BaselineFile)
+	require.NoError(t, NewBaseline(old).Save(path))
+	baseline, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), baseline.Binaries["app"].Sections[".text"])
+
+	current := []*Report{
+		{Binary: "app", Size: 1080, Sections: []Entry{{Name: ".text", FileSize: 680}, {Name: ".data", FileSize: 100}, {Name: ".new", FileSize: 4}}},
+		{Binary: "tool", Size: 510},
+		{Binary: "unrecorded", Size: 10},
+	}
+	changes := baseline.Compare(current, 5)
+	require.Len(t, changes, 5)
+
+	assert.Equal(t, Change{Binary: "app", Old: 1000, New: 1080, Regression: true}, changes[0])
+	assert.InDelta(t, 8.0, changes[0].Percent(), 0.01)
+	assert.Equal(t, Change{Binary: "app", Section: ".text", Old: 600, New: 680, Regression: true}, changes[1])
+	assert.Equal(t, Change{Binary: "app", Section: ".new", New: 4}, changes[2], "new sections are not regressions")
+	assert.Equal(t, Change{Binary: "app", Section: ".gone", Old: 10}, changes[3])
+	assert.Equal(t, Change{Binary: "tool", Old: 500, New: 510}, changes[4], "2% is under the threshold")
+
+	baseline.Update(current[2:])
+	assert.Len(t, baseline.Binaries, 3)
+}
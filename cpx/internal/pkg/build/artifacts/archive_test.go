@@ -0,0 +1,113 @@
+package artifacts
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarball(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "include", "mylib", "mylib.hpp"), 0644)
+	writeFile(t, filepath.Join(src, "lib", "libmylib.so.1"), 0755)
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.Symlink("libmylib.so.1", filepath.Join(src, "lib", "libmylib.so")))
+	}
+
+	dest := filepath.Join(t.TempDir(), "dist", "mylib-1.0.0.tar.gz")
+	require.NoError(t, Tarball(src, dest, "mylib-1.0.0"))
+
+	f, err := os.Open(dest)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	entries := make(map[string]*tar.Header)
+	contents := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		entries[hdr.Name] = hdr
+		if hdr.Typeflag == tar.TypeReg {
+			data, _ := io.ReadAll(tr)
+			contents[hdr.Name] = string(data)
+		}
+	}
+
+	assert.Contains(t, entries, "mylib-1.0.0/")
+	assert.Contains(t, entries, "mylib-1.0.0/include/mylib/")
+	assert.Equal(t, "mylib.hpp", contents["mylib-1.0.0/include/mylib/mylib.hpp"])
+	assert.Equal(t, int64(0755), entries["mylib-1.0.0/lib/libmylib.so.1"].Mode&0777)
+	assert.Equal(t, 0, entries["mylib-1.0.0/lib/libmylib.so.1"].Uid)
+	if runtime.GOOS != "windows" {
+		link := entries["mylib-1.0.0/lib/libmylib.so"]
+		require.NotNil(t, link)
+		assert.Equal(t, byte(tar.TypeSymlink), link.Typeflag)
+		assert.Equal(t, "libmylib.so.1", link.Linkname)
+	}
+}
+
+func TestTarballSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "lib", "libmylib.a"), 0644)
+
+	// archives of the same files are identical, whenever they are written
+	first := filepath.Join(t.TempDir(), "first.tar.gz")
+	require.NoError(t, Tarball(src, first, "mylib"))
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "lib", "libmylib.a"), later, later))
+	second := filepath.Join(t.TempDir(), "second.tar.gz")
+	require.NoError(t, Tarball(src, second, "mylib"))
+
+	a, err := os.ReadFile(first)
+	require.NoError(t, err)
+	b, err := os.ReadFile(second)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+}
+
+func TestZip(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "bin", "app.exe"), 0755)
+
+	dest := filepath.Join(t.TempDir(), "dist", "app-1.0.0-windows-amd64.zip")
+	require.NoError(t, Zip(src, dest, "app-1.0.0-windows-amd64"))
+
+	zr, err := zip.OpenReader(dest)
+	require.NoError(t, err)
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		assert.False(t, f.Modified.After(time.Unix(1700000000, 0)), f.Name)
+	}
+	assert.Equal(t, []string{"app-1.0.0-windows-amd64/", "app-1.0.0-windows-amd64/bin/", "app-1.0.0-windows-amd64/bin/app.exe"}, names)
+	rc, err := zr.File[2].Open()
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "app.exe", string(data))
+}
+
+func TestTarballMissingSource(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "out.tar.gz")
+	assert.Error(t, Tarball(filepath.Join(t.TempDir(), "missing"), dest, "x"))
+	assert.NoFileExists(t, dest)
+}
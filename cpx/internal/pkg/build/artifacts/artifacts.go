@@ -0,0 +1,266 @@
+// Package artifacts collects build outputs (executables and libraries) from
+// a build tree into cpx's output directories without shelling out.
+package artifacts
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build/events"
+)
+
+// goos is a variable so tests can exercise the Windows rules.
+var goos = runtime.GOOS
+
+// LibraryExtensions are the file extensions of static and shared libraries.
+var LibraryExtensions = []string{".a", ".so", ".dylib", ".lib", ".dll"}
+
+// Rule selects files below Dir to collect.
+type Rule struct {
+	// Dir is the directory to search. A missing Dir is not an error.
+	Dir string
+	// Depth is how many directory levels to search; 1 searches Dir only.
+	Depth int
+	// Executables selects executable files (.exe files on Windows).
+	Executables bool
+	// Extensions selects files with these extensions, e.g. ".a".
+	Extensions []string
+	// Skip lists filepath.Match patterns for file names to ignore.
+	Skip []string
+}
+
+// matches reports whether a regular file with the given name and mode is
+// selected by the rule.
+func (r Rule) matches(name string, mode os.FileMode) bool {
+	for _, pattern := range r.Skip {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return false
+		}
+	}
+	for _, ext := range r.Extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	// Shared libraries are often executable, but are not executables
+	if r.Executables && LibraryType(name) == "" {
+		if goos == "windows" {
+			return strings.EqualFold(filepath.Ext(name), ".exe")
+		}
+		return mode&0111 != 0
+	}
+	return false
+}
+
+// Find returns the files selected by the rules, sorted and deduplicated.
+// Symlinks are followed when deciding whether a file matches.
+func Find(rules ...Rule) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, r := range rules {
+		depth := r.Depth
+		if depth < 1 {
+			depth = 1
+		}
+		if err := walk(r.Dir, depth, func(path string, info os.FileInfo) {
+			if !seen[path] && r.matches(info.Name(), info.Mode()) {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// walk calls fn for each regular file up to depth levels below dir,
+// following symlinks. A missing dir is skipped.
+func walk(dir string, depth int, fn func(path string, info os.FileInfo)) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			// dangling symlink
+			continue
+		}
+		if info.IsDir() {
+			if depth > 1 {
+				if err := walk(path, depth-1, fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if info.Mode().IsRegular() {
+			fn(path, info)
+		}
+	}
+	return nil
+}
+
+// Collect copies the files selected by the rules into destDir, which is
+// created if needed, and returns the names of the copied files.
+func Collect(destDir string, rules ...Rule) ([]string, error) {
+	files, err := Find(rules...)
+	if err != nil {
+		return nil, err
+	}
+	return collectFiles(destDir, files)
+}
+
+// LibraryType returns "static" or "shared" for the file name of a library,
+// and "" for any other file. Versioned shared libraries (libfoo.so.1) are
+// shared; a .lib file is static unless it is the import library of a DLL,
+// which only CollectLibraries can tell.
+func LibraryType(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".a"), strings.HasSuffix(name, ".lib"):
+		return "static"
+	case strings.HasSuffix(name, ".so"), strings.Contains(name, ".so."),
+		strings.HasSuffix(name, ".dylib"), strings.HasSuffix(name, ".dll"):
+		return "shared"
+	}
+	return ""
+}
+
+// CollectLibraries copies the libraries selected by the rules into the
+// "static" and "shared" subdirectories of destDir, so both types of a
+// library can be kept side by side. When libraryType is set, only libraries
+// of that type are copied. It returns the copied files relative to destDir.
+func CollectLibraries(destDir, libraryType string, rules ...Rule) ([]string, error) {
+	files, err := Find(rules...)
+	if err != nil {
+		return nil, err
+	}
+	dlls := make(map[string]bool)
+	for _, file := range files {
+		if strings.HasSuffix(file, ".dll") {
+			dlls[strings.TrimSuffix(file, ".dll")] = true
+		}
+	}
+	byType := make(map[string][]string)
+	for _, file := range files {
+		typ := LibraryType(filepath.Base(file))
+		if strings.HasSuffix(file, ".lib") && dlls[strings.TrimSuffix(file, ".lib")] {
+			typ = "shared"
+		}
+		if typ != "" && (libraryType == "" || typ == libraryType) {
+			byType[typ] = append(byType[typ], file)
+		}
+	}
+
+	var copied []string
+	for _, typ := range []string{"shared", "static"} {
+		if len(byType[typ]) == 0 {
+			continue
+		}
+		names, err := collectFiles(filepath.Join(destDir, typ), byType[typ])
+		for _, name := range names {
+			copied = append(copied, filepath.Join(typ, name))
+		}
+		if err != nil {
+			return copied, err
+		}
+	}
+	return copied, nil
+}
+
+// collectFiles copies files into destDir along with the targets of their
+// versioned library symlinks.
+func collectFiles(destDir string, files []string) ([]string, error) {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", destDir, err)
+	}
+	queued := make(map[string]bool)
+	for _, file := range files {
+		queued[file] = true
+	}
+	var names []string
+	for i := 0; i < len(files); i++ {
+		file := files[i]
+		name := filepath.Base(file)
+		if err := CopyFile(file, filepath.Join(destDir, name)); err != nil {
+			return names, err
+		}
+		names = append(names, name)
+		// keep versioned library symlinks (libfoo.so -> libfoo.so.1) valid
+		if target, err := os.Readlink(file); err == nil && isSibling(target) {
+			targetPath := filepath.Join(filepath.Dir(file), target)
+			if !queued[targetPath] {
+				queued[targetPath] = true
+				files = append(files, targetPath)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CopyFile copies src to dest, replacing dest. The file mode is preserved
+// and made writable by the owner, since Bazel outputs are read-only. A
+// symlink to a file in the same directory (like libfoo.so -> libfoo.so.1)
+// is recreated as a symlink; any other symlink is copied as its target.
+// Each copy is reported as an artifact-written build event.
+func CopyFile(src, dest string) error {
+	if err := copyFile(src, dest); err != nil {
+		return err
+	}
+	events.Artifact(dest)
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	if target, err := os.Readlink(src); err == nil && goos != "windows" && isSibling(target) {
+		_ = os.Remove(dest)
+		if err := os.Symlink(target, dest); err != nil {
+			return fmt.Errorf("failed to copy symlink %s: %w", src, err)
+		}
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", src, err)
+	}
+
+	// Remove first so a read-only or running destination can be replaced
+	_ = os.Remove(dest)
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm()|0200)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	// OpenFile's mode is subject to the umask
+	return os.Chmod(dest, info.Mode().Perm()|0200)
+}
+
+// isSibling reports whether a symlink target names a file in the link's
+// own directory.
+func isSibling(target string) bool {
+	return !filepath.IsAbs(target) && !strings.ContainsAny(target, `/\`)
+}
@@ -0,0 +1,165 @@
+package artifacts
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/build/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, mode os.FileMode) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(filepath.Base(path)), mode))
+	require.NoError(t, os.Chmod(path, mode))
+}
+
+func TestFind(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix permissions")
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "app"), 0755)
+	writeFile(t, filepath.Join(dir, "app.params"), 0755)
+	writeFile(t, filepath.Join(dir, "notes.txt"), 0644)
+	writeFile(t, filepath.Join(dir, "src", "libcore.a"), 0644)
+	writeFile(t, filepath.Join(dir, "src", "deep", "libdeep.a"), 0644)
+
+	files, err := Find(Rule{Dir: dir, Executables: true, Skip: []string{"*.params"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app")}, files)
+
+	files, err = Find(Rule{Dir: dir, Depth: 2, Extensions: LibraryExtensions})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "src", "libcore.a")}, files)
+
+	// missing directories are skipped, duplicates removed
+	files, err = Find(
+		Rule{Dir: filepath.Join(dir, "missing"), Executables: true},
+		Rule{Dir: dir, Executables: true},
+		Rule{Dir: dir, Executables: true},
+	)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestFindWindowsExecutables(t *testing.T) {
+	oldGOOS := goos
+	t.Cleanup(func() { goos = oldGOOS })
+	goos = "windows"
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "app.exe"), 0644)
+	writeFile(t, filepath.Join(dir, "app.pdb"), 0644)
+
+	files, err := Find(Rule{Dir: dir, Executables: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app.exe")}, files)
+}
+
+func TestCollect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix permissions and symlinks")
+	}
+	src := t.TempDir()
+	dest := filepath.Join(t.TempDir(), "out")
+
+	// Bazel outputs are read-only
+	writeFile(t, filepath.Join(src, "app"), 0555)
+	writeFile(t, filepath.Join(src, "libfoo.so.1"), 0644)
+	require.NoError(t, os.Symlink("libfoo.so.1", filepath.Join(src, "libfoo.so")))
+	// a symlink out of the directory, like bazel-bin's links into the execroot
+	writeFile(t, filepath.Join(src, "real", "tool"), 0755)
+	require.NoError(t, os.Symlink(filepath.Join(src, "real", "tool"), filepath.Join(src, "tool")))
+
+	copied, err := Collect(dest, Rule{Dir: src, Executables: true, Extensions: LibraryExtensions})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app", "libfoo.so", "libfoo.so.1", "tool"}, copied)
+
+	info, err := os.Stat(filepath.Join(dest, "app"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	target, err := os.Readlink(filepath.Join(dest, "libfoo.so"))
+	require.NoError(t, err)
+	assert.Equal(t, "libfoo.so.1", target)
+
+	info, err = os.Lstat(filepath.Join(dest, "tool"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+
+	// copying again replaces the read-only files
+	_, err = Collect(dest, Rule{Dir: src, Executables: true})
+	assert.NoError(t, err)
+}
+
+func TestCopyFileEmitsArtifactEvent(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app")
+	writeFile(t, src, 0755)
+
+	var buf bytes.Buffer
+	events.Enable(&buf)
+	defer events.Disable()
+
+	dest := filepath.Join(dir, "out")
+	require.NoError(t, CopyFile(src, dest))
+	assert.Contains(t, buf.String(), `"reason":"artifact-written"`)
+	assert.Contains(t, buf.String(), `"path":"`+dest+`"`)
+}
+
+func TestLibraryType(t *testing.T) {
+	assert.Equal(t, "static", LibraryType("libfoo.a"))
+	assert.Equal(t, "static", LibraryType("foo.lib"))
+	assert.Equal(t, "shared", LibraryType("libfoo.so"))
+	assert.Equal(t, "shared", LibraryType("libfoo.so.1.2"))
+	assert.Equal(t, "shared", LibraryType("libfoo.1.dylib"))
+	assert.Equal(t, "shared", LibraryType("foo.dll"))
+	assert.Equal(t, "", LibraryType("app"))
+	assert.Equal(t, "", LibraryType("app.exe"))
+}
+
+func TestCollectLibraries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses symlinks")
+	}
+	src := t.TempDir()
+	dest := filepath.Join(t.TempDir(), "out")
+
+	writeFile(t, filepath.Join(src, "app"), 0755)
+	writeFile(t, filepath.Join(src, "libfoo.a"), 0644)
+	writeFile(t, filepath.Join(src, "libfoo.so.1"), 0755)
+	require.NoError(t, os.Symlink("libfoo.so.1", filepath.Join(src, "libfoo.so")))
+	// an import library belongs with its DLL
+	writeFile(t, filepath.Join(src, "bar.dll"), 0644)
+	writeFile(t, filepath.Join(src, "bar.lib"), 0644)
+	writeFile(t, filepath.Join(src, "baz.lib"), 0644)
+
+	rule := Rule{Dir: src, Extensions: LibraryExtensions}
+	copied, err := CollectLibraries(dest, "", rule)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("shared", "bar.dll"),
+		filepath.Join("shared", "bar.lib"),
+		filepath.Join("shared", "libfoo.so"),
+		filepath.Join("shared", "libfoo.so.1"),
+		filepath.Join("static", "baz.lib"),
+		filepath.Join("static", "libfoo.a"),
+	}, copied)
+	assert.FileExists(t, filepath.Join(dest, "static", "libfoo.a"))
+	assert.FileExists(t, filepath.Join(dest, "shared", "libfoo.so"))
+
+	copied, err = CollectLibraries(filepath.Join(t.TempDir(), "out"), "static", rule)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("static", "baz.lib"), filepath.Join("static", "libfoo.a")}, copied)
+
+	// shared libraries are not executables
+	files, err := Find(Rule{Dir: src, Executables: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(src, "app")}, files)
+}
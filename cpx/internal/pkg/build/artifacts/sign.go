@@ -0,0 +1,200 @@
+package artifacts
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runCommand runs a signing tool and returns its combined output, a
+// variable for tests.
+var runCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// CodesignArgs returns the codesign arguments signing path with a Developer
+// ID identity: with the hardened runtime and a secure timestamp, which
+// notarization requires, and the entitlements plist if there is one.
+func CodesignArgs(identity, entitlements, path string) []string {
+	args := []string{"--force", "--sign", identity, "--options", "runtime", "--timestamp"}
+	if entitlements != "" {
+		args = append(args, "--entitlements", entitlements)
+	}
+	return append(args, path)
+}
+
+// Codesign signs the macOS binary at path with identity.
+func Codesign(identity, entitlements, path string) error {
+	if out, err := runCommand("codesign", CodesignArgs(identity, entitlements, path)...); err != nil {
+		return fmt.Errorf("codesign of %s failed: %w\n%s", path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Notarize submits the signed binaries in dir to Apple's notary service
+// with the notarytool credentials stored as profile, and waits for the
+// verdict. Tickets are stapled to the app bundles, disk images and
+// installer packages in dir; Gatekeeper looks up the ticket of bare
+// executables online.
+func Notarize(profile, dir string) error {
+	tmp, err := os.MkdirTemp("", "cpx-notarize-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	zip := filepath.Join(tmp, filepath.Base(dir)+".zip")
+	if out, err := runCommand("ditto", "-c", "-k", "--keepParent", dir, zip); err != nil {
+		return fmt.Errorf("failed to zip %s for notarization: %w\n%s", dir, err, strings.TrimSpace(string(out)))
+	}
+	if out, err := runCommand("xcrun", "notarytool", "submit", zip, "--keychain-profile", profile, "--wait"); err != nil || !strings.Contains(string(out), "status: Accepted") {
+		return fmt.Errorf("notarization of %s was not accepted\n%s\n  hint: xcrun notarytool log <submission id> --keychain-profile %s shows why", dir, strings.TrimSpace(string(out)), profile)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		switch filepath.Ext(e.Name()) {
+		case ".app", ".dmg", ".pkg":
+			path := filepath.Join(dir, e.Name())
+			if out, err := runCommand("xcrun", "stapler", "staple", path); err != nil {
+				return fmt.Errorf("failed to staple the ticket to %s: %w\n%s", path, err, strings.TrimSpace(string(out)))
+			}
+		}
+	}
+	return nil
+}
+
+// machOMagics are the first four bytes of Mach-O files, thin and universal,
+// read as big endian.
+var machOMagics = map[uint32]bool{
+	0xfeedface: true, 0xcefaedfe: true, // 32-bit
+	0xfeedfacf: true, 0xcffaedfe: true, // 64-bit
+	0xcafebabe: true, // universal
+}
+
+// MachOFiles returns the Mach-O executables and libraries below dir, the
+// files codesign signs.
+func MachOFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		var magic uint32
+		if err := binary.Read(f, binary.BigEndian, &magic); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		if machOMagics[magic] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// DefaultTimestampURL is the RFC 3161 time stamp server Windows signatures
+// are countersigned by, so they stay valid after the certificate expires.
+const DefaultTimestampURL = "http://timestamp.digicert.com"
+
+// Authenticode is a certificate to sign Windows binaries with: one in the
+// Windows certificate store, by SHA-1 thumbprint, or a PFX file.
+type Authenticode struct {
+	Thumbprint   string
+	PFX          string
+	Password     string // of the PFX file
+	TimestampURL string
+}
+
+func (a Authenticode) timestampURL() string {
+	if a.TimestampURL == "" {
+		return DefaultTimestampURL
+	}
+	return a.TimestampURL
+}
+
+// SigntoolArgs returns the signtool arguments signing files with SHA-256
+// digests and a time stamp.
+func (a Authenticode) SigntoolArgs(files []string) []string {
+	args := []string{"sign", "/fd", "sha256", "/tr", a.timestampURL(), "/td", "sha256"}
+	if a.Thumbprint != "" {
+		args = append(args, "/sha1", a.Thumbprint)
+	} else {
+		args = append(args, "/f", a.PFX)
+		if a.Password != "" {
+			args = append(args, "/p", a.Password)
+		}
+	}
+	return append(args, files...)
+}
+
+// OsslsigncodeArgs returns the osslsigncode arguments signing file with the
+// PFX file into out, the way SigntoolArgs does.
+func (a Authenticode) OsslsigncodeArgs(file, out string) []string {
+	args := []string{"sign", "-pkcs12", a.PFX}
+	if a.Password != "" {
+		args = append(args, "-pass", a.Password)
+	}
+	return append(args, "-h", "sha256", "-ts", a.timestampURL(), "-in", file, "-out", out)
+}
+
+// SignWindows signs the Windows binaries files: with signtool on Windows,
+// elsewhere with osslsigncode, which only takes PFX files.
+func SignWindows(cert Authenticode, files []string) error {
+	if goos == "windows" {
+		if out, err := runCommand("signtool", cert.SigntoolArgs(files)...); err != nil {
+			return fmt.Errorf("signtool failed: %w\n%s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	if cert.PFX == "" {
+		return fmt.Errorf("signing with a certificate from the Windows certificate store needs Windows\n  hint: sign with a pfx file, which osslsigncode can use on %s", goos)
+	}
+	for _, file := range files {
+		signed := file + ".signed"
+		if out, err := runCommand("osslsigncode", cert.OsslsigncodeArgs(file, signed)...); err != nil {
+			_ = os.Remove(signed)
+			return fmt.Errorf("osslsigncode of %s failed: %w\n%s", file, err, strings.TrimSpace(string(out)))
+		}
+		if err := os.Rename(signed, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PEFiles returns the Windows executables and DLLs below dir, the files
+// SignWindows signs.
+func PEFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".exe", ".dll":
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, nil
+}
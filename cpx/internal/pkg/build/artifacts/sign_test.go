@@ -0,0 +1,118 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordCommands replaces runCommand with one recording the commands and
+// answering with reply.
+func recordCommands(t *testing.T, reply func(cmd string) ([]byte, error)) *[]string {
+	t.Helper()
+	var commands []string
+	old := runCommand
+	t.Cleanup(func() { runCommand = old })
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		cmd := name + " " + strings.Join(args, " ")
+		commands = append(commands, cmd)
+		return reply(cmd)
+	}
+	return &commands
+}
+
+func TestCodesignArgs(t *testing.T) {
+	assert.Equal(t, []string{"--force", "--sign", "Developer ID Application: A (T1)", "--options", "runtime", "--timestamp", "bin/app"},
+		CodesignArgs("Developer ID Application: A (T1)", "", "bin/app"))
+	assert.Equal(t, []string{"--force", "--sign", "X", "--options", "runtime", "--timestamp", "--entitlements", "app.plist", "bin/app"},
+		CodesignArgs("X", "app.plist", "bin/app"))
+}
+
+func TestNotarize(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mac")
+	writeFile(t, filepath.Join(dir, "app"), 0755)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "App.app"), 0755))
+
+	accepted := "Processing complete\n  id: 42\n  status: Accepted\n"
+	commands := recordCommands(t, func(cmd string) ([]byte, error) {
+		if strings.Contains(cmd, "notarytool") {
+			return []byte(accepted), nil
+		}
+		return nil, nil
+	})
+	require.NoError(t, Notarize("release", dir))
+	require.Len(t, *commands, 3)
+	assert.Contains(t, (*commands)[0], "ditto -c -k --keepParent "+dir)
+	assert.Regexp(t, `^xcrun notarytool submit .*mac\.zip --keychain-profile release --wait$`, (*commands)[1])
+	assert.Equal(t, "xcrun stapler staple "+filepath.Join(dir, "App.app"), (*commands)[2])
+
+	accepted = "  status: Invalid\n"
+	err := Notarize("release", dir)
+	assert.ErrorContains(t, err, "was not accepted")
+	assert.ErrorContains(t, err, "status: Invalid")
+}
+
+func TestMachOFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0755))
+	}
+	write("app", []byte{0xcf, 0xfa, 0xed, 0xfe, 7, 0, 0, 1})
+	write("lib/libx.dylib", []byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 2})
+	write("app.exe", []byte("MZ\x90\x00"))
+	write("README", []byte("hi"))
+
+	files, err := MachOFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app"), filepath.Join(dir, "lib", "libx.dylib")}, files)
+}
+
+func TestAuthenticodeArgs(t *testing.T) {
+	store := Authenticode{Thumbprint: "ab12"}
+	assert.Equal(t, []string{"sign", "/fd", "sha256", "/tr", DefaultTimestampURL, "/td", "sha256", "/sha1", "ab12", "a.exe", "b.dll"},
+		store.SigntoolArgs([]string{"a.exe", "b.dll"}))
+
+	pfx := Authenticode{PFX: "cert.pfx", Password: "secret", TimestampURL: "http://ts"}
+	assert.Equal(t, []string{"sign", "/fd", "sha256", "/tr", "http://ts", "/td", "sha256", "/f", "cert.pfx", "/p", "secret", "a.exe"},
+		pfx.SigntoolArgs([]string{"a.exe"}))
+	assert.Equal(t, []string{"sign", "-pkcs12", "cert.pfx", "-pass", "secret", "-h", "sha256", "-ts", "http://ts", "-in", "a.exe", "-out", "a.exe.signed"},
+		pfx.OsslsigncodeArgs("a.exe", "a.exe.signed"))
+}
+
+func TestSignWindows(t *testing.T) {
+	oldGOOS := goos
+	t.Cleanup(func() { goos = oldGOOS })
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "app.exe"), 0755)
+	writeFile(t, filepath.Join(dir, "lib", "core.DLL"), 0644)
+	writeFile(t, filepath.Join(dir, "README.txt"), 0644)
+	files, err := PEFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "app.exe"), filepath.Join(dir, "lib", "core.DLL")}, files)
+
+	// osslsigncode writes the signed copy next to the file
+	goos = "linux"
+	commands := recordCommands(t, func(cmd string) ([]byte, error) {
+		args := strings.Fields(cmd)
+		return nil, os.WriteFile(args[len(args)-1], []byte("signed"), 0644)
+	})
+	require.NoError(t, SignWindows(Authenticode{PFX: "cert.pfx"}, files))
+	assert.Len(t, *commands, 2)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, "signed", string(data))
+	assert.NoFileExists(t, files[0]+".signed")
+
+	assert.ErrorContains(t, SignWindows(Authenticode{Thumbprint: "ab12"}, files), "needs Windows")
+
+	goos = "windows"
+	*commands = nil
+	require.NoError(t, SignWindows(Authenticode{Thumbprint: "ab12"}, files))
+	require.Len(t, *commands, 1)
+	assert.True(t, strings.HasPrefix((*commands)[0], "signtool sign /fd sha256"))
+}
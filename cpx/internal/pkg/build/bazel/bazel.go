@@ -5,15 +5,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/build/progress"
 	"github.com/ozacod/cpx/internal/pkg/templates"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
 var execCommand = exec.Command
@@ -86,7 +94,7 @@ func (b *Builder) ensureBCRPath() error {
 		}
 	}
 
-	return fmt.Errorf("bazel Central Registry not configured\n  hint: run 'cpx config set-bcr-root <path>' or reinstall cpx")
+	return fmt.Errorf("bazel Central Registry not configured\n  hint: run 'cpx bcr sync' or 'cpx config set-bcr-root <path>'")
 }
 
 // getModulesDir returns the path to the modules directory
@@ -192,57 +200,25 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 	// Build args
 	bazelArgs := []string{"build"}
 
-	// Handle optimization level - optLevel takes precedence over release flag
-	var optLabel string
-	switch opts.OptLevel {
-	case "0":
-		bazelArgs = append(bazelArgs, "--copt=-O0", "-c", "dbg")
-		optLabel = "-O0 (debug)"
-	case "1":
-		bazelArgs = append(bazelArgs, "--copt=-O1", "-c", "opt")
-		optLabel = "-O1"
-	case "2":
-		bazelArgs = append(bazelArgs, "--copt=-O2", "-c", "opt")
-		optLabel = "-O2"
-	case "3":
-		bazelArgs = append(bazelArgs, "--copt=-O3", "-c", "opt")
-		optLabel = "-O3"
-	case "s":
-		bazelArgs = append(bazelArgs, "--copt=-Os", "-c", "opt")
-		optLabel = "-Os (size)"
-	case "fast":
-		bazelArgs = append(bazelArgs, "--copt=-Ofast", "-c", "opt")
-		optLabel = "-Ofast"
-	default:
-		// No explicit opt level, use release/debug config
-		if opts.Release {
-			bazelArgs = append(bazelArgs, "--config=release")
-			optLabel = "release"
-		} else {
-			bazelArgs = append(bazelArgs, "--config=debug")
-			optLabel = "debug"
-		}
+	configArgs, optLabel := configFlags(opts.Release, opts.OptLevel, opts.Sanitizer)
+	bazelArgs = append(bazelArgs, configArgs...)
+	bazelArgs = append(bazelArgs, dynamicModeFlags(opts.LibraryType)...)
+	if opts.Jobs > 0 {
+		bazelArgs = append(bazelArgs, "--jobs="+strconv.Itoa(opts.Jobs))
 	}
-
-	// Add sanitizer flags
-	if opts.Sanitizer != "" {
-		switch opts.Sanitizer {
-		case "asan":
-			bazelArgs = append(bazelArgs, "--copt=-fsanitize=address", "--copt=-fno-omit-frame-pointer",
-				"--linkopt=-fsanitize=address")
-			optLabel += "+asan"
-		case "tsan":
-			bazelArgs = append(bazelArgs, "--copt=-fsanitize=thread", "--linkopt=-fsanitize=thread")
-			optLabel += "+tsan"
-		case "msan":
-			bazelArgs = append(bazelArgs, "--copt=-fsanitize=memory", "--copt=-fno-omit-frame-pointer",
-				"--linkopt=-fsanitize=memory")
-			optLabel += "+msan"
-		case "ubsan":
-			bazelArgs = append(bazelArgs, "--copt=-fsanitize=undefined", "--linkopt=-fsanitize=undefined")
-			optLabel += "+ubsan"
-		}
+	if opts.PCH != nil && !*opts.PCH {
+		// Only the templates' force-included header can be turned off
+		bazelArgs = append(bazelArgs, "--define=pch=off")
+	}
+	for _, d := range opts.Defines {
+		bazelArgs = append(bazelArgs, "--copt=-D"+d)
+	}
+	if opts.Reproducible {
+		// Bazel compiles with relative paths and redacts __DATE__ and
+		// __TIME__ already; leave out build stamps and pass the epoch on
+		bazelArgs = append(bazelArgs, "--nostamp", "--action_env=SOURCE_DATE_EPOCH")
 	}
+	bazelArgs = append(bazelArgs, opts.ExtraArgs...)
 
 	// Add target or default to //...
 	if opts.Target != "" {
@@ -251,20 +227,25 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 		bazelArgs = append(bazelArgs, "//...")
 	}
 
-	fmt.Printf("%sBuilding with Bazel [%s]...%s\n", colors.Cyan, optLabel, colors.Reset)
+	output.Stepf("Building with Bazel [%s]...", optLabel)
 	if opts.Verbose {
-		fmt.Printf("  Running: bazel %v\n", bazelArgs)
+		fmt.Fprintf(output.Stdout(), "  Running: bazel %v\n", bazelArgs)
 	} else {
-		// Suppress progress bars for cleaner output (like vcpkg)
+		// Plain "[n / m]" progress lines feed the progress bar (like vcpkg)
 		// Use hidden symlinks (.bazel-bin, .bazel-out, etc.)
-		bazelArgs = append(bazelArgs, "--noshow_progress", "--symlink_prefix=.bazel-")
+		bazelArgs = append(bazelArgs, "--curses=no", "--color=no", "--show_progress_rate_limit=0.25", "--symlink_prefix=.bazel-")
 	}
 
 	buildCmd := execCommand("bazel", bazelArgs...)
-	buildCmd.Stdout = os.Stdout
-	buildCmd.Stderr = os.Stderr
-
-	if err := buildCmd.Run(); err != nil {
+	var err error
+	if opts.Verbose {
+		buildCmd.Stdout = buildlog.Stdout()
+		buildCmd.Stderr = buildlog.Stderr()
+		err = buildCmd.Run()
+	} else {
+		err = progress.Run(buildCmd, "Compiling", 1, 1)
+	}
+	if err != nil {
 		return fmt.Errorf("bazel build failed: %w", err)
 	}
 
@@ -275,7 +256,7 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 	} else if opts.Release {
 		outDirName = "release"
 	}
-	outputDir := filepath.Join(".bin", "native", outDirName)
+	outputDir := filepath.Join(config.BinDir(), "native", outDirName)
 
 	// Copy artifacts to build/<config>/ directory
 	// Remove existing build artifacts for this config first
@@ -285,62 +266,165 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 	}
 
 	// Copy executables and libraries from bazel-bin to build/<config>/
-	fmt.Printf("%sCopying artifacts to %s/...%s\n", colors.Cyan, outputDir, colors.Reset)
-
-	// Create a script to copy with the correct output directory variable
-	script := fmt.Sprintf(`
-		# Find the bazel-bin symlink (prefer hidden .bazel-bin)
-		BAZEL_BIN=""
-		if [ -L ".bazel-bin" ] || [ -d ".bazel-bin" ]; then
-			BAZEL_BIN=".bazel-bin"
-		elif [ -L "bazel-bin" ] || [ -d "bazel-bin" ]; then
-			BAZEL_BIN="bazel-bin"
-		elif [ -L ".bin" ] || [ -d ".bin" ]; then
-			BAZEL_BIN=".bin"
-		fi
-
-		if [ -z "$BAZEL_BIN" ]; then
-			echo "No bazel-bin found"
-			exit 0
-		fi
-
-		# Copy executables from src/ directory (where cc_binary targets are placed)
-		find -L "$BAZEL_BIN/src" -maxdepth 1 -type f -perm +111 ! -name "*.params" ! -name "*.sh" ! -name "*.cppmap" ! -name "*.repo_mapping" ! -name "*runfiles*" ! -name "*.d" -exec cp -f {} %[1]s/ \; 2>/dev/null || true
-
-		# Also copy from root of bazel-bin (for root aliases)
-		find -L "$BAZEL_BIN" -maxdepth 1 -type f -perm +111 ! -name "*.params" ! -name "*.sh" ! -name "*.cppmap" ! -name "*.repo_mapping" ! -name "*runfiles*" ! -name "*.d" -exec cp -f {} %[1]s/ \; 2>/dev/null || true
-
-		# Copy libraries from src/
-		find -L "$BAZEL_BIN/src" -maxdepth 1 -type f \( -name "*.a" -o -name "*.so" -o -name "*.dylib" \) -exec cp -f {} %[1]s/ \; 2>/dev/null || true
-
-		# Make copied files writable (Bazel creates read-only files)
-		chmod -R u+w %[1]s/ 2>/dev/null || true
-
-		# List what was copied
-		ls %[1]s/ 2>/dev/null || true
-	`, outputDir)
-
-	copyCmd := execCommand("bash", "-c", script)
-	copyCmd.Stdout = os.Stdout
-	copyCmd.Stderr = os.Stderr
-	_ = copyCmd.Run() // Ignore errors - may have no artifacts
-
-	fmt.Printf("%s✓ Build successful%s\n", colors.Green, colors.Reset)
-	fmt.Printf("  Artifacts in: %s/\n", outputDir)
+	output.Stepf("Copying artifacts to %s/...", outputDir)
+
+	// Find the bazel-bin symlink (prefer hidden .bazel-bin)
+	bazelBin := ""
+	for _, dir := range []string{".bazel-bin", "bazel-bin", ".bin"} {
+		if _, err := os.Stat(dir); err == nil {
+			bazelBin = dir
+			break
+		}
+	}
+	if bazelBin == "" {
+		fmt.Fprintln(output.Stdout(), "No bazel-bin found")
+	} else {
+		skip := []string{"*.params", "*.sh", "*.cppmap", "*.repo_mapping", "*runfiles*", "*.d"}
+		copied, err := artifacts.Collect(outputDir,
+			// Executables from src/ (where cc_binary targets are placed)
+			artifacts.Rule{Dir: filepath.Join(bazelBin, "src"), Executables: true, Skip: skip},
+			// Root of bazel-bin (for root aliases)
+			artifacts.Rule{Dir: bazelBin, Executables: true, Skip: skip},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy artifacts: %w", err)
+		}
+		// Libraries from src/, into static/ and shared/: every cc_library
+		// has both, plus the .so of any cc_shared_library
+		libs, err := artifacts.CollectLibraries(outputDir, opts.LibraryType,
+			artifacts.Rule{Dir: filepath.Join(bazelBin, "src"), Extensions: artifacts.LibraryExtensions},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy artifacts: %w", err)
+		}
+		copied = append(copied, libs...)
+		for _, name := range copied {
+			fmt.Fprintf(output.Stdout(), "  %s\n", name)
+		}
+	}
+
+	output.Successf("✓ Build successful")
+	fmt.Fprintf(output.Stdout(), "  Artifacts in: %s/\n", outputDir)
 	return nil
 }
 
+// dynamicModeFlags returns the Bazel flags that link binaries against the
+// static or the shared form of their cc_library dependencies.
+func dynamicModeFlags(libraryType string) []string {
+	switch libraryType {
+	case build.LibraryStatic:
+		return []string{"--dynamic_mode=off"}
+	case build.LibraryShared:
+		return []string{"--dynamic_mode=fully"}
+	}
+	return nil
+}
+
+// configFlags returns the Bazel flags for the given optimization level,
+// release mode and sanitizer, along with a human-readable label.
+func configFlags(release bool, optLevel, sanitizer string) ([]string, string) {
+	var args []string
+	// Handle optimization level - optLevel takes precedence over release flag
+	var optLabel string
+	switch optLevel {
+	case "0":
+		args = append(args, "--copt=-O0", "-c", "dbg")
+		optLabel = "-O0 (debug)"
+	case "1":
+		args = append(args, "--copt=-O1", "-c", "opt")
+		optLabel = "-O1"
+	case "2":
+		args = append(args, "--copt=-O2", "-c", "opt")
+		optLabel = "-O2"
+	case "3":
+		args = append(args, "--copt=-O3", "-c", "opt")
+		optLabel = "-O3"
+	case "s":
+		args = append(args, "--copt=-Os", "-c", "opt")
+		optLabel = "-Os (size)"
+	case "fast":
+		args = append(args, "--copt=-Ofast", "-c", "opt")
+		optLabel = "-Ofast"
+	default:
+		// No explicit opt level, use release/debug config
+		if release {
+			args = append(args, "--config=release")
+			optLabel = "release"
+		} else {
+			args = append(args, "--config=debug")
+			optLabel = "debug"
+		}
+	}
+
+	// Add sanitizer flags
+	if sanitizer != "" {
+		args = append(args, sanitizerFlags(sanitizer)...)
+		optLabel += "+" + sanitizer
+	}
+
+	return args, optLabel
+}
+
+// sanitizerFlags returns the --copt and --linkopt flags that build with a
+// list of sanitizers.
+func sanitizerFlags(sanitizer string) []string {
+	var args []string
+	cxxFlags, linkerFlags := build.SanitizerFlags(sanitizer)
+	for _, flag := range strings.Fields(cxxFlags) {
+		args = append(args, "--copt="+flag)
+	}
+	for _, flag := range strings.Fields(linkerFlags) {
+		args = append(args, "--linkopt="+flag)
+	}
+	return args
+}
+
 // Test runs the project's tests with the given options.
 func (b *Builder) Test(ctx context.Context, opts build.TestOptions) error {
-	fmt.Printf("%sRunning Bazel tests...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Running Bazel tests...")
 
 	bazelArgs := []string{"test"}
 
 	// Add filter if provided (bazel target pattern)
-	if opts.Filter != "" {
-		bazelArgs = append(bazelArgs, opts.Filter)
+	pattern := opts.Filter
+	if pattern == "" {
+		var tests []string
+		if opts.Pick != nil {
+			tests = bazelQuery("tests(//...)")
+		}
+		var err error
+		if pattern, err = opts.Pick.Choose(tests, "//..."); err != nil {
+			return err
+		}
+	}
+	if opts.Exclude != "" {
+		// Bazel only subtracts target patterns, so list the tests
+		tests, err := build.ExcludeTests(bazelQuery("tests("+pattern+")"), opts.Exclude)
+		if err != nil {
+			return err
+		}
+		bazelArgs = append(bazelArgs, tests...)
 	} else {
-		bazelArgs = append(bazelArgs, "//...")
+		bazelArgs = append(bazelArgs, pattern)
+	}
+	if filter := bazelTagFilters(opts.Labels, opts.ExcludeLabels); filter != "" {
+		bazelArgs = append(bazelArgs, "--test_tag_filters="+filter)
+	}
+	if opts.Timeout > 0 {
+		// Overrides the timeouts of all test sizes, in whole seconds
+		bazelArgs = append(bazelArgs, fmt.Sprintf("--test_timeout=%d", int(math.Ceil(opts.Timeout.Seconds()))))
+	}
+	if opts.RerunFailed {
+		// Bazel caches passing test results, so only failed (and changed)
+		// tests run again; report just the tests that did not pass
+		bazelArgs = append(bazelArgs, "--cache_test_results=auto", "--test_summary=terse")
+	}
+
+	if opts.Sanitizer != "" {
+		bazelArgs = append(bazelArgs, sanitizerFlags(opts.Sanitizer)...)
+		for _, env := range build.SanitizerEnv(opts.Sanitizer) {
+			bazelArgs = append(bazelArgs, "--test_env="+env)
+		}
 	}
 
 	// Add verbose flag
@@ -353,63 +437,39 @@ func (b *Builder) Test(ctx context.Context, opts build.TestOptions) error {
 	}
 
 	testCmd := execCommand("bazel", bazelArgs...)
-	testCmd.Stdout = os.Stdout
-	testCmd.Stderr = os.Stderr
+	testCmd.Stdout = buildlog.Stdout()
+	testCmd.Stderr = buildlog.Stderr()
 
-	if err := testCmd.Run(); err != nil {
+	if err := build.RunTestCommand(testCmd, opts.SessionTimeout); err != nil {
 		return fmt.Errorf("bazel test failed: %w", err)
 	}
 
-	fmt.Printf("%s✓ Tests passed%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ Tests passed")
 	return nil
 }
 
+// bazelTagFilters returns the --test_tag_filters value running tests with
+// any of labels and none of exclude, or "" to run all.
+func bazelTagFilters(labels, exclude []string) string {
+	filters := slices.Clone(labels)
+	for _, tag := range exclude {
+		filters = append(filters, "-"+tag)
+	}
+	return strings.Join(filters, ",")
+}
+
 // Run builds and runs the project's main executable.
 func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 	// Build bazel run args
 	bazelArgs := []string{"run"}
 
-	// Handle optimization level
-	switch opts.OptLevel {
-	case "0":
-		bazelArgs = append(bazelArgs, "--copt=-O0", "-c", "dbg")
-	case "1":
-		bazelArgs = append(bazelArgs, "--copt=-O1", "-c", "opt")
-	case "2":
-		bazelArgs = append(bazelArgs, "--copt=-O2", "-c", "opt")
-	case "3":
-		bazelArgs = append(bazelArgs, "--copt=-O3", "-c", "opt")
-	case "s":
-		bazelArgs = append(bazelArgs, "--copt=-Os", "-c", "opt")
-	case "fast":
-		bazelArgs = append(bazelArgs, "--copt=-Ofast", "-c", "opt")
-	default:
-		if opts.Release {
-			bazelArgs = append(bazelArgs, "--config=release")
-		} else {
-			bazelArgs = append(bazelArgs, "--config=debug")
-		}
-	}
-
-	// Add sanitizer flags
-	if opts.Sanitizer != "" {
-		switch opts.Sanitizer {
-		case "asan":
-			bazelArgs = append(bazelArgs, "--copt=-fsanitize=address", "--copt=-fno-omit-frame-pointer",
-				"--linkopt=-fsanitize=address")
-		case "tsan":
-			bazelArgs = append(bazelArgs, "--copt=-fsanitize=thread", "--linkopt=-fsanitize=thread")
-		case "msan":
-			bazelArgs = append(bazelArgs, "--copt=-fsanitize=memory", "--copt=-fno-omit-frame-pointer",
-				"--linkopt=-fsanitize=memory")
-		case "ubsan":
-			bazelArgs = append(bazelArgs, "--copt=-fsanitize=undefined", "--linkopt=-fsanitize=undefined")
-		}
-	}
+	configArgs, _ := configFlags(opts.Release, opts.OptLevel, opts.Sanitizer)
+	bazelArgs = append(bazelArgs, configArgs...)
 
 	// Add target or try to find one
+	var target string
 	if opts.Target != "" {
-		target := opts.Target
+		target = opts.Target
 		if !strings.HasPrefix(target, "//") && !strings.HasPrefix(target, ":") {
 			target = "//:" + target
 		}
@@ -420,18 +480,28 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 		if err != nil {
 			return fmt.Errorf("no target specified and could not find main target: %w\n  hint: use --target to specify the target", err)
 		}
+		if opts.Pick != nil {
+			if mainTarget, err = opts.Pick.Choose(bazelQuery("kind(cc_binary, //...) except //bench/..."), mainTarget); err != nil {
+				return err
+			}
+		}
+		target = mainTarget
 		bazelArgs = append(bazelArgs, mainTarget)
 	}
 
+	if len(opts.Wrapper) > 0 {
+		bazelArgs = append(bazelArgs, "--run_under="+strings.Join(opts.Wrapper, " "))
+	}
+
 	// Add -- and user args if present
 	if len(opts.Args) > 0 {
 		bazelArgs = append(bazelArgs, "--")
 		bazelArgs = append(bazelArgs, opts.Args...)
 	}
 
-	fmt.Printf("%sRunning with Bazel...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Running with Bazel...")
 	if opts.Verbose {
-		fmt.Printf("  Running: bazel %v\n", bazelArgs)
+		fmt.Fprintf(output.Stdout(), "  Running: bazel %v\n", bazelArgs)
 	} else {
 		// Use hidden symlinks (.bazel-bin, .bazel-out, etc.)
 		bazelArgs = append(bazelArgs, "--noshow_progress", "--symlink_prefix=.bazel-")
@@ -441,8 +511,38 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 	runCmd.Stdout = os.Stdout
 	runCmd.Stderr = os.Stderr
 	runCmd.Stdin = os.Stdin
+	if opts.Sanitizer != "" {
+		// bazel run passes its client environment on to the program
+		runCmd.Env = append(os.Environ(), build.SanitizerEnv(opts.Sanitizer)...)
+	}
+
+	err := build.RunProgram(runCmd, opts.Timeout, opts.DumpOnTimeout)
+	// The bazel client execs the program, so a crash is the program's own
+	if crash := build.CrashOf(runCmd, err); crash != nil && len(opts.Wrapper) == 0 {
+		crash.Exe = bazelBinary(target)
+		crash.Args = append([]string{}, opts.Args...)
+		build.ReportCrash(crash)
+	}
+	return err
+}
 
-	return runCmd.Run()
+// bazelBinary returns the path of the executable a label builds, in the
+// .bazel-bin symlink.
+func bazelBinary(label string) string {
+	pkg, name, _ := strings.Cut(strings.TrimPrefix(label, "//"), ":")
+	if name == "" {
+		name = filepath.Base(pkg)
+	}
+	return filepath.Join(".bazel-bin", pkg, name)
+}
+
+// bazelQuery returns the targets matching expr, or nil if the query fails.
+func bazelQuery(expr string) []string {
+	out, err := execCommand("bazel", "query", expr).Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
 }
 
 // findBazelMainTarget tries to find a cc_binary target in BUILD.bazel
@@ -480,7 +580,7 @@ func findBazelMainTarget() (string, error) {
 
 // Bench runs the project's benchmarks.
 func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
-	fmt.Printf("%sRunning Bazel benchmarks...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Running Bazel benchmarks...")
 
 	target := opts.Target
 	// If no target specified, query for bench targets
@@ -495,19 +595,23 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 				return fmt.Errorf("no benchmark targets found in //bench")
 			}
 		} else {
-			// Use first target from query
-			targets := strings.TrimSpace(string(output))
-			if targets == "" {
+			// Use first target from query, or let the user pick
+			targets := strings.Fields(string(output))
+			if len(targets) == 0 {
 				return fmt.Errorf("no benchmark targets found in //bench")
 			}
-			// Take first target
-			target = strings.Split(targets, "\n")[0]
+			if target, err = opts.Pick.Choose(targets, targets[0]); err != nil {
+				return err
+			}
 		}
 	}
 
-	fmt.Printf("  Running: %s\n", target)
+	fmt.Fprintf(output.Stdout(), "  Running: %s\n", target)
 
 	bazelArgs := []string{"run", target}
+	if len(opts.Wrapper) > 0 {
+		bazelArgs = append(bazelArgs, "--run_under="+strings.Join(opts.Wrapper, " "))
+	}
 
 	if opts.Verbose {
 		bazelArgs = append(bazelArgs, "--verbose_failures")
@@ -524,7 +628,7 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 		return fmt.Errorf("bazel benchmark failed: %w", err)
 	}
 
-	fmt.Printf("%s✓ Benchmarks complete%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ Benchmarks complete")
 	return nil
 }
 
@@ -551,28 +655,31 @@ func findBenchTarget() string {
 
 // Clean removes build artifacts.
 func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
-	fmt.Printf("%sCleaning Bazel project...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Cleaning Bazel project...")
 
 	// Run bazel clean
 	cleanCmd := execCommand("bazel", "clean")
 	cleanCmd.Stdout = os.Stdout
 	cleanCmd.Stderr = os.Stderr
 	if err := cleanCmd.Run(); err != nil {
-		fmt.Printf("%s⚠ bazel clean failed (may not be initialized)%s\n", colors.Yellow, colors.Reset)
+		output.Warnf("bazel clean failed (may not be initialized)")
 	} else {
-		fmt.Printf("%s✓ Ran bazel clean%s\n", colors.Green, colors.Reset)
+		output.Successf("✓ Ran bazel clean")
 	}
 
 	// Remove common build output directory
 	removeDir("build")
 
+	// Remove bin directory (artifacts)
+	removeDir(filepath.Join(config.BinDir(), "native"))
+
 	// Remove Bazel symlinks
 	// We want to remove .bin, .out, .testlogs which are custom symlinks we might have created
 	// And relying on standard bazel clean to remove bazel-*
 	bazelSymlinks := []string{".bin", ".out", ".testlogs"}
 	for _, symlink := range bazelSymlinks {
 		if _, err := os.Lstat(symlink); err == nil {
-			fmt.Printf("%s  Removing %s...%s\n", colors.Cyan, symlink, colors.Reset)
+			output.Stepf("  Removing %s...", symlink)
 			os.RemoveAll(symlink)
 		}
 	}
@@ -583,7 +690,7 @@ func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
 		for _, entry := range entries {
 			matched, _ := filepath.Match("bazel-*", entry.Name())
 			if matched {
-				fmt.Printf("%s  Removing %s...%s\n", colors.Cyan, entry.Name(), colors.Reset)
+				output.Stepf("  Removing %s...", entry.Name())
 				os.RemoveAll(entry.Name())
 			}
 		}
@@ -595,7 +702,7 @@ func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
 		removeDir("external")
 	}
 
-	fmt.Printf("%s✓ Bazel project cleaned%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ Bazel project cleaned")
 	return nil
 }
 
@@ -642,14 +749,14 @@ func (b *Builder) AddDependency(ctx context.Context, name string, version string
 		}
 	}
 
-	fmt.Printf("%s✓ Added %s@%s to MODULE.bazel%s\n", colors.Green, name, version, colors.Reset)
+	output.Successf("✓ Added %s@%s to MODULE.bazel", name, version)
 
 	// Print usage info
-	fmt.Printf("\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
-	fmt.Printf("Add this to your BUILD.bazel:\n\n")
-	fmt.Printf("  deps = [\"@%s//:<target>\"]\n\n", name)
-	fmt.Printf("%s📦 Find more info at:%s\n", colors.Cyan, colors.Reset)
-	fmt.Printf("   https://registry.bazel.build/modules/%s\n\n", name)
+	fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "Add this to your BUILD.bazel:\n\n")
+	fmt.Fprintf(output.Stdout(), "  deps = [\"@%s//:<target>\"]\n\n", name)
+	output.Stepf("📦 Find more info at:")
+	fmt.Fprintf(output.Stdout(), "   https://registry.bazel.build/modules/%s\n\n", name)
 
 	return nil
 }
@@ -666,11 +773,15 @@ func (b *Builder) RemoveDependency(ctx context.Context, name string) error {
 	pattern := regexp.MustCompile(fmt.Sprintf(`\n?bazel_dep\s*\(\s*name\s*=\s*"%s"[^)]*\)\n?`, regexp.QuoteMeta(name)))
 	newContent := pattern.ReplaceAll(content, []byte(""))
 
+	// And the override of a path dependency
+	override := regexp.MustCompile(fmt.Sprintf(`\n?local_path_override\s*\(\s*module_name\s*=\s*"%s"[^)]*\)\n?`, regexp.QuoteMeta(name)))
+	newContent = override.ReplaceAll(newContent, []byte("\n"))
+
 	if err := os.WriteFile(modulePath, newContent, 0644); err != nil {
 		return fmt.Errorf("failed to write MODULE.bazel: %w", err)
 	}
 
-	fmt.Printf("%s✓ Removed %s from MODULE.bazel%s\n", colors.Green, name, colors.Reset)
+	output.Successf("✓ Removed %s from MODULE.bazel", name)
 	return nil
 }
 
@@ -799,9 +910,9 @@ func (b *Builder) ListTargets(ctx context.Context) ([]string, error) {
 
 func removeDir(path string) {
 	if _, err := os.Stat(path); err == nil {
-		fmt.Printf("%s  Removing %s...%s\n", colors.Cyan, path, colors.Reset)
+		output.Stepf("  Removing %s...", path)
 		if err := os.RemoveAll(path); err != nil {
-			fmt.Printf("%s⚠ Failed to remove %s: %v%s\n", colors.Yellow, path, err, colors.Reset)
+			output.Warnf("Failed to remove %s: %v", path, err)
 		}
 	}
 }
@@ -825,18 +936,24 @@ func (b *Builder) GenerateBuildSrc(ctx context.Context, projectPath string, conf
 
 	// Generate root BUILD.bazel (aliases)
 	buildBazel := templates.GenerateBuildBazelRoot(config.Name, !config.IsLibrary)
+	if config.HeaderOnly {
+		buildBazel = templates.GenerateHeaderOnlyBuildBazelRoot(config.Name)
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "BUILD.bazel"), []byte(buildBazel), 0644); err != nil {
 		return fmt.Errorf("failed to write BUILD.bazel: %w", err)
 	}
 
-	if err := os.MkdirAll(filepath.Join(projectPath, "src"), 0755); err != nil {
-		return fmt.Errorf("failed to create src directory: %w", err)
-	}
+	// Header-only libraries have no src/
+	if !config.HeaderOnly {
+		if err := os.MkdirAll(filepath.Join(projectPath, "src"), 0755); err != nil {
+			return fmt.Errorf("failed to create src directory: %w", err)
+		}
 
-	// Generate src/BUILD.bazel
-	srcBuild := templates.GenerateBuildBazelSrc(config.Name, !config.IsLibrary)
-	if err := os.WriteFile(filepath.Join(projectPath, "src/BUILD.bazel"), []byte(srcBuild), 0644); err != nil {
-		return fmt.Errorf("failed to write src/BUILD.bazel: %w", err)
+		// Generate src/BUILD.bazel
+		srcBuild := templates.GenerateBuildBazelSrc(config.Name, !config.IsLibrary, config.SharedLibrary, config.PCH)
+		if err := os.WriteFile(filepath.Join(projectPath, "src/BUILD.bazel"), []byte(srcBuild), 0644); err != nil {
+			return fmt.Errorf("failed to write src/BUILD.bazel: %w", err)
+		}
 	}
 
 	if err := os.MkdirAll(filepath.Join(projectPath, "include"), 0755); err != nil {
@@ -845,6 +962,9 @@ func (b *Builder) GenerateBuildSrc(ctx context.Context, projectPath string, conf
 
 	// Generate include/BUILD.bazel
 	includeBuild := templates.GenerateBuildBazelInclude(config.Name)
+	if config.HeaderOnly {
+		includeBuild = templates.GenerateHeaderOnlyBuildBazelInclude(config.Name)
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "include/BUILD.bazel"), []byte(includeBuild), 0644); err != nil {
 		return fmt.Errorf("failed to write include/BUILD.bazel: %w", err)
 	}
@@ -876,6 +996,9 @@ func (b *Builder) GenerateBuildTest(ctx context.Context, projectPath string, con
 
 	// Generate tests/BUILD.bazel
 	testsBuild := templates.GenerateBuildBazelTests(config.Name, config.TestFramework)
+	if config.HeaderOnly {
+		testsBuild = templates.LinkHeaderOnlyBazel(testsBuild, config.Name)
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "tests/BUILD.bazel"), []byte(testsBuild), 0644); err != nil {
 		return fmt.Errorf("failed to write tests/BUILD.bazel: %w", err)
 	}
@@ -894,6 +1017,9 @@ func (b *Builder) GenerateBuildBench(ctx context.Context, projectPath string, co
 
 	// Generate bench/BUILD.bazel
 	benchBuild := templates.GenerateBuildBazelBench(config.Name, config.Benchmark)
+	if config.HeaderOnly {
+		benchBuild = templates.LinkHeaderOnlyBazel(benchBuild, config.Name)
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "bench/BUILD.bazel"), []byte(benchBuild), 0644); err != nil {
 		return fmt.Errorf("failed to write bench/BUILD.bazel: %w", err)
 	}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
@@ -52,13 +53,14 @@ func TestBuild(t *testing.T) {
 	require.NoError(t, os.Chdir(tmpDir))
 
 	tests := []struct {
-		name       string
-		release    bool
-		target     string
-		clean      bool
-		verbose    bool
-		sanitizer  string
-		wantConfig string
+		name        string
+		release     bool
+		target      string
+		clean       bool
+		verbose     bool
+		sanitizer   string
+		libraryType string
+		wantConfig  string
 	}{
 		{
 			name:       "Debug build",
@@ -109,6 +111,21 @@ func TestBuild(t *testing.T) {
 			sanitizer:  "asan",
 			wantConfig: "--config=debug",
 		},
+		{
+			name:       "ASan and UBSan build",
+			sanitizer:  "asan,ubsan",
+			wantConfig: "--config=debug",
+		},
+		{
+			name:        "Shared libraries",
+			libraryType: build.LibraryShared,
+			wantConfig:  "--dynamic_mode=fully",
+		},
+		{
+			name:        "Static libraries",
+			libraryType: build.LibraryStatic,
+			wantConfig:  "--dynamic_mode=off",
+		},
 	}
 
 	builder := New()
@@ -118,11 +135,12 @@ func TestBuild(t *testing.T) {
 			capturedArgs = nil
 
 			opts := build.BuildOptions{
-				Release:   tt.release,
-				Target:    tt.target,
-				Clean:     tt.clean,
-				Verbose:   tt.verbose,
-				Sanitizer: tt.sanitizer,
+				Release:     tt.release,
+				Target:      tt.target,
+				Clean:       tt.clean,
+				Verbose:     tt.verbose,
+				Sanitizer:   tt.sanitizer,
+				LibraryType: tt.libraryType,
 			}
 
 			err := builder.Build(context.Background(), opts)
@@ -138,16 +156,20 @@ func TestBuild(t *testing.T) {
 						assert.Contains(t, args, tt.target)
 					}
 					if tt.verbose {
-						// When verbose, --noshow_progress should NOT be added
-						assert.NotContains(t, args, "--noshow_progress")
+						// When verbose, Bazel's own progress output is shown
+						assert.NotContains(t, args, "--curses=no")
 					} else {
-						// When not verbose, --noshow_progress should be added
-						assert.Contains(t, args, "--noshow_progress")
+						// When not verbose, plain progress lines feed the progress bar
+						assert.Contains(t, args, "--curses=no")
 					}
 					if tt.sanitizer == "asan" {
 						assert.Contains(t, args, "--copt=-fsanitize=address")
 						assert.Contains(t, args, "--linkopt=-fsanitize=address")
 					}
+					if tt.sanitizer == "asan,ubsan" {
+						assert.Contains(t, args, "--copt=-fsanitize=address,undefined")
+						assert.Contains(t, args, "--linkopt=-fsanitize=address,undefined")
+					}
 					break
 				}
 			}
@@ -525,3 +547,60 @@ func TestListTargets(t *testing.T) {
 	assert.Contains(t, targets, "//src:main (cc_binary)")
 	assert.Contains(t, targets, "//src:mylib (cc_library)")
 }
+
+func TestBazelTagFilters(t *testing.T) {
+	assert.Empty(t, bazelTagFilters(nil, nil))
+	assert.Equal(t, "integration,e2e", bazelTagFilters([]string{"integration", "e2e"}, nil))
+	assert.Equal(t, "integration,-slow,-manual", bazelTagFilters([]string{"integration"}, []string{"slow", "manual"}))
+}
+
+func TestAddPathDependency(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+
+	libDir := filepath.Join(tmpDir, "libs", "mylib")
+	appDir := filepath.Join(tmpDir, "app")
+	require.NoError(t, os.MkdirAll(libDir, 0755))
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "MODULE.bazel"), []byte("module(\n    name = \"mylib\",\n    version = \"1.2.0\",\n)\n"), 0644))
+	appModule := "module(\n    name = \"app\",\n    version = \"0.1.0\",\n)\n\nbazel_dep(name = \"rules_cc\", version = \"0.1.1\")\n"
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "MODULE.bazel"), []byte(appModule), 0644))
+	require.NoError(t, os.Chdir(appDir))
+
+	builder := New()
+	require.NoError(t, builder.AddPathDependency(context.Background(), "../libs/mylib"))
+
+	content, err := os.ReadFile("MODULE.bazel")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `bazel_dep(name = "mylib", version = "1.2.0")`)
+	assert.Contains(t, string(content), "local_path_override(\n    module_name = \"mylib\",\n    path = \"../libs/mylib\",\n)\n")
+
+	assert.Error(t, builder.AddPathDependency(context.Background(), "../libs/mylib"), "already a dependency")
+
+	require.NoError(t, builder.RemoveDependency(context.Background(), "mylib"))
+	content, err = os.ReadFile("MODULE.bazel")
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "mylib")
+	assert.Contains(t, string(content), "rules_cc")
+}
+
+func TestAddSubmoduleDependency(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("MODULE.bazel", []byte("module(name = \"app\")\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join("third_party", "json"), 0755))
+
+	builder := New()
+	assert.Error(t, builder.AddSubmoduleDependency(context.Background(), "json", "third_party/json"), "no BUILD file")
+
+	require.NoError(t, os.WriteFile(filepath.Join("third_party", "json", "BUILD.bazel"), nil, 0644))
+	require.NoError(t, builder.AddSubmoduleDependency(context.Background(), "json", "third_party/json"))
+	content, err := os.ReadFile("MODULE.bazel")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), localRepositoryRule)
+	assert.Contains(t, string(content), "local_repository(\n    name = \"json\",\n    path = \"third_party/json\",\n)\n")
+
+	assert.Error(t, builder.AddSubmoduleDependency(context.Background(), "json", "third_party/json"), "already a dependency")
+	assert.Equal(t, 1, strings.Count(addBazelLocalRepository(string(content), "other", "third_party/other"), localRepositoryRule))
+}
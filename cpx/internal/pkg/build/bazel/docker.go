@@ -5,11 +5,13 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 )
 
 // RunDockerBuild implements the DockerBuilder interface for Bazel builds.
@@ -28,22 +30,35 @@ func (b *Builder) RunDockerBuild(ctx context.Context, opts build.DockerBuildOpti
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Create bazel cache directory
-	bazelCacheDir := filepath.Join(absProjectRoot, ".cache", "ci", opts.TargetName)
-	if err := os.MkdirAll(bazelCacheDir, 0755); err != nil {
-		return fmt.Errorf("failed to create bazel cache directory: %w", err)
-	}
-
 	// Determine build config
 	bazelConfig := "release"
 	if opts.BuildType == "Debug" || opts.BuildType == "debug" {
 		bazelConfig = "debug"
 	}
 
-	// Create bazel repository cache directory
-	bazelRepoCacheDir := filepath.Join(absProjectRoot, ".cache", "ci", "bazel_repo_cache")
-	if err := os.MkdirAll(bazelRepoCacheDir, 0755); err != nil {
-		return fmt.Errorf("failed to create bazel repo cache directory: %w", err)
+	// Sanitizer flags
+	var sanitizerOpts string
+	sanCFlags, sanLFlags := build.SanitizerFlags(opts.Sanitizer)
+	for _, flag := range strings.Fields(sanCFlags) {
+		sanitizerOpts += " --copt=" + flag
+	}
+	if sanLFlags != "" {
+		sanitizerOpts += " --linkopt=" + sanLFlags
+	}
+
+	// The output base belongs to the toolchain, while the repository and
+	// disk caches are content-addressed and shared by all toolchains
+	var cacheMounts []string
+	for _, mount := range []struct{ name, path string }{
+		{opts.TargetName, "/bazel-cache"},
+		{build.BazelRepoCache, "/bazel-repo-cache"},
+		{build.BazelDiskCache, "/bazel-disk-cache"},
+	} {
+		arg, err := opts.CacheMount(mount.name, mount.path)
+		if err != nil {
+			return err
+		}
+		cacheMounts = append(cacheMounts, "-v", arg)
 	}
 
 	// Environment exports
@@ -59,7 +74,7 @@ func (b *Builder) RunDockerBuild(ctx context.Context, opts build.DockerBuildOpti
 	if opts.RunTests {
 		testSection = `
 echo "  Running tests..."
-bazel --output_base="$BAZEL_OUTPUT_BASE" test --config=debug --symlink_prefix=/dev/null --spawn_strategy=local --repository_cache=/bazel-repo-cache --test_output=errors //...
+bazel --output_base="$BAZEL_OUTPUT_BASE" test --config=debug --symlink_prefix=/dev/null --spawn_strategy=local --repository_cache=/bazel-repo-cache --disk_cache=/bazel-disk-cache --test_output=errors //...
 `
 	}
 
@@ -67,7 +82,7 @@ bazel --output_base="$BAZEL_OUTPUT_BASE" test --config=debug --symlink_prefix=/d
 	if opts.RunBenchmarks {
 		benchSection = `
 echo "  Running benchmarks..."
-bazel --output_base="$BAZEL_OUTPUT_BASE" run --config=release --symlink_prefix=/dev/null --spawn_strategy=local --repository_cache=/bazel-repo-cache //bench/...
+bazel --output_base="$BAZEL_OUTPUT_BASE" run --config=release --symlink_prefix=/dev/null --spawn_strategy=local --repository_cache=/bazel-repo-cache --disk_cache=/bazel-disk-cache //bench/...
 `
 	}
 
@@ -121,7 +136,7 @@ set -e
 export HOME=/root
 BAZEL_OUTPUT_BASE=/bazel-cache
 mkdir -p "$BAZEL_OUTPUT_BASE"
-bazel --output_base="$BAZEL_OUTPUT_BASE" build --config=%[3]s --symlink_prefix=/dev/null --spawn_strategy=local --repository_cache=/bazel-repo-cache //...%[4]s
+bazel --output_base="$BAZEL_OUTPUT_BASE" build --config=%[3]s%[11]s --symlink_prefix=/dev/null --spawn_strategy=local --repository_cache=/bazel-repo-cache --disk_cache=/bazel-disk-cache //...%[4]s
 %[5]s
 mkdir -p /output/%[6]s
 find "$BAZEL_OUTPUT_BASE" -path "*/bin/*" -type f -executable \
@@ -135,27 +150,30 @@ find "$BAZEL_OUTPUT_BASE" -path "*/bin/*" -type f \( -name "lib*.a" -o -name "li
     -exec cp {} /output/%[6]s/ \; 2>/dev/null || true
 %[10]s
 %[7]s%[8]s%[9]s
-`, envExports, buildEcho, bazelConfig, bazelQuiet, copyEcho, opts.TargetName, testSection, benchSection, runSection, buildCompleteEcho)
+`, envExports, buildEcho, bazelConfig, bazelQuiet, copyEcho, opts.TargetName, testSection, benchSection, runSection, buildCompleteEcho, sanitizerOpts)
 
-	fmt.Printf("  %s Running Bazel build in Docker container...%s\n", colors.Cyan, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "  %s Running Bazel build in Docker container...%s\n", colors.Cyan, colors.Reset)
 
 	dockerArgs := []string{"run", "--rm"}
 	if opts.Platform != "" {
 		dockerArgs = append(dockerArgs, "--platform", opts.Platform)
 	}
+	if opts.GPUs != "" {
+		dockerArgs = append(dockerArgs, "--gpus", opts.GPUs)
+	}
 
 	dockerArgs = append(dockerArgs,
 		"-v", absProjectRoot+":/workspace:ro",
-		"-v", absOutputDir+":/output",
-		"-v", bazelCacheDir+":/bazel-cache",
-		"-v", bazelRepoCacheDir+":/bazel-repo-cache",
+		"-v", absOutputDir+":/output")
+	dockerArgs = append(dockerArgs, cacheMounts...)
+	dockerArgs = append(dockerArgs,
 		"-w", "/workspace",
 		opts.ImageName,
 		"bash", "-c", buildScript)
 
-	cmd := exec.Command("docker", dockerArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := opts.ContainerCommand(dockerArgs...)
+	cmd.Stdout = buildlog.Stdout()
+	cmd.Stderr = buildlog.Stderr()
 
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("docker bazel build failed: %w", err)
@@ -0,0 +1,58 @@
+package bazel
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ozacod/cpx/internal/pkg/build/explain"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+var _ build.RebuildExplainer = (*Builder)(nil)
+
+// ExplainRebuild implements the RebuildExplainer interface by running a build
+// with --explain and parsing the resulting explanation log.
+func (b *Builder) ExplainRebuild(ctx context.Context, opts build.ExplainOptions) ([]build.RebuildReason, error) {
+	if opts.DryRun {
+		output.Warnf("Bazel has no dry-run mode; targets will be built to collect explanations")
+	}
+
+	logFile, err := os.CreateTemp("", "cpx-bazel-explain-*.log")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create explain log: %w", err)
+	}
+	logPath := logFile.Name()
+	logFile.Close()
+	defer os.Remove(logPath)
+
+	configArgs, _ := configFlags(opts.Release, opts.OptLevel, opts.Sanitizer)
+	bazelArgs := append([]string{"build"}, configArgs...)
+	bazelArgs = append(bazelArgs, "--explain="+logPath, "--verbose_explanations",
+		"--noshow_progress", "--symlink_prefix=.bazel-")
+	if opts.Target != "" {
+		bazelArgs = append(bazelArgs, opts.Target)
+	} else {
+		bazelArgs = append(bazelArgs, "//...")
+	}
+
+	cmd := execCommand("bazel", bazelArgs...)
+	if opts.Verbose {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("bazel build failed: %w", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read explain log: %w", err)
+	}
+	if opts.Verbose {
+		fmt.Print(string(data))
+	}
+
+	return explain.ParseBazel(string(data)), nil
+}
@@ -0,0 +1,172 @@
+package bazel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+var (
+	_ build.PathDependencyAdder      = (*Builder)(nil)
+	_ build.SubmoduleDependencyAdder = (*Builder)(nil)
+)
+
+// AddPathDependency adds the Bazel module in dir to MODULE.bazel with a
+// bazel_dep and a local_path_override, so it is built from that directory
+// instead of being fetched from a registry.
+func (b *Builder) AddPathDependency(ctx context.Context, dir string) error {
+	rel, err := build.PathDependencyDir(dir)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(rel, "MODULE.bazel"))
+	if err != nil {
+		return fmt.Errorf("%s is not a Bazel module: no MODULE.bazel", dir)
+	}
+	name := moduleAttr(string(data), "name")
+	if name == "" {
+		return fmt.Errorf("no module name found in %s", filepath.Join(dir, "MODULE.bazel"))
+	}
+
+	modulePath := "MODULE.bazel"
+	content, err := os.ReadFile(modulePath)
+	if err != nil {
+		return fmt.Errorf("failed to read MODULE.bazel: %w", err)
+	}
+	if name == moduleAttr(string(content), "name") {
+		return fmt.Errorf("%s cannot depend on itself", name)
+	}
+	depPattern := regexp.MustCompile(fmt.Sprintf(`bazel_dep\s*\(\s*name\s*=\s*"%s"`, regexp.QuoteMeta(name)))
+	if depPattern.Match(content) {
+		return fmt.Errorf("%s is already a dependency in MODULE.bazel", name)
+	}
+	content = []byte(addBazelPathDependency(string(content), name, moduleAttr(string(data), "version"), rel))
+	if err := os.WriteFile(modulePath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write MODULE.bazel: %w", err)
+	}
+	output.Successf("✓ Added %s (%s) to MODULE.bazel", name, rel)
+
+	// cpx executables export their code as <name>_lib, libraries as <name>
+	target := name
+	if root, err := os.ReadFile(filepath.Join(rel, "BUILD.bazel")); err == nil &&
+		strings.Contains(string(root), fmt.Sprintf(`name = "%s_lib"`, name)) {
+		target = name + "_lib"
+	}
+	fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "Add this to your BUILD.bazel:\n\n")
+	fmt.Fprintf(output.Stdout(), "  deps = [\"@%s//:%s\"]\n\n", name, target)
+	return nil
+}
+
+// AddSubmoduleDependency adds the library checked out in dir. Bazel modules
+// are added as with --path; other repositories with a BUILD file become a
+// local_repository named name.
+func (b *Builder) AddSubmoduleDependency(ctx context.Context, name, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "MODULE.bazel")); err == nil {
+		return b.AddPathDependency(ctx, dir)
+	}
+	if !hasBuildFile(dir) {
+		return fmt.Errorf("%s has no MODULE.bazel or BUILD file to build it with Bazel", dir)
+	}
+
+	modulePath := "MODULE.bazel"
+	content, err := os.ReadFile(modulePath)
+	if err != nil {
+		return fmt.Errorf("failed to read MODULE.bazel: %w", err)
+	}
+	repoPattern := regexp.MustCompile(fmt.Sprintf(`local_repository\s*\(\s*name\s*=\s*"%s"`, regexp.QuoteMeta(name)))
+	if repoPattern.Match(content) {
+		return fmt.Errorf("%s is already a dependency in MODULE.bazel", name)
+	}
+	content = []byte(addBazelLocalRepository(string(content), name, filepath.ToSlash(dir)))
+	if err := os.WriteFile(modulePath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write MODULE.bazel: %w", err)
+	}
+	output.Successf("✓ Added %s (%s) to MODULE.bazel", name, filepath.ToSlash(dir))
+
+	fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "Add its targets to your BUILD.bazel, e.g.:\n\n")
+	fmt.Fprintf(output.Stdout(), "  deps = [\"@%s//:<target>\"]\n\n", name)
+	return nil
+}
+
+// localRepositoryRule loads local_repository, which Bzlmod only offers as a
+// repository rule.
+const localRepositoryRule = `local_repository = use_repo_rule("@bazel_tools//tools/build_defs/repo:local.bzl", "local_repository")`
+
+// addBazelLocalRepository appends a local_repository for the directory rel
+// to a MODULE.bazel, loading the rule first if needed.
+func addBazelLocalRepository(module, name, rel string) string {
+	if !strings.HasSuffix(module, "\n") {
+		module += "\n"
+	}
+	if !strings.Contains(module, localRepositoryRule) {
+		module += "\n" + localRepositoryRule + "\n"
+	}
+	return module + fmt.Sprintf(`
+local_repository(
+    name = "%s",
+    path = "%s",
+)
+`, name, rel)
+}
+
+// hasBuildFile reports whether dir has a root BUILD or BUILD.bazel file.
+func hasBuildFile(dir string) bool {
+	for _, name := range []string{"BUILD.bazel", "BUILD"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// addBazelPathDependency appends the bazel_dep and local_path_override of
+// the module name at rel to a MODULE.bazel.
+func addBazelPathDependency(module, name, version, rel string) string {
+	if !strings.HasSuffix(module, "\n") {
+		module += "\n"
+	}
+	dep := fmt.Sprintf(`bazel_dep(name = "%s")`, name)
+	if version != "" {
+		dep = fmt.Sprintf(`bazel_dep(name = "%s", version = "%s")`, name, version)
+	}
+	return module + fmt.Sprintf(`
+%s
+local_path_override(
+    module_name = "%s",
+    path = "%s",
+)
+`, dep, name, rel)
+}
+
+// moduleAttr returns a string attribute of the module() call of a
+// MODULE.bazel.
+func moduleAttr(module, attr string) string {
+	call := regexp.MustCompile(`(?s)module\s*\((.*?)\)`).FindStringSubmatch(module)
+	if call == nil {
+		return ""
+	}
+	value := regexp.MustCompile(`\b` + attr + `\s*=\s*"([^"]*)"`).FindStringSubmatch(call[1])
+	if value == nil {
+		return ""
+	}
+	return value[1]
+}
+
+// ModuleInfo returns the name and version of the module() of the
+// MODULE.bazel in root.
+func ModuleInfo(root string) (name, version string) {
+	data, err := os.ReadFile(filepath.Join(root, "MODULE.bazel"))
+	if err != nil {
+		return "", ""
+	}
+	return moduleAttr(string(data), "name"), moduleAttr(string(data), "version")
+}
@@ -0,0 +1,268 @@
+// Package buildlog captures the full output of configure, build and test
+// steps to timestamped files under .cache/logs, so diagnostics hidden by
+// the progress bar can be looked at later with "cpx log".
+package buildlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// Dir returns where the logs of the project in root are written.
+func Dir(root string) string {
+	return filepath.Join(config.DirsOf(root).Cache, "logs")
+}
+
+// keep is how many logs are kept; older ones are removed by Start.
+const keep = 50
+
+// timeFormat is the timestamp prefix of log file names. Milliseconds keep
+// logs started within a second in order.
+const timeFormat = "20060102-150405.000"
+
+// secondsTimeFormat is the prefix of logs written by older versions.
+const secondsTimeFormat = "20060102-150405"
+
+// footerPrefix starts the last line of a finished log.
+const footerPrefix = "# exit: "
+
+// now is a variable so tests can control log names.
+var now = time.Now
+
+var (
+	mu      sync.Mutex
+	current *Log
+)
+
+// Log is a log file being written.
+type Log struct {
+	Path string
+	file *os.File
+}
+
+// Start begins capturing output to a new log in the project at root, for
+// the command and, for toolchain builds, the toolchain name. Failing to
+// create the log never fails the build; Start then returns a Log that
+// discards everything.
+func Start(root, command, toolchain string) *Log {
+	name := now().Format(timeFormat) + "-" + command
+	if toolchain != "" {
+		name += "-" + sanitize(toolchain)
+	}
+	l := &Log{}
+	dir := Dir(root)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return l
+	}
+	prune(dir)
+
+	path := filepath.Join(dir, name+".log")
+	// several runs within a second get distinct names
+	for i := 2; fileExists(path); i++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s.%d.log", name, i))
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return l
+	}
+	l.Path, l.file = path, f
+	fmt.Fprintf(f, "# cpx %s", command)
+	if toolchain != "" {
+		fmt.Fprintf(f, " --toolchain %s", toolchain)
+	}
+	fmt.Fprintf(f, "\n# started: %s\n", now().Format(time.RFC3339))
+
+	mu.Lock()
+	current = l
+	mu.Unlock()
+	return l
+}
+
+// Close records how the command ended and stops capturing.
+func (l *Log) Close(err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == l {
+		current = nil
+	}
+	if l.file == nil {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(l.file, "%sfailed: %s\n", footerPrefix, strings.ReplaceAll(err.Error(), "\n", " "))
+	} else {
+		fmt.Fprintf(l.file, "%sok\n", footerPrefix)
+	}
+	_ = l.file.Close()
+	l.file = nil
+}
+
+// Writer writes to the current log, if any. Use it for output that is not
+// shown, like the lines behind a progress bar.
+func Writer() io.Writer {
+	return writer{}
+}
+
+// Stdout returns a writer that writes to os.Stdout and the current log.
+func Stdout() io.Writer {
+	return io.MultiWriter(os.Stdout, writer{})
+}
+
+// Stderr returns a writer that writes to os.Stderr and the current log.
+func Stderr() io.Writer {
+	return io.MultiWriter(os.Stderr, writer{})
+}
+
+type writer struct{}
+
+// ansiRe matches terminal escape sequences, which are stripped from logs.
+var ansiRe = regexp.MustCompile("\x1b\\[[0-9;?]*[a-zA-Z]")
+
+func (writer) Write(p []byte) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil && current.file != nil {
+		_, _ = current.file.Write(ansiRe.ReplaceAll(p, nil))
+	}
+	return len(p), nil
+}
+
+// Entry describes a log file.
+type Entry struct {
+	Path      string
+	Time      time.Time
+	Command   string
+	Toolchain string
+	// Status is "ok", "failed: <error>" or "" while the command runs.
+	Status string
+}
+
+// List returns the logs in dir, newest first.
+func List(dir string) ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, path := range files {
+		e, err := readEntry(path)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return newer(entries[i].Path, entries[j].Path) })
+	return entries, nil
+}
+
+// readEntry reads the header and footer of a log.
+func readEntry(path string) (Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer f.Close()
+
+	e := Entry{Path: path, Time: logTime(path)}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	first := true
+	for sc.Scan() {
+		line := sc.Text()
+		if first {
+			fields := strings.Fields(strings.TrimPrefix(line, "# cpx "))
+			if len(fields) > 0 {
+				e.Command = fields[0]
+			}
+			if len(fields) == 3 && fields[1] == "--toolchain" {
+				e.Toolchain = fields[2]
+			}
+			first = false
+		}
+		if strings.HasPrefix(line, footerPrefix) {
+			e.Status = strings.TrimPrefix(line, footerPrefix)
+		}
+	}
+	return e, sc.Err()
+}
+
+// Finished reports whether the log at path has its exit footer.
+func Finished(path string) bool {
+	e, err := readEntry(path)
+	return err == nil && e.Status != ""
+}
+
+// prune removes the oldest logs so at most keep-1 remain before a new one
+// is created.
+func prune(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil || len(files) < keep {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return newer(files[j], files[i]) })
+	for _, f := range files[:len(files)-keep+1] {
+		_ = os.Remove(f)
+	}
+}
+
+// logTime returns when the log at path was started, from its name.
+func logTime(path string) time.Time {
+	base := filepath.Base(path)
+	for _, layout := range []string{timeFormat, secondsTimeFormat} {
+		if len(base) > len(layout) {
+			if t, err := time.ParseInLocation(layout, base[:len(layout)], time.Local); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// logSeq returns the number Start adds to the names of logs started at the
+// same time: 2 for <name>.2.log, and 1 for the first log.
+func logSeq(path string) int {
+	name := strings.TrimSuffix(filepath.Base(path), ".log")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		if n, err := strconv.Atoi(name[i+1:]); err == nil {
+			return n
+		}
+	}
+	return 1
+}
+
+// newer reports whether the log at a was started after the one at b.
+func newer(a, b string) bool {
+	ta, tb := logTime(a), logTime(b)
+	if !ta.Equal(tb) {
+		return ta.After(tb)
+	}
+	if sa, sb := logSeq(a), logSeq(b); sa != sb {
+		return sa > sb
+	}
+	return a > b
+}
+
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ' ' || r == ':' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,120 @@
+package buildlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixedNow(t *testing.T, ts time.Time) {
+	old := now
+	t.Cleanup(func() { now = old })
+	now = func() time.Time { return ts }
+}
+
+func TestStartWriteClose(t *testing.T) {
+	root := t.TempDir()
+	fixedNow(t, time.Date(2026, 10, 18, 15, 4, 5, 0, time.Local))
+
+	l := Start(root, "build", "linux/gcc")
+	assert.Equal(t, filepath.Join(Dir(root), "20261018-150405.000-build-linux_gcc.log"), l.Path)
+	fmt.Fprintln(Writer(), "\x1b[31merror:\x1b[0m boom")
+	l.Close(errors.New("cmake build failed:\nexit status 1"))
+
+	// nothing is written once the log is closed
+	fmt.Fprintln(Writer(), "dropped")
+
+	data, err := os.ReadFile(l.Path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# cpx build --toolchain linux/gcc\n")
+	assert.Contains(t, string(data), "error: boom\n")
+	assert.Contains(t, string(data), "# exit: failed: cmake build failed: exit status 1\n")
+	assert.NotContains(t, string(data), "dropped")
+
+	// a second log in the same millisecond gets a distinct name
+	l2 := Start(root, "build", "linux/gcc")
+	l2.Close(nil)
+	assert.Equal(t, filepath.Join(Dir(root), "20261018-150405.000-build-linux_gcc.2.log"), l2.Path)
+}
+
+func TestList(t *testing.T) {
+	root := t.TempDir()
+	dir := Dir(root)
+
+	fixedNow(t, time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local))
+	Start(root, "test", "").Close(nil)
+	fixedNow(t, time.Date(2026, 10, 18, 11, 0, 0, 0, time.Local))
+	Start(root, "build", "wasm").Close(errors.New("boom"))
+	fixedNow(t, time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local))
+	running := Start(root, "build", "")
+	defer running.Close(nil)
+
+	entries, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "build", entries[0].Command)
+	assert.Empty(t, entries[0].Status)
+	assert.False(t, Finished(entries[0].Path))
+
+	assert.Equal(t, "wasm", entries[1].Toolchain)
+	assert.Equal(t, "failed: boom", entries[1].Status)
+	assert.Equal(t, 11, entries[1].Time.Hour())
+
+	assert.Equal(t, "test", entries[2].Command)
+	assert.Equal(t, "ok", entries[2].Status)
+}
+
+func TestListOrder(t *testing.T) {
+	dir := t.TempDir()
+	// older logs are named to the second; later runs sort first
+	for _, name := range []string{
+		"20261018-100000-test.log",
+		"20261018-100000-build.log",
+		"20261018-100000-build.2.log",
+		"20261018-100000.250-test.log",
+		"20261018-100000.500-build.log",
+		"20261018-100000.500-build.2.log",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("# cpx build\n"), 0644))
+	}
+
+	entries, err := List(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, filepath.Base(e.Path))
+	}
+	assert.Equal(t, []string{
+		"20261018-100000.500-build.2.log",
+		"20261018-100000.500-build.log",
+		"20261018-100000.250-test.log",
+		"20261018-100000-build.2.log",
+		"20261018-100000-test.log",
+		"20261018-100000-build.log",
+	}, names)
+	assert.Equal(t, 250*time.Millisecond, entries[2].Time.Sub(entries[3].Time))
+}
+
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	dir := Dir(root)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for i := 0; i < keep+5; i++ {
+		name := fmt.Sprintf("20260101-%06d-build.log", i)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	Start(root, "build", "").Close(nil)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	require.NoError(t, err)
+	assert.Len(t, files, keep)
+	assert.NoFileExists(t, filepath.Join(dir, "20260101-000000-build.log"))
+}
@@ -0,0 +1,204 @@
+// Package diagnostics parses GCC/Clang compiler and linker diagnostics from
+// build output and prints a grouped summary of them.
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+)
+
+// Severity of a diagnostic.
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// LinkerFile groups diagnostics that have no source location.
+const LinkerFile = "(linker)"
+
+var (
+	// "src/main.cpp:12:5: error: expected ';'" (column optional)
+	diagRe = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)?\s+(fatal error|error|warning):\s+(.*)$`)
+	// "/usr/bin/ld: main.o: in function `main': main.cpp:(.text+0x5): undefined reference to `foo()'"
+	undefinedRefRe = regexp.MustCompile("undefined reference to [`'](.+)'")
+	// "ld.lld: error: undefined symbol: foo()", "ld: symbol(s) not found"
+	linkerRe = regexp.MustCompile(`^(?:\S*ld(?:\.lld)?|collect2|clang(?:\+\+)?|[cg]\+\+):\s+(?:error:\s+)?(.*)$`)
+	// "[-Wunused-variable]" at the end of warnings
+	flagRe = regexp.MustCompile(`\s*\[-W[^\]]+\]$`)
+)
+
+// Diagnostic is one compiler or linker message.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity string
+	Message  string
+}
+
+// Summary is the deduplicated set of diagnostics of a build.
+type Summary struct {
+	Errors   int
+	Warnings int
+	// Files lists files in the order their first diagnostic appeared.
+	Files []string
+	// ByFile holds the diagnostics of each file, in order.
+	ByFile map[string][]Diagnostic
+}
+
+// Parse extracts diagnostics from build output. Identical diagnostics, such
+// as the same error reached through several template instantiations or a
+// header included by several sources, are counted once. Notes and
+// "In instantiation of" backtraces are dropped.
+func Parse(output string) Summary {
+	s := Summary{ByFile: make(map[string][]Diagnostic)}
+	seen := make(map[string]bool)
+
+	add := func(d Diagnostic) {
+		key := fmt.Sprintf("%s:%d:%d:%s:%s", d.File, d.Line, d.Column, d.Severity, d.Message)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		if _, ok := s.ByFile[d.File]; !ok {
+			s.Files = append(s.Files, d.File)
+		}
+		s.ByFile[d.File] = append(s.ByFile[d.File], d)
+		if d.Severity == Error {
+			s.Errors++
+		} else {
+			s.Warnings++
+		}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := diagRe.FindStringSubmatch(line); m != nil {
+			d := Diagnostic{
+				File:     filepath.Clean(m[1]),
+				Severity: Warning,
+				Message:  flagRe.ReplaceAllString(m[5], ""),
+			}
+			fmt.Sscanf(m[2], "%d", &d.Line)
+			if m[3] != "" {
+				fmt.Sscanf(m[3], "%d", &d.Column)
+			}
+			if m[4] != "warning" {
+				d.Severity = Error
+			}
+			add(d)
+			continue
+		}
+		if m := undefinedRefRe.FindStringSubmatch(line); m != nil {
+			add(Diagnostic{File: LinkerFile, Severity: Error, Message: "undefined reference to " + m[1]})
+			continue
+		}
+		if m := linkerRe.FindStringSubmatch(line); m != nil {
+			msg := m[1]
+			// collect2's "ld returned 1 exit status" only repeats the failure
+			if strings.Contains(msg, "returned 1 exit status") || strings.HasPrefix(msg, "note:") || strings.HasPrefix(msg, "warning:") {
+				continue
+			}
+			if strings.HasPrefix(msg, "undefined symbol") || strings.Contains(msg, "not found") ||
+				strings.Contains(msg, "multiple definition") || strings.Contains(msg, "cannot find") ||
+				strings.Contains(msg, "duplicate symbol") {
+				add(Diagnostic{File: LinkerFile, Severity: Error, Message: msg})
+			}
+		}
+	}
+	return s
+}
+
+// Empty reports whether no diagnostics were found.
+func (s Summary) Empty() bool {
+	return s.Errors == 0 && s.Warnings == 0
+}
+
+// Print writes a grouped summary: for each file its first error (or
+// warning, if it has no errors) and how many more it has, followed by the
+// totals. At most maxFiles files are listed.
+func (s Summary) Print(w io.Writer, maxFiles int) {
+	fmt.Fprintf(w, "%s✗ %s, %s%s\n", colors.Red, plural(s.Errors, "error"), plural(s.Warnings, "warning"), colors.Reset)
+
+	files := s.Files
+	// files with errors first, keeping their order
+	var ordered []string
+	for _, f := range files {
+		if countSeverity(s.ByFile[f], Error) > 0 {
+			ordered = append(ordered, f)
+		}
+	}
+	for _, f := range files {
+		if countSeverity(s.ByFile[f], Error) == 0 {
+			ordered = append(ordered, f)
+		}
+	}
+
+	for i, file := range ordered {
+		if i == maxFiles {
+			fmt.Fprintf(w, "  %s... and %d more file(s)%s\n", colors.Gray, len(ordered)-maxFiles, colors.Reset)
+			break
+		}
+		diags := s.ByFile[file]
+		first := diags[0]
+		for _, d := range diags {
+			if d.Severity == Error {
+				first = d
+				break
+			}
+		}
+
+		color := colors.Yellow
+		if first.Severity == Error {
+			color = colors.Red
+		}
+		location := file
+		if first.Line > 0 {
+			location += fmt.Sprintf(":%d", first.Line)
+			if first.Column > 0 {
+				location += fmt.Sprintf(":%d", first.Column)
+			}
+		}
+		fmt.Fprintf(w, "  %s%s%s\n", colors.Bold, location, colors.Reset)
+		fmt.Fprintf(w, "    %s%s:%s %s\n", color, first.Severity, colors.Reset, first.Message)
+
+		errors, warnings := countSeverity(diags, Error), countSeverity(diags, Warning)
+		if first.Severity == Error {
+			errors--
+		} else {
+			warnings--
+		}
+		var more []string
+		if errors > 0 {
+			more = append(more, plural(errors, "more error"))
+		}
+		if warnings > 0 {
+			more = append(more, plural(warnings, "warning"))
+		}
+		if len(more) > 0 {
+			fmt.Fprintf(w, "    %s(+%s)%s\n", colors.Gray, strings.Join(more, ", "), colors.Reset)
+		}
+	}
+}
+
+func countSeverity(diags []Diagnostic, severity string) int {
+	n := 0
+	for _, d := range diags {
+		if d.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
@@ -0,0 +1,76 @@
+package diagnostics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gccOutput = `[3/10] Building CXX object CMakeFiles/app.dir/src/main.cpp.o
+FAILED: CMakeFiles/app.dir/src/main.cpp.o
+/usr/bin/c++ -O0 -c src/main.cpp -o CMakeFiles/app.dir/src/main.cpp.o
+In file included from src/main.cpp:1:
+src/util.hpp:4:10: warning: unused variable 'x' [-Wunused-variable]
+src/main.cpp: In instantiation of 'void f(T) [with T = int]':
+src/main.cpp:20:6:   required from here
+src/main.cpp:12:5: error: no match for 'operator<<'
+src/main.cpp:12:5: note: candidate: ...
+src/main.cpp: In instantiation of 'void f(T) [with T = long]':
+src/main.cpp:21:6:   required from here
+src/main.cpp:12:5: error: no match for 'operator<<'
+src/main.cpp:30:1: error: expected ';' before '}' token
+FAILED: CMakeFiles/app.dir/src/other.cpp.o
+In file included from src/other.cpp:1:
+src/util.hpp:4:10: warning: unused variable 'x' [-Wunused-variable]
+src/other.cpp:8: fatal error: missing.hpp: No such file or directory
+compilation terminated.
+ninja: build stopped: subcommand failed.
+`
+
+func TestParse(t *testing.T) {
+	s := Parse(gccOutput)
+
+	// the instantiation backtrace and the header warning are deduplicated
+	assert.Equal(t, 3, s.Errors)
+	assert.Equal(t, 1, s.Warnings)
+	assert.Equal(t, []string{"src/util.hpp", "src/main.cpp", "src/other.cpp"}, s.Files)
+
+	require.Len(t, s.ByFile["src/main.cpp"], 2)
+	assert.Equal(t, Diagnostic{File: "src/main.cpp", Line: 12, Column: 5, Severity: Error, Message: "no match for 'operator<<'"}, s.ByFile["src/main.cpp"][0])
+	assert.Equal(t, "unused variable 'x'", s.ByFile["src/util.hpp"][0].Message)
+	assert.Equal(t, Diagnostic{File: "src/other.cpp", Line: 8, Severity: Error, Message: "missing.hpp: No such file or directory"}, s.ByFile["src/other.cpp"][0])
+}
+
+func TestParseLinker(t *testing.T) {
+	s := Parse("/usr/bin/ld: main.o: in function `main':\n" +
+		"main.cpp:(.text+0x5): undefined reference to `foo()'\n" +
+		"main.cpp:(.text+0x9): undefined reference to `foo()'\n" +
+		"collect2: error: ld returned 1 exit status\n" +
+		"ld.lld: error: undefined symbol: bar()\n" +
+		"/usr/bin/ld: cannot find -lmissing: No such file or directory\n")
+
+	assert.Equal(t, 3, s.Errors)
+	assert.Equal(t, []string{LinkerFile}, s.Files)
+	assert.Equal(t, "undefined reference to foo()", s.ByFile[LinkerFile][0].Message)
+	assert.Equal(t, "undefined symbol: bar()", s.ByFile[LinkerFile][1].Message)
+}
+
+func TestParseNoDiagnostics(t *testing.T) {
+	s := Parse("CMake Error at CMakeLists.txt:3 (find_package):\n  Could not find a package\n")
+	assert.True(t, s.Empty())
+}
+
+func TestPrint(t *testing.T) {
+	var out bytes.Buffer
+	Parse(gccOutput).Print(&out, 2)
+	text := out.String()
+
+	assert.Contains(t, text, "3 errors, 1 warning")
+	// files with errors come first, each with its first error
+	assert.Regexp(t, `(?s)src/main.cpp:12:5.*no match for 'operator<<'.*\+1 more error.*src/other.cpp:8`, text)
+	assert.NotContains(t, text, "expected ';'")
+	assert.Contains(t, text, "... and 1 more file(s)")
+	assert.NotContains(t, text, "util.hpp")
+}
@@ -0,0 +1,136 @@
+// Package events writes the NDJSON build event stream of
+// "cpx build --message-format json": one JSON object per line, each with a
+// "reason" naming the event, so editors can show progress and errors inline.
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/ozacod/cpx/internal/pkg/build/diagnostics"
+)
+
+// Event reasons.
+const (
+	ConfigureStart  = "configure-start"
+	CompileProgress = "compile-progress"
+	Diagnostic      = "diagnostic"
+	ArtifactWritten = "artifact-written"
+	BuildFinished   = "build-finished"
+)
+
+var (
+	mu  sync.Mutex
+	out io.Writer
+)
+
+// Enable starts writing events to w.
+func Enable(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// Disable stops writing events.
+func Disable() {
+	mu.Lock()
+	defer mu.Unlock()
+	out = nil
+}
+
+// Enabled reports whether events are being written.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return out != nil
+}
+
+// emit writes v as one line of JSON if events are enabled.
+func emit(v any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if out == nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	_, _ = out.Write(append(data, '\n'))
+}
+
+type configureStartEvent struct {
+	Reason string `json:"reason"`
+	Tool   string `json:"tool"`
+}
+
+// ConfigureStarted reports that tool ("cmake", "meson") began configuring.
+func ConfigureStarted(tool string) {
+	emit(configureStartEvent{Reason: ConfigureStart, Tool: tool})
+}
+
+type compileProgressEvent struct {
+	Reason  string `json:"reason"`
+	Percent int    `json:"percent"`
+}
+
+// Progress reports the completion percentage of the compile step.
+func Progress(percent int) {
+	emit(compileProgressEvent{Reason: CompileProgress, Percent: percent})
+}
+
+type diagnosticEvent struct {
+	Reason   string `json:"reason"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Diagnostics reports each compiler and linker diagnostic of a summary.
+// Linker diagnostics have no file.
+func Diagnostics(s diagnostics.Summary) {
+	for _, file := range s.Files {
+		for _, d := range s.ByFile[file] {
+			ev := diagnosticEvent{
+				Reason:   Diagnostic,
+				Severity: d.Severity,
+				File:     d.File,
+				Line:     d.Line,
+				Column:   d.Column,
+				Message:  d.Message,
+			}
+			if ev.File == diagnostics.LinkerFile {
+				ev.File = ""
+			}
+			emit(ev)
+		}
+	}
+}
+
+type artifactWrittenEvent struct {
+	Reason string `json:"reason"`
+	Path   string `json:"path"`
+}
+
+// Artifact reports that a build output was written to path.
+func Artifact(path string) {
+	emit(artifactWrittenEvent{Reason: ArtifactWritten, Path: path})
+}
+
+type buildFinishedEvent struct {
+	Reason  string `json:"reason"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Finished reports the outcome of the build.
+func Finished(err error) {
+	ev := buildFinishedEvent{Reason: BuildFinished, Success: err == nil}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	emit(ev)
+}
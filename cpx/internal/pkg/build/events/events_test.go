@@ -0,0 +1,73 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/build/diagnostics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var evs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var ev map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &ev), line)
+		evs = append(evs, ev)
+	}
+	return evs
+}
+
+func TestEventsDisabled(t *testing.T) {
+	Disable()
+	assert.False(t, Enabled())
+	// no writer: nothing to panic on
+	Progress(10)
+	Finished(nil)
+}
+
+func TestEventStream(t *testing.T) {
+	var buf bytes.Buffer
+	Enable(&buf)
+	defer Disable()
+	assert.True(t, Enabled())
+
+	ConfigureStarted("cmake")
+	Progress(0)
+	Progress(50)
+	Diagnostics(diagnostics.Parse(`src/main.cpp:3:5: error: expected ';'
+src/util.h:7: warning: unused variable 'x' [-Wunused-variable]
+/usr/bin/ld: main.o: in function 'main': main.cpp:(.text+0x5): undefined reference to 'foo()'`))
+	Artifact(".bin/native/debug/app")
+	Finished(errors.New("cmake build failed"))
+
+	evs := decode(t, buf.String())
+	require.Len(t, evs, 8)
+	assert.Equal(t, map[string]any{"reason": ConfigureStart, "tool": "cmake"}, evs[0])
+	assert.Equal(t, map[string]any{"reason": CompileProgress, "percent": float64(0)}, evs[1])
+	assert.Equal(t, float64(50), evs[2]["percent"])
+	assert.Equal(t, map[string]any{
+		"reason": Diagnostic, "severity": "error", "file": "src/main.cpp",
+		"line": float64(3), "column": float64(5), "message": "expected ';'",
+	}, evs[3])
+	assert.Equal(t, "warning", evs[4]["severity"])
+	assert.Equal(t, "unused variable 'x'", evs[4]["message"])
+	assert.NotContains(t, evs[5], "file", "linker diagnostics have no file")
+	assert.Equal(t, "undefined reference to foo()", evs[5]["message"])
+	assert.Equal(t, map[string]any{"reason": ArtifactWritten, "path": ".bin/native/debug/app"}, evs[6])
+	assert.Equal(t, map[string]any{"reason": BuildFinished, "success": false, "error": "cmake build failed"}, evs[7])
+}
+
+func TestFinishedSuccess(t *testing.T) {
+	var buf bytes.Buffer
+	Enable(&buf)
+	defer Disable()
+
+	Finished(nil)
+	assert.JSONEq(t, `{"reason": "build-finished", "success": true}`, buf.String())
+}
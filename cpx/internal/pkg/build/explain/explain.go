@@ -0,0 +1,222 @@
+// Package explain parses the "explain" output of build tools (ninja, Bazel)
+// and summarizes why targets were considered dirty.
+package explain
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+)
+
+// Root cause categories reported in build.RebuildReason.Cause.
+const (
+	CauseChangedFlags    = "changed flags"
+	CauseTouchedHeader   = "touched headers"
+	CauseModifiedSource  = "modified sources"
+	CauseBuildFiles      = "build files changed"
+	CauseClockSkew       = "clock skew"
+	CauseMissingOutput   = "missing outputs"
+	CauseMissingDepsInfo = "missing dependency info"
+	CauseNewAction       = "new actions"
+	CauseEnvironment     = "changed environment"
+	CauseOther           = "other"
+)
+
+var (
+	ninjaOlderRe      = regexp.MustCompile(`^(?:restat of |recorded mtime of )?(?:output )?(.+?) older than most recent input (.+?) \((-?\d+) vs (-?\d+)\)$`)
+	ninjaMissingRe    = regexp.MustCompile(`^output (.+?) (?:of phony edge with no inputs )?doesn't exist$`)
+	ninjaNoInEdgeRe   = regexp.MustCompile(`^(.+?) has no in-edge and is missing$`)
+	ninjaCommandRe    = regexp.MustCompile(`^command line changed for (.+)$`)
+	ninjaDepsRe       = regexp.MustCompile(`^(?:deps for '(.+?)' are missing|stored deps info out of date for '(.+?)'.*|depfile '(.+?)' is missing)$`)
+	bazelActionRe     = regexp.MustCompile(`^Executing action '(.+?)': (.+)$`)
+	bazelFileChangeRe = regexp.MustCompile(`One of the files has changed: (.+?)\.?$`)
+)
+
+// ParseNinja parses the output of "ninja -d explain". Lines that only
+// propagate dirtiness ("X is dirty") are skipped so that the result contains
+// root causes only. now is used to detect inputs with timestamps in the future.
+func ParseNinja(output string, now time.Time) []build.RebuildReason {
+	var reasons []build.RebuildReason
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		idx := strings.Index(line, "ninja explain: ")
+		if idx == -1 {
+			continue
+		}
+		msg := strings.TrimSpace(line[idx+len("ninja explain: "):])
+
+		if m := ninjaOlderRe.FindStringSubmatch(msg); m != nil {
+			target, input := m[1], m[2]
+			inputTime, _ := strconv.ParseInt(m[4], 10, 64)
+			cause := ClassifyFile(input)
+			if isFutureTimestamp(inputTime, now) {
+				cause = CauseClockSkew
+			}
+			reasons = append(reasons, build.RebuildReason{Target: target, Cause: cause, Detail: input})
+			continue
+		}
+		if m := ninjaCommandRe.FindStringSubmatch(msg); m != nil {
+			reasons = append(reasons, build.RebuildReason{Target: m[1], Cause: CauseChangedFlags})
+			continue
+		}
+		if m := ninjaMissingRe.FindStringSubmatch(msg); m != nil {
+			reasons = append(reasons, build.RebuildReason{Target: m[1], Cause: CauseMissingOutput})
+			continue
+		}
+		if m := ninjaNoInEdgeRe.FindStringSubmatch(msg); m != nil {
+			reasons = append(reasons, build.RebuildReason{Target: m[1], Cause: CauseMissingOutput, Detail: "input missing"})
+			continue
+		}
+		if m := ninjaDepsRe.FindStringSubmatch(msg); m != nil {
+			target := m[1] + m[2] + m[3]
+			reasons = append(reasons, build.RebuildReason{Target: target, Cause: CauseMissingDepsInfo})
+			continue
+		}
+		if strings.HasSuffix(msg, " is dirty") {
+			continue
+		}
+		reasons = append(reasons, build.RebuildReason{Cause: CauseOther, Detail: msg})
+	}
+	return reasons
+}
+
+// ParseBazel parses an explanation log written by
+// "bazel build --explain=<file> --verbose_explanations".
+func ParseBazel(output string) []build.RebuildReason {
+	var reasons []build.RebuildReason
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		m := bazelActionRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		action, why := m[1], m[2]
+		reason := build.RebuildReason{Target: action}
+
+		switch {
+		case bazelFileChangeRe.MatchString(why):
+			file := bazelFileChangeRe.FindStringSubmatch(why)[1]
+			reason.Cause = ClassifyFile(file)
+			reason.Detail = file
+		case strings.Contains(why, "action command has changed"),
+			strings.Contains(why, "action key has changed"):
+			reason.Cause = CauseChangedFlags
+		case strings.Contains(why, "client environment has changed"):
+			reason.Cause = CauseEnvironment
+		case strings.Contains(why, "no entry in the cache"):
+			reason.Cause = CauseNewAction
+		case strings.Contains(why, "is missing"):
+			reason.Cause = CauseMissingOutput
+		default:
+			reason.Cause = CauseOther
+			reason.Detail = strings.TrimSuffix(why, ".")
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// ClassifyFile maps a changed input file to a root cause category.
+func ClassifyFile(path string) string {
+	base := filepath.Base(path)
+	switch base {
+	case "CMakeLists.txt", "CMakeCache.txt", "CMakePresets.json", "build.ninja",
+		"meson.build", "meson_options.txt", "meson.options",
+		"BUILD", "BUILD.bazel", "MODULE.bazel", "WORKSPACE", ".bazelrc", "vcpkg.json":
+		return CauseBuildFiles
+	}
+	switch strings.ToLower(filepath.Ext(base)) {
+	case ".h", ".hh", ".hpp", ".hxx", ".h++", ".inl", ".ipp", ".tpp":
+		return CauseTouchedHeader
+	case ".cmake", ".bzl", ".wrap":
+		return CauseBuildFiles
+	}
+	return CauseModifiedSource
+}
+
+// isFutureTimestamp reports whether a ninja mtime lies in the future.
+// Ninja records nanoseconds on modern platforms and seconds on older ones.
+func isFutureTimestamp(ts int64, now time.Time) bool {
+	if ts <= 0 {
+		return false
+	}
+	var t time.Time
+	if ts > 1e15 {
+		t = time.Unix(0, ts)
+	} else {
+		t = time.Unix(ts, 0)
+	}
+	return t.After(now.Add(time.Second))
+}
+
+// Culprit is a file or setting responsible for one or more rebuilds.
+type Culprit struct {
+	Name  string
+	Count int
+}
+
+// CauseSummary aggregates all rebuild reasons sharing a root cause.
+type CauseSummary struct {
+	Cause    string
+	Count    int
+	Culprits []Culprit
+}
+
+// Summarize groups reasons by root cause, most frequent first.
+func Summarize(reasons []build.RebuildReason) []CauseSummary {
+	byCause := make(map[string]*CauseSummary)
+	culprits := make(map[string]map[string]int)
+	var order []string
+
+	for _, r := range reasons {
+		s, ok := byCause[r.Cause]
+		if !ok {
+			s = &CauseSummary{Cause: r.Cause}
+			byCause[r.Cause] = s
+			culprits[r.Cause] = make(map[string]int)
+			order = append(order, r.Cause)
+		}
+		s.Count++
+		if r.Detail != "" {
+			culprits[r.Cause][r.Detail]++
+		}
+	}
+
+	summaries := make([]CauseSummary, 0, len(order))
+	for _, cause := range order {
+		s := byCause[cause]
+		for name, count := range culprits[cause] {
+			s.Culprits = append(s.Culprits, Culprit{Name: name, Count: count})
+		}
+		sort.Slice(s.Culprits, func(i, j int) bool {
+			if s.Culprits[i].Count != s.Culprits[j].Count {
+				return s.Culprits[i].Count > s.Culprits[j].Count
+			}
+			return s.Culprits[i].Name < s.Culprits[j].Name
+		})
+		summaries = append(summaries, *s)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].Count > summaries[j].Count
+	})
+	return summaries
+}
+
+// NinjaArgs returns the ninja arguments that print explanations for dirty
+// targets in buildDir.
+func NinjaArgs(buildDir string, opts build.ExplainOptions) []string {
+	args := []string{"-C", buildDir, "-d", "explain"}
+	if opts.DryRun {
+		args = append(args, "-n")
+	}
+	if opts.Target != "" {
+		args = append(args, opts.Target)
+	}
+	return args
+}
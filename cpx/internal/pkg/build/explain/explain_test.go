@@ -0,0 +1,94 @@
+package explain
+
+import (
+	"testing"
+	"time"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNinja(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	output := `ninja explain: output CMakeFiles/app.dir/src/main.cpp.o older than most recent input ../../include/app.hpp (1699999000000000000 vs 1699999500000000000)
+ninja explain: CMakeFiles/app.dir/src/main.cpp.o is dirty
+ninja explain: output CMakeFiles/app.dir/src/util.cpp.o older than most recent input ../../src/util.cpp (1699999000000000000 vs 1699999500000000000)
+ninja explain: command line changed for CMakeFiles/app.dir/src/lib.cpp.o
+ninja explain: output app doesn't exist
+ninja explain: output CMakeFiles/app.dir/src/x.cpp.o older than most recent input ../../src/x.cpp (1699999000000000000 vs 1800000000000000000)
+ninja explain: deps for 'CMakeFiles/app.dir/src/y.cpp.o' are missing
+[1/3] Building CXX object CMakeFiles/app.dir/src/main.cpp.o`
+
+	reasons := ParseNinja(output, now)
+	require.Len(t, reasons, 6)
+	assert.Equal(t, build.RebuildReason{Target: "CMakeFiles/app.dir/src/main.cpp.o", Cause: CauseTouchedHeader, Detail: "../../include/app.hpp"}, reasons[0])
+	assert.Equal(t, CauseModifiedSource, reasons[1].Cause)
+	assert.Equal(t, CauseChangedFlags, reasons[2].Cause)
+	assert.Equal(t, "CMakeFiles/app.dir/src/lib.cpp.o", reasons[2].Target)
+	assert.Equal(t, build.RebuildReason{Target: "app", Cause: CauseMissingOutput}, reasons[3])
+	assert.Equal(t, CauseClockSkew, reasons[4].Cause)
+	assert.Equal(t, build.RebuildReason{Target: "CMakeFiles/app.dir/src/y.cpp.o", Cause: CauseMissingDepsInfo}, reasons[5])
+}
+
+func TestParseNinjaUpToDate(t *testing.T) {
+	assert.Empty(t, ParseNinja("ninja: no work to do.\n", time.Now()))
+}
+
+func TestParseBazel(t *testing.T) {
+	output := `Build options: --explain=/tmp/x.log --verbose_explanations
+Executing action 'Compiling src/main.cc': One of the files has changed: include/app.h.
+Executing action 'Compiling src/lib.cc': action command has changed.
+Executing action 'Linking app': no entry in the cache (action is new).
+Executing action 'Compiling src/env.cc': Effective client environment has changed.
+Executing action 'Compiling src/other.cc': One of the files has changed: BUILD.bazel.`
+
+	reasons := ParseBazel(output)
+	require.Len(t, reasons, 5)
+	assert.Equal(t, build.RebuildReason{Target: "Compiling src/main.cc", Cause: CauseTouchedHeader, Detail: "include/app.h"}, reasons[0])
+	assert.Equal(t, CauseChangedFlags, reasons[1].Cause)
+	assert.Equal(t, CauseNewAction, reasons[2].Cause)
+	assert.Equal(t, CauseEnvironment, reasons[3].Cause)
+	assert.Equal(t, CauseBuildFiles, reasons[4].Cause)
+}
+
+func TestClassifyFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"include/foo.hpp", CauseTouchedHeader},
+		{"src/foo.h", CauseTouchedHeader},
+		{"src/foo.cpp", CauseModifiedSource},
+		{"CMakeLists.txt", CauseBuildFiles},
+		{"cmake/deps.cmake", CauseBuildFiles},
+		{"meson.build", CauseBuildFiles},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyFile(tt.path))
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	reasons := []build.RebuildReason{
+		{Target: "a.o", Cause: CauseTouchedHeader, Detail: "common.hpp"},
+		{Target: "b.o", Cause: CauseTouchedHeader, Detail: "common.hpp"},
+		{Target: "c.o", Cause: CauseTouchedHeader, Detail: "other.hpp"},
+		{Target: "d.o", Cause: CauseChangedFlags},
+	}
+
+	summaries := Summarize(reasons)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, CauseTouchedHeader, summaries[0].Cause)
+	assert.Equal(t, 3, summaries[0].Count)
+	assert.Equal(t, []Culprit{{Name: "common.hpp", Count: 2}, {Name: "other.hpp", Count: 1}}, summaries[0].Culprits)
+	assert.Equal(t, CauseChangedFlags, summaries[1].Cause)
+	assert.Empty(t, summaries[1].Culprits)
+}
+
+func TestNinjaArgs(t *testing.T) {
+	args := NinjaArgs("builddir", build.ExplainOptions{DryRun: true, Target: "app"})
+	assert.Equal(t, []string{"-C", "builddir", "-d", "explain", "-n", "app"}, args)
+}
@@ -0,0 +1,52 @@
+package build
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// Shared caches of docker builds. Their contents are keyed by hashes of the
+// inputs, so all toolchains of a project can use the same ones.
+const (
+	VcpkgDownloadsCache = "vcpkg_downloads"
+	VcpkgBinaryCache    = "vcpkg_binary"
+	BazelRepoCache      = "bazel_repo_cache"
+	BazelDiskCache      = "bazel_disk_cache"
+)
+
+// CacheMount returns the docker run -v argument that mounts the build cache
+// name at containerPath. Caches are directories in the project's .cache/ci,
+// or named docker volumes with CacheVolumes, so that repeated builds are
+// incremental either way.
+func (o DockerBuildOptions) CacheMount(name, containerPath string) (string, error) {
+	if o.CacheVolumes {
+		return CacheVolume(o.ProjectRoot, name) + ":" + containerPath, nil
+	}
+	dir, err := filepath.Abs(filepath.Join(config.DirsOf(o.ProjectRoot).Cache, "ci", name))
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path for cache %s: %w", name, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory %s: %w", name, err)
+	}
+	return dir + ":" + containerPath, nil
+}
+
+var volumeUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// CacheVolume names the docker volume holding the build cache name of the
+// project at root. The path hash keeps projects with the same directory name
+// apart.
+func CacheVolume(root, name string) string {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	sum := sha256.Sum256([]byte(root))
+	return fmt.Sprintf("cpx-%s-%x-%s", volumeUnsafe.ReplaceAllString(filepath.Base(root), "_"), sum[:4],
+		volumeUnsafe.ReplaceAllString(name, "-"))
+}
@@ -0,0 +1,31 @@
+package build
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheMount(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "my app")
+	opts := DockerBuildOptions{ProjectRoot: root}
+
+	arg, err := opts.CacheMount("linux/.vcpkg_cache", "/tmp/.vcpkg_cache")
+	require.NoError(t, err)
+	dir := filepath.Join(root, ".cache", "ci", "linux", ".vcpkg_cache")
+	assert.Equal(t, dir+":/tmp/.vcpkg_cache", arg)
+	assert.DirExists(t, dir)
+
+	opts.CacheVolumes = true
+	arg, err = opts.CacheMount("linux/.vcpkg_cache", "/tmp/.vcpkg_cache")
+	require.NoError(t, err)
+	volume, path, _ := strings.Cut(arg, ":")
+	assert.Regexp(t, `^cpx-my_app-[0-9a-f]{8}-linux-.vcpkg_cache$`, volume)
+	assert.Equal(t, "/tmp/.vcpkg_cache", path)
+
+	// projects in different directories get their own volumes
+	assert.NotEqual(t, CacheVolume(root, VcpkgBinaryCache), CacheVolume(filepath.Join(t.TempDir(), "my app"), VcpkgBinaryCache))
+}
@@ -0,0 +1,445 @@
+package build
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// crashDir is where crash reports are written, one directory per crash.
+var crashDir = func() string { return filepath.Join(config.CacheDir(), "crashes") }
+
+// crashSignals are the signals a program is killed with when it crashes,
+// as opposed to being stopped by the user or a time limit.
+var crashSignals = []syscall.Signal{
+	syscall.SIGSEGV, syscall.SIGABRT, syscall.SIGBUS, syscall.SIGFPE, syscall.SIGILL, syscall.SIGTRAP,
+}
+
+// coreDebuggers print the stacks of every thread of the program {exe} from
+// its core dump {core}, in order of preference.
+var coreDebuggers = [][]string{
+	{"gdb", "-batch", "-ex", "thread apply all bt", "{exe}", "{core}"},
+	{"lldb", "--batch", "-c", "{core}", "{exe}", "-o", "thread backtrace all"},
+}
+
+// rerunDebuggers run {exe} with its arguments {args...} again under a
+// debugger and print the stacks of every thread when it crashes, for when
+// no core dump was written.
+var rerunDebuggers = [][]string{
+	{"gdb", "-batch", "-ex", "run", "-ex", "thread apply all bt", "--args", "{exe}", "{args...}"},
+	{"lldb", "--batch", "-o", "run", "-k", "thread backtrace all", "--", "{exe}", "{args...}"},
+}
+
+// Crash is an executable of the project that was killed by a signal.
+type Crash struct {
+	// Exe is the path of the executable.
+	Exe string
+
+	// Args are its arguments, nil when unknown.
+	Args []string
+
+	// Env is its environment, nil for the one of cpx.
+	Env []string
+
+	// Dir is its working directory, "" for the one of cpx.
+	Dir string
+
+	// PID is its process id.
+	PID int
+
+	// Signal is the name of the signal that killed it.
+	Signal string
+
+	// Core is its core dump, "" until one is found.
+	Core string
+}
+
+// CrashOf returns the crash of cmd when err, the result of running it,
+// says it was killed by a crash signal, or nil.
+func CrashOf(cmd *exec.Cmd, err error) *Crash {
+	var exitErr *exec.ExitError
+	if cmd.Process == nil || !errors.As(err, &exitErr) {
+		return nil
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || !slices.Contains(crashSignals, status.Signal()) {
+		return nil
+	}
+	return &Crash{
+		Exe:    cmd.Path,
+		Args:   slices.Clone(cmd.Args[1:]),
+		Env:    cmd.Env,
+		Dir:    cmd.Dir,
+		PID:    cmd.Process.Pid,
+		Signal: status.Signal().String(),
+	}
+}
+
+// ReportCrash writes the report of crash to .cache/crashes and prints its
+// backtrace to stderr. It does nothing when crash is nil.
+func ReportCrash(crash *Crash) {
+	if crash == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n💥 %s crashed (%s)\n", filepath.Base(crash.Exe), crash.Signal)
+	dir, err := crash.Report(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not complete the crash report: %v\n", err)
+	}
+	if dir != "" {
+		fmt.Fprintf(os.Stderr, "Crash report saved to %s\n", dir)
+	}
+}
+
+// Report writes a crash report to a new directory of .cache/crashes: the
+// command line that crashed, a copy of the executable, its core dump when
+// one can be found, and a symbolized backtrace, which is also written to w.
+// Without a core dump the backtrace comes from running the command again
+// under gdb or lldb. It returns the report directory.
+func (c *Crash) Report(w io.Writer) (string, error) {
+	name := filepath.Base(c.Exe)
+	dir := filepath.Join(crashDir(), name+"-"+time.Now().Format("20060102-150405")+"-"+strconv.Itoa(c.PID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	if c.Core == "" {
+		c.Core = findCore(c.Exe, c.PID, c.Dir, filepath.Join(dir, "core"))
+	}
+	if c.Core != "" && filepath.Dir(c.Core) != dir {
+		// Keep the core dump with the report; it stays where it is when it
+		// cannot be moved, e.g. across file systems
+		moved := filepath.Join(dir, filepath.Base(c.Core))
+		if err := os.Rename(c.Core, moved); err == nil {
+			c.Core = moved
+		}
+	}
+
+	exe := filepath.Join(dir, name)
+	if err := copyFile(c.Exe, exe); err != nil {
+		exe = c.Exe
+	}
+
+	args := "(unknown, see the backtrace)"
+	if c.Args != nil {
+		args = strings.Join(c.Args, " ")
+	}
+	core := c.Core
+	if core == "" {
+		core = "(none found)"
+	}
+	command := fmt.Sprintf("Executable: %s\nArguments: %s\nSignal: %s\nPID: %d\nTime: %s\nCore: %s\n",
+		c.Exe, args, c.Signal, c.PID, time.Now().Format(time.RFC3339), core)
+	if err := os.WriteFile(filepath.Join(dir, "command.txt"), []byte(command), 0644); err != nil {
+		return dir, err
+	}
+
+	var backtrace bytes.Buffer
+	err := c.backtrace(exe, io.MultiWriter(w, &backtrace))
+	if backtrace.Len() > 0 {
+		if werr := os.WriteFile(filepath.Join(dir, "backtrace.txt"), backtrace.Bytes(), 0644); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return dir, err
+}
+
+// backtrace writes the stacks of the crashed program to w, read from its
+// core dump or caught by running exe again under a debugger.
+func (c *Crash) backtrace(exe string, w io.Writer) error {
+	debuggers := coreDebuggers
+	if c.Core == "" {
+		if c.Args == nil {
+			return fmt.Errorf("no core dump found for %s\n  hint: enable core dumps with 'ulimit -c unlimited'", filepath.Base(c.Exe))
+		}
+		fmt.Fprintf(w, "No core dump found; running %s again under a debugger\n", filepath.Base(c.Exe))
+		debuggers = rerunDebuggers
+	}
+
+	for _, debugger := range debuggers {
+		if _, err := exec.LookPath(debugger[0]); err != nil {
+			continue
+		}
+		var args []string
+		for _, arg := range debugger[1:] {
+			if arg == "{args...}" {
+				args = append(args, c.Args...)
+				continue
+			}
+			args = append(args, strings.NewReplacer("{exe}", exe, "{core}", c.Core).Replace(arg))
+		}
+		cmd := exec.Command(debugger[0], args...)
+		cmd.Env = c.Env
+		cmd.Dir = c.Dir
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s failed: %w", debugger[0], err)
+		}
+		return nil
+	}
+	return fmt.Errorf("no gdb or lldb found for a backtrace")
+}
+
+// findCore returns the core dump process pid of exe left, or "" when there
+// is none. dir is the working directory of the process, and dest where a
+// core dump held by systemd-coredump is written.
+func findCore(exe string, pid int, dir, dest string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		core := fmt.Sprintf("/cores/core.%d", pid)
+		if _, err := os.Stat(core); err == nil {
+			return core
+		}
+	case "linux":
+		data, err := os.ReadFile("/proc/sys/kernel/core_pattern")
+		if err != nil {
+			return ""
+		}
+		pattern := strings.TrimSpace(string(data))
+		if strings.HasPrefix(pattern, "|") {
+			if strings.Contains(pattern, "systemd-coredump") && dumpCoredumpctl(pid, dest) {
+				return dest
+			}
+			return ""
+		}
+		usesPid, _ := os.ReadFile("/proc/sys/kernel/core_uses_pid")
+		pattern = expandCorePattern(pattern, exe, pid, strings.TrimSpace(string(usesPid)) == "1")
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, _ := filepath.Glob(pattern)
+		return newestFile(matches)
+	}
+	return ""
+}
+
+// expandCorePattern expands the specifiers of a kernel core_pattern that
+// are known for a crashed process into a glob matching its core dump.
+func expandCorePattern(pattern, exe string, pid int, usesPid bool) string {
+	comm := filepath.Base(exe)
+	if len(comm) > 15 {
+		// The kernel truncates process names to 15 characters
+		comm = comm[:15]
+	}
+	var b strings.Builder
+	hasPid := false
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i+1 == len(pattern) {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case '%':
+			b.WriteByte('%')
+		case 'p', 'P':
+			hasPid = true
+			b.WriteString(strconv.Itoa(pid))
+		case 'e':
+			b.WriteString(comm)
+		case 'E':
+			b.WriteString(strings.ReplaceAll(exe, "/", "!"))
+		default:
+			b.WriteString("*")
+		}
+	}
+	if usesPid && !hasPid {
+		b.WriteString("." + strconv.Itoa(pid))
+	}
+	return b.String()
+}
+
+// dumpCoredumpctl writes the core dump systemd-coredump holds for process
+// pid to dest. The dump is stored asynchronously, so it is retried briefly.
+func dumpCoredumpctl(pid int, dest string) bool {
+	if _, err := exec.LookPath("coredumpctl"); err != nil {
+		return false
+	}
+	for range 5 {
+		if exec.Command("coredumpctl", "dump", "--no-pager", "-q", "-o", dest, strconv.Itoa(pid)).Run() == nil {
+			return true
+		}
+		time.Sleep(time.Second)
+	}
+	return false
+}
+
+// coredumpctlEntry is a crash listed by coredumpctl --json=short.
+type coredumpctlEntry struct {
+	PID int    `json:"pid"`
+	Sig int    `json:"sig"`
+	Exe string `json:"exe"`
+}
+
+// FindCrashes returns the programs that crashed since since and left a
+// core dump, either held by systemd-coredump or written to one of dirs.
+// Their arguments are unknown; the backtrace of the report shows them.
+func FindCrashes(since time.Time, dirs []string) []*Crash {
+	var crashes []*Crash
+	if runtime.GOOS == "linux" {
+		if out, err := exec.Command("coredumpctl", "list", "--json=short", "--no-pager", "-q",
+			fmt.Sprintf("--since=@%d", since.Unix())).Output(); err == nil {
+			var entries []coredumpctlEntry
+			if json.Unmarshal(out, &entries) == nil {
+				for _, e := range entries {
+					crashes = append(crashes, &Crash{Exe: e.Exe, PID: e.PID, Signal: syscall.Signal(e.Sig).String()})
+				}
+			}
+		}
+	}
+
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" || d.Name() == "vcpkg_installed" || d.Name() == "crashes" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasPrefix(d.Name(), "core") {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.ModTime().Before(since) {
+				return nil
+			}
+			if crash := crashOfCore(path); crash != nil {
+				crashes = append(crashes, crash)
+			}
+			return nil
+		})
+	}
+	return crashes
+}
+
+// crashOfCore returns the crash an ELF core dump was written for, or nil
+// when path is not one. The executable is taken from the command line the
+// kernel records in the dump, relative to the directory of the dump.
+func crashOfCore(path string) *Crash {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	if f.Type != elf.ET_CORE {
+		return nil
+	}
+
+	crash := &Crash{Core: path, Signal: "unknown signal"}
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_NOTE {
+			continue
+		}
+		data, err := io.ReadAll(prog.Open())
+		if err != nil {
+			continue
+		}
+		if pid, args, ok := parsePrpsinfo(data, f.Class, f.ByteOrder); ok {
+			crash.PID = pid
+			if len(args) > 0 {
+				crash.Exe = args[0]
+				if !filepath.IsAbs(crash.Exe) {
+					crash.Exe = filepath.Join(filepath.Dir(path), crash.Exe)
+				}
+			}
+		}
+	}
+	if crash.Exe == "" {
+		return nil
+	}
+	return crash
+}
+
+// parsePrpsinfo finds the NT_PRPSINFO note among the notes of a core dump
+// and returns the process id and the command line it records, truncated
+// by the kernel to 80 bytes.
+func parsePrpsinfo(notes []byte, class elf.Class, order binary.ByteOrder) (pid int, args []string, ok bool) {
+	// Offsets of pr_pid and pr_psargs in struct elf_prpsinfo
+	pidOff, argsOff := 24, 56
+	if class == elf.ELFCLASS32 {
+		pidOff, argsOff = 12, 44
+	}
+	const ntPrpsinfo = 3
+	for len(notes) >= 12 {
+		nameSize := int(order.Uint32(notes[0:]))
+		descSize := int(order.Uint32(notes[4:]))
+		noteType := order.Uint32(notes[8:])
+		descStart := 12 + align4(nameSize)
+		descEnd := descStart + descSize
+		if descEnd > len(notes) {
+			return 0, nil, false
+		}
+		desc := notes[descStart:descEnd]
+		if noteType == ntPrpsinfo && len(desc) >= argsOff+80 {
+			psargs := desc[argsOff : argsOff+80]
+			if i := bytes.IndexByte(psargs, 0); i >= 0 {
+				psargs = psargs[:i]
+			}
+			return int(order.Uint32(desc[pidOff:])), strings.Fields(string(psargs)), true
+		}
+		notes = notes[min(len(notes), descStart+align4(descSize)):]
+	}
+	return 0, nil, false
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
+
+// newestFile returns the most recently modified of paths, or "".
+func newestFile(paths []string) string {
+	newest := ""
+	var newestTime time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = path, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// copyFile copies the file src to dst with its permissions.
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,119 @@
+package build
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrashOf(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	cmd := exec.Command("sh", "-c", "kill -SEGV $$", "crasher", "--fast")
+	crash := CrashOf(cmd, cmd.Run())
+	require.NotNil(t, crash)
+	assert.Equal(t, cmd.Path, crash.Exe)
+	assert.Equal(t, []string{"-c", "kill -SEGV $$", "crasher", "--fast"}, crash.Args)
+	assert.Equal(t, cmd.Process.Pid, crash.PID)
+	assert.Equal(t, "segmentation fault", crash.Signal)
+
+	cmd = exec.Command("sh", "-c", "kill -9 $$")
+	assert.Nil(t, CrashOf(cmd, cmd.Run()), "a killed program did not crash")
+	cmd = exec.Command("sh", "-c", "exit 1")
+	assert.Nil(t, CrashOf(cmd, cmd.Run()))
+	cmd = exec.Command("cpx-no-such-program")
+	assert.Nil(t, CrashOf(cmd, cmd.Run()))
+}
+
+func TestExpandCorePattern(t *testing.T) {
+	assert.Equal(t, "core", expandCorePattern("core", "/bin/app", 42, false))
+	assert.Equal(t, "core.42", expandCorePattern("core", "/bin/app", 42, true))
+	assert.Equal(t, "/tmp/cores/app.42.*", expandCorePattern("/tmp/cores/%e.%p.%t", "/bin/app", 42, true))
+	assert.Equal(t, "core-averylongexecut", expandCorePattern("core-%e", "averylongexecutablename", 42, false)[:20])
+	assert.Equal(t, "!bin!app-%", expandCorePattern("%E-%%", "/bin/app", 42, false))
+}
+
+func TestCrashReport(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	oldDir, oldCore, oldRerun := crashDir, coreDebuggers, rerunDebuggers
+	t.Cleanup(func() { crashDir, coreDebuggers, rerunDebuggers = oldDir, oldCore, oldRerun })
+	reports := t.TempDir()
+	crashDir = func() string { return reports }
+	coreDebuggers = [][]string{{"cpx-no-such-debugger"}, {"echo", "bt", "{core}"}}
+	rerunDebuggers = [][]string{{"echo", "rerun", "{args...}"}}
+
+	exe := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(exe, []byte("#!/bin/sh\n"), 0755))
+	core := filepath.Join(t.TempDir(), "core.7")
+	require.NoError(t, os.WriteFile(core, []byte("core"), 0644))
+
+	var buf bytes.Buffer
+	crash := &Crash{Exe: exe, Args: []string{"--input", "data.txt"}, PID: 7, Signal: "aborted", Core: core}
+	dir, err := crash.Report(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, reports, filepath.Dir(dir))
+	assert.FileExists(t, filepath.Join(dir, "app"), "the executable is kept with the report")
+	assert.FileExists(t, filepath.Join(dir, "core.7"), "the core dump is moved into the report")
+	assert.NoFileExists(t, core)
+	command, err := os.ReadFile(filepath.Join(dir, "command.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(command), "Executable: "+exe+"\nArguments: --input data.txt\nSignal: aborted\nPID: 7\n")
+	backtrace, err := os.ReadFile(filepath.Join(dir, "backtrace.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "bt "+filepath.Join(dir, "core.7")+"\n", string(backtrace))
+	assert.Equal(t, string(backtrace), buf.String())
+
+	// Without a core dump the program is run again under the debugger
+	buf.Reset()
+	crash = &Crash{Exe: exe, Args: []string{"--input", "data.txt"}, PID: -1, Signal: "aborted"}
+	_, err = crash.Report(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "running app again under a debugger\nrerun --input data.txt\n")
+
+	crash = &Crash{Exe: exe, PID: -1, Signal: "aborted"}
+	_, err = crash.Report(&buf)
+	assert.ErrorContains(t, err, "no core dump found for app")
+}
+
+func TestParsePrpsinfo(t *testing.T) {
+	desc := make([]byte, 136)
+	binary.LittleEndian.PutUint32(desc[24:], 4242)
+	copy(desc[56:], "./build/app_tests --gtest_filter=Math.*")
+	note := func(noteType uint32, desc []byte) []byte {
+		b := binary.LittleEndian.AppendUint32(nil, 5)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(desc)))
+		b = binary.LittleEndian.AppendUint32(b, noteType)
+		b = append(b, "CORE\x00\x00\x00\x00"...)
+		return append(b, desc...)
+	}
+	notes := append(note(1, []byte{1, 2, 3, 4}), note(3, desc)...)
+
+	pid, args, ok := parsePrpsinfo(notes, elf.ELFCLASS64, binary.LittleEndian)
+	require.True(t, ok)
+	assert.Equal(t, 4242, pid)
+	assert.Equal(t, []string{"./build/app_tests", "--gtest_filter=Math.*"}, args)
+
+	_, _, ok = parsePrpsinfo(note(1, nil), elf.ELFCLASS64, binary.LittleEndian)
+	assert.False(t, ok)
+}
+
+func TestFindCrashesSkipsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "core"), []byte("not an ELF file"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "core.cpp"), []byte("int main() {}"), 0644))
+	for _, crash := range FindCrashes(time.Now().Add(-time.Minute), []string{dir}) {
+		assert.NotEqual(t, dir, filepath.Dir(crash.Core))
+	}
+}
@@ -0,0 +1,75 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// cudaArchRe matches a GPU architecture as a compute capability (86, 8.6)
+// or an nvcc name (sm_86, compute_86), with CMake's -real/-virtual suffix.
+var cudaArchRe = regexp.MustCompile(`^(?:sm_|compute_)?(\d+)(?:\.(\d))?(-real|-virtual)?$`)
+
+// cudaArchKeywords are the architecture lists CMake and Meson name, with
+// their Meson spelling.
+var cudaArchKeywords = map[string]string{
+	"native":    "Auto",
+	"all":       "All",
+	"all-major": "Common",
+}
+
+// ParseCUDAArchitectures validates GPU architectures and returns them in
+// CMake's CMAKE_CUDA_ARCHITECTURES form: sm_86 and 8.6 become 86,
+// compute_86 becomes 86-virtual, and native, all and all-major are kept.
+func ParseCUDAArchitectures(archs []string) ([]string, error) {
+	var parsed []string
+	for _, arch := range archs {
+		arch = strings.ToLower(strings.TrimSpace(arch))
+		if arch == "" {
+			continue
+		}
+		if _, ok := cudaArchKeywords[arch]; ok {
+			if len(archs) > 1 {
+				return nil, fmt.Errorf("CUDA architecture '%s' cannot be combined with others", arch)
+			}
+			parsed = append(parsed, arch)
+			continue
+		}
+		m := cudaArchRe.FindStringSubmatch(arch)
+		if m == nil {
+			return nil, fmt.Errorf("invalid CUDA architecture '%s' (use sm_xx, a compute capability like 8.6, native, all or all-major)", arch)
+		}
+		suffix := m[3]
+		if suffix == "" && strings.HasPrefix(arch, "compute_") {
+			suffix = "-virtual"
+		}
+		parsed = append(parsed, m[1]+m[2]+suffix)
+	}
+	return parsed, nil
+}
+
+// CMakeCUDAArchitectures returns parsed architectures as a
+// CMAKE_CUDA_ARCHITECTURES value.
+func CMakeCUDAArchitectures(archs []string) string {
+	return strings.Join(archs, ";")
+}
+
+// MesonCUDAArchitectures returns parsed architectures in the form of the
+// cuda module's nvcc_arch_flags(): 86 becomes 8.6, a virtual architecture
+// 8.6+PTX and native, all and all-major Auto, All and Common.
+func MesonCUDAArchitectures(archs []string) []string {
+	var meson []string
+	for _, arch := range archs {
+		if keyword, ok := cudaArchKeywords[arch]; ok {
+			meson = append(meson, keyword)
+			continue
+		}
+		number, suffix, _ := strings.Cut(arch, "-")
+		capability := number[:len(number)-1] + "." + number[len(number)-1:]
+		if suffix == "virtual" {
+			capability += "+PTX"
+		}
+		meson = append(meson, capability)
+	}
+	return meson
+}
@@ -0,0 +1,41 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCUDAArchitectures(t *testing.T) {
+	tests := []struct {
+		name      string
+		archs     []string
+		wantCMake string
+		wantMeson []string
+		wantErr   string
+	}{
+		{name: "empty"},
+		{name: "sm", archs: []string{"sm_75", "SM_86"}, wantCMake: "75;86", wantMeson: []string{"7.5", "8.6"}},
+		{name: "capability", archs: []string{"8.9", "90"}, wantCMake: "89;90", wantMeson: []string{"8.9", "9.0"}},
+		{name: "three digits", archs: []string{"sm_120"}, wantCMake: "120", wantMeson: []string{"12.0"}},
+		{name: "virtual", archs: []string{"compute_80", "86-real"}, wantCMake: "80-virtual;86-real", wantMeson: []string{"8.0+PTX", "8.6"}},
+		{name: "native", archs: []string{"native"}, wantCMake: "native", wantMeson: []string{"Auto"}},
+		{name: "all-major", archs: []string{"all-major"}, wantCMake: "all-major", wantMeson: []string{"Common"}},
+		{name: "keyword and number", archs: []string{"native", "sm_86"}, wantErr: "cannot be combined"},
+		{name: "invalid", archs: []string{"ampere"}, wantErr: "invalid CUDA architecture 'ampere'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCUDAArchitectures(tt.archs)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCMake, CMakeCUDAArchitectures(got))
+			assert.Equal(t, tt.wantMeson, MesonCUDAArchitectures(got))
+		})
+	}
+}
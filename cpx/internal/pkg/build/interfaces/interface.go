@@ -6,6 +6,12 @@ package build
 
 import (
 	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/container"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
 // DockerBuildOptions contains options for Docker-based builds.
@@ -25,6 +31,9 @@ type DockerBuildOptions struct {
 	// Optimization is the optimization level.
 	Optimization string
 
+	// Sanitizer is the sanitizer list to build with ("asan", "asan,ubsan").
+	Sanitizer string
+
 	// CMakeArgs are additional CMake arguments.
 	CMakeArgs []string
 
@@ -52,19 +61,144 @@ type DockerBuildOptions struct {
 	// Platform is the Docker platform (e.g., linux/amd64).
 	Platform string
 
+	// GPUs are passed to docker run --gpus (e.g. "all").
+	GPUs string
+
 	// TargetName is the name of the toolchain/target.
 	TargetName string
 
+	// CacheVolumes keeps the build caches in named docker volumes instead
+	// of the project's .cache/ci directory.
+	CacheVolumes bool
+
+	// Runtime is the container CLI running the build (default: the
+	// configured or detected one).
+	Runtime container.Runtime
+
 	// Verbose enables verbose output.
 	Verbose bool
 }
 
+// ContainerCommand returns the command running the build's container CLI
+// with args.
+func (o DockerBuildOptions) ContainerCommand(args ...string) *exec.Cmd {
+	if o.Runtime == nil {
+		return container.Default().Command(args...)
+	}
+	return o.Runtime.Command(args...)
+}
+
 // DockerBuilder defines the interface for Docker-based builds.
 type DockerBuilder interface {
 	// RunDockerBuild runs a build inside a Docker container.
 	RunDockerBuild(ctx context.Context, opts DockerBuildOptions) error
 }
 
+// ExplainOptions contains options for explaining why targets are rebuilt.
+type ExplainOptions struct {
+	// Release selects the release build variant.
+	Release bool
+
+	// OptLevel selects the optimization level variant (0, 1, 2, 3, s, fast).
+	OptLevel string
+
+	// Sanitizer selects the sanitizer build variant (e.g. asan or asan,ubsan).
+	Sanitizer string
+
+	// Target restricts the explanation to a specific build target (optional).
+	Target string
+
+	// DryRun reports what would be rebuilt without building anything.
+	DryRun bool
+
+	// Verbose prints the raw explanation output of the build tool.
+	Verbose bool
+}
+
+// RebuildReason describes why a single target was considered dirty.
+type RebuildReason struct {
+	// Target is the output or action that was rebuilt.
+	Target string `json:"target"`
+
+	// Cause is the root cause category (see package explain).
+	Cause string `json:"cause"`
+
+	// Detail is the file or setting that triggered the rebuild, if known.
+	Detail string `json:"detail,omitempty"`
+}
+
+// RebuildExplainer defines the interface for build systems that can explain
+// why targets were considered dirty.
+type RebuildExplainer interface {
+	// ExplainRebuild runs the build with explanations enabled and returns the
+	// reasons reported by the underlying build tool.
+	ExplainRebuild(ctx context.Context, opts ExplainOptions) ([]RebuildReason, error)
+}
+
+// PackageOptions contains options for packaging a library.
+type PackageOptions struct {
+	// OutputDir is where the archive is written (default: dist).
+	OutputDir string
+
+	// Verbose shows the full build output.
+	Verbose bool
+}
+
+// Packager defines the interface for build systems that can package a
+// library for consumers: headers, libraries and the exported build config.
+type Packager interface {
+	// Package builds and installs the project in release mode and archives
+	// the installed tree. It returns the path of the archive.
+	Package(ctx context.Context, opts PackageOptions) (string, error)
+}
+
+// PublishOptions contains options for publishing a library to a registry.
+type PublishOptions struct {
+	// Registry is the git URL of the registry repository.
+	Registry string
+
+	// SourceURL is the URL of the source archive the port downloads
+	// (default: the GitHub tag archive v<version> of the origin remote).
+	SourceURL string
+
+	// Branch is the registry branch the port is committed to
+	// (default: cpx/<name>-<version>).
+	Branch string
+
+	// NoPush keeps the commit in a local clone instead of pushing it and
+	// opening a pull request.
+	NoPush bool
+
+	// Conan writes a Conan recipe (conanfile.py and test_package) into the
+	// project instead of publishing to Registry.
+	Conan bool
+}
+
+// Publisher defines the interface for build systems that can publish a
+// library to a package registry.
+type Publisher interface {
+	// Publish adds the current version of the project to the registry.
+	Publish(ctx context.Context, opts PublishOptions) error
+}
+
+// PathDependencyAdder defines the interface for build systems that can depend
+// on another cpx project on disk, such as a sibling library in the same
+// repository, building it from source with the project.
+type PathDependencyAdder interface {
+	// AddPathDependency wires the project in dir (relative to the current
+	// project or absolute) into the build.
+	AddPathDependency(ctx context.Context, dir string) error
+}
+
+// SubmoduleDependencyAdder defines the interface for build systems that can
+// build a third-party library checked out as a git submodule, which need
+// not be a cpx project, from source with the project.
+type SubmoduleDependencyAdder interface {
+	// AddSubmoduleDependency wires the library checked out in dir, relative
+	// to the current project, into the build under name.
+	AddSubmoduleDependency(ctx context.Context, name, dir string) error
+}
+
 // DependencyInfo contains detailed information about a package
 type DependencyInfo struct {
 	Name         string   `json:"name"`
@@ -73,6 +207,7 @@ type DependencyInfo struct {
 	Homepage     string   `json:"homepage"`
 	License      string   `json:"license"`
 	Dependencies []string `json:"dependencies"`
+	Versions     []string `json:"versions,omitempty"`
 }
 
 // BuildSystem defines the interface for all build system implementations.
@@ -136,13 +271,17 @@ type InitConfig struct {
 	CppStandard   int
 	TestFramework string
 	Benchmark     string
+	SharedLibrary bool
+	PCH           bool
+	Modules       bool
+	HeaderOnly    bool // Library with no sources: an INTERFACE/hdrs-only target
 }
 
 // Dependency represents a project dependency.
 type Dependency struct {
-	Name        string
-	Version     string
-	Description string
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 // BuildOptions contains options for building a project.
@@ -153,7 +292,7 @@ type BuildOptions struct {
 	// OptLevel overrides the optimization level (0, 1, 2, 3, s, fast).
 	OptLevel string
 
-	// Sanitizer specifies the sanitizer to use (asan, tsan, msan, ubsan).
+	// Sanitizer specifies the sanitizers to use (e.g. asan or asan,ubsan).
 	Sanitizer string
 
 	// Target specifies a specific build target (optional).
@@ -170,6 +309,65 @@ type BuildOptions struct {
 
 	// Toolchain specifies a custom toolchain to use.
 	Toolchain string
+
+	// ExtraArgs are additional arguments for the configure step
+	// (cmake, meson setup) or for bazel build.
+	ExtraArgs []string
+
+	// Defines are preprocessor macros (NAME or NAME=VALUE) defined for
+	// every compiled file.
+	Defines []string
+
+	// LibraryType builds the project's libraries as LibraryStatic or
+	// LibraryShared; empty keeps the project's default.
+	LibraryType string
+
+	// PCH turns the project's precompiled header on or off; nil keeps the
+	// build files' default.
+	PCH *bool
+
+	// CUDAArchitectures are the GPU architectures of a CUDA project, as
+	// returned by ParseCUDAArchitectures; empty keeps the build files'
+	// default.
+	CUDAArchitectures []string
+
+	// Preset is a configure or build preset of the project's CMake presets
+	// files to build with instead of Release, OptLevel and Sanitizer. CMake
+	// projects only.
+	Preset string
+
+	// Reproducible builds bit-identical artifacts from the same sources:
+	// source paths are mapped to relative ones and archives carry no
+	// timestamps. The caller sets ReproducibleEnv in the environment.
+	Reproducible bool
+
+	// DeveloperID signs the executables of macOS builds with a Developer ID
+	// certificate instead of ad hoc; nil keeps ad-hoc signatures.
+	DeveloperID *config.MacOSSigningConfig
+}
+
+// Library types, also the subdirectories of an output directory that
+// libraries of each type are copied to.
+const (
+	LibraryStatic = "static"
+	LibraryShared = "shared"
+)
+
+// TargetPicker lets the user choose one of several candidate targets. It
+// returns "" to keep the default: the first executable or all tests.
+type TargetPicker func(candidates []string) (string, error)
+
+// Choose returns the candidate the user picks, or def when there is nothing
+// to choose from or no picker.
+func (p TargetPicker) Choose(candidates []string, def string) (string, error) {
+	if p == nil || len(candidates) < 2 {
+		return def, nil
+	}
+	choice, err := p(candidates)
+	if err != nil || choice == "" {
+		return def, err
+	}
+	return choice, nil
 }
 
 // TestOptions contains options for running tests.
@@ -180,8 +378,35 @@ type TestOptions struct {
 	// Filter filters tests by name pattern.
 	Filter string
 
+	// Labels runs only the tests with one of these labels: ctest labels,
+	// Bazel tags or Meson suites.
+	Labels []string
+
+	// ExcludeLabels skips the tests with one of these labels.
+	ExcludeLabels []string
+
+	// Exclude is a regular expression of test names to skip.
+	Exclude string
+
+	// RerunFailed runs only the tests that failed in the last run.
+	RerunFailed bool
+
+	// Timeout limits how long each test may run; 0 keeps the build
+	// system's default.
+	Timeout time.Duration
+
+	// SessionTimeout limits how long the whole test run may take; 0 means
+	// no limit.
+	SessionTimeout time.Duration
+
+	// Sanitizer builds and runs the tests with these sanitizers.
+	Sanitizer string
+
 	// Toolchain specifies a custom toolchain to use.
 	Toolchain string
+
+	// Pick chooses the test to run when no filter is given.
+	Pick TargetPicker
 }
 
 // RunOptions contains options for running the project.
@@ -206,6 +431,19 @@ type RunOptions struct {
 
 	// Toolchain specifies a custom toolchain to use.
 	Toolchain string
+
+	// Wrapper is a command the executable is run under, e.g. a profiler.
+	Wrapper []string
+
+	// Pick chooses the executable when there are several and no target.
+	Pick TargetPicker
+
+	// Timeout kills the executable when it runs longer; 0 means no limit.
+	Timeout time.Duration
+
+	// DumpOnTimeout prints the stacks of the executable's threads before it
+	// is killed at Timeout.
+	DumpOnTimeout bool
 }
 
 // BenchOptions contains options for running benchmarks.
@@ -218,6 +456,12 @@ type BenchOptions struct {
 
 	// Toolchain specifies a custom toolchain to use.
 	Toolchain string
+
+	// Wrapper is a command the benchmark executable is run under.
+	Wrapper []string
+
+	// Pick chooses the benchmark when there are several and no target.
+	Pick TargetPicker
 }
 
 // CleanOptions contains options for cleaning build artifacts.
@@ -271,7 +515,20 @@ func GetOutputDir(release bool, optLevel, sanitizer string) string {
 		outDirName = "release"
 	}
 	if sanitizer != "" {
-		outDirName += "-" + sanitizer
+		outDirName += "-" + strings.Join(SplitSanitizer(sanitizer), "-")
 	}
 	return outDirName
 }
+
+// PresetOutputDir returns the output directory of a build with a CMake
+// preset.
+func PresetOutputDir(preset string) string {
+	return "preset-" + preset
+}
+
+// WrapCommand returns the command line that runs exe with args under
+// wrapper (see RunOptions.Wrapper).
+func WrapCommand(wrapper []string, exe string, args ...string) []string {
+	argv := append(append([]string{}, wrapper...), exe)
+	return append(argv, args...)
+}
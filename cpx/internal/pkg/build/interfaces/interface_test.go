@@ -0,0 +1,39 @@
+package build
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetPickerChoose(t *testing.T) {
+	var nilPicker TargetPicker
+	choice, err := nilPicker.Choose([]string{"a", "b"}, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", choice)
+
+	calls := 0
+	pick := TargetPicker(func(candidates []string) (string, error) {
+		calls++
+		return candidates[len(candidates)-1], nil
+	})
+	choice, err = pick.Choose([]string{"only"}, "default")
+	require.NoError(t, err)
+	assert.Equal(t, "default", choice, "a single candidate is not offered")
+	assert.Zero(t, calls)
+
+	choice, err = pick.Choose([]string{"a", "b"}, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "b", choice)
+
+	keep := TargetPicker(func([]string) (string, error) { return "", nil })
+	choice, err = keep.Choose([]string{"a", "b"}, "//...")
+	require.NoError(t, err)
+	assert.Equal(t, "//...", choice)
+
+	cancel := TargetPicker(func([]string) (string, error) { return "", errors.New("no target selected") })
+	_, err = cancel.Choose([]string{"a", "b"}, "a")
+	assert.EqualError(t, err, "no target selected")
+}
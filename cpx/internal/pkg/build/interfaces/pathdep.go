@@ -0,0 +1,36 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PathDependencyDir checks that dir, the argument of "cpx add --path", is a
+// directory other than the current one and returns it relative to the
+// current directory, with forward slashes as build files expect.
+func PathDependencyDir(dir string) (string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("path dependency: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("path dependency %s is not a directory", dir)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil {
+		rel = abs // another volume
+	}
+	if rel == "." {
+		return "", fmt.Errorf("a project cannot depend on itself")
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,59 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// SourceDateEpoch returns the time, in seconds since the Unix epoch, that
+// reproducible builds record instead of the current time: $SOURCE_DATE_EPOCH
+// if set, else the commit time of HEAD of the git checkout at dir.
+func SourceDateEpoch(dir string) (int64, error) {
+	if v := os.Getenv("SOURCE_DATE_EPOCH"); v != "" {
+		epoch, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || epoch < 0 {
+			return 0, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q (use seconds since 1970)", v)
+		}
+		return epoch, nil
+	}
+	cmd := exec.Command("git", "log", "-1", "--format=%ct")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil || len(strings.TrimSpace(string(out))) == 0 {
+		return 0, fmt.Errorf("no commit to take the build time from\n  hint: commit the project or set SOURCE_DATE_EPOCH")
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
+
+// ReproducibleEnv returns the environment of a reproducible build: compilers
+// use SOURCE_DATE_EPOCH for __DATE__ and __TIME__, and ZERO_AR_DATE makes
+// the macOS archiver leave out timestamps.
+func ReproducibleEnv(epoch int64) []string {
+	return []string{
+		"SOURCE_DATE_EPOCH=" + strconv.FormatInt(epoch, 10),
+		"ZERO_AR_DATE=1",
+	}
+}
+
+// ReproducibleFlags returns the GCC and Clang flags that keep the absolute
+// path of the project at root out of objects and debug info, so builds in
+// different checkouts are identical. Its cache directory is mapped too when
+// it is outside the project.
+func ReproducibleFlags(root string) []string {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	flags := []string{"-ffile-prefix-map=" + abs + "=."}
+	cache := config.DirsOf(abs).Cache
+	if rel, err := filepath.Rel(abs, cache); err != nil || strings.HasPrefix(rel, "..") {
+		flags = append(flags, "-ffile-prefix-map="+cache+"=.cache")
+	}
+	return flags
+}
@@ -0,0 +1,64 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceDateEpoch(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	epoch, err := SourceDateEpoch(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), epoch)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = SourceDateEpoch(dir)
+	assert.ErrorContains(t, err, "invalid SOURCE_DATE_EPOCH")
+
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	_, err = SourceDateEpoch(dir)
+	assert.ErrorContains(t, err, "set SOURCE_DATE_EPOCH")
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=@1600000000 +0000", "GIT_AUTHOR_DATE=@1600000000 +0000")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	git("-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "init")
+	epoch, err = SourceDateEpoch(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1600000000), epoch)
+}
+
+func TestReproducibleEnv(t *testing.T) {
+	assert.Equal(t, []string{"SOURCE_DATE_EPOCH=1700000000", "ZERO_AR_DATE=1"}, ReproducibleEnv(1700000000))
+}
+
+func TestReproducibleFlags(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	assert.Equal(t, []string{"-ffile-prefix-map=" + root + "=."}, ReproducibleFlags(root))
+
+	// a cache directory outside the project is mapped too
+	cache := filepath.Join(t.TempDir(), "cache")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "cpx.yaml"), []byte("version: 1\ncache_dir: "+cache+"\n"), 0644))
+	assert.Equal(t, []string{
+		"-ffile-prefix-map=" + root + "=.",
+		"-ffile-prefix-map=" + cache + "=.cache",
+	}, ReproducibleFlags(root))
+}
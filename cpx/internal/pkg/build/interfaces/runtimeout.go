@@ -0,0 +1,105 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// RunTimeoutError is returned by RunProgram for an executable killed at its
+// time limit.
+type RunTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *RunTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s and was killed", e.Timeout)
+}
+
+// hangDir is where core files of timed out executables are written.
+var hangDir = func() string { return filepath.Join(config.CacheDir(), "hangs") }
+
+// stackDumpers are the commands that print the stacks of every thread of
+// the process {pid}, in order of preference; the last one writes a core
+// file instead, for when no debugger is installed.
+var stackDumpers = [][]string{
+	{"gdb", "-p", "{pid}", "-batch", "-ex", "thread apply all bt"},
+	{"lldb", "-p", "{pid}", "--batch", "-o", "thread backtrace all"},
+	{"gcore", "-o", "{core}", "{pid}"},
+}
+
+// RunProgram runs an executable of the project, killing it when it runs
+// longer than timeout, or never when timeout is 0. With dump, the stacks
+// of its threads are printed to stderr first, to show where it hung.
+func RunProgram(cmd *exec.Cmd, timeout time.Duration, dump bool) error {
+	if timeout <= 0 {
+		return cmd.Run()
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	fmt.Fprintf(os.Stderr, "\n⏱ Still running after %s\n", timeout)
+	if dump {
+		if err := DumpStacks(cmd.Process.Pid, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not dump the stacks: %v\n", err)
+		}
+	}
+	_ = cmd.Process.Kill()
+	<-done
+	return &RunTimeoutError{Timeout: timeout}
+}
+
+// DumpStacks writes the stacks of every thread of process pid to w by
+// attaching gdb or lldb, or writes a core file with gcore when neither is
+// installed.
+func DumpStacks(pid int, w io.Writer) error {
+	for _, dumper := range stackDumpers {
+		if _, err := exec.LookPath(dumper[0]); err != nil {
+			continue
+		}
+		core := filepath.Join(hangDir(), "core."+strconv.Itoa(pid))
+		args := make([]string, len(dumper)-1)
+		for i, arg := range dumper[1:] {
+			args[i] = strings.NewReplacer("{pid}", strconv.Itoa(pid), "{core}", core).Replace(arg)
+		}
+		if dumper[0] == "gcore" {
+			if err := os.MkdirAll(hangDir(), 0755); err != nil {
+				return err
+			}
+		}
+
+		cmd := exec.Command(dumper[0], args...)
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Run(); err != nil {
+			if runtime.GOOS == "linux" {
+				return fmt.Errorf("%s failed to attach: %w\n  hint: attaching needs ptrace permission (sysctl kernel.yama.ptrace_scope=0, or CAP_SYS_PTRACE in containers)", dumper[0], err)
+			}
+			return fmt.Errorf("%s failed to attach: %w", dumper[0], err)
+		}
+		if dumper[0] == "gcore" {
+			fmt.Fprintf(w, "Wrote a core file to %s*; open it with a debugger for the stacks\n", core)
+		}
+		return nil
+	}
+	return fmt.Errorf("no gdb, lldb or gcore found to dump the stacks")
+}
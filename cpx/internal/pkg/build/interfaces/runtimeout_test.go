@@ -0,0 +1,44 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunProgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	require.NoError(t, RunProgram(exec.Command("sh", "-c", "exit 0"), time.Minute, false))
+
+	err := RunProgram(exec.Command("sleep", "30"), 100*time.Millisecond, false)
+	var timeout *RunTimeoutError
+	require.True(t, errors.As(err, &timeout), "got %v", err)
+	assert.EqualError(t, err, "timed out after 100ms and was killed")
+}
+
+func TestDumpStacks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	old := stackDumpers
+	t.Cleanup(func() { stackDumpers = old })
+
+	stackDumpers = [][]string{{"cpx-no-such-debugger", "{pid}"}, {"echo", "attached", "{pid}"}}
+	var buf bytes.Buffer
+	require.NoError(t, DumpStacks(42, &buf))
+	assert.Equal(t, "attached 42\n", buf.String())
+
+	stackDumpers = [][]string{{"false", "{pid}"}}
+	assert.ErrorContains(t, DumpStacks(42, &buf), "false failed to attach")
+
+	stackDumpers = [][]string{{"cpx-no-such-debugger", "{pid}"}}
+	assert.ErrorContains(t, DumpStacks(42, &buf), "no gdb, lldb or gcore found")
+}
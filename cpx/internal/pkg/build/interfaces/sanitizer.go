@@ -0,0 +1,180 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// sanitizerInfo describes a sanitizer cpx can build with.
+type sanitizerInfo struct {
+	// fsanitize is the -fsanitize= value enabling the sanitizer.
+	fsanitize string
+
+	// envVar is the environment variable its runtime reads options from.
+	envVar string
+
+	// options are the runtime options cpx sets by default.
+	options string
+
+	// framePointers reports whether the sanitizer's reports need frame
+	// pointers for readable stacks.
+	framePointers bool
+}
+
+// sanitizerOrder lists the supported sanitizers in the order they appear in
+// flags and output directory names.
+var sanitizerOrder = []string{"asan", "ubsan", "tsan", "msan"}
+
+var sanitizers = map[string]sanitizerInfo{
+	"asan": {
+		fsanitize: "address",
+		envVar:    "ASAN_OPTIONS",
+		// detect_leaks is left at the platform default: macOS rejects it
+		options:       "strict_string_checks=1:detect_stack_use_after_return=1:check_initialization_order=1",
+		framePointers: true,
+	},
+	"ubsan": {fsanitize: "undefined", envVar: "UBSAN_OPTIONS", options: "print_stacktrace=1"},
+	"tsan":  {fsanitize: "thread", envVar: "TSAN_OPTIONS", options: "second_deadlock_stack=1"},
+	"msan":  {fsanitize: "memory", envVar: "MSAN_OPTIONS", options: "poison_in_dtor=1", framePointers: true},
+}
+
+// SanitizerConfig holds a project's settings for one sanitizer.
+type SanitizerConfig struct {
+	// Flags are extra compiler flags, e.g. -fsanitize-address-use-after-scope.
+	Flags []string
+
+	// Options are runtime options ("key=value:key=value") appended to the
+	// defaults in the sanitizer's *SAN_OPTIONS variable.
+	Options string
+}
+
+// sanitizerConfig is the project's sanitizer configuration, keyed by
+// sanitizer name.
+var sanitizerConfig map[string]SanitizerConfig
+
+// SetSanitizerConfig sets the project's sanitizer configuration (the
+// "sanitizers" section of cpx-ci.yaml), which SanitizerFlags,
+// SanitizerOptions and SanitizerEnv apply on top of their defaults.
+func SetSanitizerConfig(cfg map[string]SanitizerConfig) error {
+	for name := range cfg {
+		if _, ok := sanitizers[name]; !ok {
+			return fmt.Errorf("unknown sanitizer '%s' (supported: %s)", name, strings.Join(sanitizerOrder, ", "))
+		}
+	}
+	sanitizerConfig = cfg
+	return nil
+}
+
+// incompatibleSanitizers are the pairs that cannot be linked into one binary.
+var incompatibleSanitizers = [][2]string{{"asan", "tsan"}, {"asan", "msan"}, {"tsan", "msan"}}
+
+// ParseSanitizer validates a comma-separated list of sanitizers ("asan,ubsan")
+// and returns it in canonical order without duplicates, so that equal
+// combinations share a build directory.
+func ParseSanitizer(spec string) (string, error) {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := sanitizers[name]; !ok {
+			return "", fmt.Errorf("unknown sanitizer '%s' (supported: %s)", name, strings.Join(sanitizerOrder, ", "))
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, pair := range incompatibleSanitizers {
+		if slices.Contains(names, pair[0]) && slices.Contains(names, pair[1]) {
+			return "", fmt.Errorf("%s and %s cannot be combined", pair[0], pair[1])
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return slices.Index(sanitizerOrder, a) - slices.Index(sanitizerOrder, b)
+	})
+	return strings.Join(names, ","), nil
+}
+
+// SplitSanitizer returns the sanitizers of a list made by ParseSanitizer.
+func SplitSanitizer(sanitizer string) []string {
+	if sanitizer == "" {
+		return nil
+	}
+	return strings.Split(sanitizer, ",")
+}
+
+// SanitizerFlags returns the compiler and linker flags for a list of
+// sanitizers ("asan", "asan,ubsan"), including the project's extra flags.
+// cxxFlags has a leading space so it can be appended to existing flags.
+func SanitizerFlags(sanitizer string) (cxxFlags, linkerFlags string) {
+	names := SplitSanitizer(sanitizer)
+	if len(names) == 0 {
+		return "", ""
+	}
+
+	var fsanitize []string
+	framePointers := false
+	for _, name := range names {
+		fsanitize = append(fsanitize, sanitizers[name].fsanitize)
+		framePointers = framePointers || sanitizers[name].framePointers
+	}
+
+	linkerFlags = "-fsanitize=" + strings.Join(fsanitize, ",")
+	cxxFlags = " " + linkerFlags
+	if framePointers {
+		cxxFlags += " -fno-omit-frame-pointer"
+	}
+	for _, flag := range SanitizerExtraFlags(sanitizer) {
+		cxxFlags += " " + flag
+	}
+	return cxxFlags, linkerFlags
+}
+
+// SanitizerExtraFlags returns the project's extra compiler flags for a list
+// of sanitizers.
+func SanitizerExtraFlags(sanitizer string) []string {
+	var flags []string
+	for _, name := range SplitSanitizer(sanitizer) {
+		flags = append(flags, sanitizerConfig[name].Flags...)
+	}
+	return flags
+}
+
+// SanitizerOptions returns the runtime option variables for a list of
+// sanitizers: cpx's defaults followed by the project's options, which win
+// because sanitizer runtimes apply options in order.
+func SanitizerOptions(sanitizer string) map[string]string {
+	env := make(map[string]string)
+	for _, name := range SplitSanitizer(sanitizer) {
+		info := sanitizers[name]
+		env[info.envVar] = joinOptions(info.options, sanitizerConfig[name].Options)
+	}
+	return env
+}
+
+// SanitizerEnv returns the environment entries ("ASAN_OPTIONS=...") to run a
+// program built with a list of sanitizers. Options already set in the
+// environment come last, so they override both defaults and project options.
+func SanitizerEnv(sanitizer string) []string {
+	var env []string
+	for _, name := range SplitSanitizer(sanitizer) {
+		envVar := sanitizers[name].envVar
+		value := joinOptions(SanitizerOptions(name)[envVar], os.Getenv(envVar))
+		env = append(env, envVar+"="+value)
+	}
+	return env
+}
+
+// joinOptions joins sanitizer option strings, skipping empty ones.
+func joinOptions(options ...string) string {
+	var parts []string
+	for _, o := range options {
+		if o = strings.Trim(o, ": "); o != "" {
+			parts = append(parts, o)
+		}
+	}
+	return strings.Join(parts, ":")
+}
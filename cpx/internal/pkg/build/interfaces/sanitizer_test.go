@@ -0,0 +1,88 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSanitizer(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr string
+	}{
+		{spec: "", want: ""},
+		{spec: "asan", want: "asan"},
+		{spec: "ubsan, ASan", want: "asan,ubsan"},
+		{spec: "ubsan,asan,ubsan", want: "asan,ubsan"},
+		{spec: "tsan,ubsan", want: "ubsan,tsan"},
+		{spec: "asan,tsan", wantErr: "asan and tsan cannot be combined"},
+		{spec: "msan,asan", wantErr: "asan and msan cannot be combined"},
+		{spec: "lsan", wantErr: "unknown sanitizer 'lsan'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseSanitizer(tt.spec)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSanitizerFlags(t *testing.T) {
+	defer func() { sanitizerConfig = nil }()
+
+	cxx, link := SanitizerFlags("")
+	assert.Empty(t, cxx)
+	assert.Empty(t, link)
+
+	cxx, link = SanitizerFlags("ubsan")
+	assert.Equal(t, " -fsanitize=undefined", cxx)
+	assert.Equal(t, "-fsanitize=undefined", link)
+
+	cxx, link = SanitizerFlags("asan,ubsan")
+	assert.Equal(t, " -fsanitize=address,undefined -fno-omit-frame-pointer", cxx)
+	assert.Equal(t, "-fsanitize=address,undefined", link)
+
+	require.NoError(t, SetSanitizerConfig(map[string]SanitizerConfig{
+		"asan": {Flags: []string{"-fsanitize-address-use-after-scope"}},
+	}))
+	cxx, _ = SanitizerFlags("asan,ubsan")
+	assert.Equal(t, " -fsanitize=address,undefined -fno-omit-frame-pointer -fsanitize-address-use-after-scope", cxx)
+
+	assert.Error(t, SetSanitizerConfig(map[string]SanitizerConfig{"lsan": {}}))
+}
+
+func TestSanitizerEnv(t *testing.T) {
+	defer func() { sanitizerConfig = nil }()
+	require.NoError(t, SetSanitizerConfig(map[string]SanitizerConfig{
+		"ubsan": {Options: "halt_on_error=1"},
+	}))
+	t.Setenv("ASAN_OPTIONS", "detect_leaks=0")
+	t.Setenv("UBSAN_OPTIONS", "")
+
+	assert.Equal(t, map[string]string{
+		"ASAN_OPTIONS":  "strict_string_checks=1:detect_stack_use_after_return=1:check_initialization_order=1",
+		"UBSAN_OPTIONS": "print_stacktrace=1:halt_on_error=1",
+	}, SanitizerOptions("asan,ubsan"))
+
+	assert.Equal(t, []string{
+		"ASAN_OPTIONS=strict_string_checks=1:detect_stack_use_after_return=1:check_initialization_order=1:detect_leaks=0",
+		"UBSAN_OPTIONS=print_stacktrace=1:halt_on_error=1",
+	}, SanitizerEnv("asan,ubsan"))
+
+	assert.Empty(t, SanitizerEnv(""))
+}
+
+func TestGetOutputDir(t *testing.T) {
+	assert.Equal(t, "debug", GetOutputDir(false, "", ""))
+	assert.Equal(t, "release-asan", GetOutputDir(true, "", "asan"))
+	assert.Equal(t, "O3-asan-ubsan", GetOutputDir(false, "3", "asan,ubsan"))
+}
@@ -0,0 +1,59 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// testStopGrace is how long a test runner gets to stop its tests after an
+// interrupt before it is killed.
+var testStopGrace = 10 * time.Second
+
+// SessionTimeoutError is returned by RunTestCommand for a test run that
+// went over its time limit.
+type SessionTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *SessionTimeoutError) Error() string {
+	return fmt.Sprintf("test run timed out after %s", e.Timeout)
+}
+
+// RunTestCommand runs a test runner such as ctest, interrupting it when it
+// runs longer than timeout, or never when timeout is 0. Test runners stop
+// their tests on an interrupt; one that has not exited within a grace
+// period is killed.
+func RunTestCommand(cmd *exec.Cmd, timeout time.Duration) error {
+	if timeout <= 0 {
+		return cmd.Run()
+	}
+	// Tests left running by a killed runner may hold its output open
+	cmd.WaitDelay = testStopGrace
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	// Windows has no interrupt to send
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case <-done:
+	case <-time.After(testStopGrace):
+		_ = cmd.Process.Kill()
+		<-done
+	}
+	return &SessionTimeoutError{Timeout: timeout}
+}
@@ -0,0 +1,28 @@
+package build
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	require.NoError(t, RunTestCommand(exec.Command("sh", "-c", "exit 0"), 0))
+	assert.Error(t, RunTestCommand(exec.Command("sh", "-c", "exit 1"), time.Minute))
+
+	start := time.Now()
+	err := RunTestCommand(exec.Command("sleep", "30"), 100*time.Millisecond)
+	var timeout *SessionTimeoutError
+	require.True(t, errors.As(err, &timeout), "got %v", err)
+	assert.Equal(t, 100*time.Millisecond, timeout.Timeout)
+	assert.Contains(t, err.Error(), "timed out after 100ms")
+	assert.Less(t, time.Since(start), 10*time.Second, "the runner is interrupted")
+}
@@ -0,0 +1,25 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ExcludeTests returns the names that the regular expression exclude does
+// not match, for build systems without name exclusion of their own.
+func ExcludeTests(names []string, exclude string) ([]string, error) {
+	re, err := regexp.Compile(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --exclude pattern: %w", err)
+	}
+	var kept []string
+	for _, name := range names {
+		if !re.MatchString(name) {
+			kept = append(kept, name)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("--exclude %q leaves no tests to run", exclude)
+	}
+	return kept, nil
+}
@@ -0,0 +1,20 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcludeTests(t *testing.T) {
+	kept, err := ExcludeTests([]string{"//tests:unit", "//tests:integration_db", "//tests:integration_http"}, "integration_")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"//tests:unit"}, kept)
+
+	_, err = ExcludeTests([]string{"//tests:unit"}, "unit")
+	assert.ErrorContains(t, err, "leaves no tests")
+
+	_, err = ExcludeTests([]string{"//tests:unit"}, "(")
+	assert.ErrorContains(t, err, "invalid --exclude pattern")
+}
@@ -5,12 +5,13 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 )
 
 // RunDockerBuild implements the DockerBuilder interface for Meson builds.
@@ -29,14 +30,10 @@ func (b *Builder) RunDockerBuild(ctx context.Context, opts build.DockerBuildOpti
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Create persistent build directory
-	hostBuildDir := filepath.Join(opts.ProjectRoot, ".cache", "ci", opts.TargetName)
-	if err := os.MkdirAll(hostBuildDir, 0755); err != nil {
-		return fmt.Errorf("failed to create build directory: %w", err)
-	}
-	absBuildDir, err := filepath.Abs(hostBuildDir)
+	// Persistent build directory
+	buildDirMount, err := opts.CacheMount(opts.TargetName, "/tmp/builddir")
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path for build directory: %w", err)
+		return err
 	}
 
 	// Determine build type
@@ -66,10 +63,14 @@ func (b *Builder) RunDockerBuild(ctx context.Context, opts build.DockerBuildOpti
 
 	// Build Meson arguments
 	setupArgs := []string{"--buildtype=" + buildType}
+	if sanitize := mesonSanitizer(opts.Sanitizer); sanitize != "" {
+		setupArgs = append(setupArgs, "-Db_sanitize="+sanitize)
+		setupArgs = append(setupArgs, compilerArgs(build.SanitizerExtraFlags(opts.Sanitizer))...)
+	}
 	setupArgs = append(setupArgs, opts.MesonArgs...)
 
 	// Detect project name
-	projectName := GetProjectNameFromMesonBuild(opts.ProjectRoot)
+	projectName := ProjectName(opts.ProjectRoot)
 	if projectName == "" {
 		projectName = filepath.Base(opts.ProjectRoot)
 	}
@@ -186,25 +187,28 @@ if [ "%[5]s" = "true" ]; then ls -la /output/%[8]s/ 2>/dev/null || echo "  (no a
 %[9]s%[10]s%[11]s
 `, envExports, setupEcho, strings.Join(setupArgs, " "), mesonQuiet, isVerbose, buildEcho, copyEcho, opts.TargetName, testSection, benchSection, runSection, buildCompleteEcho, projectName)
 
-	fmt.Printf("  %s Running Meson build in Docker container...%s\n", colors.Cyan, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "  %s Running Meson build in Docker container...%s\n", colors.Cyan, colors.Reset)
 
 	dockerArgs := []string{"run", "--rm"}
 	if opts.Platform != "" {
 		dockerArgs = append(dockerArgs, "--platform", opts.Platform)
 	}
+	if opts.GPUs != "" {
+		dockerArgs = append(dockerArgs, "--gpus", opts.GPUs)
+	}
 
 	dockerArgs = append(dockerArgs,
 		"-v", absProjectRoot+":/workspace:ro",
-		"-v", absBuildDir+":/tmp/builddir",
+		"-v", buildDirMount,
 		"-v", absSubprojectsDir+":/workspace/subprojects",
 		"-v", absOutputDir+":/output",
 		"-w", "/workspace",
 		opts.ImageName,
 		"bash", "-c", buildScript)
 
-	cmd := exec.Command("docker", dockerArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := opts.ContainerCommand(dockerArgs...)
+	cmd.Stdout = buildlog.Stdout()
+	cmd.Stderr = buildlog.Stderr()
 
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("docker meson build failed: %w", err)
@@ -213,5 +217,30 @@ if [ "%[5]s" = "true" ]; then ls -la /output/%[8]s/ 2>/dev/null || echo "  (no a
 	return nil
 }
 
-// Compile-time check that Builder implements DockerBuilder
-var _ build.DockerBuilder = (*Builder)(nil)
+// mesonSanitizer maps a cpx sanitizer list to a b_sanitize value.
+func mesonSanitizer(sanitizer string) string {
+	var values []string
+	for _, name := range build.SplitSanitizer(sanitizer) {
+		switch name {
+		case "asan":
+			values = append(values, "address")
+		case "tsan":
+			values = append(values, "thread")
+		case "msan":
+			values = append(values, "memory")
+		case "ubsan":
+			values = append(values, "undefined")
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// compilerArgs returns the -Dc_args and -Dcpp_args options for extra
+// compiler flags, or nothing if there are none.
+func compilerArgs(flags []string) []string {
+	if len(flags) == 0 {
+		return nil
+	}
+	joined := strings.Join(flags, ",")
+	return []string{"-Dc_args=" + joined, "-Dcpp_args=" + joined}
+}
@@ -0,0 +1,35 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/build/explain"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+)
+
+var _ build.RebuildExplainer = (*Builder)(nil)
+
+// ExplainRebuild implements the RebuildExplainer interface by running ninja
+// with "-d explain" in the Meson build directory.
+func (b *Builder) ExplainRebuild(ctx context.Context, opts build.ExplainOptions) ([]build.RebuildReason, error) {
+	buildDir := "builddir"
+
+	if _, err := os.Stat(filepath.Join(buildDir, "build.ninja")); err != nil {
+		return nil, fmt.Errorf("no ninja build found in %s\n  hint: run 'cpx build' first", buildDir)
+	}
+
+	cmd := execCommand("ninja", explain.NinjaArgs(buildDir, opts)...)
+	output, err := cmd.CombinedOutput()
+	if opts.Verbose {
+		fmt.Print(string(output))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ninja failed: %w", err)
+	}
+
+	return explain.ParseNinja(string(output), time.Now()), nil
+}
@@ -59,3 +59,21 @@ func TestGenerate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.FileExists(t, filepath.Join(projectPath, "bench", "meson.build"))
 }
+
+func TestGenerateHeaderOnly(t *testing.T) {
+	projectPath := t.TempDir()
+	initConfig := build.InitConfig{
+		Name:        "mylib",
+		Version:     "0.1.0",
+		IsLibrary:   true,
+		HeaderOnly:  true,
+		CppStandard: 17,
+	}
+
+	require.NoError(t, New().GenerateBuildSrc(context.Background(), projectPath, initConfig))
+	assert.NoDirExists(t, filepath.Join(projectPath, "src"))
+
+	mesonBuild, err := os.ReadFile(filepath.Join(projectPath, "meson.build"))
+	require.NoError(t, err)
+	assert.Contains(t, string(mesonBuild), "mylib_dep = declare_dependency(")
+}
@@ -8,11 +8,19 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	"github.com/ozacod/cpx/internal/pkg/build/events"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/build/progress"
 	"github.com/ozacod/cpx/internal/pkg/templates"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
 var execCommand = exec.Command
@@ -83,41 +91,73 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 		}
 	}
 
+	// Always pass b_sanitize, so reconfiguring drops a previous sanitizer
+	sanitize := mesonSanitizer(opts.Sanitizer)
+	if sanitize == "" {
+		sanitize = "none"
+	}
+	flagArgs := []string{"-Db_sanitize=" + sanitize}
+	cArgs := build.SanitizerExtraFlags(opts.Sanitizer)
+	if opts.OptLevel == "fast" {
+		// Add -ffast-math for -Ofast equivalent
+		cArgs = append([]string{"-ffast-math"}, cArgs...)
+	}
+	for _, d := range opts.Defines {
+		cArgs = append(cArgs, "-D"+d)
+	}
+	if opts.Reproducible {
+		// meson already writes static libraries without timestamps
+		cArgs = append(cArgs, build.ReproducibleFlags(".")...)
+	}
+	flagArgs = append(flagArgs, compilerArgs(cArgs)...)
+	if opts.LibraryType != "" {
+		flagArgs = append(flagArgs, "-Ddefault_library="+opts.LibraryType)
+	}
+	if opts.PCH != nil {
+		flagArgs = append(flagArgs, fmt.Sprintf("-Db_pch=%t", *opts.PCH))
+	}
+	if len(opts.CUDAArchitectures) > 0 {
+		// cuda_arch is the option of projects from the CUDA template
+		flagArgs = append(flagArgs, "-Dcuda_arch="+strings.Join(build.MesonCUDAArchitectures(opts.CUDAArchitectures), ","))
+	}
+
 	// Check if build directory exists (needs setup)
 	if _, err := os.Stat(buildDir); os.IsNotExist(err) {
-		fmt.Printf("%sSetting up Meson build directory [%s]...%s\n", colors.Cyan, optLabel, colors.Reset)
+		output.Stepf("Setting up Meson build directory [%s]...", optLabel)
 		setupArgs := []string{"setup", buildDir}
 		setupArgs = append(setupArgs, "--buildtype="+buildType)
 		setupArgs = append(setupArgs, "--optimization="+optimization)
-		if opts.OptLevel == "fast" {
-			// Add -ffast-math for -Ofast equivalent
-			setupArgs = append(setupArgs, "-Dc_args=-ffast-math", "-Dcpp_args=-ffast-math")
-		}
+		setupArgs = append(setupArgs, flagArgs...)
+		setupArgs = append(setupArgs, opts.ExtraArgs...)
+		events.ConfigureStarted("meson")
 		setupCmd := execCommand("meson", setupArgs...)
-		setupCmd.Stdout = os.Stdout
-		setupCmd.Stderr = os.Stderr
+		setupCmd.Stdout = buildlog.Stdout()
+		setupCmd.Stderr = buildlog.Stderr()
 		if err := setupCmd.Run(); err != nil {
 			return fmt.Errorf("meson setup failed: %w", err)
 		}
 	} else {
 		// Build directory exists, reconfigure if optimization changed
-		fmt.Printf("%sReconfiguring Meson [%s]...%s\n", colors.Cyan, optLabel, colors.Reset)
+		output.Stepf("Reconfiguring Meson [%s]...", optLabel)
 		reconfigArgs := []string{"configure", buildDir}
 		reconfigArgs = append(reconfigArgs, "--buildtype="+buildType)
 		reconfigArgs = append(reconfigArgs, "--optimization="+optimization)
-		if opts.OptLevel == "fast" {
-			reconfigArgs = append(reconfigArgs, "-Dc_args=-ffast-math", "-Dcpp_args=-ffast-math")
-		}
+		reconfigArgs = append(reconfigArgs, flagArgs...)
+		reconfigArgs = append(reconfigArgs, opts.ExtraArgs...)
+		events.ConfigureStarted("meson")
 		reconfigCmd := execCommand("meson", reconfigArgs...)
-		reconfigCmd.Stdout = os.Stdout
-		reconfigCmd.Stderr = os.Stderr
+		reconfigCmd.Stdout = buildlog.Stdout()
+		reconfigCmd.Stderr = buildlog.Stderr()
 		// Ignore reconfigure errors - may fail if no changes needed
 		_ = reconfigCmd.Run()
 	}
 
 	// Build
-	fmt.Printf("%sBuilding with Meson...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Building with Meson...")
 	compileArgs := []string{"compile", "-C", buildDir}
+	if opts.Jobs > 0 {
+		compileArgs = append(compileArgs, "-j", strconv.Itoa(opts.Jobs))
+	}
 	if opts.Target != "" {
 		compileArgs = append(compileArgs, opts.Target)
 	}
@@ -125,10 +165,16 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 		compileArgs = append(compileArgs, "-v")
 	}
 	buildCmd := execCommand("meson", compileArgs...)
-	buildCmd.Stdout = os.Stdout
-	buildCmd.Stderr = os.Stderr
-
-	if err := buildCmd.Run(); err != nil {
+	var err error
+	if opts.Verbose {
+		buildCmd.Stdout = buildlog.Stdout()
+		buildCmd.Stderr = buildlog.Stderr()
+		err = buildCmd.Run()
+	} else {
+		// ninja's "[x/y]" lines feed the progress bar (like vcpkg)
+		err = progress.Run(buildCmd, "Compiling", 1, 1)
+	}
+	if err != nil {
 		return fmt.Errorf("meson compile failed: %w", err)
 	}
 
@@ -139,46 +185,48 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 	} else if opts.Release {
 		outDirName = "release"
 	}
-	outputDir := filepath.Join(".bin", "native", outDirName)
+	outputDir := filepath.Join(config.BinDir(), "native", outDirName)
 
 	// Copy artifacts to output directory
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	fmt.Printf("%sCopying artifacts to %s/...%s\n", colors.Cyan, outputDir, colors.Reset)
-	copyCmd := execCommand("bash", "-c", fmt.Sprintf(`
-		# Meson places executables in subdirectories (src/, bench/, etc.)
-		# Search in builddir/src/ first (main executables)
-		if [ -d "builddir/src" ]; then
-			find builddir/src -maxdepth 1 -type f -perm +111 ! -name "*.p" ! -name "*_test" -exec cp {} %[1]s/ \; 2>/dev/null || true
-		fi
-
-		# Also check builddir root for executables
-		find builddir -maxdepth 1 -type f -perm +111 ! -name "*.p" ! -name "*_test" -exec cp {} %[1]s/ \; 2>/dev/null || true
-
-		# Copy libraries from builddir and subdirectories
-		find builddir -maxdepth 2 -type f \( -name "*.a" -o -name "*.so" -o -name "*.dylib" \) -exec cp {} %[1]s/ \; 2>/dev/null || true
-
-		# List what was copied
-		ls %[1]s/ 2>/dev/null || true
-	`, outputDir))
-	copyCmd.Stdout = os.Stdout
-	copyCmd.Stderr = os.Stderr
-	_ = copyCmd.Run()
+	output.Stepf("Copying artifacts to %s/...", outputDir)
+	// Meson places executables in subdirectories (src/, bench/, etc.)
+	skip := []string{"*.p", "*_test"}
+	copied, err := artifacts.Collect(outputDir,
+		artifacts.Rule{Dir: filepath.Join("builddir", "src"), Executables: true, Skip: skip},
+		artifacts.Rule{Dir: "builddir", Executables: true, Skip: skip},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy artifacts: %w", err)
+	}
+	// Libraries go to static/ and shared/, so both types can be kept
+	libs, err := artifacts.CollectLibraries(outputDir, opts.LibraryType,
+		artifacts.Rule{Dir: "builddir", Depth: 2, Extensions: artifacts.LibraryExtensions},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy artifacts: %w", err)
+	}
+	copied = append(copied, libs...)
+	for _, name := range copied {
+		fmt.Fprintf(output.Stdout(), "  %s\n", name)
+	}
 
-	fmt.Printf("%s✓ Build successful%s\n", colors.Green, colors.Reset)
-	fmt.Printf("  Artifacts in: %s/\n", outputDir)
+	output.Successf("✓ Build successful")
+	fmt.Fprintf(output.Stdout(), "  Artifacts in: %s/\n", outputDir)
 	return nil
 }
 
 // Test runs the project's tests with the given options.
 func (b *Builder) Test(ctx context.Context, opts build.TestOptions) error {
-	fmt.Printf("%sRunning Meson tests...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Running Meson tests...")
 
-	// Ensure builddir exists - need build first
-	if _, err := os.Stat("builddir"); os.IsNotExist(err) {
-		if err := b.Build(ctx, build.BuildOptions{Verbose: opts.Verbose}); err != nil {
+	// Ensure builddir exists - need build first. A sanitizer build always
+	// reconfigures, since builddir is shared by all variants.
+	if _, err := os.Stat("builddir"); os.IsNotExist(err) || opts.Sanitizer != "" {
+		if err := b.Build(ctx, build.BuildOptions{Sanitizer: opts.Sanitizer, Verbose: opts.Verbose}); err != nil {
 			return fmt.Errorf("build failed: %w", err)
 		}
 	}
@@ -192,28 +240,121 @@ func (b *Builder) Test(ctx context.Context, opts build.TestOptions) error {
 	mesonArgs = append(mesonArgs, "--no-suite", "gmock")
 	mesonArgs = append(mesonArgs, "--no-suite", "catch2")
 
+	if opts.Timeout > 0 {
+		// Meson scales the timeouts of meson.build, 30s by default
+		mesonArgs = append(mesonArgs, "-t", strconv.FormatFloat(opts.Timeout.Seconds()/mesonDefaultTestTimeout.Seconds(), 'g', 4, 64))
+	}
+
+	// Labels are Meson suites
+	for _, suite := range opts.Labels {
+		mesonArgs = append(mesonArgs, "--suite", suite)
+	}
+	for _, suite := range opts.ExcludeLabels {
+		mesonArgs = append(mesonArgs, "--no-suite", suite)
+	}
+
 	if opts.Verbose {
 		mesonArgs = append(mesonArgs, "-v")
 	} else {
 		mesonArgs = append(mesonArgs, "--quiet")
 	}
 
-	if opts.Filter != "" {
-		mesonArgs = append(mesonArgs, opts.Filter)
+	filter := opts.Filter
+	if filter == "" && opts.Pick != nil {
+		var err error
+		if filter, err = opts.Pick.Choose(mesonTestNames(mesonArgs), ""); err != nil {
+			return err
+		}
+	}
+	switch {
+	case opts.RerunFailed:
+		failed, err := mesonFailedTests(filepath.Join("builddir", "meson-logs", "testlog.json"))
+		if err != nil {
+			return err
+		}
+		if len(failed) == 0 {
+			output.Successf("✓ No failed tests in the last run")
+			return nil
+		}
+		mesonArgs = append(mesonArgs, failed...)
+	case filter != "":
+		mesonArgs = append(mesonArgs, filter)
+	case opts.Exclude != "":
+		// meson test only selects tests by name, so list the others
+		tests, err := build.ExcludeTests(mesonTestNames(mesonArgs), opts.Exclude)
+		if err != nil {
+			return err
+		}
+		mesonArgs = append(mesonArgs, tests...)
 	}
 
 	testCmd := execCommand("meson", mesonArgs...)
-	testCmd.Stdout = os.Stdout
-	testCmd.Stderr = os.Stderr
+	testCmd.Stdout = buildlog.Stdout()
+	testCmd.Stderr = buildlog.Stderr()
+	if opts.Sanitizer != "" {
+		testCmd.Env = append(os.Environ(), build.SanitizerEnv(opts.Sanitizer)...)
+	}
 
-	if err := testCmd.Run(); err != nil {
+	if err := build.RunTestCommand(testCmd, opts.SessionTimeout); err != nil {
 		return fmt.Errorf("meson test failed: %w", err)
 	}
 
-	fmt.Printf("%s✓ Tests passed%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ Tests passed")
 	return nil
 }
 
+// mesonDefaultTestTimeout is the timeout of tests that meson.build gives
+// none
+const mesonDefaultTestTimeout = 30 * time.Second
+
+// mesonFailedTests returns the names of the tests that did not pass in the
+// meson test run logged to testlog, a file of JSON lines.
+func mesonFailedTests(testlog string) ([]string, error) {
+	data, err := os.ReadFile(testlog)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var failed []string
+	for _, line := range strings.Split(string(data), "\n") {
+		var result struct {
+			Name   string `json:"name"`
+			Result string `json:"result"`
+		}
+		if json.Unmarshal([]byte(line), &result) != nil || result.Name == "" {
+			continue
+		}
+		switch result.Result {
+		case "OK", "SKIP", "EXPECTEDFAIL":
+		default:
+			failed = append(failed, result.Name)
+		}
+	}
+	return failed, nil
+}
+
+// mesonTestNames lists the tests meson test would run with args.
+func mesonTestNames(args []string) []string {
+	out, err := execCommand("meson", append(append([]string{}, args...), "--list")...).Output()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		// "project / name", or just the name with older Meson
+		line = strings.TrimSpace(line)
+		if i := strings.LastIndex(line, " / "); i >= 0 {
+			line = line[i+3:]
+		}
+		if line != "" {
+			names = append(names, line)
+		}
+	}
+	return names
+}
+
 // Run builds and runs the project's main executable.
 func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 	// Ensure project is built first
@@ -239,6 +380,7 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 		}
 	} else {
 		// Look for executables in builddir/src/ first (Meson puts main exe there)
+		var candidates []string
 		searchDirs := []string{filepath.Join("builddir", "src"), "builddir"}
 		for _, dir := range searchDirs {
 			entries, err := os.ReadDir(dir)
@@ -261,12 +403,14 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 					!strings.HasSuffix(name, ".a") &&
 					!strings.HasSuffix(name, ".so") &&
 					!strings.HasSuffix(name, ".dylib") {
-					exePath = filepath.Join(dir, name)
-					break
+					candidates = append(candidates, filepath.Join(dir, name))
 				}
 			}
-			if exePath != "" {
-				break
+		}
+		if len(candidates) > 0 {
+			var err error
+			if exePath, err = opts.Pick.Choose(candidates, candidates[0]); err != nil {
+				return err
 			}
 		}
 	}
@@ -275,18 +419,26 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 		return fmt.Errorf("no executable found in builddir\n  hint: use --target to specify the executable")
 	}
 
-	fmt.Printf("%sRunning %s...%s\n", colors.Cyan, exePath, colors.Reset)
-	runCmd := execCommand(exePath, opts.Args...)
+	output.Stepf("Running %s...", exePath)
+	argv := build.WrapCommand(opts.Wrapper, exePath, opts.Args...)
+	runCmd := execCommand(argv[0], argv[1:]...)
 	runCmd.Stdout = os.Stdout
 	runCmd.Stderr = os.Stderr
 	runCmd.Stdin = os.Stdin
+	if opts.Sanitizer != "" {
+		runCmd.Env = append(os.Environ(), build.SanitizerEnv(opts.Sanitizer)...)
+	}
 
-	return runCmd.Run()
+	err := build.RunProgram(runCmd, opts.Timeout, opts.DumpOnTimeout)
+	if len(opts.Wrapper) == 0 {
+		build.ReportCrash(build.CrashOf(runCmd, err))
+	}
+	return err
 }
 
 // Bench runs the project's benchmarks.
 func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
-	fmt.Printf("%sRunning Meson benchmarks...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Running Meson benchmarks...")
 
 	// Ensure builddir exists
 	if _, err := os.Stat("builddir"); os.IsNotExist(err) {
@@ -307,6 +459,7 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 		}
 	} else {
 		// Look for *_bench executables in builddir/bench/ first
+		var candidates []string
 		searchDirs := []string{filepath.Join("builddir", "bench"), "builddir"}
 		for _, dir := range searchDirs {
 			entries, err := os.ReadDir(dir)
@@ -315,12 +468,14 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 			}
 			for _, entry := range entries {
 				if strings.HasSuffix(entry.Name(), "_bench") {
-					benchPath = filepath.Join(dir, entry.Name())
-					break
+					candidates = append(candidates, filepath.Join(dir, entry.Name()))
 				}
 			}
-			if benchPath != "" {
-				break
+		}
+		if len(candidates) > 0 {
+			var err error
+			if benchPath, err = opts.Pick.Choose(candidates, candidates[0]); err != nil {
+				return err
 			}
 		}
 	}
@@ -329,9 +484,10 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 		return fmt.Errorf("no benchmark executable found\n  hint: use --target to specify the benchmark")
 	}
 
-	fmt.Printf("  Running: %s\n", benchPath)
+	fmt.Fprintf(output.Stdout(), "  Running: %s\n", benchPath)
 
-	benchCmd := execCommand(benchPath)
+	argv := build.WrapCommand(opts.Wrapper, benchPath)
+	benchCmd := execCommand(argv[0], argv[1:]...)
 	benchCmd.Stdout = os.Stdout
 	benchCmd.Stderr = os.Stderr
 
@@ -339,13 +495,13 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 		return fmt.Errorf("benchmark failed: %w", err)
 	}
 
-	fmt.Printf("%s✓ Benchmarks complete%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ Benchmarks complete")
 	return nil
 }
 
 // Clean removes build artifacts.
 func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
-	fmt.Printf("%sCleaning Meson project...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Cleaning Meson project...")
 
 	// Remove builddir
 	removeDir("builddir")
@@ -353,6 +509,9 @@ func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
 	// Remove common build output directory
 	removeDir("build")
 
+	// Remove bin directory (artifacts)
+	removeDir(filepath.Join(config.BinDir(), "native"))
+
 	if opts.All {
 		// Remove additional Meson artifacts
 		removeDir("subprojects/packagecache")
@@ -364,7 +523,7 @@ func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
 				if entry.IsDir() {
 					matched, _ := filepath.Match("build-*", entry.Name())
 					if matched {
-						fmt.Printf("%s  Removing %s...%s\n", colors.Cyan, entry.Name(), colors.Reset)
+						output.Stepf("  Removing %s...", entry.Name())
 						os.RemoveAll(entry.Name())
 					}
 				}
@@ -372,12 +531,12 @@ func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
 		}
 	}
 
-	fmt.Printf("%s✓ Meson project cleaned%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ Meson project cleaned")
 	return nil
 }
 
 func (b *Builder) AddDependency(ctx context.Context, name string, version string) error {
-	fmt.Printf("%sInstalling wrap for %s...%s\n", colors.Cyan, name, colors.Reset)
+	output.Stepf("Installing wrap for %s...", name)
 
 	// Create subprojects dir if it doesn't exist
 	if err := os.MkdirAll("subprojects", 0755); err != nil {
@@ -393,22 +552,32 @@ func (b *Builder) AddDependency(ctx context.Context, name string, version string
 		return fmt.Errorf("failed to install wrap for %s: %w", name, err)
 	}
 
-	fmt.Printf("%s✓ Added %s%s\n", colors.Green, name, colors.Reset)
+	output.Successf("✓ Added %s", name)
 
 	// Print usage info
-	fmt.Printf("\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
-	fmt.Printf("Add this to your meson.build:\n\n")
-	fmt.Printf("  %s_dep = dependency('%s')\n\n", name, name)
-	fmt.Printf("Then link it to your target:\n\n")
-	fmt.Printf("  executable(..., dependencies : %s_dep)\n\n", name)
-	fmt.Printf("%s📦 Find more info at:%s\n", colors.Cyan, colors.Reset)
-	fmt.Printf("   https://wrapdb.mesonbuild.com/\n\n")
+	fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "Add this to your meson.build:\n\n")
+	fmt.Fprintf(output.Stdout(), "  %s_dep = dependency('%s')\n\n", name, name)
+	fmt.Fprintf(output.Stdout(), "Then link it to your target:\n\n")
+	fmt.Fprintf(output.Stdout(), "  executable(..., dependencies : %s_dep)\n\n", name)
+	output.Stepf("📦 Find more info at:")
+	fmt.Fprintf(output.Stdout(), "   https://wrapdb.mesonbuild.com/\n\n")
 
 	return nil
 }
 
 // RemoveDependency removes a dependency from the project.
 func (b *Builder) RemoveDependency(ctx context.Context, name string) error {
+	// A path dependency is a link to the project
+	link := filepath.Join("subprojects", name)
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(link); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", link, err)
+		}
+		output.Successf("✓ Removed %s", name)
+		return nil
+	}
+
 	// Remove the wrap file from subprojects
 	wrapFile := filepath.Join("subprojects", name+".wrap")
 	if _, err := os.Stat(wrapFile); os.IsNotExist(err) {
@@ -425,7 +594,7 @@ func (b *Builder) RemoveDependency(ctx context.Context, name string) error {
 		os.RemoveAll(extractedDir)
 	}
 
-	fmt.Printf("%s✓ Removed %s%s\n", colors.Green, name, colors.Reset)
+	output.Successf("✓ Removed %s", name)
 	return nil
 }
 
@@ -449,6 +618,8 @@ func (b *Builder) ListDependencies(ctx context.Context) ([]build.Dependency, err
 				Name:    depName,
 				Version: "", // Wrap files don't always have version info easily accessible
 			})
+		} else if entry.Type()&os.ModeSymlink != 0 {
+			deps = append(deps, build.Dependency{Name: name, Version: "path"})
 		}
 	}
 
@@ -457,9 +628,21 @@ func (b *Builder) ListDependencies(ctx context.Context) ([]build.Dependency, err
 
 // SearchDependencies searches for available packages matching the query.
 func (b *Builder) SearchDependencies(ctx context.Context, query string) ([]build.Dependency, error) {
-	// WrapDB search would require HTTP calls to wrapdb.mesonbuild.com
-	// For now, return an error indicating this is not implemented
-	return nil, fmt.Errorf("SearchDependencies not implemented for Meson - use https://wrapdb.mesonbuild.com to search")
+	releases, err := loadWrapReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []build.Dependency
+	for _, name := range searchWrapReleases(releases, query) {
+		release := releases[name]
+		deps = append(deps, build.Dependency{
+			Name:        name,
+			Version:     release.LatestVersion(),
+			Description: wrapDescription(release),
+		})
+	}
+	return deps, nil
 }
 
 // Name returns the name of the build system.
@@ -468,8 +651,70 @@ func (b *Builder) Name() string {
 }
 
 // DependencyInfo retrieves detailed information about a specific dependency.
+// It combines the local subprojects/<name>.wrap (if installed) with WrapDB metadata.
 func (b *Builder) DependencyInfo(ctx context.Context, name string) (*build.DependencyInfo, error) {
-	return nil, fmt.Errorf("dependency info not implemented for Meson")
+	wrap, wrapErr := readWrapFile(name)
+	if wrapErr != nil && !os.IsNotExist(wrapErr) {
+		return nil, wrapErr
+	}
+
+	var release *WrapRelease
+	releases, err := loadWrapReleases(ctx)
+	if err != nil {
+		if wrap == nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "%sWarning: %v%s\n", colors.Yellow, err, colors.Reset)
+	} else if r, ok := releases[name]; ok {
+		release = &r
+	}
+
+	if wrap == nil && release == nil {
+		return nil, fmt.Errorf("package '%s' not found in WrapDB\n  hint: use 'cpx search %s' to find available wraps", name, name)
+	}
+
+	info := &build.DependencyInfo{Name: name}
+	var lines []string
+
+	if release != nil {
+		info.Version = release.LatestVersion()
+		info.Versions = release.Versions
+		info.Dependencies = release.DependencyNames
+		if len(release.ProgramNames) > 0 {
+			lines = append(lines, "Provides programs: "+strings.Join(release.ProgramNames, ", "))
+		}
+	}
+
+	if wrap != nil {
+		installed := wrap.Values["wrapdb_version"]
+		if installed == "" {
+			installed = wrap.Values["revision"]
+		}
+		if installed != "" {
+			lines = append(lines, "Installed: "+installed)
+			if info.Version == "" {
+				info.Version = installed
+			} else if installed != info.Version {
+				lines = append(lines, fmt.Sprintf("Update available: %s -> %s", installed, info.Version))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("Wrap: subprojects/%s.wrap (wrap-%s)", name, wrap.Type))
+		if src := wrap.SourceURL(); src != "" {
+			lines = append(lines, "Source: "+src)
+		}
+		if patch := wrap.Values["patch_directory"]; patch != "" {
+			lines = append(lines, "Patch: packagefiles/"+patch)
+		} else if patch := wrap.Values["patch_url"]; patch != "" {
+			lines = append(lines, "Patch: "+patch)
+		}
+		if provided := wrap.ProvidedDependencies(); len(provided) > 0 {
+			info.Dependencies = provided
+		}
+		info.Homepage = homepageFromURL(wrap.SourceURL())
+	}
+
+	info.Description = strings.Join(lines, "\n")
+	return info, nil
 }
 
 // ListTargets returns the list of build targets.
@@ -516,19 +761,25 @@ func (b *Builder) GenerateGitignore(ctx context.Context, projectPath string) err
 // GenerateBuildSrc generates the build files for source code (core project files).
 func (b *Builder) GenerateBuildSrc(ctx context.Context, projectPath string, config build.InitConfig) error {
 	// Generate meson.build (root)
-	mesonBuild := templates.GenerateMesonBuildRoot(config.Name, !config.IsLibrary, config.CppStandard, config.TestFramework, config.Benchmark)
+	mesonBuild := templates.GenerateMesonBuildRoot(config.Name, !config.IsLibrary, config.CppStandard, config.TestFramework, config.Benchmark, config.SharedLibrary)
+	if config.HeaderOnly {
+		mesonBuild = templates.GenerateHeaderOnlyMesonBuildRoot(config.Name, config.CppStandard, config.TestFramework, config.Benchmark)
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "meson.build"), []byte(mesonBuild), 0644); err != nil {
 		return fmt.Errorf("failed to write meson.build: %w", err)
 	}
 
-	if err := os.MkdirAll(filepath.Join(projectPath, "src"), 0755); err != nil {
-		return fmt.Errorf("failed to create src directory: %w", err)
-	}
+	// Header-only libraries have no src/
+	if !config.HeaderOnly {
+		if err := os.MkdirAll(filepath.Join(projectPath, "src"), 0755); err != nil {
+			return fmt.Errorf("failed to create src directory: %w", err)
+		}
 
-	// Generate src/meson.build
-	srcMeson := templates.GenerateMesonBuildSrc(config.Name, !config.IsLibrary)
-	if err := os.WriteFile(filepath.Join(projectPath, "src/meson.build"), []byte(srcMeson), 0644); err != nil {
-		return fmt.Errorf("failed to write src/meson.build: %w", err)
+		// Generate src/meson.build
+		srcMeson := templates.GenerateMesonBuildSrc(config.Name, !config.IsLibrary, config.PCH)
+		if err := os.WriteFile(filepath.Join(projectPath, "src/meson.build"), []byte(srcMeson), 0644); err != nil {
+			return fmt.Errorf("failed to write src/meson.build: %w", err)
+		}
 	}
 
 	// Generate meson_options.txt
@@ -555,7 +806,7 @@ func (b *Builder) GenerateBuildSrc(ctx context.Context, projectPath string, conf
 		}
 		if wrapName != "" {
 			if err := b.downloadWrap(projectPath, wrapName); err != nil {
-				fmt.Printf("%sWarning: could not download %s wrap: %v%s\n", colors.Yellow, wrapName, err, colors.Reset)
+				output.Warnf("could not download %s wrap: %v", wrapName, err)
 			}
 		}
 	}
@@ -570,7 +821,7 @@ func (b *Builder) GenerateBuildSrc(ctx context.Context, projectPath string, conf
 		}
 		if wrapName != "" {
 			if err := b.downloadWrap(projectPath, wrapName); err != nil {
-				fmt.Printf("%sWarning: could not download %s wrap: %v%s\n", colors.Yellow, wrapName, err, colors.Reset)
+				output.Warnf("could not download %s wrap: %v", wrapName, err)
 			}
 		}
 	}
@@ -590,6 +841,9 @@ func (b *Builder) GenerateBuildTest(ctx context.Context, projectPath string, con
 
 	// Generate tests/meson.build
 	testsMeson := templates.GenerateMesonBuildTests(config.Name, config.TestFramework)
+	if config.HeaderOnly {
+		testsMeson = templates.LinkHeaderOnlyMeson(testsMeson, config.Name)
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "tests/meson.build"), []byte(testsMeson), 0644); err != nil {
 		return fmt.Errorf("failed to write tests/meson.build: %w", err)
 	}
@@ -608,6 +862,9 @@ func (b *Builder) GenerateBuildBench(ctx context.Context, projectPath string, co
 
 	// Generate bench/meson.build
 	benchMeson := templates.GenerateMesonBuildBench(config.Name, config.Benchmark)
+	if config.HeaderOnly {
+		benchMeson = templates.LinkHeaderOnlyMeson(benchMeson, config.Name)
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "bench/meson.build"), []byte(benchMeson), 0644); err != nil {
 		return fmt.Errorf("failed to write bench/meson.build: %w", err)
 	}
@@ -626,7 +883,7 @@ func (b *Builder) downloadWrap(projectPath, wrapName string) error {
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("meson wrap install failed for %s: %w", wrapName, err)
 	}
-	fmt.Printf("  Installed %s.wrap\n", wrapName)
+	fmt.Fprintf(output.Stdout(), "  Installed %s.wrap\n", wrapName)
 	return nil
 }
 
@@ -634,9 +891,9 @@ var _ build.BuildSystem = (*Builder)(nil)
 
 func removeDir(path string) {
 	if _, err := os.Stat(path); err == nil {
-		fmt.Printf("%s  Removing %s...%s\n", colors.Cyan, path, colors.Reset)
+		output.Stepf("  Removing %s...", path)
 		if err := os.RemoveAll(path); err != nil {
-			fmt.Printf("%s⚠ Failed to remove %s: %v%s\n", colors.Yellow, path, err, colors.Reset)
+			output.Warnf("Failed to remove %s: %v", path, err)
 		}
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
@@ -59,7 +60,7 @@ func TestBuild(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
-	require.Len(t, capturedArgs, 3) // setup, compile, copy
+	require.Len(t, capturedArgs, 2) // setup, compile (artifacts are copied in Go)
 	// meson setup
 	assert.Equal(t, "meson", capturedArgs[0][0])
 	assert.Equal(t, "setup", capturedArgs[0][1])
@@ -103,9 +104,9 @@ func TestBuild(t *testing.T) {
 	// Clean implementation in Meson builder (Step 1396 lines 203+):
 	// It just removes directories.
 
-	// So capturedArgs should just have Set up, Compile, Copy
+	// So capturedArgs should just have Set up, Compile
 
-	require.Len(t, capturedArgs, 3)
+	require.Len(t, capturedArgs, 2)
 	assert.Equal(t, "setup", capturedArgs[0][1])
 	assert.Contains(t, capturedArgs[0], "--buildtype=release")
 }
@@ -161,10 +162,9 @@ func TestRun(t *testing.T) {
 	// Build logic checks `os.Stat(buildDir)`. We created `builddir/src`, so `builddir` exists.
 	// So Build calls `meson configure`.
 	// 2. Build calls `meson compile`
-	// 3. Build calls `bash` copy script
-	// 4. Run calls `./builddir/src/myapp`
+	// 3. Run calls `./builddir/src/myapp` (artifacts are copied in Go)
 
-	require.GreaterOrEqual(t, len(capturedArgs), 4)
+	require.GreaterOrEqual(t, len(capturedArgs), 3)
 
 	// Check for run execution
 	lastCmd := capturedArgs[len(capturedArgs)-1]
@@ -212,6 +212,15 @@ func TestTest(t *testing.T) {
 	assert.Equal(t, "meson", capturedArgs[0][0])
 	assert.Equal(t, "test", capturedArgs[0][1])
 	assert.Contains(t, capturedArgs[0], "mytest")
+
+	capturedArgs = nil
+	err = builder.Test(context.Background(), build.TestOptions{
+		Labels:        []string{"integration"},
+		ExcludeLabels: []string{"slow"},
+	})
+	assert.NoError(t, err)
+	require.Len(t, capturedArgs, 1)
+	assert.Contains(t, strings.Join(capturedArgs[0], " "), "--suite integration --no-suite slow")
 }
 
 func TestBench(t *testing.T) {
@@ -426,3 +435,93 @@ func TestListTargets(t *testing.T) {
 	assert.Contains(t, targets, "myapp (executable)")
 	assert.Contains(t, targets, "mylib (shared library)")
 }
+
+func TestAddPathDependency(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+
+	libDir := filepath.Join(tmpDir, "my-lib")
+	appDir := filepath.Join(tmpDir, "app")
+	require.NoError(t, os.MkdirAll(libDir, 0755))
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "meson.build"), []byte("project('my-lib', 'cpp')"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "meson.build"), []byte("project('app', 'cpp')"), 0644))
+	require.NoError(t, os.Chdir(appDir))
+
+	builder := New()
+	require.NoError(t, builder.AddPathDependency(context.Background(), "../my-lib"))
+
+	target, err := os.Readlink(filepath.Join("subprojects", "my-lib"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("../../my-lib"), target)
+	assert.FileExists(t, filepath.Join("subprojects", "my-lib", "meson.build"))
+	assert.Contains(t, mesonPathDependencyUsage("../my-lib", "my-lib"), "my_lib_proj.get_variable('my_lib_lib')")
+
+	deps, err := builder.ListDependencies(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []build.Dependency{{Name: "my-lib", Version: "path"}}, deps)
+
+	assert.Error(t, builder.AddPathDependency(context.Background(), "../my-lib"))
+
+	require.NoError(t, builder.RemoveDependency(context.Background(), "my-lib"))
+	assert.NoFileExists(t, filepath.Join("subprojects", "my-lib"))
+	assert.FileExists(t, filepath.Join(libDir, "meson.build"), "the project itself is kept")
+}
+
+func TestMesonFailedTests(t *testing.T) {
+	dir := t.TempDir()
+	failed, err := mesonFailedTests(filepath.Join(dir, "testlog.json"))
+	require.NoError(t, err)
+	assert.Empty(t, failed, "no log yet")
+
+	log := `{"name": "unit", "result": "OK", "suite": ["myapp"]}
+{"name": "parser", "result": "FAIL", "suite": ["myapp"]}
+{"name": "flaky", "result": "TIMEOUT", "suite": ["myapp"]}
+{"name": "known", "result": "EXPECTEDFAIL", "suite": ["myapp"]}
+{"name": "optional", "result": "SKIP", "suite": ["myapp"]}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "testlog.json"), []byte(log), 0644))
+	failed, err = mesonFailedTests(filepath.Join(dir, "testlog.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"parser", "flaky"}, failed)
+}
+
+func TestMesonTestNames(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+
+	var capturedArgs []string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append([]string{name}, arg...)
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "MOCK_JSON_OUTPUT=myapp / unit\nmyapp / integration\n")
+		return cmd
+	}
+
+	names := mesonTestNames([]string{"test", "-C", "builddir"})
+	assert.Equal(t, []string{"unit", "integration"}, names)
+	assert.Equal(t, []string{"meson", "test", "-C", "builddir", "--list"}, capturedArgs)
+}
+
+func TestAddSubmoduleDependency(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('app', 'cpp')"), 0644))
+	dir := filepath.Join("third_party", "json")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	builder := New()
+	assert.Error(t, builder.AddSubmoduleDependency(context.Background(), "json", "third_party/json"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "CMakeLists.txt"), []byte("project(nlohmann_json)\n"), 0644))
+	require.NoError(t, builder.AddSubmoduleDependency(context.Background(), "json", "third_party/json"))
+	target, err := os.Readlink(filepath.Join("subprojects", "json"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("../third_party/json"), target)
+	assert.FileExists(t, filepath.Join("subprojects", "json", "CMakeLists.txt"))
+
+	assert.Error(t, builder.AddSubmoduleDependency(context.Background(), "json", "third_party/json"), "already exists")
+}
@@ -0,0 +1,114 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/naming"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+var (
+	_ build.PathDependencyAdder      = (*Builder)(nil)
+	_ build.SubmoduleDependencyAdder = (*Builder)(nil)
+)
+
+// AddPathDependency makes the Meson project in dir a subproject: Meson only
+// looks for subprojects in subprojects/, so it is linked there under its
+// project name.
+func (b *Builder) AddPathDependency(ctx context.Context, dir string) error {
+	rel, err := build.PathDependencyDir(dir)
+	if err != nil {
+		return err
+	}
+	name := GetProjectNameFromMesonBuild(rel)
+	if name == "" {
+		return fmt.Errorf("%s is not a Meson project: no project() in meson.build", dir)
+	}
+	if name == GetProjectNameFromMesonBuild(".") {
+		return fmt.Errorf("%s cannot depend on itself", name)
+	}
+
+	if err := linkSubproject(name, rel); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "Add this to your meson.build:\n\n")
+	fmt.Fprintln(output.Stdout(), mesonPathDependencyUsage(rel, name))
+	return nil
+}
+
+// AddSubmoduleDependency makes the library checked out in dir a subproject.
+// Meson projects are added as with --path; CMake projects are linked as
+// name and built through Meson's cmake module.
+func (b *Builder) AddSubmoduleDependency(ctx context.Context, name, dir string) error {
+	if GetProjectNameFromMesonBuild(dir) != "" {
+		return b.AddPathDependency(ctx, dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CMakeLists.txt")); err != nil {
+		return fmt.Errorf("%s has no meson.build or CMakeLists.txt to build it with", dir)
+	}
+	rel, err := build.PathDependencyDir(dir)
+	if err != nil {
+		return err
+	}
+	if err := linkSubproject(name, rel); err != nil {
+		return err
+	}
+
+	safeName := naming.SafeIdent(name)
+	fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "%s is a CMake project; add this to your meson.build:\n\n", name)
+	fmt.Fprintf(output.Stdout(), "  cmake = import('cmake')\n")
+	fmt.Fprintf(output.Stdout(), "  %s_proj = cmake.subproject('%s')\n", safeName, name)
+	fmt.Fprintf(output.Stdout(), "  %s_dep = %s_proj.dependency('<library target>')\n\n", safeName, safeName)
+	return nil
+}
+
+// linkSubproject links subprojects/name to the directory rel, where Meson
+// finds it as a subproject.
+func linkSubproject(name, rel string) error {
+	link := filepath.Join("subprojects", name)
+	if _, err := os.Lstat(link); err == nil {
+		return fmt.Errorf("%s already exists", link)
+	}
+	if _, err := os.Stat(link + ".wrap"); err == nil {
+		return fmt.Errorf("%s is already a dependency (%s.wrap)", name, link)
+	}
+	if err := os.MkdirAll("subprojects", 0755); err != nil {
+		return fmt.Errorf("failed to create subprojects directory: %w", err)
+	}
+	target := rel
+	if !filepath.IsAbs(filepath.FromSlash(rel)) {
+		target = "../" + rel
+	}
+	if err := os.Symlink(filepath.FromSlash(target), link); err != nil {
+		return fmt.Errorf("failed to link %s to %s: %w\n  hint: on Windows, enable Developer Mode to allow symbolic links", link, rel, err)
+	}
+	output.Successf("✓ Linked %s to %s", link, rel)
+	return nil
+}
+
+// mesonPathDependencyUsage returns the meson.build lines that get the
+// dependency object of the cpx project name at rel. Header-only libraries
+// override their dependency; others expose their library and include
+// directories as subproject variables.
+func mesonPathDependencyUsage(rel, name string) string {
+	safeName := naming.SafeIdent(name)
+	if data, err := os.ReadFile(filepath.Join(rel, "meson.build")); err == nil &&
+		strings.Contains(string(data), fmt.Sprintf("meson.override_dependency('%s'", name)) {
+		return fmt.Sprintf("  %s_dep = dependency('%s')\n", safeName, name)
+	}
+	return fmt.Sprintf(`  %[1]s_proj = subproject('%[2]s')
+  %[1]s_dep = declare_dependency(
+    link_with : %[1]s_proj.get_variable('%[1]s_lib'),
+    include_directories : %[1]s_proj.get_variable('inc_dirs'),
+  )
+`, safeName, name)
+}
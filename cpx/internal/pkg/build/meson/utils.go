@@ -4,8 +4,22 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+
+	"github.com/ozacod/cpx/pkg/config"
 )
 
+// projectVersionRe matches the version: keyword of project() in meson.build
+var projectVersionRe = regexp.MustCompile(`(?s)project\s*\(.*?\bversion\s*:\s*['"]([^'"]+)['"]`)
+
+// ProjectName returns the name of the project in projectRoot: the name of
+// its cpx.yaml, else the one of its meson.build.
+func ProjectName(projectRoot string) string {
+	if manifest := config.ProjectManifest(projectRoot); manifest != nil && manifest.Name != "" {
+		return manifest.Name
+	}
+	return GetProjectNameFromMesonBuild(projectRoot)
+}
+
 func GetProjectNameFromMesonBuild(projectRoot string) string {
 	mesonBuildPath := filepath.Join(projectRoot, "meson.build")
 	data, err := os.ReadFile(mesonBuildPath)
@@ -22,3 +36,16 @@ func GetProjectNameFromMesonBuild(projectRoot string) string {
 
 	return ""
 }
+
+// GetProjectVersionFromMesonBuild returns the version of project() in the
+// meson.build of projectRoot, or "" when it has none.
+func GetProjectVersionFromMesonBuild(projectRoot string) string {
+	data, err := os.ReadFile(filepath.Join(projectRoot, "meson.build"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRe.FindStringSubmatch(string(data)); m != nil {
+		return m[1]
+	}
+	return ""
+}
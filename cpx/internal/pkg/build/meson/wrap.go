@@ -0,0 +1,117 @@
+package meson
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WrapFile is a parsed subprojects/<name>.wrap file.
+type WrapFile struct {
+	// Type is the wrap type: "file", "git", "hg", "svn" or "redirect".
+	Type string
+	// Values holds the keys of the [wrap-*] section.
+	Values map[string]string
+	// Provides holds the keys of the [provide] section.
+	Provides map[string]string
+}
+
+// readWrapFile reads and parses subprojects/<name>.wrap.
+func readWrapFile(name string) (*WrapFile, error) {
+	f, err := os.Open(filepath.Join("subprojects", name+".wrap"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	wrap := &WrapFile{
+		Values:   make(map[string]string),
+		Provides: make(map[string]string),
+	}
+
+	var section string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if strings.HasPrefix(section, "wrap-") {
+				wrap.Type = strings.TrimPrefix(section, "wrap-")
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case section == "provide":
+			wrap.Provides[key] = value
+		case strings.HasPrefix(section, "wrap-"):
+			wrap.Values[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s.wrap: %w", name, err)
+	}
+	if wrap.Type == "" {
+		return nil, fmt.Errorf("%s.wrap has no [wrap-*] section", name)
+	}
+	return wrap, nil
+}
+
+// ProvidedDependencies returns the dependency names the wrap provides.
+func (w *WrapFile) ProvidedDependencies() []string {
+	var deps []string
+	if names, ok := w.Provides["dependency_names"]; ok {
+		for _, n := range strings.Split(names, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				deps = append(deps, n)
+			}
+		}
+	}
+	var keys []string
+	for key := range w.Provides {
+		if key != "dependency_names" && key != "program_names" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return append(deps, keys...)
+}
+
+// SourceURL returns the upstream source location of the wrap.
+func (w *WrapFile) SourceURL() string {
+	if u := w.Values["source_url"]; u != "" {
+		return u
+	}
+	return w.Values["url"]
+}
+
+// homepageFromURL derives a project homepage from a source archive or git URL.
+// Only well-known forges are recognized, where the first two path segments
+// identify the repository.
+func homepageFromURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Host {
+	case "github.com", "gitlab.com", "bitbucket.org", "codeberg.org":
+	default:
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("https://%s/%s/%s", u.Host, parts[0], strings.TrimSuffix(parts[1], ".git"))
+}
@@ -0,0 +1,104 @@
+package meson
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFmtWrap = `[wrap-file]
+directory = fmt-10.1.1
+source_url = https://github.com/fmtlib/fmt/archive/10.1.1.tar.gz
+source_filename = fmt-10.1.1.tar.gz
+source_hash = 78b8c0a72b1c35e4443a7e308df52498252d1cefc2b08c9a97bc9ee6cfe61f8b
+patch_filename = fmt_10.1.1-1_patch.zip
+patch_url = https://wrapdb.mesonbuild.com/v2/fmt_10.1.1-1/get_patch
+patch_hash = 1234
+wrapdb_version = 10.1.1-1
+
+[provide]
+fmt = fmt_dep
+`
+
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+	require.NoError(t, os.Chdir(t.TempDir()))
+}
+
+func TestReadWrapFile(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll("subprojects", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("subprojects", "fmt.wrap"), []byte(testFmtWrap), 0644))
+
+	wrap, err := readWrapFile("fmt")
+	require.NoError(t, err)
+	assert.Equal(t, "file", wrap.Type)
+	assert.Equal(t, "10.1.1-1", wrap.Values["wrapdb_version"])
+	assert.Equal(t, []string{"fmt"}, wrap.ProvidedDependencies())
+	assert.Equal(t, "https://github.com/fmtlib/fmt/archive/10.1.1.tar.gz", wrap.SourceURL())
+
+	_, err = readWrapFile("missing")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestHomepageFromURL(t *testing.T) {
+	assert.Equal(t, "https://github.com/fmtlib/fmt", homepageFromURL("https://github.com/fmtlib/fmt/archive/10.1.1.tar.gz"))
+	assert.Equal(t, "https://gitlab.com/libeigen/eigen", homepageFromURL("https://gitlab.com/libeigen/eigen.git"))
+	assert.Equal(t, "", homepageFromURL("https://zlib.net/zlib-1.3.tar.gz"))
+	assert.Equal(t, "", homepageFromURL("not a url"))
+}
+
+func TestDependencyInfo(t *testing.T) {
+	chdirTemp(t)
+	mockWrapDB(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testReleasesJSON))
+	})
+	require.NoError(t, os.MkdirAll("subprojects", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("subprojects", "fmt.wrap"), []byte(testFmtWrap), 0644))
+
+	info, err := New().DependencyInfo(context.Background(), "fmt")
+	require.NoError(t, err)
+	assert.Equal(t, "fmt", info.Name)
+	assert.Equal(t, "10.2.0-1", info.Version)
+	assert.Equal(t, []string{"10.2.0-1", "10.1.1-1"}, info.Versions)
+	assert.Equal(t, "https://github.com/fmtlib/fmt", info.Homepage)
+	assert.Contains(t, info.Description, "Installed: 10.1.1-1")
+	assert.Contains(t, info.Description, "Update available: 10.1.1-1 -> 10.2.0-1")
+	assert.Contains(t, info.Description, "Patch: https://wrapdb.mesonbuild.com/v2/fmt_10.1.1-1/get_patch")
+}
+
+func TestDependencyInfoWrapDBOnly(t *testing.T) {
+	chdirTemp(t)
+	mockWrapDB(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testReleasesJSON))
+	})
+
+	info, err := New().DependencyInfo(context.Background(), "protobuf")
+	require.NoError(t, err)
+	assert.Equal(t, "25.2-1", info.Version)
+	assert.Equal(t, []string{"protobuf"}, info.Dependencies)
+	assert.Equal(t, "Provides programs: protoc", info.Description)
+
+	_, err = New().DependencyInfo(context.Background(), "does-not-exist")
+	assert.Error(t, err)
+}
+
+func TestDependencyInfoOffline(t *testing.T) {
+	chdirTemp(t)
+	mockWrapDB(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	require.NoError(t, os.MkdirAll("subprojects", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("subprojects", "fmt.wrap"), []byte(testFmtWrap), 0644))
+
+	info, err := New().DependencyInfo(context.Background(), "fmt")
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.1-1", info.Version)
+}
@@ -0,0 +1,170 @@
+package meson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// wrapdbReleasesURL is the WrapDB v2 index of all wraps and their versions.
+var wrapdbReleasesURL = "https://wrapdb.mesonbuild.com/v2/releases.json"
+
+// wrapdbCacheDir returns the directory used to cache WrapDB metadata.
+var wrapdbCacheDir = func() (string, error) {
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "wrapdb"), nil
+}
+
+// wrapdbCacheTTL is how long the cached release index is considered fresh.
+const wrapdbCacheTTL = 24 * time.Hour
+
+// WrapRelease is a WrapDB entry from releases.json.
+type WrapRelease struct {
+	DependencyNames []string `json:"dependency_names"`
+	ProgramNames    []string `json:"program_names"`
+	Versions        []string `json:"versions"`
+}
+
+// LatestVersion returns the newest version of the wrap (WrapDB lists newest first).
+func (r WrapRelease) LatestVersion() string {
+	if len(r.Versions) == 0 {
+		return ""
+	}
+	return r.Versions[0]
+}
+
+// loadWrapReleases returns the WrapDB release index, using the local cache
+// when it is fresh and falling back to a stale cache if WrapDB is unreachable.
+func loadWrapReleases(ctx context.Context) (map[string]WrapRelease, error) {
+	cacheDir, err := wrapdbCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	cachePath := filepath.Join(cacheDir, "releases.json")
+
+	if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < wrapdbCacheTTL {
+		if releases, err := readWrapReleases(cachePath); err == nil {
+			return releases, nil
+		}
+	}
+
+	data, fetchErr := fetchWrapReleases(ctx)
+	if fetchErr != nil {
+		if releases, err := readWrapReleases(cachePath); err == nil {
+			fmt.Fprintf(os.Stderr, "%sWarning: failed to refresh WrapDB index, using cached copy: %v%s\n", colors.Yellow, fetchErr, colors.Reset)
+			return releases, nil
+		}
+		return nil, fmt.Errorf("failed to fetch WrapDB index: %w", fetchErr)
+	}
+
+	var releases map[string]WrapRelease
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse WrapDB index: %w", err)
+	}
+
+	// Cache errors are not fatal; the index is simply fetched again next time
+	if err := os.MkdirAll(cacheDir, 0755); err == nil {
+		_ = os.WriteFile(cachePath, data, 0644)
+	}
+
+	return releases, nil
+}
+
+func fetchWrapReleases(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wrapdbReleasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, wrapdbReleasesURL)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func readWrapReleases(path string) (map[string]WrapRelease, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var releases map[string]WrapRelease
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// searchWrapReleases returns the names of wraps whose name or provided
+// dependency names contain query (case-insensitive). Exact and prefix
+// matches on the wrap name are ranked first.
+func searchWrapReleases(releases map[string]WrapRelease, query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	rank := func(name string) int {
+		lower := strings.ToLower(name)
+		switch {
+		case lower == query:
+			return 0
+		case strings.HasPrefix(lower, query):
+			return 1
+		case strings.Contains(lower, query):
+			return 2
+		}
+		for _, dep := range releases[name].DependencyNames {
+			if strings.Contains(strings.ToLower(dep), query) {
+				return 3
+			}
+		}
+		return -1
+	}
+
+	var names []string
+	ranks := make(map[string]int)
+	for name := range releases {
+		if r := rank(name); r >= 0 {
+			names = append(names, name)
+			ranks[name] = r
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if ranks[names[i]] != ranks[names[j]] {
+			return ranks[names[i]] < ranks[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// wrapDescription summarizes what a wrap provides, as WrapDB has no descriptions.
+func wrapDescription(r WrapRelease) string {
+	var parts []string
+	if len(r.DependencyNames) > 0 {
+		parts = append(parts, "dependencies: "+strings.Join(r.DependencyNames, ", "))
+	}
+	if len(r.ProgramNames) > 0 {
+		parts = append(parts, "programs: "+strings.Join(r.ProgramNames, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
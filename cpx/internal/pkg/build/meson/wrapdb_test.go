@@ -0,0 +1,90 @@
+package meson
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testReleasesJSON = `{
+  "fmt": {"dependency_names": ["fmt"], "versions": ["10.2.0-1", "10.1.1-1"]},
+  "spdlog": {"dependency_names": ["spdlog"], "versions": ["1.13.0-1"]},
+  "libfmtlog": {"dependency_names": ["fmtlog"], "versions": ["2.2.1-1"]},
+  "google-benchmark": {"dependency_names": ["benchmark", "benchmark-main"], "versions": ["1.8.3-1"]},
+  "protobuf": {"dependency_names": ["protobuf"], "program_names": ["protoc"], "versions": ["25.2-1"]}
+}`
+
+// mockWrapDB points the WrapDB client at a test server and a temporary cache.
+func mockWrapDB(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cacheDir := t.TempDir()
+	oldURL, oldCacheDir := wrapdbReleasesURL, wrapdbCacheDir
+	wrapdbReleasesURL = server.URL + "/v2/releases.json"
+	wrapdbCacheDir = func() (string, error) { return cacheDir, nil }
+	t.Cleanup(func() {
+		wrapdbReleasesURL, wrapdbCacheDir = oldURL, oldCacheDir
+	})
+	return cacheDir
+}
+
+func TestSearchDependencies(t *testing.T) {
+	requests := 0
+	cacheDir := mockWrapDB(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(testReleasesJSON))
+	})
+
+	b := New()
+	deps, err := b.SearchDependencies(context.Background(), "fmt")
+	require.NoError(t, err)
+	require.Len(t, deps, 2)
+	assert.Equal(t, "fmt", deps[0].Name)
+	assert.Equal(t, "10.2.0-1", deps[0].Version)
+	assert.Equal(t, "dependencies: fmt", deps[0].Description)
+	assert.Equal(t, "libfmtlog", deps[1].Name)
+
+	// Matches on provided dependency names
+	deps, err = b.SearchDependencies(context.Background(), "benchmark-main")
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	assert.Equal(t, "google-benchmark", deps[0].Name)
+
+	// The index is cached after the first request
+	assert.Equal(t, 1, requests)
+	assert.FileExists(t, filepath.Join(cacheDir, "releases.json"))
+}
+
+func TestSearchDependenciesStaleCache(t *testing.T) {
+	cacheDir := mockWrapDB(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	cachePath := filepath.Join(cacheDir, "releases.json")
+	require.NoError(t, os.WriteFile(cachePath, []byte(testReleasesJSON), 0644))
+	old := time.Now().Add(-2 * wrapdbCacheTTL)
+	require.NoError(t, os.Chtimes(cachePath, old, old))
+
+	deps, err := New().SearchDependencies(context.Background(), "protobuf")
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	assert.Equal(t, "dependencies: protobuf; programs: protoc", deps[0].Description)
+}
+
+func TestSearchDependenciesUnavailable(t *testing.T) {
+	mockWrapDB(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	_, err := New().SearchDependencies(context.Background(), "fmt")
+	assert.Error(t, err)
+}
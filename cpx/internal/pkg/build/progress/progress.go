@@ -0,0 +1,167 @@
+// Package progress shows the compact progress bar used by non-verbose
+// builds, fed by the progress lines of CMake, ninja and Bazel.
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/schollz/progressbar/v3"
+
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	"github.com/ozacod/cpx/internal/pkg/build/diagnostics"
+	"github.com/ozacod/cpx/internal/pkg/build/events"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+var (
+	// "[ 93%] Building CXX object ..." (CMake with Makefiles)
+	percentRe = regexp.MustCompile(`^\[\s*(\d+)%]`)
+	// "[12/40] Compiling C++ object ..." (ninja, also under meson compile)
+	// "[1,234 / 2,000] Compiling src/main.cc; 1s linux-sandbox" (Bazel)
+	countRe = regexp.MustCompile(`^\[\s*([\d,]+)\s*/\s*([\d,]+)\s*]`)
+)
+
+// Percent parses a build progress line and returns the completion
+// percentage. ok is false for lines that are not progress lines.
+func Percent(line string) (pct int, ok bool) {
+	if m := percentRe.FindStringSubmatch(line); m != nil {
+		pct, err := strconv.Atoi(m[1])
+		return pct, err == nil
+	}
+	if m := countRe.FindStringSubmatch(line); m != nil {
+		done, err1 := strconv.Atoi(strings.ReplaceAll(m[1], ",", ""))
+		total, err2 := strconv.Atoi(strings.ReplaceAll(m[2], ",", ""))
+		if err1 != nil || err2 != nil || total == 0 {
+			return 0, false
+		}
+		pct = done * 100 / total
+		if pct > 100 {
+			pct = 100
+		}
+		return pct, true
+	}
+	return 0, false
+}
+
+// Run runs cmd, showing its progress as a bar labelled with the step
+// number and description. Other output is held back; if the command fails
+// a summary of its compiler errors is printed.
+func Run(cmd *exec.Cmd, description string, currentStep, totalSteps int) error {
+	bar := progressbar.NewOptions(100,
+		progressbar.OptionSetWriter(os.Stderr),
+		progressbar.OptionEnableColorCodes(colors.Enabled()),
+		// CI logs and --quiet get no redrawn bar
+		progressbar.OptionSetVisibility(!output.Quiet() && output.Interactive()),
+		progressbar.OptionSetWidth(20),
+		progressbar.OptionSetDescription(fmt.Sprintf("[cyan][%d/%d][reset] %s", currentStep, totalSteps, description)),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "[cyan]█[reset]",
+			SaucerHead:    "[cyan]▸[reset]",
+			SaucerPadding: "░",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+		progressbar.OptionClearOnFinish(),
+	)
+
+	// Ensure cursor is restored on interrupt
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		<-sigCh
+		_ = bar.Clear()
+		fmt.Print("\033[?25h") // Show cursor
+		os.Exit(1)
+	}()
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+		pw.Close()
+	}()
+
+	// Everything, including progress lines, goes to the build log
+	nonProgress := scan(io.TeeReader(pr, buildlog.Writer()), func(pct int) {
+		_ = bar.Set(pct)
+		events.Progress(pct)
+	})
+	err := <-waitCh
+
+	// Complete the progress bar
+	_ = bar.Set(100)
+	_ = bar.Clear()
+
+	if events.Enabled() {
+		// warnings of successful builds are reported too
+		events.Diagnostics(diagnostics.Parse(nonProgress.String()))
+	}
+
+	if err != nil {
+		printFailure(nonProgress.String())
+		return err
+	}
+	return nil
+}
+
+// maxSummaryFiles limits how many files the failure summary lists.
+const maxSummaryFiles = 10
+
+// printFailure prints a summary of the compiler diagnostics in a failed
+// build's output, or the output itself if it has no recognizable errors.
+func printFailure(output string) {
+	summary := diagnostics.Parse(output)
+	if summary.Errors == 0 {
+		if output != "" {
+			fmt.Fprintln(os.Stderr, output)
+		}
+		return
+	}
+	summary.Print(os.Stderr, maxSummaryFiles)
+	fmt.Fprintf(os.Stderr, "  %sRun 'cpx log' for the full output%s\n", colors.Gray, colors.Reset)
+}
+
+// scan reads build output, reporting each change in progress to set and
+// returning the remaining lines.
+func scan(r io.Reader, set func(pct int)) *bytes.Buffer {
+	var nonProgress bytes.Buffer
+	lastPercent := -1
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 512*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if pct, ok := Percent(line); ok {
+			// Bazel's total grows as it discovers actions; never go backwards
+			if pct > lastPercent {
+				set(pct)
+				lastPercent = pct
+			}
+			continue
+		}
+		nonProgress.WriteString(line)
+		nonProgress.WriteByte('\n')
+	}
+	// drain so the command never blocks on a full pipe
+	_, _ = io.Copy(io.Discard, r)
+	return &nonProgress
+}
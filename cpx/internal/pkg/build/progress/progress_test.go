@@ -0,0 +1,46 @@
+package progress
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		line string
+		pct  int
+		ok   bool
+	}{
+		{"[ 93%] Building CXX object src/CMakeFiles/app.dir/main.cpp.o", 93, true},
+		{"[100%] Linking CXX executable app", 100, true},
+		{"[12/40] Building CXX object CMakeFiles/app.dir/main.cpp.o", 30, true},
+		{"[3/4] Compiling C++ object src/app.p/main.cpp.o", 75, true},
+		{"[1,234 / 2,468] Compiling src/main.cc; 1s linux-sandbox", 50, true},
+		{"[0 / 0] no actions running", 0, false},
+		{"INFO: Analyzed 3 targets (0 packages loaded, 0 targets configured).", 0, false},
+		{"src/main.cpp:3:5: error: expected ';' [-Werror]", 0, false},
+	}
+	for _, tt := range tests {
+		pct, ok := Percent(tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.pct, pct, tt.line)
+	}
+}
+
+func TestScan(t *testing.T) {
+	output := `INFO: Analyzed target //src:app
+[1 / 4] Compiling src/a.cc
+[2 / 10] Compiling src/b.cc
+[8 / 10] Compiling src/c.cc
+src/c.cc:1:1: warning: unused
+[10 / 10] Linking src/app
+`
+	var seen []int
+	rest := scan(strings.NewReader(output), func(pct int) { seen = append(seen, pct) })
+
+	// 2/10 is behind 1/4 and must not move the bar backwards
+	assert.Equal(t, []int{25, 80, 100}, seen)
+	assert.Equal(t, "INFO: Analyzed target //src:app\nsrc/c.cc:1:1: warning: unused\n", rest.String())
+}
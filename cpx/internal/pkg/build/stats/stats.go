@@ -0,0 +1,266 @@
+// Package stats records how long builds, tests and other commands take,
+// whether the build had anything to do and how large its output is, in
+// .cache/stats.jsonl, so "cpx stats" can show trends without any service.
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// File returns where the records of the project in root are kept.
+func File(root string) string {
+	return filepath.Join(config.DirsOf(root).Cache, "stats.jsonl")
+}
+
+// keep is how many records are kept; Append drops older ones.
+const keep = 2000
+
+// Record is one run of a command.
+type Record struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	// Variant is the output directory name: debug, release, O2, debug-asan...
+	Variant    string `json:"variant,omitempty"`
+	Toolchain  string `json:"toolchain,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Success    bool   `json:"success"`
+	// UpToDate is set when the build tool had nothing to rebuild
+	UpToDate bool `json:"up_to_date,omitempty"`
+	// CacheHits counts actions served from the Bazel disk or remote cache
+	CacheHits int `json:"cache_hits,omitempty"`
+	// ArtifactBytes is the size of the output directory after a build
+	ArtifactBytes int64 `json:"artifact_bytes,omitempty"`
+}
+
+// Duration returns how long the run took.
+func (r Record) Duration() time.Duration {
+	return time.Duration(r.DurationMS) * time.Millisecond
+}
+
+// Append adds r to the records of the project at root.
+func Append(root string, r Record) error {
+	path := File(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return trim(path)
+}
+
+// trim rewrites the file with the last keep records once it has grown well
+// past that, so the cost is paid rarely.
+func trim(path string) error {
+	records, err := load(path)
+	if err != nil || len(records) < keep+keep/2 {
+		return err
+	}
+	var b strings.Builder
+	for _, r := range records[len(records)-keep:] {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// Load returns the records of the project at root, oldest first.
+func Load(root string) ([]Record, error) {
+	records, err := load(File(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return records, err
+}
+
+func load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []Record
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Record
+		// A line cut short by an interrupted write is skipped
+		if err := json.Unmarshal(sc.Bytes(), &r); err == nil {
+			records = append(records, r)
+		}
+	}
+	return records, sc.Err()
+}
+
+var (
+	// upToDateRe matches what Ninja, Make and Meson print when nothing
+	// needs rebuilding
+	upToDateRe = regexp.MustCompile(`ninja: no work to do|Nothing to be done for`)
+	// cacheHitRe matches Bazel's action summary, e.g. "12 disk cache hit"
+	cacheHitRe = regexp.MustCompile(`(\d+) (?:disk|remote) cache hit`)
+	// actionsRe matches the number of targets Ninja ran, e.g. "[3/57]"
+	actionsRe = regexp.MustCompile(`^\[\d+/\d+\]`)
+)
+
+// ScanLog reads a build log for whether the build was up to date and how
+// many actions came from a cache.
+func ScanLog(path string) (upToDate bool, cacheHits int) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, 0
+	}
+	ranActions := false
+	for _, line := range strings.Split(string(data), "\n") {
+		switch {
+		case upToDateRe.MatchString(line):
+			upToDate = true
+		case actionsRe.MatchString(line):
+			ranActions = true
+		}
+		for _, m := range cacheHitRe.FindAllStringSubmatch(line, -1) {
+			n, _ := strconv.Atoi(m[1])
+			cacheHits += n
+		}
+	}
+	// Several build steps: up to date only if none of them did anything
+	return upToDate && !ranActions, cacheHits
+}
+
+// DirSize returns the total size of the files under dir, 0 if it is missing.
+func DirSize(dir string) int64 {
+	var size int64
+	_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// Summary aggregates the runs that share a key.
+type Summary struct {
+	Key       string        `json:"key"`
+	Runs      int           `json:"runs"`
+	Failures  int           `json:"failures"`
+	UpToDate  int           `json:"up_to_date"`
+	CacheHits int           `json:"cache_hits"`
+	Average   time.Duration `json:"average_ns"`
+	Fastest   time.Duration `json:"fastest_ns"`
+	Slowest   time.Duration `json:"slowest_ns"`
+	Last      time.Duration `json:"last_ns"`
+	// Trend compares the average of the newer half of the runs with the
+	// older half, in percent; positive means slower
+	Trend float64 `json:"trend_percent"`
+	// FirstSize and LastSize are the artifact sizes of the oldest and
+	// newest runs that recorded one
+	FirstSize int64 `json:"first_size,omitempty"`
+	LastSize  int64 `json:"last_size,omitempty"`
+}
+
+// Summarize groups records by key, skipping those whose key is "", and
+// returns the groups slowest first.
+func Summarize(records []Record, key func(Record) string) []Summary {
+	groups := map[string][]Record{}
+	for _, r := range records {
+		if k := key(r); k != "" {
+			groups[k] = append(groups[k], r)
+		}
+	}
+	var out []Summary
+	for k, rs := range groups {
+		s := Summary{Key: k, Runs: len(rs)}
+		var total time.Duration
+		for i, r := range rs {
+			d := r.Duration()
+			total += d
+			if i == 0 || d < s.Fastest {
+				s.Fastest = d
+			}
+			s.Slowest = max(s.Slowest, d)
+			if !r.Success {
+				s.Failures++
+			}
+			if r.UpToDate {
+				s.UpToDate++
+			}
+			s.CacheHits += r.CacheHits
+			if r.ArtifactBytes > 0 {
+				if s.FirstSize == 0 {
+					s.FirstSize = r.ArtifactBytes
+				}
+				s.LastSize = r.ArtifactBytes
+			}
+		}
+		s.Average = total / time.Duration(len(rs))
+		s.Last = rs[len(rs)-1].Duration()
+		s.Trend = trend(rs)
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Average != out[j].Average {
+			return out[i].Average > out[j].Average
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// trend compares the average duration of the newer half of rs with the
+// older half, in percent. Runs with nothing to rebuild are left out, since
+// they would hide real changes in build time.
+func trend(rs []Record) float64 {
+	rs = slices.DeleteFunc(slices.Clone(rs), func(r Record) bool { return r.UpToDate })
+	if len(rs) < 4 {
+		return 0
+	}
+	half := len(rs) / 2
+	older, newer := average(rs[:half]), average(rs[len(rs)-half:])
+	if older == 0 {
+		return 0
+	}
+	return (float64(newer) - float64(older)) / float64(older) * 100
+}
+
+func average(rs []Record) time.Duration {
+	var total time.Duration
+	for _, r := range rs {
+		total += r.Duration()
+	}
+	return total / time.Duration(len(rs))
+}
+
+// FormatTrend formats a trend percentage, "" when it is negligible.
+func FormatTrend(percent float64) string {
+	if percent > -5 && percent < 5 {
+		return ""
+	}
+	return fmt.Sprintf("%+.0f%%", percent)
+}
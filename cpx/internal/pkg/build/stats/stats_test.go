@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendLoad(t *testing.T) {
+	root := t.TempDir()
+	records, err := Load(root)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, Append(root, Record{Time: start, Command: "build", Variant: "debug", DurationMS: 1500, Success: true}))
+	require.NoError(t, Append(root, Record{Time: start.Add(time.Hour), Command: "test", DurationMS: 800}))
+
+	// A torn last line is skipped
+	f, err := os.OpenFile(File(root), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, _ = f.WriteString(`{"time":"2026-03`)
+	require.NoError(t, f.Close())
+
+	records, err = Load(root)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "build", records[0].Command)
+	assert.Equal(t, 1500*time.Millisecond, records[0].Duration())
+	assert.False(t, records[1].Success)
+}
+
+func TestAppendTrims(t *testing.T) {
+	root := t.TempDir()
+	var lines strings.Builder
+	for i := range keep + keep/2 - 1 {
+		fmt.Fprintf(&lines, `{"command":"build","duration_ms":%d}`+"\n", i)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".cache"), 0755))
+	require.NoError(t, os.WriteFile(File(root), []byte(lines.String()), 0644))
+	require.NoError(t, Append(root, Record{Command: "build", DurationMS: keep + keep/2 - 1}))
+
+	records, err := Load(root)
+	require.NoError(t, err)
+	require.Len(t, records, keep)
+	assert.Equal(t, int64(keep+keep/2-1), records[len(records)-1].DurationMS)
+}
+
+func TestScanLog(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "build.log")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	upToDate, hits := ScanLog(write("# cpx build\nninja: no work to do.\n# exit: ok\n"))
+	assert.True(t, upToDate)
+	assert.Zero(t, hits)
+
+	upToDate, _ = ScanLog(write("[1/2] Building CXX object main.cpp.o\n[2/2] Linking CXX executable app\nninja: no work to do.\n"))
+	assert.False(t, upToDate, "one of the steps ran")
+
+	_, hits = ScanLog(write("INFO: 57 processes: 40 disk cache hit, 5 remote cache hit, 12 linux-sandbox.\n"))
+	assert.Equal(t, 45, hits)
+
+	upToDate, hits = ScanLog(filepath.Join(dir, "missing.log"))
+	assert.False(t, upToDate)
+	assert.Zero(t, hits)
+}
+
+func TestSummarize(t *testing.T) {
+	var records []Record
+	for _, ms := range []int64{1000, 1000, 2000, 2000} {
+		records = append(records, Record{Command: "build", Variant: "debug", DurationMS: ms, Success: true, ArtifactBytes: ms * 10})
+	}
+	records = append(records,
+		Record{Command: "build", Variant: "debug", DurationMS: 100, Success: true, UpToDate: true},
+		Record{Command: "test", DurationMS: 5000},
+		Record{Command: "build", Toolchain: "linux-gcc", DurationMS: 9000, Success: true},
+	)
+
+	summaries := Summarize(records, func(r Record) string {
+		if r.Toolchain != "" {
+			return ""
+		}
+		return r.Command
+	})
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "test", summaries[0].Key, "slowest first")
+	assert.Equal(t, 1, summaries[0].Failures)
+
+	b := summaries[1]
+	assert.Equal(t, 5, b.Runs)
+	assert.Equal(t, 1, b.UpToDate)
+	assert.Equal(t, 100*time.Millisecond, b.Fastest)
+	assert.Equal(t, 2*time.Second, b.Slowest)
+	assert.Equal(t, 100*time.Millisecond, b.Last)
+	assert.InDelta(t, 100, b.Trend, 0.01, "up-to-date runs are left out of the trend")
+	assert.Equal(t, int64(10000), b.FirstSize)
+	assert.Equal(t, int64(20000), b.LastSize)
+}
+
+func TestFormatTrend(t *testing.T) {
+	assert.Equal(t, "", FormatTrend(3))
+	assert.Equal(t, "+25%", FormatTrend(25))
+	assert.Equal(t, "-10%", FormatTrend(-10))
+}
@@ -0,0 +1,108 @@
+package vcpkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// conanPackages maps vcpkg ports to the ConanCenter package providing them
+// where the two names differ.
+var conanPackages = map[string]string{
+	"nlohmann-json": "nlohmann_json",
+	"curl":          "libcurl",
+	"eigen3":        "eigen",
+	"sdl2":          "sdl",
+}
+
+// cxxStandardRe matches set(CMAKE_CXX_STANDARD <n>) in CMakeLists.txt.
+var cxxStandardRe = regexp.MustCompile(`set\s*\(\s*CMAKE_CXX_STANDARD\s+(\d+)`)
+
+// conanRequires maps deps to Conan references. Dependencies pinned to a
+// version in vcpkg.json require at least that version; the rest accept any.
+func conanRequires(deps []build.Dependency) []string {
+	var requires []string
+	for _, dep := range deps {
+		if privatePorts[dep.Name] {
+			continue
+		}
+		name := dep.Name
+		if n, ok := conanPackages[dep.Name]; ok {
+			name = n
+		}
+		if dep.Version != "" {
+			requires = append(requires, fmt.Sprintf("%s/[>=%s]", name, dep.Version))
+		} else {
+			requires = append(requires, name+"/[*]")
+		}
+	}
+	sort.Strings(requires)
+	return requires
+}
+
+// writeConanRecipe writes conanfile.py and test_package/ for the library, so
+// "conan create ." packages it for Conan users.
+func (b *Builder) writeConanRecipe(ctx context.Context) error {
+	data, err := os.ReadFile("CMakeLists.txt")
+	if err != nil {
+		return fmt.Errorf("failed to read CMakeLists.txt: %w", err)
+	}
+	if !installRuleRe.Match(data) {
+		return fmt.Errorf("CMakeLists.txt has no install() rules\n  hint: Conan packages the library with them; libraries created by cpx new include them")
+	}
+	if _, err := os.Stat("conanfile.py"); err == nil {
+		return fmt.Errorf("conanfile.py already exists\n  hint: delete it (and test_package/) to generate a new recipe")
+	}
+
+	manifest, err := b.portManifest(ctx, currentProjectName(), currentProjectVersion())
+	if err != nil {
+		return err
+	}
+	if manifest.Name == "" || manifest.Version == "" {
+		return fmt.Errorf("could not read the project name and VERSION from project() in CMakeLists.txt")
+	}
+	deps, err := b.ListDependencies(ctx)
+	if err != nil {
+		return err
+	}
+	recipe := templates.ConanRecipe{
+		Name:        manifest.Name,
+		Version:     manifest.Version,
+		Description: manifest.Description,
+		License:     manifest.License,
+		CppStandard: 17,
+		Requires:    conanRequires(deps),
+	}
+	if manifest := config.ProjectManifest("."); manifest != nil && manifest.CxxStandard != 0 {
+		recipe.CppStandard = manifest.CxxStandard
+	} else if m := cxxStandardRe.FindSubmatch(data); m != nil {
+		recipe.CppStandard, _ = strconv.Atoi(string(m[1]))
+	}
+
+	if err := os.WriteFile("conanfile.py", []byte(templates.GenerateConanfile(recipe)), 0644); err != nil {
+		return fmt.Errorf("failed to write conanfile.py: %w", err)
+	}
+	if err := os.MkdirAll("test_package", 0755); err != nil {
+		return fmt.Errorf("failed to create test_package directory: %w", err)
+	}
+	for name, content := range templates.GenerateConanTestPackage(recipe) {
+		path := filepath.Join("test_package", name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	output.Successf("✓ Wrote conanfile.py and test_package/ for %s %s", recipe.Name, recipe.Version)
+	fmt.Fprintf(output.Stdout(), "  %sCreate the package with: conan create .%s\n", colors.Gray, colors.Reset)
+	return nil
+}
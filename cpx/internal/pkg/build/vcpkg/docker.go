@@ -5,13 +5,14 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
 	"github.com/ozacod/cpx/internal/pkg/build/cmake"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 )
 
 // RunDockerBuild implements the DockerBuilder interface for CMake/vcpkg builds.
@@ -39,17 +40,6 @@ func (b *Builder) RunDockerBuild(ctx context.Context, opts build.DockerBuildOpti
 		optLevel = "2"
 	}
 
-	// Create a persistent build directory for this target
-	hostBuildDir := filepath.Join(opts.ProjectRoot, ".cache", "ci", opts.TargetName)
-	if err := os.MkdirAll(hostBuildDir, 0755); err != nil {
-		return fmt.Errorf("failed to create build directory: %w", err)
-	}
-
-	absBuildDir, err := filepath.Abs(hostBuildDir)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path for build directory: %w", err)
-	}
-
 	containerBuildDir := "/tmp/build"
 
 	// Build CMake arguments
@@ -69,7 +59,11 @@ func (b *Builder) RunDockerBuild(ctx context.Context, opts build.DockerBuildOpti
 		cmakeArgs = append(cmakeArgs, "-DENABLE_BENCHMARKS=ON")
 	}
 
-	cmakeArgs = append(cmakeArgs, "-DCMAKE_CXX_FLAGS=-O"+optLevel)
+	sanCFlags, sanLFlags := build.SanitizerFlags(opts.Sanitizer)
+	cmakeArgs = append(cmakeArgs, fmt.Sprintf("'-DCMAKE_CXX_FLAGS=-O%s%s'", optLevel, sanCFlags))
+	if sanLFlags != "" {
+		cmakeArgs = append(cmakeArgs, "-DCMAKE_EXE_LINKER_FLAGS="+sanLFlags, "-DCMAKE_SHARED_LINKER_FLAGS="+sanLFlags)
+	}
 	cmakeArgs = append(cmakeArgs, "-DVCPKG_DISABLE_REGISTRY_UPDATE=ON")
 	cmakeArgs = append(cmakeArgs, opts.CMakeArgs...)
 
@@ -99,22 +93,27 @@ find %s -maxdepth 2 -type f \( -name "lib*.a" -o -name "lib*.so" -o -name "lib*.
 		copyCommand = fmt.Sprintf(`find %s -maxdepth 2 -type f \( -name "lib*.a" -o -name "lib*.so" -o -name "lib*.dylib" \) ! -path "*/CMakeFiles/*" -exec cp {} /output/%s/ \; 2>/dev/null || true`, containerBuildDir, opts.TargetName)
 	}
 
-	// Setup vcpkg cache directories
-	vcpkgCacheDir := filepath.Join(absBuildDir, ".vcpkg_cache")
-	for _, subdir := range []string{"installed", "downloads", "buildtrees", "binary"} {
-		if err := os.MkdirAll(filepath.Join(vcpkgCacheDir, subdir), 0755); err != nil {
-			return fmt.Errorf("failed to create vcpkg cache directory: %w", err)
+	// Persistent build tree and vcpkg caches. Installed packages and build
+	// trees belong to the toolchain, while downloads and binary packages are
+	// keyed by hashes and shared by all toolchains.
+	var cacheMounts []string
+	for _, mount := range []struct{ name, path string }{
+		{opts.TargetName, containerBuildDir},
+		{opts.TargetName + "/.vcpkg_cache", "/tmp/.vcpkg_cache"},
+		{build.VcpkgDownloadsCache, "/tmp/.vcpkg_cache/downloads"},
+		{build.VcpkgBinaryCache, "/tmp/.vcpkg_cache/binary"},
+	} {
+		arg, err := opts.CacheMount(mount.name, mount.path)
+		if err != nil {
+			return err
 		}
+		cacheMounts = append(cacheMounts, "-v", arg)
 	}
 
 	absOutputDir, err := filepath.Abs(filepath.Join(opts.ProjectRoot, opts.OutputDir))
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path for output directory: %w", err)
 	}
-	absVcpkgCacheDir, err := filepath.Abs(vcpkgCacheDir)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path for vcpkg cache directory: %w", err)
-	}
 
 	// Environment exports
 	var envExports string
@@ -230,12 +229,15 @@ cmake %s%s
 `, envExports, vcpkgInstalledPath, vcpkgDownloadsPath, vcpkgBuildtreesPath, binaryCachePath, binaryCachePath, containerBuildDir, configEcho, strings.Join(cmakeArgs, " "), cmakeQuiet, buildEcho, strings.Join(buildArgs, " "), cmakeQuiet, testSection, benchSection, finalSteps)
 
 	// Run Docker container
-	fmt.Printf("  %s Running build in Docker container...%s\n", colors.Cyan, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "  %s Running build in Docker container...%s\n", colors.Cyan, colors.Reset)
 
 	dockerArgs := []string{"run", "--rm"}
 	if opts.Platform != "" {
 		dockerArgs = append(dockerArgs, "--platform", opts.Platform)
 	}
+	if opts.GPUs != "" {
+		dockerArgs = append(dockerArgs, "--gpus", opts.GPUs)
+	}
 
 	absProjectRoot, err := filepath.Abs(opts.ProjectRoot)
 	if err != nil {
@@ -244,16 +246,16 @@ cmake %s%s
 
 	dockerArgs = append(dockerArgs,
 		"-v", absProjectRoot+":/workspace:ro",
-		"-v", absBuildDir+":/tmp/build",
-		"-v", absOutputDir+":/output",
-		"-v", absVcpkgCacheDir+":/tmp/.vcpkg_cache",
+		"-v", absOutputDir+":/output")
+	dockerArgs = append(dockerArgs, cacheMounts...)
+	dockerArgs = append(dockerArgs,
 		"-w", "/workspace",
 		opts.ImageName,
 		"bash", "-c", buildScript)
 
-	cmd := exec.Command("docker", dockerArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := opts.ContainerCommand(dockerArgs...)
+	cmd.Stdout = buildlog.Stdout()
+	cmd.Stderr = buildlog.Stderr()
 
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("docker run failed: %w", err)
@@ -0,0 +1,37 @@
+package vcpkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/build/explain"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+var _ build.RebuildExplainer = (*Builder)(nil)
+
+// ExplainRebuild implements the RebuildExplainer interface by running ninja
+// with "-d explain" in the CMake build directory of the selected variant.
+func (b *Builder) ExplainRebuild(ctx context.Context, opts build.ExplainOptions) ([]build.RebuildReason, error) {
+	outDirName := build.GetOutputDir(opts.Release, opts.OptLevel, opts.Sanitizer)
+	cacheBuildDir := filepath.Join(config.CacheDir(), "native", outDirName)
+
+	if _, err := os.Stat(filepath.Join(cacheBuildDir, "build.ninja")); err != nil {
+		return nil, fmt.Errorf("no ninja build found in %s\n  hint: run 'cpx build' first", cacheBuildDir)
+	}
+
+	cmd := execCommand("ninja", explain.NinjaArgs(cacheBuildDir, opts)...)
+	output, err := cmd.CombinedOutput()
+	if opts.Verbose {
+		fmt.Print(string(output))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ninja failed: %w", err)
+	}
+
+	return explain.ParseNinja(string(output), time.Now()), nil
+}
@@ -57,3 +57,24 @@ func TestGenerate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.FileExists(t, filepath.Join(projectPath, "bench", "CMakeLists.txt"))
 }
+
+func TestGenerateLibraryPackageConfig(t *testing.T) {
+	projectPath := t.TempDir()
+	initConfig := build.InitConfig{
+		Name:        "mylib",
+		Version:     "1.2.0",
+		IsLibrary:   true,
+		CppStandard: 20,
+	}
+
+	require.NoError(t, New().GenerateBuildSrc(context.Background(), projectPath, initConfig))
+
+	cmakeLists, err := os.ReadFile(filepath.Join(projectPath, "CMakeLists.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(cmakeLists), "install(EXPORT mylibTargets")
+
+	config, err := os.ReadFile(filepath.Join(projectPath, "cmake", "mylibConfig.cmake.in"))
+	require.NoError(t, err)
+	assert.Contains(t, string(config), "@PACKAGE_INIT@")
+	assert.FileExists(t, filepath.Join(projectPath, "cmake", "mylib.pc.in"))
+}
@@ -0,0 +1,104 @@
+package vcpkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// Values of the vcpkg_installed setting in cpx-ci.yaml
+const (
+	InstalledLocal  = "local"
+	InstalledShared = "shared"
+)
+
+// InstalledDir returns the VCPKG_INSTALLED_DIR for the project in the
+// current directory. By default every project installs its dependencies in
+// .cache/native/vcpkg_installed. With shared_vcpkg_installed in the global
+// config, projects share <cache dir>/vcpkg_installed/<triplet>-<hash>, keyed
+// by the manifest, so projects with the same dependencies build them once.
+// "vcpkg_installed: local" or "shared" in cpx-ci.yaml overrides the global
+// setting for a project.
+func (b *Builder) InstalledDir() (string, error) {
+	if err := b.ensureConfig(); err != nil {
+		return "", err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	localDir := filepath.Join(config.DirsOf(cwd).Cache, "native", "vcpkg_installed")
+
+	mode := InstalledLocal
+	if b.globalConfig.SharedVcpkgInstalled {
+		mode = InstalledShared
+	}
+	ciConfig, err := config.LoadToolchains(filepath.Join(cwd, "cpx-ci.yaml"))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if ciConfig != nil && ciConfig.VcpkgInstalled != "" {
+		mode = ciConfig.VcpkgInstalled
+	}
+
+	switch mode {
+	case InstalledLocal:
+		return localDir, nil
+	case InstalledShared:
+	default:
+		return "", fmt.Errorf("invalid vcpkg_installed %q in cpx-ci.yaml (use %q or %q)", mode, InstalledLocal, InstalledShared)
+	}
+
+	key, err := manifestKey(cwd)
+	if os.IsNotExist(err) {
+		// Not a manifest project: nothing to share
+		return localDir, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "vcpkg_installed", hostTriplet()+"-"+key), nil
+}
+
+// manifestKey hashes the files that decide what vcpkg installs: the
+// manifest and, when present, vcpkg-configuration.json with the baseline
+// and registries.
+func manifestKey(dir string) (string, error) {
+	manifest, err := os.ReadFile(filepath.Join(dir, "vcpkg.json"))
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(manifest)
+	if configuration, err := os.ReadFile(filepath.Join(dir, "vcpkg-configuration.json")); err == nil {
+		h.Write([]byte{0})
+		h.Write(configuration)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// hostTriplet returns the triplet vcpkg installs for by default:
+// VCPKG_DEFAULT_TRIPLET, or the one matching this machine.
+func hostTriplet() string {
+	if triplet := os.Getenv("VCPKG_DEFAULT_TRIPLET"); triplet != "" {
+		return triplet
+	}
+	arch := map[string]string{"amd64": "x64", "386": "x86", "arm64": "arm64", "arm": "arm"}[runtime.GOARCH]
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	osName := map[string]string{"darwin": "osx"}[runtime.GOOS]
+	if osName == "" {
+		osName = runtime.GOOS
+	}
+	return arch + "-" + osName
+}
@@ -0,0 +1,50 @@
+package vcpkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstalledDir(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	t.Setenv("VCPKG_DEFAULT_TRIPLET", "x64-linux")
+	t.Chdir(t.TempDir())
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"dependencies": ["fmt"]}`), 0644))
+
+	localDir := filepath.Join(cwd, ".cache", "native", "vcpkg_installed")
+
+	b := &Builder{globalConfig: &config.GlobalConfig{}}
+	dir, err := b.InstalledDir()
+	require.NoError(t, err)
+	assert.Equal(t, localDir, dir)
+
+	b.globalConfig.SharedVcpkgInstalled = true
+	shared, err := b.InstalledDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheHome, "cpx", "vcpkg_installed"), filepath.Dir(shared))
+	assert.Regexp(t, `^x64-linux-[0-9a-f]{16}$`, filepath.Base(shared))
+
+	// Another manifest gets another directory
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"dependencies": ["fmt", "spdlog"]}`), 0644))
+	other, err := b.InstalledDir()
+	require.NoError(t, err)
+	assert.NotEqual(t, shared, other)
+
+	// The project can keep its own directory
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte("vcpkg_installed: local\n"), 0644))
+	dir, err = b.InstalledDir()
+	require.NoError(t, err)
+	assert.Equal(t, localDir, dir)
+
+	require.NoError(t, os.WriteFile("cpx-ci.yaml", []byte("vcpkg_installed: everywhere\n"), 0644))
+	_, err = b.InstalledDir()
+	assert.Error(t, err)
+}
@@ -0,0 +1,221 @@
+package vcpkg
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+)
+
+// hostOS and execLookPath are variables so tests can simulate a Windows host.
+var (
+	hostOS       = runtime.GOOS
+	execLookPath = exec.LookPath
+)
+
+// vswherePath is where the Visual Studio installer puts vswhere.exe. Its
+// presence means at least one Visual Studio installation exists.
+var vswherePath = filepath.Join(os.Getenv("ProgramFiles(x86)"), "Microsoft Visual Studio", "Installer", "vswhere.exe")
+
+// cmakeToolchain describes the generator and compiler CMake is configured
+// with. The zero value lets CMake pick both, with GCC/Clang-style flags.
+type cmakeToolchain struct {
+	Generator   string // passed as -G; empty lets CMake choose
+	Compiler    string // forced as CMAKE_C_COMPILER and CMAKE_CXX_COMPILER
+	MSVC        bool   // compiler takes MSVC-style flags (cl.exe or clang-cl)
+	MultiConfig bool   // artifacts go to a per-configuration subdirectory
+	Launcher    string // CMAKE_<LANG>_COMPILER_LAUNCHER, such as ccache
+}
+
+// detectCMakeToolchain resolves the cmake_generator setting: "" detects
+// the toolchain, "ninja", "vs" and "clang-cl" select one explicitly, and
+// anything else is passed to CMake as a generator name.
+func detectCMakeToolchain(setting string) (cmakeToolchain, error) {
+	windows := hostOS == "windows"
+	_, clErr := execLookPath("cl")
+	// vcvars puts cl.exe on PATH; an explicit CC/CXX wins over it
+	haveCL := windows && clErr == nil && !customCompiler()
+
+	switch strings.ToLower(setting) {
+	case "":
+		if !windows || customCompiler() {
+			return cmakeToolchain{}, nil
+		}
+		if haveCL {
+			if _, err := execLookPath("ninja"); err == nil {
+				return cmakeToolchain{Generator: "Ninja", MSVC: true}, nil
+			}
+			// CMake defaults to the newest Visual Studio generator
+			return cmakeToolchain{MSVC: true, MultiConfig: true}, nil
+		}
+		if _, err := os.Stat(vswherePath); err == nil {
+			return cmakeToolchain{MSVC: true, MultiConfig: true}, nil
+		}
+		// MinGW or another GCC-compatible toolchain
+		return cmakeToolchain{}, nil
+	case "ninja":
+		return cmakeToolchain{Generator: "Ninja", MSVC: haveCL}, nil
+	case "vs", "visual-studio":
+		if !windows {
+			return cmakeToolchain{}, fmt.Errorf("the Visual Studio generator is only available on Windows")
+		}
+		return cmakeToolchain{MSVC: true, MultiConfig: true}, nil
+	case "clang-cl":
+		if !windows {
+			return cmakeToolchain{}, fmt.Errorf("clang-cl is only supported on Windows")
+		}
+		if _, err := execLookPath("clang-cl"); err != nil {
+			return cmakeToolchain{}, fmt.Errorf("clang-cl not found in PATH\n  hint: install the \"C++ Clang tools for Windows\" Visual Studio component and run from a Developer Command Prompt")
+		}
+		return cmakeToolchain{Generator: "Ninja", Compiler: "clang-cl", MSVC: true}, nil
+	default:
+		tc := cmakeToolchain{Generator: setting}
+		if strings.HasPrefix(setting, "Visual Studio") {
+			tc.MSVC, tc.MultiConfig = true, true
+		} else {
+			tc.MSVC = haveCL
+			tc.MultiConfig = strings.HasSuffix(setting, "Multi-Config")
+		}
+		return tc, nil
+	}
+}
+
+// customCompiler reports whether the user picked a compiler through CC/CXX.
+func customCompiler() bool {
+	for _, v := range []string{"CC", "CXX"} {
+		if name := os.Getenv(v); name != "" {
+			base := strings.ToLower(strings.TrimSuffix(filepath.Base(name), ".exe"))
+			return base != "cl" && base != "clang-cl"
+		}
+	}
+	return false
+}
+
+// CMakeToolchain returns the CMake generator and compiler native builds use,
+// as resolved from the cmake_generator setting. Empty values are left to
+// CMake.
+func (b *Builder) CMakeToolchain() (generator, compiler string, err error) {
+	t, err := b.cmakeToolchain()
+	if err != nil {
+		return "", "", err
+	}
+	return t.Generator, t.Compiler, nil
+}
+
+// configureArgs returns the cmake arguments selecting the generator and compiler.
+func (t cmakeToolchain) configureArgs() []string {
+	var args []string
+	if t.Generator != "" {
+		args = append(args, "-G", t.Generator)
+	}
+	if t.Compiler != "" {
+		args = append(args, "-DCMAKE_C_COMPILER="+t.Compiler, "-DCMAKE_CXX_COMPILER="+t.Compiler)
+	}
+	if t.Launcher != "" {
+		args = append(args, "-DCMAKE_C_COMPILER_LAUNCHER="+t.Launcher, "-DCMAKE_CXX_COMPILER_LAUNCHER="+t.Launcher)
+	}
+	return args
+}
+
+// flagArgs returns the build type and the cmake arguments that set
+// optimization and sanitizer flags and defines for the toolchain, and with
+// reproducible those of a reproducible build.
+func (t cmakeToolchain) flagArgs(release bool, optLevel, sanitizer string, defines []string, reproducible bool) (string, []string, error) {
+	buildType, cxxFlags := determineBuildType(release, optLevel)
+	var linkerFlags string
+	var args []string
+
+	if t.MSVC {
+		if sanitizer != "" && sanitizer != "asan" {
+			return "", nil, fmt.Errorf("%s is not supported by MSVC (only asan is)", sanitizer)
+		}
+		cxxFlags = msvcOptFlags(optLevel)
+		if sanitizer == "asan" {
+			cxxFlags += " /fsanitize=address /Zi"
+			linkerFlags = "/INCREMENTAL:NO"
+			// the default Debug flags include /RTC1, which ASan rejects
+			args = append(args, "-DCMAKE_CXX_FLAGS_DEBUG=/Zi /Ob0 /Od", "-DCMAKE_C_FLAGS_DEBUG=/Zi /Ob0 /Od")
+		}
+		for _, d := range defines {
+			cxxFlags += " /D" + d
+		}
+		if reproducible {
+			cxxFlags += " /Brepro"
+			linkerFlags = strings.TrimSpace(linkerFlags + " /Brepro")
+		}
+		if cxxFlags != "" {
+			// setting CMAKE_<LANG>_FLAGS replaces CMake's MSVC defaults
+			cxxFlags = "/DWIN32 /D_WINDOWS /EHsc /GR " + strings.TrimSpace(cxxFlags)
+		}
+	} else {
+		sanCFlags, sanLFlags := build.SanitizerFlags(sanitizer)
+		cxxFlags += sanCFlags
+		linkerFlags = sanLFlags
+		for _, d := range defines {
+			cxxFlags += " -D" + d
+		}
+		if reproducible {
+			cxxFlags = strings.TrimSpace(cxxFlags + " " + strings.Join(build.ReproducibleFlags("."), " "))
+			if hostOS != "darwin" {
+				// D leaves timestamps, owners and modes out of static
+				// libraries; the macOS tools read ZERO_AR_DATE instead
+				for _, lang := range []string{"C", "CXX"} {
+					args = append(args,
+						"-DCMAKE_"+lang+"_ARCHIVE_CREATE=<CMAKE_AR> qcD <TARGET> <LINK_FLAGS> <OBJECTS>",
+						"-DCMAKE_"+lang+"_ARCHIVE_APPEND=<CMAKE_AR> qD <TARGET> <LINK_FLAGS> <OBJECTS>",
+						"-DCMAKE_"+lang+"_ARCHIVE_FINISH=<CMAKE_RANLIB> -D <TARGET>")
+				}
+			}
+		}
+	}
+
+	if cxxFlags != "" {
+		args = append(args, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
+	}
+	if linkerFlags != "" {
+		args = append(args, "-DCMAKE_EXE_LINKER_FLAGS="+linkerFlags, "-DCMAKE_SHARED_LINKER_FLAGS="+linkerFlags)
+	}
+	return buildType, args, nil
+}
+
+// msvcOptFlags translates an optimization level to cl.exe flags.
+func msvcOptFlags(optLevel string) string {
+	switch optLevel {
+	case "0":
+		return "/Od"
+	case "1", "s":
+		return "/O1"
+	case "2":
+		return "/O2"
+	case "3":
+		return "/O2 /Ob3"
+	case "fast":
+		return "/O2 /fp:fast"
+	}
+	return ""
+}
+
+// outputDir returns the directory the build's executables end up in.
+func (t cmakeToolchain) outputDir(buildDir, buildType string) string {
+	if t.MultiConfig {
+		return filepath.Join(buildDir, buildType)
+	}
+	return buildDir
+}
+
+// cmakeToolchain detects the toolchain for this machine's cmake_generator setting.
+func (b *Builder) cmakeToolchain() (cmakeToolchain, error) {
+	if err := b.ensureConfig(); err != nil {
+		return cmakeToolchain{}, err
+	}
+	t, err := detectCMakeToolchain(b.globalConfig.CMakeGenerator)
+	if err != nil {
+		return cmakeToolchain{}, err
+	}
+	t.Launcher = b.globalConfig.CompilerLauncher
+	return t, nil
+}
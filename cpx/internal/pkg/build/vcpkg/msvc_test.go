@@ -0,0 +1,195 @@
+package vcpkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// simulateWindows makes detection behave as on Windows with the given
+// programs on PATH.
+func simulateWindows(t *testing.T, programs ...string) {
+	oldHostOS, oldLookPath, oldVswhere := hostOS, execLookPath, vswherePath
+	t.Cleanup(func() { hostOS, execLookPath, vswherePath = oldHostOS, oldLookPath, oldVswhere })
+	hostOS = "windows"
+	vswherePath = filepath.Join(t.TempDir(), "vswhere.exe")
+	execLookPath = func(file string) (string, error) {
+		for _, p := range programs {
+			if p == file {
+				return file + ".exe", nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	t.Setenv("CC", "")
+	t.Setenv("CXX", "")
+}
+
+func TestDetectCMakeToolchain(t *testing.T) {
+	t.Run("developer prompt with ninja", func(t *testing.T) {
+		simulateWindows(t, "cl", "ninja")
+		tc, err := detectCMakeToolchain("")
+		require.NoError(t, err)
+		assert.Equal(t, cmakeToolchain{Generator: "Ninja", MSVC: true}, tc)
+	})
+
+	t.Run("developer prompt without ninja", func(t *testing.T) {
+		simulateWindows(t, "cl")
+		tc, err := detectCMakeToolchain("")
+		require.NoError(t, err)
+		assert.True(t, tc.MSVC)
+		assert.True(t, tc.MultiConfig)
+	})
+
+	t.Run("visual studio installed", func(t *testing.T) {
+		simulateWindows(t)
+		require.NoError(t, os.WriteFile(vswherePath, nil, 0644))
+		tc, err := detectCMakeToolchain("")
+		require.NoError(t, err)
+		assert.Equal(t, cmakeToolchain{MSVC: true, MultiConfig: true}, tc)
+	})
+
+	t.Run("mingw", func(t *testing.T) {
+		simulateWindows(t, "g++")
+		tc, err := detectCMakeToolchain("")
+		require.NoError(t, err)
+		assert.Equal(t, cmakeToolchain{}, tc)
+	})
+
+	t.Run("CXX overrides cl", func(t *testing.T) {
+		simulateWindows(t, "cl", "ninja")
+		t.Setenv("CXX", "g++")
+		tc, err := detectCMakeToolchain("")
+		require.NoError(t, err)
+		assert.False(t, tc.MSVC)
+	})
+
+	t.Run("clang-cl", func(t *testing.T) {
+		simulateWindows(t, "clang-cl")
+		tc, err := detectCMakeToolchain("clang-cl")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"-G", "Ninja", "-DCMAKE_C_COMPILER=clang-cl", "-DCMAKE_CXX_COMPILER=clang-cl"}, tc.configureArgs())
+		assert.True(t, tc.MSVC)
+	})
+
+	t.Run("clang-cl missing", func(t *testing.T) {
+		simulateWindows(t)
+		_, err := detectCMakeToolchain("clang-cl")
+		assert.ErrorContains(t, err, "clang-cl not found")
+	})
+
+	t.Run("generator name", func(t *testing.T) {
+		simulateWindows(t)
+		tc, err := detectCMakeToolchain("Visual Studio 17 2022")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"-G", "Visual Studio 17 2022"}, tc.configureArgs())
+		assert.True(t, tc.MultiConfig)
+	})
+
+	t.Run("compiler launcher", func(t *testing.T) {
+		tc := cmakeToolchain{Generator: "Ninja", Launcher: "ccache"}
+		assert.Equal(t, []string{"-G", "Ninja", "-DCMAKE_C_COMPILER_LAUNCHER=ccache", "-DCMAKE_CXX_COMPILER_LAUNCHER=ccache"}, tc.configureArgs())
+	})
+
+	t.Run("non-windows", func(t *testing.T) {
+		oldHostOS := hostOS
+		t.Cleanup(func() { hostOS = oldHostOS })
+		hostOS = "linux"
+		tc, err := detectCMakeToolchain("")
+		require.NoError(t, err)
+		assert.Equal(t, cmakeToolchain{}, tc)
+		_, err = detectCMakeToolchain("vs")
+		assert.Error(t, err)
+	})
+}
+
+func TestFlagArgs(t *testing.T) {
+	gcc := cmakeToolchain{}
+	buildType, args, err := gcc.flagArgs(false, "3", "asan", nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "Release", buildType)
+	assert.Contains(t, args, "-DCMAKE_CXX_FLAGS=-O3 -fsanitize=address -fno-omit-frame-pointer")
+	assert.Contains(t, args, "-DCMAKE_EXE_LINKER_FLAGS=-fsanitize=address")
+
+	msvc := cmakeToolchain{MSVC: true}
+	buildType, args, err = msvc.flagArgs(false, "2", "", nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "Release", buildType)
+	assert.Equal(t, []string{
+		"-DCMAKE_CXX_FLAGS=/DWIN32 /D_WINDOWS /EHsc /GR /O2",
+		"-DCMAKE_C_FLAGS=/DWIN32 /D_WINDOWS /EHsc /GR /O2",
+	}, args)
+
+	buildType, args, err = msvc.flagArgs(false, "", "asan", nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "Debug", buildType)
+	assert.Contains(t, args, "-DCMAKE_CXX_FLAGS=/DWIN32 /D_WINDOWS /EHsc /GR /fsanitize=address /Zi")
+	assert.Contains(t, args, "-DCMAKE_CXX_FLAGS_DEBUG=/Zi /Ob0 /Od")
+	assert.Contains(t, args, "-DCMAKE_EXE_LINKER_FLAGS=/INCREMENTAL:NO")
+
+	// Release without an explicit level keeps CMake's MSVC defaults
+	_, args, err = msvc.flagArgs(true, "", "", nil, false)
+	require.NoError(t, err)
+	assert.Empty(t, args)
+
+	_, _, err = msvc.flagArgs(false, "", "tsan", nil, false)
+	assert.ErrorContains(t, err, "not supported by MSVC")
+
+	_, args, err = gcc.flagArgs(true, "3", "", []string{"TRACING", "LEVEL=2"}, false)
+	require.NoError(t, err)
+	assert.Contains(t, args, "-DCMAKE_CXX_FLAGS=-O3 -DTRACING -DLEVEL=2")
+
+	_, args, err = msvc.flagArgs(true, "", "", []string{"TRACING"}, false)
+	require.NoError(t, err)
+	assert.Contains(t, args, "-DCMAKE_CXX_FLAGS=/DWIN32 /D_WINDOWS /EHsc /GR /DTRACING")
+}
+
+func TestFlagArgsReproducible(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Chdir(dir)
+	root, err := os.Getwd()
+	require.NoError(t, err)
+	oldHostOS := hostOS
+	t.Cleanup(func() { hostOS = oldHostOS })
+	hostOS = "linux"
+
+	_, args, err := cmakeToolchain{}.flagArgs(true, "", "", nil, true)
+	require.NoError(t, err)
+	assert.Contains(t, args, "-DCMAKE_CXX_FLAGS=-ffile-prefix-map="+root+"=.")
+	assert.Contains(t, args, "-DCMAKE_CXX_ARCHIVE_CREATE=<CMAKE_AR> qcD <TARGET> <LINK_FLAGS> <OBJECTS>")
+	assert.Contains(t, args, "-DCMAKE_C_ARCHIVE_FINISH=<CMAKE_RANLIB> -D <TARGET>")
+
+	hostOS = "darwin"
+	_, args, err = cmakeToolchain{}.flagArgs(true, "", "", nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"-DCMAKE_CXX_FLAGS=-ffile-prefix-map=" + root + "=.",
+		"-DCMAKE_C_FLAGS=-ffile-prefix-map=" + root + "=.",
+	}, args)
+
+	_, args, err = cmakeToolchain{MSVC: true}.flagArgs(true, "", "", nil, true)
+	require.NoError(t, err)
+	assert.Contains(t, args, "-DCMAKE_CXX_FLAGS=/DWIN32 /D_WINDOWS /EHsc /GR /Brepro")
+	assert.Contains(t, args, "-DCMAKE_EXE_LINKER_FLAGS=/Brepro")
+}
+
+func TestFindExecutablesMultiConfig(t *testing.T) {
+	simulateWindows(t)
+	buildDir := t.TempDir()
+	tc := cmakeToolchain{MSVC: true, MultiConfig: true}
+	outDir := tc.outputDir(buildDir, "Release")
+	require.NoError(t, os.MkdirAll(outDir, 0755))
+	for _, name := range []string{"app.exe", "app.pdb", "helper.EXE", "app_tests.exe", "lib.dll"} {
+		require.NoError(t, os.WriteFile(filepath.Join(outDir, name), nil, 0644))
+	}
+
+	executables, err := findExecutables(outDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(outDir, "app.exe"), filepath.Join(outDir, "helper.EXE")}, executables)
+	assert.Equal(t, buildDir, cmakeToolchain{}.outputDir(buildDir, "Release"))
+}
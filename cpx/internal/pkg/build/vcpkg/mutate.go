@@ -0,0 +1,75 @@
+package vcpkg
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+// MutationBuild is how cpx mutate compiles the tests: with a clang whose
+// pass plugin puts mutants into the code. The plugin finds its mull.yml
+// through MULL_CONFIG in the environment of cpx.
+type MutationBuild struct {
+	// BuildDir is the CMake build directory
+	BuildDir string
+
+	// CC and CXX are the C and C++ compilers
+	CC, CXX string
+
+	// Flags are the compiler flags of C and C++ files
+	Flags string
+
+	Verbose bool
+}
+
+// BuildMutationTests configures and builds the project's test executable
+// as mb describes, in a build directory of its own since the compiler
+// differs from other builds, and returns the path of the executable.
+func (b *Builder) BuildMutationTests(mb MutationBuild) (string, error) {
+	if err := b.SetupEnv(); err != nil {
+		return "", err
+	}
+	projectName := currentProjectName()
+	if projectName == "" {
+		return "", fmt.Errorf("failed to get project name from CMakeLists.txt")
+	}
+	profile, err := b.CMakeProfile(false, "0", "")
+	if err != nil {
+		return "", err
+	}
+
+	output.Stepf(" Building '%s' tests with mutants...", projectName)
+	args := append([]string{"-S", ".", "-B", mb.BuildDir}, profile.Args...)
+	args = append(args,
+		"-DENABLE_TESTING=ON",
+		"-DCMAKE_C_COMPILER="+mb.CC,
+		"-DCMAKE_CXX_COMPILER="+mb.CXX,
+		"-DCMAKE_C_FLAGS="+mb.Flags,
+		"-DCMAKE_CXX_FLAGS="+mb.Flags,
+	)
+	configure := execCommand("cmake", args...)
+	if err := runCMakeConfigure(configure, mb.Verbose); err != nil {
+		return "", fmt.Errorf("cmake configure failed: %w", err)
+	}
+
+	target := projectName + "_tests"
+	if err := runCMakeBuild([]string{"--build", mb.BuildDir, "--target", target}, mb.Verbose, 1, 1); err != nil {
+		return "", fmt.Errorf("failed to build tests: %w", err)
+	}
+
+	exe := ""
+	_ = filepath.WalkDir(mb.BuildDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.TrimSuffix(d.Name(), ".exe") == target {
+			exe = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if exe == "" {
+		return "", fmt.Errorf("test executable %s not found in %s", target, mb.BuildDir)
+	}
+	return exe, nil
+}
@@ -0,0 +1,139 @@
+package vcpkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+var _ build.Packager = (*Builder)(nil)
+
+// Package builds the project in release mode, installs it into a staging
+// directory with "cmake --install" and archives the result as
+// <output>/<name>-<version>-<os>-<arch>.tar.gz, or <name>-<version>-noarch
+// for a header-only library. The archive holds what the project's install()
+// rules install: headers, libraries and the exported <name>Config.cmake.
+func (b *Builder) Package(ctx context.Context, opts build.PackageOptions) (string, error) {
+	data, err := os.ReadFile("CMakeLists.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to read CMakeLists.txt: %w", err)
+	}
+	if !installRuleRe.Match(data) {
+		return "", fmt.Errorf("CMakeLists.txt has no install() rules\n  hint: libraries created by cpx new include them; copy the install section of a new library project")
+	}
+
+	if err := b.Build(ctx, build.BuildOptions{Release: true, Verbose: opts.Verbose}); err != nil {
+		return "", err
+	}
+
+	projectName := currentProjectName()
+	if projectName == "" {
+		projectName = "project"
+	}
+	base := projectName
+	if version := currentProjectVersion(); version != "" {
+		base += "-" + version
+	}
+	// A header-only library installs the same files on every platform
+	if isHeaderOnly(string(data), projectName) {
+		base += "-noarch"
+	} else {
+		base += "-" + hostOS + "-" + runtime.GOARCH
+	}
+
+	cacheBuildDir := filepath.Join(config.CacheDir(), "native", build.GetOutputDir(true, "", ""))
+	stagingDir := filepath.Join(config.CacheDir(), "package", base)
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return "", fmt.Errorf("failed to clean %s: %w", stagingDir, err)
+	}
+
+	output.Stepf("  Installing into %s...", stagingDir)
+	absStaging, err := filepath.Abs(stagingDir)
+	if err != nil {
+		return "", err
+	}
+	cmd := execCommand("cmake", "--install", cacheBuildDir, "--prefix", absStaging, "--config", "Release")
+	cmd.Stdout = buildlog.Writer()
+	cmd.Stderr = buildlog.Stderr()
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("cmake install failed: %w", err)
+	}
+
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = "dist"
+	}
+	archive := filepath.Join(outputDir, base+".tar.gz")
+	if err := artifacts.Tarball(stagingDir, archive, base); err != nil {
+		return "", err
+	}
+
+	output.Successf("✓ Packaged %s", archive)
+	fmt.Fprintf(output.Stdout(), "  %sConsumers: extract it and set CMAKE_PREFIX_PATH to the extracted directory, then find_package(%s CONFIG)%s\n",
+		colors.Gray, projectName, colors.Reset)
+	return archive, nil
+}
+
+var (
+	// install(TARGETS ...) or install(EXPORT ...), not a commented-out one
+	installRuleRe = regexp.MustCompile(`(?m)^\s*install\s*\(`)
+	// project(name VERSION 1.2.3 ...)
+	projectVersionRe = regexp.MustCompile(`project\s*\(\s*[^\s\)]+[^)]*?\bVERSION\s+([^\s\)]+)`)
+)
+
+// isHeaderOnly reports whether the project's library target in cmakeLists is
+// an INTERFACE library.
+func isHeaderOnly(cmakeLists, projectName string) bool {
+	re := regexp.MustCompile(`(?m)^\s*add_library\s*\(\s*` + regexp.QuoteMeta(projectName) + `\s+INTERFACE\b`)
+	return re.MatchString(cmakeLists)
+}
+
+// currentProjectVersion returns the version of the project in the current
+// directory: the version of its cpx.yaml, else the one of its
+// CMakeLists.txt.
+func currentProjectVersion() string {
+	if manifest := config.ProjectManifest("."); manifest != nil && manifest.Version != "" {
+		return manifest.Version
+	}
+	return getProjectVersionFromCMakeLists()
+}
+
+// CMakeProjectInfo returns the name and version of the project() of the
+// CMakeLists.txt in root, ignoring cpx.yaml.
+func CMakeProjectInfo(root string) (name, version string) {
+	data, err := os.ReadFile(filepath.Join(root, "CMakeLists.txt"))
+	if err != nil {
+		return "", ""
+	}
+	return cmakeProjectName(string(data)), cmakeProjectVersion(string(data))
+}
+
+// getProjectVersionFromCMakeLists extracts the project version from
+// CMakeLists.txt in the current directory.
+func getProjectVersionFromCMakeLists() string {
+	data, err := os.ReadFile("CMakeLists.txt")
+	if err != nil {
+		return ""
+	}
+	return cmakeProjectVersion(string(data))
+}
+
+// cmakeProjectVersion returns the VERSION of project() in CMakeLists.txt
+// content.
+func cmakeProjectVersion(content string) string {
+	if m := projectVersionRe.FindStringSubmatch(content); m != nil {
+		return strings.Trim(m[1], `"`)
+	}
+	return ""
+}
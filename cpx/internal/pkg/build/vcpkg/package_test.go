@@ -0,0 +1,133 @@
+package vcpkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackage(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	var capturedArgs [][]string
+	execCommand = mockExecCommand(&capturedArgs)
+
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	cmakeLists := "cmake_minimum_required(VERSION 3.20)\nproject(mylib VERSION 1.2.0 LANGUAGES CXX)\n" +
+		"add_library(mylib STATIC src/mylib.cpp)\ninstall(TARGETS mylib EXPORT mylibTargets)\n"
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte(cmakeLists), 0644))
+	require.NoError(t, os.WriteFile("vcpkg", []byte(""), 0755))
+	// configured release build, so only "cmake --build" runs
+	cacheDir := filepath.Join(".cache", "native", build.GetOutputDir(true, "", ""))
+	require.NoError(t, os.MkdirAll(cacheDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "CMakeCache.txt"), []byte(""), 0644))
+
+	builder := setupTestConfig(t, tmpDir)
+	archive, err := builder.Package(context.Background(), build.PackageOptions{OutputDir: "out"})
+	require.NoError(t, err)
+
+	base := "mylib-1.2.0-" + runtime.GOOS + "-" + runtime.GOARCH
+	assert.Equal(t, filepath.Join("out", base+".tar.gz"), archive)
+
+	var install []string
+	for _, args := range capturedArgs {
+		if len(args) > 1 && args[1] == "--install" {
+			install = args
+		}
+	}
+	require.NotNil(t, install, "cmake --install should be called")
+	absStaging, _ := filepath.Abs(filepath.Join(".cache", "package", base))
+	assert.Equal(t, []string{"cmake", "--install", cacheDir, "--prefix", absStaging, "--config", "Release"}, install)
+
+	f, err := os.Open(archive)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Contains(t, names, base+"/include/mylib.hpp")
+	assert.Contains(t, names, base+"/lib/libmylib.a")
+	assert.Contains(t, names, base+"/lib/cmake/mylib/mylibConfig.cmake")
+}
+
+func TestPackageWithoutInstallRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	cmakeLists := "project(app)\nadd_executable(app main.cpp)\n# install(TARGETS app)\n"
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte(cmakeLists), 0644))
+
+	_, err := New().Package(context.Background(), build.PackageOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no install() rules")
+}
+
+func TestGetProjectVersionFromCMakeLists(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"project(mylib VERSION 1.2.0 LANGUAGES CXX)", "1.2.0"},
+		{"project(\n  mylib\n  VERSION 0.3.1\n)", "0.3.1"},
+		{"project(mylib LANGUAGES CXX VERSION 2.0)", "2.0"},
+		{"project(mylib LANGUAGES CXX)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			defer func() { _ = os.Chdir(oldWd) }()
+			require.NoError(t, os.Chdir(tmpDir))
+			require.NoError(t, os.WriteFile("CMakeLists.txt", []byte(tt.content), 0644))
+			assert.Equal(t, tt.want, getProjectVersionFromCMakeLists())
+		})
+	}
+}
+
+func TestCurrentProjectInfo(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(mylib VERSION 1.2.0 LANGUAGES CXX)\n"), 0644))
+	assert.Equal(t, "mylib", currentProjectName())
+	assert.Equal(t, "1.2.0", currentProjectVersion())
+
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("name: fancylib\nversion: 2.0.0\n"), 0644))
+	assert.Equal(t, "fancylib", currentProjectName(), "cpx.yaml takes precedence")
+	assert.Equal(t, "2.0.0", currentProjectVersion())
+	name, version := CMakeProjectInfo(".")
+	assert.Equal(t, "mylib", name)
+	assert.Equal(t, "1.2.0", version)
+}
+
+func TestIsHeaderOnly(t *testing.T) {
+	assert.True(t, isHeaderOnly("add_library(mylib INTERFACE)\n", "mylib"))
+	assert.True(t, isHeaderOnly("add_library( mylib\n    INTERFACE\n)\n", "mylib"))
+	assert.False(t, isHeaderOnly("add_library(mylib\n    src/mylib.cpp\n)\n", "mylib"))
+	assert.False(t, isHeaderOnly("add_library(other INTERFACE)\nadd_library(mylib src/mylib.cpp)\n", "mylib"))
+}
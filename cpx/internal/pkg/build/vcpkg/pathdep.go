@@ -0,0 +1,184 @@
+package vcpkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+// pathDependencyMarker starts the CMakeLists.txt block of a path dependency.
+const pathDependencyMarker = "# Path dependency (cpx add --path): "
+
+var (
+	_ build.PathDependencyAdder      = (*Builder)(nil)
+	_ build.SubmoduleDependencyAdder = (*Builder)(nil)
+)
+
+// AddPathDependency builds the CMake project in dir as part of this one: it
+// adds it with add_subdirectory, links its <name>::<name> target and adds
+// the vcpkg ports it depends on to this project's manifest.
+func (b *Builder) AddPathDependency(ctx context.Context, dir string) error {
+	rel, err := build.PathDependencyDir(dir)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(rel, "CMakeLists.txt"))
+	if err != nil {
+		return fmt.Errorf("%s is not a CMake project: no CMakeLists.txt", dir)
+	}
+	name := cmakeProjectName(string(data))
+	if name == "" {
+		return fmt.Errorf("no project() found in %s", filepath.Join(dir, "CMakeLists.txt"))
+	}
+	if !strings.Contains(string(data), fmt.Sprintf("add_library(%[1]s::%[1]s ALIAS", name)) {
+		return fmt.Errorf("%s does not export a %s::%s library target", dir, name, name)
+	}
+
+	cmakeLists, err := os.ReadFile("CMakeLists.txt")
+	if err != nil {
+		return fmt.Errorf("failed to read CMakeLists.txt: %w", err)
+	}
+	if strings.Contains(string(cmakeLists), pathDependencyMarker+name+"\n") {
+		return fmt.Errorf("%s is already a dependency", name)
+	}
+	target := cmakeProjectName(string(cmakeLists))
+	if target == name {
+		return fmt.Errorf("%s cannot depend on itself", name)
+	}
+	if err := os.WriteFile("CMakeLists.txt", []byte(addCMakePathDependency(string(cmakeLists), name, target, rel)), 0644); err != nil {
+		return fmt.Errorf("failed to write CMakeLists.txt: %w", err)
+	}
+	output.Successf("✓ Added %s (%s) to CMakeLists.txt", name, rel)
+
+	// The dependency's own ports are needed to configure it
+	deps, err := manifestDependencies(filepath.Join(rel, "vcpkg.json"))
+	if err != nil {
+		return err
+	}
+	if len(deps) > 0 {
+		have, err := b.ListDependencies(ctx)
+		if err != nil {
+			return err
+		}
+		existing := make(map[string]bool, len(have))
+		for _, dep := range have {
+			existing[dep.Name] = true
+		}
+		var ports []string
+		for _, dep := range deps {
+			if !existing[dep.Name] {
+				ports = append(ports, dep.Name)
+			}
+		}
+		if len(ports) > 0 {
+			if err := b.SetupEnv(); err != nil {
+				return err
+			}
+			if err := b.RunCommand(append([]string{"add", "port"}, ports...)); err != nil {
+				return fmt.Errorf("failed to add the dependencies of %s: %w", name, err)
+			}
+			output.Successf("✓ Added %s's dependencies: %s", name, strings.Join(ports, ", "))
+		}
+	}
+
+	fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "%s is linked to %s; link it to other targets with:\n\n", name, target)
+	fmt.Fprintf(output.Stdout(), "  target_link_libraries(<target> PRIVATE %s::%s)\n\n", name, name)
+	return nil
+}
+
+// AddSubmoduleDependency builds the CMake project checked out in dir with
+// this one. cpx libraries are linked as with --path; for other libraries
+// the target names are unknown, so only add_subdirectory is added and
+// linking is left to the user.
+func (b *Builder) AddSubmoduleDependency(ctx context.Context, name, dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "CMakeLists.txt"))
+	if err != nil {
+		return fmt.Errorf("%s is not a CMake project: no CMakeLists.txt", dir)
+	}
+	if lib := cmakeProjectName(string(data)); lib != "" &&
+		strings.Contains(string(data), fmt.Sprintf("add_library(%[1]s::%[1]s ALIAS", lib)) {
+		return b.AddPathDependency(ctx, dir)
+	}
+
+	cmakeLists, err := os.ReadFile("CMakeLists.txt")
+	if err != nil {
+		return fmt.Errorf("failed to read CMakeLists.txt: %w", err)
+	}
+	if strings.Contains(string(cmakeLists), pathDependencyMarker+name+"\n") {
+		return fmt.Errorf("%s is already a dependency", name)
+	}
+	if err := os.WriteFile("CMakeLists.txt", []byte(addCMakeSubdirectory(string(cmakeLists), name, filepath.ToSlash(dir))), 0644); err != nil {
+		return fmt.Errorf("failed to write CMakeLists.txt: %w", err)
+	}
+	output.Successf("✓ Added %s (%s) to CMakeLists.txt", name, filepath.ToSlash(dir))
+
+	fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, name, colors.Reset)
+	fmt.Fprintf(output.Stdout(), "%s is built with the project; link the library targets it defines with:\n\n", name)
+	fmt.Fprintf(output.Stdout(), "  target_link_libraries(<target> PRIVATE <library>)\n\n")
+	return nil
+}
+
+// addCMakeSubdirectory appends the block that builds the project at rel
+// without linking it to anything. It uses the path dependency marker, so
+// cpx remove takes it out the same way.
+func addCMakeSubdirectory(cmakeLists, name, rel string) string {
+	if !strings.HasSuffix(cmakeLists, "\n") {
+		cmakeLists += "\n"
+	}
+	return cmakeLists + fmt.Sprintf(`
+%[1]s%[2]s
+add_subdirectory(%[3]s ${CMAKE_BINARY_DIR}/_deps/%[2]s EXCLUDE_FROM_ALL)
+`, pathDependencyMarker, name, rel)
+}
+
+// addCMakePathDependency appends the block that builds the project at rel
+// and links its library to target.
+func addCMakePathDependency(cmakeLists, name, target, rel string) string {
+	if !strings.HasSuffix(cmakeLists, "\n") {
+		cmakeLists += "\n"
+	}
+	return cmakeLists + fmt.Sprintf(`
+%[1]s%[2]s
+add_subdirectory(%[3]s ${CMAKE_BINARY_DIR}/_deps/%[2]s EXCLUDE_FROM_ALL)
+target_link_libraries(%[4]s PRIVATE %[2]s::%[2]s)
+`, pathDependencyMarker, name, rel, target)
+}
+
+// removePathDependency removes the block of the path dependency name from
+// CMakeLists.txt, reporting whether there was one. The ports it brought in
+// stay in vcpkg.json.
+func removePathDependency(name string) (bool, error) {
+	data, err := os.ReadFile("CMakeLists.txt")
+	if err != nil {
+		return false, nil
+	}
+	block := regexp.MustCompile(`\n?` + regexp.QuoteMeta(pathDependencyMarker+name) + `\n(?:(?:add_subdirectory|target_link_libraries)\([^\n]*\n)*`)
+	loc := block.FindIndex(data)
+	if loc == nil {
+		return false, nil
+	}
+	data = append(data[:loc[0]:loc[0]], data[loc[1]:]...)
+	if err := os.WriteFile("CMakeLists.txt", data, 0644); err != nil {
+		return false, fmt.Errorf("failed to write CMakeLists.txt: %w", err)
+	}
+	output.Successf("✓ Removed %s from CMakeLists.txt", name)
+	return true, nil
+}
+
+// cmakeProjectName returns the name in the project() call of a
+// CMakeLists.txt.
+func cmakeProjectName(cmakeLists string) string {
+	re := regexp.MustCompile(`project\s*\(\s*([^\s\)]+)`)
+	if matches := re.FindStringSubmatch(cmakeLists); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package vcpkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+)
+
+// pkgConfigModules maps vcpkg ports to the pkg-config module they install
+// where the two names differ.
+var pkgConfigModules = map[string]string{
+	"nlohmann-json": "nlohmann_json",
+	"curl":          "libcurl",
+	"libjpeg-turbo": "libjpeg",
+}
+
+// privatePorts are test and benchmark frameworks, which consumers of an
+// installed library never need.
+var privatePorts = map[string]bool{
+	"gtest":              true,
+	"catch2":             true,
+	"doctest":            true,
+	"benchmark":          true,
+	"nanobench":          true,
+	"vcpkg-cmake":        true,
+	"vcpkg-cmake-config": true,
+}
+
+var pkgConfigRequiresRe = regexp.MustCompile(`(?m)^Requires:.*$`)
+
+// pkgConfigRequires returns the pkg-config modules a library depending on
+// deps requires.
+func pkgConfigRequires(deps []build.Dependency) []string {
+	var requires []string
+	for _, dep := range deps {
+		if privatePorts[dep.Name] {
+			continue
+		}
+		module := dep.Name
+		if m, ok := pkgConfigModules[dep.Name]; ok {
+			module = m
+		}
+		requires = append(requires, module)
+	}
+	return requires
+}
+
+// updatePkgConfig rewrites the Requires line of cmake/<name>.pc.in from the
+// dependencies in vcpkg.json. Projects without the template (executables,
+// libraries created before cpx generated one) are left alone.
+func (b *Builder) updatePkgConfig(ctx context.Context) error {
+	projectName := currentProjectName()
+	if projectName == "" {
+		return nil
+	}
+	path := filepath.Join("cmake", projectName+".pc.in")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	deps, err := b.ListDependencies(ctx)
+	if err != nil {
+		return err
+	}
+	line := "Requires: " + strings.Join(pkgConfigRequires(deps), ", ")
+	updated := pkgConfigRequiresRe.ReplaceAllLiteral(data, []byte(line))
+	if string(updated) == string(data) {
+		return nil
+	}
+	if err := os.WriteFile(path, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package vcpkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPkgConfigRequires(t *testing.T) {
+	deps := []build.Dependency{{Name: "fmt"}, {Name: "gtest"}, {Name: "nlohmann-json"}, {Name: "benchmark"}}
+	assert.Equal(t, []string{"fmt", "nlohmann_json"}, pkgConfigRequires(deps))
+	assert.Empty(t, pkgConfigRequires(nil))
+}
+
+func TestUpdatePkgConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(mylib VERSION 1.0.0 LANGUAGES CXX)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name":"mylib","dependencies":["fmt",{"name":"zlib"},"gtest"]}`), 0644))
+	require.NoError(t, os.MkdirAll("cmake", 0755))
+	pcPath := filepath.Join("cmake", "mylib.pc.in")
+	require.NoError(t, os.WriteFile(pcPath, []byte(templates.GeneratePkgConfig("mylib", nil)), 0644))
+
+	require.NoError(t, New().updatePkgConfig(context.Background()))
+
+	data, err := os.ReadFile(pcPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Requires: fmt, zlib\n")
+	assert.Contains(t, string(data), "Libs: -L${libdir} -lmylib")
+}
+
+func TestUpdatePkgConfigWithoutTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(myapp LANGUAGES CXX)\n"), 0644))
+	assert.NoError(t, New().updatePkgConfig(context.Background()))
+	assert.NoFileExists(t, filepath.Join("cmake", "myapp.pc.in"))
+}
@@ -0,0 +1,237 @@
+package vcpkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// cmakePresetFiles are the preset files CMake reads in a project root. The
+// user presets file, which is not committed, implicitly includes the other.
+var cmakePresetFiles = []string{"CMakePresets.json", "CMakeUserPresets.json"}
+
+// CMakePreset is a configure or build preset of a CMake presets file.
+type CMakePreset struct {
+	Name        string     `json:"name"`
+	DisplayName string     `json:"displayName,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Hidden      bool       `json:"hidden,omitempty"`
+	Inherits    stringList `json:"inherits,omitempty"`
+
+	// CacheVariables of a configure preset, a string or a {type, value}
+	// object each
+	CacheVariables map[string]any `json:"cacheVariables,omitempty"`
+
+	// ConfigurePreset is the configure preset of a build preset
+	ConfigurePreset string `json:"configurePreset,omitempty"`
+
+	// Configuration is the build type of a build preset
+	Configuration string `json:"configuration,omitempty"`
+
+	// Targets are the targets a build preset builds
+	Targets stringList `json:"targets,omitempty"`
+}
+
+// stringList is a JSON string or list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or a list of strings")
+	}
+	*l = list
+	return nil
+}
+
+// presetFile is the part of a CMake presets file cpx reads.
+type presetFile struct {
+	Include          []string      `json:"include"`
+	ConfigurePresets []CMakePreset `json:"configurePresets"`
+	BuildPresets     []CMakePreset `json:"buildPresets"`
+}
+
+// CMakePresets are the presets of a project, from CMakePresets.json,
+// CMakeUserPresets.json and the files they include.
+type CMakePresets struct {
+	Configure []CMakePreset
+	Build     []CMakePreset
+}
+
+// LoadCMakePresets reads the presets of the project in dir. A project
+// without presets files has no presets.
+func LoadCMakePresets(dir string) (*CMakePresets, error) {
+	presets := &CMakePresets{}
+	seen := map[string]bool{}
+	for _, name := range cmakePresetFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := presets.load(path, seen); err != nil {
+			return nil, err
+		}
+	}
+	return presets, nil
+}
+
+// load adds the presets of the file at path and the files it includes,
+// skipping files already in seen.
+func (p *CMakePresets) load(path string, seen map[string]bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if seen[abs] {
+		return nil
+	}
+	seen[abs] = true
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var file presetFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, include := range file.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		if err := p.load(include, seen); err != nil {
+			return err
+		}
+	}
+	p.Configure = append(p.Configure, file.ConfigurePresets...)
+	p.Build = append(p.Build, file.BuildPresets...)
+	return nil
+}
+
+// Names returns the names of the presets that can be selected: the visible
+// configure and build presets, sorted.
+func (p *CMakePresets) Names() []string {
+	var names []string
+	for _, preset := range slices.Concat(p.Configure, p.Build) {
+		if !preset.Hidden && !slices.Contains(names, preset.Name) {
+			names = append(names, preset.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// HasConfigure reports whether there is a visible configure preset called
+// name.
+func (p *CMakePresets) HasConfigure(name string) bool {
+	preset := findPreset(p.Configure, name)
+	return preset != nil && !preset.Hidden
+}
+
+// PresetSelection is what building with a preset uses.
+type PresetSelection struct {
+	// Name is the preset as selected
+	Name string
+
+	// Configure is the configure preset
+	Configure string
+
+	// BuildType is the CMake build type of the preset, if it sets one
+	BuildType string
+
+	// Targets are the targets of a build preset, if any
+	Targets []string
+}
+
+// Select returns what building with the preset called name uses. name is
+// a configure preset, or a build preset and its configure preset.
+func (p *CMakePresets) Select(name string) (*PresetSelection, error) {
+	if preset := findPreset(p.Configure, name); preset != nil && !preset.Hidden {
+		return &PresetSelection{Name: name, Configure: name, BuildType: p.cacheVariable(name, "CMAKE_BUILD_TYPE")}, nil
+	}
+	preset := findPreset(p.Build, name)
+	if preset == nil || preset.Hidden {
+		names := p.Names()
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown CMake preset %q: the project has no presets\n  hint: define it in CMakePresets.json or CMakeUserPresets.json", name)
+		}
+		return nil, fmt.Errorf("unknown CMake preset %q\n  hint: available presets: %s", name, strings.Join(names, ", "))
+	}
+	configure := p.buildField(name, func(b *CMakePreset) string { return b.ConfigurePreset })
+	if configure == "" {
+		return nil, fmt.Errorf("build preset %q has no configurePreset", name)
+	}
+	buildType := p.buildField(name, func(b *CMakePreset) string { return b.Configuration })
+	if buildType == "" {
+		buildType = p.cacheVariable(configure, "CMAKE_BUILD_TYPE")
+	}
+	return &PresetSelection{Name: name, Configure: configure, BuildType: buildType, Targets: preset.Targets}, nil
+}
+
+// cacheVariable returns a cache variable of the configure preset called
+// name, or of the presets it inherits from.
+func (p *CMakePresets) cacheVariable(name, variable string) string {
+	return inherited(p.Configure, name, func(c *CMakePreset) string {
+		switch v := c.CacheVariables[variable].(type) {
+		case string:
+			return v
+		case map[string]any:
+			if value, ok := v["value"].(string); ok {
+				return value
+			}
+		}
+		return ""
+	}, map[string]bool{})
+}
+
+// buildField returns a field of the build preset called name, or of the
+// presets it inherits from.
+func (p *CMakePresets) buildField(name string, field func(*CMakePreset) string) string {
+	return inherited(p.Build, name, field, map[string]bool{})
+}
+
+// inherited returns field of the preset called name, or else of the first
+// preset it inherits from that sets it.
+func inherited(presets []CMakePreset, name string, field func(*CMakePreset) string, seen map[string]bool) string {
+	preset := findPreset(presets, name)
+	if preset == nil || seen[name] {
+		return ""
+	}
+	seen[name] = true
+	if value := field(preset); value != "" {
+		return value
+	}
+	for _, parent := range preset.Inherits {
+		if value := inherited(presets, parent, field, seen); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func findPreset(presets []CMakePreset, name string) *CMakePreset {
+	for i := range presets {
+		if presets[i].Name == name {
+			return &presets[i]
+		}
+	}
+	return nil
+}
+
+// defaultPreset returns "default" when the project in the current directory
+// has a default configure preset, which cpx configures with, or "".
+func defaultPreset() string {
+	presets, err := LoadCMakePresets(".")
+	if err != nil || !presets.HasConfigure("default") {
+		return ""
+	}
+	return "default"
+}
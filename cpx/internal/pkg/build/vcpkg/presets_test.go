@@ -0,0 +1,79 @@
+package vcpkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCMakePresets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "CMakePresets.json"), []byte(templates.GenerateCMakePresets()), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cmake"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cmake", "ci.json"), []byte(`{
+  "version": 4,
+  "configurePresets": [
+    {"name": "ci", "inherits": ["release"], "cacheVariables": {"CI": {"type": "BOOL", "value": "ON"}}}
+  ]
+}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "CMakeUserPresets.json"), []byte(`{
+  "version": 4,
+  "include": ["cmake/ci.json"],
+  "configurePresets": [
+    {"name": "mine", "inherits": "asan"}
+  ],
+  "buildPresets": [
+    {"name": "mine-app", "configurePreset": "mine", "targets": "app"},
+    {"name": "ci-fast", "inherits": "ci-base"},
+    {"name": "ci-base", "hidden": true, "configurePreset": "ci", "configuration": "RelWithDebInfo"}
+  ]
+}`), 0644))
+
+	presets, err := LoadCMakePresets(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asan", "ci", "ci-fast", "debug", "default", "mine", "mine-app", "release"}, presets.Names(), "hidden presets are not listed")
+	assert.True(t, presets.HasConfigure("default"))
+	assert.False(t, presets.HasConfigure("vcpkg"), "hidden")
+
+	sel, err := presets.Select("mine")
+	require.NoError(t, err)
+	assert.Equal(t, PresetSelection{Name: "mine", Configure: "mine", BuildType: "Debug"}, *sel, "the build type is inherited through asan from debug")
+
+	sel, err = presets.Select("mine-app")
+	require.NoError(t, err)
+	assert.Equal(t, PresetSelection{Name: "mine-app", Configure: "mine", BuildType: "Debug", Targets: []string{"app"}}, *sel)
+
+	sel, err = presets.Select("ci-fast")
+	require.NoError(t, err)
+	assert.Equal(t, "ci", sel.Configure)
+	assert.Equal(t, "RelWithDebInfo", sel.BuildType, "configuration of a build preset wins")
+	assert.Equal(t, "ON", presets.cacheVariable("ci", "CI"))
+
+	_, err = presets.Select("vcpkg")
+	assert.ErrorContains(t, err, "available presets: asan, ci")
+}
+
+func TestLoadCMakePresetsWithoutFiles(t *testing.T) {
+	presets, err := LoadCMakePresets(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, presets.Names())
+	_, err = presets.Select("debug")
+	assert.ErrorContains(t, err, "the project has no presets")
+}
+
+func TestDefaultPreset(t *testing.T) {
+	dir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(dir))
+
+	assert.Equal(t, "", defaultPreset())
+	require.NoError(t, os.WriteFile("CMakePresets.json", []byte(`{"version": 2, "configurePresets": [{"name": "dev"}]}`), 0644))
+	assert.Equal(t, "", defaultPreset(), "presets without a default one are not used")
+	require.NoError(t, os.WriteFile("CMakePresets.json", []byte(templates.GenerateCMakePresets()), 0644))
+	assert.Equal(t, "default", defaultPreset())
+}
@@ -0,0 +1,69 @@
+package vcpkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// CMakeProfile is how native builds of one variant configure CMake, for
+// IDEs that run CMake themselves.
+type CMakeProfile struct {
+	// Name is the variant, as in .cache/native/<name>: debug, release, ...
+	Name string
+
+	// BuildDir is the CMake build directory, relative to the project
+	BuildDir string
+
+	// BuildType is the CMAKE_BUILD_TYPE
+	BuildType string
+
+	// Args are the configure arguments, without -B and the source directory
+	Args []string
+}
+
+// CMakeProfile returns the CMake configuration cpx build uses for the
+// variant, so an IDE configuring the project itself shares its build
+// directory. Unlike cpx build it names vcpkg's toolchain file instead of
+// relying on CMakePresets.json and VCPKG_ROOT.
+func (b *Builder) CMakeProfile(release bool, optLevel, sanitizer string) (CMakeProfile, error) {
+	vcpkgPath, err := b.GetPath()
+	if err != nil {
+		return CMakeProfile{}, err
+	}
+	toolchainFile := filepath.Join(filepath.Dir(vcpkgPath), "scripts", "buildsystems", "vcpkg.cmake")
+	if _, err := os.Stat(toolchainFile); err != nil {
+		return CMakeProfile{}, fmt.Errorf("vcpkg toolchain file not found: %s\n  Make sure vcpkg is properly installed", toolchainFile)
+	}
+	installedDir, err := b.InstalledDir()
+	if err != nil {
+		return CMakeProfile{}, err
+	}
+	toolchain, err := b.cmakeToolchain()
+	if err != nil {
+		return CMakeProfile{}, err
+	}
+	buildType, flagArgs, err := toolchain.flagArgs(release, optLevel, sanitizer, nil, false)
+	if err != nil {
+		return CMakeProfile{}, err
+	}
+
+	name := build.GetOutputDir(release, optLevel, sanitizer)
+	args := []string{
+		"-DCMAKE_BUILD_TYPE=" + buildType,
+		"-DCMAKE_TOOLCHAIN_FILE=" + toolchainFile,
+		"-DVCPKG_INSTALLED_DIR=" + installedDir,
+		"-DCMAKE_EXPORT_COMPILE_COMMANDS=ON",
+	}
+	args = append(args, toolchain.configureArgs()...)
+	args = append(args, flagArgs...)
+	return CMakeProfile{
+		Name:      name,
+		BuildDir:  filepath.Join(config.CacheDir(), "native", name),
+		BuildType: buildType,
+		Args:      args,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package vcpkg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCMakeProfile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a fake vcpkg executable")
+	}
+	vcpkgRoot := t.TempDir()
+	toolchainFile := filepath.Join(vcpkgRoot, "scripts", "buildsystems", "vcpkg.cmake")
+	require.NoError(t, os.MkdirAll(filepath.Dir(toolchainFile), 0755))
+	require.NoError(t, os.WriteFile(toolchainFile, nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(vcpkgRoot, "vcpkg"), nil, 0755))
+	t.Chdir(t.TempDir())
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	b := &Builder{globalConfig: &config.GlobalConfig{VcpkgRoot: vcpkgRoot}}
+	profile, err := b.CMakeProfile(true, "", "asan")
+	require.NoError(t, err)
+	assert.Equal(t, "release-asan", profile.Name)
+	assert.Equal(t, filepath.Join(".cache", "native", "release-asan"), profile.BuildDir)
+	assert.Equal(t, "Release", profile.BuildType)
+	assert.Contains(t, profile.Args, "-DCMAKE_BUILD_TYPE=Release")
+	assert.Contains(t, profile.Args, "-DCMAKE_TOOLCHAIN_FILE="+toolchainFile)
+	assert.Contains(t, profile.Args, "-DVCPKG_INSTALLED_DIR="+filepath.Join(cwd, ".cache", "native", "vcpkg_installed"))
+	assert.Contains(t, profile.Args, "-DCMAKE_EXE_LINKER_FLAGS=-fsanitize=address")
+}
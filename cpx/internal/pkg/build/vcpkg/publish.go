@@ -0,0 +1,342 @@
+package vcpkg
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+var _ build.Publisher = (*Builder)(nil)
+
+// githubRemoteRe matches GitHub remotes in https and ssh form and captures
+// the owner and repository.
+var githubRemoteRe = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// licenseFiles are the license files a port installs as its copyright, in
+// order of preference.
+var licenseFiles = []string{"LICENSE", "LICENSE.txt", "LICENSE.md", "COPYING"}
+
+// portManifest is the vcpkg.json of a registry port.
+type portManifest struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Description  string `json:"description"`
+	Homepage     string `json:"homepage,omitempty"`
+	License      string `json:"license,omitempty"`
+	Dependencies []any  `json:"dependencies"`
+}
+
+// portVersion is an entry of versions/<x>-/<port>.json in a registry.
+type portVersion struct {
+	GitTree     string `json:"git-tree"`
+	Version     string `json:"version"`
+	PortVersion int    `json:"port-version"`
+}
+
+// baselineEntry is the entry of a port in versions/baseline.json.
+type baselineEntry struct {
+	Baseline    string `json:"baseline"`
+	PortVersion int    `json:"port-version"`
+}
+
+// Publish generates a vcpkg port for the library (portfile.cmake and
+// vcpkg.json, with the SHA512 of its source archive), commits it with the
+// matching version database entries to a branch of the registry repository
+// and pushes the branch. For GitHub registries it opens a pull request with
+// the gh CLI when that is installed. With opts.Conan it writes a Conan recipe
+// instead.
+func (b *Builder) Publish(ctx context.Context, opts build.PublishOptions) error {
+	if opts.Conan {
+		return b.writeConanRecipe(ctx)
+	}
+	if opts.Registry == "" {
+		return fmt.Errorf("no registry given\n  hint: cpx publish --registry <git-url>")
+	}
+
+	data, err := os.ReadFile("CMakeLists.txt")
+	if err != nil {
+		return fmt.Errorf("failed to read CMakeLists.txt: %w", err)
+	}
+	if !installRuleRe.Match(data) {
+		return fmt.Errorf("CMakeLists.txt has no install() rules\n  hint: ports install the library with them; libraries created by cpx new include them")
+	}
+	name := currentProjectName()
+	version := currentProjectVersion()
+	if name == "" || version == "" {
+		return fmt.Errorf("could not read the project name and VERSION from project() in CMakeLists.txt")
+	}
+
+	sourceURL := opts.SourceURL
+	if sourceURL == "" {
+		if sourceURL, err = defaultSourceURL(version); err != nil {
+			return err
+		}
+	}
+	output.Stepf("Downloading %s...", sourceURL)
+	sha, err := sha512OfURL(ctx, sourceURL)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := b.portManifest(ctx, name, version)
+	if err != nil {
+		return err
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	license := ""
+	for _, f := range licenseFiles {
+		if _, err := os.Stat(f); err == nil {
+			license = f
+			break
+		}
+	}
+	portfile := templates.GenerateVcpkgPortfile(name, sourceURL, sha, license)
+
+	branch := opts.Branch
+	if branch == "" {
+		branch = fmt.Sprintf("cpx/%s-%s", name, version)
+	}
+
+	registryDir, err := os.MkdirTemp("", "cpx-registry-")
+	if err != nil {
+		return err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			_ = os.RemoveAll(registryDir)
+		}
+	}()
+
+	output.Stepf("Cloning %s...", opts.Registry)
+	if _, err := runGit("", "clone", opts.Registry, registryDir); err != nil {
+		return err
+	}
+	if _, err := runGit(registryDir, "checkout", "-b", branch); err != nil {
+		return err
+	}
+
+	portDir := filepath.Join(registryDir, "ports", name)
+	if err := os.MkdirAll(portDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", portDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(portDir, "portfile.cmake"), []byte(portfile), 0644); err != nil {
+		return fmt.Errorf("failed to write portfile.cmake: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(portDir, "vcpkg.json"), append(manifestData, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write port vcpkg.json: %w", err)
+	}
+
+	// The version database references the port by its git tree, which only
+	// exists once the port is committed
+	message := fmt.Sprintf("[%s] Add version %s", name, version)
+	if _, err := runGit(registryDir, "add", "ports/"+name); err != nil {
+		return err
+	}
+	if _, err := runGit(registryDir, "commit", "-m", message); err != nil {
+		return err
+	}
+	tree, err := runGit(registryDir, "rev-parse", "HEAD:ports/"+name)
+	if err != nil {
+		return err
+	}
+	if err := addPortVersion(registryDir, name, version, tree); err != nil {
+		return err
+	}
+	if _, err := runGit(registryDir, "add", "versions"); err != nil {
+		return err
+	}
+	if _, err := runGit(registryDir, "commit", "--amend", "--no-edit"); err != nil {
+		return err
+	}
+
+	if opts.NoPush {
+		keep = true
+		output.Successf("✓ Committed %s %s to branch %s of %s", name, version, branch, registryDir)
+		fmt.Fprintf(output.Stdout(), "  %sReview it, then push with: git -C %s push -u origin %s%s\n", colors.Gray, registryDir, branch, colors.Reset)
+		return nil
+	}
+
+	output.Stepf("Pushing %s...", branch)
+	if _, err := runGit(registryDir, "push", "-u", "origin", branch); err != nil {
+		return err
+	}
+	output.Successf("✓ Pushed %s %s to branch %s", name, version, branch)
+	openPullRequest(registryDir, opts.Registry, branch, message)
+	return nil
+}
+
+// portManifest builds the port's vcpkg.json from the project's manifest.
+// Test and benchmark frameworks are dropped; the port itself needs the
+// vcpkg-cmake helper ports.
+func (b *Builder) portManifest(ctx context.Context, name, version string) (portManifest, error) {
+	manifest := portManifest{
+		Name:        name,
+		Version:     version,
+		Description: name + " library",
+	}
+
+	var project map[string]any
+	if data, err := os.ReadFile("vcpkg.json"); err == nil {
+		if err := json.Unmarshal(data, &project); err != nil {
+			return manifest, fmt.Errorf("failed to parse vcpkg.json: %w", err)
+		}
+	}
+	if desc, ok := project["description"].(string); ok && desc != "" {
+		manifest.Description = desc
+	}
+	manifest.Homepage, _ = project["homepage"].(string)
+	manifest.License, _ = project["license"].(string)
+
+	deps, err := b.ListDependencies(ctx)
+	if err != nil {
+		return manifest, err
+	}
+	for _, dep := range deps {
+		if !privatePorts[dep.Name] {
+			manifest.Dependencies = append(manifest.Dependencies, dep.Name)
+		}
+	}
+	manifest.Dependencies = append(manifest.Dependencies,
+		map[string]any{"name": "vcpkg-cmake", "host": true},
+		map[string]any{"name": "vcpkg-cmake-config", "host": true},
+	)
+	return manifest, nil
+}
+
+// addPortVersion records version of port, committed as git tree, in the
+// version database of the registry checked out at registryDir.
+func addPortVersion(registryDir, port, version, tree string) error {
+	versionsPath := filepath.Join(registryDir, "versions", port[:1]+"-", port+".json")
+	var versions struct {
+		Versions []portVersion `json:"versions"`
+	}
+	if data, err := os.ReadFile(versionsPath); err == nil {
+		if err := json.Unmarshal(data, &versions); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", versionsPath, err)
+		}
+	}
+	for _, v := range versions.Versions {
+		if v.Version == version {
+			return fmt.Errorf("%s %s is already in the registry\n  hint: bump the version with cpx release before publishing", port, version)
+		}
+	}
+	versions.Versions = append([]portVersion{{GitTree: tree, Version: version}}, versions.Versions...)
+	if err := writeJSONFile(versionsPath, versions); err != nil {
+		return err
+	}
+
+	baselinePath := filepath.Join(registryDir, "versions", "baseline.json")
+	baseline := map[string]map[string]baselineEntry{}
+	if data, err := os.ReadFile(baselinePath); err == nil {
+		if err := json.Unmarshal(data, &baseline); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", baselinePath, err)
+		}
+	}
+	if baseline["default"] == nil {
+		baseline["default"] = map[string]baselineEntry{}
+	}
+	baseline["default"][port] = baselineEntry{Baseline: version}
+	return writeJSONFile(baselinePath, baseline)
+}
+
+// writeJSONFile writes v as indented JSON to path, creating its directory.
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// defaultSourceURL returns the archive GitHub serves for the v<version> tag
+// of the origin remote.
+func defaultSourceURL(version string) (string, error) {
+	remote, err := runGit("", "remote", "get-url", "origin")
+	if err != nil {
+		return "", fmt.Errorf("no source archive URL given and no origin remote found\n  hint: pass --source-url <url of the release archive>")
+	}
+	m := githubRemoteRe.FindStringSubmatch(remote)
+	if m == nil {
+		return "", fmt.Errorf("origin %s is not a GitHub repository\n  hint: pass --source-url <url of the release archive>", remote)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/archive/refs/tags/v%s.tar.gz", m[1], m[2], version), nil
+}
+
+// sha512OfURL downloads url and returns the hex SHA512 of its content.
+func sha512OfURL(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s\n  hint: push the v<version> tag first, or pass --source-url", url, resp.Status)
+	}
+
+	h := sha512.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// openPullRequest opens a pull request for branch with the gh CLI if the
+// registry is on GitHub, and otherwise tells the user to open one.
+func openPullRequest(registryDir, registry, branch, title string) {
+	m := githubRemoteRe.FindStringSubmatch(registry)
+	if m != nil {
+		if _, err := execLookPath("gh"); err == nil {
+			cmd := execCommand("gh", "pr", "create",
+				"--repo", m[1]+"/"+m[2],
+				"--head", branch,
+				"--title", title,
+				"--body", "Generated by cpx publish.")
+			cmd.Dir = registryDir
+			out, err := cmd.CombinedOutput()
+			if err == nil {
+				output.Successf("✓ Opened %s", strings.TrimSpace(string(out)))
+				return
+			}
+			output.Warnf("could not open a pull request: %s", strings.TrimSpace(string(out)))
+		}
+	}
+	fmt.Fprintf(output.Stdout(), "  %sOpen a pull request for branch %s in %s%s\n", colors.Gray, branch, registry, colors.Reset)
+}
+
+// runGit runs git with args in dir and returns its trimmed output.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := execCommand("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w\n%s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
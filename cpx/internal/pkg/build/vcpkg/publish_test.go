@@ -0,0 +1,197 @@
+package vcpkg
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitRegistry creates a bare registry repository with one commit.
+func gitRegistry(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	bare := filepath.Join(root, "registry.git")
+	work := filepath.Join(root, "work")
+	for _, args := range [][]string{
+		{"init", "-q", "--bare", bare},
+		{"init", "-q", work},
+	} {
+		require.NoError(t, exec.Command("git", args...).Run())
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README.md"), []byte("registry\n"), 0644))
+	for _, args := range [][]string{
+		{"add", "README.md"},
+		{"commit", "-q", "-m", "init"},
+		{"push", "-q", bare, "HEAD:refs/heads/main"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = work
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	require.NoError(t, exec.Command("git", "--git-dir", bare, "symbolic-ref", "HEAD", "refs/heads/main").Run())
+	return bare
+}
+
+func TestPublish(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	execCommand = exec.Command
+
+	registry := gitRegistry(t)
+
+	archive := []byte("source archive")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+	sum := sha512.Sum512(archive)
+
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(mylib VERSION 1.2.0 LANGUAGES CXX)\ninstall(TARGETS mylib)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name":"mylib","description":"My library","license":"MIT","dependencies":["fmt","gtest"]}`), 0644))
+	require.NoError(t, os.WriteFile("LICENSE", []byte("MIT\n"), 0644))
+
+	err = New().Publish(context.Background(), build.PublishOptions{
+		Registry:  registry,
+		SourceURL: server.URL + "/mylib-1.2.0.tar.gz",
+	})
+	require.NoError(t, err)
+
+	show := func(path string) string {
+		out, err := exec.Command("git", "--git-dir", registry, "show", "cpx/mylib-1.2.0:"+path).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+
+	portfile := show("ports/mylib/portfile.cmake")
+	assert.Contains(t, portfile, "SHA512 "+hex.EncodeToString(sum[:]))
+	assert.Contains(t, portfile, `vcpkg_install_copyright(FILE_LIST "${SOURCE_PATH}/LICENSE")`)
+
+	var manifest portManifest
+	require.NoError(t, json.Unmarshal([]byte(show("ports/mylib/vcpkg.json")), &manifest))
+	assert.Equal(t, "1.2.0", manifest.Version)
+	assert.Equal(t, "My library", manifest.Description)
+	assert.Equal(t, "MIT", manifest.License)
+	assert.Len(t, manifest.Dependencies, 3, "fmt and the vcpkg-cmake host ports, without gtest")
+	assert.Equal(t, "fmt", manifest.Dependencies[0])
+
+	tree, err := exec.Command("git", "--git-dir", registry, "rev-parse", "cpx/mylib-1.2.0:ports/mylib").Output()
+	require.NoError(t, err)
+	var versions struct {
+		Versions []portVersion `json:"versions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(show("versions/m-/mylib.json")), &versions))
+	require.Len(t, versions.Versions, 1)
+	assert.Equal(t, string(tree[:len(tree)-1]), versions.Versions[0].GitTree)
+	assert.Contains(t, show("versions/baseline.json"), `"baseline": "1.2.0"`)
+}
+
+func TestPublishWithoutInstallRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(myapp VERSION 1.0.0)\n"), 0644))
+	err = New().Publish(context.Background(), build.PublishOptions{Registry: "https://example.com/registry.git"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no install() rules")
+}
+
+func TestAddPortVersion(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, addPortVersion(dir, "mylib", "1.0.0", "aaa"))
+	require.NoError(t, addPortVersion(dir, "mylib", "1.1.0", "bbb"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "versions", "m-", "mylib.json"))
+	require.NoError(t, err)
+	var versions struct {
+		Versions []portVersion `json:"versions"`
+	}
+	require.NoError(t, json.Unmarshal(data, &versions))
+	require.Len(t, versions.Versions, 2)
+	assert.Equal(t, "1.1.0", versions.Versions[0].Version, "newest version first")
+	assert.Equal(t, "bbb", versions.Versions[0].GitTree)
+
+	data, err = os.ReadFile(filepath.Join(dir, "versions", "baseline.json"))
+	require.NoError(t, err)
+	var baseline map[string]map[string]baselineEntry
+	require.NoError(t, json.Unmarshal(data, &baseline))
+	assert.Equal(t, "1.1.0", baseline["default"]["mylib"].Baseline)
+
+	err = addPortVersion(dir, "mylib", "1.1.0", "ccc")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already in the registry")
+}
+
+func TestGithubRemoteRe(t *testing.T) {
+	for _, remote := range []string{
+		"https://github.com/acme/mylib.git",
+		"https://github.com/acme/mylib",
+		"git@github.com:acme/mylib.git",
+	} {
+		m := githubRemoteRe.FindStringSubmatch(remote)
+		require.NotNil(t, m, remote)
+		assert.Equal(t, []string{"acme", "mylib"}, m[1:], remote)
+	}
+	assert.Nil(t, githubRemoteRe.FindStringSubmatch("https://gitlab.com/acme/mylib.git"))
+}
+
+func TestPublishConan(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(mylib VERSION 1.2.0 LANGUAGES CXX)\nset(CMAKE_CXX_STANDARD 20)\ninstall(TARGETS mylib)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name":"mylib","license":"MIT","dependencies":["nlohmann-json",{"name":"fmt","version":"10.2.1"},"gtest"]}`), 0644))
+
+	b := New()
+	require.NoError(t, b.Publish(context.Background(), build.PublishOptions{Conan: true}))
+
+	conanfile, err := os.ReadFile("conanfile.py")
+	require.NoError(t, err)
+	assert.Contains(t, string(conanfile), `version = "1.2.0"`)
+	assert.Contains(t, string(conanfile), `license = "MIT"`)
+	assert.Contains(t, string(conanfile), `self.requires("fmt/[>=10.2.1]")`)
+	assert.Contains(t, string(conanfile), `self.requires("nlohmann_json/[*]")`)
+	assert.NotContains(t, string(conanfile), "gtest")
+
+	testCMake, err := os.ReadFile(filepath.Join("test_package", "CMakeLists.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(testCMake), "cxx_std_20")
+	assert.FileExists(t, filepath.Join("test_package", "conanfile.py"))
+	assert.FileExists(t, filepath.Join("test_package", "test_package.cpp"))
+
+	err = b.Publish(context.Background(), build.PublishOptions{Conan: true})
+	require.Error(t, err, "an existing recipe is not overwritten")
+	assert.Contains(t, err.Error(), "conanfile.py already exists")
+}
@@ -0,0 +1,100 @@
+package vcpkg
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// The block of CMakeLists.txt that cpx rewrites from the targets of
+// cpx-ci.yaml
+const (
+	targetOptionsBegin = "# Target options from cpx-ci.yaml (rewritten by cpx build; edit cpx-ci.yaml instead)\n"
+	targetOptionsEnd   = "# End of target options from cpx-ci.yaml\n"
+)
+
+// SyncTargetOptions rewrites the target options block at the end of the
+// CMakeLists.txt at path from targets, removing it when there are none, and
+// reports whether the file changed.
+func SyncTargetOptions(path string, targets map[string]config.TargetOptions) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	updated := setTargetOptions(string(data), targetOptionsBlock(targets))
+	if updated == string(data) {
+		return false, nil
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// targetOptionsBlock returns the CMake commands adding targets' options,
+// in target name order, or "" when there are none.
+func targetOptionsBlock(targets map[string]config.TargetOptions) string {
+	var sb strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(targets)) {
+		t := targets[name]
+		for _, c := range []struct {
+			command string
+			args    []string
+		}{
+			{"target_compile_definitions", t.Defines},
+			{"target_include_directories", t.IncludeDirs},
+			{"target_link_libraries", t.LinkLibraries},
+		} {
+			if len(c.args) == 0 {
+				continue
+			}
+			quoted := make([]string, len(c.args))
+			for i, a := range c.args {
+				quoted[i] = cmakeQuote(a)
+			}
+			fmt.Fprintf(&sb, "%s(%s PRIVATE %s)\n", c.command, name, strings.Join(quoted, " "))
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return targetOptionsBegin + sb.String() + targetOptionsEnd
+}
+
+// setTargetOptions replaces the target options block of cmakeLists with
+// block, appending it when there is none yet.
+func setTargetOptions(cmakeLists, block string) string {
+	if start := strings.Index(cmakeLists, targetOptionsBegin); start >= 0 {
+		end := len(cmakeLists)
+		if i := strings.Index(cmakeLists[start:], targetOptionsEnd); i >= 0 {
+			end = start + i + len(targetOptionsEnd)
+		}
+		rest := cmakeLists[end:]
+		cmakeLists = cmakeLists[:start]
+		if block == "" {
+			// Take the blank line before the block along with it
+			cmakeLists = strings.TrimSuffix(cmakeLists, "\n")
+		}
+		return cmakeLists + block + rest
+	}
+	if block == "" {
+		return cmakeLists
+	}
+	if !strings.HasSuffix(cmakeLists, "\n") {
+		cmakeLists += "\n"
+	}
+	return cmakeLists + "\n" + block
+}
+
+// cmakeQuote quotes a CMake command argument that would otherwise be split
+// or misread.
+func cmakeQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n;\"()#\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
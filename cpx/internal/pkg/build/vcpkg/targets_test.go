@@ -0,0 +1,78 @@
+package vcpkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetOptionsBlock(t *testing.T) {
+	block := targetOptionsBlock(map[string]config.TargetOptions{
+		"myapp": {
+			Defines:       []string{"USE_FOO", "GREETING=hello world"},
+			IncludeDirs:   []string{"third_party/stb"},
+			LinkLibraries: []string{"fmt::fmt", "m"},
+		},
+		"lib":   {Defines: []string{"LIB_EXPORTS"}},
+		"empty": {},
+	})
+	assert.Equal(t, targetOptionsBegin+
+		"target_compile_definitions(lib PRIVATE LIB_EXPORTS)\n"+
+		"target_compile_definitions(myapp PRIVATE USE_FOO \"GREETING=hello world\")\n"+
+		"target_include_directories(myapp PRIVATE third_party/stb)\n"+
+		"target_link_libraries(myapp PRIVATE fmt::fmt m)\n"+
+		targetOptionsEnd, block)
+
+	assert.Empty(t, targetOptionsBlock(nil))
+	assert.Empty(t, targetOptionsBlock(map[string]config.TargetOptions{"myapp": {}}))
+}
+
+func TestCMakeQuote(t *testing.T) {
+	assert.Equal(t, "NAME=1", cmakeQuote("NAME=1"))
+	assert.Equal(t, "${CMAKE_SOURCE_DIR}/include", cmakeQuote("${CMAKE_SOURCE_DIR}/include"))
+	assert.Equal(t, `"a;b"`, cmakeQuote("a;b"))
+	assert.Equal(t, `"MSG=\"hi\""`, cmakeQuote(`MSG="hi"`))
+	assert.Equal(t, `""`, cmakeQuote(""))
+}
+
+func TestSyncTargetOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CMakeLists.txt")
+	original := "cmake_minimum_required(VERSION 3.20)\nproject(myapp)\nadd_executable(myapp src/main.cpp)\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+	read := func() string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	targets := map[string]config.TargetOptions{"myapp": {Defines: []string{"USE_FOO"}}}
+	changed, err := SyncTargetOptions(path, targets)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, original+"\n"+targetOptionsBegin+"target_compile_definitions(myapp PRIVATE USE_FOO)\n"+targetOptionsEnd, read())
+
+	changed, err = SyncTargetOptions(path, targets)
+	require.NoError(t, err)
+	assert.False(t, changed, "an up to date block is not rewritten")
+
+	// Lines the user added after the block are kept
+	require.NoError(t, os.WriteFile(path, []byte(read()+"\nmessage(STATUS done)\n"), 0644))
+	targets["myapp"] = config.TargetOptions{LinkLibraries: []string{"m"}}
+	changed, err = SyncTargetOptions(path, targets)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, original+"\n"+targetOptionsBegin+"target_link_libraries(myapp PRIVATE m)\n"+targetOptionsEnd+"\nmessage(STATUS done)\n", read())
+
+	changed, err = SyncTargetOptions(path, nil)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, original+"\nmessage(STATUS done)\n", read())
+
+	changed, err = SyncTargetOptions(path, nil)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
@@ -2,7 +2,6 @@
 package vcpkg
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,25 +10,33 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"os/signal"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
-	"github.com/schollz/progressbar/v3"
-
+	"github.com/ozacod/cpx/internal/pkg/build/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/build/buildlog"
+	"github.com/ozacod/cpx/internal/pkg/build/events"
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
+	"github.com/ozacod/cpx/internal/pkg/build/progress"
 	"github.com/ozacod/cpx/internal/pkg/templates"
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 	"github.com/ozacod/cpx/pkg/config"
 )
 
 var execCommand = exec.Command
 
+// SetupPrompt, when set, is called by SetupEnv when vcpkg_root is not
+// configured. It may set vcpkg up and return its root, or return "" to
+// give up.
+var SetupPrompt func() (string, error)
+
 // Builder implements the build.BuildSystem interface for vcpkg.
 type Builder struct {
 	globalConfig *config.GlobalConfig
@@ -40,12 +47,13 @@ func New() *Builder {
 	return &Builder{}
 }
 
-// ensureConfig ensures the global config is loaded
+// ensureConfig ensures the global config, with the overrides of the
+// project's cpx.yaml, is loaded
 func (b *Builder) ensureConfig() error {
 	if b.globalConfig != nil {
 		return nil
 	}
-	globalConfig, err := config.LoadGlobal()
+	globalConfig, err := config.LoadGlobalFor(".")
 	if err != nil {
 		return fmt.Errorf("failed to load global config: %w", err)
 	}
@@ -59,16 +67,6 @@ func (b *Builder) SetupEnv() error {
 		return err
 	}
 
-	// Set VCPKG_ROOT if not already set and we have it in config
-	if os.Getenv("VCPKG_ROOT") == "" {
-		if b.globalConfig.VcpkgRoot == "" {
-			return fmt.Errorf("vcpkg_root not set in config. Run: cpx config set-vcpkg-root <path>")
-		}
-		if err := os.Setenv("VCPKG_ROOT", b.globalConfig.VcpkgRoot); err != nil {
-			return fmt.Errorf("failed to set VCPKG_ROOT: %w", err)
-		}
-	}
-
 	// Set VCPKG_FEATURE_FLAGS=manifests if not already set
 	if os.Getenv("VCPKG_FEATURE_FLAGS") == "" {
 		if err := os.Setenv("VCPKG_FEATURE_FLAGS", "manifests"); err != nil {
@@ -83,13 +81,27 @@ func (b *Builder) SetupEnv() error {
 		}
 	}
 
-	if os.Getenv("CPX_DEBUG") != "" {
-		fmt.Printf("%s[DEBUG] VCPKG Environment:%s\n", colors.Cyan, colors.Reset)
-		fmt.Printf("  VCPKG_ROOT=%s\n", os.Getenv("VCPKG_ROOT"))
-		fmt.Printf("  VCPKG_FEATURE_FLAGS=%s\n", os.Getenv("VCPKG_FEATURE_FLAGS"))
-		fmt.Printf("  VCPKG_DISABLE_REGISTRY_UPDATE=%s\n", os.Getenv("VCPKG_DISABLE_REGISTRY_UPDATE"))
+	// Set VCPKG_ROOT if not already set and we have it in config
+	if os.Getenv("VCPKG_ROOT") == "" {
+		if b.globalConfig.VcpkgRoot == "" && SetupPrompt != nil {
+			root, err := SetupPrompt()
+			if err != nil {
+				return err
+			}
+			b.globalConfig.VcpkgRoot = root
+		}
+		if b.globalConfig.VcpkgRoot == "" {
+			return fmt.Errorf("vcpkg_root not set in config. Run: cpx setup vcpkg (or cpx config set-vcpkg-root <path>)")
+		}
+		if err := os.Setenv("VCPKG_ROOT", b.globalConfig.VcpkgRoot); err != nil {
+			return fmt.Errorf("failed to set VCPKG_ROOT: %w", err)
+		}
 	}
 
+	output.Debugf("VCPKG_ROOT=%s", os.Getenv("VCPKG_ROOT"))
+	output.Debugf("VCPKG_FEATURE_FLAGS=%s", os.Getenv("VCPKG_FEATURE_FLAGS"))
+	output.Debugf("VCPKG_DISABLE_REGISTRY_UPDATE=%s", os.Getenv("VCPKG_DISABLE_REGISTRY_UPDATE"))
+
 	return nil
 }
 
@@ -109,7 +121,7 @@ func (b *Builder) GetPath() (string, error) {
 	}
 
 	if vcpkgRoot == "" {
-		return "", fmt.Errorf("vcpkg_root not set in config. Run: cpx config set-vcpkg-root <path>")
+		return "", fmt.Errorf("vcpkg_root not set in config. Run: cpx setup vcpkg (or cpx config set-vcpkg-root <path>)")
 	}
 
 	// Convert to absolute path
@@ -167,7 +179,13 @@ func (b *Builder) GenerateBuildSrc(ctx context.Context, projectPath string, conf
 	hasBench := config.Benchmark != "" && config.Benchmark != "none"
 
 	// Generate CMakeLists.txt
-	cmakeLists := templates.GenerateVcpkgCMakeLists(config.Name, config.CppStandard, !config.IsLibrary, hasTest, config.Benchmark, hasBench, config.Version)
+	cmakeLists := templates.GenerateVcpkgCMakeLists(config.Name, config.CppStandard, !config.IsLibrary, hasTest, config.Benchmark, hasBench, config.Version, config.SharedLibrary, config.PCH)
+	if config.Modules {
+		cmakeLists = templates.GenerateModulesCMakeLists(config.Name, config.CppStandard, !config.IsLibrary, hasTest, hasBench, config.Version, config.SharedLibrary)
+	}
+	if config.HeaderOnly {
+		cmakeLists = templates.GenerateHeaderOnlyCMakeLists(config.Name, config.CppStandard, hasTest, hasBench, config.Version)
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "CMakeLists.txt"), []byte(cmakeLists), 0644); err != nil {
 		return fmt.Errorf("failed to write CMakeLists.txt: %w", err)
 	}
@@ -178,6 +196,26 @@ func (b *Builder) GenerateBuildSrc(ctx context.Context, projectPath string, conf
 		return fmt.Errorf("failed to write CMakePresets.json: %w", err)
 	}
 
+	// Libraries export a CMake package config and a pkg-config file for consumers
+	if config.IsLibrary {
+		if err := os.MkdirAll(filepath.Join(projectPath, "cmake"), 0755); err != nil {
+			return fmt.Errorf("failed to create cmake directory: %w", err)
+		}
+		packageConfig := templates.GeneratePackageConfig(config.Name)
+		configPath := filepath.Join(projectPath, "cmake", config.Name+"Config.cmake.in")
+		if err := os.WriteFile(configPath, []byte(packageConfig), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", configPath, err)
+		}
+		pkgConfig := templates.GeneratePkgConfig(config.Name, nil)
+		if config.HeaderOnly {
+			pkgConfig = templates.GenerateHeaderOnlyPkgConfig(config.Name)
+		}
+		pcPath := filepath.Join(projectPath, "cmake", config.Name+".pc.in")
+		if err := os.WriteFile(pcPath, []byte(pkgConfig), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", pcPath, err)
+		}
+	}
+
 	return nil
 }
 
@@ -193,6 +231,12 @@ func (b *Builder) GenerateBuildTest(ctx context.Context, projectPath string, con
 
 	// Generate tests/CMakeLists.txt
 	testCMake := templates.GenerateTestCMake(config.Name, config.TestFramework)
+	if config.Modules {
+		testCMake = templates.GenerateModulesTestCMake(config.Name, config.TestFramework, !config.IsLibrary)
+	}
+	if config.HeaderOnly {
+		testCMake = templates.LinkHeaderOnlyCMake(testCMake, config.Name, config.Name+"_tests")
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "tests/CMakeLists.txt"), []byte(testCMake), 0644); err != nil {
 		return fmt.Errorf("failed to write tests/CMakeLists.txt: %w", err)
 	}
@@ -211,6 +255,12 @@ func (b *Builder) GenerateBuildBench(ctx context.Context, projectPath string, co
 
 	// Generate bench/CMakeLists.txt
 	benchCMake := templates.GenerateBenchCMake(config.Name, config.Benchmark)
+	if config.Modules {
+		benchCMake = templates.GenerateModulesBenchCMake(config.Name, config.Benchmark, !config.IsLibrary)
+	}
+	if config.HeaderOnly {
+		benchCMake = templates.LinkHeaderOnlyCMake(benchCMake, config.Name, config.Name+"_bench")
+	}
 	if err := os.WriteFile(filepath.Join(projectPath, "bench/CMakeLists.txt"), []byte(benchCMake), 0644); err != nil {
 		return fmt.Errorf("failed to write bench/CMakeLists.txt: %w", err)
 	}
@@ -225,23 +275,46 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 	}
 
 	// Get project name from CMakeLists.txt (optional, for display only)
-	projectName := getProjectNameFromCMakeLists()
+	projectName := currentProjectName()
 	if projectName == "" {
 		projectName = "project"
 	}
 
+	// A CMake preset replaces the build type and flags of the options
+	var preset *PresetSelection
+	if opts.Preset != "" {
+		presets, err := LoadCMakePresets(".")
+		if err != nil {
+			return err
+		}
+		if preset, err = presets.Select(opts.Preset); err != nil {
+			return err
+		}
+	}
+
 	// Determine build output directory based on optimization/release/sanitizer
 	outDirName := build.GetOutputDir(opts.Release, opts.OptLevel, opts.Sanitizer)
+	if preset != nil {
+		outDirName = build.PresetOutputDir(preset.Name)
+	}
 
 	// Use hidden cache directory for build artifacts
-	// .cache/native/<variant>
-	cacheBuildDir := filepath.Join(".cache", "native", outDirName)
+	// .cache/native/<variant>, or <variant>-<type> for an explicit library
+	// type so switching types does not rebuild everything
+	cacheBuildDir := filepath.Join(config.CacheDir(), "native", outDirName)
+	if opts.LibraryType != "" {
+		cacheBuildDir += "-" + opts.LibraryType
+	}
+	// reproducible builds set archive rules that would stay in the cache
+	if opts.Reproducible {
+		cacheBuildDir += "-repro"
+	}
 	// Final executables go to .bin/native/<variant>
-	finalBuildDir := filepath.Join(".bin", "native", outDirName)
+	finalBuildDir := filepath.Join(config.BinDir(), "native", outDirName)
 
 	if opts.Clean {
 		if opts.Verbose {
-			fmt.Printf("%s  Cleaning build directory...%s\n", colors.Cyan, colors.Reset)
+			output.Stepf("  Cleaning build directory...")
 		}
 		os.RemoveAll(cacheBuildDir)
 		os.RemoveAll(finalBuildDir)
@@ -252,13 +325,35 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 		return fmt.Errorf("failed to create cache build dir: %w", err)
 	}
 
-	// Determine build type and optimization
-	buildType, cxxFlags := determineBuildType(opts.Release, opts.OptLevel)
-
-	// Add sanitizer flags
-	sanCFlags, sanLFlags := getSanitizerFlags(opts.Sanitizer)
-	cxxFlags += sanCFlags
-	linkerFlags := sanLFlags
+	// Determine generator, build type and compiler flags
+	toolchain, err := b.cmakeToolchain()
+	if err != nil {
+		return err
+	}
+	buildType, flagArgs, err := toolchain.flagArgs(opts.Release, opts.OptLevel, opts.Sanitizer, opts.Defines, opts.Reproducible)
+	if err != nil {
+		return err
+	}
+	configurePreset := defaultPreset()
+	if preset != nil {
+		configurePreset = preset.Configure
+		if preset.BuildType != "" {
+			buildType = preset.BuildType
+		}
+	}
+	switch opts.LibraryType {
+	case build.LibraryShared:
+		flagArgs = append(flagArgs, "-DBUILD_SHARED_LIBS=ON")
+	case build.LibraryStatic:
+		flagArgs = append(flagArgs, "-DBUILD_SHARED_LIBS=OFF")
+	}
+	if opts.PCH != nil {
+		flagArgs = append(flagArgs, "-DENABLE_PCH="+cmakeBool(*opts.PCH))
+	}
+	cudaArchs := build.CMakeCUDAArchitectures(opts.CUDAArchitectures)
+	if cudaArchs != "" {
+		flagArgs = append(flagArgs, "-DCMAKE_CUDA_ARCHITECTURES="+cudaArchs)
+	}
 
 	optLabel := "default (-O0)"
 	if opts.Release {
@@ -270,8 +365,11 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 	if opts.Sanitizer != "" {
 		optLabel += "+" + opts.Sanitizer
 	}
+	if preset != nil {
+		optLabel = "preset " + preset.Name
+	}
 
-	fmt.Printf("\n%s▸ Build%s %s %s(%s)%s %s[opt: %s]%s\n",
+	fmt.Fprintf(output.Stdout(), "\n%s▸ Build%s %s %s(%s)%s %s[opt: %s]%s\n",
 		colors.Cyan, colors.Reset, projectName, colors.Gray, buildType, colors.Reset,
 		colors.Gray, optLabel, colors.Reset)
 
@@ -280,6 +378,17 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 	if _, err := os.Stat(filepath.Join(cacheBuildDir, "CMakeCache.txt")); os.IsNotExist(err) {
 		needsConfigure = true
 	}
+	// Extra configure arguments and defines only take effect on (re)configure
+	configureArgs := append(slices.Clone(opts.ExtraArgs), opts.Defines...)
+	if configureArgsChanged(cacheBuildDir, configureArgs) {
+		needsConfigure = true
+	}
+	if opts.PCH != nil && cmakeCacheValue(cacheBuildDir, "ENABLE_PCH") != cmakeBool(*opts.PCH) {
+		needsConfigure = true
+	}
+	if cudaArchs != "" && cmakeCacheValue(cacheBuildDir, "CMAKE_CUDA_ARCHITECTURES") != cudaArchs {
+		needsConfigure = true
+	}
 
 	// Determine total steps
 	totalSteps := 1
@@ -291,53 +400,50 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 	if needsConfigure {
 		currentStep++
 		if opts.Verbose {
-			fmt.Printf("%s  • Configuring CMake%s\n", colors.Cyan, colors.Reset)
+			output.Stepf("  • Configuring CMake")
 		} else {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configuring...", colors.Cyan, currentStep, totalSteps, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\r\033[2K%s[%d/%d]%s Configuring...", colors.Cyan, currentStep, totalSteps, colors.Reset)
 		}
 
-		// Determine absolute path for shared vcpkg_installed directory
-		cwd, _ := os.Getwd()
-		vcpkgInstalledDir := filepath.Join(cwd, ".cache", "native", "vcpkg_installed")
+		vcpkgInstalledDir, err := b.InstalledDir()
+		if err != nil {
+			return err
+		}
 		vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
 
-		// Check if CMakePresets.json exists, use preset if available
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			// Use "default" preset (VCPKG_ROOT is now set from config)
+		// Use the selected preset, or the default one if the project has one
+		if configurePreset != "" {
 			// Pass -B explicitly to override preset binaryDir if needed, or ensure it goes to our cache
 			// Also pass VCPKG_INSTALLED_DIR to force shared vcpkg location
-			cmdArgs := []string{"--preset=default", "-B", cacheBuildDir, vcpkgInstallArg}
-			if cxxFlags != "" {
-				cmdArgs = append(cmdArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
-			}
-			if linkerFlags != "" {
-				cmdArgs = append(cmdArgs, "-DCMAKE_EXE_LINKER_FLAGS="+linkerFlags, "-DCMAKE_SHARED_LINKER_FLAGS="+linkerFlags)
-			}
+			cmdArgs := []string{"--preset=" + configurePreset, "-B", cacheBuildDir, vcpkgInstallArg}
+			cmdArgs = append(cmdArgs, toolchain.configureArgs()...)
+			cmdArgs = append(cmdArgs, flagArgs...)
+			cmdArgs = append(cmdArgs, opts.ExtraArgs...)
 			cmd := exec.Command("cmake", cmdArgs...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, opts.Verbose); err != nil {
-				fmt.Println()
-				return fmt.Errorf("cmake configure failed (preset 'default'): %w", err)
+				fmt.Fprintln(output.Stdout())
+				return fmt.Errorf("cmake configure failed (preset '%s'): %w", configurePreset, err)
 			}
 		} else {
 			// Fallback to traditional cmake configure
 			cmdArgs := []string{"-B", cacheBuildDir, "-DCMAKE_BUILD_TYPE=" + buildType, vcpkgInstallArg}
-			if cxxFlags != "" {
-				cmdArgs = append(cmdArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
-			}
-			if linkerFlags != "" {
-				cmdArgs = append(cmdArgs, "-DCMAKE_EXE_LINKER_FLAGS="+linkerFlags, "-DCMAKE_SHARED_LINKER_FLAGS="+linkerFlags)
-			}
+			cmdArgs = append(cmdArgs, toolchain.configureArgs()...)
+			cmdArgs = append(cmdArgs, flagArgs...)
+			cmdArgs = append(cmdArgs, opts.ExtraArgs...)
 			cmd := exec.Command("cmake", cmdArgs...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, opts.Verbose); err != nil {
-				fmt.Println()
+				fmt.Fprintln(output.Stdout())
 				return fmt.Errorf("cmake configure failed: %w", err)
 			}
 		}
 
+		if err := writeConfigureArgs(cacheBuildDir, configureArgs); err != nil {
+			return err
+		}
 		if !opts.Verbose {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configured ✓\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\r\033[2K%s[%d/%d]%s Configured ✓\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
 		}
 	}
 
@@ -360,6 +466,9 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 
 	if opts.Target != "" {
 		buildArgs = append(buildArgs, "--target", opts.Target)
+	} else if preset != nil && len(preset.Targets) > 0 {
+		buildArgs = append(buildArgs, "--target")
+		buildArgs = append(buildArgs, preset.Targets...)
 	}
 
 	currentStep++
@@ -372,16 +481,25 @@ func (b *Builder) Build(ctx context.Context, opts build.BuildOptions) error {
 		return fmt.Errorf("failed to create final build dir: %w", err)
 	}
 
-	executables, err := findExecutables(cacheBuildDir)
+	// Multi-config generators (Visual Studio) put executables in <build>/<Config>
+	executables, err := findExecutables(toolchain.outputDir(cacheBuildDir, buildType))
 	if err == nil {
 		for _, exe := range executables {
 			dest := filepath.Join(finalBuildDir, filepath.Base(exe))
-			_ = copyAndSign(exe, dest)
+			if err := copyAndSign(exe, dest, opts.DeveloperID); err != nil && opts.DeveloperID != nil {
+				return err
+			}
 		}
 	}
+	// Libraries go to static/ and shared/, so both types can be kept
+	if _, err := artifacts.CollectLibraries(finalBuildDir, opts.LibraryType,
+		artifacts.Rule{Dir: toolchain.outputDir(cacheBuildDir, buildType), Extensions: artifacts.LibraryExtensions},
+	); err != nil {
+		return fmt.Errorf("failed to copy libraries: %w", err)
+	}
 
-	fmt.Printf("%s  ✔ Build complete%s %s[%s]%s\n", colors.Green, colors.Reset, colors.Gray, time.Since(buildStart).Round(10*time.Millisecond), colors.Reset)
-	fmt.Printf("  Artifacts in: %s/\n\n", finalBuildDir)
+	output.Successf("  ✔ Build complete%s %s[%s]", colors.Reset, colors.Gray, time.Since(buildStart).Round(10*time.Millisecond))
+	fmt.Fprintf(output.Stdout(), "  Artifacts in: %s/\n\n", finalBuildDir)
 	return nil
 }
 
@@ -392,15 +510,29 @@ func (b *Builder) Test(ctx context.Context, opts build.TestOptions) error {
 		return err
 	}
 
-	projectName := getProjectNameFromCMakeLists()
+	projectName := currentProjectName()
 	if projectName == "" {
 		return fmt.Errorf("failed to get project name from CMakeLists.txt")
 	}
-	fmt.Printf("%s Running tests for '%s'...%s\n", "\033[36m", projectName, "\033[0m")
+	output.Stepf(" Running tests for '%s'...", projectName)
+
+	toolchain, err := b.cmakeToolchain()
+	if err != nil {
+		return err
+	}
+
+	_, flagArgs, err := toolchain.flagArgs(false, "", opts.Sanitizer, nil, false)
+	if err != nil {
+		return err
+	}
 
 	// Default to debug for tests if no config specified
-	// Use .cache/native/test for building tests (separate from normal builds)
-	buildDir := filepath.Join(".cache", "native", "test")
+	// Use .cache/native/test for building tests (separate from normal builds),
+	// with a directory per sanitizer variant
+	buildDir := filepath.Join(config.CacheDir(), "native", "test")
+	if opts.Sanitizer != "" {
+		buildDir += "-" + strings.Join(build.SplitSanitizer(opts.Sanitizer), "-")
+	}
 
 	// Check if configure is needed
 	needsConfigure := false
@@ -419,45 +551,52 @@ func (b *Builder) Test(ctx context.Context, opts build.TestOptions) error {
 	if needsConfigure {
 		currentStep++
 		if opts.Verbose {
-			fmt.Printf("%s  Configuring CMake (with testing enabled)...%s\n", "\033[36m", "\033[0m")
+			output.Stepf("  Configuring CMake (with testing enabled)...")
 		} else {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configuring...", colors.Cyan, currentStep, totalSteps, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\r\033[2K%s[%d/%d]%s Configuring...", colors.Cyan, currentStep, totalSteps, colors.Reset)
 		}
 
-		// Determine absolute path for shared vcpkg_installed directory
-		cwd, _ := os.Getwd()
-		vcpkgInstalledDir := filepath.Join(cwd, ".cache", "native", "vcpkg_installed")
+		vcpkgInstalledDir, err := b.InstalledDir()
+		if err != nil {
+			return err
+		}
 		vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
 
 		// Enable testing
 		enableTestingArg := "-DENABLE_TESTING=ON"
 
-		// Check if CMakePresets.json exists, use preset if available
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			// Use "default" preset (VCPKG_ROOT is now set from config)
-			cmd := execCommand("cmake", "--preset=default", "-B", buildDir, vcpkgInstallArg, enableTestingArg)
+		// Use the default preset if the project has one
+		if defaultPreset() != "" {
+			cmdArgs := append([]string{"--preset=default", "-B", buildDir, vcpkgInstallArg, enableTestingArg}, toolchain.configureArgs()...)
+			cmdArgs = append(cmdArgs, flagArgs...)
+			cmd := execCommand("cmake", cmdArgs...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, opts.Verbose); err != nil {
-				fmt.Println()
+				fmt.Fprintln(output.Stdout())
 				return fmt.Errorf("cmake configure failed (preset 'default'): %w", err)
 			}
 		} else {
 			// Fallback to traditional cmake configure
-			cmd := execCommand("cmake", "-B", buildDir, vcpkgInstallArg, enableTestingArg)
+			cmdArgs := append([]string{"-B", buildDir, vcpkgInstallArg, enableTestingArg}, toolchain.configureArgs()...)
+			cmdArgs = append(cmdArgs, flagArgs...)
+			cmd := execCommand("cmake", cmdArgs...)
 			if err := runCMakeConfigure(cmd, opts.Verbose); err != nil {
-				fmt.Println()
+				fmt.Fprintln(output.Stdout())
 				return fmt.Errorf("cmake configure failed: %w", err)
 			}
 		}
 
 		if !opts.Verbose {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configured ✓\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\r\033[2K%s[%d/%d]%s Configured ✓\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
 		}
 	}
 
 	// Build tests
 	currentStep++
 	buildArgs := []string{"--build", buildDir, "--target", projectName + "_tests"}
+	if toolchain.MultiConfig {
+		buildArgs = append(buildArgs, "--config", "Debug")
+	}
 	if err := runCMakeBuild(buildArgs, opts.Verbose, currentStep, totalSteps); err != nil {
 		return fmt.Errorf("failed to build tests: %w", err)
 	}
@@ -465,32 +604,61 @@ func (b *Builder) Test(ctx context.Context, opts build.TestOptions) error {
 	// Run tests with CTest
 	currentStep++
 	if !opts.Verbose {
-		fmt.Printf("%s[%d/%d]%s Running tests...\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
+		fmt.Fprintf(output.Stdout(), "%s[%d/%d]%s Running tests...\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
 	} else {
-		fmt.Printf("%s Running tests...%s\n", "\033[36m", "\033[0m")
+		output.Stepf(" Running tests...")
 	}
 
 	ctestArgs := []string{"--test-dir", buildDir}
+	if toolchain.MultiConfig {
+		ctestArgs = append(ctestArgs, "-C", "Debug")
+	}
 
 	if opts.Verbose {
 		ctestArgs = append(ctestArgs, "--verbose")
 	}
+	ctestArgs = append(ctestArgs, ctestSelectArgs(opts)...)
+	if opts.Timeout > 0 {
+		ctestArgs = append(ctestArgs, "--timeout", strconv.FormatFloat(opts.Timeout.Seconds(), 'f', -1, 64))
+	}
+	if opts.RerunFailed {
+		// ctest keeps the failures of its last run in this log
+		if info, err := os.Stat(filepath.Join(buildDir, "Testing", "Temporary", "LastTestsFailed.log")); err != nil || info.Size() == 0 {
+			output.Successf(" No failed tests in the last run")
+			return nil
+		}
+		ctestArgs = append(ctestArgs, "--rerun-failed")
+	}
+
+	filter := opts.Filter
+	if filter == "" && opts.Pick != nil {
+		test, err := opts.Pick.Choose(ctestNames(ctestArgs), "")
+		if err != nil {
+			return err
+		}
+		if test != "" {
+			filter = "^" + regexp.QuoteMeta(test) + "$"
+		}
+	}
 
-	if opts.Filter != "" {
-		ctestArgs = append(ctestArgs, "--output-on-failure", "-R", opts.Filter)
+	if filter != "" {
+		ctestArgs = append(ctestArgs, "--output-on-failure", "-R", filter)
 	} else {
 		ctestArgs = append(ctestArgs, "--output-on-failure")
 	}
 
 	ctestCmd := execCommand("ctest", ctestArgs...)
-	ctestCmd.Stdout = os.Stdout
-	ctestCmd.Stderr = os.Stderr
+	ctestCmd.Stdout = buildlog.Stdout()
+	ctestCmd.Stderr = buildlog.Stderr()
+	if opts.Sanitizer != "" {
+		ctestCmd.Env = append(os.Environ(), build.SanitizerEnv(opts.Sanitizer)...)
+	}
 
-	if err := ctestCmd.Run(); err != nil {
+	if err := build.RunTestCommand(ctestCmd, opts.SessionTimeout); err != nil {
 		return fmt.Errorf("tests failed: %w", err)
 	}
 
-	fmt.Printf("%s All tests passed!%s\n", "\033[32m", "\033[0m")
+	output.Successf(" All tests passed!")
 	return nil
 }
 
@@ -502,17 +670,19 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 	}
 
 	// Get project name from CMakeLists.txt (optional, for display only)
-	projectName := getProjectNameFromCMakeLists()
+	projectName := currentProjectName()
 	if projectName == "" {
 		projectName = "project"
 	}
 
-	buildType, cxxFlags := determineBuildType(opts.Release, opts.OptLevel)
-
-	// Add sanitizer flags
-	sanCFlags, sanLFlags := getSanitizerFlags(opts.Sanitizer)
-	cxxFlags += sanCFlags
-	linkerFlags := sanLFlags
+	toolchain, err := b.cmakeToolchain()
+	if err != nil {
+		return err
+	}
+	buildType, flagArgs, err := toolchain.flagArgs(opts.Release, opts.OptLevel, opts.Sanitizer, nil, false)
+	if err != nil {
+		return err
+	}
 
 	optLabel := "default (-O0)"
 	if opts.Release {
@@ -525,14 +695,14 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 		optLabel += "+" + opts.Sanitizer
 	}
 
-	fmt.Printf("\n%s▸ Build%s %s %s(%s)%s %s[opt: %s]%s\n",
+	fmt.Fprintf(output.Stdout(), "\n%s▸ Build%s %s %s(%s)%s %s[opt: %s]%s\n",
 		colors.Cyan, colors.Reset, projectName, colors.Gray, buildType, colors.Reset,
 		colors.Gray, optLabel, colors.Reset)
 
 	// Configure CMake if needed
 	outDirName := build.GetOutputDir(opts.Release, opts.OptLevel, opts.Sanitizer)
-	cacheBuildDir := filepath.Join(".cache", "native", outDirName)
-	finalBuildDir := filepath.Join(".bin", "native", outDirName)
+	cacheBuildDir := filepath.Join(config.CacheDir(), "native", outDirName)
+	finalBuildDir := filepath.Join(config.BinDir(), "native", outDirName)
 	needsConfigure := false
 	if _, err := os.Stat(filepath.Join(cacheBuildDir, "CMakeCache.txt")); os.IsNotExist(err) {
 		needsConfigure = true
@@ -548,50 +718,42 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 	if needsConfigure {
 		currentStep++
 		if opts.Verbose {
-			fmt.Printf("%s  • Configuring CMake%s\n", colors.Cyan, colors.Reset)
+			output.Stepf("  • Configuring CMake")
 		} else {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configuring...", colors.Cyan, currentStep, totalSteps, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\r\033[2K%s[%d/%d]%s Configuring...", colors.Cyan, currentStep, totalSteps, colors.Reset)
 		}
 
-		// Determine absolute path for shared vcpkg_installed directory
-		cwd, _ := os.Getwd()
-		vcpkgInstalledDir := filepath.Join(cwd, ".cache", "native", "vcpkg_installed")
+		vcpkgInstalledDir, err := b.InstalledDir()
+		if err != nil {
+			return err
+		}
 		vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
 
-		// Check if CMakePresets.json exists, use preset if available
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			// Use "default" preset (VCPKG_ROOT is now set from config)
+		// Use the default preset if the project has one
+		if defaultPreset() != "" {
 			cmdArgs := []string{"--preset=default", "-B", cacheBuildDir, vcpkgInstallArg}
-			if cxxFlags != "" {
-				cmdArgs = append(cmdArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
-			}
-			if linkerFlags != "" {
-				cmdArgs = append(cmdArgs, "-DCMAKE_EXE_LINKER_FLAGS="+linkerFlags, "-DCMAKE_SHARED_LINKER_FLAGS="+linkerFlags)
-			}
+			cmdArgs = append(cmdArgs, toolchain.configureArgs()...)
+			cmdArgs = append(cmdArgs, flagArgs...)
 			cmd := exec.Command("cmake", cmdArgs...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, opts.Verbose); err != nil {
-				fmt.Println()
+				fmt.Fprintln(output.Stdout())
 				return fmt.Errorf("cmake configure failed (preset 'default'): %w", err)
 			}
 		} else {
 			// Fallback to traditional cmake configure
 			cmdArgs := []string{"-B", cacheBuildDir, "-DCMAKE_BUILD_TYPE=" + buildType, vcpkgInstallArg}
-			if cxxFlags != "" {
-				cmdArgs = append(cmdArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
-			}
-			if linkerFlags != "" {
-				cmdArgs = append(cmdArgs, "-DCMAKE_EXE_LINKER_FLAGS="+linkerFlags, "-DCMAKE_SHARED_LINKER_FLAGS="+linkerFlags)
-			}
+			cmdArgs = append(cmdArgs, toolchain.configureArgs()...)
+			cmdArgs = append(cmdArgs, flagArgs...)
 			cmd := execCommand("cmake", cmdArgs...)
 			if err := runCMakeConfigure(cmd, opts.Verbose); err != nil {
-				fmt.Println()
+				fmt.Fprintln(output.Stdout())
 				return fmt.Errorf("cmake configure failed: %w", err)
 			}
 		}
 
 		if !opts.Verbose {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configured ✓\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\r\033[2K%s[%d/%d]%s Configured ✓\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
 		}
 	}
 
@@ -613,11 +775,12 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 		return fmt.Errorf("failed to create final build dir: %w", err)
 	}
 
-	executables, err := findExecutables(cacheBuildDir)
+	// Multi-config generators (Visual Studio) put executables in <build>/<Config>
+	executables, err := findExecutables(toolchain.outputDir(cacheBuildDir, buildType))
 	if err == nil {
 		for _, exe := range executables {
 			dest := filepath.Join(finalBuildDir, filepath.Base(exe))
-			_ = copyAndSign(exe, dest)
+			_ = copyAndSign(exe, dest, nil)
 		}
 	}
 
@@ -627,7 +790,7 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 	// If target specified, look for that specific executable
 	if opts.Target != "" {
 		targetName := opts.Target
-		if runtime.GOOS == "windows" && !strings.HasSuffix(targetName, ".exe") {
+		if hostOS == "windows" && !strings.HasSuffix(strings.ToLower(targetName), ".exe") {
 			targetName += ".exe"
 		}
 		execPath = filepath.Join(finalBuildDir, targetName)
@@ -637,11 +800,24 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 	} else {
 		// Look for project name executable first
 		execName := projectName
-		if runtime.GOOS == "windows" {
+		if hostOS == "windows" {
 			execName += ".exe"
 		}
 
 		execPath = filepath.Join(finalBuildDir, execName)
+		if executables, err := findExecutables(finalBuildDir); err == nil && opts.Pick != nil && len(executables) > 1 {
+			names := make([]string, len(executables))
+			for i, executable := range executables {
+				names[i] = filepath.Base(executable)
+			}
+			choice, err := opts.Pick.Choose(names, "")
+			if err != nil {
+				return err
+			}
+			if choice != "" {
+				execPath = filepath.Join(finalBuildDir, choice)
+			}
+		}
 		if _, err := os.Stat(execPath); os.IsNotExist(err) {
 			// Find all executables
 			executables, err := findExecutables(finalBuildDir)
@@ -657,27 +833,81 @@ func (b *Builder) Run(ctx context.Context, opts build.RunOptions) error {
 				execPath = executables[0]
 			} else {
 				// Multiple executables found, list them
-				fmt.Printf("%s Multiple executables found:%s\n", colors.Gray, colors.Reset)
+				fmt.Fprintf(output.Stdout(), "%s Multiple executables found:%s\n", colors.Gray, colors.Reset)
 				for i, executable := range executables {
-					fmt.Printf("  [%d] %s\n", i+1, filepath.Base(executable))
+					fmt.Fprintf(output.Stdout(), "  [%d] %s\n", i+1, filepath.Base(executable))
 				}
-				fmt.Printf("\nUse --target <name> to specify which one to run\n")
+				fmt.Fprintf(output.Stdout(), "\nUse --target <name> to specify which one to run\n")
 				// Run the first one by default
 				execPath = executables[0]
-				fmt.Printf("%s Running first: %s%s\n", "\033[33m", filepath.Base(execPath), "\033[0m")
+				fmt.Fprintf(output.Stdout(), "%s Running first: %s%s\n", colors.Yellow, filepath.Base(execPath), colors.Reset)
 			}
 		}
 	}
 
-	fmt.Printf("%s  ✔ Build complete%s %s[%s]%s\n", colors.Green, colors.Reset, colors.Gray, time.Since(buildStart).Round(10*time.Millisecond), colors.Reset)
-	fmt.Printf("%s  ▶ Run%s %s%s%s\n\n", colors.Cyan, colors.Reset, colors.Green, filepath.Base(execPath), colors.Reset)
-	fmt.Println(strings.Repeat("─", 40))
+	output.Successf("  ✔ Build complete%s %s[%s]", colors.Reset, colors.Gray, time.Since(buildStart).Round(10*time.Millisecond))
+	fmt.Fprintf(output.Stdout(), "%s  ▶ Run%s %s%s%s\n\n", colors.Cyan, colors.Reset, colors.Green, filepath.Base(execPath), colors.Reset)
+	fmt.Fprintln(output.Stdout(), strings.Repeat("─", 40))
 
-	runCmd := execCommand(execPath, opts.Args...)
+	argv := build.WrapCommand(opts.Wrapper, execPath, opts.Args...)
+	runCmd := execCommand(argv[0], argv[1:]...)
 	runCmd.Stdout = os.Stdout
 	runCmd.Stderr = os.Stderr
 	runCmd.Stdin = os.Stdin
-	return runCmd.Run()
+	if opts.Sanitizer != "" {
+		runCmd.Env = append(os.Environ(), build.SanitizerEnv(opts.Sanitizer)...)
+	}
+	err = build.RunProgram(runCmd, opts.Timeout, opts.DumpOnTimeout)
+	if len(opts.Wrapper) == 0 {
+		build.ReportCrash(build.CrashOf(runCmd, err))
+	}
+	return err
+}
+
+// ctestSelectArgs returns the ctest arguments selecting tests by label and
+// skipping them by label or name.
+func ctestSelectArgs(opts build.TestOptions) []string {
+	var args []string
+	if len(opts.Labels) > 0 {
+		args = append(args, "-L", labelRegexp(opts.Labels))
+	}
+	if len(opts.ExcludeLabels) > 0 {
+		args = append(args, "-LE", labelRegexp(opts.ExcludeLabels))
+	}
+	if opts.Exclude != "" {
+		args = append(args, "-E", opts.Exclude)
+	}
+	return args
+}
+
+// labelRegexp matches any of labels exactly; ctest takes label regular
+// expressions, and ANDs repeated -L options.
+func labelRegexp(labels []string) string {
+	quoted := make([]string, len(labels))
+	for i, label := range labels {
+		quoted[i] = regexp.QuoteMeta(label)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// ctestNames lists the tests ctest would run with args.
+func ctestNames(args []string) []string {
+	out, err := execCommand("ctest", append(append([]string{}, args...), "-N")...).Output()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		// "  Test #1: name"
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "Test #") {
+			continue
+		}
+		if _, name, ok := strings.Cut(line, ": "); ok {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names
 }
 
 // Bench runs the project's benchmarks.
@@ -687,15 +917,20 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 		return err
 	}
 
-	projectName := getProjectNameFromCMakeLists()
+	projectName := currentProjectName()
 	if projectName == "" {
 		return fmt.Errorf("failed to get project name from CMakeLists.txt")
 	}
-	fmt.Printf("%s Running benchmarks for '%s'...%s\n", "\033[36m", projectName, "\033[0m")
+	output.Stepf(" Running benchmarks for '%s'...", projectName)
+
+	toolchain, err := b.cmakeToolchain()
+	if err != nil {
+		return err
+	}
 
 	// Default to release for benchmarks (benchmarks should be optimized)
 	// Use .cache/native/bench for building benchmarks (separate from normal builds)
-	buildDir := filepath.Join(".cache", "native", "bench")
+	buildDir := filepath.Join(config.CacheDir(), "native", "bench")
 	benchTarget := projectName + "_bench"
 	if opts.Target != "" {
 		benchTarget = opts.Target
@@ -718,44 +953,50 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 	if needsConfigure {
 		currentStep++
 		if opts.Verbose {
-			fmt.Printf("%s  Configuring CMake (with benchmarks enabled)...%s\n", "\033[36m", "\033[0m")
+			output.Stepf("  Configuring CMake (with benchmarks enabled)...")
 		} else {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configuring...", colors.Cyan, currentStep, totalSteps, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\r\033[2K%s[%d/%d]%s Configuring...", colors.Cyan, currentStep, totalSteps, colors.Reset)
 		}
 
-		// Determine absolute path for shared vcpkg_installed directory
-		cwd, _ := os.Getwd()
-		vcpkgInstalledDir := filepath.Join(cwd, ".cache", "native", "vcpkg_installed")
+		vcpkgInstalledDir, err := b.InstalledDir()
+		if err != nil {
+			return err
+		}
 		vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
 
 		// Enable benchmarks with Release build type for optimal performance
 		enableBenchArg := "-DENABLE_BENCHMARKS=ON"
 		buildTypeArg := "-DCMAKE_BUILD_TYPE=Release"
 
-		// Check if CMakePresets.json exists, use preset if available
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			cmd := execCommand("cmake", "--preset=default", "-B", buildDir, vcpkgInstallArg, enableBenchArg, buildTypeArg)
+		// Use the default preset if the project has one
+		if defaultPreset() != "" {
+			cmdArgs := append([]string{"--preset=default", "-B", buildDir, vcpkgInstallArg, enableBenchArg, buildTypeArg}, toolchain.configureArgs()...)
+			cmd := execCommand("cmake", cmdArgs...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, opts.Verbose); err != nil {
-				fmt.Println()
+				fmt.Fprintln(output.Stdout())
 				return fmt.Errorf("cmake configure failed (preset 'default'): %w", err)
 			}
 		} else {
-			cmd := execCommand("cmake", "-B", buildDir, vcpkgInstallArg, enableBenchArg, buildTypeArg)
+			cmdArgs := append([]string{"-B", buildDir, vcpkgInstallArg, enableBenchArg, buildTypeArg}, toolchain.configureArgs()...)
+			cmd := execCommand("cmake", cmdArgs...)
 			if err := runCMakeConfigure(cmd, opts.Verbose); err != nil {
-				fmt.Println()
+				fmt.Fprintln(output.Stdout())
 				return fmt.Errorf("cmake configure failed: %w", err)
 			}
 		}
 
 		if !opts.Verbose {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configured ✓\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
+			fmt.Fprintf(output.Stdout(), "\r\033[2K%s[%d/%d]%s Configured ✓\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
 		}
 	}
 
 	// Build benchmarks
 	currentStep++
 	buildArgs := []string{"--build", buildDir, "--target", benchTarget}
+	if toolchain.MultiConfig {
+		buildArgs = append(buildArgs, "--config", "Release")
+	}
 	if err := runCMakeBuild(buildArgs, opts.Verbose, currentStep, totalSteps); err != nil {
 		return fmt.Errorf("failed to build benchmarks: %w", err)
 	}
@@ -763,16 +1004,20 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 	// Run benchmarks
 	currentStep++
 	if !opts.Verbose {
-		fmt.Printf("%s[%d/%d]%s Running benchmarks...\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
+		fmt.Fprintf(output.Stdout(), "%s[%d/%d]%s Running benchmarks...\n", colors.Cyan, currentStep, totalSteps, colors.Reset)
 	} else {
-		fmt.Printf("%s Running benchmarks...%s\n", "\033[36m", "\033[0m")
+		output.Stepf(" Running benchmarks...")
 	}
 
 	// Find the benchmark executable
 	// Try common locations
+	benchExe := benchTarget
+	if hostOS == "windows" {
+		benchExe += ".exe"
+	}
 	possiblePaths := []string{
-		filepath.Join(buildDir, "bench", benchTarget),
-		filepath.Join(buildDir, benchTarget),
+		filepath.Join(toolchain.outputDir(filepath.Join(buildDir, "bench"), "Release"), benchExe),
+		filepath.Join(toolchain.outputDir(buildDir, "Release"), benchExe),
 	}
 
 	var benchPath string
@@ -787,39 +1032,40 @@ func (b *Builder) Bench(ctx context.Context, opts build.BenchOptions) error {
 		return fmt.Errorf("benchmark executable not found. Tried: %v", possiblePaths)
 	}
 
-	benchCmd := execCommand(benchPath)
+	argv := build.WrapCommand(opts.Wrapper, benchPath)
+	benchCmd := execCommand(argv[0], argv[1:]...)
 	benchCmd.Stdout = os.Stdout
 	benchCmd.Stderr = os.Stderr
 
-	fmt.Println() // Add blank line before benchmark output
+	fmt.Fprintln(output.Stdout()) // Add blank line before benchmark output
 	if err := benchCmd.Run(); err != nil {
 		return fmt.Errorf("benchmarks failed: %w", err)
 	}
 
-	fmt.Printf("\n%s✓ Benchmarks completed!%s\n", "\033[32m", "\033[0m")
+	fmt.Fprintf(output.Stdout(), "\n%s✓ Benchmarks completed!%s\n", colors.Green, colors.Reset)
 	return nil
 }
 
 // Clean removes build artifacts.
 func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
-	fmt.Printf("%sCleaning CMake/vcpkg project...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Cleaning CMake/vcpkg project...")
 
 	// Remove bin directory (artifacts)
-	removeDir(filepath.Join(".bin", "native"))
+	removeDir(filepath.Join(config.BinDir(), "native"))
 
 	// Remove intermediate build directories (keep vcpkg_installed unless --all)
 	// We iterate common variants instead of blowing away .cache/native
 	variants := []string{"debug", "release", "O0", "O1", "O2", "O3", "Os", "Ofast", "test", "bench"}
 	for _, v := range variants {
-		removeDir(filepath.Join(".cache", "native", v))
+		removeDir(filepath.Join(config.CacheDir(), "native", v))
 	}
 
 	if opts.All {
 		// Clean everything including vcpkg dependencies and CI artifacts
 		dirsToRemove := []string{
-			filepath.Join(".cache", "native"),
-			filepath.Join(".cache", "ci"),
-			filepath.Join(".bin", "ci"),
+			filepath.Join(config.CacheDir(), "native"),
+			filepath.Join(config.CacheDir(), "ci"),
+			filepath.Join(config.BinDir(), "ci"),
 			"out",
 			"cmake-build-debug",
 			"cmake-build-release",
@@ -835,7 +1081,7 @@ func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
 				if entry.IsDir() {
 					matched, _ := filepath.Match("build-*", entry.Name())
 					if matched {
-						fmt.Printf("%s  Removing %s...%s\n", colors.Cyan, entry.Name(), colors.Reset)
+						output.Stepf("  Removing %s...", entry.Name())
 						os.RemoveAll(entry.Name())
 					}
 				}
@@ -843,15 +1089,15 @@ func (b *Builder) Clean(ctx context.Context, opts build.CleanOptions) error {
 		}
 	}
 
-	fmt.Printf("%s✓ CMake project cleaned%s\n", colors.Green, colors.Reset)
+	output.Successf("✓ CMake project cleaned")
 	return nil
 }
 
 func removeDir(path string) {
 	if _, err := os.Stat(path); err == nil {
-		fmt.Printf("%s  Removing %s...%s\n", colors.Cyan, path, colors.Reset)
+		output.Stepf("  Removing %s...", path)
 		if err := os.RemoveAll(path); err != nil {
-			fmt.Printf("%s⚠ Failed to remove %s: %v%s\n", colors.Yellow, path, err, colors.Reset)
+			output.Warnf("Failed to remove %s: %v", path, err)
 		}
 	}
 }
@@ -869,7 +1115,11 @@ func (b *Builder) AddDependency(ctx context.Context, name string, version string
 		return fmt.Errorf("failed to add dependency: %w", err)
 	}
 
-	fmt.Printf("%s✓ Added %s%s\n", colors.Green, name, colors.Reset)
+	output.Successf("✓ Added %s", name)
+
+	if err := b.updatePkgConfig(ctx); err != nil {
+		output.Warnf("could not update pkg-config file: %v", err)
+	}
 
 	// Print usage info from vcpkg GitHub
 	b.printUsageInfo(name)
@@ -892,18 +1142,22 @@ func (b *Builder) printUsageInfo(pkgName string) {
 
 	content := strings.TrimSpace(string(data))
 	if content != "" {
-		fmt.Printf("\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, pkgName, colors.Reset)
-		fmt.Println(content)
-		fmt.Println()
+		fmt.Fprintf(output.Stdout(), "\n%sUSAGE INFO FOR %s:%s\n", colors.Cyan, pkgName, colors.Reset)
+		fmt.Fprintln(output.Stdout(), content)
+		fmt.Fprintln(output.Stdout())
 	}
 
 	// Print link to cpx website for more info
-	fmt.Printf("%s📦 Find sample usage and more info at:%s\n", colors.Cyan, colors.Reset)
-	fmt.Printf("   https://cpx-dev.vercel.app/packages#package/%s\n\n", pkgName)
+	output.Stepf("📦 Find sample usage and more info at:")
+	fmt.Fprintf(output.Stdout(), "   https://cpx-dev.vercel.app/packages#package/%s\n\n", pkgName)
 }
 
 // RemoveDependency removes a dependency from the project.
 func (b *Builder) RemoveDependency(ctx context.Context, name string) error {
+	if removed, err := removePathDependency(name); err != nil || removed {
+		return err
+	}
+
 	// Check for vcpkg.json (Manifest mode)
 	if _, err := os.Stat("vcpkg.json"); err != nil {
 		return fmt.Errorf("vcpkg.json not found - manifest mode required")
@@ -970,14 +1224,22 @@ func (b *Builder) RemoveDependency(ctx context.Context, name string) error {
 		return fmt.Errorf("failed to write vcpkg.json: %w", err)
 	}
 
-	fmt.Printf("%s✓ Removed %s from vcpkg.json%s\n", colors.Green, name, colors.Reset)
+	output.Successf("✓ Removed %s from vcpkg.json", name)
+
+	if err := b.updatePkgConfig(ctx); err != nil {
+		output.Warnf("could not update pkg-config file: %v", err)
+	}
 	return nil
 }
 
 // ListDependencies returns the list of dependencies in the project.
 func (b *Builder) ListDependencies(ctx context.Context) ([]build.Dependency, error) {
-	// Read vcpkg.json
-	data, err := os.ReadFile("vcpkg.json")
+	return manifestDependencies("vcpkg.json")
+}
+
+// manifestDependencies returns the dependencies of the vcpkg.json at path.
+func manifestDependencies(path string) ([]build.Dependency, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil // No vcpkg.json means no dependencies
@@ -1185,6 +1447,53 @@ func (b *Builder) DependencyInfo(ctx context.Context, name string) (*build.Depen
 // Compile-time check that Builder implements build.BuildSystem.
 var _ build.BuildSystem = (*Builder)(nil)
 
+// cmakeBool formats a boolean cache value.
+func cmakeBool(on bool) string {
+	if on {
+		return "ON"
+	}
+	return "OFF"
+}
+
+// cmakeCacheValue returns the value of a variable in the CMakeCache.txt of
+// buildDir, or "" if it is not set.
+func cmakeCacheValue(buildDir, name string) string {
+	data, err := os.ReadFile(filepath.Join(buildDir, "CMakeCache.txt"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && strings.HasPrefix(key, name+":") {
+			return value
+		}
+	}
+	return ""
+}
+
+// configureArgsStamp records, in the build directory, the extra configure
+// arguments and defines it was last configured with.
+const configureArgsStamp = ".cpx-configure-args"
+
+// configureArgsChanged reports whether buildDir was configured with other
+// extra arguments than args.
+func configureArgsChanged(buildDir string, args []string) bool {
+	data, _ := os.ReadFile(filepath.Join(buildDir, configureArgsStamp))
+	return string(data) != strings.Join(args, "\n")
+}
+
+// writeConfigureArgs records args as those buildDir was configured with.
+func writeConfigureArgs(buildDir string, args []string) error {
+	path := filepath.Join(buildDir, configureArgsStamp)
+	if len(args) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, []byte(strings.Join(args, "\n")), 0644)
+}
+
 // FindExecutables finds all executables in the build directory
 func findExecutables(buildDir string) ([]string, error) {
 	var executables []string
@@ -1208,7 +1517,7 @@ func findExecutables(buildDir string) ([]string, error) {
 
 		// Skip test executables and common non-executable files
 		if strings.Contains(name, "_test") || strings.Contains(name, "_tests") ||
-			strings.HasSuffix(name, ".a") || strings.HasSuffix(name, ".so") ||
+			strings.HasSuffix(name, ".a") || strings.HasSuffix(name, ".so") || strings.Contains(name, ".so.") ||
 			strings.HasSuffix(name, ".dylib") || strings.HasSuffix(name, ".dll") ||
 			strings.HasSuffix(name, ".lib") || strings.HasSuffix(name, ".o") ||
 			strings.HasSuffix(name, ".cmake") || strings.HasSuffix(name, ".ninja") ||
@@ -1217,8 +1526,8 @@ func findExecutables(buildDir string) ([]string, error) {
 		}
 
 		// Check if it's executable
-		if runtime.GOOS == "windows" {
-			if strings.HasSuffix(name, ".exe") {
+		if hostOS == "windows" {
+			if strings.HasSuffix(strings.ToLower(name), ".exe") {
 				executables = append(executables, filepath.Join(buildDir, name))
 			}
 		} else {
@@ -1234,120 +1543,32 @@ func findExecutables(buildDir string) ([]string, error) {
 	return executables, nil
 }
 
-var progressRe = regexp.MustCompile(`^\[\s*\d+%]`)
-
 // runCMakeBuild runs "cmake --build" with optional verbose output.
-// If verbose is false, it streams only progress lines like "[ 93%]" and errors.
+// If verbose is false, it shows a progress bar and prints other output only on failure.
 func runCMakeBuild(buildArgs []string, verbose bool, currentStep, totalSteps int) error {
 	cmd := execCommand("cmake", buildArgs...)
 
 	if verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		cmd.Stdout = buildlog.Stdout()
+		cmd.Stderr = buildlog.Stderr()
 		return cmd.Run()
 	}
-
-	// Create a progress bar for the build percentage
-	bar := progressbar.NewOptions(100,
-		progressbar.OptionSetWriter(os.Stderr),
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionSetWidth(20),
-		progressbar.OptionSetDescription(fmt.Sprintf("[cyan][%d/%d][reset] Compiling", currentStep, totalSteps)),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "[cyan]█[reset]",
-			SaucerHead:    "[cyan]▸[reset]",
-			SaucerPadding: "░",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-		progressbar.OptionClearOnFinish(),
-	)
-
-	// Ensure cursor is restored on interrupt
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-	defer signal.Stop(sigCh)
-	go func() {
-		<-sigCh
-		_ = bar.Clear()
-		fmt.Print("\033[?25h") // Show cursor
-		os.Exit(1)
-	}()
-
-	pr, pw := io.Pipe()
-	cmd.Stdout = pw
-	cmd.Stderr = pw
-
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-
-	var nonProgress bytes.Buffer
-	lastPercent := -1
-
-	waitCh := make(chan error, 1)
-	go func() {
-		waitCh <- cmd.Wait()
-		pw.Close()
-	}()
-
-	sc := bufio.NewScanner(pr)
-	sc.Buffer(make([]byte, 0, 64*1024), 512*1024)
-	for sc.Scan() {
-		line := sc.Text()
-		if match := progressRe.FindString(line); match != "" {
-			pct := extractPercent(match)
-			if pct >= 0 && pct != lastPercent {
-				_ = bar.Set(pct)
-				lastPercent = pct
-			}
-			continue
-		}
-		nonProgress.WriteString(line)
-		nonProgress.WriteByte('\n')
-	}
-
-	err := <-waitCh
-
-	// Complete the progress bar
-	_ = bar.Set(100)
-	_ = bar.Clear()
-
-	if err != nil {
-		if nonProgress.Len() > 0 {
-			fmt.Fprintln(os.Stderr, nonProgress.String())
-		}
-		return err
-	}
-
-	return nil
-}
-
-func extractPercent(line string) int {
-	// line format: [ 93%] ...
-	start := strings.Index(line, "[")
-	end := strings.Index(line, "%")
-	if start == -1 || end == -1 || end <= start {
-		return -1
-	}
-	var pct int
-	if _, err := fmt.Sscanf(line[start+1:end], "%d", &pct); err != nil {
-		return -1
-	}
-	return pct
+	return progress.Run(cmd, "Compiling", currentStep, totalSteps)
 }
 
 // runCMakeConfigure runs cmake configure quietly unless verbose is true.
 func runCMakeConfigure(cmd *exec.Cmd, verbose bool) error {
+	events.ConfigureStarted("cmake")
 	if verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		cmd.Stdout = buildlog.Stdout()
+		cmd.Stderr = buildlog.Stderr()
 		return cmd.Run()
 	}
 
 	var buf bytes.Buffer
-	cmd.Stdout = &buf
-	cmd.Stderr = &buf
+	out := io.MultiWriter(&buf, buildlog.Writer())
+	cmd.Stdout = out
+	cmd.Stderr = out
 
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("%v\n%s", err, buf.String())
@@ -1355,26 +1576,17 @@ func runCMakeConfigure(cmd *exec.Cmd, verbose bool) error {
 	return nil
 }
 
-// copyAndSign copies a file and signs it on macOS to prevent signal: killed
-func copyAndSign(src, dest string) error {
-	// Remove destination to ensure clean copy
-	os.Remove(dest)
-
-	// Simple copy for Windows
-	if runtime.GOOS == "windows" {
-		input, err := os.ReadFile(src)
-		if err != nil {
-			return err
-		}
-		return os.WriteFile(dest, input, 0755)
-	}
-
-	// Use cp -f on unix-like systems to preserve attributes
-	cmd := execCommand("cp", "-f", src, dest)
-	if err := cmd.Run(); err != nil {
+// copyAndSign copies a file and signs it on macOS to prevent signal: killed.
+// With a Developer ID it is signed for distribution instead, and a failure
+// to sign is an error.
+func copyAndSign(src, dest string, developerID *config.MacOSSigningConfig) error {
+	if err := artifacts.CopyFile(src, dest); err != nil {
 		return fmt.Errorf("failed to copy binary: %w", err)
 	}
 
+	if runtime.GOOS == "darwin" && developerID != nil {
+		return artifacts.Codesign(developerID.Identity, developerID.Entitlements, dest)
+	}
 	// On macOS/Darwin, force ad-hoc codesign
 	if runtime.GOOS == "darwin" {
 		cmd := execCommand("codesign", "-s", "-", "--force", dest)
@@ -1385,22 +1597,22 @@ func copyAndSign(src, dest string) error {
 	return nil
 }
 
+// currentProjectName returns the name of the project in the current directory:
+// the name of its cpx.yaml, else the one of its CMakeLists.txt.
+func currentProjectName() string {
+	if manifest := config.ProjectManifest("."); manifest != nil && manifest.Name != "" {
+		return manifest.Name
+	}
+	return getProjectNameFromCMakeLists()
+}
+
 // GetProjectNameFromCMakeLists extracts project name from CMakeLists.txt in current directory
 func getProjectNameFromCMakeLists() string {
-	cmakeListsPath := "CMakeLists.txt"
-	data, err := os.ReadFile(cmakeListsPath)
+	data, err := os.ReadFile("CMakeLists.txt")
 	if err != nil {
 		return ""
 	}
-
-	// Look for: project(PROJECT_NAME ...)
-	re := regexp.MustCompile(`project\s*\(\s*([^\s\)]+)`)
-	matches := re.FindStringSubmatch(string(data))
-	if len(matches) > 1 {
-		return matches[1]
-	}
-
-	return ""
+	return cmakeProjectName(string(data))
 }
 
 // DetermineBuildType determines the CMake build type and CXX flags based on release flag and optimization level.
@@ -1438,30 +1650,10 @@ func determineBuildType(release bool, optLevel string) (string, string) {
 	return buildType, cxxFlags
 }
 
-// GetSanitizerFlags returns the CXX flags and linker flags for the given sanitizer
-func getSanitizerFlags(sanitizer string) (string, string) {
-	cxxFlags := ""
-	linkerFlags := ""
-	switch sanitizer {
-	case "asan":
-		cxxFlags = " -fsanitize=address -fno-omit-frame-pointer"
-		linkerFlags = "-fsanitize=address"
-	case "tsan":
-		cxxFlags = " -fsanitize=thread"
-		linkerFlags = "-fsanitize=thread"
-	case "msan":
-		cxxFlags = " -fsanitize=memory -fno-omit-frame-pointer"
-		linkerFlags = "-fsanitize=memory"
-	case "ubsan":
-		cxxFlags = " -fsanitize=undefined"
-	}
-	return cxxFlags, linkerFlags
-}
-
 // ListTargets returns the list of build targets.
 func (b *Builder) ListTargets(ctx context.Context) ([]string, error) {
 	// Look for any configured build directory in .cache/native
-	cacheDir := filepath.Join(".cache", "native")
+	cacheDir := filepath.Join(config.CacheDir(), "native")
 	entries, err := os.ReadDir(cacheDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -5,7 +5,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	build "github.com/ozacod/cpx/internal/pkg/build/interfaces"
 	"github.com/ozacod/cpx/pkg/config"
@@ -19,6 +21,18 @@ func TestHelperProcess(t *testing.T) {
 	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
 		return
 	}
+	// "cmake --install <dir> --prefix <prefix>" installs a header and a library
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--prefix" && i >= 2 && i+1 < len(args) && args[i-2] == "--install" {
+			prefix := args[i+1]
+			_ = os.MkdirAll(filepath.Join(prefix, "include"), 0755)
+			_ = os.WriteFile(filepath.Join(prefix, "include", "mylib.hpp"), []byte("#pragma once\n"), 0644)
+			_ = os.MkdirAll(filepath.Join(prefix, "lib", "cmake", "mylib"), 0755)
+			_ = os.WriteFile(filepath.Join(prefix, "lib", "libmylib.a"), []byte("!<arch>\n"), 0644)
+			_ = os.WriteFile(filepath.Join(prefix, "lib", "cmake", "mylib", "mylibConfig.cmake"), []byte(""), 0644)
+		}
+	}
 	os.Exit(0)
 }
 
@@ -173,6 +187,27 @@ func TestTest(t *testing.T) {
 		}
 	}
 	assert.True(t, foundCtest, "ctest should be called")
+
+	// --failed without failures from the last run runs nothing
+	capturedArgs = nil
+	require.NoError(t, builder.Test(context.Background(), build.TestOptions{RerunFailed: true}))
+	for _, args := range capturedArgs {
+		assert.NotEqual(t, "ctest", args[0])
+	}
+
+	failedLog := filepath.Join(testCacheDir, "Testing", "Temporary", "LastTestsFailed.log")
+	require.NoError(t, os.MkdirAll(filepath.Dir(failedLog), 0755))
+	require.NoError(t, os.WriteFile(failedLog, []byte("3:MathTest.Divide\n"), 0644))
+	capturedArgs = nil
+	require.NoError(t, builder.Test(context.Background(), build.TestOptions{RerunFailed: true}))
+	last := capturedArgs[len(capturedArgs)-1]
+	assert.Equal(t, "ctest", last[0])
+	assert.Contains(t, last, "--rerun-failed")
+
+	capturedArgs = nil
+	require.NoError(t, builder.Test(context.Background(), build.TestOptions{Timeout: 90 * time.Second}))
+	last = capturedArgs[len(capturedArgs)-1]
+	assert.Contains(t, strings.Join(last, " "), "--timeout 90")
 }
 
 func TestRun(t *testing.T) {
@@ -247,3 +282,105 @@ func TestAddDependency(t *testing.T) {
 	}
 	assert.True(t, foundVcpkgAdd, "vcpkg add port zlib should be called")
 }
+
+func TestCMakeCacheValue(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, "", cmakeCacheValue(dir, "ENABLE_PCH"), "no cache yet")
+
+	cache := "# This is the CMakeCache file.\n" +
+		"ENABLE_PCH:BOOL=OFF\n" +
+		"ENABLE_PCH_EXTRA:STRING=x\n" +
+		"CMAKE_BUILD_TYPE:STRING=Debug\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "CMakeCache.txt"), []byte(cache), 0644))
+	assert.Equal(t, "OFF", cmakeCacheValue(dir, "ENABLE_PCH"))
+	assert.Equal(t, "Debug", cmakeCacheValue(dir, "CMAKE_BUILD_TYPE"))
+	assert.Equal(t, cmakeBool(false), cmakeCacheValue(dir, "ENABLE_PCH"))
+}
+
+func TestConfigureArgsChanged(t *testing.T) {
+	dir := t.TempDir()
+	assert.False(t, configureArgsChanged(dir, nil), "no extra args, none recorded")
+	args := []string{"-DFOO=ON", "BAR=1"}
+	assert.True(t, configureArgsChanged(dir, args))
+
+	require.NoError(t, writeConfigureArgs(dir, args))
+	assert.False(t, configureArgsChanged(dir, args), "unchanged args keep the configuration")
+	assert.True(t, configureArgsChanged(dir, []string{"-DFOO=OFF", "BAR=1"}))
+	assert.True(t, configureArgsChanged(dir, nil))
+
+	require.NoError(t, writeConfigureArgs(dir, nil))
+	assert.NoFileExists(t, filepath.Join(dir, configureArgsStamp))
+	assert.False(t, configureArgsChanged(dir, nil))
+}
+
+func TestCTestSelectArgs(t *testing.T) {
+	assert.Empty(t, ctestSelectArgs(build.TestOptions{}))
+	assert.Equal(t, []string{"-L", "^(integration|e2e)$", "-LE", "^(slow)$", "-E", "Network"},
+		ctestSelectArgs(build.TestOptions{
+			Labels:        []string{"integration", "e2e"},
+			ExcludeLabels: []string{"slow"},
+			Exclude:       "Network",
+		}))
+	assert.Equal(t, `^(c\+\+)$`, labelRegexp([]string{"c++"}))
+}
+
+func TestAddPathDependency(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldWd) }()
+
+	libDir := filepath.Join(tmpDir, "mylib")
+	appDir := filepath.Join(tmpDir, "app")
+	require.NoError(t, os.MkdirAll(libDir, 0755))
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "CMakeLists.txt"), []byte("project(mylib VERSION 1.0.0 LANGUAGES CXX)\nadd_library(mylib src/mylib.cpp)\nadd_library(mylib::mylib ALIAS mylib)\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "vcpkg.json"), []byte(`{"dependencies": ["fmt"]}`), 0644))
+	appCMake := "project(app VERSION 1.0.0 LANGUAGES CXX)\nadd_executable(app src/main.cpp)\n"
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "CMakeLists.txt"), []byte(appCMake), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "vcpkg.json"), []byte(`{"dependencies": ["fmt"]}`), 0644))
+	require.NoError(t, os.Chdir(appDir))
+
+	builder := New()
+	require.NoError(t, builder.AddPathDependency(context.Background(), "../mylib"))
+
+	data, err := os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "add_subdirectory(../mylib ${CMAKE_BINARY_DIR}/_deps/mylib EXCLUDE_FROM_ALL)\n")
+	assert.Contains(t, string(data), "target_link_libraries(app PRIVATE mylib::mylib)\n")
+
+	err = builder.AddPathDependency(context.Background(), "../mylib")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already a dependency")
+	assert.Error(t, builder.AddPathDependency(context.Background(), "."))
+	assert.Error(t, builder.AddPathDependency(context.Background(), "../missing"))
+
+	require.NoError(t, builder.RemoveDependency(context.Background(), "mylib"))
+	data, err = os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Equal(t, appCMake, string(data))
+}
+
+func TestAddSubmoduleDependency(t *testing.T) {
+	t.Chdir(t.TempDir())
+	appCMake := "project(app VERSION 1.0.0 LANGUAGES CXX)\nadd_executable(app src/main.cpp)\n"
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte(appCMake), 0644))
+	dir := filepath.Join("third_party", "json")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "CMakeLists.txt"), []byte("project(nlohmann_json VERSION 3.11.3 LANGUAGES CXX)\nadd_library(nlohmann_json INTERFACE)\n"), 0644))
+
+	builder := New()
+	require.NoError(t, builder.AddSubmoduleDependency(context.Background(), "json", "third_party/json"))
+	data, err := os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "add_subdirectory(third_party/json ${CMAKE_BINARY_DIR}/_deps/json EXCLUDE_FROM_ALL)\n")
+	assert.NotContains(t, string(data), "target_link_libraries(app")
+
+	assert.Error(t, builder.AddSubmoduleDependency(context.Background(), "json", "third_party/json"), "already a dependency")
+	assert.Error(t, builder.AddSubmoduleDependency(context.Background(), "missing", "third_party/missing"))
+
+	require.NoError(t, builder.RemoveDependency(context.Background(), "json"))
+	data, err = os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Equal(t, appCMake, string(data))
+}
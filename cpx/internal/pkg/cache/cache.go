@@ -0,0 +1,173 @@
+// Package cache measures and prunes the directories cpx and the build
+// systems fill up: build trees, vcpkg installs and archives, Bazel output
+// bases and downloaded registries.
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Scopes of a cache entry
+const (
+	ScopeProject = "project"
+	ScopeGlobal  = "global"
+)
+
+// Entry is one cache directory.
+type Entry struct {
+	Name     string    `json:"name"`
+	Scope    string    `json:"scope"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+	// Keep marks entries that are reported but never removed by clean or
+	// gc, such as installed templates.
+	Keep bool `json:"keep,omitempty"`
+	// PerFile makes gc prune the files inside the directory rather than the
+	// directory itself, e.g. the vcpkg binary cache archives.
+	PerFile bool `json:"-"`
+}
+
+// Item is something gc can remove: a whole cache entry or, for PerFile
+// entries, one file inside it.
+type Item struct {
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+// Measure returns the total size of the files under path and the most
+// recent modification time among them. Symlinks are not followed.
+func Measure(path string) (int64, time.Time, error) {
+	var size int64
+	var lastUsed time.Time
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files vanishing or unreadable mid-walk are not worth failing over
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		if info.ModTime().After(lastUsed) {
+			lastUsed = info.ModTime()
+		}
+		return nil
+	})
+	return size, lastUsed, err
+}
+
+// Files lists the regular files under dir as gc items.
+func Files(dir string) ([]Item, error) {
+	var items []Item
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		items = append(items, Item{Path: path, Size: info.Size(), LastUsed: info.ModTime()})
+		return nil
+	})
+	return items, err
+}
+
+// GC selects the items to remove: everything not used within maxAge, then
+// the least recently used items until the rest fit in maxSize. A zero
+// maxAge or maxSize disables that limit.
+func GC(items []Item, now time.Time, maxAge time.Duration, maxSize int64) []Item {
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b Item) int { return a.LastUsed.Compare(b.LastUsed) })
+
+	var total int64
+	for _, item := range sorted {
+		total += item.Size
+	}
+
+	var remove []Item
+	for _, item := range sorted {
+		expired := maxAge > 0 && now.Sub(item.LastUsed) > maxAge
+		tooBig := maxSize > 0 && total > maxSize
+		if !expired && !tooBig {
+			continue
+		}
+		remove = append(remove, item)
+		total -= item.Size
+	}
+	return remove
+}
+
+// ParseSize parses sizes like "500MB", "20G" or "1.5GiB". Units are
+// powers of 1024; a plain number is bytes.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 500MB or 20GB)", s)
+	}
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "B"), "I")
+	exp := 0
+	if unit != "" {
+		exp = strings.Index("KMGT", unit) + 1
+		if exp == 0 || len(unit) > 1 {
+			return 0, fmt.Errorf("invalid size unit in %q (use B, KB, MB, GB or TB)", s)
+		}
+	}
+	for range exp {
+		n *= 1024
+	}
+	return int64(n), nil
+}
+
+// ParseAge parses ages like "30d", "2w" or any Go duration such as "12h".
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(s, suffix); ok {
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", s)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", s)
+	}
+	return d, nil
+}
+
+// Remove deletes path. Bazel makes its output base read-only, so write
+// permission is restored first when a plain removal fails.
+func Remove(path string) error {
+	if err := os.RemoveAll(path); err == nil {
+		return nil
+	}
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = os.Chmod(p, 0755)
+		}
+		return nil
+	})
+	return os.RemoveAll(path)
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasure(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 50), 0644))
+	newest := time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "sub", "b"), newest, newest))
+
+	size, lastUsed, err := Measure(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), size)
+	assert.True(t, lastUsed.Equal(newest))
+}
+
+func TestGC(t *testing.T) {
+	now := time.Now()
+	items := []Item{
+		{Path: "new", Size: 10, LastUsed: now.Add(-time.Hour)},
+		{Path: "old", Size: 10, LastUsed: now.Add(-40 * 24 * time.Hour)},
+		{Path: "mid", Size: 30, LastUsed: now.Add(-5 * 24 * time.Hour)},
+	}
+
+	paths := func(items []Item) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.Path)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"old"}, paths(GC(items, now, 30*24*time.Hour, 0)))
+	assert.Equal(t, []string{"old", "mid"}, paths(GC(items, now, 0, 15)))
+	assert.Equal(t, []string{"old"}, paths(GC(items, now, 0, 40)))
+	assert.Empty(t, GC(items, now, 0, 0))
+}
+
+func TestParseSize(t *testing.T) {
+	for input, want := range map[string]int64{
+		"100":    100,
+		"1KB":    1024,
+		"500MB":  500 << 20,
+		"20G":    20 << 30,
+		"1.5GiB": 3 << 29,
+	} {
+		got, err := ParseSize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"", "GB", "10XB", "-1GB"} {
+		_, err := ParseSize(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	} {
+		got, err := ParseAge(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	_, err := ParseAge("soon")
+	assert.Error(t, err)
+}
@@ -0,0 +1,204 @@
+// Package container drives the container CLI that runs docker toolchains.
+//
+// cpx speaks docker's command line, which podman and nerdctl accept as
+// well; Runtime covers the few places where they differ.
+package container
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// Supported container CLIs, in the order Detect prefers them.
+const (
+	Docker  = "docker"
+	Podman  = "podman"
+	Nerdctl = "nerdctl"
+)
+
+// Names are the supported container CLIs.
+var Names = []string{Docker, Podman, Nerdctl}
+
+// CommandFunc creates a command, like exec.Command.
+type CommandFunc func(name string, arg ...string) *exec.Cmd
+
+// Image is a local container image.
+type Image struct {
+	Repository   string
+	Tag          string
+	ID           string
+	Size         string
+	Created      string
+	Architecture string
+}
+
+// FullName returns the full image name (repo:tag).
+func (i Image) FullName() string {
+	if i.Tag == "" || i.Tag == "<none>" {
+		return i.Repository
+	}
+	return i.Repository + ":" + i.Tag
+}
+
+// Runtime is a docker-compatible container CLI.
+type Runtime interface {
+	// Name is the CLI's executable, e.g. podman.
+	Name() string
+
+	// Command returns a command running the CLI with args.
+	Command(args ...string) *exec.Cmd
+
+	// ImageExists reports whether image is available locally.
+	ImageExists(image string) bool
+
+	// Images lists the local images, without dangling ones.
+	Images() ([]Image, error)
+
+	// Pull pulls image for platform (the host's if empty), writing the
+	// CLI's progress to out.
+	Pull(image, platform string, out io.Writer) error
+
+	// Login logs in to registry, like docker login.
+	Login(registry, username, password string) error
+}
+
+// New returns the runtime of the CLI name, running its commands through
+// command (exec.Command if nil).
+func New(name string, command CommandFunc) Runtime {
+	if command == nil {
+		command = exec.Command
+	}
+	return &cli{name: name, command: command}
+}
+
+// Detect picks the container CLI: configured if set, otherwise the first of
+// docker, podman and nerdctl that lookPath finds. Without any, it returns
+// docker so errors name the CLI most users expect.
+func Detect(configured string, lookPath func(string) (string, error)) string {
+	if configured != "" {
+		return configured
+	}
+	for _, name := range Names {
+		if _, err := lookPath(name); err == nil {
+			return name
+		}
+	}
+	return Docker
+}
+
+// Default returns the runtime set by container_runtime in the global config,
+// or the detected one.
+func Default() Runtime {
+	return New(Detect(config.ContainerRuntime(), exec.LookPath), nil)
+}
+
+type cli struct {
+	name    string
+	command CommandFunc
+}
+
+func (c *cli) Name() string {
+	return c.name
+}
+
+func (c *cli) Command(args ...string) *exec.Cmd {
+	return c.command(c.name, args...)
+}
+
+func (c *cli) ImageExists(image string) bool {
+	if c.name == Podman {
+		// podman images -q also matches short names against other registries
+		return c.Command("image", "exists", image).Run() == nil
+	}
+	out, err := c.Command("images", "-q", image).Output()
+	return err == nil && len(strings.TrimSpace(string(out))) > 0
+}
+
+func (c *cli) Images() ([]Image, error) {
+	out, err := c.Command("images", "--format", "{{.Repository}}\t{{.Tag}}\t{{.ID}}\t{{.Size}}\t{{.CreatedSince}}").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var images []Image
+	var ids []string
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 5 || parts[0] == "<none>" {
+			continue
+		}
+		images = append(images, Image{Repository: parts[0], Tag: parts[1], ID: parts[2], Size: parts[3], Created: parts[4]})
+		ids = append(ids, parts[2])
+	}
+
+	// Fetch the architectures of all images in one call
+	if len(ids) > 0 {
+		archs := c.architectures(ids)
+		for i := range images {
+			images[i].Architecture = archs[shortID(images[i].ID)]
+		}
+	}
+	return images, nil
+}
+
+func (c *cli) Pull(image, platform string, out io.Writer) error {
+	args := []string{"pull"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	cmd := c.Command(append(args, image)...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s pull %s failed: %w", c.name, image, err)
+	}
+	return nil
+}
+
+func (c *cli) Login(registry, username, password string) error {
+	cmd := c.Command("login", registry, "--username", username, "--password-stdin")
+	cmd.Stdin = strings.NewReader(password)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s login %s failed: %s", c.name, registry, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// architectures maps short image IDs to the images' architectures.
+func (c *cli) architectures(ids []string) map[string]string {
+	archs := make(map[string]string)
+	out, err := c.Command(append([]string{"image", "inspect", "--format", "{{.Id}}\t{{.Architecture}}"}, ids...)...).Output()
+	if err != nil {
+		return archs
+	}
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		if id, arch, ok := strings.Cut(line, "\t"); ok {
+			archs[shortID(id)] = arch
+		}
+	}
+	return archs
+}
+
+// shortID returns the 12 character form of an image ID, which docker
+// prefixes with sha256: and podman does not.
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return id
+}
+
+// Registry returns the registry host of an image reference: its first
+// component when that names a host, docker.io otherwise.
+func Registry(image string) string {
+	host, rest, ok := strings.Cut(image, "/")
+	if ok && rest != "" && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host
+	}
+	return "docker.io"
+}
@@ -0,0 +1,78 @@
+package container
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCLI answers the commands of a container CLI with canned output and
+// records them.
+func fakeCLI(t *testing.T, outputs map[string]string) (CommandFunc, *[]string) {
+	var calls []string
+	return func(name string, arg ...string) *exec.Cmd {
+		call := name + " " + strings.Join(arg, " ")
+		calls = append(calls, call)
+		for prefix, out := range outputs {
+			if strings.HasPrefix(call, prefix) {
+				return exec.Command("printf", "%s", out)
+			}
+		}
+		return exec.Command("false")
+	}, &calls
+}
+
+func TestDetect(t *testing.T) {
+	only := func(names ...string) func(string) (string, error) {
+		return func(file string) (string, error) {
+			for _, name := range names {
+				if name == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+	assert.Equal(t, Docker, Detect("", only(Docker, Podman)))
+	assert.Equal(t, Podman, Detect("", only(Podman, Nerdctl)))
+	assert.Equal(t, Nerdctl, Detect("", only(Nerdctl)))
+	assert.Equal(t, Docker, Detect("", only()))
+	assert.Equal(t, Nerdctl, Detect(Nerdctl, only(Docker)))
+}
+
+func TestImages(t *testing.T) {
+	command, calls := fakeCLI(t, map[string]string{
+		"podman images --format":  "docker.io/library/gcc\t14\t0123456789abcdef\t1.4GB\t2 weeks ago\n<none>\t<none>\tfedcba987654\t10MB\t3 weeks ago\n",
+		"podman image inspect":    "0123456789abcdef0123\tarm64\n",
+		"podman image exists gcc": "",
+	})
+	rt := New(Podman, command)
+	assert.Equal(t, Podman, rt.Name())
+
+	images, err := rt.Images()
+	require.NoError(t, err)
+	assert.Equal(t, []Image{{Repository: "docker.io/library/gcc", Tag: "14", ID: "0123456789abcdef", Size: "1.4GB", Created: "2 weeks ago", Architecture: "arm64"}}, images)
+	assert.Equal(t, "docker.io/library/gcc:14", images[0].FullName())
+
+	assert.True(t, rt.ImageExists("gcc:14"))
+	assert.False(t, rt.ImageExists("clang:18"))
+	assert.Equal(t, "podman image exists clang:18", (*calls)[len(*calls)-1])
+
+	// docker prints no image ID for a missing image
+	command, _ = fakeCLI(t, map[string]string{"docker images -q gcc": "0123456789ab\n", "docker images -q clang": ""})
+	rt = New(Docker, command)
+	assert.True(t, rt.ImageExists("gcc:14"))
+	assert.False(t, rt.ImageExists("clang:18"))
+}
+
+func TestRegistry(t *testing.T) {
+	assert.Equal(t, "docker.io", Registry("gcc:14"))
+	assert.Equal(t, "docker.io", Registry("acme/gcc:14"))
+	assert.Equal(t, "ghcr.io", Registry("ghcr.io/acme/gcc:14"))
+	assert.Equal(t, "localhost:5000", Registry("localhost:5000/gcc"))
+	assert.Equal(t, "localhost", Registry("localhost/gcc"))
+}
@@ -0,0 +1,100 @@
+package ide
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// clangdHeader starts the .clangd files cpx writes, so they can be
+// told from hand-written ones
+const clangdHeader = "# Generated by cpx ide clangd; run it again after changing dependencies.\n"
+
+// clangdRemoved are GCC flags clangd does not understand, which a compile
+// database produced with GCC contains
+var clangdRemoved = []string{
+	"-fmodules-ts",
+	"-fmodule-mapper=*",
+	"-fdeps-format=*",
+	"-fconcepts-diagnostics-depth=*",
+	"-fno-canonical-system-headers",
+}
+
+// ClangdOptions configures WriteClangd.
+type ClangdOptions struct {
+	// CompilationDatabase is the directory with compile_commands.json,
+	// relative to the project root
+	CompilationDatabase string
+
+	// CppStandard is added as -std=c++N when not 0, for headers and files
+	// the database does not list
+	CppStandard int
+
+	// SystemIncludes are added with -isystem: vcpkg's installed headers
+	// and the compiler's, as cpx lint passes them to clang-tidy
+	SystemIncludes []string
+}
+
+type clangdConfig struct {
+	CompileFlags struct {
+		CompilationDatabase string   `yaml:"CompilationDatabase"`
+		Add                 []string `yaml:"Add,omitempty"`
+		Remove              []string `yaml:"Remove"`
+	} `yaml:"CompileFlags"`
+}
+
+// WriteClangd writes the .clangd file of root and returns its path. A
+// .clangd cpx did not write is only replaced with force.
+func WriteClangd(root string, opts ClangdOptions, force bool) (string, error) {
+	path := filepath.Join(root, ".clangd")
+	if data, err := os.ReadFile(path); err == nil && !force && !strings.HasPrefix(string(data), clangdHeader) {
+		return "", fmt.Errorf("%s was not written by cpx\n  hint: use --force to replace it", path)
+	}
+
+	var config clangdConfig
+	config.CompileFlags.CompilationDatabase = filepath.ToSlash(opts.CompilationDatabase)
+	if opts.CppStandard != 0 {
+		config.CompileFlags.Add = append(config.CompileFlags.Add, fmt.Sprintf("-std=c++%d", opts.CppStandard))
+	}
+	for _, dir := range opts.SystemIncludes {
+		config.CompileFlags.Add = append(config.CompileFlags.Add, "-isystem"+filepath.ToSlash(dir))
+	}
+	config.CompileFlags.Remove = clangdRemoved
+
+	var buf bytes.Buffer
+	buf.WriteString(clangdHeader)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(config); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// VcpkgIncludeDirs returns the include directories of the triplets
+// installed in a vcpkg_installed directory.
+func VcpkgIncludeDirs(installedDir string) []string {
+	entries, err := os.ReadDir(installedDir)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		// vcpkg_installed/vcpkg holds vcpkg's own bookkeeping
+		if !e.IsDir() || e.Name() == "vcpkg" {
+			continue
+		}
+		include := filepath.Join(installedDir, e.Name(), "include")
+		if info, err := os.Stat(include); err == nil && info.IsDir() {
+			dirs = append(dirs, include)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,76 @@
+package ide
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteClangd(t *testing.T) {
+	root := t.TempDir()
+	path, err := WriteClangd(root, ClangdOptions{
+		CompilationDatabase: filepath.Join(".cache", "native", "debug"),
+		CppStandard:         20,
+		SystemIncludes:      []string{"/vcpkg_installed/x64-linux/include"},
+	}, false)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var config clangdConfig
+	require.NoError(t, yaml.Unmarshal(data, &config))
+	assert.Equal(t, ".cache/native/debug", config.CompileFlags.CompilationDatabase)
+	assert.Equal(t, []string{"-std=c++20", "-isystem/vcpkg_installed/x64-linux/include"}, config.CompileFlags.Add)
+	assert.Contains(t, config.CompileFlags.Remove, "-fmodules-ts")
+
+	// Regenerating replaces cpx's own file
+	_, err = WriteClangd(root, ClangdOptions{CompilationDatabase: "."}, false)
+	assert.NoError(t, err)
+}
+
+func TestWriteClangdKeepsHandWrittenFile(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ".clangd")
+	require.NoError(t, os.WriteFile(path, []byte("Diagnostics:\n  UnusedIncludes: Strict\n"), 0644))
+
+	_, err := WriteClangd(root, ClangdOptions{CompilationDatabase: "."}, false)
+	assert.Error(t, err)
+
+	_, err = WriteClangd(root, ClangdOptions{CompilationDatabase: "."}, true)
+	assert.NoError(t, err)
+}
+
+func TestVcpkgIncludeDirs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "x64-linux", "include"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "vcpkg", "info"), 0755))
+
+	assert.Equal(t, []string{filepath.Join(dir, "x64-linux", "include")}, VcpkgIncludeDirs(dir))
+}
+
+func TestDetectCppStandard(t *testing.T) {
+	tests := []struct {
+		file, content string
+		want          int
+	}{
+		{"CMakeLists.txt", "set(CMAKE_CXX_STANDARD 20)\n", 20},
+		{"CMakeLists.txt", "target_compile_features(app PRIVATE cxx_std_17)\n", 17},
+		{"meson.build", "project('app', 'cpp', default_options: ['cpp_std=c++23'])\n", 23},
+		{".bazelrc", "build --cxxopt=-std=c++17\n", 17},
+		{"CMakeLists.txt", "project(app)\n", 0},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, tt.file), []byte(tt.content), 0644))
+		assert.Equal(t, tt.want, DetectCppStandard(root), tt.content)
+	}
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "CMakeLists.txt"), []byte("set(CMAKE_CXX_STANDARD 17)\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "cpx.yaml"), []byte("cxx_standard: 23\n"), 0644))
+	assert.Equal(t, 23, DetectCppStandard(root), "cpx.yaml takes precedence")
+}
@@ -0,0 +1,145 @@
+package ide
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+)
+
+// clionSettings is .idea/cmake.xml, where CLion keeps the CMake profiles
+// shared through version control
+type clionSettings struct {
+	XMLName   xml.Name `xml:"project"`
+	Version   string   `xml:"version,attr"`
+	Component struct {
+		Name           string               `xml:"name,attr"`
+		Configurations []clionConfiguration `xml:"configurations>configuration"`
+	} `xml:"component"`
+}
+
+// clionConfiguration is one CMake profile; attributes cpx does not set are
+// kept as they are
+type clionConfiguration struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+func (c clionConfiguration) attr(name string) string {
+	for _, a := range c.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// WriteCLion writes a CLion CMake profile per profile to .idea/cmake.xml
+// in root, building in the same directories with the same arguments as
+// cpx build. Profiles cpx wrote before are replaced; the user's own are
+// kept. It returns the file written.
+func WriteCLion(root string, profiles []vcpkg.CMakeProfile) (string, error) {
+	path := filepath.Join(root, ".idea", "cmake.xml")
+	var settings clionSettings
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := xml.Unmarshal(data, &settings); err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return "", err
+	}
+	settings.Version = "4"
+	settings.Component.Name = "CMakeSharedSettings"
+
+	var configs []clionConfiguration
+	for _, c := range settings.Component.Configurations {
+		if !strings.HasPrefix(c.attr("PROFILE_NAME"), generatedPrefix) {
+			configs = append(configs, c)
+		}
+	}
+	for _, p := range profiles {
+		configs = append(configs, clionConfiguration{Attrs: []xml.Attr{
+			{Name: xml.Name{Local: "PROFILE_NAME"}, Value: generatedPrefix + p.Name},
+			{Name: xml.Name{Local: "ENABLED"}, Value: "true"},
+			{Name: xml.Name{Local: "CONFIG_NAME"}, Value: p.BuildType},
+			{Name: xml.Name{Local: "GENERATION_DIR"}, Value: filepath.ToSlash(p.BuildDir)},
+			{Name: xml.Name{Local: "GENERATION_OPTIONS"}, Value: joinArgs(p.Args)},
+		}})
+	}
+	settings.Component.Configurations = configs
+
+	out, err := xml.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append([]byte(xml.Header), append(out, '\n')...), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// joinArgs joins command line arguments, quoting those with spaces.
+func joinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if strings.ContainsAny(a, " \t") {
+			a = `"` + a + `"`
+		}
+		quoted[i] = a
+	}
+	return strings.Join(quoted, " ")
+}
+
+// presetPrefix names the CMake presets cpx writes; preset names are used
+// on the command line, so they avoid generatedPrefix's space
+const presetPrefix = "cpx-"
+
+// WriteUserPresets writes a configure and a build preset per profile to
+// CMakeUserPresets.json in root, which Qt Creator, CLion and Visual Studio
+// offer as build configurations and "cmake --preset cpx-debug" accepts.
+// Presets cpx wrote before are replaced; the user's own are kept. It
+// returns the file written.
+func WriteUserPresets(root string, profiles []vcpkg.CMakeProfile) (string, error) {
+	var configure, builds []any
+	for _, p := range profiles {
+		preset := map[string]any{
+			"name":        presetPrefix + p.Name,
+			"displayName": "cpx " + p.Name,
+			"binaryDir":   "${sourceDir}/" + filepath.ToSlash(p.BuildDir),
+		}
+		vars := map[string]string{}
+		for i := 0; i < len(p.Args); i++ {
+			switch a := p.Args[i]; {
+			case a == "-G" && i+1 < len(p.Args):
+				i++
+				preset["generator"] = p.Args[i]
+			case strings.HasPrefix(a, "-D"):
+				key, value, _ := strings.Cut(strings.TrimPrefix(a, "-D"), "=")
+				vars[key] = value
+			}
+		}
+		preset["cacheVariables"] = vars
+		configure = append(configure, preset)
+		builds = append(builds, map[string]any{
+			"name":            presetPrefix + p.Name,
+			"configurePreset": presetPrefix + p.Name,
+		})
+	}
+
+	path := filepath.Join(root, "CMakeUserPresets.json")
+	fields := map[string]any{"version": 3}
+	if err := mergeEntries(path, "configurePresets", "name", presetPrefix, fields, configure); err != nil {
+		return "", err
+	}
+	if err := mergeEntries(path, "buildPresets", "name", presetPrefix, fields, builds); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,68 @@
+package ide
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/build/vcpkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testProfiles = []vcpkg.CMakeProfile{
+	{
+		Name:      "debug",
+		BuildDir:  filepath.Join(".cache", "native", "debug"),
+		BuildType: "Debug",
+		Args:      []string{"-DCMAKE_BUILD_TYPE=Debug", "-G", "Ninja", "-DCMAKE_CXX_FLAGS=-O0 -g"},
+	},
+}
+
+func TestWriteCLion(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ".idea", "cmake.xml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<project version="4">
+  <component name="CMakeSharedSettings">
+    <configurations>
+      <configuration PROFILE_NAME="Mine" ENABLED="true" CONFIG_NAME="Debug" TOOLCHAIN_NAME="WSL" />
+      <configuration PROFILE_NAME="cpx: old" ENABLED="true" CONFIG_NAME="Debug" />
+    </configurations>
+  </component>
+</project>
+`), 0644))
+
+	_, err := WriteCLion(root, testProfiles)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, `PROFILE_NAME="Mine"`)
+	assert.Contains(t, content, `TOOLCHAIN_NAME="WSL"`)
+	assert.NotContains(t, content, "cpx: old")
+	assert.Contains(t, content, `PROFILE_NAME="cpx: debug"`)
+	assert.Contains(t, content, `GENERATION_DIR=".cache/native/debug"`)
+	assert.Contains(t, content, `GENERATION_OPTIONS="-DCMAKE_BUILD_TYPE=Debug -G Ninja &#34;-DCMAKE_CXX_FLAGS=-O0 -g&#34;"`)
+}
+
+func TestWriteUserPresets(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "CMakeUserPresets.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 6, "configurePresets": [{"name": "mine", "inherits": "default"}]}`), 0644))
+
+	_, err := WriteUserPresets(root, testProfiles)
+	require.NoError(t, err)
+
+	doc := readJSON(t, path)
+	assert.EqualValues(t, 6, doc["version"])
+	assert.Equal(t, []string{"mine", "cpx-debug"}, names(t, doc, "configurePresets", "name"))
+	assert.Equal(t, []string{"cpx-debug"}, names(t, doc, "buildPresets", "name"))
+
+	preset := doc["configurePresets"].([]any)[1].(map[string]any)
+	assert.Equal(t, "${sourceDir}/.cache/native/debug", preset["binaryDir"])
+	assert.Equal(t, "Ninja", preset["generator"])
+	assert.Equal(t, map[string]any{"CMAKE_BUILD_TYPE": "Debug", "CMAKE_CXX_FLAGS": "-O0 -g"}, preset["cacheVariables"])
+}
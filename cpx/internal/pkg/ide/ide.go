@@ -0,0 +1,131 @@
+// Package ide writes editor and IDE project settings that point at cpx's
+// build: the compile database, the built executables and cpx commands.
+package ide
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// generatedPrefix marks the entries cpx writes into settings files that
+// also hold the user's own, so regenerating replaces only cpx's.
+const generatedPrefix = "cpx: "
+
+// mergeEntries reads the JSON object in path, replaces the entries of its
+// key array whose nameKey starts with prefix by entries and writes it
+// back; fields holds the object's defaults when the file is new. Comments
+// and trailing commas, which VS Code allows, are accepted but not preserved.
+func mergeEntries(path, key, nameKey, prefix string, fields map[string]any, entries []any) error {
+	doc := map[string]any{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(stripJSONComments(data), &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case os.IsNotExist(err):
+		for k, v := range fields {
+			doc[k] = v
+		}
+	default:
+		return err
+	}
+
+	merged := []any{}
+	existing, _ := doc[key].([]any)
+	for _, e := range existing {
+		obj, ok := e.(map[string]any)
+		if name, _ := obj[nameKey].(string); ok && strings.HasPrefix(name, prefix) {
+			continue
+		}
+		merged = append(merged, e)
+	}
+	doc[key] = append(merged, entries...)
+	return writeJSON(path, doc)
+}
+
+// writeJSON writes v to path as indented JSON, creating its directory.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// stripJSONComments turns JSON with comments (JSONC) into JSON: // and
+// /* */ comments outside strings are removed, then trailing commas.
+func stripJSONComments(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return dropTrailingCommas(out.Bytes())
+}
+
+// dropTrailingCommas removes the commas before } and ] in JSON without
+// comments.
+func dropTrailingCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			if c == '\\' && i+1 < len(data) {
+				out = append(out, c)
+				i++
+				c = data[i]
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			rest := bytes.TrimLeft(data[i+1:], " \t\r\n")
+			if len(rest) > 0 && (rest[0] == '}' || rest[0] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
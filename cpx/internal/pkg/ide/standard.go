@@ -0,0 +1,41 @@
+package ide
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// standardPatterns find the C++ standard in the build files cpx generates
+var standardPatterns = []struct {
+	file    string
+	pattern *regexp.Regexp
+}{
+	{"CMakeLists.txt", regexp.MustCompile(`set\s*\(\s*CMAKE_CXX_STANDARD\s+(\d+)`)},
+	{"CMakeLists.txt", regexp.MustCompile(`cxx_std_(\d+)`)},
+	{"meson.build", regexp.MustCompile(`cpp_std\s*=\s*(?:c|gnu)\+\+(\d+)`)},
+	{".bazelrc", regexp.MustCompile(`-std=(?:c|gnu)\+\+(\d+)`)},
+}
+
+// DetectCppStandard returns the C++ standard the project in root builds
+// with, such as 20: the cxx_standard of its cpx.yaml, else the one its
+// build files set, or 0 when they do not say.
+func DetectCppStandard(root string) int {
+	if manifest := config.ProjectManifest(root); manifest != nil && manifest.CxxStandard != 0 {
+		return manifest.CxxStandard
+	}
+	for _, p := range standardPatterns {
+		data, err := os.ReadFile(filepath.Join(root, p.file))
+		if err != nil {
+			continue
+		}
+		if m := p.pattern.FindSubmatch(data); m != nil {
+			std, _ := strconv.Atoi(string(m[1]))
+			return std
+		}
+	}
+	return 0
+}
@@ -0,0 +1,114 @@
+package ide
+
+import (
+	"path/filepath"
+)
+
+// VSCodeOptions configures WriteVSCode.
+type VSCodeOptions struct {
+	// CompileDatabase is the compile_commands.json IntelliSense reads,
+	// relative to the project root
+	CompileDatabase string
+
+	// Executables get a debug configuration each; paths are relative to
+	// the project root
+	Executables []string
+
+	// Debugger is gdb or lldb
+	Debugger string
+}
+
+// vscodeTask is one entry of tasks.json
+type vscodeTask struct {
+	Label          string         `json:"label"`
+	Type           string         `json:"type"`
+	Command        string         `json:"command"`
+	Args           []string       `json:"args"`
+	Group          map[string]any `json:"group,omitempty"`
+	ProblemMatcher []string       `json:"problemMatcher"`
+}
+
+// vscodeLaunch is one entry of launch.json
+type vscodeLaunch struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	Request       string   `json:"request"`
+	Program       string   `json:"program"`
+	Args          []string `json:"args"`
+	Cwd           string   `json:"cwd"`
+	MIMode        string   `json:"MIMode"`
+	PreLaunchTask string   `json:"preLaunchTask"`
+}
+
+// vscodeBuildTask is the task launch configurations build with first
+const vscodeBuildTask = generatedPrefix + "build"
+
+// WriteVSCode writes c_cpp_properties.json, tasks.json and launch.json to
+// the .vscode directory of root. Entries cpx wrote before are replaced;
+// the user's own are kept. It returns the files written.
+func WriteVSCode(root string, opts VSCodeOptions) ([]string, error) {
+	dir := filepath.Join(root, ".vscode")
+	var written []string
+
+	properties := filepath.Join(dir, "c_cpp_properties.json")
+	if err := mergeEntries(properties, "configurations", "name", generatedPrefix, map[string]any{"version": 4}, []any{
+		map[string]any{
+			"name":            generatedPrefix + "compile database",
+			"compileCommands": "${workspaceFolder}/" + filepath.ToSlash(opts.CompileDatabase),
+		},
+	}); err != nil {
+		return written, err
+	}
+	written = append(written, properties)
+
+	tasks := filepath.Join(dir, "tasks.json")
+	if err := mergeEntries(tasks, "tasks", "label", generatedPrefix, map[string]any{"version": "2.0.0"}, vscodeTasks()); err != nil {
+		return written, err
+	}
+	written = append(written, tasks)
+
+	launch := filepath.Join(dir, "launch.json")
+	var configs []any
+	for _, exe := range opts.Executables {
+		configs = append(configs, vscodeLaunch{
+			Name:          generatedPrefix + "debug " + filepath.Base(exe),
+			Type:          "cppdbg",
+			Request:       "launch",
+			Program:       "${workspaceFolder}/" + filepath.ToSlash(exe),
+			Args:          []string{},
+			Cwd:           "${workspaceFolder}",
+			MIMode:        opts.Debugger,
+			PreLaunchTask: vscodeBuildTask,
+		})
+	}
+	if err := mergeEntries(launch, "configurations", "name", generatedPrefix, map[string]any{"version": "0.2.0"}, configs); err != nil {
+		return written, err
+	}
+	written = append(written, launch)
+
+	return written, nil
+}
+
+// vscodeTasks wraps cpx build, test and run
+func vscodeTasks() []any {
+	task := func(label string, args []string, group string, isDefault bool) vscodeTask {
+		t := vscodeTask{
+			Label:          generatedPrefix + label,
+			Type:           "shell",
+			Command:        "cpx",
+			Args:           args,
+			ProblemMatcher: []string{"$gcc"},
+		}
+		if group != "" {
+			t.Group = map[string]any{"kind": group, "isDefault": isDefault}
+		}
+		return t
+	}
+	return []any{
+		task("build", []string{"build"}, "build", true),
+		task("build release", []string{"build", "--release"}, "build", false),
+		task("test", []string{"test"}, "test", true),
+		task("run", []string{"run"}, "", false),
+		task("clean", []string{"clean"}, "", false),
+	}
+}
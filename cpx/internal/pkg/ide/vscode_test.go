@@ -0,0 +1,87 @@
+package ide
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readJSON(t *testing.T, path string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	doc := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	return doc
+}
+
+func names(t *testing.T, doc map[string]any, key, nameKey string) []string {
+	t.Helper()
+	var out []string
+	for _, e := range doc[key].([]any) {
+		out = append(out, e.(map[string]any)[nameKey].(string))
+	}
+	return out
+}
+
+func TestWriteVSCode(t *testing.T) {
+	root := t.TempDir()
+	written, err := WriteVSCode(root, VSCodeOptions{
+		CompileDatabase: "compile_commands.json",
+		Executables:     []string{filepath.Join(".bin", "native", "debug", "app")},
+		Debugger:        "gdb",
+	})
+	require.NoError(t, err)
+	assert.Len(t, written, 3)
+
+	props := readJSON(t, filepath.Join(root, ".vscode", "c_cpp_properties.json"))
+	config := props["configurations"].([]any)[0].(map[string]any)
+	assert.Equal(t, "${workspaceFolder}/compile_commands.json", config["compileCommands"])
+
+	tasks := readJSON(t, filepath.Join(root, ".vscode", "tasks.json"))
+	assert.Contains(t, names(t, tasks, "tasks", "label"), "cpx: build")
+
+	launch := readJSON(t, filepath.Join(root, ".vscode", "launch.json"))
+	configs := launch["configurations"].([]any)
+	require.Len(t, configs, 1)
+	app := configs[0].(map[string]any)
+	assert.Equal(t, "cpx: debug app", app["name"])
+	assert.Equal(t, "${workspaceFolder}/.bin/native/debug/app", app["program"])
+	assert.Equal(t, "gdb", app["MIMode"])
+	assert.Equal(t, "cpx: build", app["preLaunchTask"])
+}
+
+func TestWriteVSCodeKeepsUserEntries(t *testing.T) {
+	root := t.TempDir()
+	launchPath := filepath.Join(root, ".vscode", "launch.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(launchPath), 0755))
+	require.NoError(t, os.WriteFile(launchPath, []byte(`{
+  // Use IntelliSense to learn about possible attributes.
+  "version": "0.2.0",
+  "configurations": [
+    {"name": "attach", "type": "cppdbg", "request": "attach", "program": "/usr/bin/app"},
+    {"name": "cpx: debug old", "type": "cppdbg", "request": "launch", "program": "old"}, /* removed target */
+  ]
+}`), 0644))
+
+	_, err := WriteVSCode(root, VSCodeOptions{Executables: []string{"new"}, Debugger: "lldb"})
+	require.NoError(t, err)
+
+	launch := readJSON(t, launchPath)
+	assert.Equal(t, "0.2.0", launch["version"])
+	assert.Equal(t, []string{"attach", "cpx: debug new"}, names(t, launch, "configurations", "name"))
+}
+
+func TestStripJSONComments(t *testing.T) {
+	in := `{"url": "http://example.com/*x*/", // comment
+  "list": [1, 2,], /* block */ "s": "a\"//b",}`
+	var v map[string]any
+	require.NoError(t, json.Unmarshal(stripJSONComments([]byte(in)), &v))
+	assert.Equal(t, "http://example.com/*x*/", v["url"])
+	assert.Equal(t, `a"//b`, v["s"])
+	assert.Len(t, v["list"], 2)
+}
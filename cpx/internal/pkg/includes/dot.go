@@ -0,0 +1,67 @@
+package includes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// WriteDOT writes the graph in Graphviz DOT format. Translation units are
+// filled, edges on include cycles are red. Headers outside the project are
+// left out unless external is set.
+func (g *Graph) WriteDOT(w io.Writer, external bool) error {
+	keep := func(path string) bool { return external || !g.External(path) }
+
+	onCycle := map[[2]string]bool{}
+	for _, cycle := range g.Cycles() {
+		for i := 0; i+1 < len(cycle); i++ {
+			onCycle[[2]string{cycle[i], cycle[i+1]}] = true
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph includes {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, `  node [shape=box, fontname="Helvetica", fontsize=10];`)
+
+	units := map[string]bool{}
+	for _, u := range g.Units {
+		units[u] = true
+		fmt.Fprintf(bw, "  %s [style=filled, fillcolor=\"#dbeafe\"];\n", strconv.Quote(g.Display(u)))
+	}
+	for _, h := range g.Headers() {
+		if units[h.Path] || !keep(h.Path) {
+			continue
+		}
+		attrs := fmt.Sprintf("tooltip=%s", strconv.Quote(fmt.Sprintf("included by %d, %d bytes", h.Included, h.Size)))
+		if h.External {
+			attrs += ", color=gray, fontcolor=gray"
+		}
+		fmt.Fprintf(bw, "  %s [%s];\n", strconv.Quote(g.Display(h.Path)), attrs)
+	}
+
+	froms := make([]string, 0, len(g.Edges))
+	for from := range g.Edges {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	for _, from := range froms {
+		if !keep(from) {
+			continue
+		}
+		for _, to := range g.Edges[from] {
+			if !keep(to) {
+				continue
+			}
+			attrs := ""
+			if onCycle[[2]string{from, to}] {
+				attrs = " [color=red, penwidth=2]"
+			}
+			fmt.Fprintf(bw, "  %s -> %s%s;\n", strconv.Quote(g.Display(from)), strconv.Quote(g.Display(to)), attrs)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
@@ -0,0 +1,476 @@
+// Package includes builds the include graph of a project from its compile
+// database, finds include cycles and ranks headers by what they cost the
+// build.
+package includes
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// CompileCommand is an entry of compile_commands.json.
+type CompileCommand struct {
+	Directory string   `json:"directory"`
+	File      string   `json:"file"`
+	Command   string   `json:"command,omitempty"`
+	Arguments []string `json:"arguments,omitempty"`
+}
+
+// LoadCompileDatabase reads a compile_commands.json.
+func LoadCompileDatabase(path string) ([]CompileCommand, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cmds []CompileCommand
+	if err := json.Unmarshal(data, &cmds); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cmds, nil
+}
+
+// Graph is the include graph of a set of translation units. Nodes are
+// absolute, cleaned paths.
+type Graph struct {
+	// Root is the project root; files outside it are external.
+	Root string
+
+	// Units are the translation units of the compile database.
+	Units []string
+
+	// Edges maps a file to the files it includes, sorted.
+	Edges map[string][]string
+
+	// Sizes are the file sizes in bytes.
+	Sizes map[string]int64
+
+	// Unresolved counts the includes that were not found in the include
+	// paths, such as standard library headers.
+	Unresolved int
+
+	// included counts the translation units that include a header,
+	// directly or transitively.
+	included map[string]int
+
+	// projectDirs are the project's cache and artifact directories,
+	// relative to Root.
+	projectDirs []string
+}
+
+// HeaderCost is what a header costs the build: it is parsed once for every
+// translation unit that includes it.
+type HeaderCost struct {
+	Path     string
+	Size     int64
+	Included int
+	External bool
+}
+
+// Cost returns the bytes the compiler parses for the header over a build.
+func (h HeaderCost) Cost() int64 {
+	return h.Size * int64(h.Included)
+}
+
+// includeRe matches #include directives. Conditional includes count as
+// well, which over-approximates the graph.
+var includeRe = regexp.MustCompile(`^\s*#\s*include\s*([<"])([^>"]+)[>"]`)
+
+// externalDirs are project directories holding build outputs and
+// third-party code.
+var externalDirs = map[string]bool{
+	"build":           true,
+	"builddir":        true,
+	"subprojects":     true,
+	"external":        true,
+	"third_party":     true,
+	".vcpkg":          true,
+	"vcpkg_installed": true,
+}
+
+type include struct {
+	name   string
+	quoted bool
+}
+
+type searchPaths struct {
+	quote  []string
+	angled []string
+}
+
+// Build parses the translation units of cmds and the headers they include.
+func Build(cmds []CompileCommand, root string) (*Graph, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	g := &Graph{
+		Root:        root,
+		Edges:       map[string][]string{},
+		Sizes:       map[string]int64{},
+		included:    map[string]int{},
+		projectDirs: config.DirsOf(root).Within(root),
+	}
+	b := &builder{graph: g, directives: map[string][]include{}, edges: map[string]map[string]bool{}, resolved: map[string]string{}}
+
+	seen := map[string]bool{}
+	for _, cmd := range cmds {
+		unit := cmd.File
+		if !filepath.IsAbs(unit) {
+			unit = filepath.Join(cmd.Directory, unit)
+		}
+		unit = filepath.Clean(unit)
+		if seen[unit] {
+			continue
+		}
+		seen[unit] = true
+		g.Units = append(g.Units, unit)
+
+		paths := parseSearchPaths(cmd)
+		visited := map[string]bool{unit: true}
+		b.walk(unit, paths, visited)
+		for file := range visited {
+			if file != unit {
+				g.included[file]++
+			}
+		}
+	}
+	sort.Strings(g.Units)
+
+	for from, tos := range b.edges {
+		for to := range tos {
+			g.Edges[from] = append(g.Edges[from], to)
+		}
+		sort.Strings(g.Edges[from])
+	}
+	return g, nil
+}
+
+type builder struct {
+	graph      *Graph
+	directives map[string][]include
+	edges      map[string]map[string]bool
+	resolved   map[string]string
+}
+
+// walk follows the includes of file depth-first, marking what it reaches.
+func (b *builder) walk(file string, paths searchPaths, visited map[string]bool) {
+	for _, inc := range b.includes(file) {
+		target := b.resolve(file, inc, paths)
+		if target == "" {
+			b.graph.Unresolved++
+			continue
+		}
+		if b.edges[file] == nil {
+			b.edges[file] = map[string]bool{}
+		}
+		b.edges[file][target] = true
+		if !visited[target] {
+			visited[target] = true
+			b.walk(target, paths, visited)
+		}
+	}
+}
+
+// includes returns the #include directives of file, read once.
+func (b *builder) includes(file string) []include {
+	if incs, ok := b.directives[file]; ok {
+		return incs
+	}
+	var incs []include
+	f, err := os.Open(file)
+	if err == nil {
+		if info, err := f.Stat(); err == nil {
+			b.graph.Sizes[file] = info.Size()
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if m := includeRe.FindStringSubmatch(scanner.Text()); m != nil {
+				incs = append(incs, include{name: m[2], quoted: m[1] == `"`})
+			}
+		}
+		f.Close()
+	}
+	b.directives[file] = incs
+	return incs
+}
+
+// resolve finds the file an include refers to the way the compiler does:
+// quoted includes are looked up next to the including file first.
+func (b *builder) resolve(from string, inc include, paths searchPaths) string {
+	dirs := paths.angled
+	if inc.quoted {
+		dirs = append(append([]string{filepath.Dir(from)}, paths.quote...), paths.angled...)
+	}
+	key := strings.Join(dirs, "\x00") + "\x00" + inc.name
+	if target, ok := b.resolved[key]; ok {
+		return target
+	}
+	target := ""
+	for _, dir := range dirs {
+		candidate := filepath.Join(dir, inc.name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			target = filepath.Clean(candidate)
+			break
+		}
+	}
+	b.resolved[key] = target
+	return target
+}
+
+// parseSearchPaths extracts the include search paths of a compile command.
+func parseSearchPaths(cmd CompileCommand) searchPaths {
+	args := cmd.Arguments
+	if len(args) == 0 {
+		args = splitCommand(cmd.Command)
+	}
+	abs := func(dir string) string {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(cmd.Directory, dir)
+		}
+		return filepath.Clean(dir)
+	}
+
+	var paths searchPaths
+	var system []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		for _, flag := range []string{"-iquote", "-isystem", "-I"} {
+			if !strings.HasPrefix(arg, flag) {
+				continue
+			}
+			dir := strings.TrimPrefix(arg, flag)
+			if dir == "" && i+1 < len(args) {
+				i++
+				dir = args[i]
+			}
+			if dir == "" {
+				break
+			}
+			switch flag {
+			case "-iquote":
+				paths.quote = append(paths.quote, abs(dir))
+			case "-isystem":
+				system = append(system, abs(dir))
+			default:
+				paths.angled = append(paths.angled, abs(dir))
+			}
+			break
+		}
+	}
+	paths.angled = append(paths.angled, system...)
+	return paths
+}
+
+// splitCommand splits a shell command line into arguments, honoring quotes
+// and backslash escapes.
+func splitCommand(command string) []string {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// Headers returns the headers reached from the translation units, most
+// expensive first.
+func (g *Graph) Headers() []HeaderCost {
+	headers := make([]HeaderCost, 0, len(g.included))
+	for path, n := range g.included {
+		headers = append(headers, HeaderCost{Path: path, Size: g.Sizes[path], Included: n, External: g.External(path)})
+	}
+	sort.Slice(headers, func(i, j int) bool {
+		if headers[i].Cost() != headers[j].Cost() {
+			return headers[i].Cost() > headers[j].Cost()
+		}
+		return headers[i].Path < headers[j].Path
+	})
+	return headers
+}
+
+// External reports whether path is outside the project's own sources.
+func (g *Graph) External(path string) bool {
+	rel, err := filepath.Rel(g.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return true
+	}
+	rel = filepath.ToSlash(rel)
+	for _, dir := range g.projectDirs {
+		if rel == dir || strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	for _, part := range strings.Split(rel, "/") {
+		if externalDirs[part] || strings.HasPrefix(part, "bazel-") {
+			return true
+		}
+	}
+	return false
+}
+
+// Display returns path relative to the project root when it is inside it.
+func (g *Graph) Display(path string) string {
+	rel, err := filepath.Rel(g.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Cycles returns the include cycles of the graph. Each cycle starts and
+// ends with the same file.
+func (g *Graph) Cycles() [][]string {
+	var cycles [][]string
+	for _, scc := range g.components() {
+		if len(scc) == 1 && !g.hasEdge(scc[0], scc[0]) {
+			continue
+		}
+		cycles = append(cycles, g.cycleThrough(scc))
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+func (g *Graph) hasEdge(from, to string) bool {
+	for _, t := range g.Edges[from] {
+		if t == to {
+			return true
+		}
+	}
+	return false
+}
+
+// components returns the strongly connected components of the graph
+// (Tarjan's algorithm), each sorted.
+func (g *Graph) components() [][]string {
+	index := map[string]int{}
+	low := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var sccs [][]string
+	next := 0
+
+	var connect func(v string)
+	connect = func(v string) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.Edges[v] {
+			if _, ok := index[w]; !ok {
+				connect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] == index[v] {
+			var scc []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				scc = append(scc, w)
+				if w == v {
+					break
+				}
+			}
+			sort.Strings(scc)
+			sccs = append(sccs, scc)
+		}
+	}
+
+	nodes := make([]string, 0, len(g.Edges))
+	for n := range g.Edges {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	for _, n := range nodes {
+		if _, ok := index[n]; !ok {
+			connect(n)
+		}
+	}
+	return sccs
+}
+
+// cycleThrough returns a shortest cycle from the first file of a strongly
+// connected component back to itself.
+func (g *Graph) cycleThrough(scc []string) []string {
+	start := scc[0]
+	inSCC := map[string]bool{}
+	for _, n := range scc {
+		inSCC[n] = true
+	}
+
+	prev := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, w := range g.Edges[n] {
+			if !inSCC[w] {
+				continue
+			}
+			if w == start {
+				cycle := []string{start}
+				for at := n; at != start; at = prev[at] {
+					cycle = append(cycle, at)
+				}
+				cycle = append(cycle, start)
+				// Collected backwards from the end of the cycle
+				for i, j := 1, len(cycle)-2; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return cycle
+			}
+			if _, seen := prev[w]; !seen {
+				prev[w] = n
+				queue = append(queue, w)
+			}
+		}
+	}
+	return append(scc, start)
+}
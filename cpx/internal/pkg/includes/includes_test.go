@@ -0,0 +1,122 @@
+package includes
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	assert.Equal(t,
+		[]string{"c++", "-Iinclude", "-DNAME=a b", `-DQ="x"`, "-c", "src/main.cpp"},
+		splitCommand(`c++ -Iinclude "-DNAME=a b" -DQ=\"x\" -c  src/main.cpp`))
+	assert.Equal(t, []string{"a", ""}, splitCommand(`a ''`))
+}
+
+func TestParseSearchPaths(t *testing.T) {
+	paths := parseSearchPaths(CompileCommand{
+		Directory: "/p/build",
+		Command:   "c++ -I../include -isystem /deps/include -iquote quoted -I /abs -include pch.h -c ../src/a.cpp",
+	})
+	assert.Equal(t, []string{"/p/build/quoted"}, paths.quote)
+	assert.Equal(t, []string{"/p/include", "/abs", "/deps/include"}, paths.angled, "system directories are searched last")
+}
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"src/a.cpp":              "#include \"a.h\"\n#include <vector>\n#include <dep/dep.h>\n",
+		"src/b.cpp":              "#include <common.h>\n",
+		"src/a.h":                "#pragma once\n#include <common.h>\n",
+		"include/common.h":       "#pragma once\n  #  include \"util.h\"\nint common;\n",
+		"include/util.h":         "#pragma once\n",
+		"deps/include/dep/dep.h": "// dependency\n",
+	})
+	dir := filepath.Join(root, "build")
+	cmds := []CompileCommand{
+		{Directory: dir, File: "../src/a.cpp", Arguments: []string{"c++", "-I../include", "-isystem", filepath.Join(root, "deps", "include"), "-c", "../src/a.cpp"}},
+		{Directory: dir, File: filepath.Join(root, "src", "b.cpp"), Command: "c++ -I../include -c ../src/b.cpp"},
+		{Directory: dir, File: "../src/b.cpp", Command: "c++ -I../include -c ../src/b.cpp"},
+	}
+
+	g, err := Build(cmds, root)
+	require.NoError(t, err)
+	path := func(name string) string { return filepath.Join(root, filepath.FromSlash(name)) }
+
+	assert.Equal(t, []string{path("src/a.cpp"), path("src/b.cpp")}, g.Units, "units are deduplicated")
+	assert.Equal(t, []string{path("deps/include/dep/dep.h"), path("src/a.h")}, g.Edges[path("src/a.cpp")])
+	assert.Equal(t, []string{path("include/util.h")}, g.Edges[path("include/common.h")])
+	assert.Equal(t, 1, g.Unresolved, "<vector> is not on the include path")
+	assert.Empty(t, g.Cycles())
+
+	headers := g.Headers()
+	require.NotEmpty(t, headers)
+	assert.Equal(t, path("include/common.h"), headers[0].Path)
+	assert.Equal(t, 2, headers[0].Included)
+	assert.Equal(t, 2*headers[0].Size, headers[0].Cost())
+
+	assert.False(t, g.External(path("deps/include/dep/dep.h")), "only known third-party directories are external")
+	assert.True(t, g.External(path("build/vcpkg_installed/x64-linux/include/fmt/core.h")))
+	assert.True(t, g.External("/usr/include/stdio.h"))
+	assert.Equal(t, "src/a.h", g.Display(path("src/a.h")))
+}
+
+func TestExternalProjectDirs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"cpx.yaml": "cache_dir: tmp/cache\n"})
+
+	g, err := Build(nil, root)
+	require.NoError(t, err)
+	assert.True(t, g.External(filepath.Join(root, "tmp", "cache", "native", "gen.h")), "the configured cache directory is external")
+	assert.True(t, g.External(filepath.Join(root, ".bin", "gen.h")))
+	assert.False(t, g.External(filepath.Join(root, ".cache", "gen.h")), "the default cache directory is not used")
+}
+
+func TestCyclesAndDOT(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.cpp": "#include \"a.h\"\n",
+		"a.h":      "#include \"b.h\"\n",
+		"b.h":      "#include \"c.h\"\n#include \"a.h\"\n",
+		"c.h":      "#include \"c.h\"\n",
+	})
+	g, err := Build([]CompileCommand{{Directory: root, File: "main.cpp", Command: "cc -c main.cpp"}}, root)
+	require.NoError(t, err)
+
+	cycles := g.Cycles()
+	require.Len(t, cycles, 2)
+	var names [][]string
+	for _, c := range cycles {
+		var n []string
+		for _, p := range c {
+			n = append(n, g.Display(p))
+		}
+		names = append(names, n)
+	}
+	assert.Equal(t, [][]string{{"a.h", "b.h", "a.h"}, {"c.h", "c.h"}}, names)
+
+	var out bytes.Buffer
+	require.NoError(t, g.WriteDOT(&out, false))
+	dot := out.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph includes {\n"))
+	assert.Contains(t, dot, `"main.cpp" [style=filled`)
+	assert.Contains(t, dot, `"main.cpp" -> "a.h";`)
+	assert.Contains(t, dot, `"b.h" -> "a.h" [color=red, penwidth=2];`)
+	assert.Contains(t, dot, `"b.h" -> "c.h";`)
+	assert.Contains(t, dot, `"c.h" -> "c.h" [color=red, penwidth=2];`)
+}
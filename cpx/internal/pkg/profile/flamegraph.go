@@ -0,0 +1,130 @@
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Flamegraph layout, in pixels
+const (
+	svgWidth    = 1200
+	svgPad      = 10
+	frameHeight = 16
+	titleHeight = 40
+	charWidth   = 7
+	minWidth    = 0.1
+)
+
+// node is a frame of the merged call tree.
+type node struct {
+	name     string
+	value    int
+	children map[string]*node
+}
+
+func (n *node) child(name string) *node {
+	if c, ok := n.children[name]; ok {
+		return c
+	}
+	c := &node{name: name, children: map[string]*node{}}
+	n.children[name] = c
+	return c
+}
+
+func (n *node) depth() int {
+	d := 0
+	for _, c := range n.children {
+		d = max(d, c.depth())
+	}
+	return d + 1
+}
+
+// RenderSVG writes the flamegraph of stacks as an SVG image: one box per
+// frame, as wide as the samples it was on the stack for, with its callers
+// below it. Siblings are sorted by name, like flamegraph.pl does.
+func RenderSVG(w io.Writer, stacks Stacks, title string) error {
+	root := &node{name: "all", children: map[string]*node{}}
+	for stack, count := range stacks {
+		root.value += count
+		n := root
+		for _, frame := range strings.Split(stack, ";") {
+			n = n.child(frame)
+			n.value += count
+		}
+	}
+	if root.value == 0 {
+		return fmt.Errorf("no samples to render")
+	}
+
+	height := titleHeight + root.depth()*frameHeight + svgPad
+	scale := float64(svgWidth-2*svgPad) / float64(root.value)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<?xml version="1.0" standalone="no"?>
+<svg version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">
+<rect x="0" y="0" width="100%%" height="100%%" fill="#f8f8f8"/>
+<text x="%d" y="24" font-family="Verdana" font-size="17" text-anchor="middle">%s</text>
+<g font-family="Verdana" font-size="12">
+`, svgWidth, height, svgWidth, height, svgWidth/2, html.EscapeString(title))
+
+	var draw func(n *node, x float64, depth int)
+	draw = func(n *node, x float64, depth int) {
+		width := float64(n.value) * scale
+		if width < minWidth {
+			return
+		}
+		y := height - svgPad - (depth+1)*frameHeight
+		percent := 100 * float64(n.value) / float64(root.value)
+		fmt.Fprintf(bw, `<g><title>%s (%d samples, %.2f%%)</title><rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" rx="2"/>`,
+			html.EscapeString(n.name), n.value, percent, x, y, width, frameHeight-1, frameColor(n.name))
+		if label := fitLabel(n.name, width); label != "" {
+			fmt.Fprintf(bw, `<text x="%.1f" y="%d">%s</text>`, x+3, y+frameHeight-4, html.EscapeString(label))
+		}
+		bw.WriteString("</g>\n")
+
+		names := make([]string, 0, len(n.children))
+		for name := range n.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := n.children[name]
+			draw(c, x, depth+1)
+			x += float64(c.value) * scale
+		}
+	}
+	draw(root, svgPad, 0)
+
+	bw.WriteString("</g>\n</svg>\n")
+	return bw.Flush()
+}
+
+// fitLabel truncates name to fit a box of width pixels, or returns "" if
+// not even a few characters fit.
+func fitLabel(name string, width float64) string {
+	chars := int((width - 6) / charWidth)
+	if chars < 3 {
+		return ""
+	}
+	if len(name) <= chars {
+		return name
+	}
+	return name[:chars-2] + ".."
+}
+
+// frameColor returns a warm color derived from name, so a function keeps
+// its color across flamegraphs.
+func frameColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	v := h.Sum32()
+	r := 205 + v%50
+	g := (v >> 8) % 230
+	b := (v >> 16) % 55
+	return fmt.Sprintf("rgb(%d,%d,%d)", r, g, b)
+}
@@ -0,0 +1,293 @@
+package profile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AllocationSite is a place in the program that allocated heap memory.
+type AllocationSite struct {
+	// Bytes is the memory attributed to the site: at the heap peak for
+	// heaptrack and massif, still allocated at exit for malloc stack logging.
+	Bytes int64
+
+	// Calls is the number of allocations, or 0 if the tool does not say.
+	Calls int
+
+	// Location is the allocating function, with its source line if known.
+	Location string
+}
+
+// HeapProfiler records the heap allocations of a program run under its
+// Wrapper.
+type HeapProfiler struct {
+	// Name is the name of the heap profiling tool.
+	Name string
+
+	// Wrapper is the command the profiled program is run under.
+	Wrapper []string
+
+	// Output is the file the tool writes its profile to.
+	Output string
+
+	sites func() ([]AllocationSite, error)
+}
+
+// NewHeap returns the heap profiler for goos: heaptrack on Linux, malloc
+// stack logging on macOS, and valgrind's massif where those are missing.
+func NewHeap(goos string, opts Options) (*HeapProfiler, error) {
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if goos == "linux" {
+		if _, err := execLookPath("heaptrack"); err == nil {
+			base := filepath.Join(dir, "heaptrack")
+			h := &HeapProfiler{Name: "heaptrack", Wrapper: []string{"heaptrack", "-o", base}}
+			h.sites = func() ([]AllocationSite, error) {
+				// heaptrack appends the compression suffix (.zst or .gz)
+				matches, _ := filepath.Glob(base + ".*")
+				if len(matches) == 0 {
+					return nil, fmt.Errorf("heaptrack wrote no profile")
+				}
+				h.Output = matches[0]
+				out, err := execCommand("heaptrack_print", "--print-peaks", "-f", h.Output).Output()
+				if err != nil {
+					return nil, fmt.Errorf("heaptrack_print failed: %w", err)
+				}
+				return ParseHeaptrackPeaks(bytes.NewReader(out))
+			}
+			return h, nil
+		}
+	}
+
+	if goos == "darwin" {
+		if _, err := execLookPath("leaks"); err == nil {
+			graph := filepath.Join(dir, "heap.memgraph")
+			return &HeapProfiler{
+				Name:    "malloc stack logging",
+				Wrapper: []string{"env", "MallocStackLogging=1", "leaks", "--atExit", "--outputGraph=" + graph, "--"},
+				Output:  graph,
+				sites: func() ([]AllocationSite, error) {
+					out, err := execCommand("malloc_history", graph, "-allBySize").Output()
+					if err != nil && len(out) == 0 {
+						return nil, fmt.Errorf("malloc_history failed: %w", err)
+					}
+					return ParseMallocHistory(bytes.NewReader(out))
+				},
+			}, nil
+		}
+	}
+
+	if _, err := execLookPath("valgrind"); err == nil {
+		out := filepath.Join(dir, "massif.out")
+		return &HeapProfiler{
+			Name:    "massif",
+			Wrapper: []string{"valgrind", "--tool=massif", "--massif-out-file=" + out},
+			Output:  out,
+			sites: func() ([]AllocationSite, error) {
+				f, err := os.Open(out)
+				if err != nil {
+					return nil, fmt.Errorf("massif wrote no profile: %w", err)
+				}
+				defer f.Close()
+				return ParseMassif(f)
+			},
+		}, nil
+	}
+
+	switch goos {
+	case "linux":
+		return nil, fmt.Errorf("no heap profiler found\n  hint: install heaptrack (apt install heaptrack) or valgrind")
+	case "darwin":
+		return nil, fmt.Errorf("no heap profiler found\n  hint: leaks ships with the Xcode command line tools (xcode-select --install)")
+	}
+	return nil, fmt.Errorf("heap profiling is not supported on %s (supported: linux, macOS)", goos)
+}
+
+// TopSites returns the n sites that allocated the most memory.
+func (h *HeapProfiler) TopSites(n int) ([]AllocationSite, error) {
+	sites, err := h.sites()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(sites, func(i, j int) bool { return sites[i].Bytes > sites[j].Bytes })
+	if len(sites) > n {
+		sites = sites[:n]
+	}
+	return sites, nil
+}
+
+var (
+	// "1.00MB peak memory consumed over 12 calls from"
+	heaptrackPeakRe = regexp.MustCompile(`^([\d.]+)([KMGT]?B?) peak memory consumed over (\d+) calls from`)
+
+	// "3 calls for 4096 bytes: thread_0x1 | start | main | malloc"
+	mallocHistoryRe = regexp.MustCompile(`^(\d+) calls? for ([\d,]+) bytes: (.*)$`)
+
+	// " n0: 3000 0x109189: foo() (main.cpp:5)"
+	massifNodeRe = regexp.MustCompile(`^ n\d+: (\d+) (?:0x[0-9A-Fa-f]+: )?(.*)$`)
+)
+
+// ParseHeaptrackPeaks reads the PEAK MEMORY CONSUMERS section of
+// "heaptrack_print --print-peaks". Each entry is a summary line followed by
+// the allocating function and its location.
+func ParseHeaptrackPeaks(r io.Reader) ([]AllocationSite, error) {
+	var sites []AllocationSite
+	inPeaks := false
+	var current *AllocationSite
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "PEAK MEMORY CONSUMERS":
+			inPeaks = true
+		case !inPeaks:
+		case isSectionHeader(trimmed):
+			inPeaks = false
+		case heaptrackPeakRe.MatchString(trimmed):
+			m := heaptrackPeakRe.FindStringSubmatch(trimmed)
+			calls, _ := strconv.Atoi(m[3])
+			sites = append(sites, AllocationSite{Bytes: parseSize(m[1], m[2]), Calls: calls})
+			current = &sites[len(sites)-1]
+		case current != nil && trimmed != "":
+			if current.Location == "" {
+				current.Location = trimmed
+			} else {
+				if strings.HasPrefix(trimmed, "at ") {
+					current.Location += " (" + strings.TrimPrefix(trimmed, "at ") + ")"
+				}
+				current = nil
+			}
+		}
+	}
+	return sites, scanner.Err()
+}
+
+// isSectionHeader reports whether line is an all-caps heaptrack_print
+// section header.
+func isSectionHeader(line string) bool {
+	return line != "" && strings.ToUpper(line) == line && strings.ContainsAny(line, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") &&
+		!strings.ContainsAny(line, "0123456789")
+}
+
+// parseSize converts a heaptrack size ("1.50" "MB") to bytes.
+func parseSize(number, unit string) int64 {
+	v, _ := strconv.ParseFloat(number, 64)
+	switch strings.TrimSuffix(unit, "B") {
+	case "K":
+		v *= 1 << 10
+	case "M":
+		v *= 1 << 20
+	case "G":
+		v *= 1 << 30
+	case "T":
+		v *= 1 << 40
+	}
+	return int64(v)
+}
+
+// mallocFrames are allocator frames skipped when naming the allocating
+// function in a malloc_history stack.
+var mallocFrames = map[string]bool{
+	"malloc": true, "calloc": true, "realloc": true, "malloc_zone_malloc": true,
+	"_malloc_zone_malloc_instrumented_or_legacy": true, "operator new(unsigned long)": true,
+	"operator new[](unsigned long)": true, "_malloc_zone_calloc": true, "_realloc": true,
+}
+
+// ParseMallocHistory reads the output of "malloc_history <memgraph>
+// -allBySize", one line per distinct allocation stack, outermost frame
+// first.
+func ParseMallocHistory(r io.Reader) ([]AllocationSite, error) {
+	bySite := map[string]*AllocationSite{}
+	var order []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := mallocHistoryRe.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		calls, _ := strconv.Atoi(m[1])
+		size, _ := strconv.ParseInt(strings.ReplaceAll(m[2], ",", ""), 10, 64)
+		frames := strings.Split(m[3], " | ")
+		location := "[unknown]"
+		for i := len(frames) - 1; i >= 0; i-- {
+			if f := strings.TrimSpace(frames[i]); f != "" && !mallocFrames[f] {
+				location = f
+				break
+			}
+		}
+		site, ok := bySite[location]
+		if !ok {
+			site = &AllocationSite{Location: location}
+			bySite[location] = site
+			order = append(order, location)
+		}
+		site.Bytes += size
+		site.Calls += calls
+	}
+
+	sites := make([]AllocationSite, 0, len(order))
+	for _, location := range order {
+		sites = append(sites, *bySite[location])
+	}
+	return sites, scanner.Err()
+}
+
+// ParseMassif reads a massif.out file and returns the allocation sites of
+// its peak snapshot: the direct children of the snapshot's heap tree.
+func ParseMassif(r io.Reader) ([]AllocationSite, error) {
+	var sites []AllocationSite
+	inPeak := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "heap_tree="):
+			inPeak = line == "heap_tree=peak"
+		case !inPeak:
+		case strings.HasPrefix(line, "snapshot="):
+			inPeak = false
+		default:
+			m := massifNodeRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			size, _ := strconv.ParseInt(m[1], 10, 64)
+			sites = append(sites, AllocationSite{Bytes: size, Location: m[2]})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if sites == nil {
+		return nil, fmt.Errorf("massif recorded no heap peak")
+	}
+	return sites, nil
+}
+
+// FormatBytes formats n bytes for humans ("1.5 MiB").
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const heaptrackPeaks = `reading file "heaptrack.app.zst" - please wait, this might take some time...
+Debuggee command was: ./app
+finished reading file, now analyzing data:
+
+PEAK MEMORY CONSUMERS
+10.00MB peak memory consumed over 1 calls from
+buildTable()
+  at /src/main.cpp:12
+  in /src/app
+10.00MB consumed over 1 calls from:
+    main
+      at /src/main.cpp:30
+
+512.00KB peak memory consumed over 64 calls from
+parse(std::string const&)
+  in /src/app
+
+MEMORY LEAKS
+`
+
+const mallocHistory = `malloc_history Report Version:  2.0
+Process:         app [4242]
+
+1 call for 1048576 bytes: thread_7ff8 | start | main | buildTable() | operator new[](unsigned long) | malloc
+3 calls for 96 bytes: thread_7ff8 | start | main | parse(std::string const&) | malloc
+1 call for 32 bytes: thread_7ff8 | start | main | parse(std::string const&) | malloc
+`
+
+const massifOut = `desc: (none)
+cmd: ./app
+time_unit: i
+#-----------
+snapshot=0
+#-----------
+time=0
+mem_heap_B=0
+mem_heap_extra_B=0
+mem_stacks_B=0
+heap_tree=empty
+#-----------
+snapshot=1
+#-----------
+time=1000
+mem_heap_B=4000
+mem_heap_extra_B=16
+mem_stacks_B=0
+heap_tree=peak
+n2: 4000 (heap allocation functions) malloc/new/new[], --alloc-fns, etc.
+ n1: 3000 0x109189: buildTable() (main.cpp:12)
+  n0: 3000 0x1091F0: main (main.cpp:30)
+ n0: 1000 0x1091A0: parse(std::string const&) (main.cpp:5)
+#-----------
+snapshot=2
+#-----------
+heap_tree=detailed
+n1: 10 (heap allocation functions) malloc/new/new[], --alloc-fns, etc.
+ n0: 10 0x1091A0: other() (main.cpp:40)
+`
+
+func TestParseHeaptrackPeaks(t *testing.T) {
+	sites, err := ParseHeaptrackPeaks(strings.NewReader(heaptrackPeaks))
+	require.NoError(t, err)
+
+	assert.Equal(t, []AllocationSite{
+		{Bytes: 10 << 20, Calls: 1, Location: "buildTable() (/src/main.cpp:12)"},
+		{Bytes: 512 << 10, Calls: 64, Location: "parse(std::string const&)"},
+	}, sites)
+}
+
+func TestParseMallocHistory(t *testing.T) {
+	sites, err := ParseMallocHistory(strings.NewReader(mallocHistory))
+	require.NoError(t, err)
+
+	assert.Equal(t, []AllocationSite{
+		{Bytes: 1048576, Calls: 1, Location: "buildTable()"},
+		{Bytes: 128, Calls: 4, Location: "parse(std::string const&)"},
+	}, sites)
+}
+
+func TestParseMassif(t *testing.T) {
+	sites, err := ParseMassif(strings.NewReader(massifOut))
+	require.NoError(t, err)
+
+	assert.Equal(t, []AllocationSite{
+		{Bytes: 3000, Location: "buildTable() (main.cpp:12)"},
+		{Bytes: 1000, Location: "parse(std::string const&) (main.cpp:5)"},
+	}, sites)
+
+	_, err = ParseMassif(strings.NewReader("heap_tree=empty\n"))
+	assert.Error(t, err)
+}
+
+func TestNewHeap(t *testing.T) {
+	oldLookPath := execLookPath
+	defer func() { execLookPath = oldLookPath }()
+	available := map[string]bool{}
+	execLookPath = func(file string) (string, error) {
+		if available[file] {
+			return "/usr/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+	dir := t.TempDir()
+
+	available["heaptrack"], available["valgrind"], available["leaks"] = true, true, true
+	h, err := NewHeap("linux", Options{Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, "heaptrack", h.Name)
+
+	h, err = NewHeap("darwin", Options{Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, "malloc stack logging", h.Name)
+	assert.Contains(t, h.Wrapper, "MallocStackLogging=1")
+
+	available["heaptrack"] = false
+	h, err = NewHeap("linux", Options{Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, "massif", h.Name, "massif is the fallback")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "massif.out"), []byte(massifOut), 0644))
+	sites, err := h.TopSites(1)
+	require.NoError(t, err)
+	assert.Equal(t, []AllocationSite{{Bytes: 3000, Location: "buildTable() (main.cpp:12)"}}, sites)
+
+	available["valgrind"] = false
+	_, err = NewHeap("linux", Options{Dir: dir})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no heap profiler found")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "1.5 KiB", FormatBytes(1536))
+	assert.Equal(t, "10.0 MiB", FormatBytes(10<<20))
+}
@@ -0,0 +1,134 @@
+// Package profile runs programs under the platform's sampling profiler (perf
+// on Linux, sample on macOS) and turns the samples into flamegraphs.
+package profile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+var (
+	execCommand  = exec.Command
+	execLookPath = exec.LookPath
+)
+
+// Options configures a profiling session.
+type Options struct {
+	// Dir is where the raw profile, the folded stacks and the flamegraph are
+	// written.
+	Dir string
+
+	// Frequency is the sampling frequency in Hz (perf only).
+	Frequency int
+
+	// Duration is how many seconds to sample for (sample only).
+	Duration int
+}
+
+// Profiler samples a program run under its Wrapper.
+type Profiler struct {
+	// Name is the name of the profiling tool.
+	Name string
+
+	// Wrapper is the command the profiled program is run under.
+	Wrapper []string
+
+	collect func() (Stacks, error)
+}
+
+// New returns the profiler for goos.
+func New(goos string, opts Options) (*Profiler, error) {
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Frequency <= 0 {
+		opts.Frequency = 999
+	}
+	if opts.Duration <= 0 {
+		opts.Duration = 10
+	}
+
+	switch goos {
+	case "linux":
+		if _, err := execLookPath("perf"); err != nil {
+			return nil, fmt.Errorf("perf not found\n  hint: install linux-tools (apt install linux-tools-generic) or perf (dnf install perf)")
+		}
+		data := filepath.Join(dir, "perf.data")
+		return &Profiler{
+			Name: "perf",
+			Wrapper: []string{"perf", "record", "-F", strconv.Itoa(opts.Frequency),
+				"--call-graph", "dwarf", "-o", data, "--"},
+			collect: func() (Stacks, error) {
+				out, err := execCommand("perf", "script", "-i", data).Output()
+				if err != nil {
+					return nil, fmt.Errorf("perf script failed: %w", err)
+				}
+				return ParsePerfScript(bytes.NewReader(out))
+			},
+		}, nil
+	case "darwin":
+		if _, err := execLookPath("sample"); err != nil {
+			return nil, fmt.Errorf("sample not found\n  hint: it ships with the Xcode command line tools (xcode-select --install)")
+		}
+		report := filepath.Join(dir, "sample.txt")
+		// sample attaches to a running process, so start the program in the
+		// background and sample it until it exits or the duration is over
+		script := fmt.Sprintf(`"$@" & pid=$!
+sample "$pid" %d -mayDie -file %q >/dev/null 2>&1 &
+wait "$pid"; status=$?
+wait
+exit $status`, opts.Duration, report)
+		return &Profiler{
+			Name:    "sample",
+			Wrapper: []string{"sh", "-c", script, "sh"},
+			collect: func() (Stacks, error) {
+				f, err := os.Open(report)
+				if err != nil {
+					return nil, fmt.Errorf("no samples were recorded: %w", err)
+				}
+				defer f.Close()
+				return ParseSample(f)
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("profiling is not supported on %s (supported: linux with perf, macOS with sample)", goos)
+}
+
+// Collect reads the samples the profiled run recorded.
+func (p *Profiler) Collect() (Stacks, error) {
+	stacks, err := p.collect()
+	if err != nil {
+		return nil, err
+	}
+	if len(stacks) == 0 {
+		return nil, fmt.Errorf("%s recorded no samples", p.Name)
+	}
+	return stacks, nil
+}
+
+// Write writes the folded stacks (stacks.folded) and the flamegraph
+// (flamegraph.svg) of stacks to dir and returns the path of the flamegraph.
+func Write(dir string, stacks Stacks, title string) (string, error) {
+	var folded bytes.Buffer
+	if err := stacks.WriteFolded(&folded); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stacks.folded"), folded.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write folded stacks: %w", err)
+	}
+
+	var svg bytes.Buffer
+	if err := RenderSVG(&svg, stacks, title); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "flamegraph.svg")
+	if err := os.WriteFile(path, svg.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write flamegraph: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,129 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const perfScript = `            app 12345 123.456789:    1001001 cpu-clock:u:
+	    55d0c3a0 compute+0x20 (/home/u/app)
+	    55d0c3b0 main+0x15 (/home/u/app)
+	    7f123400 __libc_start_main+0xf3 (/usr/lib/libc.so.6)
+
+            app 12345 123.457790:    1001001 cpu-clock:u:
+	    55d0c3a0 compute+0x24 (/home/u/app)
+	    55d0c3b0 main+0x15 (/home/u/app)
+	    7f123400 __libc_start_main+0xf3 (/usr/lib/libc.so.6)
+
+            app 12345 123.458791:    1001001 cpu-clock:u:
+	    55d0c3c0 main+0x30 (/home/u/app)
+	    7f123400 __libc_start_main+0xf3 (/usr/lib/libc.so.6)
+	           0 [unknown] ([unknown])
+
+`
+
+const sampleReport = `Analysis of sampling app (pid 4242) every 1 millisecond
+Process:         app [4242]
+
+Call graph:
+    100 Thread_3364551   DispatchQueue_1: com.apple.main-thread  (serial)
+    + 100 start  (in dyld) + 1903  [0x18d2b0274]
+    +   100 main  (in app) + 40  [0x1000034c8]
+    +     70 compute()  (in app) + 52  [0x100003404]
+    +     ! 70 inner  (in app) + 8  [0x1000033f0]
+    +     25 other  (in app) + 12  [0x100003300]
+
+Total number in stack (recursive counted multiple, when >=5):
+`
+
+func TestParsePerfScript(t *testing.T) {
+	stacks, err := ParsePerfScript(strings.NewReader(perfScript))
+	require.NoError(t, err)
+
+	assert.Equal(t, Stacks{
+		"app;__libc_start_main;main;compute":   2,
+		"app;[unknown];__libc_start_main;main": 1,
+	}, stacks)
+}
+
+func TestParseSample(t *testing.T) {
+	stacks, err := ParseSample(strings.NewReader(sampleReport))
+	require.NoError(t, err)
+
+	assert.Equal(t, Stacks{
+		"Thread_3364551;start;main;compute();inner": 70,
+		"Thread_3364551;start;main;other":           25,
+		"Thread_3364551;start;main":                 5,
+	}, stacks)
+}
+
+func TestWriteFolded(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Stacks{"b;c": 2, "a": 1}.WriteFolded(&buf))
+	assert.Equal(t, "a 1\nb;c 2\n", buf.String())
+}
+
+func TestRenderSVG(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderSVG(&buf, Stacks{"main;compute<int>": 3, "main": 1}, "app")
+	require.NoError(t, err)
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, "<?xml"))
+	assert.Contains(t, svg, "<title>all (4 samples, 100.00%)</title>")
+	assert.Contains(t, svg, "<title>main (4 samples, 100.00%)</title>")
+	assert.Contains(t, svg, "<title>compute&lt;int&gt; (3 samples, 75.00%)</title>")
+	assert.True(t, strings.HasSuffix(svg, "</svg>\n"))
+
+	assert.Error(t, RenderSVG(&buf, Stacks{}, "empty"))
+}
+
+func TestFitLabel(t *testing.T) {
+	assert.Equal(t, "main", fitLabel("main", 100))
+	assert.Equal(t, "", fitLabel("main", 10))
+	assert.Equal(t, "very_lo..", fitLabel("very_long_function_name", 70))
+}
+
+func TestNew(t *testing.T) {
+	oldLookPath := execLookPath
+	defer func() { execLookPath = oldLookPath }()
+	execLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	dir := t.TempDir()
+	p, err := New("linux", Options{Dir: dir, Frequency: 499})
+	require.NoError(t, err)
+	assert.Equal(t, "perf", p.Name)
+	assert.Equal(t, []string{"perf", "record", "-F", "499", "--call-graph", "dwarf", "-o", filepath.Join(dir, "perf.data"), "--"}, p.Wrapper)
+
+	p, err = New("darwin", Options{Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, "sample", p.Name)
+	assert.Equal(t, "sh", p.Wrapper[0])
+	assert.Contains(t, p.Wrapper[2], `sample "$pid" 10 -mayDie`)
+
+	_, err = New("windows", Options{Dir: dir})
+	assert.Error(t, err)
+
+	execLookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	_, err = New("linux", Options{Dir: dir})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "perf not found")
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path, err := Write(dir, Stacks{"main;compute": 2}, "app")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "flamegraph.svg"), path)
+
+	folded, err := os.ReadFile(filepath.Join(dir, "stacks.folded"))
+	require.NoError(t, err)
+	assert.Equal(t, "main;compute 2\n", string(folded))
+}
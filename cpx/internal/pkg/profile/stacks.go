@@ -0,0 +1,171 @@
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Stacks maps folded call stacks ("root;caller;callee") to the number of
+// samples whose innermost frame was callee.
+type Stacks map[string]int
+
+// WriteFolded writes stacks in the folded format of Brendan Gregg's
+// FlameGraph tools ("stack count" per line), sorted by stack.
+func (s Stacks) WriteFolded(w io.Writer) error {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s %d\n", k, s[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// symbolOffsetRe matches the "+0x1f" offset perf appends to symbols.
+var symbolOffsetRe = regexp.MustCompile(`\+0x[0-9a-fA-F]+$`)
+
+// ParsePerfScript folds the output of "perf script". Each sample is a header
+// line naming the command, followed by one tab-indented line per frame,
+// innermost first, and a blank line.
+func ParsePerfScript(r io.Reader) (Stacks, error) {
+	stacks := Stacks{}
+	var comm string
+	var frames []string
+	flush := func() {
+		if comm == "" {
+			return
+		}
+		stack := []string{comm}
+		for i := len(frames) - 1; i >= 0; i-- {
+			stack = append(stack, frames[i])
+		}
+		stacks[strings.Join(stack, ";")]++
+		comm, frames = "", nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case line[0] != '\t':
+			flush()
+			comm = strings.Fields(line)[0]
+		default:
+			frames = append(frames, perfSymbol(strings.TrimSpace(line)))
+		}
+	}
+	flush()
+	return stacks, scanner.Err()
+}
+
+// perfSymbol extracts the symbol from a perf script frame line
+// ("55d0c3a0 compute+0x20 (/home/u/app)").
+func perfSymbol(line string) string {
+	_, sym, _ := strings.Cut(line, " ")
+	if i := strings.LastIndex(sym, " ("); i >= 0 && strings.HasSuffix(sym, ")") {
+		sym = sym[:i]
+	}
+	sym = symbolOffsetRe.ReplaceAllString(strings.TrimSpace(sym), "")
+	if sym == "" {
+		return "[unknown]"
+	}
+	return sym
+}
+
+// ParseSample folds the call graph section of a macOS "sample" report. Its
+// lines hold the inclusive sample count of a frame, indented by depth:
+//
+//	2350 Thread_3364551   DispatchQueue_1: com.apple.main-thread  (serial)
+//	+ 2350 start  (in dyld) + 1903  [0x18d2b0274]
+//	+   2350 main  (in app) + 40  [0x1000034c8]
+func ParseSample(r io.Reader) (Stacks, error) {
+	type frame struct {
+		column   int
+		name     string
+		count    int
+		children int
+	}
+	stacks := Stacks{}
+	var stack []frame
+	pop := func() {
+		top := stack[len(stack)-1]
+		if self := top.count - top.children; self > 0 {
+			names := make([]string, len(stack))
+			for i, f := range stack {
+				names[i] = f.name
+			}
+			stacks[strings.Join(names, ";")] += self
+		}
+		stack = stack[:len(stack)-1]
+	}
+
+	inGraph := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !inGraph {
+			inGraph = strings.HasPrefix(line, "Call graph:")
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			if len(stack) > 0 || len(stacks) > 0 {
+				break
+			}
+			continue
+		}
+
+		column := strings.IndexFunc(line, unicode.IsDigit)
+		if column < 0 {
+			continue
+		}
+		rest := line[column:]
+		end := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsDigit(r) })
+		if end < 0 {
+			continue
+		}
+		count, err := strconv.Atoi(rest[:end])
+		if err != nil {
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].column >= column {
+			pop()
+		}
+		if len(stack) > 0 {
+			stack[len(stack)-1].children += count
+		}
+		stack = append(stack, frame{column: column, name: sampleSymbol(rest[end:]), count: count})
+	}
+	for len(stack) > 0 {
+		pop()
+	}
+	return stacks, scanner.Err()
+}
+
+// sampleSymbol extracts the symbol from a sample frame
+// ("main  (in app) + 40  [0x1000034c8]").
+func sampleSymbol(s string) string {
+	s = strings.TrimSpace(s)
+	for _, sep := range []string{"  (in ", "   ", "  ["} {
+		if i := strings.Index(s, sep); i >= 0 {
+			s = s[:i]
+		}
+	}
+	if s == "" || strings.HasPrefix(s, "???") {
+		return "[unknown]"
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+// Package project finds cpx projects and tells which build system they use.
+// Every command asks it, so the build_system key of cpx.yaml and the
+// --build-system flag are honored everywhere.
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// BuildSystem is the build system of a project.
+type BuildSystem string
+
+const (
+	Vcpkg   BuildSystem = "vcpkg"
+	Bazel   BuildSystem = "bazel"
+	Meson   BuildSystem = "meson"
+	Unknown BuildSystem = "unknown"
+)
+
+// BuildSystems are the build systems cpx supports, in the order their
+// marker files are looked for.
+var BuildSystems = []BuildSystem{Vcpkg, Bazel, Meson}
+
+// markers are the files that identify a project of each build system.
+var markers = map[BuildSystem]string{
+	Vcpkg: "vcpkg.json",
+	Bazel: "MODULE.bazel",
+	Meson: "meson.build",
+}
+
+// override is the build system given with --build-system.
+var override BuildSystem
+
+// Parse returns the build system called name. "cmake" names vcpkg, the
+// build system of cpx's CMake projects.
+func Parse(name string) (BuildSystem, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "vcpkg", "cmake":
+		return Vcpkg, nil
+	case "bazel":
+		return Bazel, nil
+	case "meson":
+		return Meson, nil
+	}
+	return Unknown, fmt.Errorf("unknown build system '%s' (supported: vcpkg, bazel, meson)", name)
+}
+
+// SetOverride makes Detect return the build system called name for every
+// project, or clears the override when name is empty.
+func SetOverride(name string) error {
+	if name == "" {
+		override = ""
+		return nil
+	}
+	bs, err := Parse(name)
+	if err != nil {
+		return fmt.Errorf("--build-system: %w", err)
+	}
+	override = bs
+	return nil
+}
+
+// Detect returns the build system of the project in dir: the --build-system
+// override, else the build_system of its cpx.yaml, else the one whose
+// marker file (vcpkg.json, MODULE.bazel, meson.build) is present. It
+// returns Unknown when there is none, or when cpx.yaml names a build system
+// cpx does not know; Check reports why.
+func Detect(dir string) BuildSystem {
+	bs, _ := detect(dir)
+	return bs
+}
+
+// Check returns the error that made Detect return Unknown for dir, or nil.
+func Check(dir string) error {
+	_, err := detect(dir)
+	return err
+}
+
+func detect(dir string) (BuildSystem, error) {
+	if override != "" {
+		return override, nil
+	}
+	manifest, err := config.LoadManifest(filepath.Join(dir, config.ManifestFile))
+	if err != nil && !os.IsNotExist(err) {
+		return Unknown, err
+	}
+	if err == nil && manifest.BuildSystem != "" {
+		bs, err := Parse(manifest.BuildSystem)
+		if err != nil {
+			return Unknown, fmt.Errorf("%s: build_system: %w", config.ManifestFile, err)
+		}
+		return bs, nil
+	}
+	return DetectFiles(dir), nil
+}
+
+// DetectFiles returns the build system whose marker file is in dir,
+// ignoring cpx.yaml and the override.
+func DetectFiles(dir string) BuildSystem {
+	for _, bs := range BuildSystems {
+		if _, err := os.Stat(filepath.Join(dir, markers[bs])); err == nil {
+			return bs
+		}
+	}
+	return Unknown
+}
+
+// rootMarkers are the files whose directory is a project root.
+var rootMarkers = []string{config.ManifestFile, "CMakeLists.txt", "vcpkg.json", "meson.build", "MODULE.bazel", ".git"}
+
+// FindRoot returns the nearest directory from start upwards that holds a
+// project marker, or start when there is none.
+func FindRoot(start string) string {
+	dir := start
+	for {
+		for _, marker := range rootMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return start
+		}
+		dir = parent
+	}
+}
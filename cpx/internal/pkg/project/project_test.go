@@ -0,0 +1,64 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func TestParse(t *testing.T) {
+	for name, want := range map[string]BuildSystem{"vcpkg": Vcpkg, "CMake": Vcpkg, "bazel": Bazel, " meson ": Meson} {
+		got, err := Parse(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, name)
+	}
+	_, err := Parse("make")
+	assert.ErrorContains(t, err, "unknown build system 'make'")
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, Unknown, Detect(dir))
+
+	writeFiles(t, dir, map[string]string{"MODULE.bazel": "", "meson.build": ""})
+	assert.Equal(t, Bazel, Detect(dir), "marker files are looked for in order")
+
+	writeFiles(t, dir, map[string]string{"cpx.yaml": "build_system: meson\n"})
+	assert.Equal(t, Meson, Detect(dir), "cpx.yaml wins over the marker files")
+	assert.Equal(t, Bazel, DetectFiles(dir))
+
+	t.Cleanup(func() { _ = SetOverride("") })
+	require.NoError(t, SetOverride("cmake"))
+	assert.Equal(t, Vcpkg, Detect(dir), "--build-system wins over cpx.yaml")
+	assert.Error(t, SetOverride("scons"))
+	require.NoError(t, SetOverride(""))
+	assert.Equal(t, Meson, Detect(dir))
+
+	writeFiles(t, dir, map[string]string{"cpx.yaml": "build_system: scons\n"})
+	assert.Equal(t, Unknown, Detect(dir))
+	assert.ErrorContains(t, Check(dir), "cpx.yaml: build_system: unknown build system 'scons'")
+
+	writeFiles(t, dir, map[string]string{"cpx.yaml": "name: app\n"})
+	assert.Equal(t, Bazel, Detect(dir), "a cpx.yaml without build_system falls back to the marker files")
+	assert.NoError(t, Check(dir))
+}
+
+func TestFindRoot(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "src", "core")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	assert.Equal(t, sub, FindRoot(sub), "without markers the start directory is the root")
+
+	writeFiles(t, root, map[string]string{"cpx.yaml": "build_system: vcpkg\n"})
+	assert.Equal(t, root, FindRoot(sub))
+}
@@ -10,14 +10,16 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ozacod/cpx/internal/pkg/utils/colors"
 	"github.com/ozacod/cpx/internal/pkg/utils/git"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
 // VcpkgSetup is an interface for vcpkg setup operations
 type VcpkgSetup interface {
 	SetupEnv() error
 	GetPath() (string, error)
+	InstalledDir() (string, error)
 }
 
 // AnalysisResult represents a single finding from any tool
@@ -55,7 +57,7 @@ type ComprehensiveAnalysis struct {
 
 // RunComprehensiveAnalysis runs all analysis tools and generates an HTML report
 func RunComprehensiveAnalysis(outputFile string, skipCppcheck, skipLint, skipFlawfinder bool, targets []string, vcpkg VcpkgSetup) error {
-	fmt.Printf("%sRunning comprehensive code analysis...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Running comprehensive code analysis...")
 
 	analysis := ComprehensiveAnalysis{
 		Timestamp: time.Now(),
@@ -66,7 +68,7 @@ func RunComprehensiveAnalysis(outputFile string, skipCppcheck, skipLint, skipFla
 
 	// Run Cppcheck
 	if !skipCppcheck {
-		fmt.Printf("%sRunning Cppcheck...%s\n", colors.Cyan, colors.Reset)
+		output.Stepf("Running Cppcheck...")
 		cppcheckResults := runCppcheckAnalysis(targets)
 		analysis.Tools = append(analysis.Tools, cppcheckResults)
 		updateSummary(&analysis, cppcheckResults)
@@ -74,7 +76,7 @@ func RunComprehensiveAnalysis(outputFile string, skipCppcheck, skipLint, skipFla
 
 	// Run clang-tidy
 	if !skipLint {
-		fmt.Printf("%sRunning clang-tidy...%s\n", colors.Cyan, colors.Reset)
+		output.Stepf("Running clang-tidy...")
 		lintResults := runLintAnalysis(vcpkg)
 		analysis.Tools = append(analysis.Tools, lintResults)
 		updateSummary(&analysis, lintResults)
@@ -82,19 +84,19 @@ func RunComprehensiveAnalysis(outputFile string, skipCppcheck, skipLint, skipFla
 
 	// Run Flawfinder
 	if !skipFlawfinder {
-		fmt.Printf("%sRunning Flawfinder...%s\n", colors.Cyan, colors.Reset)
+		output.Stepf("Running Flawfinder...")
 		flawfinderResults := runFlawfinderAnalysis(targets)
 		analysis.Tools = append(analysis.Tools, flawfinderResults)
 		updateSummary(&analysis, flawfinderResults)
 	}
 
 	// Generate HTML report
-	fmt.Printf("%sGenerating HTML report...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf("Generating HTML report...")
 	if err := generateHTMLReport(analysis, outputFile); err != nil {
 		return fmt.Errorf("failed to generate HTML report: %w", err)
 	}
 
-	fmt.Printf("%sAnalysis complete! Report saved to: %s%s\n", colors.Green, outputFile, colors.Reset)
+	output.Successf("Analysis complete! Report saved to: %s", outputFile)
 	fmt.Printf("   Total findings: %d\n", analysis.Summary.TotalFindings)
 	for tool, count := range analysis.Summary.ByTool {
 		fmt.Printf("   %s: %d findings\n", tool, count)
@@ -130,7 +132,6 @@ func discoverSourceDirectories(targets []string) []string {
 		"subprojects":    true,
 		"external":       true,
 		".bazel":         true,
-		".cache":         true,
 		"bazel-bin":      true,
 		"bazel-out":      true,
 		"bazel-testlogs": true,
@@ -138,6 +139,9 @@ func discoverSourceDirectories(targets []string) []string {
 		"bin":            true,
 		".vcpkg":         true,
 	}
+	for _, dir := range config.DirsOf(".").Within(".") {
+		skipDirs[dir] = true
+	}
 
 	// Common source directory names
 	commonDirs := []string{"src", "examples", "include", "lib", "libs", "source", "sources", "test", "tests"}
@@ -239,16 +243,12 @@ func runCppcheckAnalysis(targets []string) ToolResults {
 	if len(sourceDirs) == 0 {
 		result.Status = "skipped"
 		result.Error = "no source directories found to scan"
-		if os.Getenv("CPX_DEBUG") != "" {
-			fmt.Printf("Debug: cppcheck no source directories found, targets: %v\n", targets)
-		}
+		output.Debugf("cppcheck no source directories found, targets: %v", targets)
 		return result
 	}
 
-	if os.Getenv("CPX_DEBUG") != "" {
-		fmt.Printf("Debug: cppcheck targets: %v\n", targets)
-		fmt.Printf("Debug: cppcheck sourceDirs: %v\n", sourceDirs)
-	}
+	output.Debugf("cppcheck targets: %v", targets)
+	output.Debugf("cppcheck sourceDirs: %v", sourceDirs)
 
 	// Create temporary XML file
 	tmpXML, err := os.CreateTemp("", "cppcheck-*.xml")
@@ -267,21 +267,7 @@ func runCppcheckAnalysis(targets []string) ToolResults {
 
 	// Add exclusions for build system directories and external dependencies
 	// to prevent scanning third-party code
-	excludeDirs := []string{
-		"build",       // CMake build dir
-		"builddir",    // Meson build dir
-		"subprojects", // Meson subprojects
-		"external",    // Bazel external
-		".bazel",      // Bazel cache
-		".cache",      // vcpkg cache
-		"bazel-bin",   // Bazel output
-		"bazel-out",   // Bazel output
-		"bazel-testlogs",
-		"out",
-		"bin",
-		".vcpkg",
-	}
-	for _, dir := range excludeDirs {
+	for _, dir := range excludeDirs(".") {
 		args = append(args, "-i"+dir)
 	}
 
@@ -721,26 +707,21 @@ func runFlawfinderAnalysis(targets []string) ToolResults {
 	_ = cmd.Run()
 
 	// CSV output goes to stdout
-	output := stdout.String()
-
-	// Debug output if enabled
-	if os.Getenv("CPX_DEBUG") != "" {
-		fmt.Printf("Debug: flawfinder sourceDirs: %v\n", sourceDirs)
-		fmt.Printf("Debug: flawfinder stdout length: %d\n", len(output))
-		fmt.Printf("Debug: flawfinder stderr length: %d\n", stderr.Len())
-		if len(output) > 0 {
-			lines := strings.Split(output, "\n")
-			fmt.Printf("Debug: flawfinder CSV lines: %d (first 3: %v)\n", len(lines), lines[:min(3, len(lines))])
-		}
+	csv := stdout.String()
+
+	output.Debugf("flawfinder sourceDirs: %v", sourceDirs)
+	output.Debugf("flawfinder stdout length: %d", len(csv))
+	output.Debugf("flawfinder stderr length: %d", stderr.Len())
+	if output.DebugEnabled() && len(csv) > 0 {
+		lines := strings.Split(csv, "\n")
+		output.Debugf("flawfinder CSV lines: %d (first 3: %v)", len(lines), lines[:min(3, len(lines))])
 	}
 
 	// Parse CSV output
-	results := parseFlawfinderCSV(output)
+	results := parseFlawfinderCSV(csv)
 	result.Results = results
 
-	if os.Getenv("CPX_DEBUG") != "" {
-		fmt.Printf("Debug: flawfinder parsed results: %d\n", len(results))
-	}
+	output.Debugf("flawfinder parsed results: %d", len(results))
 
 	return result
 }
@@ -0,0 +1,470 @@
+package quality
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+// DefaultMaxComplexity is the cyclomatic complexity above which cpx audit
+// reports a function.
+const DefaultMaxComplexity = 15
+
+// AuditOptions configures RunAudit.
+type AuditOptions struct {
+	// OutputDir receives index.html, one page per file and audit.json.
+	OutputDir string
+
+	// Targets are the directories to analyze (default: the source directories).
+	Targets []string
+
+	SkipLint       bool
+	SkipCppcheck   bool
+	SkipFlawfinder bool
+	SkipCoverage   bool
+	SkipComplexity bool
+
+	// MaxComplexity is the cyclomatic complexity above which functions are
+	// reported (default: DefaultMaxComplexity).
+	MaxComplexity int
+}
+
+// AuditReport is everything cpx audit collected.
+type AuditReport struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Tools     []ToolResults `json:"tools"`
+
+	Coverage   CoverageSummary   `json:"coverage"`
+	Complexity ComplexitySummary `json:"complexity"`
+
+	// Files are the files with findings, coverage or functions, by path.
+	Files []AuditFile `json:"files"`
+}
+
+// CoverageSummary is the line coverage measured by gcovr.
+type CoverageSummary struct {
+	Status       string         `json:"status"`
+	Error        string         `json:"error,omitempty"`
+	LinesTotal   int            `json:"lines_total"`
+	LinesCovered int            `json:"lines_covered"`
+	Files        []FileCoverage `json:"-"`
+}
+
+// FileCoverage is the line coverage of a file. Lines maps executable lines
+// to their execution counts.
+type FileCoverage struct {
+	File         string      `json:"file"`
+	LinesTotal   int         `json:"lines_total"`
+	LinesCovered int         `json:"lines_covered"`
+	Lines        map[int]int `json:"-"`
+}
+
+// ComplexitySummary is the function complexity measured by lizard.
+type ComplexitySummary struct {
+	Status    string               `json:"status"`
+	Error     string               `json:"error,omitempty"`
+	Threshold int                  `json:"threshold"`
+	Functions []FunctionComplexity `json:"-"`
+}
+
+// FunctionComplexity is lizard's measurement of a function.
+type FunctionComplexity struct {
+	File      string `json:"file"`
+	Name      string `json:"name"`
+	Line      int    `json:"line"`
+	EndLine   int    `json:"end_line"`
+	NLOC      int    `json:"nloc"`
+	CCN       int    `json:"ccn"`
+	Params    int    `json:"params"`
+	Complex   bool   `json:"complex"`
+	Signature string `json:"-"`
+}
+
+// AuditFile is the drill-down of a single file.
+type AuditFile struct {
+	Path      string               `json:"path"`
+	Page      string               `json:"page"`
+	Findings  []AnalysisResult     `json:"findings"`
+	Coverage  *FileCoverage        `json:"coverage,omitempty"`
+	Functions []FunctionComplexity `json:"functions,omitempty"`
+}
+
+// Percent returns the covered share of lines, or -1 without coverage data.
+func (c CoverageSummary) Percent() float64 {
+	return percent(c.LinesCovered, c.LinesTotal)
+}
+
+// Percent returns the covered share of the file's lines.
+func (c FileCoverage) Percent() float64 {
+	return percent(c.LinesCovered, c.LinesTotal)
+}
+
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return -1
+	}
+	return 100 * float64(covered) / float64(total)
+}
+
+// MaxCCN returns the highest function complexity of the file.
+func (f AuditFile) MaxCCN() int {
+	maxCCN := 0
+	for _, fn := range f.Functions {
+		maxCCN = max(maxCCN, fn.CCN)
+	}
+	return maxCCN
+}
+
+// Count returns the number of findings with severity.
+func (f AuditFile) Count(severity string) int {
+	n := 0
+	for _, r := range f.Findings {
+		if r.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// TotalFindings returns the number of findings of all tools.
+func (r *AuditReport) TotalFindings() int {
+	n := 0
+	for _, t := range r.Tools {
+		n += len(t.Results)
+	}
+	return n
+}
+
+// RunAudit runs the linters, analyzers, coverage and complexity tools and
+// writes a static HTML dashboard to opts.OutputDir. Tools that are missing
+// are reported as skipped; findings do not fail the audit.
+func RunAudit(opts AuditOptions, vcpkg VcpkgSetup) error {
+	if opts.OutputDir == "" {
+		opts.OutputDir = "audit"
+	}
+	if opts.MaxComplexity <= 0 {
+		opts.MaxComplexity = DefaultMaxComplexity
+	}
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = []string{"."}
+	}
+
+	output.Stepf(" Running code audit...")
+	report := &AuditReport{Timestamp: time.Now()}
+
+	if !opts.SkipLint {
+		output.Stepf(" Running clang-tidy...")
+		report.Tools = append(report.Tools, runLintAnalysis(vcpkg))
+	}
+	if !opts.SkipCppcheck {
+		output.Stepf(" Running Cppcheck...")
+		report.Tools = append(report.Tools, runCppcheckAnalysis(targets))
+	}
+	if !opts.SkipFlawfinder {
+		output.Stepf(" Running Flawfinder...")
+		report.Tools = append(report.Tools, runFlawfinderAnalysis(targets))
+	}
+	if !opts.SkipComplexity {
+		output.Stepf(" Measuring complexity...")
+		report.Complexity = runComplexityAnalysis(targets, opts.MaxComplexity)
+		report.Tools = append(report.Tools, complexityFindings(report.Complexity))
+	} else {
+		report.Complexity = ComplexitySummary{Status: "skipped", Error: "skipped by --skip-complexity", Threshold: opts.MaxComplexity}
+	}
+	if !opts.SkipCoverage {
+		output.Stepf(" Collecting coverage...")
+		report.Coverage = runCoverageAnalysis()
+	} else {
+		report.Coverage = CoverageSummary{Status: "skipped", Error: "skipped by --skip-coverage"}
+	}
+
+	report.Files = auditFiles(report)
+
+	if err := writeAuditReport(report, opts.OutputDir); err != nil {
+		return err
+	}
+
+	output.Successf(" Audit complete! Report saved to: %s", path.Join(opts.OutputDir, "index.html"))
+	fmt.Printf("   Total findings: %d\n", report.TotalFindings())
+	for _, t := range report.Tools {
+		if t.Status == "success" {
+			fmt.Printf("   %s: %d findings\n", t.Tool, len(t.Results))
+		} else {
+			fmt.Printf("   %s: %s (%s)\n", t.Tool, t.Status, t.Error)
+		}
+	}
+	if report.Coverage.Status == "success" {
+		fmt.Printf("   Line coverage: %.1f%%\n", report.Coverage.Percent())
+	}
+	return nil
+}
+
+// runCoverageAnalysis reads the coverage of the last instrumented test run
+// with gcovr.
+func runCoverageAnalysis() CoverageSummary {
+	summary := CoverageSummary{Status: "success"}
+
+	if _, err := exec.LookPath("gcovr"); err != nil {
+		summary.Status = "skipped"
+		summary.Error = "gcovr not found"
+		return summary
+	}
+
+	args := []string{"--root", ".", "--json", "-"}
+	for _, dir := range excludeDirs(".") {
+		args = append(args, "--exclude", "(.+/)?"+regexp.QuoteMeta(dir)+"/")
+	}
+	cmd := exec.Command("gcovr", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		summary.Status = "error"
+		summary.Error = strings.TrimSpace(stderr.String())
+		if summary.Error == "" {
+			summary.Error = err.Error()
+		}
+		return summary
+	}
+
+	files, err := parseGcovrJSON(stdout.Bytes())
+	if err != nil {
+		summary.Status = "error"
+		summary.Error = err.Error()
+		return summary
+	}
+	if len(files) == 0 {
+		summary.Status = "skipped"
+		summary.Error = "no coverage data found. Build with --coverage and run the tests first."
+		return summary
+	}
+
+	summary.Files = files
+	for _, f := range files {
+		summary.LinesTotal += f.LinesTotal
+		summary.LinesCovered += f.LinesCovered
+	}
+	return summary
+}
+
+// parseGcovrJSON parses gcovr's JSON report into per-file line coverage.
+func parseGcovrJSON(data []byte) ([]FileCoverage, error) {
+	var report struct {
+		Files []struct {
+			File  string `json:"file"`
+			Lines []struct {
+				LineNumber int  `json:"line_number"`
+				Count      int  `json:"count"`
+				NonCode    bool `json:"gcovr/noncode"`
+			} `json:"lines"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse gcovr report: %w", err)
+	}
+
+	var files []FileCoverage
+	for _, f := range report.Files {
+		cov := FileCoverage{File: normalizeAuditPath(f.File), Lines: map[int]int{}}
+		for _, l := range f.Lines {
+			if l.NonCode {
+				continue
+			}
+			// Template instantiations report a line once per instance
+			if _, seen := cov.Lines[l.LineNumber]; !seen {
+				cov.LinesTotal++
+			}
+			cov.Lines[l.LineNumber] += l.Count
+		}
+		for _, count := range cov.Lines {
+			if count > 0 {
+				cov.LinesCovered++
+			}
+		}
+		files = append(files, cov)
+	}
+	return files, nil
+}
+
+// runComplexityAnalysis measures function complexity with lizard.
+func runComplexityAnalysis(targets []string, threshold int) ComplexitySummary {
+	summary := ComplexitySummary{Status: "success", Threshold: threshold}
+
+	if _, err := exec.LookPath("lizard"); err != nil {
+		summary.Status = "skipped"
+		summary.Error = "lizard not found (pip install lizard)"
+		return summary
+	}
+
+	sourceDirs := discoverSourceDirectories(targets)
+	if len(sourceDirs) == 0 {
+		summary.Status = "skipped"
+		summary.Error = "no source directories found to scan"
+		return summary
+	}
+
+	args := []string{"--csv", "-l", "cpp"}
+	for _, dir := range excludeDirs(".") {
+		args = append(args, "-x", "*/"+dir+"/*")
+	}
+	args = append(args, sourceDirs...)
+
+	cmd := exec.Command("lizard", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// lizard exits non-zero when functions exceed its own warning limits
+	_ = cmd.Run()
+
+	output.Debugf("lizard sourceDirs: %v, stderr: %s", sourceDirs, stderr.String())
+
+	functions, err := parseLizardCSV(stdout.String(), threshold)
+	if err != nil {
+		summary.Status = "error"
+		summary.Error = err.Error()
+		return summary
+	}
+	summary.Functions = functions
+	return summary
+}
+
+// parseLizardCSV parses lizard's CSV output:
+// NLOC,CCN,token,PARAM,length,location,file,function,long_name,start,end
+func parseLizardCSV(out string, threshold int) ([]FunctionComplexity, error) {
+	r := csv.NewReader(strings.NewReader(out))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lizard output: %w", err)
+	}
+
+	var functions []FunctionComplexity
+	for _, rec := range records {
+		if len(rec) < 11 {
+			continue
+		}
+		nloc, err := strconv.Atoi(rec[0])
+		if err != nil {
+			// Header row
+			continue
+		}
+		ccn, _ := strconv.Atoi(rec[1])
+		params, _ := strconv.Atoi(rec[3])
+		start, _ := strconv.Atoi(rec[9])
+		end, _ := strconv.Atoi(rec[10])
+		functions = append(functions, FunctionComplexity{
+			File:      normalizeAuditPath(rec[6]),
+			Name:      rec[7],
+			Signature: rec[8],
+			Line:      start,
+			EndLine:   end,
+			NLOC:      nloc,
+			CCN:       ccn,
+			Params:    params,
+			Complex:   ccn > threshold,
+		})
+	}
+	return functions, nil
+}
+
+// complexityFindings reports the functions above the complexity threshold
+// as findings.
+func complexityFindings(summary ComplexitySummary) ToolResults {
+	result := ToolResults{
+		Tool:    "Complexity",
+		Status:  summary.Status,
+		Error:   summary.Error,
+		Results: []AnalysisResult{},
+	}
+	for _, fn := range summary.Functions {
+		if !fn.Complex {
+			continue
+		}
+		severity := "warning"
+		if fn.CCN > 2*summary.Threshold {
+			severity = "error"
+		}
+		result.Results = append(result.Results, AnalysisResult{
+			Tool:     "Complexity",
+			Severity: severity,
+			File:     fn.File,
+			Line:     fn.Line,
+			EndLine:  fn.EndLine,
+			Message:  fmt.Sprintf("%s has cyclomatic complexity %d (threshold %d)", fn.Name, fn.CCN, summary.Threshold),
+			Rule:     "cyclomatic-complexity",
+		})
+	}
+	return result
+}
+
+// auditFiles groups findings, coverage and functions by file.
+func auditFiles(report *AuditReport) []AuditFile {
+	byPath := map[string]*AuditFile{}
+	file := func(p string) *AuditFile {
+		p = normalizeAuditPath(p)
+		if f, ok := byPath[p]; ok {
+			return f
+		}
+		f := &AuditFile{Path: p}
+		byPath[p] = f
+		return f
+	}
+
+	for _, t := range report.Tools {
+		for _, r := range t.Results {
+			if r.File == "" {
+				continue
+			}
+			f := file(r.File)
+			f.Findings = append(f.Findings, r)
+		}
+	}
+	for i := range report.Coverage.Files {
+		file(report.Coverage.Files[i].File).Coverage = &report.Coverage.Files[i]
+	}
+	for _, fn := range report.Complexity.Functions {
+		f := file(fn.File)
+		f.Functions = append(f.Functions, fn)
+	}
+
+	files := make([]AuditFile, 0, len(byPath))
+	pages := map[string]bool{}
+	for _, f := range byPath {
+		sort.SliceStable(f.Findings, func(i, j int) bool { return f.Findings[i].Line < f.Findings[j].Line })
+		sort.SliceStable(f.Functions, func(i, j int) bool { return f.Functions[i].Line < f.Functions[j].Line })
+		files = append(files, *f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	for i := range files {
+		files[i].Page = auditPage(files[i].Path, pages)
+	}
+	return files
+}
+
+// auditPage returns a unique page name under files/ for a source file.
+func auditPage(file string, taken map[string]bool) string {
+	base := strings.NewReplacer("/", "_", "\\", "_", ":", "_", "..", "_").Replace(file)
+	page := "files/" + base + ".html"
+	for n := 2; taken[page]; n++ {
+		page = fmt.Sprintf("files/%s-%d.html", base, n)
+	}
+	taken[page] = true
+	return page
+}
+
+// normalizeAuditPath makes tool paths comparable: relative to the project
+// and with forward slashes.
+func normalizeAuditPath(file string) string {
+	return path.Clean(relPath(file))
+}
@@ -0,0 +1,281 @@
+package quality
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// auditLine is a source line on a file page.
+type auditLine struct {
+	Number     int
+	Text       string
+	Executable bool
+	Hits       int
+	Findings   []AnalysisResult
+	Function   *FunctionComplexity
+}
+
+// auditFilePage is the data of a file page.
+type auditFilePage struct {
+	Report *AuditReport
+	File   AuditFile
+	Lines  []auditLine
+}
+
+var auditFuncs = template.FuncMap{
+	"pct": func(p float64) string {
+		if p < 0 {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.1f%%", p)
+	},
+	"pctClass": func(p float64) string {
+		switch {
+		case p < 0:
+			return "none"
+		case p >= 80:
+			return "good"
+		case p >= 50:
+			return "fair"
+		}
+		return "poor"
+	},
+}
+
+// writeAuditReport writes index.html, a page per file and audit.json to dir.
+func writeAuditReport(report *AuditReport, dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	index, err := template.New("index").Funcs(auditFuncs).Parse(auditStyle + auditIndexTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	if err := executeAuditTemplate(index, filepath.Join(dir, "index.html"), report); err != nil {
+		return err
+	}
+
+	page, err := template.New("file").Funcs(auditFuncs).Parse(auditStyle + auditFileTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	for _, f := range report.Files {
+		data := auditFilePage{Report: report, File: f, Lines: auditSourceLines(f)}
+		if err := executeAuditTemplate(page, filepath.Join(dir, filepath.FromSlash(f.Page)), data); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "audit.json"), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write audit.json: %w", err)
+	}
+	return nil
+}
+
+func executeAuditTemplate(tmpl *template.Template, path string, data any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+	if err := tmpl.Execute(file, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// auditSourceLines annotates the source of f with coverage, findings and
+// function complexity. It returns nil if the source cannot be read.
+func auditSourceLines(f AuditFile) []auditLine {
+	data, err := os.ReadFile(filepath.FromSlash(f.Path))
+	if err != nil {
+		return nil
+	}
+	text := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	lines := make([]auditLine, len(text))
+	for i, t := range text {
+		lines[i] = auditLine{Number: i + 1, Text: t}
+		if f.Coverage != nil {
+			lines[i].Hits, lines[i].Executable = f.Coverage.Lines[i+1]
+		}
+	}
+	for _, r := range f.Findings {
+		if r.Line >= 1 && r.Line <= len(lines) {
+			lines[r.Line-1].Findings = append(lines[r.Line-1].Findings, r)
+		}
+	}
+	for i := range f.Functions {
+		if fn := f.Functions[i]; fn.Line >= 1 && fn.Line <= len(lines) {
+			lines[fn.Line-1].Function = &f.Functions[i]
+		}
+	}
+	return lines
+}
+
+const auditStyle = `{{define "style"}}<style>
+  * { box-sizing: border-box; }
+  body { margin: 0; padding: 24px; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Arial, sans-serif; background: #0f1020; color: #e2e8f0; line-height: 1.5; }
+  a { color: #38bdf8; text-decoration: none; }
+  a:hover { text-decoration: underline; }
+  h1 { margin: 0 0 4px; color: #00d4ff; }
+  h2 { margin: 32px 0 12px; font-size: 1.2em; color: #cbd5e1; }
+  .muted { color: #94a3b8; font-size: 0.9em; }
+  .cards { display: grid; grid-template-columns: repeat(auto-fit, minmax(170px, 1fr)); gap: 12px; margin-top: 20px; }
+  .card { background: #1a1b33; border: 1px solid #2a2d4f; border-radius: 10px; padding: 14px 16px; }
+  .card .label { color: #94a3b8; font-size: 0.8em; text-transform: uppercase; letter-spacing: 0.05em; }
+  .card .value { font-size: 1.8em; font-weight: 700; }
+  .card .note { color: #94a3b8; font-size: 0.8em; }
+  table { width: 100%; border-collapse: collapse; font-size: 0.9em; }
+  th, td { padding: 6px 10px; border-bottom: 1px solid #24264a; text-align: left; vertical-align: top; }
+  th { color: #94a3b8; font-weight: 600; background: #16172d; position: sticky; top: 0; }
+  td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+  .error { color: #f87171; }
+  .warning { color: #fbbf24; }
+  .style, .info, .information, .performance, .portability { color: #60a5fa; }
+  .status-success { color: #4ade80; }
+  .status-skipped { color: #94a3b8; }
+  .status-error { color: #f87171; }
+  .good { color: #4ade80; }
+  .fair { color: #fbbf24; }
+  .poor { color: #f87171; }
+  .none { color: #64748b; }
+  .bar { display: inline-block; width: 80px; height: 8px; background: #2a2d4f; border-radius: 4px; overflow: hidden; vertical-align: middle; margin-right: 6px; }
+  .bar span { display: block; height: 100%; background: currentColor; }
+  code, pre { font-family: 'SF Mono', Menlo, Consolas, monospace; }
+  .source { background: #12132a; border: 1px solid #24264a; border-radius: 8px; overflow-x: auto; font-size: 0.85em; }
+  .source td { border: none; padding: 0 8px; white-space: pre; }
+  .source td.ln { color: #475569; text-align: right; user-select: none; width: 1%; }
+  .source td.hits { color: #64748b; text-align: right; width: 1%; }
+  .source tr.covered td.code { background: rgba(74, 222, 128, 0.08); }
+  .source tr.uncovered td.code { background: rgba(248, 113, 113, 0.14); }
+  .source tr.finding td { white-space: normal; background: #1e1f3a; padding: 4px 8px; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Arial, sans-serif; }
+  .source tr.function td { white-space: normal; color: #a78bfa; padding-top: 6px; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Arial, sans-serif; }
+  .rule { color: #94a3b8; }
+</style>{{end}}`
+
+const auditIndexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Cpx Audit Report</title>
+{{template "style"}}
+</head>
+<body>
+<h1>Cpx Audit Report</h1>
+<div class="muted">Generated {{.Timestamp.Format "2006-01-02 15:04:05"}} &middot; <a href="audit.json">audit.json</a></div>
+
+<div class="cards">
+  <div class="card"><div class="label">Findings</div><div class="value">{{.TotalFindings}}</div></div>
+  {{range .Tools}}
+  <div class="card"><div class="label">{{.Tool}}</div>
+    {{if eq .Status "success"}}<div class="value">{{len .Results}}</div><div class="note">findings</div>
+    {{else}}<div class="value status-{{.Status}}">{{.Status}}</div><div class="note">{{.Error}}</div>{{end}}
+  </div>
+  {{end}}
+  <div class="card"><div class="label">Line coverage</div>
+    {{if eq .Coverage.Status "success"}}<div class="value {{pctClass .Coverage.Percent}}">{{pct .Coverage.Percent}}</div><div class="note">{{.Coverage.LinesCovered}} / {{.Coverage.LinesTotal}} lines</div>
+    {{else}}<div class="value status-{{.Coverage.Status}}">{{.Coverage.Status}}</div><div class="note">{{.Coverage.Error}}</div>{{end}}
+  </div>
+  <div class="card"><div class="label">Functions</div>
+    {{if eq .Complexity.Status "success"}}<div class="value">{{len .Complexity.Functions}}</div><div class="note">complexity threshold {{.Complexity.Threshold}}</div>
+    {{else}}<div class="value status-{{.Complexity.Status}}">{{.Complexity.Status}}</div><div class="note">{{.Complexity.Error}}</div>{{end}}
+  </div>
+</div>
+
+<h2>Files</h2>
+{{if .Files}}
+<table>
+  <thead><tr><th>File</th><th class="num">Errors</th><th class="num">Warnings</th><th class="num">Findings</th><th>Coverage</th><th class="num">Functions</th><th class="num">Max CCN</th></tr></thead>
+  <tbody>
+  {{range .Files}}
+  <tr>
+    <td><a href="{{.Page}}">{{.Path}}</a></td>
+    <td class="num {{if gt (.Count "error") 0}}error{{end}}">{{.Count "error"}}</td>
+    <td class="num {{if gt (.Count "warning") 0}}warning{{end}}">{{.Count "warning"}}</td>
+    <td class="num">{{len .Findings}}</td>
+    <td>{{with .Coverage}}<span class="{{pctClass .Percent}}"><span class="bar"><span style="width: {{printf "%.0f" .Percent}}%"></span></span>{{pct .Percent}}</span>{{else}}<span class="none">n/a</span>{{end}}</td>
+    <td class="num">{{len .Functions}}</td>
+    <td class="num {{if gt .MaxCCN $.Complexity.Threshold}}warning{{end}}">{{if .Functions}}{{.MaxCCN}}{{end}}</td>
+  </tr>
+  {{end}}
+  </tbody>
+</table>
+{{else}}
+<p class="muted">No findings, coverage or complexity data.</p>
+{{end}}
+</body>
+</html>
+`
+
+const auditFileTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.File.Path}} - Cpx Audit Report</title>
+{{template "style"}}
+</head>
+<body>
+<div class="muted"><a href="../index.html">&larr; Audit report</a></div>
+<h1>{{.File.Path}}</h1>
+
+<div class="cards">
+  <div class="card"><div class="label">Findings</div><div class="value">{{len .File.Findings}}</div><div class="note">{{.File.Count "error"}} errors, {{.File.Count "warning"}} warnings</div></div>
+  <div class="card"><div class="label">Line coverage</div>
+    {{with .File.Coverage}}<div class="value {{pctClass .Percent}}">{{pct .Percent}}</div><div class="note">{{.LinesCovered}} / {{.LinesTotal}} lines</div>
+    {{else}}<div class="value none">n/a</div>{{end}}
+  </div>
+  <div class="card"><div class="label">Max CCN</div><div class="value {{if gt .File.MaxCCN .Report.Complexity.Threshold}}warning{{end}}">{{.File.MaxCCN}}</div><div class="note">{{len .File.Functions}} functions</div></div>
+</div>
+
+{{if .File.Findings}}
+<h2>Findings</h2>
+<table>
+  <thead><tr><th class="num">Line</th><th>Tool</th><th>Severity</th><th>Message</th><th>Rule</th></tr></thead>
+  <tbody>
+  {{range .File.Findings}}
+  <tr><td class="num"><a href="#L{{.Line}}">{{.Line}}</a></td><td>{{.Tool}}</td><td class="{{.Severity}}">{{.Severity}}</td><td>{{.Message}}</td><td class="rule">{{.Rule}}</td></tr>
+  {{end}}
+  </tbody>
+</table>
+{{end}}
+
+{{if .File.Functions}}
+<h2>Functions</h2>
+<table>
+  <thead><tr><th>Function</th><th class="num">Line</th><th class="num">CCN</th><th class="num">NLOC</th><th class="num">Params</th></tr></thead>
+  <tbody>
+  {{range .File.Functions}}
+  <tr><td><code>{{.Name}}</code></td><td class="num"><a href="#L{{.Line}}">{{.Line}}</a></td><td class="num {{if .Complex}}warning{{end}}">{{.CCN}}</td><td class="num">{{.NLOC}}</td><td class="num">{{.Params}}</td></tr>
+  {{end}}
+  </tbody>
+</table>
+{{end}}
+
+<h2>Source</h2>
+{{if .Lines}}
+<table class="source">
+  {{range .Lines}}
+  {{with .Function}}<tr class="function"><td></td><td></td><td>{{.Name}} &middot; CCN {{.CCN}} &middot; {{.NLOC}} NLOC</td></tr>{{end}}
+  <tr id="L{{.Number}}" class="{{if .Executable}}{{if gt .Hits 0}}covered{{else}}uncovered{{end}}{{end}}">
+    <td class="ln">{{.Number}}</td><td class="hits">{{if .Executable}}{{.Hits}}{{end}}</td><td class="code">{{.Text}}</td>
+  </tr>
+  {{range .Findings}}<tr class="finding"><td></td><td></td><td><span class="{{.Severity}}">{{.Severity}}</span> {{.Tool}}: {{.Message}} <span class="rule">[{{.Rule}}]</span></td></tr>{{end}}
+  {{end}}
+</table>
+{{else}}
+<p class="muted">Source not available.</p>
+{{end}}
+</body>
+</html>
+`
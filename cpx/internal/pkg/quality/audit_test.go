@@ -0,0 +1,143 @@
+package quality
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gcovrJSON = `{
+  "gcovr/format_version": "0.6",
+  "files": [
+    {
+      "file": "src/main.cpp",
+      "lines": [
+        {"line_number": 1, "count": 1, "gcovr/noncode": false},
+        {"line_number": 2, "count": 0, "gcovr/noncode": false},
+        {"line_number": 3, "count": 0, "gcovr/noncode": true},
+        {"line_number": 4, "count": 0, "gcovr/noncode": false},
+        {"line_number": 4, "count": 2, "gcovr/noncode": false}
+      ]
+    }
+  ]
+}`
+
+func TestParseGcovrJSON(t *testing.T) {
+	files, err := parseGcovrJSON([]byte(gcovrJSON))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	cov := files[0]
+	assert.Equal(t, "src/main.cpp", cov.File)
+	assert.Equal(t, 3, cov.LinesTotal)
+	assert.Equal(t, 2, cov.LinesCovered, "instantiations of a line are merged")
+	assert.Equal(t, map[int]int{1: 1, 2: 0, 4: 2}, cov.Lines)
+	assert.InDelta(t, 66.7, cov.Percent(), 0.1)
+
+	_, err = parseGcovrJSON([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestParseLizardCSV(t *testing.T) {
+	out := `NLOC,CCN,token,PARAM,length,location,file,function,long_name,start,end
+12,3,80,1,14,"main@3-16@./src/main.cpp","./src/main.cpp","main","main( int argc , char * argv [ ] )",3,16
+40,22,300,2,45,"parse@20-64@./src/parser.cpp","./src/parser.cpp","parse","parse( const std :: string & s , int flags )",20,64
+`
+	functions, err := parseLizardCSV(out, 15)
+	require.NoError(t, err)
+	require.Len(t, functions, 2)
+
+	assert.Equal(t, FunctionComplexity{
+		File: "src/main.cpp", Name: "main", Line: 3, EndLine: 16, NLOC: 12, CCN: 3, Params: 1,
+		Signature: "main( int argc , char * argv [ ] )",
+	}, functions[0])
+	assert.True(t, functions[1].Complex)
+
+	findings := complexityFindings(ComplexitySummary{Status: "success", Threshold: 15, Functions: functions})
+	require.Len(t, findings.Results, 1)
+	assert.Equal(t, "src/parser.cpp", findings.Results[0].File)
+	assert.Equal(t, "warning", findings.Results[0].Severity)
+}
+
+func TestAuditFiles(t *testing.T) {
+	root, err := os.Getwd()
+	require.NoError(t, err)
+	report := &AuditReport{
+		Tools: []ToolResults{
+			{Tool: "clang-tidy", Status: "success", Results: []AnalysisResult{
+				{File: filepath.Join(root, "src", "main.cpp"), Line: 9, Severity: "warning"},
+				{File: "src/main.cpp", Line: 2, Severity: "error"},
+			}},
+			{Tool: "Cppcheck", Status: "success", Results: []AnalysisResult{{Line: 1}}},
+		},
+		Coverage:   CoverageSummary{Files: []FileCoverage{{File: "src/util.cpp", LinesTotal: 4, LinesCovered: 1}}},
+		Complexity: ComplexitySummary{Functions: []FunctionComplexity{{File: "src/main.cpp", Name: "main", CCN: 7}}},
+	}
+
+	files := auditFiles(report)
+	require.Len(t, files, 2, "findings without a file are not attached to a page")
+
+	main := files[0]
+	assert.Equal(t, "src/main.cpp", main.Path)
+	assert.Equal(t, "files/src_main.cpp.html", main.Page)
+	require.Len(t, main.Findings, 2)
+	assert.Equal(t, 2, main.Findings[0].Line, "findings are sorted by line")
+	assert.Equal(t, 1, main.Count("error"))
+	assert.Equal(t, 7, main.MaxCCN())
+	assert.Nil(t, main.Coverage)
+
+	assert.Equal(t, "src/util.cpp", files[1].Path)
+	assert.Equal(t, 25.0, files[1].Coverage.Percent())
+
+	taken := map[string]bool{}
+	assert.Equal(t, "files/a_b.c.html", auditPage("a/b.c", taken))
+	assert.Equal(t, "files/a_b.c-2.html", auditPage("a_b.c", taken))
+}
+
+func TestWriteAuditReport(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("src", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("src", "main.cpp"), []byte("int main() {\n  char *p = 0;\n  return *p;\n}\n"), 0644))
+
+	report := &AuditReport{
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Tools: []ToolResults{
+			{Tool: "Cppcheck", Status: "success", Results: []AnalysisResult{
+				{Tool: "Cppcheck", File: "src/main.cpp", Line: 3, Severity: "error", Message: "Null pointer dereference: p", Rule: "nullPointer"},
+			}},
+			{Tool: "clang-tidy", Status: "skipped", Error: "clang-tidy not found", Results: []AnalysisResult{}},
+		},
+		Coverage: CoverageSummary{Status: "success", LinesTotal: 3, LinesCovered: 2, Files: []FileCoverage{
+			{File: "src/main.cpp", LinesTotal: 3, LinesCovered: 2, Lines: map[int]int{1: 1, 2: 1, 3: 0}},
+		}},
+		Complexity: ComplexitySummary{Status: "skipped", Error: "lizard not found", Threshold: 15},
+	}
+	report.Files = auditFiles(report)
+
+	dir := filepath.Join("report", "audit")
+	require.NoError(t, writeAuditReport(report, dir))
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), `<a href="files/src_main.cpp.html">src/main.cpp</a>`)
+	assert.Contains(t, string(index), "clang-tidy not found")
+	assert.Contains(t, string(index), "66.7%")
+
+	page, err := os.ReadFile(filepath.Join(dir, "files", "src_main.cpp.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `<a href="../index.html">`)
+	assert.Contains(t, string(page), `<tr id="L3" class="uncovered">`)
+	assert.Contains(t, string(page), "Null pointer dereference: p")
+	assert.Contains(t, string(page), "  char *p = 0;")
+
+	data, err := os.ReadFile(filepath.Join(dir, "audit.json"))
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Len(t, decoded["files"], 1)
+}
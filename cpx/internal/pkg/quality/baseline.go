@@ -0,0 +1,192 @@
+package quality
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+)
+
+// Baseline files of the analysis tools, checked in at the project root.
+const (
+	CppcheckBaselineFile   = ".cppcheck-baseline.json"
+	FlawfinderBaselineFile = ".flawfinder-baseline.json"
+)
+
+// Baseline is the set of accepted findings of an analysis tool. Runs with a
+// baseline only fail on findings that are not in it.
+type Baseline struct {
+	Tool     string          `json:"tool"`
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is an accepted finding. Line is informational: findings are
+// matched by Fingerprint, which does not change when code above them moves.
+type BaselineEntry struct {
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Rule        string `json:"rule"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// NewBaseline returns a baseline accepting results.
+func NewBaseline(tool string, results []AnalysisResult) *Baseline {
+	fp := newFingerprinter()
+	b := &Baseline{Tool: tool, Findings: []BaselineEntry{}}
+	for _, r := range results {
+		b.Findings = append(b.Findings, BaselineEntry{
+			File:        relPath(r.File),
+			Line:        r.Line,
+			Rule:        r.Rule,
+			Message:     r.Message,
+			Fingerprint: fp.fingerprint(r),
+		})
+	}
+	sort.SliceStable(b.Findings, func(i, j int) bool {
+		if b.Findings[i].File != b.Findings[j].File {
+			return b.Findings[i].File < b.Findings[j].File
+		}
+		return b.Findings[i].Line < b.Findings[j].Line
+	})
+	return b
+}
+
+// LoadBaseline reads a baseline file.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to path.
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline %s: %w", path, err)
+	}
+	return nil
+}
+
+// NewFindings returns the results that are not in the baseline. A finding
+// repeated more often than the baseline recorded counts as new.
+func (b *Baseline) NewFindings(results []AnalysisResult) []AnalysisResult {
+	accepted := make(map[string]int, len(b.Findings))
+	for _, f := range b.Findings {
+		accepted[f.Fingerprint]++
+	}
+
+	fp := newFingerprinter()
+	var added []AnalysisResult
+	for _, r := range results {
+		key := fp.fingerprint(r)
+		if accepted[key] > 0 {
+			accepted[key]--
+			continue
+		}
+		added = append(added, r)
+	}
+	return added
+}
+
+// fingerprinter identifies findings by file, rule, message and the text of
+// the flagged source line, caching the files it reads.
+type fingerprinter struct {
+	files map[string][]string
+}
+
+func newFingerprinter() *fingerprinter {
+	return &fingerprinter{files: make(map[string][]string)}
+}
+
+func (f *fingerprinter) fingerprint(r AnalysisResult) string {
+	h := sha256.New()
+	for _, part := range []string{relPath(r.File), r.Rule, r.Message, f.sourceLine(r.File, r.Line)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// sourceLine returns the whitespace-trimmed text of a line of file, or ""
+// if it cannot be read.
+func (f *fingerprinter) sourceLine(file string, line int) string {
+	lines, ok := f.files[file]
+	if !ok {
+		if data, err := os.ReadFile(file); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		f.files[file] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.Join(strings.Fields(lines[line-1]), " ")
+}
+
+// relPath makes absolute paths inside the project relative, so baselines
+// recorded in one checkout match in another.
+func relPath(file string) string {
+	if !filepath.IsAbs(file) {
+		return filepath.ToSlash(file)
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return file
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// recordBaseline writes a baseline of results to path and reports it.
+func recordBaseline(tool, path string, results []AnalysisResult) error {
+	if err := NewBaseline(tool, results).Save(path); err != nil {
+		return err
+	}
+	output.Successf(" Recorded %d %s finding(s) in %s", len(results), tool, path)
+	output.Infof("  Commit it; later runs only fail on findings that are not in the baseline.")
+	return nil
+}
+
+// checkBaseline compares results with the baseline at path, if there is one,
+// and returns an error listing the findings that are not in it.
+func checkBaseline(tool, path string, results []AnalysisResult) error {
+	baseline, err := LoadBaseline(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	added := baseline.NewFindings(results)
+	if len(added) == 0 {
+		output.Successf(" No new %s findings (%d in baseline %s)", tool, len(baseline.Findings), path)
+		return nil
+	}
+
+	fmt.Printf("\n%s New %s findings not in %s:%s\n", colors.Red, tool, path, colors.Reset)
+	for _, r := range added {
+		fmt.Printf("  %s:%d: %s%s%s: %s [%s]\n", r.File, r.Line, severityColor(r.Severity), r.Severity, colors.Reset, r.Message, r.Rule)
+	}
+	return fmt.Errorf("%d new %s finding(s) not in the baseline\n  hint: fix them, or accept them by re-recording the baseline with --baseline", len(added), tool)
+}
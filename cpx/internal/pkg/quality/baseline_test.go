@@ -0,0 +1,73 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselineRoundTrip(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("main.cpp", []byte("int main() {\n  char buf[10];\n  strcpy(buf, argv[1]);\n}\n"), 0644))
+
+	results := []AnalysisResult{
+		{File: "main.cpp", Line: 3, Rule: "strcpy", Message: "Does not check for buffer overflows"},
+		{File: "main.cpp", Line: 2, Rule: "char", Message: "Statically-sized arrays"},
+	}
+	baseline := NewBaseline("flawfinder", results)
+	assert.Equal(t, 2, baseline.Findings[0].Line, "findings are sorted by file and line")
+	require.NoError(t, baseline.Save(FlawfinderBaselineFile))
+
+	loaded, err := LoadBaseline(FlawfinderBaselineFile)
+	require.NoError(t, err)
+	assert.Equal(t, baseline, loaded)
+	assert.Empty(t, loaded.NewFindings(results))
+
+	_, err = LoadBaseline("missing.json")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestBaselineNewFindings(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("main.cpp", []byte("strcpy(a, b);\n"), 0644))
+
+	strcpy := AnalysisResult{File: "main.cpp", Line: 1, Rule: "strcpy", Message: "Does not check for buffer overflows"}
+	baseline := NewBaseline("flawfinder", []AnalysisResult{strcpy})
+
+	// Code inserted above a finding moves it without making it new
+	require.NoError(t, os.WriteFile("main.cpp", []byte("#include <cstring>\n\nstrcpy(a, b);\n"), 0644))
+	moved := strcpy
+	moved.Line = 3
+	assert.Empty(t, baseline.NewFindings([]AnalysisResult{moved}))
+
+	// A second occurrence of the same finding is new
+	assert.Len(t, baseline.NewFindings([]AnalysisResult{moved, moved}), 1)
+
+	abs, err := filepath.Abs("main.cpp")
+	require.NoError(t, err)
+	absolute := moved
+	absolute.File = abs
+	assert.Empty(t, baseline.NewFindings([]AnalysisResult{absolute}))
+
+	other := AnalysisResult{File: "main.cpp", Line: 3, Rule: "strcat", Message: "Does not check for buffer overflows"}
+	assert.Equal(t, []AnalysisResult{other}, baseline.NewFindings([]AnalysisResult{moved, other}))
+}
+
+func TestCheckBaseline(t *testing.T) {
+	t.Chdir(t.TempDir())
+	finding := AnalysisResult{File: "a.cpp", Line: 1, Rule: "nullPointer", Message: "Null pointer dereference", Severity: "error"}
+
+	assert.NoError(t, checkBaseline("cppcheck", CppcheckBaselineFile, []AnalysisResult{finding}), "no baseline, nothing fails")
+
+	require.NoError(t, recordBaseline("cppcheck", CppcheckBaselineFile, []AnalysisResult{finding}))
+	assert.NoError(t, checkBaseline("cppcheck", CppcheckBaselineFile, []AnalysisResult{finding}))
+
+	added := finding
+	added.Rule = "uninitvar"
+	err := checkBaseline("cppcheck", CppcheckBaselineFile, []AnalysisResult{finding, added})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 new cppcheck finding(s)")
+}
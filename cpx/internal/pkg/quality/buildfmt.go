@@ -0,0 +1,163 @@
+package quality
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// buildFormatter formats one kind of build file with an external tool
+type buildFormatter struct {
+	Kind    string   // CMake, Bazel or Meson
+	Tool    string   // executable
+	Install string   // how to get the tool
+	Format  []string // arguments before the file to format it in place
+	Check   []string // arguments before the file to check it; fails if it needs formatting
+	Matches func(name string) bool
+}
+
+// buildFormatters are the build file formatters cpx fmt runs
+var buildFormatters = []buildFormatter{
+	{
+		Kind:    "CMake",
+		Tool:    "cmake-format",
+		Install: "pip install cmakelang",
+		Format:  []string{"-i"},
+		Check:   []string{"--check"},
+		Matches: func(name string) bool {
+			return name == "CMakeLists.txt" || strings.HasSuffix(name, ".cmake")
+		},
+	},
+	{
+		Kind:    "Bazel",
+		Tool:    "buildifier",
+		Install: "go install github.com/bazelbuild/buildtools/buildifier@latest",
+		Format:  []string{"-mode=fix", "-lint=off"},
+		Check:   []string{"-mode=check", "-lint=off"},
+		Matches: func(name string) bool {
+			switch name {
+			case "BUILD", "BUILD.bazel", "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel":
+				return true
+			}
+			return strings.HasSuffix(name, ".bzl")
+		},
+	},
+	{
+		Kind:    "Meson",
+		Tool:    "meson",
+		Install: "pip install 'meson>=1.5'",
+		Format:  []string{"format", "--inplace"},
+		Check:   []string{"format", "--check-only"},
+		Matches: func(name string) bool {
+			return name == "meson.build" || name == "meson.options" || name == "meson_options.txt"
+		},
+	},
+}
+
+// IsBuildFile reports whether cpx fmt formats the file at path as a
+// CMake, Bazel or Meson build file.
+func IsBuildFile(path string) bool {
+	name := filepath.Base(path)
+	for _, f := range buildFormatters {
+		if f.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildFileSkipDirs are not searched for build files: they hold build
+// output or other projects' code.
+var buildFileSkipDirs = map[string]bool{
+	".git": true, "build": true, "builddir": true,
+	"out": true, "subprojects": true, "third_party": true, "vcpkg_installed": true,
+	"node_modules": true,
+}
+
+// FindBuildFiles returns the CMake, Bazel and Meson build files of the
+// project in the current directory.
+func FindBuildFiles() []string {
+	var files []string
+	projectDirs := config.DirsOf(".").Within(".")
+	_ = filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != "." && (buildFileSkipDirs[info.Name()] || strings.HasPrefix(info.Name(), "bazel-") || inDirs(path, projectDirs)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsBuildFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+// FormatBuildFiles formats build files with cmake-format, buildifier and
+// meson format, skipping the kinds whose tool is not installed. With
+// checkOnly, files are only checked.
+func FormatBuildFiles(checkOnly bool, files []string) error {
+	needsFormat := false
+	for _, f := range buildFormatters {
+		var matched []string
+		for _, file := range files {
+			if f.Matches(filepath.Base(file)) {
+				matched = append(matched, file)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		if !f.available() {
+			output.Warnf("%s not found, skipping %d %s file(s) (install: %s)", f.Tool, len(matched), f.Kind, f.Install)
+			continue
+		}
+
+		args := f.Format
+		if checkOnly {
+			args = f.Check
+		}
+		for _, file := range matched {
+			cmd := exec.Command(f.Tool, append(append([]string{}, args...), file)...)
+			out, err := cmd.CombinedOutput()
+			switch {
+			case checkOnly && err != nil:
+				needsFormat = true
+				fmt.Printf("   %s %s needs formatting%s\n", colors.Yellow, file, colors.Reset)
+			case err != nil:
+				return fmt.Errorf("%s failed on %s: %w\n%s", f.Tool, file, err, out)
+			default:
+				if !checkOnly {
+					output.Infof("    %s", file)
+				}
+			}
+		}
+	}
+
+	if needsFormat {
+		return fmt.Errorf("some build files need formatting. Run 'cpx fmt' to fix")
+	}
+	return nil
+}
+
+// available reports whether the formatter's tool is installed. meson
+// format is only in Meson 1.5 and later.
+func (f buildFormatter) available() bool {
+	if _, err := exec.LookPath(f.Tool); err != nil {
+		return false
+	}
+	if f.Tool == "meson" {
+		return exec.Command("meson", "format", "--help").Run() == nil
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBuildFile(t *testing.T) {
+	for _, path := range []string{"CMakeLists.txt", "cmake/deps.cmake", "BUILD.bazel", "MODULE.bazel", "tools/defs.bzl", "meson.build", "meson.options"} {
+		assert.True(t, IsBuildFile(path), path)
+	}
+	for _, path := range []string{"src/main.cpp", "CMakeCache.txt", "README.md", "BUILD.txt"} {
+		assert.False(t, IsBuildFile(path), path)
+	}
+}
+
+func TestFindBuildFilesSkipsBuildOutput(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"CMakeLists.txt", "src/CMakeLists.txt", "src/main.cpp",
+		"build/_deps/fmt/CMakeLists.txt", "bazel-out/BUILD", "subprojects/zlib/meson.build",
+	} {
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+	t.Chdir(dir)
+
+	assert.ElementsMatch(t, []string{"CMakeLists.txt", filepath.Join("src", "CMakeLists.txt")}, FindBuildFiles())
+}
+
+func TestFormatBuildFilesSkipsMissingTools(t *testing.T) {
+	t.Setenv("PATH", "")
+	assert.NoError(t, FormatBuildFiles(true, []string{"CMakeLists.txt", "BUILD.bazel", "meson.build"}))
+}
@@ -0,0 +1,195 @@
+package quality
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/ozacod/cpx/internal/pkg/project"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// compileDatabaseName is the file clangd and clang-tidy look for
+const compileDatabaseName = "compile_commands.json"
+
+// ErrNoCompileCommandsExtractor is returned for Bazel projects without the
+// hedron compile commands extractor, which Bazel needs to write a compile
+// database.
+var ErrNoCompileCommandsExtractor = errors.New("hedron_compile_commands is not set up")
+
+// hedronSetup is what MODULE.bazel needs for the extractor
+const hedronSetup = `bazel_dep(name = "hedron_compile_commands", dev_dependency = True)
+git_override(
+    module_name = "hedron_compile_commands",
+    remote = "https://github.com/hedronvision/bazel-compile-commands-extractor.git",
+    commit = "<latest commit>",
+)`
+
+// GenerateCompileDatabase writes the compile_commands.json of the project
+// in the current directory with its build system: CMake's export for
+// CMake/vcpkg projects, meson setup for Meson and the hedron extractor for
+// Bazel. An existing database is kept unless refresh is set. It returns
+// the database's path.
+func GenerateCompileDatabase(refresh bool, vcpkg VcpkgSetup) (string, error) {
+	switch project.Detect(".") {
+	case project.Meson:
+		return generateMesonCompileDatabase(refresh)
+	case project.Bazel:
+		return generateBazelCompileDatabase(refresh)
+	}
+	return generateCMakeCompileDatabase(refresh, vcpkg)
+}
+
+func generateMesonCompileDatabase(refresh bool) (string, error) {
+	buildDir := "builddir"
+	compileDb := filepath.Join(buildDir, compileDatabaseName)
+
+	var args []string
+	if _, err := os.Stat(buildDir); os.IsNotExist(err) {
+		args = []string{"setup", buildDir}
+	} else if _, err := os.Stat(compileDb); refresh || os.IsNotExist(err) {
+		args = []string{"setup", "--reconfigure", buildDir}
+	} else {
+		return compileDb, nil
+	}
+
+	output.Stepf("  Generating compile_commands.json for Meson project...")
+	cmd := exec.Command("meson", args...)
+	cmd.Stdout = output.Stdout()
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to setup meson project: %w\n  Run 'cpx build' first", err)
+	}
+	return compileDb, nil
+}
+
+func generateBazelCompileDatabase(refresh bool) (string, error) {
+	if _, err := os.Stat(compileDatabaseName); err == nil && !refresh {
+		return compileDatabaseName, nil
+	}
+
+	output.Stepf("  Generating compile_commands.json for Bazel project...")
+	cmd := exec.Command("bazel", "run", "@hedron_compile_commands//:refresh_all")
+	cmd.Stdout = output.Stdout()
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: add it to MODULE.bazel:\n\n%s\n\n  See: https://github.com/hedronvision/bazel-compile-commands-extractor", ErrNoCompileCommandsExtractor, hedronSetup)
+	}
+	return compileDatabaseName, nil
+}
+
+func generateCMakeCompileDatabase(refresh bool, vcpkg VcpkgSetup) (string, error) {
+	if err := vcpkg.SetupEnv(); err != nil {
+		return "", fmt.Errorf("failed to setup vcpkg: %w", err)
+	}
+
+	// Use .cache/native/debug for consistency with build command
+	buildDir := filepath.Join(config.CacheDir(), "native", "debug")
+	compileDb := filepath.Join(buildDir, compileDatabaseName)
+
+	switch {
+	case refresh:
+		output.Stepf("  Regenerating compile_commands.json...")
+	case !fileExists(compileDb):
+		output.Stepf("  Generating compile_commands.json...")
+	case !fileExists(filepath.Join(buildDir, "CMakeCache.txt")):
+		output.Stepf("  Regenerating compile_commands.json (CMake not configured)...")
+	default:
+		return compileDb, nil
+	}
+
+	// Get vcpkg root for toolchain file
+	vcpkgPath, err := vcpkg.GetPath()
+	if err != nil {
+		return "", fmt.Errorf("vcpkg not configured: %w", err)
+	}
+	vcpkgRoot := filepath.Dir(vcpkgPath)
+	toolchainFile := filepath.Join(vcpkgRoot, "scripts", "buildsystems", "vcpkg.cmake")
+
+	// Check if toolchain file exists
+	if _, err := os.Stat(toolchainFile); os.IsNotExist(err) {
+		return "", fmt.Errorf("vcpkg toolchain file not found: %s\n  Make sure vcpkg is properly installed", toolchainFile)
+	}
+
+	// Configure CMake with vcpkg toolchain
+	vcpkgInstalledDir, err := vcpkg.InstalledDir()
+	if err != nil {
+		return "", err
+	}
+	vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
+
+	cmakeArgs := []string{
+		"-B", buildDir,
+		"-DCMAKE_EXPORT_COMPILE_COMMANDS=ON",
+		"-DCMAKE_TOOLCHAIN_FILE=" + toolchainFile,
+		vcpkgInstallArg,
+	}
+
+	// Check if CMakePresets.json exists and use it
+	if _, err := os.Stat("CMakePresets.json"); err == nil {
+		// Use preset if available
+		cmakeArgs = []string{
+			"--preset", "default",
+			"-B", buildDir,
+			"-DCMAKE_EXPORT_COMPILE_COMMANDS=ON",
+			vcpkgInstallArg,
+		}
+	}
+
+	cmd := exec.Command("cmake", cmakeArgs...)
+	cmd.Env = os.Environ()
+	cmd.Stdout = output.Stdout()
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to generate compile_commands.json: %w\n  Try running 'cpx build' first to configure the project", err)
+	}
+	return compileDb, nil
+}
+
+// LinkCompileDatabase makes compile_commands.json in the current directory
+// point at compileDb, so clangd finds it without configuration. A database
+// already there, as a link or a copy, is replaced; where links cannot be
+// created the file is copied.
+func LinkCompileDatabase(compileDb string) error {
+	if filepath.Clean(compileDb) == compileDatabaseName {
+		return nil
+	}
+	if info, err := os.Lstat(compileDatabaseName); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", compileDatabaseName)
+		}
+		if err := os.Remove(compileDatabaseName); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", compileDatabaseName, err)
+		}
+	}
+	if err := os.Symlink(compileDb, compileDatabaseName); err == nil {
+		return nil
+	}
+	return copyFile(compileDb, compileDatabaseName)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
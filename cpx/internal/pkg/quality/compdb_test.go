@@ -0,0 +1,42 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCompileDatabaseKeepsMesonDatabase(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('demo', 'cpp')\n"), 0644))
+	require.NoError(t, os.MkdirAll("builddir", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("builddir", "compile_commands.json"), []byte("[]"), 0644))
+
+	compileDb, err := GenerateCompileDatabase(false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("builddir", "compile_commands.json"), compileDb)
+}
+
+func TestLinkCompileDatabase(t *testing.T) {
+	t.Chdir(t.TempDir())
+	compileDb := filepath.Join("builddir", "compile_commands.json")
+	require.NoError(t, os.MkdirAll("builddir", 0755))
+	require.NoError(t, os.WriteFile(compileDb, []byte(`[{"file":"a.cpp"}]`), 0644))
+	// A stale copy, as CMake's copy_compile_commands target leaves, is replaced
+	require.NoError(t, os.WriteFile("compile_commands.json", []byte("[]"), 0644))
+
+	require.NoError(t, LinkCompileDatabase(compileDb))
+	data, err := os.ReadFile("compile_commands.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"file":"a.cpp"}]`, string(data))
+
+	target, err := os.Readlink("compile_commands.json")
+	require.NoError(t, err)
+	assert.Equal(t, compileDb, target)
+
+	// Linking the root database to itself leaves it alone
+	require.NoError(t, LinkCompileDatabase("compile_commands.json"))
+}
@@ -1,122 +1,322 @@
 package quality
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
 	"github.com/ozacod/cpx/internal/pkg/utils/git"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
-func RunCppcheck(enable, output string, xml, csv, quiet, force, inlineSuppr bool, platform, std string, targets []string) error {
+// CppcheckSuppressionsFile is the checked-in suppressions file cpx cppcheck
+// uses when it exists in the project root.
+const CppcheckSuppressionsFile = ".cppcheck-suppressions"
+
+// CppcheckOptions configures RunCppcheck.
+type CppcheckOptions struct {
+	// Enable is the --enable value (all, style, performance, ...).
+	Enable string
+
+	// Output is the report file; empty prints to stdout.
+	Output string
+
+	// Format is the report format: text, xml, csv or sarif.
+	Format string
+
+	Quiet       bool
+	Force       bool
+	InlineSuppr bool
+	Platform    string
+	Std         string
+
+	// Project is a cppcheck project file or compile database. When empty and
+	// no targets are given, one is generated from compile_commands.json.
+	Project string
+
+	// Suppressions is a suppressions file (default: .cppcheck-suppressions).
+	Suppressions string
+
+	// Severities keeps only findings of these severities.
+	Severities []string
+
+	// Targets are files or directories to scan instead of using a project.
+	Targets []string
+
+	// Baseline is the baseline file (default: .cppcheck-baseline.json). When
+	// it exists, findings that are not in it fail the run.
+	Baseline string
+
+	// RecordBaseline records the current findings as the baseline.
+	RecordBaseline bool
+}
+
+// buildSystemDirs are build system directories and external dependencies
+// that are never analyzed, to prevent scanning third-party code.
+var buildSystemDirs = []string{
+	"build",       // CMake build dir
+	"builddir",    // Meson build dir
+	"subprojects", // Meson subprojects
+	"external",    // Bazel external
+	".bazel",      // Bazel cache
+	"bazel-bin",   // Bazel output
+	"bazel-out",   // Bazel output
+	"bazel-testlogs",
+	"out",
+	"bin",
+	".vcpkg",
+}
+
+// excludeDirs returns the directories of the project in root that are never
+// analyzed: buildSystemDirs and its cache and artifact directories.
+func excludeDirs(root string) []string {
+	return append(slices.Clone(buildSystemDirs), config.DirsOf(root).Within(root)...)
+}
+
+// RunCppcheck runs cppcheck on the project and writes the findings in the
+// requested format.
+func RunCppcheck(opts CppcheckOptions) error {
 	// Check if cppcheck is available
 	if _, err := exec.LookPath("cppcheck"); err != nil {
 		return fmt.Errorf("cppcheck not found. Please install it first:\n  brew install cppcheck\n  or\n  apt-get install cppcheck (Debian/Ubuntu)\n  or\n  Download from https://cppcheck.sourcecpx.io/")
 	}
-
-	fmt.Printf("%s Running Cppcheck analysis...%s\n", colors.Cyan, colors.Reset)
-
-	// Filter targets to only include git-tracked files (respect .gitignore)
-	filteredTargets, err := git.FilterGitTrackedFiles(targets)
-	if err != nil {
-		// If git is not available or not in a git repo, use original targets
-		fmt.Printf("%s Warning: Not in a git repository or git not available. Scanning all files.%s\n", colors.Yellow, colors.Reset)
-		filteredTargets = targets
-	} else if len(filteredTargets) == 0 {
-		return fmt.Errorf("no git-tracked C/C++ files found to scan")
+	if err := validateSeverities(opts.Severities); err != nil {
+		return err
 	}
 
+	output.Stepf(" Running Cppcheck analysis...")
+
 	// Build cppcheck command
 	var cppcheckArgs []string
 
 	// Enable checks
-	if enable != "" {
-		cppcheckArgs = append(cppcheckArgs, "--enable="+enable)
-	}
-
-	// Output format
-	if xml {
-		cppcheckArgs = append(cppcheckArgs, "--xml")
-	} else if csv {
-		// Cppcheck uses --template for CSV format, not --csv
-		cppcheckArgs = append(cppcheckArgs, "--template={file},{line},{severity},{id},{message}")
-	}
-
-	// Output file
-	if output != "" {
-		cppcheckArgs = append(cppcheckArgs, "--output-file="+output)
-		fmt.Printf("%s Writing output to: %s%s\n", colors.Cyan, output, colors.Reset)
+	if opts.Enable != "" {
+		cppcheckArgs = append(cppcheckArgs, "--enable="+opts.Enable)
 	}
 
 	// Quiet mode
-	if quiet {
+	if opts.Quiet {
 		cppcheckArgs = append(cppcheckArgs, "--quiet")
 	}
 
 	// Force checking all configurations
-	if force {
+	if opts.Force {
 		cppcheckArgs = append(cppcheckArgs, "--force")
 	}
 
 	// Inline suppressions
-	if inlineSuppr {
+	if opts.InlineSuppr {
 		cppcheckArgs = append(cppcheckArgs, "--inline-suppr")
 	}
 
 	// Platform
-	if platform != "" {
-		cppcheckArgs = append(cppcheckArgs, "--platform="+platform)
+	if opts.Platform != "" {
+		cppcheckArgs = append(cppcheckArgs, "--platform="+opts.Platform)
 	}
 
 	// C/C++ standard
-	if std != "" {
-		cppcheckArgs = append(cppcheckArgs, "--std="+std)
+	if opts.Std != "" {
+		cppcheckArgs = append(cppcheckArgs, "--std="+opts.Std)
 	}
 
-	// Add exclusions for build system directories and external dependencies
-	// to prevent scanning third-party code
-	excludeDirs := []string{
-		"build",       // CMake build dir
-		"builddir",    // Meson build dir
-		"subprojects", // Meson subprojects
-		"external",    // Bazel external
-		".bazel",      // Bazel cache
-		".cache",      // vcpkg cache
-		"bazel-bin",   // Bazel output
-		"bazel-out",   // Bazel output
-		"bazel-testlogs",
-		"out",
-		"bin",
-		".vcpkg",
+	// Checked-in suppressions
+	suppressions := opts.Suppressions
+	if suppressions == "" {
+		if _, err := os.Stat(CppcheckSuppressionsFile); err == nil {
+			suppressions = CppcheckSuppressionsFile
+		}
+	} else if _, err := os.Stat(suppressions); err != nil {
+		return fmt.Errorf("suppressions file not found: %s", suppressions)
+	}
+	if suppressions != "" {
+		output.Infof("%s Using suppressions from %s%s", colors.Gray, suppressions, colors.Reset)
+		cppcheckArgs = append(cppcheckArgs, "--suppressions-list="+suppressions)
 	}
 
-	// Exclude any directory starting with "bazel-" (project-specific bazel dirs)
-	for _, dir := range excludeDirs {
-		cppcheckArgs = append(cppcheckArgs, "-i"+dir)
+	sourceArgs, err := cppcheckSources(opts)
+	if err != nil {
+		return err
 	}
+	cppcheckArgs = append(cppcheckArgs, sourceArgs...)
 
-	// Add target files
-	cppcheckArgs = append(cppcheckArgs, filteredTargets...)
+	// Findings are collected as XML and then filtered and converted
+	tmpXML, err := os.CreateTemp("", "cppcheck-*.xml")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpXML.Close()
+	defer os.Remove(tmpXML.Name())
+	cppcheckArgs = append(cppcheckArgs, "--xml", "--xml-version=2", "--output-file="+tmpXML.Name())
 
 	cmd := exec.Command("cppcheck", cppcheckArgs...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+
+	data, err := os.ReadFile(tmpXML.Name())
+	if err != nil || len(bytes.TrimSpace(data)) == 0 {
+		if runErr != nil {
+			return fmt.Errorf("cppcheck failed: %w", runErr)
+		}
+		return fmt.Errorf("cppcheck wrote no results")
+	}
+	report, err := parseCppcheckReport(data)
+	if err != nil {
+		return err
+	}
+	report.filterSeverity(opts.Severities)
 
-	if err := cmd.Run(); err != nil {
-		// Cppcheck returns non-zero on findings, which is normal
-		if output != "" {
-			fmt.Printf("%s  Cppcheck found potential issues (saved to %s)%s\n", colors.Yellow, output, colors.Reset)
+	baseline := opts.Baseline
+	if baseline == "" {
+		baseline = CppcheckBaselineFile
+	}
+	if opts.RecordBaseline {
+		return recordBaseline("cppcheck", baseline, report.results())
+	}
+
+	var w io.Writer = os.Stdout
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", opts.Output, err)
+		}
+		defer f.Close()
+		w = f
+		output.Stepf(" Writing output to: %s", opts.Output)
+	}
+	if err := report.write(w, opts.Format, opts.Output == ""); err != nil {
+		return err
+	}
+
+	if n := len(report.Errors); n > 0 {
+		// Findings are reported, not treated as a failure unless they are
+		// missing from the baseline
+		if opts.Output != "" {
+			output.Warnf("Cppcheck found %d potential issue(s) (saved to %s)", n, opts.Output)
 		} else {
-			fmt.Printf("%s  Cppcheck found potential issues%s\n", colors.Yellow, colors.Reset)
+			output.Warnf("Cppcheck found %d potential issue(s)", n)
 		}
-		return nil
+		return checkBaseline("cppcheck", baseline, report.results())
 	}
 
-	if output != "" {
-		fmt.Printf("%s Analysis complete! Report saved to: %s%s\n", colors.Green, output, colors.Reset)
+	if opts.Output != "" {
+		output.Successf(" Analysis complete! Report saved to: %s", opts.Output)
 	} else {
-		fmt.Printf("%s No issues found!%s\n", colors.Green, colors.Reset)
+		output.Successf(" No issues found!")
 	}
 	return nil
 }
+
+// cppcheckSources returns the arguments that select what cppcheck analyzes:
+// the given project, the given targets, a project generated from the
+// compile database, or a scan of the git-tracked files.
+func cppcheckSources(opts CppcheckOptions) ([]string, error) {
+	if opts.Project != "" {
+		if _, err := os.Stat(opts.Project); err != nil {
+			return nil, fmt.Errorf("project file not found: %s", opts.Project)
+		}
+		output.Infof("%s Using project %s%s", colors.Gray, opts.Project, colors.Reset)
+		return []string{"--project=" + opts.Project}, nil
+	}
+
+	if len(opts.Targets) == 0 {
+		if compileDb := FindCompileDatabase(); compileDb != "" {
+			project, err := writeCppcheckProject(filepath.Join(config.CacheDir(), "cppcheck"), compileDb)
+			if err != nil {
+				return nil, err
+			}
+			output.Infof("%s Using %s (project %s)%s", colors.Gray, compileDb, project, colors.Reset)
+			return []string{"--project=" + project}, nil
+		}
+		output.Warnf("No compile_commands.json found, scanning sources (run 'cpx build' first for include paths and defines)")
+	}
+
+	// Get remaining args as target directories/files (default to current directory)
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = []string{"."}
+	}
+
+	// Filter targets to only include git-tracked files (respect .gitignore)
+	filteredTargets, err := git.FilterGitTrackedFiles(targets)
+	if err != nil {
+		// If git is not available or not in a git repo, use original targets
+		output.Warnf("Not in a git repository or git not available. Scanning all files.")
+		filteredTargets = targets
+	} else if len(filteredTargets) == 0 {
+		return nil, fmt.Errorf("no git-tracked C/C++ files found to scan")
+	}
+
+	var args []string
+	for _, dir := range excludeDirs(".") {
+		args = append(args, "-i"+dir)
+	}
+	return append(args, filteredTargets...), nil
+}
+
+// compileDatabases returns where cpx's build systems leave
+// compile_commands.json.
+func compileDatabases() []string {
+	return []string{
+		"compile_commands.json",                                                      // project root (Bazel, copied by CMake)
+		filepath.Join("builddir", "compile_commands.json"),                           // Meson
+		filepath.Join(config.CacheDir(), "native", "debug", "compile_commands.json"), // CMake/vcpkg
+	}
+}
+
+// FindCompileDatabase returns the compile_commands.json of the build, or "".
+func FindCompileDatabase() string {
+	for _, path := range compileDatabases() {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// writeCppcheckProject writes a cppcheck project file to dir that imports
+// compileDb, skips third-party directories and keeps cppcheck's incremental
+// analysis results under dir/build. It returns the project file's path.
+func writeCppcheckProject(dir, compileDb string) (string, error) {
+	buildDir := filepath.Join(dir, "build")
+	if err := os.MkdirAll(buildDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", buildDir, err)
+	}
+	absBuildDir, err := filepath.Abs(buildDir)
+	if err != nil {
+		return "", err
+	}
+	absCompileDb, err := filepath.Abs(compileDb)
+	if err != nil {
+		return "", err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	b.WriteString("<!-- Generated by cpx cppcheck from " + compileDb + " -->\n")
+	b.WriteString("<project version=\"1\">\n")
+	fmt.Fprintf(&b, "    <builddir>%s</builddir>\n", xmlEscape(absBuildDir))
+	fmt.Fprintf(&b, "    <importproject>%s</importproject>\n", xmlEscape(absCompileDb))
+	b.WriteString("    <exclude>\n")
+	for _, d := range excludeDirs(root) {
+		fmt.Fprintf(&b, "        <path name=\"%s/\"/>\n", xmlEscape(filepath.Join(root, d)))
+	}
+	b.WriteString("    </exclude>\n")
+	b.WriteString("</project>\n")
+
+	path := filepath.Join(dir, "cpx.cppcheck")
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,275 @@
+package quality
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+)
+
+// cppcheckSeverities are the severities cppcheck reports findings with.
+var cppcheckSeverities = []string{"error", "warning", "style", "performance", "portability", "information"}
+
+// cppcheckReport is cppcheck's XML report (--xml-version=2).
+type cppcheckReport struct {
+	XMLName  xml.Name `xml:"results"`
+	Version  string   `xml:"version,attr"`
+	Cppcheck struct {
+		Version string `xml:"version,attr"`
+	} `xml:"cppcheck"`
+	Errors []cppcheckError `xml:"errors>error"`
+}
+
+type cppcheckError struct {
+	ID        string             `xml:"id,attr"`
+	Severity  string             `xml:"severity,attr"`
+	Msg       string             `xml:"msg,attr"`
+	Verbose   string             `xml:"verbose,attr"`
+	CWE       string             `xml:"cwe,attr,omitempty"`
+	File0     string             `xml:"file0,attr,omitempty"`
+	Locations []cppcheckLocation `xml:"location"`
+	Symbols   []string           `xml:"symbol"`
+}
+
+type cppcheckLocation struct {
+	File   string `xml:"file,attr"`
+	Line   int    `xml:"line,attr"`
+	Column int    `xml:"column,attr"`
+	Info   string `xml:"info,attr,omitempty"`
+}
+
+// parseCppcheckReport parses cppcheck's XML output.
+func parseCppcheckReport(data []byte) (*cppcheckReport, error) {
+	var report cppcheckReport
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse cppcheck results: %w", err)
+	}
+	return &report, nil
+}
+
+// validateSeverities checks severities against the ones cppcheck reports.
+func validateSeverities(severities []string) error {
+	for _, s := range severities {
+		if !slices.Contains(cppcheckSeverities, s) {
+			return fmt.Errorf("unknown severity '%s' (supported: %s)", s, strings.Join(cppcheckSeverities, ", "))
+		}
+	}
+	return nil
+}
+
+// filterSeverity keeps the findings with one of severities; none keeps all.
+func (r *cppcheckReport) filterSeverity(severities []string) {
+	if len(severities) == 0 {
+		return
+	}
+	r.Errors = slices.DeleteFunc(r.Errors, func(e cppcheckError) bool {
+		return !slices.Contains(severities, e.Severity)
+	})
+}
+
+// results converts the findings for baselines.
+func (r *cppcheckReport) results() []AnalysisResult {
+	results := make([]AnalysisResult, 0, len(r.Errors))
+	for _, e := range r.Errors {
+		loc := e.location()
+		results = append(results, AnalysisResult{
+			Tool:     "Cppcheck",
+			Severity: e.Severity,
+			File:     loc.File,
+			Line:     loc.Line,
+			Column:   loc.Column,
+			Message:  e.Msg,
+			Rule:     e.ID,
+		})
+	}
+	return results
+}
+
+// write writes the report as text, xml, csv or sarif. color highlights
+// severities in the text format.
+func (r *cppcheckReport) write(w io.Writer, format string, color bool) error {
+	switch format {
+	case "", "text":
+		for _, e := range r.Errors {
+			severity := e.Severity
+			if color {
+				severity = severityColor(e.Severity) + severity + colors.Reset
+			}
+			fmt.Fprintf(w, "%s: %s: %s [%s]\n", e.position(), severity, e.Msg, e.ID)
+		}
+		return nil
+	case "csv":
+		for _, e := range r.Errors {
+			loc := e.location()
+			fmt.Fprintf(w, "%s,%d,%s,%s,%s\n", loc.File, loc.Line, e.Severity, e.ID, e.Msg)
+		}
+		return nil
+	case "xml":
+		data, err := xml.MarshalIndent(r, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+		return err
+	case "sarif":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.sarif())
+	}
+	return fmt.Errorf("unknown format '%s' (supported: text, xml, csv, sarif)", format)
+}
+
+// location returns the primary location of a finding; it is empty for
+// findings about the whole analysis.
+func (e cppcheckError) location() cppcheckLocation {
+	if len(e.Locations) > 0 {
+		return e.Locations[0]
+	}
+	return cppcheckLocation{File: e.File0}
+}
+
+// position formats the location as file:line:column.
+func (e cppcheckError) position() string {
+	loc := e.location()
+	switch {
+	case loc.File == "":
+		return "cppcheck"
+	case loc.Line == 0:
+		return loc.File
+	case loc.Column == 0:
+		return fmt.Sprintf("%s:%d", loc.File, loc.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", loc.File, loc.Line, loc.Column)
+}
+
+func severityColor(severity string) string {
+	switch severity {
+	case "error":
+		return colors.Red
+	case "warning":
+		return colors.Yellow
+	case "information":
+		return colors.Gray
+	}
+	return colors.Cyan
+}
+
+// SARIF 2.1.0 log, reduced to what code scanning services read.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarif converts the report to a SARIF log.
+func (r *cppcheckReport) sarif() sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "Cppcheck",
+			Version:        r.Cppcheck.Version,
+			InformationURI: "https://cppcheck.sourceforge.io",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	seen := map[string]bool{}
+	for _, e := range r.Errors {
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: e.ID, ShortDescription: sarifMessage{Text: e.Msg}})
+		}
+
+		result := sarifResult{RuleID: e.ID, Level: sarifLevel(e.Severity), Message: sarifMessage{Text: e.Verbose}}
+		if result.Message.Text == "" {
+			result.Message.Text = e.Msg
+		}
+		if loc := e.location(); loc.File != "" {
+			physical := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: strings.ReplaceAll(loc.File, "\\", "/")}}
+			if loc.Line > 0 {
+				physical.Region = &sarifRegion{StartLine: loc.Line, StartColumn: loc.Column}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: physical}}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// sarifLevel maps a cppcheck severity to a SARIF level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error":
+		return "error"
+	case "warning":
+		return "warning"
+	}
+	return "note"
+}
+
+// xmlEscape escapes s for use in XML text and attributes.
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
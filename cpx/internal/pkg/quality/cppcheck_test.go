@@ -0,0 +1,128 @@
+package quality
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cppcheckXML = `<?xml version="1.0" encoding="UTF-8"?>
+<results version="2">
+    <cppcheck version="2.13.0"/>
+    <errors>
+        <error id="nullPointer" severity="error" msg="Null pointer dereference: p" verbose="Null pointer dereference: p" cwe="476" file0="src/main.cpp">
+            <location file="src/main.cpp" line="12" column="6" info="Null pointer dereference"/>
+            <symbol>p</symbol>
+        </error>
+        <error id="unusedVariable" severity="style" msg="Unused variable: x" verbose="Unused variable: x" cwe="563" file0="src/util.cpp">
+            <location file="src/util.cpp" line="3" column="9"/>
+        </error>
+        <error id="missingIncludeSystem" severity="information" msg="Include file not found" verbose="Include file not found"/>
+    </errors>
+</results>
+`
+
+func TestParseCppcheckReport(t *testing.T) {
+	report, err := parseCppcheckReport([]byte(cppcheckXML))
+	require.NoError(t, err)
+
+	assert.Equal(t, "2.13.0", report.Cppcheck.Version)
+	require.Len(t, report.Errors, 3)
+	assert.Equal(t, "nullPointer", report.Errors[0].ID)
+	assert.Equal(t, []string{"p"}, report.Errors[0].Symbols)
+	assert.Equal(t, "src/main.cpp:12:6", report.Errors[0].position())
+	assert.Equal(t, "cppcheck", report.Errors[2].position())
+
+	_, err = parseCppcheckReport([]byte("not xml"))
+	assert.Error(t, err)
+}
+
+func TestCppcheckFilterSeverity(t *testing.T) {
+	report, err := parseCppcheckReport([]byte(cppcheckXML))
+	require.NoError(t, err)
+
+	report.filterSeverity(nil)
+	assert.Len(t, report.Errors, 3)
+
+	report.filterSeverity([]string{"error", "warning"})
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "nullPointer", report.Errors[0].ID)
+
+	assert.NoError(t, validateSeverities([]string{"style"}))
+	assert.Error(t, validateSeverities([]string{"fatal"}))
+}
+
+func TestCppcheckReportFormats(t *testing.T) {
+	report, err := parseCppcheckReport([]byte(cppcheckXML))
+	require.NoError(t, err)
+	report.filterSeverity([]string{"error", "style"})
+
+	var text bytes.Buffer
+	require.NoError(t, report.write(&text, "text", false))
+	assert.Equal(t, "src/main.cpp:12:6: error: Null pointer dereference: p [nullPointer]\n"+
+		"src/util.cpp:3:9: style: Unused variable: x [unusedVariable]\n", text.String())
+
+	var csv bytes.Buffer
+	require.NoError(t, report.write(&csv, "csv", false))
+	assert.Equal(t, "src/main.cpp,12,error,nullPointer,Null pointer dereference: p\n"+
+		"src/util.cpp,3,style,unusedVariable,Unused variable: x\n", csv.String())
+
+	var xmlOut bytes.Buffer
+	require.NoError(t, report.write(&xmlOut, "xml", false))
+	reparsed, err := parseCppcheckReport(xmlOut.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, report.Errors, reparsed.Errors)
+
+	assert.Error(t, report.write(&bytes.Buffer{}, "html", false))
+}
+
+func TestCppcheckSARIF(t *testing.T) {
+	report, err := parseCppcheckReport([]byte(cppcheckXML))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, report.write(&out, "sarif", false))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "Cppcheck", run.Tool.Driver.Name)
+	assert.Len(t, run.Tool.Driver.Rules, 3)
+	require.Len(t, run.Results, 3)
+
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "src/main.cpp", run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 12, StartColumn: 6}, run.Results[0].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "note", run.Results[1].Level)
+	assert.Empty(t, run.Results[2].Locations)
+}
+
+func TestWriteCppcheckProject(t *testing.T) {
+	t.Chdir(t.TempDir())
+	assert.Empty(t, FindCompileDatabase())
+
+	compileDb := filepath.Join("builddir", "compile_commands.json")
+	require.NoError(t, os.MkdirAll("builddir", 0755))
+	require.NoError(t, os.WriteFile(compileDb, []byte("[]"), 0644))
+	assert.Equal(t, compileDb, FindCompileDatabase())
+
+	path, err := writeCppcheckProject(filepath.Join(".cache", "cppcheck"), compileDb)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(".cache", "cppcheck", "cpx.cppcheck"), path)
+	assert.DirExists(t, filepath.Join(".cache", "cppcheck", "build"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	root, _ := os.Getwd()
+	content := string(data)
+	assert.Contains(t, content, "<importproject>"+filepath.Join(root, compileDb)+"</importproject>")
+	assert.Contains(t, content, "<builddir>"+filepath.Join(root, ".cache", "cppcheck", "build")+"</builddir>")
+	assert.Contains(t, content, `<path name="`+filepath.Join(root, "subprojects")+`/"/>`)
+}
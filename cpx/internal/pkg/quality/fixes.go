@@ -0,0 +1,231 @@
+package quality
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"gopkg.in/yaml.v3"
+)
+
+// Replacement is one edit of a clang-tidy fix-it: Length bytes at Offset
+// in File are replaced with Text.
+type Replacement struct {
+	File   string `yaml:"FilePath"`
+	Offset int    `yaml:"Offset"`
+	Length int    `yaml:"Length"`
+	Text   string `yaml:"ReplacementText"`
+}
+
+// Fix is the fix-it of one clang-tidy diagnostic.
+type Fix struct {
+	Check        string
+	Message      string
+	File         string
+	Offset       int
+	Replacements []Replacement
+}
+
+// FixReviewer chooses which of the proposed fixes to apply.
+type FixReviewer func(fixes []Fix) ([]Fix, error)
+
+// exportedFixes is the file clang-tidy -export-fixes writes
+type exportedFixes struct {
+	Diagnostics []struct {
+		DiagnosticName    string `yaml:"DiagnosticName"`
+		DiagnosticMessage struct {
+			Message      string        `yaml:"Message"`
+			FilePath     string        `yaml:"FilePath"`
+			FileOffset   int           `yaml:"FileOffset"`
+			Replacements []Replacement `yaml:"Replacements"`
+		} `yaml:"DiagnosticMessage"`
+	} `yaml:"Diagnostics"`
+}
+
+// parseFixes reads the fixes of a clang-tidy -export-fixes file. The same
+// diagnostic in a header is reported once per source that includes it, so
+// duplicates are dropped.
+func parseFixes(data []byte) ([]Fix, error) {
+	var exported exportedFixes
+	if err := yaml.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("failed to parse clang-tidy fixes: %w", err)
+	}
+	seen := map[string]bool{}
+	var fixes []Fix
+	for _, d := range exported.Diagnostics {
+		msg := d.DiagnosticMessage
+		if len(msg.Replacements) == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s\x00%s\x00%d\x00%v", d.DiagnosticName, msg.FilePath, msg.FileOffset, msg.Replacements)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		fixes = append(fixes, Fix{
+			Check:        d.DiagnosticName,
+			Message:      msg.Message,
+			File:         msg.FilePath,
+			Offset:       msg.FileOffset,
+			Replacements: msg.Replacements,
+		})
+	}
+	return fixes, nil
+}
+
+// Location returns where the diagnostic is, as file:line with the file
+// relative to the project when it is inside it.
+func (f Fix) Location() string {
+	line := 0
+	if data, err := os.ReadFile(f.File); err == nil && f.Offset <= len(data) {
+		line = bytes.Count(data[:f.Offset], []byte("\n")) + 1
+	}
+	return fmt.Sprintf("%s:%d", relPath(f.File), line)
+}
+
+// Diff returns the lines the fix changes, prefixed with "-" before and "+"
+// after, under an "@@ file:line" header per file.
+func (f Fix) Diff() ([]string, error) {
+	var out []string
+	for _, file := range fixFiles(f.Replacements) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		line, before, after, err := diffReplacements(data, replacementsIn(f.Replacements, file))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		out = append(out, fmt.Sprintf("@@ %s:%d", relPath(file), line))
+		for _, l := range before {
+			out = append(out, "-"+l)
+		}
+		for _, l := range after {
+			out = append(out, "+"+l)
+		}
+	}
+	return out, nil
+}
+
+// diffReplacements applies reps to data and returns the first line of the
+// affected region with its text before and after.
+func diffReplacements(data []byte, reps []Replacement) (line int, before, after []string, err error) {
+	start, end := len(data), 0
+	for _, r := range reps {
+		if r.Offset < 0 || r.Offset+r.Length > len(data) {
+			return 0, nil, nil, fmt.Errorf("replacement at offset %d is outside the file", r.Offset)
+		}
+		start, end = min(start, r.Offset), max(end, r.Offset+r.Length)
+	}
+	// Widen to whole lines
+	start = bytes.LastIndexByte(data[:start], '\n') + 1
+	if i := bytes.IndexByte(data[end:], '\n'); i >= 0 {
+		end += i
+	} else {
+		end = len(data)
+	}
+
+	region := data[start:end]
+	shifted := make([]Replacement, len(reps))
+	for i, r := range reps {
+		r.Offset -= start
+		shifted[i] = r
+	}
+	changed, err := applyReplacements(region, shifted)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	line = bytes.Count(data[:start], []byte("\n")) + 1
+	return line, strings.Split(string(region), "\n"), strings.Split(string(changed), "\n"), nil
+}
+
+// ApplyFixes writes fixes to their files and returns how many were
+// applied. A fix that overlaps one before it is skipped, as clang-tidy does.
+func ApplyFixes(fixes []Fix) (int, error) {
+	var all []Replacement
+	applied := 0
+	for _, f := range fixes {
+		if slices.ContainsFunc(f.Replacements, func(r Replacement) bool {
+			return slices.ContainsFunc(all, r.overlaps)
+		}) {
+			output.Warnf("Skipped %s at %s: it conflicts with another fix", f.Check, f.Location())
+			continue
+		}
+		all = append(all, f.Replacements...)
+		applied++
+	}
+	for _, file := range fixFiles(all) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return 0, err
+		}
+		changed, err := applyReplacements(data, replacementsIn(all, file))
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", file, err)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(file, changed, info.Mode().Perm()); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+	return applied, nil
+}
+
+// overlaps reports whether r and o edit overlapping bytes. Identical
+// replacements, which the same fix reported twice produces, do not.
+func (r Replacement) overlaps(o Replacement) bool {
+	return r != o && r.File == o.File && r.Offset < o.Offset+o.Length && o.Offset < r.Offset+r.Length
+}
+
+// applyReplacements returns data with reps applied. Identical replacements
+// are applied once; overlapping ones, which would corrupt the file, are an
+// error.
+func applyReplacements(data []byte, reps []Replacement) ([]byte, error) {
+	reps = append([]Replacement(nil), reps...)
+	sort.SliceStable(reps, func(i, j int) bool { return reps[i].Offset < reps[j].Offset })
+	var out bytes.Buffer
+	pos := 0
+	for i, r := range reps {
+		if i > 0 && r == reps[i-1] {
+			continue
+		}
+		if r.Offset < pos || r.Offset+r.Length > len(data) {
+			return nil, fmt.Errorf("conflicting fixes at offset %d", r.Offset)
+		}
+		out.Write(data[pos:r.Offset])
+		out.WriteString(r.Text)
+		pos = r.Offset + r.Length
+	}
+	out.Write(data[pos:])
+	return out.Bytes(), nil
+}
+
+// fixFiles returns the files reps edit, in order of first appearance.
+func fixFiles(reps []Replacement) []string {
+	var files []string
+	seen := map[string]bool{}
+	for _, r := range reps {
+		if !seen[r.File] {
+			seen[r.File] = true
+			files = append(files, r.File)
+		}
+	}
+	return files
+}
+
+func replacementsIn(reps []Replacement, file string) []Replacement {
+	var out []Replacement
+	for _, r := range reps {
+		if r.File == file {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleSource = "#include <cstddef>\nint *p = NULL;\nint *q = 0;\n"
+
+func sampleFixes(file string) string {
+	return `---
+MainSourceFile: '` + file + `'
+Diagnostics:
+  - DiagnosticName: modernize-use-nullptr
+    DiagnosticMessage:
+      Message: use nullptr
+      FilePath: '` + file + `'
+      FileOffset: 28
+      Replacements:
+        - FilePath: '` + file + `'
+          Offset: 28
+          Length: 4
+          ReplacementText: nullptr
+    Level: Warning
+  - DiagnosticName: modernize-use-nullptr
+    DiagnosticMessage:
+      Message: use nullptr
+      FilePath: '` + file + `'
+      FileOffset: 28
+      Replacements:
+        - FilePath: '` + file + `'
+          Offset: 28
+          Length: 4
+          ReplacementText: nullptr
+    Level: Warning
+  - DiagnosticName: modernize-use-nullptr
+    DiagnosticMessage:
+      Message: use nullptr
+      FilePath: '` + file + `'
+      FileOffset: 43
+      Replacements:
+        - FilePath: '` + file + `'
+          Offset: 43
+          Length: 1
+          ReplacementText: nullptr
+    Level: Warning
+  - DiagnosticName: readability-identifier-length
+    DiagnosticMessage:
+      Message: variable name 'p' is too short
+      FilePath: '` + file + `'
+      FileOffset: 24
+      Replacements: []
+    Level: Warning
+...
+`
+}
+
+func TestParseFixes(t *testing.T) {
+	fixes, err := parseFixes([]byte(sampleFixes("/src/main.cpp")))
+	require.NoError(t, err)
+	require.Len(t, fixes, 2, "duplicates and diagnostics without fixes are dropped")
+	assert.Equal(t, "modernize-use-nullptr", fixes[0].Check)
+	assert.Equal(t, []Replacement{{File: "/src/main.cpp", Offset: 28, Length: 4, Text: "nullptr"}}, fixes[0].Replacements)
+
+	_, err = parseFixes([]byte("Diagnostics: [unterminated"))
+	assert.Error(t, err)
+}
+
+func TestFixDiffAndApply(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.cpp")
+	require.NoError(t, os.WriteFile(file, []byte(sampleSource), 0644))
+	fixes, err := parseFixes([]byte(sampleFixes(file)))
+	require.NoError(t, err)
+
+	assert.Equal(t, file+":2", fixes[0].Location())
+	diff, err := fixes[1].Diff()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@@ " + filepath.ToSlash(file) + ":3", "-int *q = 0;", "+int *q = nullptr;"}, diff)
+
+	applied, err := ApplyFixes(fixes[1:])
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "#include <cstddef>\nint *p = NULL;\nint *q = nullptr;\n", string(data))
+}
+
+func TestApplyFixesSkipsConflicts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.cpp")
+	require.NoError(t, os.WriteFile(file, []byte(sampleSource), 0644))
+	fixes := []Fix{
+		{Check: "a", File: file, Replacements: []Replacement{{File: file, Offset: 28, Length: 4, Text: "nullptr"}}},
+		{Check: "b", File: file, Replacements: []Replacement{{File: file, Offset: 30, Length: 2, Text: "LL"}}},
+	}
+	applied, err := ApplyFixes(fixes)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "int *p = nullptr;")
+}
@@ -1,70 +1,104 @@
 package quality
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
 
-	"github.com/ozacod/cpx/internal/pkg/utils/colors"
 	"github.com/ozacod/cpx/internal/pkg/utils/git"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 )
 
-func RunFlawfinder(minLevel int, csv, html bool, output string, dataflow, quiet, singleline bool, context int, targets []string) error {
+// FlawfinderOptions configures RunFlawfinder.
+type FlawfinderOptions struct {
+	MinLevel   int
+	CSV        bool
+	HTML       bool
+	Output     string
+	Dataflow   bool
+	Quiet      bool
+	SingleLine bool
+	Context    int
+	Targets    []string
+
+	// Baseline is the baseline file (default: .flawfinder-baseline.json).
+	// When it exists, findings that are not in it fail the run.
+	Baseline string
+
+	// RecordBaseline records the current findings as the baseline.
+	RecordBaseline bool
+}
+
+// RunFlawfinder runs flawfinder on the targets.
+func RunFlawfinder(opts FlawfinderOptions) error {
 	// Check if flawfinder is available
 	if _, err := exec.LookPath("flawfinder"); err != nil {
 		return fmt.Errorf("flawfinder not found. Please install it first:\n  pip install flawfinder\n  or\n  brew install flawfinder\n  or\n  apt-get install flawfinder (Debian/Ubuntu)")
 	}
 
 	// Validate output file for HTML/CSV
-	if (html || csv) && output == "" {
+	if (opts.HTML || opts.CSV) && opts.Output == "" {
 		return fmt.Errorf("--output file is required when using --html or --csv flags")
 	}
 
-	fmt.Printf("%s Running Flawfinder analysis...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf(" Running Flawfinder analysis...")
 
 	// Filter targets to only include git-tracked files (respect .gitignore)
-	filteredTargets, err := git.FilterGitTrackedFiles(targets)
+	filteredTargets, err := git.FilterGitTrackedFiles(opts.Targets)
 	if err != nil {
 		// If git is not available or not in a git repo, use original targets
-		fmt.Printf("%s Warning: Not in a git repository or git not available. Scanning all files.%s\n", colors.Yellow, colors.Reset)
-		filteredTargets = targets
+		output.Warnf("Not in a git repository or git not available. Scanning all files.")
+		filteredTargets = opts.Targets
 	} else if len(filteredTargets) == 0 {
 		return fmt.Errorf("no git-tracked C/C++ files found to scan")
 	}
 
+	baseline := opts.Baseline
+	if baseline == "" {
+		baseline = FlawfinderBaselineFile
+	}
+	if opts.RecordBaseline {
+		results, err := flawfinderResults(opts.MinLevel, opts.Dataflow, filteredTargets)
+		if err != nil {
+			return err
+		}
+		return recordBaseline("flawfinder", baseline, results)
+	}
+
 	// Build flawfinder command
 	var flawfinderArgs []string
 
 	// Add min level
-	if minLevel >= 0 && minLevel <= 5 {
-		flawfinderArgs = append(flawfinderArgs, "-m", fmt.Sprintf("%d", minLevel))
+	if opts.MinLevel >= 0 && opts.MinLevel <= 5 {
+		flawfinderArgs = append(flawfinderArgs, "-m", fmt.Sprintf("%d", opts.MinLevel))
 	}
 
 	// Output format
-	if csv {
+	if opts.CSV {
 		flawfinderArgs = append(flawfinderArgs, "-C")
-	} else if html {
+	} else if opts.HTML {
 		flawfinderArgs = append(flawfinderArgs, "-H")
 	}
 
 	// Dataflow analysis
-	if dataflow {
+	if opts.Dataflow {
 		flawfinderArgs = append(flawfinderArgs, "-D")
 	}
 
 	// Quiet mode
-	if quiet {
+	if opts.Quiet {
 		flawfinderArgs = append(flawfinderArgs, "--quiet")
 	}
 
 	// Single line output
-	if singleline {
+	if opts.SingleLine {
 		flawfinderArgs = append(flawfinderArgs, "--singleline")
 	}
 
 	// Context lines
-	if context > 0 {
-		flawfinderArgs = append(flawfinderArgs, "-c", fmt.Sprintf("%d", context))
+	if opts.Context > 0 {
+		flawfinderArgs = append(flawfinderArgs, "-c", fmt.Sprintf("%d", opts.Context))
 	}
 
 	// Add filtered target files
@@ -73,14 +107,14 @@ func RunFlawfinder(minLevel int, csv, html bool, output string, dataflow, quiet,
 	cmd := exec.Command("flawfinder", flawfinderArgs...)
 
 	// Handle output file for HTML/CSV
-	if output != "" {
-		file, err := os.Create(output)
+	if opts.Output != "" {
+		file, err := os.Create(opts.Output)
 		if err != nil {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
 		defer file.Close()
 		cmd.Stdout = file
-		fmt.Printf("%s Writing output to: %s%s\n", colors.Cyan, output, colors.Reset)
+		output.Stepf(" Writing output to: %s", opts.Output)
 	} else {
 		cmd.Stdout = os.Stdout
 	}
@@ -89,18 +123,42 @@ func RunFlawfinder(minLevel int, csv, html bool, output string, dataflow, quiet,
 
 	if err := cmd.Run(); err != nil {
 		// Flawfinder returns non-zero on findings, which is normal
-		if output != "" {
-			fmt.Printf("%s  Flawfinder found potential issues (saved to %s)%s\n", colors.Yellow, output, colors.Reset)
+		if opts.Output != "" {
+			output.Warnf("Flawfinder found potential issues (saved to %s)", opts.Output)
 		} else {
-			fmt.Printf("%s  Flawfinder found potential issues%s\n", colors.Yellow, colors.Reset)
+			output.Warnf("Flawfinder found potential issues")
 		}
+	} else if opts.Output != "" {
+		output.Successf(" Analysis complete! Report saved to: %s", opts.Output)
+	} else {
+		output.Successf(" No issues found!")
+	}
+
+	// With a baseline, new findings fail the run
+	if _, err := os.Stat(baseline); err != nil {
 		return nil
 	}
+	results, err := flawfinderResults(opts.MinLevel, opts.Dataflow, filteredTargets)
+	if err != nil {
+		return err
+	}
+	return checkBaseline("flawfinder", baseline, results)
+}
 
-	if output != "" {
-		fmt.Printf("%s Analysis complete! Report saved to: %s%s\n", colors.Green, output, colors.Reset)
-	} else {
-		fmt.Printf("%s No issues found!%s\n", colors.Green, colors.Reset)
+// flawfinderResults runs flawfinder with CSV output and parses its findings.
+func flawfinderResults(minLevel int, dataflow bool, targets []string) ([]AnalysisResult, error) {
+	args := []string{"--csv", "-m", fmt.Sprintf("%d", minLevel)}
+	if dataflow {
+		args = append(args, "-D")
+	}
+	args = append(args, targets...)
+
+	cmd := exec.Command("flawfinder", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil && stdout.Len() == 0 {
+		return nil, fmt.Errorf("flawfinder failed: %w\n%s", err, stderr.String())
 	}
-	return nil
+	return parseFlawfinderCSV(stdout.String()), nil
 }
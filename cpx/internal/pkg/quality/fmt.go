@@ -8,22 +8,27 @@ import (
 	"strings"
 
 	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 )
 
-// FormatCode formats C++ source files using clang-format
-func FormatCode(checkOnly bool) error {
+// FormatCode formats C++ source files using clang-format. Only the given
+// files are formatted; with none, every source under src, include and tests.
+func FormatCode(checkOnly bool, only []string) error {
 	// Check if clang-format is available
 	if _, err := exec.LookPath("clang-format"); err != nil {
 		return fmt.Errorf("clang-format not found. Please install it first")
 	}
 
-	fmt.Printf("%s Formatting code...%s\n", colors.Cyan, colors.Reset)
+	output.Stepf(" Formatting code...")
 
 	// Find all source files
-	var files []string
+	files := only
 	extensions := []string{".cpp", ".hpp", ".c", ".h", ".cc", ".cxx", ".hxx"}
 
 	for _, dir := range []string{"src", "include", "tests"} {
+		if len(only) > 0 {
+			break
+		}
 		if _, err := os.Stat(dir); os.IsNotExist(err) {
 			continue
 		}
@@ -42,7 +47,7 @@ func FormatCode(checkOnly bool) error {
 	}
 
 	if len(files) == 0 {
-		fmt.Printf("%s No source files found%s\n", colors.Green, colors.Reset)
+		output.Successf(" No source files found")
 		return nil
 	}
 
@@ -58,17 +63,17 @@ func FormatCode(checkOnly bool) error {
 	for _, file := range files {
 		args := append(formatArgs, file)
 		cmd := exec.Command("clang-format", args...)
-		output, err := cmd.CombinedOutput()
+		out, err := cmd.CombinedOutput()
 
 		if checkOnly && err != nil {
 			needsFormat = true
 			fmt.Printf("   %s %s needs formatting%s\n", colors.Yellow, file, colors.Reset)
 		} else if !checkOnly {
-			fmt.Printf("    %s\n", file)
+			output.Infof("    %s", file)
 		}
 
-		if len(output) > 0 && checkOnly {
-			fmt.Print(string(output))
+		if len(out) > 0 && checkOnly {
+			fmt.Print(string(out))
 		}
 	}
 
@@ -76,6 +81,6 @@ func FormatCode(checkOnly bool) error {
 		return fmt.Errorf("some files need formatting. Run 'cpx fmt' to fix")
 	}
 
-	fmt.Printf("%s Formatted %d files%s\n", colors.Green, len(files), colors.Reset)
+	output.Successf(" Formatted %d files", len(files))
 	return nil
 }
@@ -1,198 +1,56 @@
 package quality
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
-	"github.com/ozacod/cpx/internal/pkg/utils/colors"
 	"github.com/ozacod/cpx/internal/pkg/utils/git"
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
+// LintOptions configures LintCode.
+type LintOptions struct {
+	// Fix applies clang-tidy's fix-its
+	Fix bool
+
+	// Review, with Fix, is shown the proposed fixes and chooses which are
+	// applied; nil applies them all
+	Review FixReviewer
+
+	// Files restricts the analysis; empty analyzes every source
+	Files []string
+}
+
 // LintCode runs clang-tidy static analysis
-func LintCode(fix bool, vcpkg VcpkgSetup) error {
+func LintCode(opts LintOptions, vcpkg VcpkgSetup) error {
 	// Check if clang-tidy is available
 	if _, err := exec.LookPath("clang-tidy"); err != nil {
 		return fmt.Errorf("clang-tidy not found. Please install it first")
 	}
 
-	fmt.Printf("%s Running static analysis...%s\n", colors.Cyan, colors.Reset)
-
-	// Detect project type and find compile_commands.json
-	var compileDb string
-	var buildDir string
-
-	// Check for Meson project
-	if _, err := os.Stat("meson.build"); err == nil {
-		buildDir = "builddir"
-		compileDb = filepath.Join(buildDir, "compile_commands.json")
-
-		if _, err := os.Stat(compileDb); os.IsNotExist(err) {
-			// Try to generate compile_commands.json with meson
-			fmt.Printf("%s  Generating compile_commands.json for Meson project...%s\n", colors.Cyan, colors.Reset)
-			if _, err := os.Stat(buildDir); os.IsNotExist(err) {
-				// Need to run meson setup first
-				cmd := exec.Command("meson", "setup", buildDir)
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-				if err := cmd.Run(); err != nil {
-					return fmt.Errorf("failed to setup meson project: %w\n  Run 'cpx build' first", err)
-				}
-			}
-		}
-	} else if _, err := os.Stat("MODULE.bazel"); err == nil {
-		// Bazel project - use bazel-compile-commands or hedron
-		buildDir = "."
-		compileDb = "compile_commands.json"
+	output.Stepf(" Running static analysis...")
 
-		if _, err := os.Stat(compileDb); os.IsNotExist(err) {
-			// Try to generate using refresh_compile_commands if available
-			fmt.Printf("%s  Generating compile_commands.json for Bazel project...%s\n", colors.Cyan, colors.Reset)
-			// Check if hedron compile-commands is configured
-			cmd := exec.Command("bazel", "run", "@hedron_compile_commands//:refresh_all")
-			if err := cmd.Run(); err != nil {
-				// Hedron not available, print instructions
-				fmt.Printf("%s  Note: To enable clang-tidy for Bazel, add hedron_compile_commands to your project.%s\n", colors.Yellow, colors.Reset)
-				fmt.Printf("  See: https://github.com/hedronvision/bazel-compile-commands-extractor\n")
-				fmt.Printf("%s  Proceeding without compile_commands.json (limited analysis)...%s\n", colors.Yellow, colors.Reset)
-				compileDb = "" // Will skip compile database usage
-			}
-		}
-	} else {
-		// CMake/vcpkg project
-		// Set up vcpkg environment
-		if err := vcpkg.SetupEnv(); err != nil {
-			return fmt.Errorf("failed to setup vcpkg: %w", err)
-		}
-
-		// Use .cache/native/debug for consistency with build command
-		buildDir = filepath.Join(".cache", "native", "debug")
-		compileDb = filepath.Join(buildDir, "compile_commands.json")
-		needsRegenerate := false
-
-		if _, err := os.Stat(compileDb); os.IsNotExist(err) {
-			needsRegenerate = true
-			fmt.Printf("%s  Generating compile_commands.json...%s\n", colors.Cyan, colors.Reset)
-		} else {
-			// Check if CMakeCache.txt exists - if not, we need to configure
-			if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
-				needsRegenerate = true
-				fmt.Printf("%s  Regenerating compile_commands.json (CMake not configured)...%s\n", colors.Cyan, colors.Reset)
-			}
-		}
-
-		if needsRegenerate {
-			// Get vcpkg root for toolchain file
-			vcpkgPath, err := vcpkg.GetPath()
-			if err != nil {
-				return fmt.Errorf("vcpkg not configured: %w", err)
-			}
-			vcpkgRoot := filepath.Dir(vcpkgPath)
-			toolchainFile := filepath.Join(vcpkgRoot, "scripts", "buildsystems", "vcpkg.cmake")
-
-			// Check if toolchain file exists
-			if _, err := os.Stat(toolchainFile); os.IsNotExist(err) {
-				return fmt.Errorf("vcpkg toolchain file not found: %s\n  Make sure vcpkg is properly installed", toolchainFile)
-			}
-
-			// Configure CMake with vcpkg toolchain
-			// Use shared vcpkg_installed directory
-			cwd, _ := os.Getwd()
-			vcpkgInstalledDir := filepath.Join(cwd, ".cache", "native", "vcpkg_installed")
-			vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
-
-			cmakeArgs := []string{
-				"-B", buildDir,
-				"-DCMAKE_EXPORT_COMPILE_COMMANDS=ON",
-				"-DCMAKE_TOOLCHAIN_FILE=" + toolchainFile,
-				vcpkgInstallArg,
-			}
-
-			// Check if CMakePresets.json exists and use it
-			if _, err := os.Stat("CMakePresets.json"); err == nil {
-				// Use preset if available
-				cmakeArgs = []string{
-					"--preset", "default",
-					"-B", buildDir,
-					"-DCMAKE_EXPORT_COMPILE_COMMANDS=ON",
-					vcpkgInstallArg,
-				}
-			}
-
-			cmd := exec.Command("cmake", cmakeArgs...)
-			cmd.Env = os.Environ()
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			if err := cmd.Run(); err != nil {
-				return fmt.Errorf("failed to generate compile_commands.json: %w\n  Try running 'cpx build' first to configure the project", err)
-			}
-		}
+	compileDb, err := GenerateCompileDatabase(false, vcpkg)
+	if errors.Is(err, ErrNoCompileCommandsExtractor) {
+		output.Warnf("To enable clang-tidy for Bazel, add hedron_compile_commands to your project.\n  See: https://github.com/hedronvision/bazel-compile-commands-extractor")
+		output.Warnf("Proceeding without compile_commands.json (limited analysis)...")
+		compileDb = "" // Will skip compile database usage
+	} else if err != nil {
+		return err
 	}
 
-	// Find source files (only git-tracked files, respect .gitignore)
-	var files []string
-	trackedFiles, err := git.GetGitTrackedCppFiles()
-	if err != nil {
-		// If not in git repo, fall back to scanning src/include directories
-		fmt.Printf("%s Warning: Not in a git repository. Scanning src/, include/, and current directory.%s\n", colors.Yellow, colors.Reset)
-		for _, dir := range []string{".", "src", "include"} {
-			if _, err := os.Stat(dir); os.IsNotExist(err) {
-				continue
-			}
-			_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-				if err != nil || info.IsDir() {
-					return nil
-				}
-				// Skip build directories, cache, and third-party dependencies
-				// Check for common build/cache directory patterns
-				if strings.HasPrefix(path, ".cache") ||
-					strings.HasPrefix(path, ".bin") ||
-					strings.HasPrefix(path, "build") ||
-					strings.HasPrefix(path, "builddir") ||
-					strings.HasPrefix(path, "subprojects") ||
-					strings.HasPrefix(path, "out") ||
-					strings.HasPrefix(path, ".bazel") ||
-					strings.HasPrefix(path, "bazel-") ||
-					strings.Contains(path, "/build/") ||
-					strings.Contains(path, "\\build\\") ||
-					strings.Contains(path, "/.cache/") ||
-					strings.Contains(path, "\\.cache\\") ||
-					strings.Contains(path, "_deps/") ||
-					strings.Contains(path, "CMakeFiles/") {
-					return nil
-				}
-				ext := filepath.Ext(path)
-				if ext == ".cpp" || ext == ".cc" || ext == ".cxx" || ext == ".c++" {
-					files = append(files, path)
-				}
-				return nil
-			})
-		}
-	} else {
-		// Filter out files in build directories and other common ignored paths
-		for _, file := range trackedFiles {
-			// Skip files in build/, out/, bin/, .vcpkg/, builddir/, subprojects/, etc.
-			if strings.HasPrefix(file, "build/") ||
-				strings.HasPrefix(file, "builddir/") ||
-				strings.HasPrefix(file, "subprojects/") ||
-				strings.HasPrefix(file, "out/") ||
-				strings.HasPrefix(file, "bin/") ||
-				strings.HasPrefix(file, ".vcpkg/") ||
-				strings.HasPrefix(file, ".cache/") ||
-				strings.HasPrefix(file, ".bazel/") ||
-				strings.HasPrefix(file, "bazel-") ||
-				strings.Contains(file, "/build/") ||
-				strings.Contains(file, "\\build\\") {
-				continue
-			}
-			files = append(files, file)
-		}
+	files := opts.Files
+	if len(files) == 0 {
+		files = lintSources()
 	}
 
 	if len(files) == 0 {
-		fmt.Printf("%s No source files found%s\n", colors.Green, colors.Reset)
+		output.Successf(" No source files found")
 		return nil
 	}
 
@@ -204,11 +62,21 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 		if _, err := os.Stat(compileDb); os.IsNotExist(err) {
 			return fmt.Errorf("compile_commands.json not found at %s\n  Run 'cpx build' first to generate it", compileDb)
 		}
-		absBuildDir, _ := filepath.Abs(buildDir)
+		absBuildDir, _ := filepath.Abs(filepath.Dir(compileDb))
 		tidyArgs = append(tidyArgs, "-p", absBuildDir)
 	}
 
-	if fix {
+	// Fixes to review are exported instead of written
+	var fixesFile string
+	if opts.Fix && opts.Review != nil {
+		tmpDir, err := os.MkdirTemp("", "cpx-tidy-fixes-")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		fixesFile = filepath.Join(tmpDir, "fixes.yaml")
+		tidyArgs = append(tidyArgs, "-export-fixes="+fixesFile)
+	} else if opts.Fix {
 		tidyArgs = append(tidyArgs, "-fix")
 	}
 
@@ -223,33 +91,63 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 	tidyArgs = append(tidyArgs, files...)
 
 	cmd := exec.Command("clang-tidy", tidyArgs...)
-	output, err := cmd.CombinedOutput()
+	out, err := cmd.CombinedOutput()
 
 	// Write output to stderr (warnings/errors) and stdout (info)
-	os.Stderr.Write(output)
+	os.Stderr.Write(out)
 
 	// Check if output contains warnings or errors
-	outputStr := string(output)
+	outputStr := string(out)
 	hasWarnings := strings.Contains(outputStr, "warning:") ||
 		strings.Contains(outputStr, "error:") ||
 		strings.Contains(outputStr, "note:")
 
-	if err != nil {
+	switch {
+	case err != nil && hasWarnings:
 		// clang-tidy returns non-zero on errors or when warnings are treated as errors
-		if hasWarnings {
-			fmt.Printf("%s  Analysis complete with issues found%s\n", colors.Yellow, colors.Reset)
-		} else {
-			fmt.Printf("%s  Analysis failed%s\n", colors.Yellow, colors.Reset)
-		}
-		return nil
+		output.Warnf("Analysis complete with issues found")
+	case err != nil:
+		output.Errorf("Analysis failed")
+	case hasWarnings:
+		output.Warnf("Analysis complete with warnings")
+	default:
+		output.Successf(" No issues found!")
 	}
 
-	if hasWarnings {
-		fmt.Printf("%s  Analysis complete with warnings%s\n", colors.Yellow, colors.Reset)
-		return nil
+	if fixesFile != "" {
+		return reviewFixes(fixesFile, opts.Review)
 	}
+	return nil
+}
 
-	fmt.Printf("%s No issues found!%s\n", colors.Green, colors.Reset)
+// reviewFixes lets review choose among the fixes clang-tidy exported to
+// path and applies those it accepts.
+func reviewFixes(path string, review FixReviewer) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// Nothing to fix
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fixes, err := parseFixes(data)
+	if err != nil {
+		return err
+	}
+	if len(fixes) == 0 {
+		output.Successf(" No fixes proposed")
+		return nil
+	}
+	accepted, err := review(fixes)
+	if err != nil {
+		return err
+	}
+	applied, err := ApplyFixes(accepted)
+	if err != nil {
+		return err
+	}
+	output.Successf(" Applied %d of %d fixes", applied, len(fixes))
 	return nil
 }
 
@@ -309,3 +207,78 @@ func GetSystemIncludePaths() []string {
 
 	return includes
 }
+
+// lintSources returns the sources to lint: the git-tracked C/C++ files
+// outside build directories, or the sources found under ., src and include
+// outside a git repository.
+func lintSources() []string {
+	var files []string
+	projectDirs := config.DirsOf(".").Within(".")
+	trackedFiles, err := git.GetGitTrackedCppFiles()
+	if err != nil {
+		// If not in git repo, fall back to scanning src/include directories
+		output.Warnf("Not in a git repository. Scanning src/, include/, and current directory.")
+		for _, dir := range []string{".", "src", "include"} {
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				continue
+			}
+			_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return nil
+				}
+				// Skip build directories, cache, and third-party dependencies
+				// Check for common build/cache directory patterns
+				if inDirs(path, projectDirs) ||
+					strings.HasPrefix(path, "build") ||
+					strings.HasPrefix(path, "builddir") ||
+					strings.HasPrefix(path, "subprojects") ||
+					strings.HasPrefix(path, "out") ||
+					strings.HasPrefix(path, ".bazel") ||
+					strings.HasPrefix(path, "bazel-") ||
+					strings.Contains(path, "/build/") ||
+					strings.Contains(path, "\\build\\") ||
+					strings.Contains(path, "_deps/") ||
+					strings.Contains(path, "CMakeFiles/") {
+					return nil
+				}
+				ext := filepath.Ext(path)
+				if ext == ".cpp" || ext == ".cc" || ext == ".cxx" || ext == ".c++" {
+					files = append(files, path)
+				}
+				return nil
+			})
+		}
+	} else {
+		// Filter out files in build directories and other common ignored paths
+		for _, file := range trackedFiles {
+			// Skip files in build/, out/, bin/, .vcpkg/, builddir/, subprojects/, etc.
+			if inDirs(file, projectDirs) ||
+				strings.HasPrefix(file, "build/") ||
+				strings.HasPrefix(file, "builddir/") ||
+				strings.HasPrefix(file, "subprojects/") ||
+				strings.HasPrefix(file, "out/") ||
+				strings.HasPrefix(file, "bin/") ||
+				strings.HasPrefix(file, ".vcpkg/") ||
+				strings.HasPrefix(file, ".bazel/") ||
+				strings.HasPrefix(file, "bazel-") ||
+				strings.Contains(file, "/build/") ||
+				strings.Contains(file, "\\build\\") {
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// inDirs reports whether path, relative to the project root, is in one of
+// dirs.
+func inDirs(path string, dirs []string) bool {
+	path = filepath.ToSlash(path)
+	for _, dir := range dirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package quality
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"gopkg.in/yaml.v3"
+)
+
+// Mull is an installation of the mull mutation testing tools. Its compiler
+// plugin only loads into the clang release it was built for.
+type Mull struct {
+	// Version is the LLVM major version mull was built for.
+	Version int
+
+	// Runner runs a test executable once per mutant.
+	Runner string
+
+	// Plugin is the clang pass plugin that inserts the mutants.
+	Plugin string
+
+	// CC and CXX are the matching clang compilers.
+	CC, CXX string
+}
+
+// mullLibDirs are searched for the mull compiler plugin.
+var mullLibDirs = []string{"/usr/lib", "/usr/local/lib", "/opt/homebrew/lib", "/usr/lib/mull", "/usr/local/lib/mull"}
+
+var mullRunnerRe = regexp.MustCompile(`^mull-runner-(\d+)$`)
+
+// FindMull returns the newest mull installation on the PATH that has its
+// compiler plugin and a matching clang.
+func FindMull() (*Mull, error) {
+	var versions []int
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if m := mullRunnerRe.FindStringSubmatch(entry.Name()); m != nil {
+				v, _ := strconv.Atoi(m[1])
+				if !slices.Contains(versions, v) {
+					versions = append(versions, v)
+				}
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("mull not found. Please install it first:\n  https://github.com/mull-project/mull/releases (mull-<llvm version> packages)\n  or\n  brew install mull-project/mull/mull")
+	}
+	slices.Sort(versions)
+	slices.Reverse(versions)
+
+	var missing []string
+	for _, v := range versions {
+		suffix := "-" + strconv.Itoa(v)
+		mull := &Mull{Version: v, Runner: "mull-runner" + suffix}
+		for _, dir := range mullLibDirs {
+			for _, name := range []string{"mull-ir-frontend" + suffix, "mull-ir-frontend" + suffix + ".so", "mull-ir-frontend" + suffix + ".dylib"} {
+				if _, err := os.Stat(filepath.Join(dir, name)); err == nil && mull.Plugin == "" {
+					mull.Plugin = filepath.Join(dir, name)
+				}
+			}
+		}
+		if mull.Plugin == "" {
+			missing = append(missing, "mull-ir-frontend"+suffix)
+			continue
+		}
+		mull.CC, mull.CXX = clangForVersion(v)
+		if mull.CXX == "" {
+			missing = append(missing, "clang++"+suffix)
+			continue
+		}
+		return mull, nil
+	}
+	return nil, fmt.Errorf("mull is installed but incomplete: %s not found\n  hint: install the clang release mull was built for", strings.Join(missing, ", "))
+}
+
+// clangForVersion returns clang and clang++ of LLVM release version: the
+// versioned names, or the plain ones when they are that release.
+func clangForVersion(version int) (cc, cxx string) {
+	suffix := "-" + strconv.Itoa(version)
+	if _, err := exec.LookPath("clang++" + suffix); err == nil {
+		return "clang" + suffix, "clang++" + suffix
+	}
+	out, err := exec.Command("clang++", "--version").Output()
+	if err == nil && strings.Contains(string(out), fmt.Sprintf("version %d.", version)) {
+		return "clang", "clang++"
+	}
+	return "", ""
+}
+
+// CompileFlags are the compiler flags that build code with mutants in it.
+func (m *Mull) CompileFlags() string {
+	return "-O0 -g -grecord-command-line -fpass-plugin=" + m.Plugin
+}
+
+// MullConfig is the mull.yml the compiler plugin reads while compiling.
+type MullConfig struct {
+	Mutators     []string `yaml:"mutators,omitempty"`
+	ExcludePaths []string `yaml:"excludePaths,omitempty"`
+	Timeout      int      `yaml:"timeout,omitempty"` // milliseconds per mutant
+	Quiet        bool     `yaml:"quiet"`
+}
+
+// defaultMutateExclude keeps mutants out of tests and dependencies.
+var defaultMutateExclude = []string{".*/tests?/.*", ".*/vcpkg_installed/.*", ".*/_deps/.*", ".*/\\.cache/.*", "/usr/.*"}
+
+// WriteMullConfig writes the mull.yml for mutators, or mull's defaults when
+// empty, that leaves out the paths matching exclude and the project's tests
+// and dependencies.
+func WriteMullConfig(path string, mutators, exclude []string, timeoutMs int) error {
+	cfg := MullConfig{
+		Mutators:     mutators,
+		ExcludePaths: append(slices.Clone(defaultMutateExclude), exclude...),
+		Timeout:      timeoutMs,
+		Quiet:        true,
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Mutant is a mutation mull tried, with whether the tests caught it.
+type Mutant struct {
+	ID       string `json:"id"`
+	Mutator  string `json:"mutatorName"`
+	Replaced string `json:"replacement"`
+	Status   string `json:"status"`
+	Location struct {
+		Start struct {
+			Line   int `json:"line"`
+			Column int `json:"column"`
+		} `json:"start"`
+	} `json:"location"`
+}
+
+// Detected reports whether the tests caught the mutant: a test failed or
+// timed out with it in.
+func (m Mutant) Detected() bool {
+	return m.Status == "Killed" || m.Status == "Timeout"
+}
+
+// FileMutations are the mutants of one source file.
+type FileMutations struct {
+	Path    string
+	Mutants []Mutant
+}
+
+// MutationReport is the result of a mutation testing run, read from mull's
+// mutation-testing-elements report.
+type MutationReport struct {
+	Files []FileMutations
+}
+
+// ParseMutationReport reads a mutation-testing-elements JSON report.
+func ParseMutationReport(data []byte) (*MutationReport, error) {
+	var raw struct {
+		Files map[string]struct {
+			Mutants []Mutant `json:"mutants"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse the mutation report: %w", err)
+	}
+	report := &MutationReport{}
+	for path, file := range raw.Files {
+		mutants := file.Mutants
+		sort.SliceStable(mutants, func(i, j int) bool {
+			return mutants[i].Location.Start.Line < mutants[j].Location.Start.Line
+		})
+		report.Files = append(report.Files, FileMutations{Path: path, Mutants: mutants})
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Path < report.Files[j].Path })
+	return report, nil
+}
+
+// Counts returns how many of the mutants the tests detected, and how many
+// there are.
+func (f FileMutations) Counts() (detected, total int) {
+	for _, m := range f.Mutants {
+		if m.Detected() {
+			detected++
+		}
+	}
+	return detected, len(f.Mutants)
+}
+
+// Score returns the mutation score of the report: the percentage of the
+// mutants the tests detected, 100 when there are none.
+func (r *MutationReport) Score() float64 {
+	detected, total := 0, 0
+	for _, f := range r.Files {
+		d, t := f.Counts()
+		detected += d
+		total += t
+	}
+	return score(detected, total)
+}
+
+func score(detected, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(detected) / float64(total)
+}
+
+// Print writes a table of the mutants and score of each file to w, then
+// the mutants that survived, as file:line:column for editors to jump to.
+func (r *MutationReport) Print(w io.Writer) {
+	width := len("File")
+	for _, f := range r.Files {
+		width = max(width, len(f.Path))
+	}
+	fmt.Fprintf(w, "%s%-*s  %7s  %8s  %6s%s\n", colors.Bold, width, "File", "Mutants", "Survived", "Score", colors.Reset)
+	var survivors []string
+	for _, f := range r.Files {
+		detected, total := f.Counts()
+		fmt.Fprintf(w, "%-*s  %7d  %8d  %5.1f%%\n", width, f.Path, total, total-detected, score(detected, total))
+		for _, m := range f.Mutants {
+			if !m.Detected() {
+				survivors = append(survivors, fmt.Sprintf("%s:%d:%d: %s%s%s (%s)",
+					f.Path, m.Location.Start.Line, m.Location.Start.Column, colors.Yellow, m.Mutator, colors.Reset, strings.ToLower(m.Status)))
+			}
+		}
+	}
+	if len(survivors) > 0 {
+		fmt.Fprintf(w, "\n%sSurviving mutants:%s\n", colors.Bold, colors.Reset)
+		for _, s := range survivors {
+			fmt.Fprintf(w, "  %s\n", s)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package quality
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const elementsReport = `{
+  "schemaVersion": "1",
+  "thresholds": {"high": 80, "low": 60},
+  "files": {
+    "/src/proj/src/math.cpp": {
+      "language": "cpp",
+      "mutants": [
+        {"id": "2", "mutatorName": "cxx_lt_to_le", "replacement": "<=", "status": "Survived", "location": {"start": {"line": 12, "column": 9}, "end": {"line": 12, "column": 10}}},
+        {"id": "1", "mutatorName": "cxx_add_to_sub", "replacement": "-", "status": "Killed", "location": {"start": {"line": 4, "column": 14}, "end": {"line": 4, "column": 15}}},
+        {"id": "3", "mutatorName": "cxx_remove_void_call", "status": "Timeout", "location": {"start": {"line": 20, "column": 3}, "end": {"line": 20, "column": 9}}}
+      ]
+    },
+    "/src/proj/src/io.cpp": {
+      "language": "cpp",
+      "mutants": [
+        {"id": "4", "mutatorName": "cxx_eq_to_ne", "status": "NoCoverage", "location": {"start": {"line": 7, "column": 11}, "end": {"line": 7, "column": 13}}}
+      ]
+    }
+  }
+}`
+
+func TestParseMutationReport(t *testing.T) {
+	report, err := ParseMutationReport([]byte(elementsReport))
+	require.NoError(t, err)
+	require.Len(t, report.Files, 2)
+	assert.Equal(t, "/src/proj/src/io.cpp", report.Files[0].Path)
+	assert.Equal(t, "/src/proj/src/math.cpp", report.Files[1].Path)
+
+	math := report.Files[1]
+	assert.Equal(t, []string{"1", "2", "3"}, []string{math.Mutants[0].ID, math.Mutants[1].ID, math.Mutants[2].ID}, "mutants are ordered by line")
+	detected, total := math.Counts()
+	assert.Equal(t, 2, detected, "killed and timed out mutants are detected")
+	assert.Equal(t, 3, total)
+	assert.InDelta(t, 50.0, report.Score(), 0.01)
+
+	assert.Equal(t, 100.0, (&MutationReport{}).Score())
+	_, err = ParseMutationReport([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestMutationReportPrint(t *testing.T) {
+	report, err := ParseMutationReport([]byte(elementsReport))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	report.Print(&buf)
+	out := buf.String()
+	assert.Regexp(t, `/src/proj/src/math.cpp +3 +1 +66.7%`, out)
+	assert.Regexp(t, `/src/proj/src/io.cpp +1 +1 +0.0%`, out)
+	assert.Contains(t, out, "/src/proj/src/io.cpp:7:11:")
+	assert.Contains(t, out, "/src/proj/src/math.cpp:12:9:")
+	assert.NotContains(t, out, "math.cpp:4:14:", "killed mutants are not listed")
+}
+
+func TestWriteMullConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mull.yml")
+	require.NoError(t, WriteMullConfig(path, []string{"cxx_arithmetic"}, []string{".*/generated/.*"}, 5000))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var cfg MullConfig
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+	assert.Equal(t, []string{"cxx_arithmetic"}, cfg.Mutators)
+	assert.Contains(t, cfg.ExcludePaths, ".*/tests?/.*", "tests are never mutated")
+	assert.Contains(t, cfg.ExcludePaths, ".*/generated/.*")
+	assert.Equal(t, 5000, cfg.Timeout)
+}
+
+func TestFindMull(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as executables")
+	}
+	bin, lib := t.TempDir(), t.TempDir()
+	oldDirs := mullLibDirs
+	t.Cleanup(func() { mullLibDirs = oldDirs })
+	mullLibDirs = []string{lib}
+	t.Setenv("PATH", bin)
+
+	_, err := FindMull()
+	assert.ErrorContains(t, err, "mull not found")
+
+	for _, name := range []string{"mull-runner-16", "mull-runner-17", "clang++-16", "clang++-17"} {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0755))
+	}
+	_, err = FindMull()
+	assert.ErrorContains(t, err, "mull-ir-frontend-17, mull-ir-frontend-16")
+
+	require.NoError(t, os.WriteFile(filepath.Join(lib, "mull-ir-frontend-16"), nil, 0644))
+	mull, err := FindMull()
+	require.NoError(t, err)
+	assert.Equal(t, 16, mull.Version, "the newest complete installation is used")
+	assert.Equal(t, "mull-runner-16", mull.Runner)
+	assert.Equal(t, "clang++-16", mull.CXX)
+	assert.Contains(t, mull.CompileFlags(), "-fpass-plugin="+filepath.Join(lib, "mull-ir-frontend-16"))
+}
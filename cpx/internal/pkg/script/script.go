@@ -0,0 +1,394 @@
+// Package script runs the optional cpx.star project script.
+//
+// cpx.star is a Starlark file that may define any of the following hook
+// functions, which cpx calls at well-defined points:
+//
+//	build_args(ctx)        -> list of extra arguments for the build tool
+//	toolchain_matrix(tcs)  -> list of toolchains to build (cpx-ci.yaml keys)
+//	post_artifacts(ctx)    -> dict of artifact renames {"old": "new"}
+//
+// Scripts run in a sandboxed interpreter: there is no file system, network
+// or process access, load() is disabled and execution is bounded by a step
+// limit.
+package script
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// FileName is the name of the project script.
+const FileName = "cpx.star"
+
+// Hook names that can be defined in cpx.star.
+const (
+	HookBuildArgs       = "build_args"
+	HookToolchainMatrix = "toolchain_matrix"
+	HookPostArtifacts   = "post_artifacts"
+)
+
+// maxSteps bounds the number of Starlark computation steps per call.
+const maxSteps = 10_000_000
+
+// Script is a loaded cpx.star script.
+type Script struct {
+	globals starlark.StringDict
+	out     func(msg string)
+}
+
+// BuildContext describes the build passed to the build_args hook.
+type BuildContext struct {
+	BuildSystem string
+	Release     bool
+	OptLevel    string
+	Sanitizer   string
+	Target      string
+	Toolchain   string
+}
+
+// Load loads the cpx.star script from projectDir.
+// It returns nil without error if the project has no script.
+func Load(projectDir string) (*Script, error) {
+	path := filepath.Join(projectDir, FileName)
+	src, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	return LoadSource(path, src)
+}
+
+// LoadSource loads a script from source. path is only used in error messages.
+func LoadSource(path string, src []byte) (*Script, error) {
+	s := &Script{
+		out: func(msg string) { fmt.Fprintln(os.Stderr, msg) },
+	}
+
+	thread := s.newThread("load")
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", FileName, formatError(err))
+	}
+	s.globals = globals
+	return s, nil
+}
+
+// SetOutput redirects print() output of the script.
+func (s *Script) SetOutput(fn func(msg string)) {
+	s.out = fn
+}
+
+// HasHook reports whether the script defines the named hook function.
+func (s *Script) HasHook(name string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.globals[name].(starlark.Callable)
+	return ok
+}
+
+// BuildArgs calls the build_args hook and returns the extra arguments
+// to pass to the build tool.
+func (s *Script) BuildArgs(ctx BuildContext) ([]string, error) {
+	if !s.HasHook(HookBuildArgs) {
+		return nil, nil
+	}
+
+	dict := starlark.NewDict(6)
+	_ = dict.SetKey(starlark.String("build_system"), starlark.String(ctx.BuildSystem))
+	_ = dict.SetKey(starlark.String("release"), starlark.Bool(ctx.Release))
+	_ = dict.SetKey(starlark.String("opt_level"), starlark.String(ctx.OptLevel))
+	_ = dict.SetKey(starlark.String("sanitizer"), starlark.String(ctx.Sanitizer))
+	_ = dict.SetKey(starlark.String("target"), starlark.String(ctx.Target))
+	_ = dict.SetKey(starlark.String("toolchain"), starlark.String(ctx.Toolchain))
+	dict.Freeze()
+
+	result, err := s.call(HookBuildArgs, dict)
+	if err != nil {
+		return nil, err
+	}
+	if result == starlark.None {
+		return nil, nil
+	}
+	args, err := toStringList(result)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", HookBuildArgs, err)
+	}
+	return args, nil
+}
+
+// ToolchainMatrix calls the toolchain_matrix hook with the toolchains from
+// cpx-ci.yaml and returns the toolchains to build.
+func (s *Script) ToolchainMatrix(toolchains []config.Toolchain) ([]config.Toolchain, error) {
+	if !s.HasHook(HookToolchainMatrix) {
+		return toolchains, nil
+	}
+
+	input, err := toStarlark(toolchains)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", HookToolchainMatrix, err)
+	}
+
+	result, err := s.call(HookToolchainMatrix, input)
+	if err != nil {
+		return nil, err
+	}
+	if result == starlark.None {
+		return toolchains, nil
+	}
+	if _, ok := result.(*starlark.List); !ok {
+		return nil, fmt.Errorf("%s: expected list, got %s", HookToolchainMatrix, result.Type())
+	}
+
+	var matrix []config.Toolchain
+	if err := fromStarlark(result, &matrix); err != nil {
+		return nil, fmt.Errorf("%s: %w", HookToolchainMatrix, err)
+	}
+	for i, tc := range matrix {
+		if tc.Name == "" {
+			return nil, fmt.Errorf("%s: toolchain #%d has no name", HookToolchainMatrix, i+1)
+		}
+	}
+	return matrix, nil
+}
+
+// PostArtifacts calls the post_artifacts hook for the artifacts in outputDir
+// and applies the returned renames. Renames may not escape outputDir.
+func (s *Script) PostArtifacts(outputDir string) error {
+	if !s.HasHook(HookPostArtifacts) {
+		return nil
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read artifacts: %w", err)
+	}
+	var artifacts []starlark.Value
+	for _, e := range entries {
+		if !e.IsDir() {
+			artifacts = append(artifacts, starlark.String(e.Name()))
+		}
+	}
+
+	dict := starlark.NewDict(2)
+	_ = dict.SetKey(starlark.String("output_dir"), starlark.String(outputDir))
+	_ = dict.SetKey(starlark.String("artifacts"), starlark.NewList(artifacts))
+	dict.Freeze()
+
+	result, err := s.call(HookPostArtifacts, dict)
+	if err != nil {
+		return err
+	}
+	if result == starlark.None {
+		return nil
+	}
+	renames, ok := result.(*starlark.Dict)
+	if !ok {
+		return fmt.Errorf("%s: expected dict, got %s", HookPostArtifacts, result.Type())
+	}
+
+	var pairs [][2]string
+	for _, item := range renames.Items() {
+		from, ok1 := starlark.AsString(item[0])
+		to, ok2 := starlark.AsString(item[1])
+		if !ok1 || !ok2 {
+			return fmt.Errorf("%s: renames must map strings to strings", HookPostArtifacts)
+		}
+		if !isLocalName(from) || !isLocalName(to) {
+			return fmt.Errorf("%s: invalid rename %q -> %q (must stay inside %s)", HookPostArtifacts, from, to, outputDir)
+		}
+		pairs = append(pairs, [2]string{from, to})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+
+	for _, p := range pairs {
+		if err := os.Rename(filepath.Join(outputDir, p[0]), filepath.Join(outputDir, p[1])); err != nil {
+			return fmt.Errorf("%s: failed to rename %s: %w", HookPostArtifacts, p[0], err)
+		}
+	}
+	return nil
+}
+
+// call invokes a hook function with a fresh, step-limited thread.
+func (s *Script) call(name string, args ...starlark.Value) (starlark.Value, error) {
+	fn := s.globals[name].(starlark.Callable)
+	thread := s.newThread(name)
+	result, err := starlark.Call(thread, fn, starlark.Tuple(args), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s hook failed: %w", name, formatError(err))
+	}
+	return result, nil
+}
+
+func (s *Script) newThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			s.out(msg)
+		},
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("load(%q) is not allowed in %s", module, FileName)
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	return thread
+}
+
+func formatError(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%s", evalErr.Backtrace())
+	}
+	return err
+}
+
+// isLocalName reports whether name is a plain relative path inside a directory.
+func isLocalName(name string) bool {
+	return name != "" && filepath.IsLocal(name)
+}
+
+func toStringList(v starlark.Value) ([]string, error) {
+	iterable, ok := v.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("expected list of strings, got %s", v.Type())
+	}
+	var result []string
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		str, ok := starlark.AsString(item)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %s", item.Type())
+		}
+		result = append(result, str)
+	}
+	return result, nil
+}
+
+// toStarlark converts a Go value to Starlark using its YAML representation,
+// so scripts see the same keys as cpx-ci.yaml.
+func toStarlark(v any) (starlark.Value, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return goToStarlark(generic)
+}
+
+// fromStarlark converts a Starlark value into out via its YAML representation.
+func fromStarlark(v starlark.Value, out any) error {
+	generic, err := starlarkToGo(v)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+func goToStarlark(v any) (starlark.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case int:
+		return starlark.MakeInt(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case string:
+		return starlark.String(v), nil
+	case []any:
+		items := make([]starlark.Value, 0, len(v))
+		for _, item := range v {
+			sv, err := goToStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, sv)
+		}
+		return starlark.NewList(items), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(v))
+		for _, k := range keys {
+			sv, err := goToStarlark(v[k])
+			if err != nil {
+				return nil, err
+			}
+			_ = dict.SetKey(starlark.String(k), sv)
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %T", v)
+}
+
+func starlarkToGo(v starlark.Value) (any, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int:
+		i, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("integer %s out of range", v)
+		}
+		return i, nil
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.String:
+		return string(v), nil
+	case *starlark.List, starlark.Tuple:
+		var items []any
+		iter := v.(starlark.Iterable).Iterate()
+		defer iter.Done()
+		var item starlark.Value
+		for iter.Next(&item) {
+			gv, err := starlarkToGo(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, gv)
+		}
+		return items, nil
+	case *starlark.Dict:
+		m := make(map[string]any, v.Len())
+		for _, item := range v.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings, got %s", item[0].Type())
+			}
+			gv, err := starlarkToGo(item[1])
+			if err != nil {
+				return nil, err
+			}
+			m[key] = gv
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %s", v.Type())
+}
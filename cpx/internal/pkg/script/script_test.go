@@ -0,0 +1,139 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+func TestLoadMissing(t *testing.T) {
+	s, err := Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, s)
+	assert.False(t, s.HasHook(HookBuildArgs))
+
+	args, err := s.BuildArgs(BuildContext{})
+	require.NoError(t, err)
+	assert.Nil(t, args)
+}
+
+func TestLoadSyntaxError(t *testing.T) {
+	_, err := LoadSource("cpx.star", []byte("def build_args(ctx)\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadDisallowed(t *testing.T) {
+	_, err := LoadSource("cpx.star", []byte(`load("other.star", "x")`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+}
+
+func TestStepLimit(t *testing.T) {
+	s, err := LoadSource("cpx.star", []byte(`
+def build_args(ctx):
+    n = 0
+    for i in range(100000000):
+        n += i
+    return []
+`))
+	require.NoError(t, err)
+	_, err = s.BuildArgs(BuildContext{})
+	assert.Error(t, err)
+}
+
+func TestBuildArgs(t *testing.T) {
+	s, err := LoadSource("cpx.star", []byte(`
+def build_args(ctx):
+    args = []
+    if ctx["release"]:
+        args.append("-DENABLE_LTO=ON")
+    if ctx["sanitizer"] == "asan":
+        args.append("-DASAN=ON")
+    print("computed", len(args), "args")
+    return args
+`))
+	require.NoError(t, err)
+	var printed []string
+	s.SetOutput(func(msg string) { printed = append(printed, msg) })
+
+	args, err := s.BuildArgs(BuildContext{Release: true, Sanitizer: "asan"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-DENABLE_LTO=ON", "-DASAN=ON"}, args)
+	assert.Equal(t, []string{"computed 2 args"}, printed)
+}
+
+func TestBuildArgsInvalidResult(t *testing.T) {
+	s, err := LoadSource("cpx.star", []byte(`
+def build_args(ctx):
+    return [1, 2]
+`))
+	require.NoError(t, err)
+	_, err = s.BuildArgs(BuildContext{})
+	assert.Error(t, err)
+}
+
+func TestToolchainMatrix(t *testing.T) {
+	s, err := LoadSource("cpx.star", []byte(`
+def toolchain_matrix(toolchains):
+    result = []
+    for tc in toolchains:
+        for opt in ["2", "3"]:
+            result.append({
+                "name": tc["name"] + "-O" + opt,
+                "runner": tc.get("runner", ""),
+                "optimization": opt,
+            })
+    return result
+`))
+	require.NoError(t, err)
+
+	matrix, err := s.ToolchainMatrix([]config.Toolchain{{Name: "linux", Runner: "gcc"}})
+	require.NoError(t, err)
+	require.Len(t, matrix, 2)
+	assert.Equal(t, "linux-O2", matrix[0].Name)
+	assert.Equal(t, "gcc", matrix[0].Runner)
+	assert.Equal(t, "3", matrix[1].Optimization)
+}
+
+func TestToolchainMatrixMissingName(t *testing.T) {
+	s, err := LoadSource("cpx.star", []byte(`
+def toolchain_matrix(toolchains):
+    return [{"runner": "gcc"}]
+`))
+	require.NoError(t, err)
+	_, err = s.ToolchainMatrix(nil)
+	assert.Error(t, err)
+}
+
+func TestPostArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app"), []byte("bin"), 0755))
+
+	s, err := LoadSource("cpx.star", []byte(`
+def post_artifacts(ctx):
+    return {a: a + "-linux-x64" for a in ctx["artifacts"]}
+`))
+	require.NoError(t, err)
+	require.NoError(t, s.PostArtifacts(dir))
+
+	assert.FileExists(t, filepath.Join(dir, "app-linux-x64"))
+	assert.NoFileExists(t, filepath.Join(dir, "app"))
+}
+
+func TestPostArtifactsRejectsEscape(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app"), []byte("bin"), 0755))
+
+	s, err := LoadSource("cpx.star", []byte(`
+def post_artifacts(ctx):
+    return {"app": "../app"}
+`))
+	require.NoError(t, err)
+	assert.Error(t, s.PostArtifacts(dir))
+	assert.FileExists(t, filepath.Join(dir, "app"))
+}
@@ -0,0 +1,214 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/naming"
+)
+
+// ============================================================================
+// HEADER-ONLY LIBRARY TEMPLATES
+// ============================================================================
+
+// A header-only library has no src/: its code lives in
+// include/<name>/<name>.hpp, and each build system declares a target that
+// only carries the include path (a CMake INTERFACE library, a hdrs-only
+// cc_library, a Meson declare_dependency). Tests and benchmarks depend on
+// that target instead of linking a compiled library.
+
+// GenerateHeaderOnlyLibHeader generates include/<name>/<name>.hpp with inline
+// definitions.
+func GenerateHeaderOnlyLibHeader(projectName string) string {
+	safeName := naming.SafeIdent(projectName)
+	guard := naming.SafeIdentUpper(projectName) + "_HPP"
+	return fmt.Sprintf(`#ifndef %[1]s
+#define %[1]s
+
+#include <iostream>
+#include <string>
+
+namespace %[2]s {
+
+/**
+ * @brief Greet function
+ */
+inline void greet() {
+    std::cout << "Hello from %[3]s!" << std::endl;
+}
+
+/**
+ * @brief Get the library version
+ * @return Version string
+ */
+inline std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace %[2]s
+
+#endif  // %[1]s
+`, guard, safeName, projectName)
+}
+
+// GenerateHeaderOnlyCMakeLists generates the root CMakeLists.txt of a
+// header-only library: an INTERFACE target with the usual install rules.
+func GenerateHeaderOnlyCMakeLists(projectName string, cppStandard int, includeTests bool, includeBench bool, projectVersion string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf(`cmake_minimum_required(VERSION 3.20)
+project(%s VERSION %s LANGUAGES CXX)
+
+# Set C++ standard
+set(CMAKE_CXX_STANDARD %d)
+set(CMAKE_CXX_STANDARD_REQUIRED ON)
+set(CMAKE_CXX_EXTENSIONS OFF)
+
+# Export compile commands for IDE support
+set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+
+`, projectName, projectVersion, cppStandard))
+
+	if includeTests || includeBench {
+		sb.WriteString("# Build options\n")
+		if includeTests {
+			sb.WriteString("option(ENABLE_TESTING \"Build tests\" OFF)\n")
+		}
+		if includeBench {
+			sb.WriteString("option(ENABLE_BENCHMARKS \"Build benchmarks\" OFF)\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf(`# Header-only library: nothing is compiled, consumers get the include path
+# and the C++ standard
+add_library(%[1]s INTERFACE)
+add_library(%[1]s::%[1]s ALIAS %[1]s)
+
+target_include_directories(%[1]s
+    INTERFACE
+        $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>
+        $<INSTALL_INTERFACE:include>
+)
+target_compile_features(%[1]s INTERFACE cxx_std_%[2]d)
+
+`, projectName, cppStandard))
+
+	// The installed package does not depend on the architecture
+	install := GenerateCMakeInstallRules(projectName)
+	install = strings.Replace(install, "    COMPATIBILITY SameMajorVersion\n",
+		"    COMPATIBILITY SameMajorVersion\n    ARCH_INDEPENDENT\n", 1)
+	install = strings.Replace(install, "${CMAKE_INSTALL_LIBDIR}/pkgconfig", "${CMAKE_INSTALL_DATADIR}/pkgconfig", 1)
+	sb.WriteString(install)
+
+	if includeTests {
+		sb.WriteString(`# Testing
+if(ENABLE_TESTING)
+    enable_testing()
+    add_subdirectory(tests)
+endif()
+`)
+	}
+
+	if includeBench {
+		sb.WriteString(`
+# Benchmarks
+if(ENABLE_BENCHMARKS)
+    add_subdirectory(bench)
+endif()
+`)
+	}
+
+	return sb.String()
+}
+
+// GenerateHeaderOnlyPkgConfig generates cmake/<name>.pc.in for a header-only
+// library, which has only Cflags.
+func GenerateHeaderOnlyPkgConfig(projectName string) string {
+	return fmt.Sprintf(`prefix=${pcfiledir}/../..
+includedir=${prefix}/@CMAKE_INSTALL_INCLUDEDIR@
+
+Name: %[1]s
+Description: %[1]s header-only library
+Version: @PROJECT_VERSION@
+Cflags: -I${includedir}
+`, projectName)
+}
+
+// LinkHeaderOnlyCMake rewrites tests/ or bench/CMakeLists.txt, generated by
+// GenerateTestCMake or GenerateBenchCMake, to link the INTERFACE target of a
+// header-only library instead of compiling src/<name>.cpp.
+func LinkHeaderOnlyCMake(cmake, projectName, target string) string {
+	cmake = strings.Replace(cmake, fmt.Sprintf("    ${CMAKE_CURRENT_SOURCE_DIR}/../src/%s.cpp\n", projectName), "", 1)
+	return strings.Replace(cmake, fmt.Sprintf(`target_include_directories(%s
+    PRIVATE
+        ${CMAKE_CURRENT_SOURCE_DIR}/../include
+)`, target), fmt.Sprintf("target_link_libraries(%s PRIVATE %s::%s)", target, projectName, projectName), 1)
+}
+
+// GenerateHeaderOnlyBuildBazelRoot generates the root BUILD.bazel of a
+// header-only library, aliasing the library in include/.
+func GenerateHeaderOnlyBuildBazelRoot(projectName string) string {
+	return fmt.Sprintf(`# Root BUILD.bazel - aliases for convenience
+
+# Alias to the header-only library
+alias(
+    name = "%[1]s",
+    actual = "//include:%[1]s",
+    visibility = ["//visibility:public"],
+)
+`, projectName)
+}
+
+// GenerateHeaderOnlyBuildBazelInclude generates include/BUILD.bazel of a
+// header-only library: a cc_library with headers only.
+func GenerateHeaderOnlyBuildBazelInclude(projectName string) string {
+	return fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "cc_library")
+
+# Header-only library
+cc_library(
+    name = "%[1]s",
+    hdrs = glob(["%[1]s/*.hpp"]),
+    includes = ["."],
+    visibility = ["//visibility:public"],
+)
+`, projectName)
+}
+
+// LinkHeaderOnlyBazel rewrites tests/ or bench/BUILD.bazel to depend on the
+// header-only library instead of //src.
+func LinkHeaderOnlyBazel(buildFile, projectName string) string {
+	return strings.ReplaceAll(buildFile, fmt.Sprintf(`"//src:%s_lib"`, projectName), fmt.Sprintf(`"//include:%s"`, projectName))
+}
+
+// GenerateHeaderOnlyMesonBuildRoot generates the root meson.build of a
+// header-only library, which declares the library's dependency object in
+// place of subdir('src').
+func GenerateHeaderOnlyMesonBuildRoot(projectName string, cppStandard int, testFramework, benchmarkFramework string) string {
+	safeName := naming.SafeIdent(projectName)
+	root := GenerateMesonBuildRoot(projectName, false, cppStandard, testFramework, benchmarkFramework, false)
+	root = strings.Replace(root, "subdir('src')\n", fmt.Sprintf(`# Header-only library: nothing is compiled, dependents get the include path
+%[2]s_dep = declare_dependency(include_directories : inc_dirs)
+meson.override_dependency('%[1]s', %[2]s_dep)
+
+# Headers and pkg-config file for consumers
+install_subdir('include/%[1]s', install_dir : get_option('includedir'))
+pkg = import('pkgconfig')
+pkg.generate(
+  name : '%[1]s',
+  description : '%[1]s header-only library',
+  install_dir : get_option('datadir') / 'pkgconfig',
+)
+
+`, projectName, safeName), 1)
+	return strings.Replace(root, "'Type': 'library'", "'Type': 'header-only library'", 1)
+}
+
+// LinkHeaderOnlyMeson rewrites tests/ or bench/meson.build to use the
+// header-only library's dependency object instead of linking <name>_lib.
+func LinkHeaderOnlyMeson(mesonBuild, projectName string) string {
+	safeName := naming.SafeIdent(projectName)
+	lib := fmt.Sprintf("  link_with : %s_lib", safeName)
+	mesonBuild = strings.Replace(mesonBuild, lib+",\n  dependencies : [", fmt.Sprintf("  dependencies : [%s_dep, ", safeName), 1)
+	return strings.Replace(mesonBuild, lib+"\n", fmt.Sprintf("  dependencies : [%s_dep]\n", safeName), 1)
+}
@@ -0,0 +1,237 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/naming"
+)
+
+// ============================================================================
+// C++20 MODULES TEMPLATES
+// ============================================================================
+
+// ModulesMinCppStandard is the lowest C++ standard with modules.
+const ModulesMinCppStandard = 20
+
+// ModuleLibraryTarget returns the CMake target holding the module of a
+// modules project: the library itself, or <name>_lib for an executable.
+func ModuleLibraryTarget(projectName string, isExe bool) string {
+	if isExe {
+		return projectName + "_lib"
+	}
+	return projectName
+}
+
+// GenerateModuleInterface generates src/<name>.cppm, the primary module
+// interface unit. The module is named after the project's namespace.
+func GenerateModuleInterface(projectName string) string {
+	safeName := naming.SafeIdent(projectName)
+	return fmt.Sprintf(`// Primary module interface unit: declares what the module exports
+module;
+
+#include <string>
+
+export module %[1]s;
+
+export namespace %[1]s {
+
+/**
+ * @brief Greet function
+ */
+void greet();
+
+/**
+ * @brief Get the library version
+ * @return Version string
+ */
+std::string version();
+
+}  // namespace %[1]s
+`, safeName)
+}
+
+// GenerateModuleSource generates src/<name>.cpp, the module implementation
+// unit.
+func GenerateModuleSource(projectName string) string {
+	safeName := naming.SafeIdent(projectName)
+	return fmt.Sprintf(`// Module implementation unit
+module;
+
+#include <iostream>
+#include <string>
+
+module %[1]s;
+
+namespace %[1]s {
+
+void greet() {
+    std::cout << "Hello from %[2]s!" << std::endl;
+}
+
+std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace %[1]s
+`, safeName, projectName)
+}
+
+// GenerateModuleMainCpp generates src/main.cpp importing the module.
+func GenerateModuleMainCpp(projectName string) string {
+	safeName := naming.SafeIdent(projectName)
+	return fmt.Sprintf(`import %[1]s;
+
+int main() {
+    %[1]s::greet();
+    return 0;
+}
+`, safeName)
+}
+
+// ImportModule rewrites a test or benchmark source generated for the
+// header/source layout to import the project's module instead of including
+// its header.
+func ImportModule(source, projectName string) string {
+	include := fmt.Sprintf("#include <%s/%s.hpp>\n", projectName, projectName)
+	return strings.Replace(source, include, fmt.Sprintf("import %s;\n", naming.SafeIdent(projectName)), 1)
+}
+
+// GenerateModulesCMakeLists generates the root CMakeLists.txt of a C++20
+// modules project. Modules need CMake 3.28 (CXX_MODULES file sets), a
+// generator that can scan module dependencies and a compiler that supports
+// them, which the generated file checks before anything is built.
+func GenerateModulesCMakeLists(projectName string, cppStandard int, isExe bool, includeTests bool, includeBench bool, projectVersion string, sharedLibrary bool) string {
+	if cppStandard < ModulesMinCppStandard {
+		cppStandard = ModulesMinCppStandard
+	}
+	lib := ModuleLibraryTarget(projectName, isExe)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`cmake_minimum_required(VERSION 3.28)
+project(%s VERSION %s LANGUAGES CXX)
+
+# Set C++ standard (modules need C++20)
+set(CMAKE_CXX_STANDARD %d)
+set(CMAKE_CXX_STANDARD_REQUIRED ON)
+set(CMAKE_CXX_EXTENSIONS OFF)
+
+# Export compile commands for IDE support
+set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+
+`, projectName, projectVersion, cppStandard))
+
+	sb.WriteString(`# C++20 modules need a generator that scans module dependencies and a
+# compiler that supports them
+if(NOT CMAKE_GENERATOR MATCHES "Ninja|Visual Studio")
+    message(FATAL_ERROR "C++20 modules need the Ninja (1.11+) or Visual Studio generator, not ${CMAKE_GENERATOR}")
+endif()
+if(CMAKE_CXX_COMPILER_ID STREQUAL "GNU" AND CMAKE_CXX_COMPILER_VERSION VERSION_LESS 14)
+    message(FATAL_ERROR "C++20 modules need GCC 14 or newer (found ${CMAKE_CXX_COMPILER_VERSION})")
+elseif(CMAKE_CXX_COMPILER_ID STREQUAL "Clang" AND CMAKE_CXX_COMPILER_VERSION VERSION_LESS 16)
+    message(FATAL_ERROR "C++20 modules need Clang 16 or newer (found ${CMAKE_CXX_COMPILER_VERSION})")
+elseif(CMAKE_CXX_COMPILER_ID STREQUAL "AppleClang")
+    message(FATAL_ERROR "AppleClang cannot scan C++20 modules; use LLVM Clang 16 or newer (brew install llvm)")
+elseif(CMAKE_CXX_COMPILER_ID STREQUAL "MSVC" AND CMAKE_CXX_COMPILER_VERSION VERSION_LESS 19.34)
+    message(FATAL_ERROR "C++20 modules need MSVC 19.34 (Visual Studio 17.4) or newer (found ${CMAKE_CXX_COMPILER_VERSION})")
+endif()
+
+`)
+
+	if includeTests || includeBench {
+		sb.WriteString("# Options\n")
+		if includeTests {
+			sb.WriteString("option(ENABLE_TESTING \"Build tests\" OFF)\n")
+		}
+		if includeBench {
+			sb.WriteString("option(ENABLE_BENCHMARKS \"Build benchmarks\" OFF)\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	comment := "# Library (static or shared, see BUILD_SHARED_LIBS)"
+	if isExe {
+		comment = "# Module library (for linking by the executable, tests and benchmarks)"
+	} else {
+		sharedDefault := "OFF"
+		if sharedLibrary {
+			sharedDefault = "ON"
+		}
+		sb.WriteString(fmt.Sprintf("option(BUILD_SHARED_LIBS \"Build shared libraries\" %s)\n\n", sharedDefault))
+	}
+	sb.WriteString(fmt.Sprintf(`%[1]s
+add_library(%[2]s)
+target_sources(%[2]s
+    PUBLIC
+        FILE_SET CXX_MODULES FILES src/%[3]s.cppm
+    PRIVATE
+        src/%[3]s.cpp
+)
+add_library(%[3]s::%[2]s ALIAS %[2]s)
+
+target_include_directories(%[2]s
+    PUBLIC
+        $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>
+        $<INSTALL_INTERFACE:include>
+)
+
+`, comment, lib, projectName))
+
+	if isExe {
+		sb.WriteString(fmt.Sprintf(`# Executable
+add_executable(%[1]s
+    src/main.cpp
+)
+target_link_libraries(%[1]s PRIVATE %[2]s)
+
+`, projectName, lib))
+	} else {
+		// The module interface is installed next to the package config, so
+		// consumers can build it with their own compiler settings
+		install := strings.Replace(GenerateCMakeInstallRules(projectName),
+			"    INCLUDES DESTINATION ${CMAKE_INSTALL_INCLUDEDIR}\n",
+			fmt.Sprintf("    INCLUDES DESTINATION ${CMAKE_INSTALL_INCLUDEDIR}\n    FILE_SET CXX_MODULES DESTINATION ${CMAKE_INSTALL_LIBDIR}/cmake/%s/modules\n", projectName), 1)
+		sb.WriteString(install)
+	}
+
+	if includeTests {
+		sb.WriteString(`# Testing
+if(ENABLE_TESTING)
+    enable_testing()
+    add_subdirectory(tests)
+endif()
+`)
+	}
+
+	if includeBench {
+		sb.WriteString(`
+# Benchmarks
+if(ENABLE_BENCHMARKS)
+    add_subdirectory(bench)
+endif()
+`)
+	}
+
+	return sb.String()
+}
+
+// GenerateModulesTestCMake generates tests/CMakeLists.txt for a modules
+// project: the tests link the module library instead of compiling its
+// sources again.
+func GenerateModulesTestCMake(projectName string, testingFramework string, isExe bool) string {
+	return linkModuleLibrary(GenerateTestCMake(projectName, testingFramework), projectName, projectName+"_tests", isExe)
+}
+
+// GenerateModulesBenchCMake generates bench/CMakeLists.txt for a modules
+// project.
+func GenerateModulesBenchCMake(projectName string, benchmarkFramework string, isExe bool) string {
+	return linkModuleLibrary(GenerateBenchCMake(projectName, benchmarkFramework), projectName, projectName+"_bench", isExe)
+}
+
+// linkModuleLibrary replaces the project source compiled into target with a
+// link to the module library.
+func linkModuleLibrary(cmake, projectName, target string, isExe bool) string {
+	source := fmt.Sprintf("    ${CMAKE_CURRENT_SOURCE_DIR}/../src/%s.cpp\n", projectName)
+	cmake = strings.Replace(cmake, source, "", 1)
+	return cmake + fmt.Sprintf("\n# The module library provides the project's module\ntarget_link_libraries(%s PRIVATE %s)\n", target, ModuleLibraryTarget(projectName, isExe))
+}
@@ -35,6 +35,37 @@ type ProjectTemplate interface {
 	Dependencies() []string
 }
 
+// BuildSystemsTemplate is implemented by templates that can generate more
+// than the default vcpkg/CMake layout
+type BuildSystemsTemplate interface {
+	// PackageManagers returns the supported build systems, default first
+	PackageManagers() []string
+}
+
+// PackageManagers returns the build systems a template supports
+func PackageManagers(t ProjectTemplate) []string {
+	if bt, ok := t.(BuildSystemsTemplate); ok {
+		return bt.PackageManagers()
+	}
+	return []string{"vcpkg"}
+}
+
+// PostGenerateTemplate is implemented by templates with commands to run in
+// the generated project, such as code generators or asset downloads
+type PostGenerateTemplate interface {
+	// PostGenerate returns shell commands, run in order from the project
+	// directory
+	PostGenerate() []string
+}
+
+// PostGenerateHooks returns the post-generate commands of a template
+func PostGenerateHooks(t ProjectTemplate) []string {
+	if pt, ok := t.(PostGenerateTemplate); ok {
+		return pt.PostGenerate()
+	}
+	return nil
+}
+
 // TemplateInfo contains display information for a template
 type TemplateInfo struct {
 	Name        string
@@ -0,0 +1,521 @@
+package project_templates
+
+import (
+	"fmt"
+
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/internal/pkg/utils/naming"
+)
+
+func init() {
+	RegisterTemplate(&CUDATemplate{})
+}
+
+// CUDATemplate generates a CUDA application
+type CUDATemplate struct {
+	BaseTemplateHelper
+}
+
+func (t *CUDATemplate) Name() string {
+	return "CUDA"
+}
+
+func (t *CUDATemplate) Description() string {
+	return "GPU application with CUDA kernels (needs nvcc or the CUDA toolchain)"
+}
+
+func (t *CUDATemplate) Dependencies() []string {
+	return []string{} // The CUDA toolkit provides the runtime
+}
+
+// cudaMaxCppStandard is the newest C++ standard nvcc accepts.
+const cudaMaxCppStandard = 20
+
+func (t *CUDATemplate) PackageManagers() []string {
+	return []string{"vcpkg", "meson"}
+}
+
+func (t *CUDATemplate) Generate(config TemplateConfig) error {
+	projectName := config.ProjectName
+	if config.PackageManager == "bazel" {
+		return fmt.Errorf("the CUDA template supports vcpkg (CMake) and meson projects, not bazel")
+	}
+
+	// Create directory structure
+	dirs := []string{
+		"include/" + projectName,
+		"src",
+		"tests",
+		"dockerfiles",
+		"docs",
+	}
+	if err := t.CreateProjectStructure(projectName, dirs); err != nil {
+		return err
+	}
+
+	// Generate sources
+	files := map[string]string{
+		"include/" + projectName + "/kernels.hpp": t.generateKernelsHeader(projectName),
+		"include/" + projectName + "/version.hpp": templates.GenerateVersionHpp(projectName, "0.1.0"),
+		"src/kernels.cu":              t.generateKernelsSource(projectName),
+		"src/main.cpp":                t.generateMainCpp(projectName),
+		"tests/test_kernels.cpp":      t.generateTest(projectName),
+		"dockerfiles/Dockerfile.cuda": t.generateDockerfile(),
+	}
+	for path, content := range files {
+		if err := t.WriteFile(projectName, path, content); err != nil {
+			return err
+		}
+	}
+
+	// Generate build system files
+	if config.PackageManager == "meson" {
+		if err := t.WriteFile(projectName, "meson.build", t.generateMesonBuild(projectName, config.CppStandard)); err != nil {
+			return err
+		}
+		if err := t.WriteFile(projectName, "meson_options.txt", t.generateMesonOptions()); err != nil {
+			return err
+		}
+	} else {
+		cmakeLists := t.generateCMakeLists(projectName, config.CppStandard)
+		if err := t.WriteFile(projectName, "CMakeLists.txt", cmakeLists); err != nil {
+			return err
+		}
+		cmakePresets := templates.GenerateCMakePresets()
+		if err := t.WriteFile(projectName, "CMakePresets.json", cmakePresets); err != nil {
+			return err
+		}
+		if err := t.SetupVcpkg(projectName, t.Dependencies()); err != nil {
+			return fmt.Errorf("failed to setup vcpkg: %w", err)
+		}
+	}
+
+	// Generate common files, with a cpx-ci.yaml for the CUDA toolchain
+	if err := t.GenerateCommonFiles(config); err != nil {
+		return err
+	}
+	if err := t.WriteFile(projectName, "cpx-ci.yaml", t.generateCpxCI()); err != nil {
+		return err
+	}
+
+	// Generate README
+	readme := t.generateReadme(projectName)
+	if err := t.WriteFile(projectName, "README.md", readme); err != nil {
+		return err
+	}
+
+	// Initialize git
+	_ = t.InitGitRepo(projectName)
+
+	t.PrintSuccess(projectName)
+	fmt.Println("  Note: This is a CUDA project. Without a local nvcc, use 'cpx build --toolchain cuda'.")
+	return nil
+}
+
+// cudaStandard returns the C++ standard CUDA sources are compiled with.
+func cudaStandard(cppStandard int) int {
+	if cppStandard > cudaMaxCppStandard {
+		return cudaMaxCppStandard
+	}
+	return cppStandard
+}
+
+func (t *CUDATemplate) generateKernelsHeader(projectName string) string {
+	guardName := fmt.Sprintf("%s_KERNELS_HPP", toUpperSnakeCase(projectName))
+	return fmt.Sprintf(`#ifndef %[1]s
+#define %[1]s
+
+#include <vector>
+
+namespace %[2]s {
+
+/**
+ * @brief Number of CUDA devices available (0 without a GPU or driver)
+ */
+int device_count();
+
+/**
+ * @brief Add two vectors element-wise on the GPU
+ * @throws std::runtime_error if a CUDA call fails
+ */
+std::vector<float> vector_add(const std::vector<float>& a, const std::vector<float>& b);
+
+}  // namespace %[2]s
+
+#endif // %[1]s
+`, guardName, naming.SafeIdent(projectName))
+}
+
+func (t *CUDATemplate) generateKernelsSource(projectName string) string {
+	return fmt.Sprintf(`#include "%[1]s/kernels.hpp"
+
+#include <cuda_runtime.h>
+
+#include <stdexcept>
+#include <string>
+
+namespace %[2]s {
+
+namespace {
+
+__global__ void vector_add_kernel(const float* a, const float* b, float* out, int n) {
+    int i = blockIdx.x * blockDim.x + threadIdx.x;
+    if (i < n) {
+        out[i] = a[i] + b[i];
+    }
+}
+
+void check(cudaError_t err, const char* what) {
+    if (err != cudaSuccess) {
+        throw std::runtime_error(std::string(what) + ": " + cudaGetErrorString(err));
+    }
+}
+
+// DeviceBuffer owns an array in GPU memory
+class DeviceBuffer {
+public:
+    explicit DeviceBuffer(size_t count) : m_bytes(count * sizeof(float)) {
+        check(cudaMalloc(&m_data, m_bytes), "cudaMalloc");
+    }
+    ~DeviceBuffer() { cudaFree(m_data); }
+    DeviceBuffer(const DeviceBuffer&) = delete;
+    DeviceBuffer& operator=(const DeviceBuffer&) = delete;
+
+    float* data() { return m_data; }
+
+    void upload(const std::vector<float>& host) {
+        check(cudaMemcpy(m_data, host.data(), m_bytes, cudaMemcpyHostToDevice), "cudaMemcpy");
+    }
+
+    void download(std::vector<float>& host) const {
+        check(cudaMemcpy(host.data(), m_data, m_bytes, cudaMemcpyDeviceToHost), "cudaMemcpy");
+    }
+
+private:
+    float* m_data = nullptr;
+    size_t m_bytes;
+};
+
+}  // namespace
+
+int device_count() {
+    int count = 0;
+    if (cudaGetDeviceCount(&count) != cudaSuccess) {
+        return 0;
+    }
+    return count;
+}
+
+std::vector<float> vector_add(const std::vector<float>& a, const std::vector<float>& b) {
+    if (a.size() != b.size()) {
+        throw std::invalid_argument("vector_add: vectors differ in size");
+    }
+    const int n = static_cast<int>(a.size());
+    std::vector<float> out(a.size());
+    if (n == 0) {
+        return out;
+    }
+
+    DeviceBuffer dA(a.size()), dB(b.size()), dOut(out.size());
+    dA.upload(a);
+    dB.upload(b);
+
+    const int threads = 256;
+    const int blocks = (n + threads - 1) / threads;
+    vector_add_kernel<<<blocks, threads>>>(dA.data(), dB.data(), dOut.data(), n);
+    check(cudaGetLastError(), "vector_add_kernel");
+    check(cudaDeviceSynchronize(), "cudaDeviceSynchronize");
+
+    dOut.download(out);
+    return out;
+}
+
+}  // namespace %[2]s
+`, projectName, naming.SafeIdent(projectName))
+}
+
+func (t *CUDATemplate) generateMainCpp(projectName string) string {
+	return fmt.Sprintf(`// %[1]s - CUDA Application
+// Generated by cpx
+
+#include <iostream>
+#include <vector>
+
+#include "%[1]s/kernels.hpp"
+
+int main() {
+    if (%[2]s::device_count() == 0) {
+        std::cerr << "No CUDA device found" << std::endl;
+        return 1;
+    }
+
+    std::vector<float> a(1 << 20, 1.0f);
+    std::vector<float> b(a.size(), 2.0f);
+    std::vector<float> sum = %[2]s::vector_add(a, b);
+
+    std::cout << "Added " << sum.size() << " elements on the GPU: "
+              << a[0] << " + " << b[0] << " = " << sum[0] << std::endl;
+    return 0;
+}
+`, projectName, naming.SafeIdent(projectName))
+}
+
+// generateTest generates a test without a framework, so it builds with any
+// CUDA toolkit. It exits with 77, which CTest and Meson report as skipped,
+// on machines without a GPU.
+func (t *CUDATemplate) generateTest(projectName string) string {
+	return fmt.Sprintf(`#include <cmath>
+#include <iostream>
+#include <vector>
+
+#include "%[1]s/kernels.hpp"
+
+int main() {
+    if (%[2]s::device_count() == 0) {
+        std::cout << "No CUDA device found, skipping" << std::endl;
+        return 77;
+    }
+
+    std::vector<float> a = {1.0f, 2.0f, 3.0f};
+    std::vector<float> b = {10.0f, 20.0f, 30.0f};
+    std::vector<float> sum = %[2]s::vector_add(a, b);
+
+    for (size_t i = 0; i < a.size(); ++i) {
+        if (std::fabs(sum[i] - (a[i] + b[i])) > 1e-6f) {
+            std::cerr << "vector_add: element " << i << " is " << sum[i] << std::endl;
+            return 1;
+        }
+    }
+    std::cout << "vector_add: ok" << std::endl;
+    return 0;
+}
+`, projectName, naming.SafeIdent(projectName))
+}
+
+func (t *CUDATemplate) generateCMakeLists(projectName string, cppStandard int) string {
+	return fmt.Sprintf(`cmake_minimum_required(VERSION 3.24)
+
+# GPU architectures: cpx passes the "cuda" setting of cpx-ci.yaml as
+# CMAKE_CUDA_ARCHITECTURES (or CUDAARCHS); otherwise the GPUs of this
+# machine are targeted
+if(NOT DEFINED CMAKE_CUDA_ARCHITECTURES AND NOT DEFINED ENV{CUDAARCHS})
+    set(CMAKE_CUDA_ARCHITECTURES native)
+endif()
+
+project(%[1]s VERSION 0.1.0 LANGUAGES CXX CUDA)
+
+set(CMAKE_CXX_STANDARD %[2]d)
+set(CMAKE_CXX_STANDARD_REQUIRED ON)
+set(CMAKE_CUDA_STANDARD %[3]d)
+set(CMAKE_CUDA_STANDARD_REQUIRED ON)
+set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+
+find_package(CUDAToolkit REQUIRED)
+
+option(ENABLE_TESTING "Build tests" OFF)
+
+# Kernels
+add_library(%[1]s_kernels STATIC
+    src/kernels.cu
+)
+target_include_directories(%[1]s_kernels PUBLIC
+    ${CMAKE_CURRENT_SOURCE_DIR}/include
+)
+set_target_properties(%[1]s_kernels PROPERTIES POSITION_INDEPENDENT_CODE ON)
+target_link_libraries(%[1]s_kernels PUBLIC CUDA::cudart)
+
+# Executable
+add_executable(%[1]s
+    src/main.cpp
+)
+target_link_libraries(%[1]s PRIVATE %[1]s_kernels)
+
+# Testing (exit code 77 marks a test skipped on machines without a GPU)
+if(ENABLE_TESTING)
+    enable_testing()
+    add_executable(%[1]s_tests tests/test_kernels.cpp)
+    target_link_libraries(%[1]s_tests PRIVATE %[1]s_kernels)
+    add_test(NAME %[1]s_tests COMMAND %[1]s_tests)
+    set_tests_properties(%[1]s_tests PROPERTIES SKIP_RETURN_CODE 77)
+endif()
+
+# Copy compile_commands.json to project root
+if(CMAKE_EXPORT_COMPILE_COMMANDS)
+    add_custom_target(copy_compile_commands ALL
+        ${CMAKE_COMMAND} -E copy_if_different
+        ${CMAKE_BINARY_DIR}/compile_commands.json
+        ${CMAKE_SOURCE_DIR}/compile_commands.json
+    )
+endif()
+`, projectName, cppStandard, cudaStandard(cppStandard))
+}
+
+func (t *CUDATemplate) generateMesonBuild(projectName string, cppStandard int) string {
+	return fmt.Sprintf(`project('%[1]s', ['cpp', 'cuda'],
+  version : '0.1.0',
+  meson_version : '>= 1.1.0',
+  default_options : [
+    'cpp_std=c++%[2]d',
+    'cuda_std=c++%[3]d',
+  ]
+)
+
+# GPU architectures: cpx passes the "cuda" setting of cpx-ci.yaml as
+# -Dcuda_arch=7.5,8.6
+nvcc = meson.get_compiler('cuda')
+cuda = import('unstable-cuda')
+arch_flags = cuda.nvcc_arch_flags(nvcc.version(), get_option('cuda_arch'))
+add_project_arguments(arch_flags, language : 'cuda')
+add_project_link_arguments(arch_flags, language : 'cuda')
+
+cuda_dep = dependency('cuda')
+inc = include_directories('include')
+
+# Kernels
+kernels = static_library('%[1]s_kernels',
+  'src/kernels.cu',
+  include_directories : inc,
+  dependencies : cuda_dep,
+)
+
+# Executable
+executable('%[1]s',
+  'src/main.cpp',
+  include_directories : inc,
+  link_with : kernels,
+  dependencies : cuda_dep,
+)
+
+# Tests (exit code 77 marks a test skipped on machines without a GPU)
+test_exe = executable('%[1]s_tests',
+  'tests/test_kernels.cpp',
+  include_directories : inc,
+  link_with : kernels,
+  dependencies : cuda_dep,
+)
+test('%[1]s_tests', test_exe)
+`, projectName, cppStandard, cudaStandard(cppStandard))
+}
+
+func (t *CUDATemplate) generateMesonOptions() string {
+	return `option('cuda_arch', type : 'array', value : ['Auto'],
+  description : 'GPU architectures for nvcc, e.g. 7.5,8.6 (Auto, Common and All select sets of architectures)')
+`
+}
+
+func (t *CUDATemplate) generateCpxCI() string {
+	return `# cpx-ci.yaml - CI toolchain configuration
+
+# GPU architectures CUDA sources are compiled for: sm_xx names, compute
+# capabilities (8.6) or native, all, all-major. Without this, cpx build
+# targets the GPUs of the build machine.
+# cuda:
+#   architectures: [sm_75, sm_86]
+
+runners:
+  # nvcc, CMake, Meson and vcpkg on the nvidia/cuda image
+  - name: cuda
+    type: docker
+    dockerfile: dockerfiles/Dockerfile.cuda
+    image: cpx-cuda:latest
+    gpus: all   # for cpx run/test --toolchain cuda; needs the NVIDIA Container Toolkit
+
+toolchains:
+  - name: cuda
+    runner: cuda
+    build_type: Release
+    cuda:
+      architectures: [all-major]   # the container may have no GPU to detect
+`
+}
+
+func (t *CUDATemplate) generateDockerfile() string {
+	return `# CUDA toolchain for cpx: nvcc from the nvidia/cuda image plus CMake,
+# Ninja, Meson and vcpkg
+FROM nvidia/cuda:12.6.3-devel-ubuntu22.04
+
+ENV DEBIAN_FRONTEND=noninteractive
+
+RUN apt-get update && apt-get install -y \
+    build-essential \
+    ninja-build \
+    pkg-config \
+    git \
+    curl \
+    tar \
+    zip \
+    unzip \
+    python3 \
+    python3-pip \
+    && rm -rf /var/lib/apt/lists/*
+
+# Install Meson
+RUN pip3 install meson
+
+# Install latest CMake from Kitware
+RUN CMAKE_VERSION=$(curl -s https://api.github.com/repos/Kitware/CMake/releases/latest | grep '"tag_name":' | sed -E 's/.*"v([^"]+)".*/\1/') && \
+    CMAKE_ARCH=$(uname -m) && \
+    curl -L "https://github.com/Kitware/CMake/releases/download/v${CMAKE_VERSION}/cmake-${CMAKE_VERSION}-linux-${CMAKE_ARCH}.tar.gz" -o /tmp/cmake.tar.gz && \
+    tar -xzf /tmp/cmake.tar.gz -C /opt && \
+    mv /opt/cmake-${CMAKE_VERSION}-linux-${CMAKE_ARCH} /opt/cmake && \
+    rm /tmp/cmake.tar.gz && \
+    ln -s /opt/cmake/bin/* /usr/local/bin/
+
+# Install vcpkg
+RUN git clone https://github.com/Microsoft/vcpkg.git /opt/vcpkg && \
+    /opt/vcpkg/bootstrap-vcpkg.sh
+
+ENV VCPKG_ROOT=/opt/vcpkg
+ENV PATH="${VCPKG_ROOT}:${PATH}"
+
+WORKDIR /workspace
+
+CMD ["/bin/bash"]
+`
+}
+
+func (t *CUDATemplate) generateReadme(projectName string) string {
+	return fmt.Sprintf(`# %s
+
+A CUDA application: a vector addition kernel called from C++.
+
+## Prerequisites
+
+The CUDA toolkit (nvcc) and an NVIDIA GPU to run on. cpx finds nvcc on PATH,
+in $CUDA_PATH or $CUDA_HOME, or in /usr/local/cuda.
+
+## Building
+
+`+"```"+`bash
+cpx build
+`+"```"+`
+
+Set the GPU architectures in cpx-ci.yaml; by default the GPUs of the build
+machine are targeted:
+
+`+"```"+`yaml
+cuda:
+  architectures: [sm_75, sm_86]
+`+"```"+`
+
+Without a local CUDA toolkit, build in the nvidia/cuda image:
+
+`+"```"+`bash
+cpx build --toolchain cuda
+`+"```"+`
+
+## Running
+
+`+"```"+`bash
+cpx run
+cpx test    # skipped on machines without a GPU
+`+"```"+`
+
+`+"`cpx run --toolchain cuda`"+` and `+"`cpx test --toolchain cuda`"+` run in the container
+with `+"`--gpus all`"+`, which needs the NVIDIA Container Toolkit.
+
+## License
+
+MIT
+`, projectName)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/internal/pkg/utils/naming"
 )
 
 func init() {
@@ -27,8 +28,13 @@ func (t *GRPCTemplate) Dependencies() []string {
 	return []string{"grpc", "protobuf"}
 }
 
+func (t *GRPCTemplate) PackageManagers() []string {
+	return []string{"vcpkg", "bazel", "meson"}
+}
+
 func (t *GRPCTemplate) Generate(config TemplateConfig) error {
 	projectName := config.ProjectName
+	protoName := naming.SafeIdent(projectName)
 
 	// Create directory structure
 	dirs := []string{
@@ -43,7 +49,7 @@ func (t *GRPCTemplate) Generate(config TemplateConfig) error {
 
 	// Generate proto file
 	protoFile := t.generateProtoFile(projectName)
-	if err := t.WriteFile(projectName, "proto/"+projectName+".proto", protoFile); err != nil {
+	if err := t.WriteFile(projectName, "proto/"+protoName+".proto", protoFile); err != nil {
 		return err
 	}
 
@@ -77,17 +83,39 @@ func (t *GRPCTemplate) Generate(config TemplateConfig) error {
 		return err
 	}
 
-	// Generate build system files (vcpkg only)
-	cmakeLists := t.generateCMakeLists(projectName, config.CppStandard)
-	if err := t.WriteFile(projectName, "CMakeLists.txt", cmakeLists); err != nil {
-		return err
+	// Generate build system files; each runs protoc with grpc_cpp_plugin as
+	// part of the build and puts the generated proto/<name>.pb.h and
+	// proto/<name>.grpc.pb.h on the include path
+	var buildFiles map[string]string
+	switch config.PackageManager {
+	case "bazel":
+		buildFiles = map[string]string{
+			"MODULE.bazel":      t.generateModuleBazel(projectName),
+			"BUILD.bazel":       t.generateBuildBazel(projectName),
+			"proto/BUILD.bazel": t.generateProtoBuildBazel(projectName),
+			".bazelrc":          templates.GenerateBazelrc(config.CppStandard),
+			".bazelignore":      templates.GenerateBazelignore(),
+		}
+	case "meson":
+		buildFiles = map[string]string{
+			"meson.build":       t.generateMesonBuild(projectName, config.CppStandard),
+			"proto/meson.build": t.generateProtoMesonBuild(projectName),
+		}
+	default:
+		buildFiles = map[string]string{
+			"CMakeLists.txt":    t.generateCMakeLists(projectName, config.CppStandard),
+			"CMakePresets.json": templates.GenerateCMakePresets(),
+		}
 	}
-	cmakePresets := templates.GenerateCMakePresets()
-	if err := t.WriteFile(projectName, "CMakePresets.json", cmakePresets); err != nil {
-		return err
+	for path, content := range buildFiles {
+		if err := t.WriteFile(projectName, path, content); err != nil {
+			return err
+		}
 	}
-	if err := t.SetupVcpkg(projectName, t.Dependencies()); err != nil {
-		return fmt.Errorf("failed to setup vcpkg: %w", err)
+	if config.PackageManager == "" || config.PackageManager == "vcpkg" {
+		if err := t.SetupVcpkg(projectName, t.Dependencies()); err != nil {
+			return fmt.Errorf("failed to setup vcpkg: %w", err)
+		}
 	}
 
 	// Generate common files
@@ -96,7 +124,7 @@ func (t *GRPCTemplate) Generate(config TemplateConfig) error {
 	}
 
 	// Generate README
-	readme := t.generateReadme(projectName)
+	readme := t.generateReadme(projectName, config.PackageManager)
 	if err := t.WriteFile(projectName, "README.md", readme); err != nil {
 		return err
 	}
@@ -133,7 +161,7 @@ message HelloRequest {
 message HelloReply {
   string message = 1;
 }
-`, projectName)
+`, naming.SafeIdent(projectName))
 }
 
 func (t *GRPCTemplate) generateServerMain(projectName string) string {
@@ -169,7 +197,7 @@ int main() {
 }
 
 func (t *GRPCTemplate) generateClientMain(projectName string) string {
-	return fmt.Sprintf(`// %s - gRPC Client
+	return fmt.Sprintf(`// %[1]s - gRPC Client
 // Generated by cpx
 
 #include <iostream>
@@ -177,18 +205,18 @@ func (t *GRPCTemplate) generateClientMain(projectName string) string {
 #include <string>
 
 #include <grpcpp/grpcpp.h>
-#include "%s.grpc.pb.h"
+#include "proto/%[2]s.grpc.pb.h"
 
 class GreeterClient {
 public:
     GreeterClient(std::shared_ptr<grpc::Channel> channel)
-        : stub_(%s::Greeter::NewStub(channel)) {}
+        : stub_(%[2]s::Greeter::NewStub(channel)) {}
 
     std::string SayHello(const std::string& name) {
-        %s::HelloRequest request;
+        %[2]s::HelloRequest request;
         request.set_name(name);
 
-        %s::HelloReply reply;
+        %[2]s::HelloReply reply;
         grpc::ClientContext context;
 
         grpc::Status status = stub_->SayHello(&context, request, &reply);
@@ -202,7 +230,7 @@ public:
     }
 
 private:
-    std::unique_ptr<%s::Greeter::Stub> stub_;
+    std::unique_ptr<%[2]s::Greeter::Stub> stub_;
 };
 
 int main(int argc, char** argv) {
@@ -218,39 +246,39 @@ int main(int argc, char** argv) {
 
     return 0;
 }
-`, projectName, projectName, projectName, projectName, projectName, projectName)
+`, projectName, naming.SafeIdent(projectName))
 }
 
 func (t *GRPCTemplate) generateServiceHeader(projectName string) string {
 	guardName := fmt.Sprintf("%s_SERVICE_IMPL_HPP", toUpperSnakeCase(projectName))
-	return fmt.Sprintf(`#ifndef %s
-#define %s
+	return fmt.Sprintf(`#ifndef %[1]s
+#define %[1]s
 
 #include <grpcpp/grpcpp.h>
-#include "%s.grpc.pb.h"
+#include "proto/%[2]s.grpc.pb.h"
 
-class GreeterServiceImpl final : public %s::Greeter::Service {
+class GreeterServiceImpl final : public %[2]s::Greeter::Service {
     grpc::Status SayHello(grpc::ServerContext* context,
-                          const %s::HelloRequest* request,
-                          %s::HelloReply* reply) override;
+                          const %[2]s::HelloRequest* request,
+                          %[2]s::HelloReply* reply) override;
 
     grpc::Status SayHelloStream(grpc::ServerContext* context,
-                                const %s::HelloRequest* request,
-                                grpc::ServerWriter<%s::HelloReply>* writer) override;
+                                const %[2]s::HelloRequest* request,
+                                grpc::ServerWriter<%[2]s::HelloReply>* writer) override;
 };
 
-#endif // %s
-`, guardName, guardName, projectName, projectName, projectName, projectName, projectName, projectName, guardName)
+#endif // %[1]s
+`, guardName, naming.SafeIdent(projectName))
 }
 
 func (t *GRPCTemplate) generateServiceImpl(projectName string) string {
-	return fmt.Sprintf(`#include "%s/service_impl.hpp"
+	return fmt.Sprintf(`#include "%[1]s/service_impl.hpp"
 #include <thread>
 #include <chrono>
 
 grpc::Status GreeterServiceImpl::SayHello(grpc::ServerContext* context,
-                                          const %s::HelloRequest* request,
-                                          %s::HelloReply* reply) {
+                                          const %[2]s::HelloRequest* request,
+                                          %[2]s::HelloReply* reply) {
     (void)context;
     std::string prefix("Hello ");
     reply->set_message(prefix + request->name());
@@ -258,25 +286,25 @@ grpc::Status GreeterServiceImpl::SayHello(grpc::ServerContext* context,
 }
 
 grpc::Status GreeterServiceImpl::SayHelloStream(grpc::ServerContext* context,
-                                                const %s::HelloRequest* request,
-                                                grpc::ServerWriter<%s::HelloReply>* writer) {
+                                                const %[2]s::HelloRequest* request,
+                                                grpc::ServerWriter<%[2]s::HelloReply>* writer) {
     (void)context;
     for (int i = 0; i < 5; i++) {
-        %s::HelloReply reply;
+        %[2]s::HelloReply reply;
         reply.set_message("Hello " + request->name() + " #" + std::to_string(i + 1));
         writer->Write(reply);
         std::this_thread::sleep_for(std::chrono::milliseconds(500));
     }
     return grpc::Status::OK;
 }
-`, projectName, projectName, projectName, projectName, projectName, projectName)
+`, projectName, naming.SafeIdent(projectName))
 }
 
 func (t *GRPCTemplate) generateCMakeLists(projectName string, cppStandard int) string {
 	return fmt.Sprintf(`cmake_minimum_required(VERSION 3.16)
-project(%s VERSION 0.1.0 LANGUAGES CXX)
+project(%[1]s VERSION 0.1.0 LANGUAGES CXX)
 
-set(CMAKE_CXX_STANDARD %d)
+set(CMAKE_CXX_STANDARD %[2]d)
 set(CMAKE_CXX_STANDARD_REQUIRED ON)
 set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
 
@@ -284,61 +312,50 @@ set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
 find_package(protobuf CONFIG REQUIRED)
 find_package(gRPC CONFIG REQUIRED)
 
-# Proto file
-get_filename_component(proto_file "proto/%s.proto" ABSOLUTE)
-get_filename_component(proto_path "${proto_file}" PATH)
-
-# Generated sources
-set(proto_srcs "${CMAKE_CURRENT_BINARY_DIR}/%s.pb.cc")
-set(proto_hdrs "${CMAKE_CURRENT_BINARY_DIR}/%s.pb.h")
-set(grpc_srcs "${CMAKE_CURRENT_BINARY_DIR}/%s.grpc.pb.cc")
-set(grpc_hdrs "${CMAKE_CURRENT_BINARY_DIR}/%s.grpc.pb.h")
-
-find_program(_PROTOBUF_PROTOC protoc)
-find_program(_GRPC_CPP_PLUGIN_EXECUTABLE grpc_cpp_plugin)
-
-# Generate protobuf and gRPC sources
-add_custom_command(
-    OUTPUT "${proto_srcs}" "${proto_hdrs}" "${grpc_srcs}" "${grpc_hdrs}"
-    COMMAND ${_PROTOBUF_PROTOC}
-    ARGS --grpc_out "${CMAKE_CURRENT_BINARY_DIR}"
-         --cpp_out "${CMAKE_CURRENT_BINARY_DIR}"
-         -I "${proto_path}"
-         --plugin=protoc-gen-grpc="${_GRPC_CPP_PLUGIN_EXECUTABLE}"
-         "${proto_file}"
-    DEPENDS "${proto_file}"
-)
-
-# Proto library
-add_library(proto_lib
-    ${proto_srcs}
-    ${proto_hdrs}
-    ${grpc_srcs}
-    ${grpc_hdrs}
+# Proto library: protoc generates proto/%[3]s.pb.{h,cc} and, with
+# grpc_cpp_plugin, proto/%[3]s.grpc.pb.{h,cc} in the build directory
+# whenever the .proto file changes
+add_library(${PROJECT_NAME}_proto STATIC
+    proto/%[3]s.proto
 )
 
-target_link_libraries(proto_lib PUBLIC
+target_link_libraries(${PROJECT_NAME}_proto PUBLIC
     protobuf::libprotobuf
     gRPC::grpc++
 )
 
-target_include_directories(proto_lib PUBLIC
+target_include_directories(${PROJECT_NAME}_proto PUBLIC
     ${CMAKE_CURRENT_BINARY_DIR}
 )
 
-# Server executable
-add_executable(${PROJECT_NAME}_server
+protobuf_generate(
+    TARGET ${PROJECT_NAME}_proto
+    LANGUAGE cpp
+    IMPORT_DIRS ${CMAKE_CURRENT_SOURCE_DIR}
+    PROTOC_OUT_DIR ${CMAKE_CURRENT_BINARY_DIR}
+)
+
+protobuf_generate(
+    TARGET ${PROJECT_NAME}_proto
+    LANGUAGE grpc
+    GENERATE_EXTENSIONS .grpc.pb.h .grpc.pb.cc
+    PLUGIN "protoc-gen-grpc=$<TARGET_FILE:gRPC::grpc_cpp_plugin>"
+    IMPORT_DIRS ${CMAKE_CURRENT_SOURCE_DIR}
+    PROTOC_OUT_DIR ${CMAKE_CURRENT_BINARY_DIR}
+)
+
+# Server executable (the main target)
+add_executable(${PROJECT_NAME}
     src/server_main.cpp
     src/service_impl.cpp
 )
 
-target_include_directories(${PROJECT_NAME}_server PRIVATE
+target_include_directories(${PROJECT_NAME} PRIVATE
     ${CMAKE_CURRENT_SOURCE_DIR}/include
-    ${CMAKE_CURRENT_BINARY_DIR}
 )
 
-target_link_libraries(${PROJECT_NAME}_server PRIVATE
-    proto_lib
+target_link_libraries(${PROJECT_NAME} PRIVATE
+    ${PROJECT_NAME}_proto
 )
 
 # Client executable
@@ -346,19 +363,179 @@ add_executable(${PROJECT_NAME}_client
     src/client_main.cpp
 )
 
-target_include_directories(${PROJECT_NAME}_client PRIVATE
-    ${CMAKE_CURRENT_SOURCE_DIR}/include
-    ${CMAKE_CURRENT_BINARY_DIR}
+target_link_libraries(${PROJECT_NAME}_client PRIVATE
+    ${PROJECT_NAME}_proto
 )
 
-target_link_libraries(${PROJECT_NAME}_client PRIVATE
-    proto_lib
+# Copy compile_commands.json to project root
+if(CMAKE_EXPORT_COMPILE_COMMANDS)
+    add_custom_target(copy_compile_commands ALL
+        ${CMAKE_COMMAND} -E copy_if_different
+        ${CMAKE_BINARY_DIR}/compile_commands.json
+        ${CMAKE_SOURCE_DIR}/compile_commands.json
+    )
+endif()
+`, projectName, cppStandard, naming.SafeIdent(projectName))
+}
+
+func (t *GRPCTemplate) generateMesonBuild(projectName string, cppStandard int) string {
+	return fmt.Sprintf(`project('%[1]s', 'cpp',
+  version : '0.1.0',
+  default_options : ['cpp_std=c++%[2]d'],
+)
+
+protobuf_dep = dependency('protobuf')
+grpcpp_dep = dependency('grpc++')
+
+# Generates the protobuf and gRPC sources; defines proto_dep
+subdir('proto')
+
+inc = include_directories('include')
+
+# Server executable (the main target)
+executable('%[1]s',
+  'src/server_main.cpp',
+  'src/service_impl.cpp',
+  include_directories : inc,
+  dependencies : proto_dep,
 )
-`, projectName, cppStandard, projectName, projectName, projectName, projectName, projectName)
+
+# Client executable
+executable('%[1]s_client',
+  'src/client_main.cpp',
+  dependencies : proto_dep,
+)
+`, projectName, cppStandard)
 }
 
-func (t *GRPCTemplate) generateReadme(projectName string) string {
-	return fmt.Sprintf(`# %s
+func (t *GRPCTemplate) generateProtoMesonBuild(projectName string) string {
+	return fmt.Sprintf(`protoc = find_program('protoc')
+grpc_cpp_plugin = find_program('grpc_cpp_plugin')
+
+# protoc runs with the project root as import path, so the generated files
+# land in proto/ of the build directory and are included as
+# "proto/%[1]s.grpc.pb.h", as with CMake and Bazel
+proto_gen = custom_target('%[1]s_proto_gen',
+  input : '%[1]s.proto',
+  output : ['%[1]s.pb.cc', '%[1]s.pb.h', '%[1]s.grpc.pb.cc', '%[1]s.grpc.pb.h'],
+  command : [
+    protoc,
+    '--proto_path=' + meson.project_source_root(),
+    '--cpp_out=' + meson.project_build_root(),
+    '--grpc_out=' + meson.project_build_root(),
+    '--plugin=protoc-gen-grpc=' + grpc_cpp_plugin.full_path(),
+    meson.current_source_dir() / '%[1]s.proto',
+  ],
+)
+
+# The project root of the build directory holds proto/
+proto_inc = include_directories('..')
+
+proto_lib = static_library('%[1]s_proto',
+  proto_gen,
+  include_directories : proto_inc,
+  dependencies : [protobuf_dep, grpcpp_dep],
+)
+
+# Depending on the generated headers makes them exist before the
+# sources that include them are compiled
+proto_dep = declare_dependency(
+  link_with : proto_lib,
+  sources : [proto_gen[1], proto_gen[3]],
+  include_directories : proto_inc,
+  dependencies : [protobuf_dep, grpcpp_dep],
+)
+`, naming.SafeIdent(projectName))
+}
+
+func (t *GRPCTemplate) generateModuleBazel(projectName string) string {
+	return fmt.Sprintf(`module(
+    name = "%s",
+    version = "0.1.0",
+)
+
+bazel_dep(name = "rules_cc", version = "0.1.1")
+bazel_dep(name = "protobuf", version = "29.3")
+bazel_dep(name = "grpc", version = "1.69.0")
+`, projectName)
+}
+
+func (t *GRPCTemplate) generateProtoBuildBazel(projectName string) string {
+	return fmt.Sprintf(`load("@grpc//bazel:cc_grpc_library.bzl", "cc_grpc_library")
+load("@protobuf//bazel:cc_proto_library.bzl", "cc_proto_library")
+load("@protobuf//bazel:proto_library.bzl", "proto_library")
+
+package(default_visibility = ["//visibility:public"])
+
+proto_library(
+    name = "%[1]s_proto",
+    srcs = ["%[1]s.proto"],
+)
+
+# proto/%[1]s.pb.h
+cc_proto_library(
+    name = "%[1]s_cc_proto",
+    deps = [":%[1]s_proto"],
+)
+
+# proto/%[1]s.grpc.pb.h, generated by grpc_cpp_plugin
+cc_grpc_library(
+    name = "%[1]s_cc_grpc",
+    srcs = [":%[1]s_proto"],
+    grpc_only = True,
+    deps = [":%[1]s_cc_proto"],
+)
+`, naming.SafeIdent(projectName))
+}
+
+func (t *GRPCTemplate) generateBuildBazel(projectName string) string {
+	return fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "cc_binary", "cc_library")
+
+cc_library(
+    name = "service",
+    srcs = ["src/service_impl.cpp"],
+    hdrs = ["include/%[1]s/service_impl.hpp"],
+    includes = ["include"],
+    deps = [
+        "//proto:%[2]s_cc_grpc",
+        "@grpc//:grpc++",
+    ],
+)
+
+# Server executable (the main target)
+cc_binary(
+    name = "%[1]s",
+    srcs = ["src/server_main.cpp"],
+    deps = [":service"],
+)
+
+# Client executable
+cc_binary(
+    name = "%[1]s_client",
+    srcs = ["src/client_main.cpp"],
+    deps = [
+        "//proto:%[2]s_cc_grpc",
+        "@grpc//:grpc++",
+    ],
+)
+`, projectName, naming.SafeIdent(projectName))
+}
+
+func (t *GRPCTemplate) generateReadme(projectName, packageManager string) string {
+	var deps string
+	switch packageManager {
+	case "bazel":
+		deps = "gRPC and Protocol Buffers come from the Bazel Central Registry (`MODULE.bazel`)."
+	case "meson":
+		deps = "gRPC and Protocol Buffers are found with pkg-config, and protoc and grpc_cpp_plugin on PATH:\n\n" +
+			"```bash\n" +
+			"sudo apt install libgrpc++-dev protobuf-compiler-grpc   # Debian/Ubuntu\n" +
+			"brew install grpc                                      # macOS\n" +
+			"```"
+	default:
+		deps = "gRPC and Protocol Buffers come from vcpkg (`vcpkg.json`), including protoc and grpc_cpp_plugin."
+	}
+	return fmt.Sprintf(`# %[1]s
 
 A gRPC service with Protocol Buffers.
 
@@ -368,31 +545,37 @@ A gRPC service with Protocol Buffers.
 cpx build
 `+"```"+`
 
+The build runs protoc with grpc_cpp_plugin on `+"`proto/%[2]s.proto`"+`, so the
+generated code is never checked in. Sources include it as
+`+"`\"proto/%[2]s.grpc.pb.h\"`"+`.
+
 ## Running
 
 Start the server:
 `+"```"+`bash
-./build/%s_server
+cpx run
 `+"```"+`
 
 In another terminal, run the client:
 `+"```"+`bash
-./build/%s_client [name]
+cpx run --target %[1]s_client [name]
 `+"```"+`
 
 ## Proto Definition
 
-The service is defined in `+"`proto/%s.proto`"+`:
+The service is defined in `+"`proto/%[2]s.proto`"+`:
 - `+"`SayHello`"+`: Unary RPC - sends a greeting
 - `+"`SayHelloStream`"+`: Server streaming RPC - sends multiple greetings
 
+Add messages and RPCs there and rebuild; new .proto files are added to the
+proto library in the build file.
+
 ## Dependencies
 
-- gRPC
-- Protocol Buffers
+%[3]s
 
 ## License
 
 MIT
-`, projectName, projectName, projectName, projectName)
+`, projectName, naming.SafeIdent(projectName), deps)
 }
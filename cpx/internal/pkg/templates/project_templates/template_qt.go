@@ -20,11 +20,11 @@ func (t *QtTemplate) Name() string {
 }
 
 func (t *QtTemplate) Description() string {
-	return "GUI application with Qt Widgets"
+	return "GUI application with Qt 6 Widgets"
 }
 
 func (t *QtTemplate) Dependencies() []string {
-	return []string{"qt5-base"}
+	return []string{"qtbase"}
 }
 
 func (t *QtTemplate) Generate(config TemplateConfig) error {
@@ -52,11 +52,14 @@ func (t *QtTemplate) Generate(config TemplateConfig) error {
 		return err
 	}
 
-	// Generate MainWindow source
+	// Generate MainWindow source and its Designer form (compiled by AUTOUIC)
 	mainWindowCpp := t.generateMainWindowSource(projectName)
 	if err := t.WriteFile(projectName, "src/mainwindow.cpp", mainWindowCpp); err != nil {
 		return err
 	}
+	if err := t.WriteFile(projectName, "src/mainwindow.ui", t.generateMainWindowUI()); err != nil {
+		return err
+	}
 
 	// Generate version header
 	versionHpp := templates.GenerateVersionHpp(projectName, "0.1.0")
@@ -82,11 +85,14 @@ func (t *QtTemplate) Generate(config TemplateConfig) error {
 		return err
 	}
 
-	// Generate README
-	readme := t.generateReadme(projectName, config.PackageManager)
+	// Generate README and deployment docs
+	readme := t.generateReadme(projectName)
 	if err := t.WriteFile(projectName, "README.md", readme); err != nil {
 		return err
 	}
+	if err := t.WriteFile(projectName, "docs/deploying.md", t.generateDeployingDoc(projectName)); err != nil {
+		return err
+	}
 
 	// Initialize git
 	_ = t.InitGitRepo(projectName)
@@ -104,10 +110,10 @@ func (t *QtTemplate) generateMainCpp(projectName string) string {
 
 int main(int argc, char *argv[]) {
     QApplication app(argc, argv);
-    
+
     MainWindow window;
     window.show();
-    
+
     return app.exec();
 }
 `, projectName, projectName)
@@ -115,112 +121,196 @@ int main(int argc, char *argv[]) {
 
 func (t *QtTemplate) generateMainWindowHeader(projectName string) string {
 	guardName := fmt.Sprintf("%s_MAINWINDOW_HPP", toUpperSnakeCase(projectName))
-	return fmt.Sprintf(`#ifndef %s
-#define %s
+	return fmt.Sprintf(`#ifndef %[1]s
+#define %[1]s
 
 #include <QMainWindow>
-#include <QLabel>
-#include <QPushButton>
-#include <QVBoxLayout>
+
+QT_BEGIN_NAMESPACE
+namespace Ui {
+class MainWindow;
+}
+QT_END_NAMESPACE
 
 class MainWindow : public QMainWindow {
     Q_OBJECT
 
 public:
     explicit MainWindow(QWidget *parent = nullptr);
-    ~MainWindow() override = default;
+    ~MainWindow() override;
 
 private slots:
     void onButtonClicked();
+    void onAbout();
 
 private:
-    QLabel *m_label;
-    QPushButton *m_button;
+    Ui::MainWindow *m_ui;
     int m_clickCount = 0;
 };
 
-#endif // %s
-`, guardName, guardName, guardName)
+#endif // %[1]s
+`, guardName)
 }
 
 func (t *QtTemplate) generateMainWindowSource(projectName string) string {
-	return fmt.Sprintf(`#include "%s/mainwindow.hpp"
-#include <QMenuBar>
-#include <QStatusBar>
+	return fmt.Sprintf(`#include "%[1]s/mainwindow.hpp"
+#include "ui_mainwindow.h"
+
 #include <QMessageBox>
 
 MainWindow::MainWindow(QWidget *parent)
-    : QMainWindow(parent)
+    : QMainWindow(parent), m_ui(new Ui::MainWindow)
 {
-    setWindowTitle("%s");
-    setMinimumSize(800, 600);
+    // The widgets are laid out in mainwindow.ui
+    m_ui->setupUi(this);
+    setWindowTitle("%[1]s");
+    m_ui->label->setText("Welcome to %[1]s!");
 
-    // Create menu bar
-    auto *fileMenu = menuBar()->addMenu("&File");
-    auto *exitAction = fileMenu->addAction("E&xit");
-    connect(exitAction, &QAction::triggered, this, &QMainWindow::close);
+    connect(m_ui->button, &QPushButton::clicked, this, &MainWindow::onButtonClicked);
+    connect(m_ui->actionExit, &QAction::triggered, this, &QMainWindow::close);
+    connect(m_ui->actionAbout, &QAction::triggered, this, &MainWindow::onAbout);
 
-    auto *helpMenu = menuBar()->addMenu("&Help");
-    auto *aboutAction = helpMenu->addAction("&About");
-    connect(aboutAction, &QAction::triggered, this, [this]() {
-        QMessageBox::about(this, "About %s",
-            "A Qt application created with cpx.");
-    });
-
-    // Create central widget
-    auto *centralWidget = new QWidget(this);
-    auto *layout = new QVBoxLayout(centralWidget);
-
-    m_label = new QLabel("Welcome to %s!", this);
-    m_label->setAlignment(Qt::AlignCenter);
-    layout->addWidget(m_label);
-
-    m_button = new QPushButton("Click me!", this);
-    connect(m_button, &QPushButton::clicked, this, &MainWindow::onButtonClicked);
-    layout->addWidget(m_button);
-
-    layout->addStretch();
-    setCentralWidget(centralWidget);
-
-    // Status bar
     statusBar()->showMessage("Ready");
 }
 
+MainWindow::~MainWindow() {
+    delete m_ui;
+}
+
 void MainWindow::onButtonClicked() {
     m_clickCount++;
-    m_label->setText(QString("Button clicked %%1 times!").arg(m_clickCount));
+    m_ui->label->setText(QString("Button clicked %%1 times!").arg(m_clickCount));
     statusBar()->showMessage(QString("Click count: %%1").arg(m_clickCount));
 }
-`, projectName, projectName, projectName, projectName)
+
+void MainWindow::onAbout() {
+    QMessageBox::about(this, "About %[1]s", "A Qt application created with cpx.");
+}
+`, projectName)
+}
+
+func (t *QtTemplate) generateMainWindowUI() string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<ui version="4.0">
+ <class>MainWindow</class>
+ <widget class="QMainWindow" name="MainWindow">
+  <property name="geometry">
+   <rect>
+    <x>0</x>
+    <y>0</y>
+    <width>800</width>
+    <height>600</height>
+   </rect>
+  </property>
+  <widget class="QWidget" name="centralwidget">
+   <layout class="QVBoxLayout" name="layout">
+    <item>
+     <widget class="QLabel" name="label">
+      <property name="alignment">
+       <set>Qt::AlignCenter</set>
+      </property>
+     </widget>
+    </item>
+    <item>
+     <widget class="QPushButton" name="button">
+      <property name="text">
+       <string>Click me!</string>
+      </property>
+     </widget>
+    </item>
+    <item>
+     <spacer name="spacer">
+      <property name="orientation">
+       <enum>Qt::Vertical</enum>
+      </property>
+     </spacer>
+    </item>
+   </layout>
+  </widget>
+  <widget class="QMenuBar" name="menubar">
+   <widget class="QMenu" name="menuFile">
+    <property name="title">
+     <string>&amp;File</string>
+    </property>
+    <addaction name="actionExit"/>
+   </widget>
+   <widget class="QMenu" name="menuHelp">
+    <property name="title">
+     <string>&amp;Help</string>
+    </property>
+    <addaction name="actionAbout"/>
+   </widget>
+   <addaction name="menuFile"/>
+   <addaction name="menuHelp"/>
+  </widget>
+  <widget class="QStatusBar" name="statusbar"/>
+  <action name="actionExit">
+   <property name="text">
+    <string>E&amp;xit</string>
+   </property>
+  </action>
+  <action name="actionAbout">
+   <property name="text">
+    <string>&amp;About</string>
+   </property>
+  </action>
+ </widget>
+ <resources/>
+ <connections/>
+</ui>
+`
 }
 
 func (t *QtTemplate) generateCMakeLists(projectName string, cppStandard int) string {
-	return fmt.Sprintf(`cmake_minimum_required(VERSION 3.16)
-project(%s VERSION 0.1.0 LANGUAGES CXX)
+	return fmt.Sprintf(`cmake_minimum_required(VERSION 3.21)
+project(%[1]s VERSION 0.1.0 LANGUAGES CXX)
 
-set(CMAKE_CXX_STANDARD %d)
+set(CMAKE_CXX_STANDARD %[2]d)
 set(CMAKE_CXX_STANDARD_REQUIRED ON)
+set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+
+# Find Qt 6 (qtbase from vcpkg)
+find_package(Qt6 6.3 REQUIRED COMPONENTS Widgets)
+
+# moc for Q_OBJECT classes, uic for .ui forms and rcc for .qrc resources
+qt_standard_project_setup()
 set(CMAKE_AUTOMOC ON)
-set(CMAKE_AUTORCC ON)
 set(CMAKE_AUTOUIC ON)
-set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+set(CMAKE_AUTORCC ON)
 
-# Find Qt5
-find_package(Qt5 COMPONENTS Widgets REQUIRED)
+option(APP_BUNDLE "Build a macOS app bundle (needed by macdeployqt)" OFF)
 
-# Add executable
-add_executable(${PROJECT_NAME}
+# Add executable; the header is listed so AUTOMOC finds its Q_OBJECT
+qt_add_executable(${PROJECT_NAME} WIN32
     src/main.cpp
     src/mainwindow.cpp
+    src/mainwindow.ui
+    include/%[1]s/mainwindow.hpp
 )
+if(APP_BUNDLE)
+    set_target_properties(${PROJECT_NAME} PROPERTIES MACOSX_BUNDLE ON)
+endif()
 
 target_include_directories(${PROJECT_NAME} PRIVATE
     ${CMAKE_CURRENT_SOURCE_DIR}/include
 )
 
 target_link_libraries(${PROJECT_NAME} PRIVATE
-    Qt5::Widgets
+    Qt6::Widgets
+)
+
+# Installing runs windeployqt (Windows) or macdeployqt (macOS app bundles),
+# which copy the Qt libraries and plugins next to the executable
+install(TARGETS ${PROJECT_NAME}
+    BUNDLE DESTINATION .
+    RUNTIME DESTINATION ${CMAKE_INSTALL_BINDIR}
+)
+qt_generate_deploy_app_script(
+    TARGET ${PROJECT_NAME}
+    OUTPUT_SCRIPT deploy_script
+    NO_UNSUPPORTED_PLATFORM_ERROR
 )
+install(SCRIPT ${deploy_script})
 
 # Copy compile_commands.json to project root
 if(CMAKE_EXPORT_COMPILE_COMMANDS)
@@ -233,7 +323,7 @@ endif()
 `, projectName, cppStandard)
 }
 
-func (t *QtTemplate) generateReadme(projectName, packageManager string) string {
+func (t *QtTemplate) generateReadme(projectName string) string {
 	return fmt.Sprintf(`# %s
 
 A Qt Widgets GUI application.
@@ -244,6 +334,8 @@ A Qt Widgets GUI application.
 cpx build
 `+"```"+`
 
+The first build compiles qtbase through vcpkg, which takes a while.
+
 ## Running
 
 `+"```"+`bash
@@ -252,14 +344,20 @@ cpx run
 
 ## Features
 
-- Qt5 Widgets application
+- Qt 6 Widgets application
+- Main window laid out in Qt Designer (`+"`src/mainwindow.ui`"+`, compiled by AUTOUIC)
 - Menu bar with File and Help menus
 - Status bar
 - Interactive button with click counter
 
+## Deploying
+
+See [docs/deploying.md](docs/deploying.md) for shipping the application with
+windeployqt and macdeployqt.
+
 ## Dependencies
 
-- Qt5 (Widgets)
+- Qt 6 (qtbase: Widgets)
 
 ## License
 
@@ -267,6 +365,63 @@ MIT
 `, projectName)
 }
 
+func (t *QtTemplate) generateDeployingDoc(projectName string) string {
+	return fmt.Sprintf(`# Deploying %[1]s
+
+A Qt application needs the Qt libraries and plugins (platform, styles, image
+formats) next to it on machines without Qt. windeployqt (Windows) and
+macdeployqt (macOS) copy them; on Linux, ship an AppImage or depend on the
+distribution's Qt packages.
+
+## 1. Bump the version
+
+`+"```"+`bash
+cpx release minor   # or major / patch; updates project(VERSION) in CMakeLists.txt
+`+"```"+`
+
+## 2. Build in release mode
+
+`+"```"+`bash
+cpx build --release
+`+"```"+`
+
+The executable is in `+"`.bin/native/release/`"+`.
+
+## 3. Deploy
+
+The install rules in CMakeLists.txt run Qt's deploy script, which calls
+windeployqt or macdeployqt for you:
+
+`+"```"+`bash
+cmake --install .cache/native/release --prefix dist
+`+"```"+`
+
+Or run the tools by hand on the build output.
+
+Windows:
+
+`+"```"+`bash
+windeployqt --release .bin/native/release/%[1]s.exe
+`+"```"+`
+
+macOS needs an app bundle. Turn it on for release builds in cpx.star:
+
+`+"```"+`python
+def build_args(ctx):
+    return ["-DAPP_BUNDLE=ON"] if ctx["release"] else []
+`+"```"+`
+
+and deploy the bundle from the build directory, optionally as a disk image:
+
+`+"```"+`bash
+macdeployqt .cache/native/release/%[1]s.app -dmg
+`+"```"+`
+
+With vcpkg's Qt, the tools are in
+`+"`vcpkg_installed/<triplet>/tools/Qt6/bin`"+`.
+`, projectName)
+}
+
 // Helper function to convert to UPPER_SNAKE_CASE
 func toUpperSnakeCase(s string) string {
 	result := ""
@@ -69,6 +69,11 @@ func (t *WASMTemplate) Generate(config TemplateConfig) error {
 		return err
 	}
 
+	// Generate cpx-ci.yaml with the wasm toolchain
+	if err := t.WriteFile(projectName, "cpx-ci.yaml", t.generateCpxCI()); err != nil {
+		return err
+	}
+
 	// Generate build script
 	buildScript := t.generateBuildScript(projectName)
 	if err := t.WriteFile(projectName, "build.sh", buildScript); err != nil {
@@ -91,7 +96,7 @@ func (t *WASMTemplate) Generate(config TemplateConfig) error {
 	_ = t.InitGitRepo(projectName)
 
 	t.PrintSuccess(projectName)
-	fmt.Println("  Note: This is a WebAssembly project. Use './build.sh' or 'emcmake cmake' to build.")
+	fmt.Println("  Note: This is a WebAssembly project. Use 'cpx build --toolchain wasm' to build into .bin/wasm.")
 	return nil
 }
 
@@ -490,14 +495,12 @@ if(EMSCRIPTEN)
         --bind
     )
     
-    # Copy output to web directory
+    # Copy the web page next to the generated .js/.wasm
     add_custom_command(TARGET ${PROJECT_NAME} POST_BUILD
         COMMAND ${CMAKE_COMMAND} -E copy
-            $<TARGET_FILE_DIR:${PROJECT_NAME}>/${PROJECT_NAME}.js
-            ${CMAKE_SOURCE_DIR}/web/${PROJECT_NAME}.js
-        COMMAND ${CMAKE_COMMAND} -E copy
-            $<TARGET_FILE_DIR:${PROJECT_NAME}>/${PROJECT_NAME}.wasm
-            ${CMAKE_SOURCE_DIR}/web/${PROJECT_NAME}.wasm
+            ${CMAKE_SOURCE_DIR}/web/index.html
+            ${CMAKE_SOURCE_DIR}/web/style.css
+            $<TARGET_FILE_DIR:${PROJECT_NAME}>
     )
 endif()
 `, projectName, cppStandard)
@@ -522,20 +525,32 @@ emcmake cmake .. -DCMAKE_BUILD_TYPE=Release
 cmake --build .
 
 echo ""
-echo "Build complete! Files in web/ directory:"
-ls -la ../web/
+echo "Build complete! Files in build/ directory:"
+ls -la *.html *.js *.wasm
 
 echo ""
 echo "To run locally:"
-echo "  cd web && python3 -m http.server 8080"
+echo "  python3 -m http.server 8080 -d build"
 echo "  Open http://localhost:8080"
 `, projectName, projectName)
 }
 
+func (t *WASMTemplate) generateCpxCI() string {
+	return `# cpx-ci.yaml - CI toolchain configuration
+# The wasm toolchain uses a local emcc, or the emscripten/emsdk image if
+# Emscripten is not installed. Artifacts are written to .bin/wasm.
+
+toolchains:
+  - name: wasm
+    type: wasm
+    build_type: Release
+`
+}
+
 func (t *WASMTemplate) generateGitignore() string {
 	return `build/
-web/*.js
-web/*.wasm
+.bin/
+.cache/
 *.o
 *.a
 .DS_Store
@@ -558,14 +573,15 @@ cd emsdk
 source ./emsdk_env.sh
 `+"```"+`
 
+Alternatively, cpx builds in the `+"`emscripten/emsdk`"+` Docker image when `+"`emcc`"+` is not installed.
+
 ## Building
 
 `+"```"+`bash
-chmod +x build.sh
-./build.sh
+cpx build --toolchain wasm
 `+"```"+`
 
-Or manually:
+The `+"`.wasm`"+`, `+"`.js`"+` and web page are written to `+"`.bin/wasm`"+`. Without cpx, use `+"`./build.sh`"+` or:
 `+"```"+`bash
 mkdir build && cd build
 emcmake cmake ..
@@ -576,8 +592,7 @@ cmake --build .
 
 Start a local web server:
 `+"```"+`bash
-cd web
-python3 -m http.server 8080
+python3 -m http.server 8080 -d .bin/wasm
 `+"```"+`
 
 Open http://localhost:8080 in your browser.
@@ -602,6 +617,7 @@ Open http://localhost:8080 in your browser.
 │   ├── index.html       # Demo page
 │   └── style.css        # Styles
 ├── CMakeLists.txt       # CMake config
+├── cpx-ci.yaml          # wasm toolchain
 ├── build.sh             # Build script
 └── README.md
 `+"```"+`
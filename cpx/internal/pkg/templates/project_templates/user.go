@@ -0,0 +1,222 @@
+package project_templates
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/naming"
+	"gopkg.in/yaml.v3"
+)
+
+// UserManifestFile is the optional manifest at the root of a user template.
+// It is not copied into generated projects.
+const UserManifestFile = "cpx-template.yaml"
+
+// UserManifest describes a user template.
+type UserManifest struct {
+	Description string `yaml:"description"`
+	// Dependencies are added with vcpkg when the template has no vcpkg.json
+	Dependencies []string `yaml:"dependencies"`
+	// Verbatim lists glob patterns (relative to the template root) of files
+	// copied without rendering, for sources that contain "{{"
+	Verbatim []string `yaml:"verbatim"`
+	// PostGenerate are shell commands run in the generated project
+	PostGenerate []string `yaml:"post_generate"`
+}
+
+// UserTemplateData is what placeholders in user templates can refer to.
+type UserTemplateData struct {
+	Name           string // Project name, as typed
+	SafeName       string // Project name as a C++ identifier
+	CppStandard    int
+	PackageManager string
+}
+
+// UserTemplate is a project template registered with "cpx template add": a
+// directory whose files, and file names, are Go templates rendered with
+// UserTemplateData.
+type UserTemplate struct {
+	BaseTemplateHelper
+	name     string
+	dir      string
+	manifest UserManifest
+}
+
+// LoadUserTemplate loads the user template in dir.
+func LoadUserTemplate(name, dir string) (*UserTemplate, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %w", name, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template '%s': %s is not a directory", name, dir)
+	}
+	t := &UserTemplate{name: name, dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, UserManifestFile))
+	if err == nil {
+		if err := yaml.Unmarshal(data, &t.manifest); err != nil {
+			return nil, fmt.Errorf("template '%s': failed to parse %s: %w", name, UserManifestFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RegisterUserTemplates loads and registers user templates (name to
+// directory), skipping names taken by built-in templates. It returns the
+// errors of templates that could not be loaded.
+func RegisterUserTemplates(dirs map[string]string) []error {
+	var errs []error
+	for _, name := range sortedKeys(dirs) {
+		if _, ok := GetTemplateByName(name); ok {
+			continue
+		}
+		t, err := LoadUserTemplate(name, dirs[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		RegisterTemplate(t)
+	}
+	return errs
+}
+
+func (t *UserTemplate) Name() string {
+	return t.name
+}
+
+func (t *UserTemplate) Description() string {
+	if t.manifest.Description != "" {
+		return t.manifest.Description
+	}
+	return "User template (" + t.dir + ")"
+}
+
+func (t *UserTemplate) Dependencies() []string {
+	return t.manifest.Dependencies
+}
+
+func (t *UserTemplate) PostGenerate() []string {
+	return t.manifest.PostGenerate
+}
+
+func (t *UserTemplate) Generate(config TemplateConfig) error {
+	projectName := config.ProjectName
+	data := UserTemplateData{
+		Name:           projectName,
+		SafeName:       naming.SafeIdent(projectName),
+		CppStandard:    config.CppStandard,
+		PackageManager: config.PackageManager,
+	}
+	if data.PackageManager == "" {
+		data.PackageManager = "vcpkg"
+	}
+
+	err := filepath.WalkDir(t.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(t.dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if rel == UserManifestFile {
+			return nil
+		}
+
+		target, err := render(filepath.ToSlash(rel), filepath.ToSlash(rel), data)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(projectName, filepath.FromSlash(target))
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !t.verbatim(rel) && utf8.Valid(content) {
+			rendered, err := render(filepath.ToSlash(rel), string(content), data)
+			if err != nil {
+				return fmt.Errorf("%w\n  hint: list files that contain \"{{\" under verbatim in %s", err, UserManifestFile)
+			}
+			content = []byte(rendered)
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(dest, content, info.Mode().Perm())
+	})
+	if err != nil {
+		return fmt.Errorf("template '%s': %w", t.name, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(projectName, "vcpkg.json")); os.IsNotExist(err) && len(t.Dependencies()) > 0 {
+		if err := t.SetupVcpkg(projectName, t.Dependencies()); err != nil {
+			return fmt.Errorf("failed to setup vcpkg: %w", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(projectName, ".git")); os.IsNotExist(err) {
+		_ = t.InitGitRepo(projectName)
+	}
+
+	t.PrintSuccess(projectName)
+	return nil
+}
+
+// verbatim reports whether the file at rel is copied without rendering.
+func (t *UserTemplate) verbatim(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range t.manifest.Verbatim {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// render executes text as a template named name.
+func render(name, text string, data UserTemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
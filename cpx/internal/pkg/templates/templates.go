@@ -59,6 +59,30 @@ int main() {
 `, projectName, projectName, safeName)
 }
 
+// GeneratePCHHeader generates src/pch/pch.hpp, the precompiled header:
+// standard library headers that most sources include. Project headers that
+// change often do not belong in it, since any change rebuilds everything.
+func GeneratePCHHeader() string {
+	return `// Precompiled header: included in every source file of the project
+// before it is compiled. Add headers that are used widely and rarely change.
+#pragma once
+
+#include <algorithm>
+#include <cstddef>
+#include <cstdint>
+#include <functional>
+#include <iostream>
+#include <map>
+#include <memory>
+#include <optional>
+#include <string>
+#include <string_view>
+#include <unordered_map>
+#include <utility>
+#include <vector>
+`
+}
+
 func GenerateLibHeader(projectName string) string {
 	safeName := naming.SafeIdent(projectName)
 	guard := naming.SafeIdentUpper(projectName) + "_HPP"
@@ -172,7 +196,7 @@ int main() {
 // CMAKE TEMPLATES
 // ============================================================================
 
-func GenerateVcpkgCMakeLists(projectName string, cppStandard int, isExe bool, includeTests bool, benchmarkFramework string, includeBench bool, projectVersion string) string {
+func GenerateVcpkgCMakeLists(projectName string, cppStandard int, isExe bool, includeTests bool, benchmarkFramework string, includeBench bool, projectVersion string, sharedLibrary bool, pch bool) string {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf(`cmake_minimum_required(VERSION 3.20)
@@ -214,18 +238,44 @@ target_include_directories(%s
 
 `, projectName, projectName, projectName))
 	} else {
-		sb.WriteString(fmt.Sprintf(`# Library (static by default)
-add_library(%s STATIC
-    src/%s.cpp
+		sharedDefault := "OFF"
+		if sharedLibrary {
+			sharedDefault = "ON"
+		}
+		sb.WriteString(fmt.Sprintf(`# Library (static or shared, see BUILD_SHARED_LIBS; cpx build --shared/--static)
+option(BUILD_SHARED_LIBS "Build shared libraries" %[2]s)
+
+add_library(%[1]s
+    src/%[1]s.cpp
 )
+add_library(%[1]s::%[1]s ALIAS %[1]s)
 
-target_include_directories(%s
+# Shared library version, and exported symbols for Windows DLLs
+set_target_properties(%[1]s PROPERTIES
+    VERSION ${PROJECT_VERSION}
+    SOVERSION ${PROJECT_VERSION_MAJOR}
+    WINDOWS_EXPORT_ALL_SYMBOLS ON
+)
+
+target_include_directories(%[1]s
     PUBLIC
         $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>
         $<INSTALL_INTERFACE:include>
 )
 
-`, projectName, projectName, projectName))
+`, projectName, sharedDefault))
+	}
+	if pch {
+		sb.WriteString(fmt.Sprintf(`# Precompiled header (cpx-ci.yaml "pch: false" or -DENABLE_PCH=OFF to disable)
+option(ENABLE_PCH "Use precompiled headers" ON)
+if(ENABLE_PCH)
+    target_precompile_headers(%s PRIVATE src/pch/pch.hpp)
+endif()
+
+`, projectName))
+	}
+	if !isExe {
+		sb.WriteString(GenerateCMakeInstallRules(projectName))
 	}
 
 	if includeTests {
@@ -249,22 +299,310 @@ endif()
 	return sb.String()
 }
 
-// generateCMakePresets generates CMakePresets.json
+// GenerateCMakeInstallRules generates the install() rules that export a
+// library as a relocatable CMake package, so consumers can use
+// find_package(<name> CONFIG) and link <name>::<name>. "cpx package"
+// archives the result of installing them.
+func GenerateCMakeInstallRules(projectName string) string {
+	return fmt.Sprintf(`# Install rules and exported CMake package (used by cpx package)
+include(GNUInstallDirs)
+include(CMakePackageConfigHelpers)
+
+install(TARGETS %[1]s
+    EXPORT %[1]sTargets
+    ARCHIVE DESTINATION ${CMAKE_INSTALL_LIBDIR}
+    LIBRARY DESTINATION ${CMAKE_INSTALL_LIBDIR}
+    RUNTIME DESTINATION ${CMAKE_INSTALL_BINDIR}
+    INCLUDES DESTINATION ${CMAKE_INSTALL_INCLUDEDIR}
+)
+install(DIRECTORY include/ DESTINATION ${CMAKE_INSTALL_INCLUDEDIR})
+install(EXPORT %[1]sTargets
+    FILE %[1]sTargets.cmake
+    NAMESPACE %[1]s::
+    DESTINATION ${CMAKE_INSTALL_LIBDIR}/cmake/%[1]s
+)
+
+configure_package_config_file(cmake/%[1]sConfig.cmake.in
+    ${CMAKE_CURRENT_BINARY_DIR}/%[1]sConfig.cmake
+    INSTALL_DESTINATION ${CMAKE_INSTALL_LIBDIR}/cmake/%[1]s
+)
+write_basic_package_version_file(${CMAKE_CURRENT_BINARY_DIR}/%[1]sConfigVersion.cmake
+    VERSION ${PROJECT_VERSION}
+    COMPATIBILITY SameMajorVersion
+)
+install(FILES
+    ${CMAKE_CURRENT_BINARY_DIR}/%[1]sConfig.cmake
+    ${CMAKE_CURRENT_BINARY_DIR}/%[1]sConfigVersion.cmake
+    DESTINATION ${CMAKE_INSTALL_LIBDIR}/cmake/%[1]s
+)
+
+# pkg-config file for non-CMake consumers
+configure_file(cmake/%[1]s.pc.in ${CMAKE_CURRENT_BINARY_DIR}/%[1]s.pc @ONLY)
+install(FILES ${CMAKE_CURRENT_BINARY_DIR}/%[1]s.pc
+    DESTINATION ${CMAKE_INSTALL_LIBDIR}/pkgconfig
+)
+
+`, projectName)
+}
+
+// GeneratePkgConfig generates cmake/<name>.pc.in, the template CMake turns
+// into the installed <name>.pc. Paths are relative to the .pc file so the
+// installed tree stays relocatable; requires lists the pkg-config modules of
+// the library's dependencies.
+func GeneratePkgConfig(projectName string, requires []string) string {
+	return fmt.Sprintf(`prefix=${pcfiledir}/../..
+exec_prefix=${prefix}
+libdir=${prefix}/@CMAKE_INSTALL_LIBDIR@
+includedir=${prefix}/@CMAKE_INSTALL_INCLUDEDIR@
+
+Name: %[1]s
+Description: %[1]s library
+Version: @PROJECT_VERSION@
+Requires: %[2]s
+Libs: -L${libdir} -l%[1]s
+Cflags: -I${includedir}
+`, projectName, strings.Join(requires, ", "))
+}
+
+// GeneratePackageConfig generates cmake/<name>Config.cmake.in, the template
+// of the config file find_package loads from an installed library.
+func GeneratePackageConfig(projectName string) string {
+	return fmt.Sprintf(`@PACKAGE_INIT@
+
+include(CMakeFindDependencyMacro)
+# Add find_dependency() calls for the library's public dependencies here
+
+include("${CMAKE_CURRENT_LIST_DIR}/%[1]sTargets.cmake")
+check_required_components(%[1]s)
+`, projectName)
+}
+
+// GenerateVcpkgPortfile generates the portfile.cmake of a vcpkg port that
+// builds a cpx library from the source archive at sourceURL. licenseFile is
+// the license file in the source tree, or "" if the project has none.
+func GenerateVcpkgPortfile(projectName, sourceURL, sha512, licenseFile string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`vcpkg_download_distfile(ARCHIVE
+    URLS "%[2]s"
+    FILENAME "%[1]s-${VERSION}.tar.gz"
+    SHA512 %[3]s
+)
+vcpkg_extract_source_archive(SOURCE_PATH ARCHIVE "${ARCHIVE}")
+
+vcpkg_cmake_configure(
+    SOURCE_PATH "${SOURCE_PATH}"
+    OPTIONS
+        -DENABLE_TESTING=OFF
+        -DENABLE_BENCHMARKS=OFF
+)
+vcpkg_cmake_install()
+vcpkg_cmake_config_fixup(CONFIG_PATH lib/cmake/%[1]s)
+vcpkg_fixup_pkgconfig()
+
+file(REMOVE_RECURSE "${CURRENT_PACKAGES_DIR}/debug/include")
+`, projectName, sourceURL, sha512))
+
+	if licenseFile != "" {
+		sb.WriteString(fmt.Sprintf("vcpkg_install_copyright(FILE_LIST \"${SOURCE_PATH}/%s\")\n", licenseFile))
+	} else {
+		sb.WriteString(`file(WRITE "${CURRENT_PACKAGES_DIR}/share/${PORT}/copyright" "No license file was provided.\n")
+`)
+	}
+	return sb.String()
+}
+
+// ConanRecipe describes the library a Conan recipe is generated for.
+type ConanRecipe struct {
+	Name        string
+	Version     string
+	Description string
+	License     string
+	CppStandard int
+	// Requires are Conan references, e.g. "fmt/[*]".
+	Requires []string
+}
+
+// GenerateConanfile generates the conanfile.py that builds and packages a
+// cpx library with its CMake install() rules.
+func GenerateConanfile(r ConanRecipe) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`from conan import ConanFile
+from conan.tools.cmake import CMake, CMakeDeps, CMakeToolchain, cmake_layout
+
+
+class %sConan(ConanFile):
+    name = "%s"
+    version = "%s"
+    description = "%s"
+`, naming.SafeIdentTitle(r.Name), r.Name, r.Version, r.Description))
+	if r.License != "" {
+		sb.WriteString(fmt.Sprintf("    license = \"%s\"\n", r.License))
+	}
+	sb.WriteString(`    package_type = "library"
+    settings = "os", "compiler", "build_type", "arch"
+    options = {"shared": [True, False], "fPIC": [True, False]}
+    default_options = {"shared": False, "fPIC": True}
+    exports_sources = "CMakeLists.txt", "cmake/*", "include/*", "src/*", "LICENSE*"
+`)
+	if len(r.Requires) > 0 {
+		sb.WriteString("\n    def requirements(self):\n")
+		for _, req := range r.Requires {
+			sb.WriteString(fmt.Sprintf("        self.requires(\"%s\")\n", req))
+		}
+	}
+	sb.WriteString(fmt.Sprintf(`
+    def config_options(self):
+        if self.settings.os == "Windows":
+            del self.options.fPIC
+
+    def configure(self):
+        if self.options.shared:
+            self.options.rm_safe("fPIC")
+
+    def layout(self):
+        cmake_layout(self)
+
+    def generate(self):
+        CMakeDeps(self).generate()
+        tc = CMakeToolchain(self)
+        tc.cache_variables["ENABLE_TESTING"] = False
+        tc.cache_variables["ENABLE_BENCHMARKS"] = False
+        tc.generate()
+
+    def build(self):
+        cmake = CMake(self)
+        cmake.configure()
+        cmake.build()
+
+    def package(self):
+        CMake(self).install()
+
+    def package_info(self):
+        self.cpp_info.libs = ["%[1]s"]
+        self.cpp_info.set_property("cmake_file_name", "%[1]s")
+        self.cpp_info.set_property("cmake_target_name", "%[1]s::%[1]s")
+        self.cpp_info.set_property("pkg_config_name", "%[1]s")
+`, r.Name))
+	return sb.String()
+}
+
+// GenerateConanTestPackage generates the files of the recipe's test_package,
+// which Conan builds against the created package, keyed by path relative to
+// test_package/.
+func GenerateConanTestPackage(r ConanRecipe) map[string]string {
+	return map[string]string{
+		"conanfile.py": `import os
+
+from conan import ConanFile
+from conan.tools.build import can_run
+from conan.tools.cmake import CMake, cmake_layout
+
+
+class TestPackageConan(ConanFile):
+    settings = "os", "compiler", "build_type", "arch"
+    generators = "CMakeDeps", "CMakeToolchain"
+
+    def requirements(self):
+        self.requires(self.tested_reference_str)
+
+    def layout(self):
+        cmake_layout(self)
+
+    def build(self):
+        cmake = CMake(self)
+        cmake.configure()
+        cmake.build()
+
+    def test(self):
+        if can_run(self):
+            self.run(os.path.join(self.cpp.build.bindir, "test_package"), env="conanrun")
+`,
+		"CMakeLists.txt": fmt.Sprintf(`cmake_minimum_required(VERSION 3.20)
+project(test_package LANGUAGES CXX)
+
+find_package(%[1]s CONFIG REQUIRED)
+
+add_executable(test_package test_package.cpp)
+target_link_libraries(test_package PRIVATE %[1]s::%[1]s)
+target_compile_features(test_package PRIVATE cxx_std_%[2]d)
+`, r.Name, r.CppStandard),
+		"test_package.cpp": fmt.Sprintf(`#include <%[1]s/%[1]s.hpp>
+
+int main() {
+    %[2]s::greet();
+    return 0;
+}
+`, r.Name, naming.SafeIdent(r.Name)),
+	}
+}
+
+// generateCMakePresets generates CMakePresets.json: the default preset cpx
+// configures with, and debug, release and asan presets for cpx build
+// --preset and IDEs.
 // Assumes VCPKG_ROOT environment variable is set
 func GenerateCMakePresets() string {
 	return `{
   "version": 2,
   "configurePresets": [
     {
-      "name": "default",
+      "name": "vcpkg",
+      "hidden": true,
       "generator": "Ninja",
-      "binaryDir": "${sourceDir}/build",
+      "binaryDir": "${sourceDir}/build/${presetName}",
       "environment": {
         "VCPKG_DISABLE_REGISTRY_UPDATE": "1"
       },
       "cacheVariables": {
         "CMAKE_TOOLCHAIN_FILE": "$env{VCPKG_ROOT}/scripts/buildsystems/vcpkg.cmake"
       }
+    },
+    {
+      "name": "default",
+      "displayName": "Default",
+      "description": "Configuration cpx build uses; cpx sets the build type",
+      "inherits": "vcpkg"
+    },
+    {
+      "name": "debug",
+      "displayName": "Debug",
+      "inherits": "vcpkg",
+      "cacheVariables": {
+        "CMAKE_BUILD_TYPE": "Debug"
+      }
+    },
+    {
+      "name": "release",
+      "displayName": "Release",
+      "inherits": "vcpkg",
+      "cacheVariables": {
+        "CMAKE_BUILD_TYPE": "Release"
+      }
+    },
+    {
+      "name": "asan",
+      "displayName": "AddressSanitizer",
+      "description": "Debug build with AddressSanitizer",
+      "inherits": "debug",
+      "cacheVariables": {
+        "CMAKE_CXX_FLAGS": "-fsanitize=address -fno-omit-frame-pointer",
+        "CMAKE_C_FLAGS": "-fsanitize=address -fno-omit-frame-pointer",
+        "CMAKE_EXE_LINKER_FLAGS": "-fsanitize=address",
+        "CMAKE_SHARED_LINKER_FLAGS": "-fsanitize=address"
+      }
+    }
+  ],
+  "buildPresets": [
+    {
+      "name": "debug",
+      "configurePreset": "debug"
+    },
+    {
+      "name": "release",
+      "configurePreset": "release"
+    },
+    {
+      "name": "asan",
+      "configurePreset": "asan"
     }
   ]
 }
@@ -480,6 +818,9 @@ test_results/
 
 # Local Cache
 .cache/
+
+# Local environment (cpx run, test and bench load it after .env)
+.env.local
 `
 }
 
@@ -520,81 +861,60 @@ IndentCaseLabels: true
 
 // GenerateCpxCI generates a cpx-ci.yaml file with empty toolchains
 func GenerateCpxCI() string {
-	return `# cpx-ci.yaml - Toolchain configuration
-# This file defines toolchains for building your project
+	return `# yaml-language-server: $schema=https://raw.githubusercontent.com/ozacod/cpx/main/schema/cpx-ci.schema.json
+# cpx-ci.yaml - Toolchain configuration
+# runners: execution environments (docker/ssh) with optional compiler settings
+# toolchains: named build configurations, built with cpx build --toolchain <name>
+# Check this file with: cpx ci validate
+
+runners:
+  # Example: a pre-built image
+  # - name: "alpine"
+  #   type: "docker"
+  #   image: "alpine:latest"
+  #   platform: "linux/amd64"
+
+  # Example: an image built from a Dockerfile (rebuilt when it changes)
+  # - name: "gcc13"
+  #   type: "docker"
+  #   dockerfile: "Dockerfile"
+  #   build_args:
+  #     GCC_VER: "13"
+  #   cc: "gcc-13"
+  #   cxx: "g++-13"
+
+  # Example: a remote machine
+  # - name: "arm-box"
+  #   type: "ssh"
+  #   host: "arm-box.local"
+  #   user: "ci"
 
 toolchains:
-  # Example: Pull a pre-built image from registry
-  # - name: "alpine-gcc"
-  #   active: true                 # Set to false to skip this toolchain (default: true)
-  #   runner: "docker"
-  #   docker:
-  #     mode: "pull"              # pull, local, or build
-  #     image: "alpine:latest"
-  #     platform: "linux/amd64"
-  #     pullPolicy: "ifNotPresent" # always, never, ifNotPresent
-  #   # Per-toolchain build configuration (overrides global defaults)
+  # Example: a release build in the alpine runner
+  # - name: "alpine-release"
+  #   runner: "alpine"
+  #   active: true                # false skips this toolchain (default: true)
   #   build_type: "Release"       # Debug, Release, RelWithDebInfo, MinSizeRel
-  #   cmake_options: []           # Additional CMake arguments
-  #   build_options: []           # Additional build arguments (cmake --build args)
-  #   env:                        # Environment variables
+  #   optimization: "2"           # 0, 1, 2, 3, s, fast
+  #   cmake_options: []           # additional CMake arguments
+  #   build_options: []           # additional cmake --build arguments
+  #   jobs: 0                     # parallel jobs (0 = auto)
+  #   env:
   #     CC: "gcc"
   #     CXX: "g++"
 
-  # Example: Use a local image (no network)
-  # - name: "my-toolchain"
-  #   runner: "docker"
-  #   docker:
-  #     mode: "local"
-  #     image: "my-toolchain:latest"
-  #   build_type: "Debug"
-
-  # Example: Build from Dockerfile (with content-based caching)
-  # - name: "custom-toolchain"
-  #   runner: "docker"
-  #   docker:
-  #     mode: "build"
-  #     image: "cpx-dev"          # tag for the built image
-  #     platform: "linux/arm64"
-  #     build:
-  #       context: "."
-  #       dockerfile: "Dockerfile"
-  #       args:
-  #         GCC_VER: "13"
-  #   build_type: "RelWithDebInfo"
-  #   cmake_options:
-  #     - "-DENABLE_TESTS=ON"
+  # Example: one job per build type and sanitizer
+  # - name: "gcc13"
+  #   runner: "gcc13"
+  #   matrix:
+  #     build_types: ["Debug", "Release"]
+  #     sanitizers: ["none", "asan,ubsan"]
 
-  # Example: Native build (runs on host, CMake only)
+  # Example: a native build on the host
   # - name: "local-debug"
-  #   runner: "native"
   #   build_type: "Debug"
   #   cmake_options:
   #     - "-DCMAKE_EXPORT_COMPILE_COMMANDS=ON"
-  #   env:
-  #     CC: "clang"
-  #     CXX: "clang++"
-
-# Global build configuration (used as defaults if not specified per-toolchain)
-build:
-  # CMake build type (Debug, Release, RelWithDebInfo, MinSizeRel)
-  # Note: Per-toolchain build_type overrides this
-  type: Release
-
-  # Optimization level (0, 1, 2, 3, s, fast)
-  optimization: 2
-
-  # Number of parallel jobs (0 = auto)
-  jobs: 0
-
-  # Additional CMake arguments (per-toolchain cmake_options overrides this)
-  cmake_args: []
-
-  # Additional build arguments (per-toolchain build_options overrides this)
-  build_args: []
-
-# Output directory for artifacts
-output: .bin/ci
 `
 }
 
@@ -769,7 +1089,39 @@ alias(
 }
 
 // GenerateBuildBazelSrc generates src/BUILD.bazel
-func GenerateBuildBazelSrc(projectName string, isExe bool) string {
+func GenerateBuildBazelSrc(projectName string, isExe bool, sharedLibrary bool, pch bool) string {
+	src := generateBuildBazelSrc(projectName, isExe, sharedLibrary)
+	if !pch {
+		return src
+	}
+	// Bazel has no precompiled headers; the nearest pattern is a cc_library
+	// holding the header, force-included into the project's sources
+	load, rest, _ := strings.Cut(src, "\n")
+	deps := fmt.Sprintf(`    deps = ["//include:%s_headers"],
+`, projectName)
+	rest = strings.Replace(rest, deps, fmt.Sprintf(`    deps = ["//include:%s_headers", ":pch"],
+    copts = select({
+        ":pch_off": [],
+        "//conditions:default": ["-include", "src/pch/pch.hpp"],
+    }),
+`, projectName), 1)
+	return load + `
+
+# Precompiled header. Bazel cannot precompile headers, so the header is
+# force-included instead; build with --define=pch=off to leave it out.
+config_setting(
+    name = "pch_off",
+    define_values = {"pch": "off"},
+)
+
+cc_library(
+    name = "pch",
+    hdrs = ["pch/pch.hpp"],
+)
+` + rest
+}
+
+func generateBuildBazelSrc(projectName string, isExe bool, sharedLibrary bool) string {
 	if isExe {
 		return fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "cc_binary", "cc_library")
 
@@ -789,6 +1141,25 @@ cc_binary(
     visibility = ["//visibility:public"],
 )
 `, projectName, projectName, projectName, projectName, projectName)
+	}
+	if sharedLibrary {
+		return fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "cc_library", "cc_shared_library")
+
+# Core library
+cc_library(
+    name = "%[1]s",
+    srcs = ["%[1]s.cpp"],
+    deps = ["//include:%[1]s_headers"],
+    visibility = ["//visibility:public"],
+)
+
+# Shared library for consumers outside Bazel
+cc_shared_library(
+    name = "%[1]s_shared",
+    deps = [":%[1]s"],
+    visibility = ["//visibility:public"],
+)
+`, projectName)
 	}
 	return fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "cc_library")
 
@@ -1098,10 +1469,15 @@ MIT
 // ============================================================================
 
 // GenerateMesonBuildRoot generates root meson.build
-func GenerateMesonBuildRoot(projectName string, isExe bool, cppStandard int, testFramework, benchmarkFramework string) string {
+func GenerateMesonBuildRoot(projectName string, isExe bool, cppStandard int, testFramework, benchmarkFramework string, sharedLibrary bool) string {
 	targetType := "executable"
+	defaultLibrary := "static"
 	if !isExe {
 		targetType = "library"
+		if sharedLibrary {
+			targetType = "shared library"
+			defaultLibrary = "shared"
+		}
 	}
 
 	// Build subdir includes
@@ -1118,7 +1494,8 @@ func GenerateMesonBuildRoot(projectName string, isExe bool, cppStandard int, tes
   default_options : [
     'cpp_std=c++%d',
     'warning_level=3',
-    'buildtype=debugoptimized'
+    'buildtype=debugoptimized',
+    'default_library=%s'
   ]
 )
 
@@ -1127,7 +1504,7 @@ inc_dirs = include_directories('include')
 
 # Subdirectories
 %s
-`, projectName, cppStandard, subdirs) + fmt.Sprintf(`
+`, projectName, cppStandard, defaultLibrary, subdirs) + fmt.Sprintf(`
 # Summary
 summary({
   'Project': '%s',
@@ -1138,7 +1515,17 @@ summary({
 }
 
 // GenerateMesonBuildSrc generates src/meson.build
-func GenerateMesonBuildSrc(projectName string, isExe bool) string {
+func GenerateMesonBuildSrc(projectName string, isExe bool, pch bool) string {
+	src := generateMesonBuildSrc(projectName, isExe)
+	if !pch {
+		return src
+	}
+	// Turned off with the built-in b_pch option
+	return strings.ReplaceAll(src, "  include_directories : inc_dirs,\n",
+		"  include_directories : inc_dirs,\n  cpp_pch : 'pch/pch.hpp',\n")
+}
+
+func generateMesonBuildSrc(projectName string, isExe bool) string {
 	safeName := naming.SafeIdent(projectName)
 
 	if isExe {
@@ -1164,19 +1551,28 @@ src_files = files(
 `, projectName, safeName, safeName, projectName, safeName, projectName)
 	}
 
-	// Library only (static by default)
+	// Library only, of the type set by default_library
 	return fmt.Sprintf(`# Source files
 src_files = files(
   '%s.cpp'
 )
 
-# Library (static by default)
-%s_lib = static_library('%s',
+# Library (static or shared, see default_library; cpx build --shared/--static)
+%[2]s_lib = library('%[1]s',
   src_files,
   include_directories : inc_dirs,
+  version : meson.project_version(),
   install : true
 )
-`, projectName, safeName, projectName)
+
+# Headers and pkg-config file for consumers; Requires is derived from the
+# library's dependencies
+install_subdir('../include/%[1]s', install_dir : get_option('includedir'))
+pkg = import('pkgconfig')
+pkg.generate(%[2]s_lib,
+  description : '%[1]s library',
+)
+`, projectName, safeName)
 }
 
 // GenerateMesonBuildTests generates tests/meson.build
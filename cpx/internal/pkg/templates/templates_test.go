@@ -1,10 +1,16 @@
 package templates
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerateModuleBazel(t *testing.T) {
@@ -377,7 +383,7 @@ func TestGenerateMesonBuildRoot(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GenerateMesonBuildRoot(tt.projectName, tt.isExe, tt.cppStandard, tt.testFramework, tt.benchmarkFramework)
+			result := GenerateMesonBuildRoot(tt.projectName, tt.isExe, tt.cppStandard, tt.testFramework, tt.benchmarkFramework, false)
 
 			for _, s := range tt.shouldContain {
 				assert.Contains(t, result, s, "Expected to contain: %s", s)
@@ -414,13 +420,15 @@ func TestGenerateMesonBuildSrc(t *testing.T) {
 			shouldContain: []string{
 				"library('mylib'",
 				"mylib.cpp",
+				"import('pkgconfig')",
+				"pkg.generate(mylib_lib",
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GenerateMesonBuildSrc(tt.projectName, tt.isExe)
+			result := GenerateMesonBuildSrc(tt.projectName, tt.isExe, false)
 
 			for _, s := range tt.shouldContain {
 				assert.Contains(t, result, s, "Expected to contain: %s", s)
@@ -623,27 +631,192 @@ func TestGenerateVcpkgCMakeLists(t *testing.T) {
 				"project(mylib",
 				"CMAKE_CXX_STANDARD 20",
 				"add_library",
+				"add_library(mylib::mylib ALIAS mylib)",
+				"install(TARGETS mylib",
+				"NAMESPACE mylib::",
+				"configure_package_config_file(cmake/mylibConfig.cmake.in",
+				"mylibConfigVersion.cmake",
+				"configure_file(cmake/mylib.pc.in",
+				"${CMAKE_INSTALL_LIBDIR}/pkgconfig",
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GenerateVcpkgCMakeLists(tt.projectName, tt.cppStandard, tt.isExe, tt.includeTests, "", false, "0.1.0")
+			result := GenerateVcpkgCMakeLists(tt.projectName, tt.cppStandard, tt.isExe, tt.includeTests, "", false, "0.1.0", false, false)
 
 			for _, s := range tt.shouldContain {
 				assert.Contains(t, result, s, "Expected to contain: %s", s)
 			}
+			if tt.isExe {
+				assert.NotContains(t, result, "install(", "executables are not packaged")
+			}
 		})
 	}
 }
 
+func TestGenerateSharedLibrary(t *testing.T) {
+	cmake := GenerateVcpkgCMakeLists("mylib", 17, false, false, "", false, "1.0.0", true, false)
+	assert.Contains(t, cmake, `option(BUILD_SHARED_LIBS "Build shared libraries" ON)`)
+	assert.Contains(t, cmake, "add_library(mylib\n")
+	assert.Contains(t, cmake, "SOVERSION ${PROJECT_VERSION_MAJOR}")
+	assert.NotContains(t, cmake, "STATIC")
+
+	cmake = GenerateVcpkgCMakeLists("mylib", 17, false, false, "", false, "1.0.0", false, false)
+	assert.Contains(t, cmake, `option(BUILD_SHARED_LIBS "Build shared libraries" OFF)`)
+
+	// executables have no library to choose a type for
+	cmake = GenerateVcpkgCMakeLists("myapp", 17, true, false, "", false, "1.0.0", true, false)
+	assert.NotContains(t, cmake, "BUILD_SHARED_LIBS")
+
+	meson := GenerateMesonBuildRoot("mylib", false, 17, "", "", true)
+	assert.Contains(t, meson, "'default_library=shared'")
+	meson = GenerateMesonBuildRoot("mylib", false, 17, "", "", false)
+	assert.Contains(t, meson, "'default_library=static'")
+
+	bazel := GenerateBuildBazelSrc("mylib", false, true, false)
+	assert.Contains(t, bazel, "cc_shared_library(")
+	assert.Contains(t, bazel, `deps = [":mylib"]`)
+	assert.NotContains(t, GenerateBuildBazelSrc("mylib", false, false, false), "cc_shared_library")
+}
+
+func TestGeneratePCH(t *testing.T) {
+	assert.Contains(t, GeneratePCHHeader(), "#pragma once")
+
+	cmake := GenerateVcpkgCMakeLists("mylib", 17, false, false, "", false, "1.0.0", false, true)
+	assert.Contains(t, cmake, `option(ENABLE_PCH "Use precompiled headers" ON)`)
+	assert.Contains(t, cmake, "target_precompile_headers(mylib PRIVATE src/pch/pch.hpp)")
+	cmake = GenerateVcpkgCMakeLists("myapp", 17, true, false, "", false, "1.0.0", false, true)
+	assert.Contains(t, cmake, "target_precompile_headers(myapp PRIVATE src/pch/pch.hpp)")
+	assert.NotContains(t, GenerateVcpkgCMakeLists("mylib", 17, false, false, "", false, "1.0.0", false, false), "target_precompile_headers")
+
+	meson := GenerateMesonBuildSrc("myapp", true, true)
+	assert.Equal(t, 2, strings.Count(meson, "cpp_pch : 'pch/pch.hpp'"), "library and executable use the PCH")
+	assert.NotContains(t, GenerateMesonBuildSrc("mylib", false, false), "cpp_pch")
+
+	bazel := GenerateBuildBazelSrc("mylib", false, false, true)
+	assert.True(t, strings.HasPrefix(bazel, `load("@rules_cc//cc:defs.bzl", "cc_library")`))
+	assert.Contains(t, bazel, `hdrs = ["pch/pch.hpp"]`)
+	assert.Contains(t, bazel, `deps = ["//include:mylib_headers", ":pch"]`)
+	assert.Contains(t, bazel, `"//conditions:default": ["-include", "src/pch/pch.hpp"]`)
+	assert.Contains(t, bazel, `define_values = {"pch": "off"}`)
+}
+
+func TestGenerateHeaderOnly(t *testing.T) {
+	header := GenerateHeaderOnlyLibHeader("my-lib")
+	assert.Contains(t, header, "inline void greet() {")
+	assert.Contains(t, header, "namespace my_lib {")
+
+	cmake := GenerateHeaderOnlyCMakeLists("mylib", 20, true, false, "1.0.0")
+	assert.Contains(t, cmake, "add_library(mylib INTERFACE)")
+	assert.Contains(t, cmake, "target_compile_features(mylib INTERFACE cxx_std_20)")
+	assert.Contains(t, cmake, "    ARCH_INDEPENDENT\n")
+	assert.Contains(t, cmake, "DESTINATION ${CMAKE_INSTALL_DATADIR}/pkgconfig")
+	assert.Contains(t, cmake, "add_subdirectory(tests)")
+	assert.NotContains(t, cmake, "src/")
+	assert.NotContains(t, GenerateHeaderOnlyPkgConfig("mylib"), "Libs:")
+
+	tests := LinkHeaderOnlyCMake(GenerateTestCMake("mylib", "googletest"), "mylib", "mylib_tests")
+	assert.NotContains(t, tests, "../src/mylib.cpp")
+	assert.Contains(t, tests, "target_link_libraries(mylib_tests PRIVATE mylib::mylib)")
+	bench := LinkHeaderOnlyCMake(GenerateBenchCMake("mylib", "google-benchmark"), "mylib", "mylib_bench")
+	assert.Contains(t, bench, "target_link_libraries(mylib_bench PRIVATE mylib::mylib)")
+
+	assert.Contains(t, GenerateHeaderOnlyBuildBazelRoot("mylib"), `actual = "//include:mylib"`)
+	assert.Contains(t, GenerateHeaderOnlyBuildBazelInclude("mylib"), `name = "mylib",`)
+	bazelTests := LinkHeaderOnlyBazel(GenerateBuildBazelTests("mylib", "catch2"), "mylib")
+	assert.Contains(t, bazelTests, `"//include:mylib"`)
+	assert.NotContains(t, bazelTests, "//src:")
+
+	meson := GenerateHeaderOnlyMesonBuildRoot("my-lib", 17, "doctest", "")
+	assert.NotContains(t, meson, "subdir('src')")
+	assert.Contains(t, meson, "my_lib_dep = declare_dependency(include_directories : inc_dirs)")
+	assert.Contains(t, meson, "subdir('tests')")
+	assert.Contains(t, meson, "'Type': 'header-only library'")
+	mesonTests := LinkHeaderOnlyMeson(GenerateMesonBuildTests("my-lib", "doctest"), "my-lib")
+	assert.Contains(t, mesonTests, "  dependencies : [my_lib_dep, doctest_dep]")
+	assert.NotContains(t, mesonTests, "link_with")
+	mesonTests = LinkHeaderOnlyMeson(GenerateMesonBuildTests("my-lib", "none"), "my-lib")
+	assert.Contains(t, mesonTests, "  dependencies : [my_lib_dep]\n")
+}
+
+func TestGenerateModules(t *testing.T) {
+	assert.Contains(t, GenerateModuleInterface("my-lib"), "export module my_lib;")
+	assert.Contains(t, GenerateModuleSource("my-lib"), "module my_lib;")
+	assert.Contains(t, GenerateModuleMainCpp("my-lib"), "import my_lib;")
+
+	lib := GenerateModulesCMakeLists("mylib", 17, false, true, false, "1.0.0", true)
+	assert.Contains(t, lib, "cmake_minimum_required(VERSION 3.28)")
+	assert.Contains(t, lib, "set(CMAKE_CXX_STANDARD 20)")
+	assert.Contains(t, lib, "FILE_SET CXX_MODULES FILES src/mylib.cppm")
+	assert.Contains(t, lib, "FILE_SET CXX_MODULES DESTINATION ${CMAKE_INSTALL_LIBDIR}/cmake/mylib/modules")
+	assert.Contains(t, lib, `option(BUILD_SHARED_LIBS "Build shared libraries" ON)`)
+	assert.Contains(t, lib, "VERSION_LESS 14")
+	assert.Contains(t, lib, "add_subdirectory(tests)")
+
+	exe := GenerateModulesCMakeLists("myapp", 23, true, false, false, "1.0.0", false)
+	assert.Contains(t, exe, "set(CMAKE_CXX_STANDARD 23)")
+	assert.Contains(t, exe, "add_library(myapp_lib)")
+	assert.Contains(t, exe, "target_link_libraries(myapp PRIVATE myapp_lib)")
+	assert.NotContains(t, exe, "install(")
+
+	bench := GenerateModulesBenchCMake("mylib", "google-benchmark", false)
+	assert.NotContains(t, bench, "../src/mylib.cpp")
+	assert.Contains(t, bench, "target_link_libraries(mylib_bench PRIVATE mylib)")
+
+	test := ImportModule(GenerateTestMain("my-lib", "catch2"), "my-lib")
+	assert.Contains(t, test, "import my_lib;")
+	assert.NotContains(t, test, "my-lib.hpp")
+}
+
+func TestGeneratePackageConfig(t *testing.T) {
+	result := GeneratePackageConfig("mylib")
+
+	assert.Contains(t, result, "@PACKAGE_INIT@")
+	assert.Contains(t, result, `include("${CMAKE_CURRENT_LIST_DIR}/mylibTargets.cmake")`)
+	assert.Contains(t, result, "check_required_components(mylib)")
+}
+
+func TestGeneratePkgConfig(t *testing.T) {
+	result := GeneratePkgConfig("mylib", []string{"fmt", "zlib"})
+
+	assert.Contains(t, result, "prefix=${pcfiledir}/../..")
+	assert.Contains(t, result, "libdir=${prefix}/@CMAKE_INSTALL_LIBDIR@")
+	assert.Contains(t, result, "Version: @PROJECT_VERSION@")
+	assert.Contains(t, result, "Requires: fmt, zlib\n")
+	assert.Contains(t, result, "Libs: -L${libdir} -lmylib")
+	assert.Contains(t, result, "Cflags: -I${includedir}")
+}
+
 func TestGenerateCMakePresets(t *testing.T) {
 	result := GenerateCMakePresets()
 
 	assert.Contains(t, result, "configurePresets")
 	assert.Contains(t, result, "VCPKG_ROOT")
 	assert.Contains(t, result, "vcpkg.cmake")
+
+	var presets struct {
+		ConfigurePresets []struct {
+			Name   string `json:"name"`
+			Hidden bool   `json:"hidden"`
+		} `json:"configurePresets"`
+		BuildPresets []struct {
+			Name            string `json:"name"`
+			ConfigurePreset string `json:"configurePreset"`
+		} `json:"buildPresets"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result), &presets))
+	var names []string
+	for _, p := range presets.ConfigurePresets {
+		if !p.Hidden {
+			names = append(names, p.Name)
+		}
+	}
+	assert.Equal(t, []string{"default", "debug", "release", "asan"}, names)
+	for _, p := range presets.BuildPresets {
+		assert.Equal(t, p.Name, p.ConfigurePreset)
+	}
 }
 
 func TestGenerateTestMain(t *testing.T) {
@@ -731,5 +904,59 @@ func TestGenerateCpxCI(t *testing.T) {
 	result := GenerateCpxCI()
 
 	assert.Contains(t, result, "toolchains:")
-	assert.Contains(t, result, "build:")
+	assert.Empty(t, config.ValidateToolchains([]byte(result), t.TempDir()), "the generated file passes cpx ci validate")
+
+	// the examples are valid too once uncommented
+	uncommented := regexp.MustCompile(`(?m)^(\s*)# (\s*- |\s+\w)`).ReplaceAllString(result, "$1$2")
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Dockerfile"), []byte("FROM ubuntu\n"), 0644))
+	assert.Empty(t, config.ValidateToolchains([]byte(uncommented), root))
+}
+
+func TestGenerateVcpkgPortfile(t *testing.T) {
+	result := GenerateVcpkgPortfile("mylib", "https://example.com/mylib-1.0.0.tar.gz", "abc123", "LICENSE")
+
+	assert.Contains(t, result, `URLS "https://example.com/mylib-1.0.0.tar.gz"`)
+	assert.Contains(t, result, "SHA512 abc123")
+	assert.Contains(t, result, "vcpkg_cmake_config_fixup(CONFIG_PATH lib/cmake/mylib)")
+	assert.Contains(t, result, `vcpkg_install_copyright(FILE_LIST "${SOURCE_PATH}/LICENSE")`)
+
+	result = GenerateVcpkgPortfile("mylib", "https://example.com/mylib.tar.gz", "abc123", "")
+	assert.NotContains(t, result, "vcpkg_install_copyright")
+	assert.Contains(t, result, "share/${PORT}/copyright")
+}
+
+func TestGenerateConanfile(t *testing.T) {
+	recipe := ConanRecipe{
+		Name:        "my-lib",
+		Version:     "1.2.0",
+		Description: "My library",
+		License:     "MIT",
+		CppStandard: 20,
+		Requires:    []string{"fmt/[*]", "zlib/[>=1.3]"},
+	}
+	result := GenerateConanfile(recipe)
+
+	assert.Contains(t, result, "class My_libConan(ConanFile):")
+	assert.Contains(t, result, `version = "1.2.0"`)
+	assert.Contains(t, result, `license = "MIT"`)
+	assert.Contains(t, result, `self.requires("fmt/[*]")`)
+	assert.Contains(t, result, `self.requires("zlib/[>=1.3]")`)
+	assert.Contains(t, result, `set_property("cmake_target_name", "my-lib::my-lib")`)
+
+	recipe.Requires = nil
+	recipe.License = ""
+	result = GenerateConanfile(recipe)
+	assert.NotContains(t, result, "def requirements")
+	assert.NotContains(t, result, "license =")
+}
+
+func TestGenerateConanTestPackage(t *testing.T) {
+	files := GenerateConanTestPackage(ConanRecipe{Name: "mylib", CppStandard: 17})
+
+	assert.Contains(t, files["conanfile.py"], "self.requires(self.tested_reference_str)")
+	assert.Contains(t, files["CMakeLists.txt"], "find_package(mylib CONFIG REQUIRED)")
+	assert.Contains(t, files["CMakeLists.txt"], "cxx_std_17")
+	assert.Contains(t, files["test_package.cpp"], "#include <mylib/mylib.hpp>")
+	assert.Contains(t, files["test_package.cpp"], "mylib::greet();")
 }
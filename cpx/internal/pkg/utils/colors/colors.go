@@ -1,12 +1,25 @@
 package colors
 
-// ANSI color escape sequences
-const (
-	Reset  = "\033[0m"
-	Red    = "\033[31m"
-	Green  = "\033[32m"
-	Yellow = "\033[33m"
-	Cyan   = "\033[36m"
-	Gray   = "\033[90m"
-	Bold   = "\033[1m"
+// ANSI color escape sequences. They are variables so Disable can turn
+// colored output off.
+var (
+	Reset   = "\033[0m"
+	Red     = "\033[31m"
+	Green   = "\033[32m"
+	Yellow  = "\033[33m"
+	Blue    = "\033[34m"
+	Magenta = "\033[35m"
+	Cyan    = "\033[36m"
+	Gray    = "\033[90m"
+	Bold    = "\033[1m"
 )
+
+// Disable turns colored output off by clearing all escape sequences.
+func Disable() {
+	Reset, Red, Green, Yellow, Blue, Magenta, Cyan, Gray, Bold = "", "", "", "", "", "", "", "", ""
+}
+
+// Enabled reports whether colored output is on.
+func Enabled() bool {
+	return Reset != ""
+}
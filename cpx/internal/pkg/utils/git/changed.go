@@ -0,0 +1,80 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// cppExtensions are the C/C++ source and header extensions cpx formats and lints
+var cppExtensions = map[string]bool{
+	".cpp": true, ".cxx": true, ".cc": true, ".c++": true,
+	".hpp": true, ".hxx": true, ".hh": true, ".h++": true,
+	".c": true, ".h": true,
+	".cppm": true, ".ixx": true, // C++20 modules
+}
+
+// IsCppFile reports whether path has a C/C++ source or header extension.
+func IsCppFile(path string) bool {
+	return cppExtensions[filepath.Ext(path)]
+}
+
+// StagedFiles returns the files added, copied, modified or renamed in the
+// index, relative to the current directory.
+func StagedFiles() ([]string, error) {
+	return existingChanges("--cached")
+}
+
+// ChangedFiles returns the files that differ between ref and the working
+// tree, relative to the current directory. Deleted files are left out.
+func ChangedFiles(ref string) ([]string, error) {
+	if err := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}").Run(); err != nil {
+		return nil, fmt.Errorf("unknown git revision %q", ref)
+	}
+	return existingChanges(ref)
+}
+
+// UnstagedFiles returns the files with changes in the working tree that
+// are not in the index, relative to the current directory.
+func UnstagedFiles() ([]string, error) {
+	return diffNames()
+}
+
+func existingChanges(args ...string) ([]string, error) {
+	names, err := diffNames(append([]string{"--diff-filter=ACMR"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range names {
+		if _, err := os.Stat(name); err == nil {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// diffNames runs git diff --name-only with args and returns the file names
+// relative to the current directory.
+func diffNames(args ...string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git not found")
+	}
+	if err := exec.Command("git", "rev-parse", "--git-dir").Run(); err != nil {
+		return nil, fmt.Errorf("not in a git repository")
+	}
+	cmd := exec.Command("git", append([]string{"diff", "--name-only", "--relative"}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, filepath.FromSlash(line))
+		}
+	}
+	return names, nil
+}
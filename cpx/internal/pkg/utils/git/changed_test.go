@@ -0,0 +1,63 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates a git repository in a temporary directory with main.cpp
+// and util.cpp committed, and changes into it.
+func initRepo(t *testing.T) {
+	t.Chdir(t.TempDir())
+	run := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@test.com")
+	run("config", "user.name", "Test User")
+	require.NoError(t, os.WriteFile("main.cpp", []byte("int main() {}\n"), 0644))
+	require.NoError(t, os.WriteFile("util.cpp", []byte("// util\n"), 0644))
+	run("add", ".")
+	run("commit", "-qm", "initial")
+}
+
+func TestStagedFiles(t *testing.T) {
+	initRepo(t)
+	require.NoError(t, os.WriteFile("main.cpp", []byte("int main() { return 0; }\n"), 0644))
+	require.NoError(t, os.WriteFile("new.hpp", []byte("#pragma once\n"), 0644))
+	require.NoError(t, os.WriteFile("notes.txt", []byte("notes\n"), 0644))
+	require.NoError(t, exec.Command("git", "add", "new.hpp", "notes.txt").Run())
+	require.NoError(t, exec.Command("git", "rm", "-q", "util.cpp").Run())
+
+	files, err := StagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new.hpp", "notes.txt"}, files)
+
+	unstaged, err := UnstagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.cpp"}, unstaged)
+}
+
+func TestChangedFiles(t *testing.T) {
+	initRepo(t)
+	require.NoError(t, os.WriteFile("main.cpp", []byte("int main() { return 0; }\n"), 0644))
+
+	files, err := ChangedFiles("HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.cpp"}, files)
+
+	_, err = ChangedFiles("no-such-branch")
+	assert.Error(t, err)
+}
+
+func TestIsCppFile(t *testing.T) {
+	assert.True(t, IsCppFile("src/main.cpp"))
+	assert.True(t, IsCppFile("include/lib.h"))
+	assert.True(t, IsCppFile("mod.cppm"))
+	assert.False(t, IsCppFile("CMakeLists.txt"))
+}
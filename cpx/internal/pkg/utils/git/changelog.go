@@ -0,0 +1,98 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// changelogGroups are the sections of a changelog entry, in order, with the
+// Conventional Commits types they collect. Subjects of other types, and
+// those that are not conventional commits, go to "Other changes".
+var changelogGroups = []struct {
+	title string
+	types []string
+}{
+	{"Features", []string{"feat"}},
+	{"Fixes", []string{"fix"}},
+	{"Performance", []string{"perf"}},
+}
+
+// ChangelogSection returns the Markdown changelog entry of version, released
+// on date (YYYY-MM-DD), listing commit subjects by kind. Breaking changes,
+// marked with "!", come first; merge commits are left out.
+func ChangelogSection(version, date string, subjects []string) string {
+	var breaking, other []string
+	grouped := make(map[string][]string)
+	for _, subject := range subjects {
+		if strings.HasPrefix(subject, "Merge ") {
+			continue
+		}
+		m := conventionalRe.FindStringSubmatch(subject)
+		if m == nil {
+			other = append(other, subject)
+			continue
+		}
+		entry := m[4]
+		if m[2] != "" {
+			entry = "**" + m[2] + ":** " + entry
+		}
+		if m[3] == "!" {
+			breaking = append(breaking, entry)
+			continue
+		}
+		title := ""
+		for _, g := range changelogGroups {
+			for _, t := range g.types {
+				if strings.EqualFold(m[1], t) {
+					title = g.title
+				}
+			}
+		}
+		if title == "" {
+			other = append(other, entry)
+			continue
+		}
+		grouped[title] = append(grouped[title], entry)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## [%s] - %s\n", version, date)
+	empty := true
+	write := func(title string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		empty = false
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for _, e := range entries {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	write("Breaking changes", breaking)
+	for _, g := range changelogGroups {
+		write(g.title, grouped[g.title])
+	}
+	write("Other changes", other)
+	if empty {
+		b.WriteString("\nNo changes.\n")
+	}
+	return b.String()
+}
+
+// InsertChangelogSection adds section to a changelog above its latest entry,
+// below any title and introduction, or at the end when it has no entries.
+func InsertChangelogSection(changelog, section string) string {
+	if changelog == "" {
+		return "# Changelog\n\n" + section
+	}
+	lines := strings.SplitAfter(changelog, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			return strings.Join(lines[:i], "") + section + "\n" + strings.Join(lines[i:], "")
+		}
+	}
+	if !strings.HasSuffix(changelog, "\n") {
+		changelog += "\n"
+	}
+	return changelog + "\n" + section
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangelogSection(t *testing.T) {
+	section := ChangelogSection("1.3.0", "2026-10-18", []string{
+		"feat(parser): support arrays",
+		"fix: crash on empty input",
+		"Merge branch 'main'",
+		"feat!: drop the C API",
+		"docs: explain the options",
+		"Update README",
+		"perf(lexer): avoid copies",
+	})
+	assert.Equal(t, `## [1.3.0] - 2026-10-18
+
+### Breaking changes
+
+- drop the C API
+
+### Features
+
+- **parser:** support arrays
+
+### Fixes
+
+- crash on empty input
+
+### Performance
+
+- **lexer:** avoid copies
+
+### Other changes
+
+- explain the options
+- Update README
+`, section)
+
+	assert.Equal(t, "## [1.3.1] - 2026-10-18\n\nNo changes.\n", ChangelogSection("1.3.1", "2026-10-18", nil))
+}
+
+func TestInsertChangelogSection(t *testing.T) {
+	section := "## [1.1.0] - 2026-10-18\n\n- new\n"
+
+	assert.Equal(t, "# Changelog\n\n"+section, InsertChangelogSection("", section))
+	assert.Equal(t,
+		"# Changelog\n\nAll notable changes.\n\n"+section+"\n## [1.0.0] - 2026-01-01\n\n- old\n",
+		InsertChangelogSection("# Changelog\n\nAll notable changes.\n\n## [1.0.0] - 2026-01-01\n\n- old\n", section))
+	assert.Equal(t, "# Changelog\n\n"+section, InsertChangelogSection("# Changelog", section))
+}
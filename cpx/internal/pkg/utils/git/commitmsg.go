@@ -0,0 +1,63 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultCommitTypes are the Conventional Commits types accepted when a
+// project configures none.
+var DefaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// conventionalRe matches a Conventional Commits subject:
+// <type>[(<scope>)][!]: <description>
+var conventionalRe = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: (\S.*)$`)
+
+// CheckCommitMessage reports whether msg, as git passes it to the commit-msg
+// hook, starts with a Conventional Commits subject. types defaults to
+// DefaultCommitTypes; when scopes is empty any scope is accepted. Merge,
+// revert, fixup and squash commits that git writes itself pass.
+func CheckCommitMessage(msg string, types, scopes []string) error {
+	subject := commitSubject(msg)
+	if subject == "" {
+		return fmt.Errorf("empty commit message")
+	}
+	for _, prefix := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(subject, prefix) {
+			return nil
+		}
+	}
+
+	if len(types) == 0 {
+		types = DefaultCommitTypes
+	}
+	m := conventionalRe.FindStringSubmatch(subject)
+	if m == nil {
+		return fmt.Errorf("%q is not a conventional commit: expected <type>(<scope>): <description>, e.g. \"feat(parser): support arrays\"", subject)
+	}
+	if !slices.Contains(types, m[1]) {
+		return fmt.Errorf("unknown commit type %q (allowed: %s)", m[1], strings.Join(types, ", "))
+	}
+	if m[2] != "" && len(scopes) > 0 {
+		for _, scope := range strings.Split(m[2], ",") {
+			if scope = strings.TrimSpace(scope); !slices.Contains(scopes, scope) {
+				return fmt.Errorf("unknown commit scope %q (allowed: %s)", scope, strings.Join(scopes, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// commitSubject returns the first line of msg that is not empty or a git
+// comment.
+func commitSubject(msg string) string {
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimRight(line, "\r \t")
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
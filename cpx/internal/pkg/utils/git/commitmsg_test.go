@@ -0,0 +1,52 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCommitMessage(t *testing.T) {
+	valid := []string{
+		"feat: add arrays",
+		"fix(parser): handle empty input\n\nLonger body.",
+		"feat(api)!: drop the v1 endpoints",
+		"# Please enter the commit message\n\nchore: bump deps\n",
+		"Merge branch 'main' into topic",
+		"Revert \"feat: add arrays\"",
+		"fixup! feat: add arrays",
+	}
+	for _, msg := range valid {
+		assert.NoError(t, CheckCommitMessage(msg, nil, nil), msg)
+	}
+
+	invalid := []string{
+		"",
+		"# only a comment\n",
+		"Add arrays",
+		"feat:add arrays",
+		"feat(): ",
+		"wip: add arrays",
+	}
+	for _, msg := range invalid {
+		assert.Error(t, CheckCommitMessage(msg, nil, nil), msg)
+	}
+}
+
+func TestCheckCommitMessage_ConfiguredTypesAndScopes(t *testing.T) {
+	types := []string{"feat", "fix"}
+	scopes := []string{"core", "cli"}
+
+	assert.NoError(t, CheckCommitMessage("feat(core): add arrays", types, scopes))
+	assert.NoError(t, CheckCommitMessage("fix(core, cli): share the parser", types, scopes))
+	assert.NoError(t, CheckCommitMessage("fix: no scope is fine", types, scopes))
+
+	err := CheckCommitMessage("docs(core): explain arrays", types, scopes)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "allowed: feat, fix")
+	}
+	err = CheckCommitMessage("feat(gui): add a window", types, scopes)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `unknown commit scope "gui"`)
+	}
+}
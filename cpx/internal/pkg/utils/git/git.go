@@ -30,14 +30,6 @@ func GetGitTrackedCppFiles() ([]string, error) {
 
 	allTrackedFiles := strings.Split(strings.TrimSpace(string(output)), "\n")
 
-	// C/C++ file extensions
-	cppExtensions := map[string]bool{
-		".cpp": true, ".cxx": true, ".cc": true, ".c++": true,
-		".hpp": true, ".hxx": true, ".hh": true, ".h++": true,
-		".c": true, ".h": true,
-		".cppm": true, ".ixx": true, // C++20 modules
-	}
-
 	// Filter to only C/C++ files
 	var trackedCppFiles []string
 	for _, file := range allTrackedFiles {
@@ -79,14 +71,6 @@ func FilterGitTrackedFiles(targets []string) ([]string, error) {
 
 	allTrackedFiles := strings.Split(strings.TrimSpace(string(output)), "\n")
 
-	// C/C++ file extensions
-	cppExtensions := map[string]bool{
-		".cpp": true, ".cxx": true, ".cc": true, ".c++": true,
-		".hpp": true, ".hxx": true, ".hh": true, ".h++": true,
-		".c": true, ".h": true,
-		".cppm": true, ".ixx": true, // C++20 modules
-	}
-
 	// Filter to only C/C++ files
 	var trackedCppFiles []string
 	for _, file := range allTrackedFiles {
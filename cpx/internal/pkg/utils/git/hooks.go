@@ -6,10 +6,13 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/output"
 )
 
-// InstallHooksWithConfig installs git hooks with specified configuration
-func InstallHooksWithConfig(preCommit []string, prePush []string) error {
+// InstallHooksWithConfig installs git hooks with specified configuration.
+// commitMsg adds the commit-msg hook that checks for Conventional Commits.
+func InstallHooksWithConfig(preCommit []string, prePush []string, commitMsg bool) error {
 	// Check if we're in a git repository
 	cmd := exec.Command("git", "rev-parse", "--git-dir")
 	if err := cmd.Run(); err != nil {
@@ -18,11 +21,11 @@ func InstallHooksWithConfig(preCommit []string, prePush []string) error {
 
 	// Get .git directory
 	cmd = exec.Command("git", "rev-parse", "--git-dir")
-	output, err := cmd.Output()
+	out, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to get git directory: %w", err)
 	}
-	gitDir := strings.TrimSpace(string(output))
+	gitDir := strings.TrimSpace(string(out))
 
 	// Convert to absolute path if relative
 	if !filepath.IsAbs(gitDir) {
@@ -38,7 +41,7 @@ func InstallHooksWithConfig(preCommit []string, prePush []string) error {
 		return fmt.Errorf("failed to create hooks directory: %w", err)
 	}
 
-	fmt.Printf("%s Installing git hooks...%s\n", "\033[36m", "\033[0m")
+	output.Stepf(" Installing git hooks...")
 
 	// Install pre-commit hook if configured
 	if len(preCommit) > 0 {
@@ -49,7 +52,7 @@ func InstallHooksWithConfig(preCommit []string, prePush []string) error {
 		if err := InstallPreCommitHook(hooksDir, preCommit); err != nil {
 			return fmt.Errorf("failed to install pre-commit hook: %w", err)
 		}
-		fmt.Printf("%s   pre-commit%s\n", "\033[32m", "\033[0m")
+		output.Successf("   pre-commit")
 	}
 
 	// Install pre-push hook if configured
@@ -61,10 +64,17 @@ func InstallHooksWithConfig(preCommit []string, prePush []string) error {
 		if err := InstallPrePushHook(hooksDir, prePush); err != nil {
 			return fmt.Errorf("failed to install pre-push hook: %w", err)
 		}
-		fmt.Printf("%s   pre-push%s\n", "\033[32m", "\033[0m")
+		output.Successf("   pre-push")
+	}
+
+	if commitMsg {
+		if err := InstallCommitMsgHook(hooksDir); err != nil {
+			return fmt.Errorf("failed to install commit-msg hook: %w", err)
+		}
+		output.Successf("   commit-msg")
 	}
 
-	fmt.Printf("%s Git hooks installed successfully!%s\n", "\033[32m", "\033[0m")
+	output.Successf(" Git hooks installed successfully!")
 	return nil
 }
 
@@ -88,10 +98,10 @@ func InstallPreCommitHook(hooksDir string, checks []string) error {
 		check = strings.TrimSpace(strings.ToLower(check))
 		switch check {
 		case "fmt":
-			sb.WriteString(`# Format code
+			sb.WriteString(`# Format the staged files
 if command -v cpx &> /dev/null; then
     echo " Formatting code..."
-    if ! cpx fmt; then
+    if ! cpx fmt --staged; then
         echo "  cpx fmt failed, continuing..."
     fi
 else
@@ -100,10 +110,10 @@ fi
 
 `)
 		case "lint":
-			sb.WriteString(`# Run linter
+			sb.WriteString(`# Lint the staged files
 if command -v cpx &> /dev/null; then
     echo " Running linter..."
-    if ! cpx lint; then
+    if ! cpx lint --staged; then
         echo "  cpx lint found issues (non-blocking)"
     fi
 else
@@ -256,6 +266,30 @@ fi
 	return writeHook(hookPath, sb.String())
 }
 
+// InstallCommitMsgHook installs the commit-msg hook, which rejects commit
+// messages that are not Conventional Commits. The types and scopes are read
+// from cpx-ci.yaml by cpx when the hook runs, so changing them needs no
+// reinstall.
+func InstallCommitMsgHook(hooksDir string) error {
+	content := `#!/bin/bash
+# Cpx commit-msg hook
+# Generated by cpx
+
+# Validate the commit message
+if command -v cpx &> /dev/null; then
+    if ! cpx hooks commit-msg "$1"; then
+        echo " Commit aborted. Edit the message to match <type>(<scope>): <description>"
+        exit 1
+    fi
+else
+    echo "  cpx not found, skipping commit message check"
+fi
+
+exit 0
+`
+	return writeHook(filepath.Join(hooksDir, "commit-msg"), content)
+}
+
 // writeHook writes a hook file and makes it executable
 func writeHook(hookPath, content string) error {
 	// Remove any existing .sample file for the same hook
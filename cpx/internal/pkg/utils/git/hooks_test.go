@@ -241,6 +241,7 @@ func TestInstallHooksWithConfig(t *testing.T) {
 		name            string
 		preCommit       []string
 		prePush         []string
+		commitMsg       bool
 		expectPreCommit bool
 		expectPrePush   bool
 	}{
@@ -272,6 +273,14 @@ func TestInstallHooksWithConfig(t *testing.T) {
 			expectPreCommit: false,
 			expectPrePush:   false,
 		},
+		{
+			name:            "Commit-msg only",
+			preCommit:       []string{},
+			prePush:         []string{},
+			commitMsg:       true,
+			expectPreCommit: false,
+			expectPrePush:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -286,8 +295,9 @@ func TestInstallHooksWithConfig(t *testing.T) {
 			// Clean up hooks before test
 			os.Remove(filepath.Join(hooksDir, "pre-commit"))
 			os.Remove(filepath.Join(hooksDir, "pre-push"))
+			os.Remove(filepath.Join(hooksDir, "commit-msg"))
 
-			err = InstallHooksWithConfig(tt.preCommit, tt.prePush)
+			err = InstallHooksWithConfig(tt.preCommit, tt.prePush, tt.commitMsg)
 			require.NoError(t, err)
 
 			// Check pre-commit hook
@@ -307,6 +317,14 @@ func TestInstallHooksWithConfig(t *testing.T) {
 			} else {
 				assert.True(t, os.IsNotExist(err), "pre-push hook should not exist")
 			}
+
+			// Check commit-msg hook
+			_, err = os.Stat(filepath.Join(hooksDir, "commit-msg"))
+			if tt.commitMsg {
+				assert.NoError(t, err, "commit-msg hook should exist")
+			} else {
+				assert.True(t, os.IsNotExist(err), "commit-msg hook should not exist")
+			}
 		})
 	}
 }
@@ -319,7 +337,7 @@ func TestInstallHooksWithConfig_NotInGitRepo(t *testing.T) {
 	defer func() { _ = os.Chdir(oldWd) }()
 	require.NoError(t, os.Chdir(tmpDir))
 
-	err = InstallHooksWithConfig([]string{"fmt"}, []string{"test"})
+	err = InstallHooksWithConfig([]string{"fmt"}, []string{"test"}, false)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not in a git repository")
 }
@@ -379,7 +397,7 @@ func TestInstallHooksWithConfig_CreatesHooksDir(t *testing.T) {
 	require.True(t, os.IsNotExist(err))
 
 	// Install hooks
-	err = InstallHooksWithConfig([]string{"fmt"}, []string{})
+	err = InstallHooksWithConfig([]string{"fmt"}, []string{}, false)
 	require.NoError(t, err)
 
 	// Verify hooks dir was created
@@ -410,7 +428,7 @@ func TestInstallHooksWithConfig_RemovesSampleFiles(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "pre-push.sample"), []byte("sample"), 0644))
 
 	// Install hooks
-	err = InstallHooksWithConfig([]string{"fmt"}, []string{"test"})
+	err = InstallHooksWithConfig([]string{"fmt"}, []string{"test"}, false)
 	require.NoError(t, err)
 
 	// Verify sample files were removed
@@ -456,3 +474,17 @@ func TestHookContent_EndsWithExit0(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, strings.HasSuffix(strings.TrimSpace(string(content)), "exit 0"))
 }
+
+func TestInstallCommitMsgHook(t *testing.T) {
+	hooksDir := t.TempDir()
+	require.NoError(t, InstallCommitMsgHook(hooksDir))
+
+	content, err := os.ReadFile(filepath.Join(hooksDir, "commit-msg"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `cpx hooks commit-msg "$1"`)
+	assert.Contains(t, string(content), "exit 1")
+
+	info, err := os.Stat(filepath.Join(hooksDir, "commit-msg"))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0100, "hook should be executable")
+}
@@ -0,0 +1,65 @@
+// Package notify sends desktop notifications.
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Send shows a desktop notification with osascript on macOS, a PowerShell
+// toast on Windows and notify-send elsewhere.
+func Send(title, message string) error {
+	argv, err := Command(runtime.GOOS, title, message)
+	if err != nil {
+		return err
+	}
+	if _, err := exec.LookPath(argv[0]); err != nil {
+		return fmt.Errorf("%s not found: %w", argv[0], err)
+	}
+	if out, err := exec.Command(argv[0], argv[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", argv[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Command returns the command that shows a notification on goos.
+func Command(goos, title, message string) ([]string, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return []string{"osascript", "-e", script}, nil
+	case "windows":
+		return []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToast(title, message)}, nil
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		return []string{"notify-send", "--app-name=cpx", title, message}, nil
+	default:
+		return nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// windowsToast is a PowerShell script that shows a toast through the
+// WinRT notification API, which needs no module to be installed.
+func windowsToast(title, message string) string {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	return strings.Join([]string{
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null",
+		"$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+		"$text = $xml.GetElementsByTagName('text')",
+		"$text.Item(0).AppendChild($xml.CreateTextNode(" + quote(title) + ")) | Out-Null",
+		"$text.Item(1).AppendChild($xml.CreateTextNode(" + quote(message) + ")) | Out-Null",
+		"$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)",
+		// PowerShell's app ID, since cpx has none registered
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\\WindowsPowerShell\\v1.0\\powershell.exe').Show($toast)",
+	}, "; ")
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	argv, err := Command("darwin", "cpx build", `done in "1m"`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"osascript", "-e", `display notification "done in \"1m\"" with title "cpx build"`}, argv)
+
+	argv, err = Command("linux", "cpx build", "done")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notify-send", "--app-name=cpx", "cpx build", "done"}, argv)
+
+	argv, err = Command("windows", "cpx build", "it's done")
+	require.NoError(t, err)
+	assert.Equal(t, "powershell", argv[0])
+	assert.Contains(t, argv[len(argv)-1], "CreateTextNode('it''s done')")
+
+	_, err = Command("plan9", "cpx build", "done")
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+// Package output is cpx's console output layer. Status messages go through
+// it so --quiet, NO_COLOR and CPX_LOG are honored in one place instead of
+// at every fmt.Printf.
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+)
+
+// Level controls which messages are printed.
+type Level int
+
+const (
+	// LevelQuiet prints only warnings and errors.
+	LevelQuiet Level = iota
+	// LevelInfo also prints status messages (the default).
+	LevelInfo
+	// LevelDebug also prints debug messages.
+	LevelDebug
+)
+
+var level = LevelInfo
+
+// Variables for mocking in tests
+var (
+	getenv     = os.Getenv
+	isTerminal = func() bool {
+		fd := os.Stdout.Fd()
+		return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+	}
+)
+
+// Init sets the level and color mode from the global flags and the
+// environment. CPX_LOG selects the level (quiet, info or debug; CPX_DEBUG=1
+// is the same as CPX_LOG=debug) and --quiet overrides it. Colors are off
+// with --no-color, NO_COLOR, TERM=dumb, or when stdout is not a terminal,
+// as in CI logs, unless FORCE_COLOR or CLICOLOR_FORCE is set.
+func Init(quiet, noColor bool) {
+	level = LevelInfo
+	switch strings.ToLower(getenv("CPX_LOG")) {
+	case "debug", "trace":
+		level = LevelDebug
+	case "quiet", "error", "warn":
+		level = LevelQuiet
+	}
+	if getenv("CPX_DEBUG") != "" {
+		level = LevelDebug
+	}
+	if quiet {
+		level = LevelQuiet
+	}
+
+	if !useColor(noColor) {
+		colors.Disable()
+	}
+}
+
+func useColor(noColor bool) bool {
+	if noColor || getenv("NO_COLOR") != "" || getenv("TERM") == "dumb" {
+		return false
+	}
+	if getenv("FORCE_COLOR") != "" || getenv("CLICOLOR_FORCE") != "" {
+		return true
+	}
+	return isTerminal()
+}
+
+// SetLevel sets the output level.
+func SetLevel(l Level) {
+	level = l
+}
+
+// Quiet reports whether status messages are suppressed.
+func Quiet() bool {
+	return level == LevelQuiet
+}
+
+// DebugEnabled reports whether debug messages are printed.
+func DebugEnabled() bool {
+	return level >= LevelDebug
+}
+
+// Interactive reports whether stdout is a terminal, where progress bars
+// and spinners can redraw in place.
+func Interactive() bool {
+	return isTerminal()
+}
+
+// Stdout returns where status output goes: os.Stdout, or io.Discard when
+// quiet. Use it for multi-line status text.
+func Stdout() io.Writer {
+	if Quiet() {
+		return io.Discard
+	}
+	return os.Stdout
+}
+
+// Infof prints a status message.
+func Infof(format string, args ...any) {
+	if Quiet() {
+		return
+	}
+	fmt.Fprintf(os.Stdout, format+"\n", args...)
+}
+
+// Stepf prints a status message in cyan, for steps that are starting.
+func Stepf(format string, args ...any) {
+	Infof(colors.Cyan+format+colors.Reset, args...)
+}
+
+// Successf prints a status message in green.
+func Successf(format string, args ...any) {
+	Infof(colors.Green+format+colors.Reset, args...)
+}
+
+// Warnf prints a warning to stderr. Warnings are shown even when quiet.
+func Warnf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, colors.Yellow+"⚠ "+format+colors.Reset+"\n", args...)
+}
+
+// Errorf prints an error to stderr. Errors are always shown.
+func Errorf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, colors.Red+"✗ "+format+colors.Reset+"\n", args...)
+}
+
+// Debugf prints a debug message to stderr when CPX_LOG=debug.
+func Debugf(format string, args ...any) {
+	if !DebugEnabled() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, colors.Gray+"[debug] "+format+colors.Reset+"\n", args...)
+}
@@ -0,0 +1,124 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/utils/colors"
+	"github.com/stretchr/testify/assert"
+)
+
+// withEnv mocks the environment and terminal detection, restoring them,
+// the level and the colors afterwards.
+func withEnv(t *testing.T, env map[string]string, terminal bool) {
+	t.Helper()
+	oldGetenv, oldIsTerminal, oldLevel := getenv, isTerminal, level
+	saved := []string{colors.Reset, colors.Red, colors.Green, colors.Yellow, colors.Blue, colors.Magenta, colors.Cyan, colors.Gray, colors.Bold}
+	t.Cleanup(func() {
+		getenv, isTerminal, level = oldGetenv, oldIsTerminal, oldLevel
+		colors.Reset, colors.Red, colors.Green, colors.Yellow, colors.Blue, colors.Magenta, colors.Cyan, colors.Gray, colors.Bold =
+			saved[0], saved[1], saved[2], saved[3], saved[4], saved[5], saved[6], saved[7], saved[8]
+	})
+	getenv = func(key string) string { return env[key] }
+	isTerminal = func() bool { return terminal }
+}
+
+// capture returns what fn writes to stdout and stderr.
+func capture(fn func()) (string, string) {
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+	fn()
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+
+	var out, errOut bytes.Buffer
+	_, _ = out.ReadFrom(outR)
+	_, _ = errOut.ReadFrom(errR)
+	return out.String(), errOut.String()
+}
+
+func TestInitLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		quiet bool
+		want  Level
+	}{
+		{"default", nil, false, LevelInfo},
+		{"CPX_LOG=debug", map[string]string{"CPX_LOG": "debug"}, false, LevelDebug},
+		{"CPX_LOG=DEBUG", map[string]string{"CPX_LOG": "DEBUG"}, false, LevelDebug},
+		{"CPX_DEBUG", map[string]string{"CPX_DEBUG": "1"}, false, LevelDebug},
+		{"CPX_LOG=quiet", map[string]string{"CPX_LOG": "quiet"}, false, LevelQuiet},
+		{"--quiet", nil, true, LevelQuiet},
+		{"--quiet wins over CPX_LOG", map[string]string{"CPX_LOG": "debug"}, true, LevelQuiet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEnv(t, tt.env, true)
+			Init(tt.quiet, false)
+			assert.Equal(t, tt.want, level)
+		})
+	}
+}
+
+func TestInitColors(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		terminal bool
+		noColor  bool
+		want     bool
+	}{
+		{"terminal", nil, true, false, true},
+		{"piped (CI logs)", nil, false, false, false},
+		{"--no-color", nil, true, true, false},
+		{"NO_COLOR", map[string]string{"NO_COLOR": "1"}, true, false, false},
+		{"TERM=dumb", map[string]string{"TERM": "dumb"}, true, false, false},
+		{"FORCE_COLOR when piped", map[string]string{"FORCE_COLOR": "1"}, false, false, true},
+		{"NO_COLOR wins over FORCE_COLOR", map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEnv(t, tt.env, tt.terminal)
+			Init(false, tt.noColor)
+			assert.Equal(t, tt.want, colors.Enabled())
+		})
+	}
+}
+
+func TestQuietSuppressesStatus(t *testing.T) {
+	withEnv(t, nil, false)
+	Init(true, false)
+
+	stdout, stderr := capture(func() {
+		Infof("info")
+		Stepf("step")
+		Successf("done")
+		Debugf("debug")
+		Warnf("careful")
+		Errorf("broken")
+	})
+	assert.Empty(t, stdout)
+	assert.Equal(t, "⚠ careful\n✗ broken\n", stderr)
+}
+
+func TestLevels(t *testing.T) {
+	withEnv(t, map[string]string{"CPX_LOG": "debug"}, false)
+	Init(false, false)
+
+	stdout, stderr := capture(func() {
+		Infof("building %s", "app")
+		Successf("✓ Build successful")
+		Debugf("cmake %v", []string{"--build"})
+	})
+	assert.Equal(t, "building app\n✓ Build successful\n", stdout)
+	assert.Equal(t, "[debug] cmake [--build]\n", stderr)
+
+	SetLevel(LevelInfo)
+	_, stderr = capture(func() { Debugf("hidden") })
+	assert.Empty(t, stderr)
+}
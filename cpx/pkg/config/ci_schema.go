@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// ToolchainsSchemaURL is where the JSON schema of cpx-ci.yaml is published,
+// for editors to complete and check the file with.
+const ToolchainsSchemaURL = "https://raw.githubusercontent.com/ozacod/cpx/main/schema/cpx-ci.schema.json"
+
+// ToolchainsSchema returns the JSON schema of cpx-ci.yaml, generated from
+// the same types and enums cpx ci validate checks the file against.
+func ToolchainsSchema() ([]byte, error) {
+	schema := jsonSchema(reflect.TypeFor[ToolchainConfig]())
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = ToolchainsSchemaURL
+	schema["title"] = "cpx-ci.yaml"
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// jsonSchema returns the schema of values of type t.
+func jsonSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]any{}
+		for name, field := range yamlFields(t) {
+			if enum, ok := fieldEnums[t.Name()+"."+name]; ok {
+				if field.Type.Kind() == reflect.Slice {
+					properties[name] = map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": enum}}
+				} else {
+					properties[name] = map[string]any{"type": "string", "enum": enum}
+				}
+				continue
+			}
+			properties[name] = jsonSchema(field.Type)
+		}
+		return map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{"type": "string"}
+}
@@ -121,3 +121,27 @@ func TestSaveGlobalConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadGlobalFor(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, config.SaveGlobal(&config.GlobalConfig{VcpkgRoot: "/opt/vcpkg", BcrRoot: "/opt/bcr", Jobs: 4}))
+
+	root := t.TempDir()
+	cfg, err := config.LoadGlobalFor(root)
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/vcpkg", cfg.VcpkgRoot, "without cpx.yaml the global config applies")
+
+	manifest := &config.Manifest{Config: &config.ConfigOverrides{VcpkgRoot: "third_party/vcpkg", Jobs: 2, CompilerLauncher: "sccache"}}
+	require.NoError(t, config.SaveManifest(filepath.Join(root, config.ManifestFile), manifest))
+	cfg, err = config.LoadGlobalFor(root)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "third_party", "vcpkg"), cfg.VcpkgRoot, "relative roots are relative to the project")
+	assert.Equal(t, "/opt/bcr", cfg.BcrRoot, "keys the project leaves out keep the global value")
+	assert.Equal(t, 2, cfg.Jobs)
+	assert.Equal(t, "sccache", cfg.CompilerLauncher)
+
+	global, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/vcpkg", global.VcpkgRoot, "the global config file is not changed")
+	assert.Equal(t, 4, global.Jobs)
+}
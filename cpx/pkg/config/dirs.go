@@ -0,0 +1,142 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default locations of a project's cache (build trees, logs, reports) and
+// artifact directories, relative to the project root.
+const (
+	DefaultCacheDir = ".cache"
+	DefaultBinDir   = ".bin"
+)
+
+// ProjectDirs are the cache and artifact directories of a project.
+type ProjectDirs struct {
+	Cache string
+	Bin   string
+}
+
+// DirsOf returns the cache and artifact directories of the project in root:
+// cache_dir and bin_dir of its cpx.yaml, else of the global config, else
+// .cache and .bin. Relative directories are relative to root. A global
+// absolute directory, such as one on a scratch disk, holds a subdirectory
+// per project so projects do not share build trees.
+func DirsOf(root string) ProjectDirs {
+	dirs := ProjectDirs{Cache: DefaultCacheDir, Bin: DefaultBinDir}
+	global := readGlobal()
+	if global.CacheDir != "" {
+		dirs.Cache = perProject(global.CacheDir, root)
+	}
+	if global.BinDir != "" {
+		dirs.Bin = perProject(global.BinDir, root)
+	}
+	if manifest := ProjectManifest(root); manifest != nil {
+		if manifest.CacheDir != "" {
+			dirs.Cache = expandHome(manifest.CacheDir)
+		}
+		if manifest.BinDir != "" {
+			dirs.Bin = expandHome(manifest.BinDir)
+		}
+	}
+	if !filepath.IsAbs(dirs.Cache) {
+		dirs.Cache = filepath.Join(root, dirs.Cache)
+	}
+	if !filepath.IsAbs(dirs.Bin) {
+		dirs.Bin = filepath.Join(root, dirs.Bin)
+	}
+	return dirs
+}
+
+// Within returns the cache and artifact directories that lie inside root,
+// relative to it with forward slashes, for tools that skip them when
+// walking the project's sources.
+func (d ProjectDirs) Within(root string) []string {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, dir := range []string{d.Cache, d.Bin} {
+		if dir, err = filepath.Abs(dir); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		dirs = append(dirs, filepath.ToSlash(rel))
+	}
+	return dirs
+}
+
+// CacheDir returns the cache directory of the project in the current
+// directory, .cache unless configured otherwise.
+func CacheDir() string {
+	return DirsOf(".").Cache
+}
+
+// BinDir returns the artifact directory of the project in the current
+// directory, .bin unless configured otherwise.
+func BinDir() string {
+	return DirsOf(".").Bin
+}
+
+// perProject returns the directory of the project in root under a global
+// directory: dir itself when relative, else <dir>/<project>-<hash of root>.
+func perProject(dir, root string) string {
+	dir = expandHome(dir)
+	if !filepath.IsAbs(dir) {
+		return dir
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, filepath.Base(abs)+"-"+hex.EncodeToString(sum[:4]))
+}
+
+// expandHome expands a leading ~/ to the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// ContainerRuntime returns the container_runtime of the global config,
+// empty when it is not set.
+func ContainerRuntime() string {
+	return readGlobal().ContainerRuntime
+}
+
+// readGlobal returns the global config, or an empty one when there is none
+// or it cannot be parsed. It decodes the file as LoadGlobal does, ignoring
+// unknown keys, and unlike LoadGlobal does not create it.
+func readGlobal() *GlobalConfig {
+	path, err := GetConfigPath()
+	if err != nil {
+		return &GlobalConfig{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &GlobalConfig{}
+	}
+	cfg, err := decodeGlobal(data, path)
+	var unknown *UnknownKeysError
+	if errors.As(err, &unknown) {
+		return unknown.Config
+	}
+	if err != nil {
+		return &GlobalConfig{}
+	}
+	return cfg
+}
@@ -0,0 +1,56 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirsOf(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+
+	dirs := config.DirsOf(root)
+	assert.Equal(t, filepath.Join(root, ".cache"), dirs.Cache)
+	assert.Equal(t, filepath.Join(root, ".bin"), dirs.Bin)
+
+	scratch := t.TempDir()
+	require.NoError(t, config.SaveGlobal(&config.GlobalConfig{CacheDir: scratch, BinDir: "out"}))
+	dirs = config.DirsOf(root)
+	assert.Equal(t, scratch, filepath.Dir(dirs.Cache), "a global absolute directory holds a directory per project")
+	assert.True(t, strings.HasPrefix(filepath.Base(dirs.Cache), filepath.Base(root)+"-"))
+	assert.NotEqual(t, dirs.Cache, config.DirsOf(t.TempDir()).Cache, "projects do not share a directory")
+	assert.Equal(t, filepath.Join(root, "out"), dirs.Bin)
+
+	manifest := "cache_dir: build/cache\nbin_dir: /opt/artifacts\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, config.ManifestFile), []byte(manifest), 0644))
+	dirs = config.DirsOf(root)
+	assert.Equal(t, filepath.Join(root, "build", "cache"), dirs.Cache, "cpx.yaml takes precedence")
+	assert.Equal(t, "/opt/artifacts", dirs.Bin)
+}
+
+func TestProjectDirsWithin(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	assert.Equal(t, []string{".cache", ".bin"}, config.DirsOf(root).Within(root))
+
+	manifest := "cache_dir: build/cache\nbin_dir: /opt/artifacts\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, config.ManifestFile), []byte(manifest), 0644))
+	assert.Equal(t, []string{"build/cache"}, config.DirsOf(root).Within(root), "directories outside the project are not listed")
+}
+
+func TestDirsOfUnknownKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configPath, err := config.GetConfigPath()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(configPath), 0755))
+	require.NoError(t, os.WriteFile(configPath, []byte("bin_dirr: x\nbin_dir: out\n"), 0644))
+
+	root := t.TempDir()
+	assert.Equal(t, filepath.Join(root, "out"), config.DirsOf(root).Bin, "unknown keys are ignored as in LoadGlobal's config")
+}
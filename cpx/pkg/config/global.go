@@ -14,6 +14,47 @@ type GlobalConfig struct {
 	VcpkgRoot  string `yaml:"vcpkg_root"`
 	BcrRoot    string `yaml:"bcr_root"`    // Bazel Central Registry path
 	WrapdbRoot string `yaml:"wrapdb_root"` // Meson WrapDB path
+	// CMakeGenerator selects the CMake generator for native vcpkg builds:
+	// empty (detect), "ninja", "vs", "clang-cl" or a CMake generator name.
+	CMakeGenerator string `yaml:"cmake_generator,omitempty"`
+	// SharedVcpkgInstalled makes vcpkg projects share one vcpkg_installed
+	// directory per triplet and manifest in the cache dir instead of each
+	// installing its dependencies in .cache/native/vcpkg_installed
+	SharedVcpkgInstalled bool `yaml:"shared_vcpkg_installed,omitempty"`
+	// UpgradeChannel is the release channel cpx upgrade follows: "stable"
+	// (default) or "nightly"
+	UpgradeChannel string `yaml:"upgrade_channel,omitempty"`
+	// NotifyAfter is how many seconds a build, test run or toolchain build
+	// must take for cpx to send a desktop notification when it finishes;
+	// 0 turns notifications off
+	NotifyAfter int `yaml:"notify_after,omitempty"`
+	// CacheDir and BinDir move the cache (build trees, logs, reports) and
+	// artifact directories of every project from .cache and .bin, e.g. to a
+	// scratch disk; an absolute directory holds one subdirectory per project
+	CacheDir string `yaml:"cache_dir,omitempty"`
+	BinDir   string `yaml:"bin_dir,omitempty"`
+	// Jobs is the number of parallel build jobs when --jobs is not given;
+	// 0 lets the build tool decide
+	Jobs int `yaml:"jobs,omitempty"`
+	// CompilerLauncher wraps compiler invocations of CMake builds, e.g.
+	// ccache or sccache
+	CompilerLauncher string `yaml:"compiler_launcher,omitempty"`
+	// DockerRegistry is the registry and namespace cpx docker publish pushes
+	// images to, e.g. ghcr.io/acme
+	DockerRegistry string `yaml:"docker_registry,omitempty"`
+	// ContainerRuntime is the container CLI of docker runners: docker,
+	// podman or nerdctl. Empty picks the first one installed.
+	ContainerRuntime string `yaml:"container_runtime,omitempty"`
+	// Templates are the user project templates added with "cpx template
+	// add", by name
+	Templates map[string]UserTemplate `yaml:"templates,omitempty"`
+}
+
+// UserTemplate is a user project template. Dir is the directory generated
+// projects are rendered from: Source itself, or the clone of a git Source.
+type UserTemplate struct {
+	Source string `yaml:"source"`
+	Dir    string `yaml:"dir"`
 }
 
 // GetConfigDir returns the directory where cpx stores its global config
@@ -50,7 +91,8 @@ func GetConfigPath() (string, error) {
 }
 
 // LoadGlobal loads the global cpx configuration
-// If the config file doesn't exist, it will be created with default values
+// If the config file doesn't exist, it will be created with default values.
+// Unknown keys, usually misspelled ones, are an error.
 func LoadGlobal() (*GlobalConfig, error) {
 	configPath, err := GetConfigPath()
 	if err != nil {
@@ -70,12 +112,20 @@ func LoadGlobal() (*GlobalConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
+	return decodeGlobal(data, configPath)
+}
 
+// decodeGlobal parses the config.yaml data at path. Unknown keys are an
+// *UnknownKeysError holding the config without them.
+func decodeGlobal(data []byte, path string) (*GlobalConfig, error) {
 	var config GlobalConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
-
+	if unknown := checkKeys(data, path); unknown != nil {
+		unknown.Config = &config
+		return nil, unknown
+	}
 	return &config, nil
 }
 
@@ -107,3 +157,69 @@ func SaveGlobal(config *GlobalConfig) error {
 
 	return nil
 }
+
+// ConfigOverrides are the global config keys a project can override in
+// the config section of its cpx.yaml, for checkouts on machines with a
+// different layout. Empty values keep the global setting.
+type ConfigOverrides struct {
+	// VcpkgRoot, BcrRoot and WrapdbRoot are relative to the project root
+	// unless absolute
+	VcpkgRoot        string `yaml:"vcpkg_root,omitempty"`
+	BcrRoot          string `yaml:"bcr_root,omitempty"`
+	WrapdbRoot       string `yaml:"wrapdb_root,omitempty"`
+	Jobs             int    `yaml:"jobs,omitempty"`
+	CompilerLauncher string `yaml:"compiler_launcher,omitempty"`
+}
+
+// LoadGlobalFor loads the global config with the overrides of the cpx.yaml
+// of the project in dir applied. The global config file is not changed.
+func LoadGlobalFor(dir string) (*GlobalConfig, error) {
+	cfg, err := LoadGlobal()
+	if err != nil {
+		return nil, err
+	}
+	if manifest := ProjectManifest(dir); manifest != nil && manifest.Config != nil {
+		manifest.Config.apply(cfg, dir)
+	}
+	return cfg, nil
+}
+
+// apply sets the overridden keys of cfg; relative roots are made absolute
+// against the project in dir.
+func (o *ConfigOverrides) apply(cfg *GlobalConfig, dir string) {
+	root := func(path string) string {
+		path = expandHome(path)
+		if filepath.IsAbs(path) {
+			return path
+		}
+		if abs, err := filepath.Abs(filepath.Join(dir, path)); err == nil {
+			return abs
+		}
+		return filepath.Join(dir, path)
+	}
+	if o.VcpkgRoot != "" {
+		cfg.VcpkgRoot = root(o.VcpkgRoot)
+	}
+	if o.BcrRoot != "" {
+		cfg.BcrRoot = root(o.BcrRoot)
+	}
+	if o.WrapdbRoot != "" {
+		cfg.WrapdbRoot = root(o.WrapdbRoot)
+	}
+	if o.Jobs != 0 {
+		cfg.Jobs = o.Jobs
+	}
+	if o.CompilerLauncher != "" {
+		cfg.CompilerLauncher = o.CompilerLauncher
+	}
+}
+
+// GetCacheDir returns the directory where cpx stores downloaded data
+// (registry mirrors, API caches, ...)
+func GetCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "cpx"), nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the project manifest in the project root
+const ManifestFile = "cpx.yaml"
+
+// ManifestVersion is the version of the cpx.yaml format this cpx reads and
+// writes. Manifests written before it was versioned have none (0).
+const ManifestVersion = 1
+
+// Manifest is the cpx.yaml of a project: what cpx knows about the project,
+// read by every command instead of being scraped from the build files.
+type Manifest struct {
+	// ManifestVersion is the version of the cpx.yaml format
+	ManifestVersion int `yaml:"manifest_version,omitempty"`
+
+	Name    string `yaml:"name,omitempty"`
+	Version string `yaml:"version,omitempty"`
+
+	// CxxStandard is the C++ standard the project builds with, such as 20
+	CxxStandard int `yaml:"cxx_standard,omitempty"`
+
+	// BuildSystem is the project's build system (vcpkg, bazel or meson);
+	// when set, cpx uses it instead of guessing from the files present.
+	BuildSystem string `yaml:"build_system,omitempty"`
+
+	// CacheDir and BinDir replace the project's .cache and .bin directories;
+	// relative to the project root unless absolute
+	CacheDir string `yaml:"cache_dir,omitempty"`
+	BinDir   string `yaml:"bin_dir,omitempty"`
+
+	// Config overrides global config keys for this project, e.g. a vcpkg
+	// checkout inside the repository
+	Config *ConfigOverrides `yaml:"config,omitempty"`
+
+	// Dependencies describes the project's dependencies. The build files
+	// stay the source of truth for what is built; this is metadata for
+	// tools and people reading the manifest.
+	Dependencies []ManifestDependency `yaml:"dependencies,omitempty"`
+
+	// Profiles are named build configurations for cpx build --profile.
+	// They take precedence over the profiles of cpx-ci.yaml.
+	Profiles map[string]BuildProfile `yaml:"profiles,omitempty"`
+
+	// Tasks are named shell commands run with cpx task
+	Tasks map[string]Task `yaml:"tasks,omitempty"`
+}
+
+// ManifestDependency is a dependency listed in cpx.yaml.
+type ManifestDependency struct {
+	Name     string   `yaml:"name"`
+	Version  string   `yaml:"version,omitempty"`
+	Features []string `yaml:"features,omitempty"`
+}
+
+// Task is a named shell command of cpx.yaml.
+type Task struct {
+	// Run is the command, run by the shell in the project root
+	Run string `yaml:"run"`
+
+	// Description is shown by cpx task
+	Description string `yaml:"description,omitempty"`
+}
+
+// LoadManifest reads the cpx.yaml at path. It fails for a manifest written
+// by a newer cpx than this one.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManifestFile, err)
+	}
+	if manifest.ManifestVersion > ManifestVersion {
+		return nil, fmt.Errorf("%s has manifest_version %d, this cpx supports up to %d\n  hint: upgrade cpx", ManifestFile, manifest.ManifestVersion, ManifestVersion)
+	}
+	return &manifest, nil
+}
+
+// SaveManifest writes manifest to path with the current manifest version.
+func SaveManifest(path string, manifest *Manifest) error {
+	manifest.ManifestVersion = ManifestVersion
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", ManifestFile, err)
+	}
+	header := "# cpx project manifest: https://github.com/ozacod/cpx#project-manifest\n"
+	return os.WriteFile(path, append([]byte(header), data...), 0644)
+}
+
+// ProjectManifest returns the cpx.yaml of the project in dir, or nil when
+// it has none or it cannot be read. Lookups fall back to the build files
+// then; commands that need the manifest call LoadManifest to report errors.
+func ProjectManifest(dir string) *Manifest {
+	manifest, err := LoadManifest(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil
+	}
+	return manifest
+}
@@ -0,0 +1,49 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.ManifestFile)
+	manifest := &config.Manifest{
+		Name:         "mylib",
+		Version:      "1.0.0",
+		CxxStandard:  20,
+		BuildSystem:  "vcpkg",
+		Dependencies: []config.ManifestDependency{{Name: "fmt", Version: "10.2.1", Features: []string{"std"}}},
+		Profiles:     map[string]config.BuildProfile{"lto": {Release: true, Args: []string{"-DLTO=ON"}}},
+		Tasks:        map[string]config.Task{"gen": {Run: "./gen.sh", Description: "Generate code"}},
+	}
+	require.NoError(t, config.SaveManifest(path, manifest))
+
+	loaded, err := config.LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, config.ManifestVersion, loaded.ManifestVersion, "the current version is written")
+	assert.Equal(t, manifest, loaded)
+	assert.Equal(t, manifest, config.ProjectManifest(dir))
+}
+
+func TestLoadManifestVersion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.ManifestFile)
+
+	require.NoError(t, os.WriteFile(path, []byte("build_system: meson\n"), 0644))
+	manifest, err := config.LoadManifest(path)
+	require.NoError(t, err, "manifests from before versioning are read")
+	assert.Equal(t, 0, manifest.ManifestVersion)
+
+	require.NoError(t, os.WriteFile(path, []byte("manifest_version: 99\nname: x\n"), 0644))
+	_, err = config.LoadManifest(path)
+	assert.ErrorContains(t, err, "manifest_version 99")
+	assert.Nil(t, config.ProjectManifest(dir))
+
+	assert.Nil(t, config.ProjectManifest(t.TempDir()), "no cpx.yaml")
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,6 +17,269 @@ import (
 type ToolchainConfig struct {
 	Runners    []Runner    `yaml:"runners,omitempty"`
 	Toolchains []Toolchain `yaml:"toolchains,omitempty"`
+	// Sanitizers adjusts sanitizer builds, keyed by sanitizer name (asan, ubsan, tsan, msan)
+	Sanitizers map[string]SanitizerSettings `yaml:"sanitizers,omitempty"`
+	// PCH turns the precompiled header of projects created with cpx new --pch on or off
+	PCH *bool `yaml:"pch,omitempty"`
+	// CUDA selects the GPU architectures of CUDA projects
+	CUDA *CUDAConfig `yaml:"cuda,omitempty"`
+	// Hooks are shell commands run before and after cpx commands
+	Hooks *CommandHooks `yaml:"hooks,omitempty"`
+	// VcpkgInstalled overrides the global shared_vcpkg_installed setting:
+	// "local" or "shared"
+	VcpkgInstalled string `yaml:"vcpkg_installed,omitempty"`
+	// Submodules are dependencies added with cpx add --submodule
+	Submodules []Submodule `yaml:"submodules,omitempty"`
+	// CommitMsg configures the commit-msg git hook
+	CommitMsg *CommitMsgConfig `yaml:"commit_msg,omitempty"`
+	// Profiles are named sets of build options, selected with
+	// cpx build --profile <name>
+	Profiles map[string]BuildProfile `yaml:"profiles,omitempty"`
+	// Targets adds compile options to targets of the project, keyed by
+	// target name
+	Targets map[string]TargetOptions `yaml:"targets,omitempty"`
+	// Test sets the time limits of cpx test
+	Test *TestConfig `yaml:"test,omitempty"`
+	// Mutate configures mutation testing with cpx mutate
+	Mutate *MutateConfig `yaml:"mutate,omitempty"`
+	// Release configures what cpx release does besides bumping the version
+	Release *ReleaseConfig `yaml:"release,omitempty"`
+	// Signing signs release binaries for distribution
+	Signing *SigningConfig `yaml:"signing,omitempty"`
+}
+
+// TestConfig limits how long tests may run, as Go durations such as 90s or
+// 10m. A test or run over its limit is stopped and reported as timed out.
+type TestConfig struct {
+	Timeout        string `yaml:"timeout,omitempty"`         // each test
+	SessionTimeout string `yaml:"session_timeout,omitempty"` // the whole cpx test run
+}
+
+// Timeouts returns the per-test and session time limits, 0 when unset.
+func (t *TestConfig) Timeouts() (test, session time.Duration, err error) {
+	if t == nil {
+		return 0, 0, nil
+	}
+	parse := func(key, value string) (time.Duration, error) {
+		if value == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid test.%s %q (use e.g. 90s or 10m)", key, value)
+		}
+		return d, nil
+	}
+	if test, err = parse("timeout", t.Timeout); err != nil {
+		return 0, 0, err
+	}
+	if session, err = parse("session_timeout", t.SessionTimeout); err != nil {
+		return 0, 0, err
+	}
+	return test, session, nil
+}
+
+// MutateConfig configures cpx mutate.
+type MutateConfig struct {
+	Threshold float64  `yaml:"threshold,omitempty"` // minimum mutation score, in percent
+	Mutators  []string `yaml:"mutators,omitempty"`  // mull mutators or groups, e.g. cxx_arithmetic
+	Exclude   []string `yaml:"exclude,omitempty"`   // regexps of source paths not to mutate
+	Timeout   string   `yaml:"timeout,omitempty"`   // per mutant, as a Go duration
+}
+
+// ReleaseConfig configures cpx release.
+type ReleaseConfig struct {
+	Changelog string `yaml:"changelog,omitempty"`  // changelog to add a section to (default: CHANGELOG.md if it exists)
+	Tag       bool   `yaml:"tag,omitempty"`        // commit the release and tag it
+	TagPrefix string `yaml:"tag_prefix,omitempty"` // prefix of the tag name (default: v)
+	Push      bool   `yaml:"push,omitempty"`       // push the release commit and tag
+	Remote    string `yaml:"remote,omitempty"`     // remote to push to (default: origin)
+	// Artifacts builds the CI toolchains and archives their artifacts as
+	// <dist>/<project>-<version>-<os>-<arch>.tar.gz (.zip for Windows)
+	Artifacts  bool     `yaml:"artifacts,omitempty"`
+	Toolchains []string `yaml:"toolchains,omitempty"` // toolchains to release (default: all active)
+	Dist       string   `yaml:"dist,omitempty"`       // directory of the archives (default: dist)
+	// Package metadata of the Homebrew, winget and Chocolatey packages;
+	// description, homepage and license default to those of vcpkg.json
+	Binary      string `yaml:"binary,omitempty"` // executable the packages install (default: the project name)
+	Description string `yaml:"description,omitempty"`
+	Homepage    string `yaml:"homepage,omitempty"`
+	License     string `yaml:"license,omitempty"`   // SPDX identifier
+	Publisher   string `yaml:"publisher,omitempty"` // person or company publishing the packages
+	// DownloadURL is where the packages download the archives from, with
+	// {tag}, {version} and {file} replaced (default: the GitHub release of
+	// the remote)
+	DownloadURL string `yaml:"download_url,omitempty"`
+	// Brew writes a Homebrew formula of the archives (implies artifacts)
+	Brew *BrewConfig `yaml:"brew,omitempty"`
+	// Winget writes winget manifests of the Windows archives (implies artifacts)
+	Winget *WingetConfig `yaml:"winget,omitempty"`
+	// Chocolatey writes a Chocolatey package of the Windows archives (implies
+	// artifacts)
+	Chocolatey *ChocolateyConfig `yaml:"chocolatey,omitempty"`
+}
+
+// BrewConfig configures the Homebrew formula of cpx release.
+type BrewConfig struct {
+	// Tap is the tap repository the formula is pushed to: owner/homebrew-name
+	// on GitHub or a git URL; without it the formula is only written
+	Tap  string `yaml:"tap,omitempty"`
+	Test string `yaml:"test,omitempty"` // command brew test runs (default: <binary> --version)
+}
+
+// WingetConfig configures the winget manifests of cpx release.
+type WingetConfig struct {
+	PackageIdentifier string `yaml:"package_identifier,omitempty"` // default: <publisher>.<project>
+	// PullRequest submits the manifests to microsoft/winget-pkgs from a
+	// fork, with the gh CLI
+	PullRequest bool `yaml:"pull_request,omitempty"`
+}
+
+// ChocolateyConfig configures the Chocolatey package of cpx release.
+type ChocolateyConfig struct {
+	ID string `yaml:"id,omitempty"` // package id (default: the project name in lower case)
+}
+
+// SigningConfig configures code signing of release binaries per platform.
+type SigningConfig struct {
+	MacOS   *MacOSSigningConfig   `yaml:"macos,omitempty"`
+	Windows *WindowsSigningConfig `yaml:"windows,omitempty"`
+}
+
+// MacOSSigningConfig signs macOS binaries with a Developer ID certificate
+// instead of ad hoc, and notarizes them when a keychain profile is given.
+type MacOSSigningConfig struct {
+	Identity        string `yaml:"identity"`                   // e.g. "Developer ID Application: Name (TEAMID)"
+	Entitlements    string `yaml:"entitlements,omitempty"`     // entitlements plist, relative to the project root
+	KeychainProfile string `yaml:"keychain_profile,omitempty"` // notarytool credentials, see xcrun notarytool store-credentials
+}
+
+// DeveloperID returns the macOS signing settings, nil when they name no
+// identity.
+func (s *SigningConfig) DeveloperID() *MacOSSigningConfig {
+	if s == nil || s.MacOS == nil || s.MacOS.Identity == "" {
+		return nil
+	}
+	return s.MacOS
+}
+
+// WindowsSigningConfig signs Windows executables and DLLs with an
+// Authenticode certificate: one in the Windows certificate store, by
+// thumbprint, or a PFX file. The environment variables in WindowsSigningEnv
+// override it, so CI can keep certificates out of the repository.
+type WindowsSigningConfig struct {
+	CertificateThumbprint string `yaml:"certificate_thumbprint,omitempty"` // SHA-1 thumbprint of a certificate in the store
+	PFX                   string `yaml:"pfx,omitempty"`                    // PFX file, relative to the project root
+	TimestampURL          string `yaml:"timestamp_url,omitempty"`          // RFC 3161 server (default: DigiCert's)
+}
+
+// Environment variables of Windows signing. The PFX password is only read
+// from the environment.
+const (
+	WindowsSigningThumbprintEnv = "CPX_WINDOWS_CERT_THUMBPRINT"
+	WindowsSigningPFXEnv        = "CPX_WINDOWS_PFX"
+	WindowsSigningPasswordEnv   = "CPX_WINDOWS_PFX_PASSWORD"
+)
+
+// Authenticode returns the Windows signing settings with the environment
+// applied, nil when they name no certificate.
+func (s *SigningConfig) Authenticode() *WindowsSigningConfig {
+	var w WindowsSigningConfig
+	if s != nil && s.Windows != nil {
+		w = *s.Windows
+	}
+	if v := os.Getenv(WindowsSigningThumbprintEnv); v != "" {
+		w.CertificateThumbprint, w.PFX = v, ""
+	}
+	if v := os.Getenv(WindowsSigningPFXEnv); v != "" {
+		w.PFX, w.CertificateThumbprint = v, ""
+	}
+	if w.CertificateThumbprint == "" && w.PFX == "" {
+		return nil
+	}
+	return &w
+}
+
+// TargetOptions are compile options of one target that cpx adds to the
+// build files, so small customizations need no hand-edited CMakeLists.txt.
+type TargetOptions struct {
+	Defines       []string `yaml:"defines,omitempty"`        // preprocessor macros, NAME or NAME=VALUE
+	IncludeDirs   []string `yaml:"include_dirs,omitempty"`   // relative to the project root
+	LinkLibraries []string `yaml:"link_libraries,omitempty"` // targets such as fmt::fmt, or library names
+}
+
+// BuildProfile bundles the options of a kind of build, such as an LTO
+// release or a sanitizer build for CI, so they need not be retyped.
+type BuildProfile struct {
+	Release   bool     `yaml:"release,omitempty"`
+	Opt       string   `yaml:"opt,omitempty"`       // 0, 1, 2, 3, s or fast
+	Sanitizer string   `yaml:"sanitizer,omitempty"` // e.g. asan,ubsan
+	Defines   []string `yaml:"defines,omitempty"`   // preprocessor macros, NAME or NAME=VALUE
+	Args      []string `yaml:"args,omitempty"`      // extra cmake/meson setup or bazel build arguments
+}
+
+// CommitMsgConfig lists the Conventional Commits types and scopes the
+// commit-msg hook accepts. No types means the standard ones; no scopes means
+// any scope.
+type CommitMsgConfig struct {
+	Types  []string `yaml:"types,omitempty"`
+	Scopes []string `yaml:"scopes,omitempty"`
+}
+
+// Submodule is a library checked out as a git submodule and built from
+// source with the project.
+type Submodule struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	Path string `yaml:"path"` // relative to the project root, e.g. third_party/fmt
+}
+
+// CommandHooks lists shell commands run, in the project root, before and
+// after cpx build, test and release, e.g. for code generation or embedding
+// assets. A failing pre hook stops the command; post hooks run only when the
+// command succeeded.
+type CommandHooks struct {
+	PreBuild    []string `yaml:"pre_build,omitempty"`
+	PostBuild   []string `yaml:"post_build,omitempty"`
+	PreTest     []string `yaml:"pre_test,omitempty"`
+	PostTest    []string `yaml:"post_test,omitempty"`
+	PreRelease  []string `yaml:"pre_release,omitempty"`
+	PostRelease []string `yaml:"post_release,omitempty"`
+}
+
+// Commands returns the commands of the hook named name (e.g. pre_build).
+func (h *CommandHooks) Commands(name string) []string {
+	if h == nil {
+		return nil
+	}
+	switch name {
+	case "pre_build":
+		return h.PreBuild
+	case "post_build":
+		return h.PostBuild
+	case "pre_test":
+		return h.PreTest
+	case "post_test":
+		return h.PostTest
+	case "pre_release":
+		return h.PreRelease
+	case "post_release":
+		return h.PostRelease
+	}
+	return nil
+}
+
+// CUDAConfig selects the GPU architectures CUDA sources are compiled for.
+type CUDAConfig struct {
+	// Architectures are sm_xx names, compute capabilities (86, 8.6) or
+	// native, all and all-major (default: the GPUs of the build machine)
+	Architectures []string `yaml:"architectures,omitempty"`
+}
+
+// SanitizerSettings are a project's additions to cpx's sanitizer defaults
+type SanitizerSettings struct {
+	Flags   []string `yaml:"flags,omitempty"`   // extra compiler flags, e.g. -fsanitize-address-use-after-scope
+	Options string   `yaml:"options,omitempty"` // runtime options appended to *SAN_OPTIONS, e.g. halt_on_error=1
 }
 
 // Runner defines an execution environment with optional compiler settings
@@ -24,10 +289,47 @@ type Runner struct {
 	Image string `yaml:"image,omitempty"` // for docker
 	Host  string `yaml:"host,omitempty"`  // for ssh
 	User  string `yaml:"user,omitempty"`  // for ssh
+	// Build the docker image from a Dockerfile instead of using an existing image
+	Dockerfile   string            `yaml:"dockerfile,omitempty"`
+	BuildContext string            `yaml:"build_context,omitempty"` // defaults to the project root
+	BuildArgs    map[string]string `yaml:"build_args,omitempty"`
+	// Platform is the docker platform of the runner container (e.g. linux/arm64).
+	// Foreign platforms run under QEMU emulation.
+	Platform string `yaml:"platform,omitempty"`
+	// TargetPlatform is the platform of the produced binaries when it differs
+	// from the container (cross compilers); --run/--test execute them under QEMU
+	TargetPlatform string `yaml:"target_platform,omitempty"`
+	// Sysroot holds the target's dynamic loader and libraries for running
+	// cross-built binaries (default: /usr/<target triple>)
+	Sysroot string `yaml:"sysroot,omitempty"`
 	// Compiler settings (optional, can be set in runner)
 	CC                 string `yaml:"cc,omitempty"`
 	CXX                string `yaml:"cxx,omitempty"`
 	CMakeToolchainFile string `yaml:"cmake_toolchain_file,omitempty"`
+	// GPUs are passed to docker run --gpus when binaries are run in the
+	// container (e.g. "all"); needs the NVIDIA Container Toolkit
+	GPUs string `yaml:"gpus,omitempty"`
+	// Cache keeps the build caches of docker builds in the project's .cache/ci
+	// directory ("bind", the default) or in named docker volumes ("volume"),
+	// which are faster than bind mounts on Docker Desktop
+	Cache string `yaml:"cache,omitempty"`
+	// Pull decides when the image of a docker runner is pulled: "missing"
+	// (the default) pulls it when it is not available locally, "always"
+	// before every build and "never" leaves pulling to the user
+	Pull string `yaml:"pull,omitempty"`
+	// Registry logs in to a private registry before pulling or building the
+	// image; without it the credentials of docker login are used
+	Registry *RegistryAuth `yaml:"registry,omitempty"`
+}
+
+// RegistryAuth are the credentials of a private container registry. The
+// password is read from an environment variable, so it stays out of
+// cpx-ci.yaml.
+type RegistryAuth struct {
+	// Server is the registry host (default: the registry of the image)
+	Server      string `yaml:"server,omitempty"`
+	Username    string `yaml:"username"`
+	PasswordEnv string `yaml:"password_env"`
 }
 
 // IsNative returns true if the runner type is native/local (or unspecified)
@@ -40,6 +342,11 @@ func (r *Runner) IsDocker() bool {
 	return r.Type == "docker"
 }
 
+// BuildsImage returns true if the runner's docker image is built from a Dockerfile
+func (r *Runner) BuildsImage() bool {
+	return r.IsDocker() && r.Dockerfile != ""
+}
+
 // IsSSH returns true if the runner type is ssh
 func (r *Runner) IsSSH() bool {
 	return r.Type == "ssh"
@@ -48,6 +355,7 @@ func (r *Runner) IsSSH() bool {
 // Toolchain defines a build configuration (renamed from BuildConfig)
 type Toolchain struct {
 	Name         string            `yaml:"name"`
+	Type         string            `yaml:"type,omitempty"`   // built-in preset: "wasm", "android", "mingw" (default: plain build)
 	Runner       string            `yaml:"runner,omitempty"` // references Runner.Name
 	Active       *bool             `yaml:"active,omitempty"` // true (default) or false to disable
 	BuildType    string            `yaml:"build_type,omitempty"`
@@ -55,9 +363,47 @@ type Toolchain struct {
 	BuildOptions []string          `yaml:"build_options,omitempty"`
 	Env          map[string]string `yaml:"env,omitempty"`
 	Optimization string            `yaml:"optimization,omitempty"` // "0", "1", "2", "3", "s", "fast"
+	Sanitizer    string            `yaml:"sanitizer,omitempty"`    // "asan", "tsan", "msan", "ubsan" or a list like "asan,ubsan"
 	Jobs         int               `yaml:"jobs,omitempty"`         // number of parallel jobs
+	// Matrix expands the toolchain into one build job per combination
+	Matrix *ToolchainMatrix `yaml:"matrix,omitempty"`
+	// Android configures toolchains of type android
+	Android *AndroidConfig `yaml:"android,omitempty"`
+	// MinGW configures toolchains of type mingw
+	MinGW *MinGWConfig `yaml:"mingw,omitempty"`
+	// CUDA overrides the top-level cuda settings for this toolchain
+	CUDA *CUDAConfig `yaml:"cuda,omitempty"`
+	// MatrixBase is the name of the toolchain a matrix job was expanded from
+	MatrixBase string `yaml:"-"`
+}
+
+// ToolchainMatrix lists the values a toolchain is built with.
+// Every combination becomes a separate build job.
+type ToolchainMatrix struct {
+	BuildTypes    []string `yaml:"build_types,omitempty"`
+	Optimizations []string `yaml:"optimizations,omitempty"`
+	Sanitizers    []string `yaml:"sanitizers,omitempty"` // "none" builds without a sanitizer
 }
 
+// AndroidConfig selects the ABIs and API level of an android toolchain.
+type AndroidConfig struct {
+	ABIs     []string `yaml:"abis,omitempty"`      // default: arm64-v8a, x86_64
+	APILevel int      `yaml:"api_level,omitempty"` // minimum API level (default: 24)
+	NDK      string   `yaml:"ndk,omitempty"`       // NDK root (default: $ANDROID_NDK_HOME)
+}
+
+// MinGWConfig selects the target of a mingw toolchain.
+type MinGWConfig struct {
+	Arch string `yaml:"arch,omitempty"` // x86_64 (default) or i686
+}
+
+// Built-in toolchain types
+const (
+	ToolchainTypeWasm    = "wasm"
+	ToolchainTypeAndroid = "android"
+	ToolchainTypeMinGW   = "mingw"
+)
+
 // IsActive returns whether the toolchain is active (defaults to true if not specified)
 func (t *Toolchain) IsActive() bool {
 	if t.Active == nil {
@@ -66,6 +412,18 @@ func (t *Toolchain) IsActive() bool {
 	return *t.Active
 }
 
+// CUDAArchitectures returns the GPU architectures tc is built for: its own
+// cuda settings, or the top-level ones.
+func (c *ToolchainConfig) CUDAArchitectures(tc *Toolchain) []string {
+	if tc != nil && tc.CUDA != nil && len(tc.CUDA.Architectures) > 0 {
+		return tc.CUDA.Architectures
+	}
+	if c.CUDA != nil {
+		return c.CUDA.Architectures
+	}
+	return nil
+}
+
 // LoadToolchains loads the toolchain configuration from cpx-ci.yaml
 func LoadToolchains(path string) (*ToolchainConfig, error) {
 	data, err := os.ReadFile(path)
@@ -88,6 +446,75 @@ func LoadToolchains(path string) (*ToolchainConfig, error) {
 	return &config, nil
 }
 
+// ExpandMatrix replaces every toolchain that declares a matrix with one
+// toolchain per combination. Jobs are named <name>-<build type>[-O<opt>][-<sanitizer>],
+// so each job gets its own output directory. Axes with a single value are
+// not added to the name.
+func (c *ToolchainConfig) ExpandMatrix() error {
+	var expanded []Toolchain
+	seen := make(map[string]string)
+	for _, tc := range c.Toolchains {
+		jobs := tc.expand()
+		for _, job := range jobs {
+			if from, ok := seen[job.Name]; ok {
+				return fmt.Errorf("toolchain '%s' from matrix of '%s' conflicts with toolchain '%s'", job.Name, tc.Name, from)
+			}
+			seen[job.Name] = tc.Name
+		}
+		expanded = append(expanded, jobs...)
+	}
+	c.Toolchains = expanded
+	return nil
+}
+
+func (t Toolchain) expand() []Toolchain {
+	if t.Matrix == nil {
+		return []Toolchain{t}
+	}
+
+	axis := func(values []string, fallback string) []string {
+		if len(values) == 0 {
+			return []string{fallback}
+		}
+		return values
+	}
+	buildTypes := axis(t.Matrix.BuildTypes, t.BuildType)
+	optimizations := axis(t.Matrix.Optimizations, t.Optimization)
+	sanitizers := axis(t.Matrix.Sanitizers, t.Sanitizer)
+
+	var jobs []Toolchain
+	for _, buildType := range buildTypes {
+		for _, opt := range optimizations {
+			for _, sanitizer := range sanitizers {
+				if sanitizer == "none" {
+					sanitizer = ""
+				}
+
+				job := t
+				job.Matrix = nil
+				job.MatrixBase = t.Name
+				job.BuildType = buildType
+				job.Optimization = opt
+				job.Sanitizer = sanitizer
+
+				name := t.Name
+				if len(buildTypes) > 1 {
+					name += "-" + strings.ToLower(buildType)
+				}
+				if len(optimizations) > 1 && opt != "" {
+					name += "-O" + opt
+				}
+				if len(sanitizers) > 1 && sanitizer != "" {
+					name += "-" + strings.ReplaceAll(sanitizer, ",", "-")
+				}
+				job.Name = name
+				jobs = append(jobs, job)
+			}
+		}
+	}
+	return jobs
+}
+
 // FindRunner finds a runner by name
 func (c *ToolchainConfig) FindRunner(name string) *Runner {
 	for i := range c.Runners {
@@ -108,9 +535,10 @@ func (c *ToolchainConfig) FindToolchain(name string) *Toolchain {
 	return nil
 }
 
-// GetOutputDir returns the output directory (always .bin/ci)
+// GetOutputDir returns the output directory of toolchain builds, ci in the
+// project's artifact directory
 func (c *ToolchainConfig) GetOutputDir() string {
-	return filepath.Join(".bin", "ci")
+	return filepath.Join(BinDir(), "ci")
 }
 
 // SaveToolchains saves the toolchain configuration to cpx-ci.yaml
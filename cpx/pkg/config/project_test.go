@@ -0,0 +1,142 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandMatrix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cpx-ci.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`toolchains:
+  - name: linux-gcc
+    runner: gcc
+    cmake_options: [-DFOO=ON]
+    matrix:
+      build_types: [Debug, Release]
+      sanitizers: [none, asan]
+  - name: plain
+`), 0644))
+
+	cfg, err := config.LoadToolchains(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.ExpandMatrix())
+
+	var names []string
+	for _, tc := range cfg.Toolchains {
+		names = append(names, tc.Name)
+	}
+	assert.Equal(t, []string{
+		"linux-gcc-debug",
+		"linux-gcc-debug-asan",
+		"linux-gcc-release",
+		"linux-gcc-release-asan",
+		"plain",
+	}, names)
+
+	job := cfg.FindToolchain("linux-gcc-debug-asan")
+	require.NotNil(t, job)
+	assert.Equal(t, "Debug", job.BuildType)
+	assert.Equal(t, "asan", job.Sanitizer)
+	assert.Equal(t, "gcc", job.Runner)
+	assert.Equal(t, []string{"-DFOO=ON"}, job.CMakeOptions)
+	assert.Equal(t, "linux-gcc", job.MatrixBase)
+	assert.Nil(t, job.Matrix)
+
+	plain := cfg.FindToolchain("plain")
+	require.NotNil(t, plain)
+	assert.Equal(t, "Release", plain.BuildType)
+	assert.Empty(t, plain.MatrixBase)
+}
+
+func TestExpandMatrixOptimizations(t *testing.T) {
+	cfg := &config.ToolchainConfig{Toolchains: []config.Toolchain{{
+		Name:      "clang",
+		BuildType: "Release",
+		Sanitizer: "ubsan",
+		Matrix:    &config.ToolchainMatrix{Optimizations: []string{"2", "3"}},
+	}}}
+	require.NoError(t, cfg.ExpandMatrix())
+
+	require.Len(t, cfg.Toolchains, 2)
+	assert.Equal(t, "clang-O2", cfg.Toolchains[0].Name)
+	assert.Equal(t, "clang-O3", cfg.Toolchains[1].Name)
+	assert.Equal(t, "3", cfg.Toolchains[1].Optimization)
+	assert.Equal(t, "ubsan", cfg.Toolchains[1].Sanitizer)
+}
+
+func TestExpandMatrixNameConflict(t *testing.T) {
+	cfg := &config.ToolchainConfig{Toolchains: []config.Toolchain{
+		{Name: "gcc", Matrix: &config.ToolchainMatrix{Sanitizers: []string{"none", "asan"}}},
+		{Name: "gcc-asan"},
+	}}
+	err := cfg.ExpandMatrix()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gcc-asan")
+}
+
+func TestCUDAArchitectures(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cpx-ci.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`cuda:
+  architectures: [sm_75, sm_86]
+runners:
+  - name: cuda
+    type: docker
+    image: cpx-cuda:latest
+    gpus: all
+toolchains:
+  - name: native
+  - name: cuda
+    runner: cuda
+    cuda:
+      architectures: [all-major]
+`), 0644))
+
+	cfg, err := config.LoadToolchains(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sm_75", "sm_86"}, cfg.CUDAArchitectures(nil))
+	assert.Equal(t, []string{"sm_75", "sm_86"}, cfg.CUDAArchitectures(cfg.FindToolchain("native")))
+	assert.Equal(t, []string{"all-major"}, cfg.CUDAArchitectures(cfg.FindToolchain("cuda")))
+	assert.Equal(t, "all", cfg.FindRunner("cuda").GPUs)
+
+	assert.Nil(t, (&config.ToolchainConfig{}).CUDAArchitectures(nil))
+}
+
+func TestTestTimeouts(t *testing.T) {
+	test, session, err := (*config.TestConfig)(nil).Timeouts()
+	require.NoError(t, err)
+	assert.Zero(t, test)
+	assert.Zero(t, session)
+
+	test, session, err = (&config.TestConfig{Timeout: "90s", SessionTimeout: "20m"}).Timeouts()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, test)
+	assert.Equal(t, 20*time.Minute, session)
+
+	_, _, err = (&config.TestConfig{Timeout: "60"}).Timeouts()
+	assert.ErrorContains(t, err, `invalid test.timeout "60"`)
+	_, _, err = (&config.TestConfig{SessionTimeout: "-1m"}).Timeouts()
+	assert.ErrorContains(t, err, "test.session_timeout")
+}
+
+func TestAuthenticode(t *testing.T) {
+	t.Setenv(config.WindowsSigningThumbprintEnv, "")
+	t.Setenv(config.WindowsSigningPFXEnv, "")
+	assert.Nil(t, (*config.SigningConfig)(nil).Authenticode())
+	assert.Nil(t, (&config.SigningConfig{Windows: &config.WindowsSigningConfig{TimestampURL: "http://ts"}}).Authenticode())
+
+	signing := &config.SigningConfig{Windows: &config.WindowsSigningConfig{CertificateThumbprint: "ab12", TimestampURL: "http://ts"}}
+	assert.Equal(t, &config.WindowsSigningConfig{CertificateThumbprint: "ab12", TimestampURL: "http://ts"}, signing.Authenticode())
+
+	// the environment replaces the certificate of the config
+	t.Setenv(config.WindowsSigningPFXEnv, "/secrets/cert.pfx")
+	assert.Equal(t, &config.WindowsSigningConfig{PFX: "/secrets/cert.pfx", TimestampURL: "http://ts"}, signing.Authenticode())
+	assert.Equal(t, &config.WindowsSigningConfig{PFX: "/secrets/cert.pfx"}, (*config.SigningConfig)(nil).Authenticode())
+}
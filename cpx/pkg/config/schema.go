@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeyKind is what kind of value a config key holds, which decides how it is
+// validated.
+type KeyKind int
+
+const (
+	// KindString is any string
+	KindString KeyKind = iota
+	// KindPath is a path that must exist
+	KindPath
+	// KindCommand is a command that must be on PATH
+	KindCommand
+	// KindEnum is one of the key's Values
+	KindEnum
+	// KindInt is a non-negative number
+	KindInt
+	// KindBool is true or false
+	KindBool
+)
+
+// ConfigKey is a key of the global config that cpx config get, set and edit
+// work with.
+type ConfigKey struct {
+	Name        string
+	Description string
+	Kind        KeyKind
+
+	// Values are the values of an enum, or common values of a string
+	Values []string
+
+	// field returns a pointer to the key's field: *string, *int or *bool
+	field func(*GlobalConfig) any
+}
+
+// ConfigKeys are the keys of the global config, in the order cpx config
+// edit lists them.
+var ConfigKeys = []ConfigKey{
+	{Name: "vcpkg_root", Kind: KindPath, Description: "vcpkg checkout used by vcpkg projects",
+		field: func(c *GlobalConfig) any { return &c.VcpkgRoot }},
+	{Name: "bcr_root", Kind: KindPath, Description: "Bazel Central Registry clone used by cpx add and search",
+		field: func(c *GlobalConfig) any { return &c.BcrRoot }},
+	{Name: "wrapdb_root", Kind: KindPath, Description: "Meson WrapDB wraps used by cpx add and search",
+		field: func(c *GlobalConfig) any { return &c.WrapdbRoot }},
+	{Name: "cmake_generator", Kind: KindString, Values: []string{"ninja", "vs", "clang-cl"},
+		Description: "CMake generator of native builds: ninja, vs, clang-cl or a generator name; empty detects",
+		field:       func(c *GlobalConfig) any { return &c.CMakeGenerator }},
+	{Name: "shared_vcpkg_installed", Kind: KindBool, Description: "share vcpkg dependencies between projects",
+		field: func(c *GlobalConfig) any { return &c.SharedVcpkgInstalled }},
+	{Name: "upgrade_channel", Kind: KindEnum, Values: []string{"stable", "nightly"}, Description: "release channel cpx upgrade follows",
+		field: func(c *GlobalConfig) any { return &c.UpgradeChannel }},
+	{Name: "notify_after", Kind: KindInt, Description: "seconds a build must take to send a desktop notification; 0 turns them off",
+		field: func(c *GlobalConfig) any { return &c.NotifyAfter }},
+	{Name: "cache_dir", Kind: KindString, Description: "cache directory of every project instead of .cache",
+		field: func(c *GlobalConfig) any { return &c.CacheDir }},
+	{Name: "bin_dir", Kind: KindString, Description: "artifact directory of every project instead of .bin",
+		field: func(c *GlobalConfig) any { return &c.BinDir }},
+	{Name: "jobs", Kind: KindInt, Description: "parallel build jobs without -j; 0 lets the build tool decide",
+		field: func(c *GlobalConfig) any { return &c.Jobs }},
+	{Name: "compiler_launcher", Kind: KindCommand, Values: []string{"ccache", "sccache"}, Description: "command CMake builds run the compiler through",
+		field: func(c *GlobalConfig) any { return &c.CompilerLauncher }},
+	{Name: "docker_registry", Kind: KindString, Description: "registry and namespace cpx docker publish pushes images to, e.g. ghcr.io/acme",
+		field: func(c *GlobalConfig) any { return &c.DockerRegistry }},
+	{Name: "container_runtime", Kind: KindEnum, Values: []string{"docker", "podman", "nerdctl"}, Description: "container CLI of docker runners; empty uses the first one installed",
+		field: func(c *GlobalConfig) any { return &c.ContainerRuntime }},
+}
+
+// structuredKeys are keys of config.yaml that are not edited as a single
+// value.
+var structuredKeys = []string{"templates"}
+
+// LookupConfigKey returns the key called name, which may use dashes for
+// underscores.
+func LookupConfigKey(name string) (*ConfigKey, error) {
+	name = strings.ReplaceAll(name, "-", "_")
+	for i := range ConfigKeys {
+		if ConfigKeys[i].Name == name {
+			return &ConfigKeys[i], nil
+		}
+	}
+	return nil, unknownKeyError(name, "")
+}
+
+// Get returns the value of the key in cfg as text. Unset values are empty.
+func (k *ConfigKey) Get(cfg *GlobalConfig) string {
+	switch v := k.field(cfg).(type) {
+	case *string:
+		return *v
+	case *int:
+		if *v == 0 {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	case *bool:
+		if !*v {
+			return ""
+		}
+		return "true"
+	}
+	return ""
+}
+
+// Set validates value and sets the key in cfg. An empty value unsets it.
+func (k *ConfigKey) Set(cfg *GlobalConfig, value string) error {
+	if err := k.Validate(value); err != nil {
+		return err
+	}
+	switch v := k.field(cfg).(type) {
+	case *string:
+		*v = value
+	case *int:
+		*v, _ = strconv.Atoi(value)
+	case *bool:
+		*v = value == "true" || value == "on"
+	}
+	return nil
+}
+
+// Validate checks value for the key: paths must exist, commands must be on
+// PATH, enums must be one of their values and numbers must not be negative.
+// An empty value is valid, it unsets the key.
+func (k *ConfigKey) Validate(value string) error {
+	if value == "" {
+		return nil
+	}
+	switch k.Kind {
+	case KindPath:
+		if _, err := os.Stat(expandHome(value)); err != nil {
+			return fmt.Errorf("%s: %s does not exist", k.Name, value)
+		}
+	case KindCommand:
+		command := strings.Fields(value)[0]
+		if _, err := exec.LookPath(command); err != nil {
+			return fmt.Errorf("%s: %s not found in PATH", k.Name, command)
+		}
+	case KindEnum:
+		if !slices.Contains(k.Values, value) {
+			return fmt.Errorf("%s: invalid value %q (use %s)", k.Name, value, strings.Join(k.Values, " or "))
+		}
+	case KindInt:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("%s: invalid value %q (use a number)", k.Name, value)
+		}
+	case KindBool:
+		if !slices.Contains([]string{"true", "false", "on", "off"}, value) {
+			return fmt.Errorf("%s: invalid value %q (use true or false)", k.Name, value)
+		}
+	}
+	return nil
+}
+
+// UnknownKeysError is the error of LoadGlobal for a config.yaml with keys
+// cpx does not know.
+type UnknownKeysError struct {
+	// Keys are the unknown keys
+	Keys []string
+
+	// Config is the config without them, for cpx config edit to fix
+	Config *GlobalConfig
+
+	message string
+}
+
+func (e *UnknownKeysError) Error() string {
+	return e.message
+}
+
+// checkKeys reports the top-level keys of config.yaml data that cpx does
+// not know, with the known key each is most likely a misspelling of.
+func checkKeys(data []byte, path string) *UnknownKeysError {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	var unknown []string
+	for key := range doc {
+		if !isKnownKey(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	slices.Sort(unknown)
+	var errs []string
+	for _, key := range unknown {
+		errs = append(errs, unknownKeyError(key, path).Error())
+	}
+	return &UnknownKeysError{
+		Keys:    unknown,
+		message: strings.Join(errs, "\n") + "\n  hint: fix the key or run: cpx config edit",
+	}
+}
+
+func isKnownKey(name string) bool {
+	if slices.Contains(structuredKeys, name) {
+		return true
+	}
+	return slices.ContainsFunc(ConfigKeys, func(k ConfigKey) bool { return k.Name == name })
+}
+
+// unknownKeyError describes an unknown key, suggesting the closest known
+// one when the key looks like a misspelling of it.
+func unknownKeyError(name, path string) error {
+	where := ""
+	if path != "" {
+		where = " in " + path
+	}
+	names := slices.Clone(structuredKeys)
+	for _, k := range ConfigKeys {
+		names = append(names, k.Name)
+	}
+	if suggestion := closestKey(name, names); suggestion != "" {
+		return fmt.Errorf("unknown config key %q%s, did you mean %q?", name, where, suggestion)
+	}
+	return fmt.Errorf("unknown config key %q%s", name, where)
+}
+
+// closestKey returns the candidate with the smallest edit distance to name,
+// or "" when none is close enough to be a misspelling.
+func closestKey(name string, candidates []string) string {
+	best, bestDistance := "", 0
+	for _, c := range candidates {
+		d := editDistance(name, c)
+		if best == "" || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	if bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
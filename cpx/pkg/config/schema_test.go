@@ -0,0 +1,77 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGlobalUnknownKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "cpx")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	data := "vcpkg_root: /opt/vcpkg\nvcpkg_rot: /opt/other\nfrobnicate: true\n"
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(data), 0644))
+
+	cfg, err := config.LoadGlobal()
+	assert.Nil(t, cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown config key "vcpkg_rot"`)
+	assert.Contains(t, err.Error(), `did you mean "vcpkg_root"?`)
+	assert.Contains(t, err.Error(), `unknown config key "frobnicate"`)
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if strings.Contains(line, "frobnicate") {
+			assert.NotContains(t, line, "did you mean", "no suggestion for a key unlike any other")
+		}
+	}
+
+	var unknown *config.UnknownKeysError
+	require.True(t, errors.As(err, &unknown))
+	assert.Equal(t, []string{"frobnicate", "vcpkg_rot"}, unknown.Keys)
+	assert.Equal(t, "/opt/vcpkg", unknown.Config.VcpkgRoot, "the known keys are still read")
+}
+
+func TestConfigKeys(t *testing.T) {
+	cfg := &config.GlobalConfig{}
+
+	key, err := config.LookupConfigKey("upgrade-channel")
+	require.NoError(t, err)
+	assert.ErrorContains(t, key.Set(cfg, "beta"), "use stable or nightly")
+	require.NoError(t, key.Set(cfg, "nightly"))
+	assert.Equal(t, "nightly", cfg.UpgradeChannel)
+
+	key, err = config.LookupConfigKey("vcpkg_root")
+	require.NoError(t, err)
+	assert.ErrorContains(t, key.Set(cfg, filepath.Join(t.TempDir(), "missing")), "does not exist")
+	dir := t.TempDir()
+	require.NoError(t, key.Set(cfg, dir))
+	assert.Equal(t, dir, key.Get(cfg))
+
+	key, err = config.LookupConfigKey("jobs")
+	require.NoError(t, err)
+	assert.Error(t, key.Set(cfg, "-1"))
+	require.NoError(t, key.Set(cfg, "6"))
+	assert.Equal(t, 6, cfg.Jobs)
+	require.NoError(t, key.Set(cfg, ""), "an empty value unsets the key")
+	assert.Zero(t, cfg.Jobs)
+	assert.Empty(t, key.Get(cfg))
+
+	key, err = config.LookupConfigKey("shared_vcpkg_installed")
+	require.NoError(t, err)
+	require.NoError(t, key.Set(cfg, "on"))
+	assert.True(t, cfg.SharedVcpkgInstalled)
+
+	key, err = config.LookupConfigKey("compiler_launcher")
+	require.NoError(t, err)
+	assert.ErrorContains(t, key.Validate("cpx-no-such-launcher"), "not found in PATH")
+
+	_, err = config.LookupConfigKey("notify_afer")
+	assert.ErrorContains(t, err, `did you mean "notify_after"?`)
+}
@@ -0,0 +1,510 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is a problem in cpx-ci.yaml at a line and column.
+type ValidationError struct {
+	Line    int
+	Column  int
+	Message string
+
+	// Warning is set for problems that do not make the file invalid, such
+	// as deprecated fields.
+	Warning bool
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Values of cpx-ci.yaml fields that take one of a fixed set.
+var (
+	ciBuildTypes    = []string{"Debug", "Release", "RelWithDebInfo", "MinSizeRel"}
+	ciOptimizations = []string{"0", "1", "2", "3", "s", "fast"}
+	ciSanitizers    = []string{"asan", "ubsan", "tsan", "msan"}
+)
+
+// fieldEnums are the values of enum fields, keyed by <Go type>.<yaml key>.
+// The schema lists them and ValidateToolchains checks them.
+var fieldEnums = map[string][]string{
+	"Runner.type":                     {"native", "local", "docker", "ssh"},
+	"Runner.cache":                    {"bind", "volume"},
+	"Runner.pull":                     {"missing", "always", "never"},
+	"Toolchain.type":                  {ToolchainTypeWasm, ToolchainTypeAndroid, ToolchainTypeMinGW},
+	"Toolchain.build_type":            ciBuildTypes,
+	"Toolchain.optimization":          ciOptimizations,
+	"ToolchainMatrix.build_types":     ciBuildTypes,
+	"ToolchainMatrix.optimizations":   ciOptimizations,
+	"ToolchainConfig.vcpkg_installed": {"local", "shared"},
+	"MinGWConfig.arch":                {"x86_64", "i686"},
+	"BuildProfile.opt":                ciOptimizations,
+}
+
+// deprecatedFields are fields cpx-ci.yaml used to have, keyed like
+// fieldEnums, with what replaced them. They are ignored with a warning, and
+// cpx migrate removes them.
+var deprecatedFields = map[string]string{
+	"ToolchainConfig.build":  "set build_type, optimization and cmake_options per toolchain",
+	"ToolchainConfig.output": "artifacts are in <bin_dir>/ci/<toolchain>",
+}
+
+// ValidateToolchains checks cpx-ci.yaml data against its schema: unknown
+// fields, values of the wrong type or outside their enum, and what a build
+// would only find out late, such as docker runners without an image,
+// duplicate names and toolchains referencing missing runners. Paths, such as
+// Dockerfiles, are relative to root. Deprecated fields are warnings. The
+// problems are in file order.
+func ValidateToolchains(data []byte, root string) []ValidationError {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []ValidationError{yamlSyntaxError(err)}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	v := &validator{root: root}
+	v.walk(doc.Content[0], reflect.TypeFor[ToolchainConfig]())
+	if slices.ContainsFunc(v.errs, func(e ValidationError) bool { return !e.Warning }) {
+		// the checks below need a config of the right shape
+		return v.sorted()
+	}
+	var cfg ToolchainConfig
+	if err := doc.Decode(&cfg); err != nil {
+		return []ValidationError{yamlSyntaxError(err)}
+	}
+	v.checkRunners(doc.Content[0], &cfg)
+	v.checkToolchains(doc.Content[0], &cfg)
+	v.checkSanitizers(doc.Content[0])
+	v.checkTest(doc.Content[0], &cfg)
+	v.checkSigning(doc.Content[0], &cfg)
+	return v.sorted()
+}
+
+// RemoveDeprecatedToolchainFields removes the deprecated top-level fields
+// from cpx-ci.yaml data, with the comment lines right above them, and
+// returns the data and the removed fields. The rest of the file, comments
+// included, is kept as is.
+func RemoveDeprecatedToolchainFields(data []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil, nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	remove := make([]bool, len(lines))
+	var removed []string
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		if _, ok := deprecatedFields["ToolchainConfig."+key.Value]; !ok {
+			continue
+		}
+		removed = append(removed, key.Value)
+		start := key.Line - 1
+		end := len(lines)
+		if i+2 < len(root.Content) {
+			end = root.Content[i+2].Line - 1
+		}
+		// blank lines and comments before the next field belong to it
+		for end > start+1 && isBlankOrTopComment(lines[end-1]) {
+			end--
+		}
+		for start > 0 && strings.HasPrefix(lines[start-1], "#") {
+			start--
+		}
+		if start > 0 && strings.TrimSpace(lines[start-1]) == "" && (end == len(lines) || strings.TrimSpace(lines[end]) == "") {
+			// keep one blank line between the fields around
+			start--
+		}
+		for j := start; j < end; j++ {
+			remove[j] = true
+		}
+	}
+	var b strings.Builder
+	for j, line := range lines {
+		if !remove[j] {
+			b.WriteString(line)
+		}
+	}
+	return []byte(b.String()), removed, nil
+}
+
+func isBlankOrTopComment(line string) bool {
+	return strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#")
+}
+
+type validator struct {
+	root string
+	errs []ValidationError
+}
+
+func (v *validator) errorf(node *yaml.Node, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Line: node.Line, Column: node.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(node *yaml.Node, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Line: node.Line, Column: node.Column, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+func (v *validator) sorted() []ValidationError {
+	slices.SortStableFunc(v.errs, func(a, b ValidationError) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return v.errs
+}
+
+// walk checks that node has the shape of t: mappings have known keys,
+// sequences and scalars are where t expects them, and enums have one of
+// their values.
+func (v *validator) walk(node *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		// an empty value, such as a section with only comments
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.errorf(node, "expected a mapping, got %s", describeNode(node))
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if replacement, deprecated := deprecatedFields[t.Name()+"."+key.Value]; !ok && deprecated {
+				v.warnf(key, "field %q is deprecated and ignored (%s), cpx migrate removes it", key.Value, replacement)
+				continue
+			}
+			if !ok {
+				names := make([]string, 0, len(fields))
+				for name := range fields {
+					names = append(names, name)
+				}
+				if suggestion := closestKey(key.Value, names); suggestion != "" {
+					v.errorf(key, "unknown field %q in %s, did you mean %q?", key.Value, schemaName(t), suggestion)
+				} else {
+					v.errorf(key, "unknown field %q in %s", key.Value, schemaName(t))
+				}
+				continue
+			}
+			enum := fieldEnums[t.Name()+"."+key.Value]
+			if enum != nil {
+				v.checkEnum(key.Value, value, enum)
+				continue
+			}
+			v.walk(value, field.Type)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.errorf(node, "expected a list, got %s", describeNode(node))
+			return
+		}
+		for _, item := range node.Content {
+			v.walk(item, t.Elem())
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.errorf(node, "expected a mapping, got %s", describeNode(node))
+			return
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			v.walk(node.Content[i], t.Elem())
+		}
+	default:
+		if node.Kind != yaml.ScalarNode {
+			v.errorf(node, "expected a %s, got %s", scalarName(t), describeNode(node))
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			v.errorf(node, "expected a %s, got %q", scalarName(t), node.Value)
+		}
+	}
+}
+
+// checkEnum checks that a scalar, or each item of a list, is one of values.
+func (v *validator) checkEnum(key string, node *yaml.Node, values []string) {
+	items := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		items = node.Content
+	}
+	for _, item := range items {
+		if item.Kind != yaml.ScalarNode {
+			v.errorf(item, "expected a string, got %s", describeNode(item))
+			continue
+		}
+		if item.Value == "" || slices.Contains(values, item.Value) {
+			continue
+		}
+		i := slices.IndexFunc(values, func(value string) bool { return strings.EqualFold(value, item.Value) })
+		if i >= 0 {
+			v.errorf(item, "invalid %s %q, did you mean %q?", key, item.Value, values[i])
+		} else {
+			v.errorf(item, "invalid %s %q (use %s)", key, item.Value, strings.Join(values, ", "))
+		}
+	}
+}
+
+func (v *validator) checkRunners(root *yaml.Node, cfg *ToolchainConfig) {
+	nodes := mappingValue(root, "runners")
+	seen := map[string]bool{}
+	for i, runner := range cfg.Runners {
+		node := nodes.Content[i]
+		if runner.Name == "" {
+			v.errorf(node, "runner has no name")
+		} else if seen[runner.Name] {
+			v.errorf(fieldNode(node, "name"), "duplicate runner name %q", runner.Name)
+		}
+		seen[runner.Name] = true
+
+		switch {
+		case runner.IsDocker() && runner.Image == "" && runner.Dockerfile == "":
+			v.errorf(node, "docker runner %q needs an image or a dockerfile", runner.Name)
+		case runner.IsSSH() && runner.Host == "":
+			v.errorf(node, "ssh runner %q needs a host", runner.Name)
+		}
+		if runner.Cache != "" && !runner.IsDocker() {
+			v.errorf(fieldNode(node, "cache"), "cache is only supported by docker runners")
+		}
+		if runner.Pull != "" && !runner.IsDocker() {
+			v.errorf(fieldNode(node, "pull"), "pull is only supported by docker runners")
+		}
+		if auth := runner.Registry; auth != nil && (auth.Username == "" || auth.PasswordEnv == "") {
+			v.errorf(fieldNode(node, "registry"), "registry of runner %q needs a username and a password_env", runner.Name)
+		}
+		if runner.Dockerfile != "" {
+			path := runner.Dockerfile
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(v.root, path)
+			}
+			if _, err := os.Stat(path); err != nil {
+				v.errorf(fieldNode(node, "dockerfile"), "dockerfile %s does not exist", runner.Dockerfile)
+			}
+		}
+	}
+}
+
+func (v *validator) checkToolchains(root *yaml.Node, cfg *ToolchainConfig) {
+	nodes := mappingValue(root, "toolchains")
+	seen := map[string]bool{}
+	duplicates := false
+	for i, tc := range cfg.Toolchains {
+		node := nodes.Content[i]
+		if tc.Name == "" {
+			v.errorf(node, "toolchain has no name")
+		} else if seen[tc.Name] {
+			v.errorf(fieldNode(node, "name"), "duplicate toolchain name %q", tc.Name)
+			duplicates = true
+		}
+		seen[tc.Name] = true
+
+		if tc.Runner != "" && cfg.FindRunner(tc.Runner) == nil {
+			v.errorf(fieldNode(node, "runner"), "toolchain %q uses runner %q, which is not defined in runners", tc.Name, tc.Runner)
+		}
+		if tc.Sanitizer != "" {
+			v.checkSanitizerList(fieldNode(node, "sanitizer"), tc.Sanitizer)
+		}
+		if tc.Jobs < 0 {
+			v.errorf(fieldNode(node, "jobs"), "jobs must not be negative")
+		}
+		if tc.Matrix != nil {
+			matrix := fieldNode(node, "matrix")
+			for j, sanitizer := range tc.Matrix.Sanitizers {
+				if sanitizer != "none" {
+					v.checkSanitizerList(mappingValue(matrix, "sanitizers").Content[j], sanitizer)
+				}
+			}
+		}
+	}
+
+	// matrix jobs get generated names, which may clash with other toolchains
+	expanded := *cfg
+	if err := expanded.ExpandMatrix(); err != nil && !duplicates {
+		v.errorf(nodes, "%s", err)
+	}
+}
+
+func (v *validator) checkSanitizers(root *yaml.Node) {
+	nodes := mappingValue(root, "sanitizers")
+	if nodes == nil {
+		return
+	}
+	for i := 0; i+1 < len(nodes.Content); i += 2 {
+		if name := nodes.Content[i]; !slices.Contains(ciSanitizers, name.Value) {
+			v.errorf(name, "unknown sanitizer %q (use %s)", name.Value, strings.Join(ciSanitizers, ", "))
+		}
+	}
+}
+
+func (v *validator) checkTest(root *yaml.Node, cfg *ToolchainConfig) {
+	if cfg.Test == nil {
+		return
+	}
+	if _, _, err := cfg.Test.Timeouts(); err != nil {
+		v.errorf(mappingValue(root, "test"), "%s", err)
+	}
+}
+
+func (v *validator) checkSigning(root *yaml.Node, cfg *ToolchainConfig) {
+	if cfg.Signing == nil {
+		return
+	}
+	if macOS := cfg.Signing.MacOS; macOS != nil {
+		node := mappingValue(mappingValue(root, "signing"), "macos")
+		if macOS.Identity == "" {
+			v.errorf(node, "signing.macos needs an identity, e.g. \"Developer ID Application: Name (TEAMID)\"")
+		}
+		v.checkFile(fieldNode(node, "entitlements"), "entitlements", macOS.Entitlements)
+	}
+	if windows := cfg.Signing.Windows; windows != nil {
+		node := mappingValue(mappingValue(root, "signing"), "windows")
+		if windows.CertificateThumbprint != "" && windows.PFX != "" {
+			v.errorf(node, "signing.windows takes a certificate_thumbprint or a pfx, not both")
+		}
+		v.checkFile(fieldNode(node, "pfx"), "pfx", windows.PFX)
+	}
+}
+
+// checkFile checks that the file at path, relative to the project root,
+// exists; an empty path is fine.
+func (v *validator) checkFile(node *yaml.Node, what, path string) {
+	if path == "" {
+		return
+	}
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(v.root, full)
+	}
+	if _, err := os.Stat(full); err != nil {
+		v.errorf(node, "%s %s does not exist", what, path)
+	}
+}
+
+// checkSanitizerList checks a sanitizer field: one name or a comma list.
+func (v *validator) checkSanitizerList(node *yaml.Node, value string) {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); !slices.Contains(ciSanitizers, name) {
+			v.errorf(node, "unknown sanitizer %q (use %s, or a list like asan,ubsan)", name, strings.Join(ciSanitizers, ", "))
+		}
+	}
+}
+
+// yamlFields returns the fields of struct t by yaml key.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// fieldNode returns the value of key in node, or node itself when the key is
+// missing, so errors point somewhere sensible.
+func fieldNode(node *yaml.Node, key string) *yaml.Node {
+	if value := mappingValue(node, key); value != nil {
+		return value
+	}
+	return node
+}
+
+// schemaName is how errors name the section of type t.
+func schemaName(t reflect.Type) string {
+	switch t.Name() {
+	case "ToolchainConfig":
+		return "cpx-ci.yaml"
+	case "ToolchainMatrix":
+		return "matrix"
+	case "AndroidConfig":
+		return "android"
+	case "MinGWConfig":
+		return "mingw"
+	case "CUDAConfig":
+		return "cuda"
+	case "CommandHooks":
+		return "hooks"
+	case "CommitMsgConfig":
+		return "commit_msg"
+	case "TestConfig":
+		return "test"
+	case "MutateConfig":
+		return "mutate"
+	case "SanitizerSettings":
+		return "sanitizer settings"
+	case "TargetOptions":
+		return "target options"
+	case "BuildProfile":
+		return "profile"
+	}
+	return strings.ToLower(t.Name())
+}
+
+func scalarName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "number"
+	}
+	return "string"
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", node.Value)
+}
+
+// yamlSyntaxError turns a yaml error into a ValidationError, taking the line
+// from messages like "yaml: line 3: ...".
+func yamlSyntaxError(err error) ValidationError {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	line := 0
+	if _, scanErr := fmt.Sscanf(msg, "line %d:", &line); scanErr == nil {
+		_, msg, _ = strings.Cut(msg, ": ")
+	}
+	return ValidationError{Line: line, Message: msg}
+}
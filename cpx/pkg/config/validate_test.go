@@ -0,0 +1,257 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateToolchains(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Dockerfile"), []byte("FROM ubuntu\n"), 0644))
+
+	tests := []struct {
+		name string
+		data string
+		want []config.ValidationError
+	}{
+		{
+			name: "valid",
+			data: `runners:
+  - name: alpine
+    type: docker
+    image: alpine:latest
+  - name: gcc13
+    type: docker
+    dockerfile: Dockerfile
+toolchains:
+  - name: release
+    runner: alpine
+    build_type: Release
+    optimization: "2"
+    sanitizer: asan,ubsan
+  - name: local
+    matrix:
+      build_types: [Debug, Release]
+      sanitizers: [none, tsan]
+`,
+		},
+		{
+			name: "empty sections",
+			data: "runners:\ntoolchains:\n",
+		},
+		{
+			name: "misspelled field",
+			data: "toolchains:\n  - name: release\n    optimisation: \"2\"\n",
+			want: []config.ValidationError{{Line: 3, Column: 5, Message: `unknown field "optimisation" in toolchain, did you mean "optimization"?`}},
+		},
+		{
+			name: "wrong case of an enum",
+			data: "toolchains:\n  - name: release\n    build_type: release\n",
+			want: []config.ValidationError{{Line: 3, Column: 17, Message: `invalid build_type "release", did you mean "Release"?`}},
+		},
+		{
+			name: "wrong type",
+			data: "toolchains:\n  - name: release\n    jobs: many\n",
+			want: []config.ValidationError{{Line: 3, Column: 11, Message: `expected a number, got "many"`}},
+		},
+		{
+			name: "docker runner without an image",
+			data: "runners:\n  - name: box\n    type: docker\n",
+			want: []config.ValidationError{{Line: 2, Column: 5, Message: `docker runner "box" needs an image or a dockerfile`}},
+		},
+		{
+			name: "missing dockerfile",
+			data: "runners:\n  - name: box\n    type: docker\n    dockerfile: ci/Dockerfile\n",
+			want: []config.ValidationError{{Line: 4, Column: 17, Message: "dockerfile ci/Dockerfile does not exist"}},
+		},
+		{
+			name: "unknown runner cache",
+			data: "runners:\n  - name: box\n    type: docker\n    image: gcc\n    cache: tmpfs\n",
+			want: []config.ValidationError{{Line: 5, Column: 12, Message: `invalid cache "tmpfs" (use bind, volume)`}},
+		},
+		{
+			name: "cache of a native runner",
+			data: "runners:\n  - name: local\n    cache: volume\n",
+			want: []config.ValidationError{{Line: 3, Column: 12, Message: "cache is only supported by docker runners"}},
+		},
+		{
+			name: "registry without a password",
+			data: "runners:\n  - name: box\n    type: docker\n    image: ghcr.io/acme/gcc\n    pull: always\n    registry:\n      username: bot\n",
+			want: []config.ValidationError{{Line: 7, Column: 7, Message: `registry of runner "box" needs a username and a password_env`}},
+		},
+		{
+			name: "duplicate toolchain and missing runner",
+			data: "toolchains:\n  - name: release\n    runner: box\n  - name: release\n",
+			want: []config.ValidationError{
+				{Line: 3, Column: 13, Message: `toolchain "release" uses runner "box", which is not defined in runners`},
+				{Line: 4, Column: 11, Message: `duplicate toolchain name "release"`},
+			},
+		},
+		{
+			name: "unknown sanitizer",
+			data: "toolchains:\n  - name: release\n    sanitizer: asan,leaky\n",
+			want: []config.ValidationError{{Line: 3, Column: 16, Message: `unknown sanitizer "leaky" (use asan, ubsan, tsan, msan, or a list like asan,ubsan)`}},
+		},
+		{
+			name: "macOS signing without an identity",
+			data: "signing:\n  macos:\n    keychain_profile: notary\n    entitlements: app.plist\n",
+			want: []config.ValidationError{
+				{Line: 3, Column: 5, Message: `signing.macos needs an identity, e.g. "Developer ID Application: Name (TEAMID)"`},
+				{Line: 4, Column: 19, Message: "entitlements app.plist does not exist"},
+			},
+		},
+		{
+			name: "windows signing with two certificates",
+			data: "signing:\n  windows:\n    certificate_thumbprint: ab12\n    pfx: cert.pfx\n",
+			want: []config.ValidationError{
+				{Line: 3, Column: 5, Message: "signing.windows takes a certificate_thumbprint or a pfx, not both"},
+				{Line: 4, Column: 10, Message: "pfx cert.pfx does not exist"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, config.ValidateToolchains([]byte(tt.data), root))
+		})
+	}
+}
+
+func TestValidateToolchainsSyntaxError(t *testing.T) {
+	errs := config.ValidateToolchains([]byte("runners: [\n"), t.TempDir())
+	require.Len(t, errs, 1)
+	assert.Equal(t, 1, errs[0].Line)
+}
+
+// legacyToolchainsFile is the cpx-ci.yaml cpx new used to write, with the
+// top-level build and output fields cpx no longer reads.
+const legacyToolchainsFile = `# cpx-ci.yaml - Toolchain configuration
+# This file defines toolchains for building your project
+
+toolchains:
+  # Example: Pull a pre-built image from registry
+  # - name: "alpine-gcc"
+  #   active: true                 # Set to false to skip this toolchain (default: true)
+  #   runner: "docker"
+  #   docker:
+  #     mode: "pull"              # pull, local, or build
+  #     image: "alpine:latest"
+  #     platform: "linux/amd64"
+  #     pullPolicy: "ifNotPresent" # always, never, ifNotPresent
+  #   # Per-toolchain build configuration (overrides global defaults)
+  #   build_type: "Release"       # Debug, Release, RelWithDebInfo, MinSizeRel
+  #   cmake_options: []           # Additional CMake arguments
+  #   build_options: []           # Additional build arguments (cmake --build args)
+  #   env:                        # Environment variables
+  #     CC: "gcc"
+  #     CXX: "g++"
+
+  # Example: Use a local image (no network)
+  # - name: "my-toolchain"
+  #   runner: "docker"
+  #   docker:
+  #     mode: "local"
+  #     image: "my-toolchain:latest"
+  #   build_type: "Debug"
+
+  # Example: Build from Dockerfile (with content-based caching)
+  # - name: "custom-toolchain"
+  #   runner: "docker"
+  #   docker:
+  #     mode: "build"
+  #     image: "cpx-dev"          # tag for the built image
+  #     platform: "linux/arm64"
+  #     build:
+  #       context: "."
+  #       dockerfile: "Dockerfile"
+  #       args:
+  #         GCC_VER: "13"
+  #   build_type: "RelWithDebInfo"
+  #   cmake_options:
+  #     - "-DENABLE_TESTS=ON"
+
+  # Example: Native build (runs on host, CMake only)
+  # - name: "local-debug"
+  #   runner: "native"
+  #   build_type: "Debug"
+  #   cmake_options:
+  #     - "-DCMAKE_EXPORT_COMPILE_COMMANDS=ON"
+  #   env:
+  #     CC: "clang"
+  #     CXX: "clang++"
+
+# Global build configuration (used as defaults if not specified per-toolchain)
+build:
+  # CMake build type (Debug, Release, RelWithDebInfo, MinSizeRel)
+  # Note: Per-toolchain build_type overrides this
+  type: Release
+
+  # Optimization level (0, 1, 2, 3, s, fast)
+  optimization: 2
+
+  # Number of parallel jobs (0 = auto)
+  jobs: 0
+
+  # Additional CMake arguments (per-toolchain cmake_options overrides this)
+  cmake_args: []
+
+  # Additional build arguments (per-toolchain build_options overrides this)
+  build_args: []
+
+# Output directory for artifacts
+output: .bin/ci
+`
+
+func TestValidateToolchainsDeprecatedFields(t *testing.T) {
+	errs := config.ValidateToolchains([]byte(legacyToolchainsFile), t.TempDir())
+	require.Len(t, errs, 2)
+	for _, e := range errs {
+		assert.True(t, e.Warning, "%s is a warning", e.Message)
+		assert.Contains(t, e.Message, "deprecated")
+	}
+	assert.Equal(t, 57, errs[0].Line)
+	assert.Equal(t, 75, errs[1].Line)
+}
+
+func TestRemoveDeprecatedToolchainFields(t *testing.T) {
+	data, removed, err := config.RemoveDeprecatedToolchainFields([]byte(legacyToolchainsFile))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build", "output"}, removed)
+	assert.Empty(t, config.ValidateToolchains(data, t.TempDir()))
+	assert.Contains(t, string(data), "#     CXX: \"clang++\"\n", "comments of the other fields are kept")
+	assert.NotContains(t, string(data), "Global build configuration")
+	assert.NotContains(t, string(data), "Output directory")
+	assert.False(t, strings.HasSuffix(string(data), "\n\n"))
+
+	again, removed, err := config.RemoveDeprecatedToolchainFields(data)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+	assert.Equal(t, data, again)
+}
+
+func TestToolchainsSchema(t *testing.T) {
+	data, err := config.ToolchainsSchema()
+	require.NoError(t, err)
+
+	var schema struct {
+		ID                   string         `json:"$id"`
+		Properties           map[string]any `json:"properties"`
+		AdditionalProperties bool           `json:"additionalProperties"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, config.ToolchainsSchemaURL, schema.ID)
+	assert.Contains(t, schema.Properties, "runners")
+	assert.Contains(t, schema.Properties, "toolchains")
+	assert.False(t, schema.AdditionalProperties)
+
+	// the published copy is regenerated with make schema
+	published, err := os.ReadFile(filepath.Join("..", "..", "..", "schema", "cpx-ci.schema.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(published), "schema/cpx-ci.schema.json is out of date, run make schema")
+}